@@ -0,0 +1,240 @@
+// Package bench measures copy throughput for a sample workload at different
+// worker counts and buffer sizes, to tune --jobs and --buffer-size for a
+// given source and destination.
+package bench
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/units"
+)
+
+// Setting is one combination of tuning knobs. A zero BufferSize leaves the
+// copy to the operating system, like the default import.
+type Setting struct {
+	Jobs       int
+	BufferSize units.ByteSize
+}
+
+func (s Setting) String() string {
+	buf := "default"
+	if s.BufferSize > 0 {
+		buf = s.BufferSize.String()
+	}
+	return fmt.Sprintf("jobs=%d buffer=%s", s.Jobs, buf)
+}
+
+// Result is the outcome of copying the workload with one Setting.
+type Result struct {
+	Setting
+	Files   int
+	Bytes   int64
+	Elapsed time.Duration
+}
+
+// BytesPerSecond is the result's throughput.
+func (r Result) BytesPerSecond() float64 {
+	if r.Elapsed <= 0 {
+		return 0
+	}
+	return float64(r.Bytes) / r.Elapsed.Seconds()
+}
+
+// Workload is the set of files copied for every Setting.
+type Workload struct {
+	Files []string
+	Bytes int64
+}
+
+// Sample picks the regular, non-hidden files below dir in walk order until
+// their total size reaches limit. It always includes at least one file.
+func Sample(dir string, limit int64) (Workload, error) {
+	var w Workload
+	done := errors.New("limit reached")
+	err := filepath.WalkDir(dir, func(path string, e fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(e.Name(), ".") && path != dir {
+			if e.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !e.Type().IsRegular() {
+			return nil
+		}
+		info, err := e.Info()
+		if err != nil {
+			return err
+		}
+		if len(w.Files) > 0 && w.Bytes+info.Size() > limit {
+			return done
+		}
+		w.Files = append(w.Files, path)
+		w.Bytes += info.Size()
+		return nil
+	})
+	if err != nil && err != done {
+		return w, err
+	}
+	if len(w.Files) == 0 {
+		return w, fmt.Errorf("no files to sample in %s", dir)
+	}
+	return w, nil
+}
+
+// Generate writes a synthetic workload of about total bytes into dir: mostly
+// photo-sized files plus a few video-sized ones, the mix of a camera card.
+func Generate(dir string, total int64) (Workload, error) {
+	const photo, video = 4 << 20, 64 << 20
+	var w Workload
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return w, err
+	}
+	chunk := make([]byte, 1<<20)
+	for i := range chunk {
+		chunk[i] = byte(i * 7)
+	}
+	for i := 0; w.Bytes < total; i++ {
+		size := int64(photo)
+		if i%16 == 15 {
+			size = video
+		}
+		size = min(size, total-w.Bytes)
+		path := filepath.Join(dir, fmt.Sprintf("sample_%04d.bin", i))
+		if err := writeFile(path, chunk, size); err != nil {
+			return w, err
+		}
+		w.Files = append(w.Files, path)
+		w.Bytes += size
+	}
+	return w, nil
+}
+
+func writeFile(path string, chunk []byte, size int64) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	for left := size; left > 0; left -= int64(len(chunk)) {
+		if _, err := f.Write(chunk[:min(left, int64(len(chunk)))]); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	return f.Close()
+}
+
+// Warm reads the workload once, so the first measured Setting does not pay
+// for a cold cache that later ones would not.
+func (w Workload) Warm() error {
+	for _, path := range w.Files {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(io.Discard, f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Run copies the workload into dst, which must exist, with s, syncing each
+// copy so the destination's write speed is measured rather than the page
+// cache. The copies are removed afterwards.
+func Run(w Workload, dst string, s Setting) (Result, error) {
+	res := Result{Setting: s, Files: len(w.Files), Bytes: w.Bytes}
+	out, err := os.MkdirTemp(dst, "run-")
+	if err != nil {
+		return res, err
+	}
+	defer os.RemoveAll(out)
+
+	jobs := max(s.Jobs, 1)
+	next := make(chan int)
+	errs := make(chan error, jobs)
+	var wg sync.WaitGroup
+	start := time.Now()
+	for range jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				target := filepath.Join(out, fmt.Sprintf("%d_%s", i, filepath.Base(w.Files[i])))
+				if err := copyFile(w.Files[i], target, int(s.BufferSize)); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}()
+	}
+	var runErr error
+feed:
+	for i := range w.Files {
+		select {
+		case next <- i:
+		case runErr = <-errs:
+			break feed
+		}
+	}
+	close(next)
+	wg.Wait()
+	res.Elapsed = time.Since(start)
+	if runErr == nil {
+		select {
+		case runErr = <-errs:
+		default:
+		}
+	}
+	return res, runErr
+}
+
+func copyFile(src, dst string, bufSize int) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := files.CopyBuffered(out, in, bufSize); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// Best returns the fastest result. Results within 5% of the fastest count
+// as equal, and the earliest of them wins, so listing cheaper settings
+// first prefers them when they are as good.
+func Best(results []Result) Result {
+	var fastest float64
+	for _, r := range results {
+		fastest = max(fastest, r.BytesPerSecond())
+	}
+	for _, r := range results {
+		if r.BytesPerSecond() >= fastest*0.95 {
+			return r
+		}
+	}
+	return Result{}
+}
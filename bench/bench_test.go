@@ -0,0 +1,77 @@
+package bench
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+func TestGenerateAndSample(t *testing.T) {
+	tmp := testutil.TempDir(t)
+	w, err := Generate(filepath.Join(tmp, "gen"), 9<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Bytes != 9<<20 || len(w.Files) != 3 {
+		t.Fatalf("generated %d file(s), %d bytes; want 3 and 9 MiB", len(w.Files), w.Bytes)
+	}
+	if info, _ := os.Stat(w.Files[2]); info == nil || info.Size() != 1<<20 {
+		t.Errorf("last file should hold the remainder, got %v", info)
+	}
+
+	s, err := Sample(filepath.Join(tmp, "gen"), 5<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Files) != 1 || s.Bytes != 4<<20 {
+		t.Errorf("sample = %d file(s), %d bytes; want the first file only", len(s.Files), s.Bytes)
+	}
+	if s, err := Sample(filepath.Join(tmp, "gen"), 1); err != nil || len(s.Files) != 1 {
+		t.Errorf("a sample should hold at least one file: %v, %v", s.Files, err)
+	}
+	if _, err := Sample(t.TempDir(), 1<<20); err == nil {
+		t.Error("expected an error for an empty directory")
+	}
+}
+
+func TestRun(t *testing.T) {
+	tmp := testutil.TempDir(t)
+	w, err := Generate(filepath.Join(tmp, "src"), 3<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst := filepath.Join(tmp, "dst")
+	if err := os.MkdirAll(dst, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := Run(w, dst, Setting{Jobs: 2, BufferSize: 64 << 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Files != 1 || r.Bytes != 3<<20 || r.Elapsed <= 0 {
+		t.Errorf("unexpected result %+v", r)
+	}
+	if entries, _ := os.ReadDir(dst); len(entries) != 0 {
+		t.Errorf("copies should be removed, found %d entries", len(entries))
+	}
+
+	w.Files = append(w.Files, filepath.Join(tmp, "missing"))
+	if _, err := Run(w, dst, Setting{Jobs: 1}); err == nil {
+		t.Error("expected an error for a missing source")
+	}
+}
+
+func TestBest(t *testing.T) {
+	results := []Result{
+		{Setting: Setting{Jobs: 1}, Bytes: 100, Elapsed: 2 * time.Second},
+		{Setting: Setting{Jobs: 2}, Bytes: 100, Elapsed: time.Second - 30*time.Millisecond},
+		{Setting: Setting{Jobs: 4}, Bytes: 100, Elapsed: 930 * time.Millisecond},
+	}
+	if got := Best(results); got.Jobs != 2 {
+		t.Errorf("Best = %v, want jobs=2 as it is within 5%% of the fastest", got.Setting)
+	}
+}
@@ -0,0 +1,161 @@
+// Package burst finds bursts and bracketed sequences among a set of photos so
+// they can be filed together instead of flooding a day folder.
+//
+// Shots belong to the same burst when the camera tagged them with the same
+// BurstUUID, or when each was taken within a short window of the previous
+// one.
+package burst
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/pathtmpl"
+)
+
+// Dir is the folder, next to a burst's regular destination, that holds one
+// subfolder per burst.
+const Dir = "bursts"
+
+// DefaultWindow is the largest gap between consecutive shots of a burst.
+const DefaultWindow = 500 * time.Millisecond
+
+// IDTags are the tags cameras use to mark shots of one burst.
+var IDTags = []string{"BurstUUID", "BurstID"}
+
+// subSecTags carry capture times with sub-second precision, best first.
+var subSecTags = []string{"SubSecDateTimeOriginal", "SubSecCreateDate"}
+
+// Options tunes detection.
+type Options struct {
+	// Window is the largest gap between consecutive shots; zero means
+	// DefaultWindow.
+	Window time.Duration
+	// MinShots is the smallest sequence treated as a burst; values below 2
+	// mean 2.
+	MinShots int
+}
+
+// Shot is the part of a file's metadata that detection looks at.
+type Shot struct {
+	Path    string
+	Time    time.Time // zero when the file has no usable capture time
+	BurstID string
+}
+
+// ShotFromMetadata extracts a Shot from exiftool metadata, preferring
+// sub-second capture times so shots taken in the same second can be told
+// apart.
+func ShotFromMetadata(md files.FileMetadata) Shot {
+	s := Shot{Path: md.Filepath}
+	for _, tag := range IDTags {
+		if v := strings.TrimSpace(md.Tags[tag]); v != "" {
+			s.BurstID = v
+			break
+		}
+	}
+
+	for _, tag := range subSecTags {
+		if t, err := files.ParseExifDate(md.Tags[tag]); err == nil {
+			s.Time = t
+			return s
+		}
+	}
+	if t, _, ok := pathtmpl.CaptureTime(md); ok {
+		if sub := strings.TrimSpace(md.Tags["SubSecTimeOriginal"]); sub != "" && t.Nanosecond() == 0 {
+			if frac, err := time.ParseDuration("0." + sub + "s"); err == nil {
+				t = t.Add(frac)
+			}
+		}
+		s.Time = t
+	}
+	return s
+}
+
+// Detect groups shots into bursts and returns the burst ID of every path
+// that belongs to one. A burst's ID is the file name, without extension, of
+// its earliest shot.
+func Detect(shots []Shot, opts Options) map[string]string {
+	if opts.Window <= 0 {
+		opts.Window = DefaultWindow
+	}
+	if opts.MinShots < 2 {
+		opts.MinShots = 2
+	}
+
+	groups := map[string]string{}
+	assign := func(members []Shot) {
+		if len(members) < opts.MinShots {
+			return
+		}
+		sortShots(members)
+		id := stem(members[0].Path)
+		for _, s := range members {
+			groups[s.Path] = id
+		}
+	}
+
+	// Camera-assigned burst IDs are authoritative.
+	byID := map[string][]Shot{}
+	var ids []string
+	for _, s := range shots {
+		if s.BurstID == "" {
+			continue
+		}
+		if _, ok := byID[s.BurstID]; !ok {
+			ids = append(ids, s.BurstID)
+		}
+		byID[s.BurstID] = append(byID[s.BurstID], s)
+	}
+	for _, id := range ids {
+		assign(byID[id])
+	}
+
+	// Everything else is chained by time.
+	var timed []Shot
+	for _, s := range shots {
+		if _, grouped := groups[s.Path]; !grouped && s.BurstID == "" && !s.Time.IsZero() {
+			timed = append(timed, s)
+		}
+	}
+	sortShots(timed)
+
+	var run []Shot
+	for _, s := range timed {
+		if len(run) > 0 && s.Time.Sub(run[len(run)-1].Time) > opts.Window {
+			assign(run)
+			run = nil
+		}
+		run = append(run, s)
+	}
+	assign(run)
+
+	return groups
+}
+
+// Destination moves dst into the burst's folder next to it, keeping the
+// source's file name so shots taken in the same minute do not collide.
+func Destination(dst, src, id string) string {
+	return filepath.Join(filepath.Dir(dst), Dir, id, filepath.Base(src))
+}
+
+func sortShots(shots []Shot) {
+	sort.SliceStable(shots, func(i, j int) bool {
+		// Shots without a time sort last.
+		if zi, zj := shots[i].Time.IsZero(), shots[j].Time.IsZero(); zi != zj {
+			return zj
+		}
+		if !shots[i].Time.Equal(shots[j].Time) {
+			return shots[i].Time.Before(shots[j].Time)
+		}
+		return shots[i].Path < shots[j].Path
+	})
+}
+
+func stem(path string) string {
+	base := filepath.Base(path)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
@@ -0,0 +1,102 @@
+package burst
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Tmunayyer/gocamelpack/files"
+)
+
+func md(path string, tags map[string]string) files.FileMetadata {
+	return files.FileMetadata{Filepath: path, Tags: tags}
+}
+
+func TestShotFromMetadata(t *testing.T) {
+	tests := []struct {
+		name string
+		tags map[string]string
+		want string
+	}{
+		{"subsec composite", map[string]string{"SubSecDateTimeOriginal": "2024:05:06 07:08:09.120+02:00"}, "2024-05-06T05:08:09.12Z"},
+		{"subsec separate", map[string]string{"DateTimeOriginal": "2024:05:06 07:08:09", "SubSecTimeOriginal": "45"}, "2024-05-06T07:08:09.45Z"},
+		{"seconds only", map[string]string{"CreationDate": "2024:05:06 07:08:09"}, "2024-05-06T07:08:09Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := ShotFromMetadata(md("/a.jpg", tt.tags))
+			if got := s.Time.UTC().Format(time.RFC3339Nano); got != tt.want {
+				t.Errorf("time = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	if s := ShotFromMetadata(md("/a.jpg", map[string]string{"BurstUUID": " ABC "})); s.BurstID != "ABC" || !s.Time.IsZero() {
+		t.Errorf("unexpected shot %+v", s)
+	}
+}
+
+func TestDetect(t *testing.T) {
+	base := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	at := func(ms int) time.Time { return base.Add(time.Duration(ms) * time.Millisecond) }
+
+	shots := []Shot{
+		// burst by time, given out of order
+		{Path: "/in/IMG_0003.jpg", Time: at(200)},
+		{Path: "/in/IMG_0001.jpg", Time: at(0)},
+		{Path: "/in/IMG_0002.jpg", Time: at(100)},
+		// lone shot two seconds later
+		{Path: "/in/IMG_0004.jpg", Time: at(2000)},
+		// camera-tagged burst with a slow gap and no times at all
+		{Path: "/in/IMG_0010.heic", BurstID: "U1", Time: at(9000)},
+		{Path: "/in/IMG_0011.heic", BurstID: "U1"},
+		// a tagged burst of one is not a burst, even inside the window
+		{Path: "/in/IMG_0020.jpg", BurstID: "U2", Time: at(2100)},
+		// undated files are never chained
+		{Path: "/in/scan1.jpg"},
+		{Path: "/in/scan2.jpg"},
+	}
+
+	got := Detect(shots, Options{})
+	want := map[string]string{
+		"/in/IMG_0001.jpg":  "IMG_0001",
+		"/in/IMG_0002.jpg":  "IMG_0001",
+		"/in/IMG_0003.jpg":  "IMG_0001",
+		"/in/IMG_0010.heic": "IMG_0010",
+		"/in/IMG_0011.heic": "IMG_0010",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for path, id := range want {
+		if got[path] != id {
+			t.Errorf("%s: got %q, want %q", path, got[path], id)
+		}
+	}
+}
+
+func TestDetect_WindowAndMinShots(t *testing.T) {
+	base := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	shots := []Shot{
+		{Path: "/a.jpg", Time: base},
+		{Path: "/b.jpg", Time: base.Add(800 * time.Millisecond)},
+		{Path: "/c.jpg", Time: base.Add(1600 * time.Millisecond)},
+	}
+
+	if got := Detect(shots, Options{}); len(got) != 0 {
+		t.Errorf("default window should not chain 800ms gaps: %v", got)
+	}
+	if got := Detect(shots, Options{Window: time.Second}); len(got) != 3 {
+		t.Errorf("1s window should chain all shots: %v", got)
+	}
+	if got := Detect(shots[:2], Options{Window: time.Second, MinShots: 3}); len(got) != 0 {
+		t.Errorf("two shots are below MinShots 3: %v", got)
+	}
+}
+
+func TestDestination(t *testing.T) {
+	got := Destination(filepath.FromSlash("/out/2024/05/06/07_08.jpg"), "/in/IMG_0002.jpg", "IMG_0001")
+	if want := filepath.FromSlash("/out/2024/05/06/bursts/IMG_0001/IMG_0002.jpg"); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,359 @@
+// Package catalog records every file imported into an archive, with its
+// checksum, capture date, camera and the session that archived it, so the
+// archive can be deduplicated against, searched and summarised without
+// rescanning the disk.
+//
+// The catalog is an append-only log in a hidden file at the archive root.
+// Each import appends its files as one batch between a begin and a commit
+// line; a batch whose commit line is missing, e.g. after a crash, is
+// ignored when the catalog is opened, so an import is recorded entirely or
+// not at all.
+package catalog
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FileName is the catalog file kept at the archive root.
+const FileName = ".gocamelpack-catalog.jsonl"
+
+// RebuildFileName is the scratch file a rebuild writes before it replaces
+// the catalog.
+const RebuildFileName = ".gocamelpack-catalog-rebuild.jsonl"
+
+// Entry is what the catalog knows about one archived file.
+type Entry struct {
+	// Path is slash-separated and relative to the archive root.
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mtime"`
+	// Hash is the content hash as hashindex records it: hex SHA-256, or
+	// prefixed with its algorithm, e.g. "xxh64:".
+	Hash string `json:"hash"`
+	// Captured is the file's capture date; zero when unknown.
+	Captured time.Time `json:"captured,omitzero"`
+	// Camera is the make and model of the camera, when known.
+	Camera string `json:"camera,omitempty"`
+	// Source is where the file was imported from.
+	Source   string            `json:"source,omitempty"`
+	Session  string            `json:"session,omitempty"`
+	Archived time.Time         `json:"archived"`
+	Tags     map[string]string `json:"tags,omitempty"`
+}
+
+// UnmarshalJSON also reads the "sha256" key catalogs used for the hash
+// before other algorithms were supported.
+func (e *Entry) UnmarshalJSON(b []byte) error {
+	type entry Entry
+	var v struct {
+		entry
+		SHA256 string `json:"sha256"`
+	}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	*e = Entry(v.entry)
+	if e.Hash == "" {
+		e.Hash = v.SHA256
+	}
+	return nil
+}
+
+// record is one line of the catalog file.
+type record struct {
+	// Op is "begin", "put", "delete" or "commit".
+	Op    string `json:"op"`
+	Entry *Entry `json:"entry,omitempty"`
+	Path  string `json:"path,omitempty"`
+}
+
+// Catalog is the catalog of the archive at a root. Changes are staged by
+// Put and Delete, seen by Lookup and Get at once, and written by Commit.
+type Catalog struct {
+	root string
+	// path is the file commits go to: the catalog, or the scratch file of
+	// a rebuild.
+	path    string
+	entries map[string]Entry
+	byHash  map[string][]string
+	staged  []record
+}
+
+// Path returns where the catalog of the archive at root is kept.
+func Path(root string) string {
+	return filepath.Join(root, FileName)
+}
+
+// Exists reports whether the archive at root has a catalog.
+func Exists(root string) bool {
+	_, err := os.Stat(Path(root))
+	return err == nil
+}
+
+// Open reads the catalog of the archive at root. A missing catalog is
+// empty and is created by the first Commit.
+func Open(root string) (*Catalog, error) {
+	c := &Catalog{root: root, path: Path(root), entries: map[string]Entry{}}
+	f, err := os.Open(Path(root))
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		c.reindex()
+		return c, nil
+	case err != nil:
+		return nil, fmt.Errorf("reading catalog: %w", err)
+	}
+	defer f.Close()
+
+	var batch []record
+	torn := 0
+	sc := bufio.NewScanner(f)
+	sc.Buffer(nil, 64<<20)
+	for n := 1; sc.Scan(); n++ {
+		var r record
+		if err := json.Unmarshal(sc.Bytes(), &r); err != nil {
+			// Only the tail of an interrupted batch should be torn; the
+			// next begin drops it.
+			torn = n
+			continue
+		}
+		switch r.Op {
+		case "begin":
+			batch, torn = nil, 0
+		case "commit":
+			if torn > 0 {
+				return nil, fmt.Errorf("%s:%d: corrupt catalog line; run gocamelpack catalog rebuild", Path(root), torn)
+			}
+			for _, r := range batch {
+				c.apply(r)
+			}
+			batch = nil
+		default:
+			batch = append(batch, r)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("reading catalog: %w", err)
+	}
+	c.reindex()
+	return c, nil
+}
+
+func (c *Catalog) apply(r record) {
+	switch r.Op {
+	case "put":
+		if r.Entry != nil {
+			c.entries[r.Entry.Path] = *r.Entry
+		}
+	case "delete":
+		delete(c.entries, r.Path)
+	}
+}
+
+func (c *Catalog) reindex() {
+	c.byHash = map[string][]string{}
+	for rel, e := range c.entries {
+		c.byHash[e.Hash] = append(c.byHash[e.Hash], rel)
+	}
+}
+
+// Root returns the archive root of the catalog.
+func (c *Catalog) Root() string {
+	return c.root
+}
+
+// Len returns the number of catalogued files.
+func (c *Catalog) Len() int {
+	return len(c.entries)
+}
+
+// Rel returns path, inside the archive, relative to the root in the
+// catalog's slash-separated form.
+func (c *Catalog) Rel(path string) (string, error) {
+	rel, err := filepath.Rel(c.root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside the archive %s", path, c.root)
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// Abs returns the absolute path of a catalogued file.
+func (c *Catalog) Abs(e Entry) string {
+	return filepath.Join(c.root, filepath.FromSlash(e.Path))
+}
+
+// Put stages e, replacing any entry for the same path.
+func (c *Catalog) Put(e Entry) {
+	if old, ok := c.entries[e.Path]; ok {
+		c.unhash(old)
+	}
+	c.entries[e.Path] = e
+	c.byHash[e.Hash] = append(c.byHash[e.Hash], e.Path)
+	c.staged = append(c.staged, record{Op: "put", Entry: &e})
+}
+
+// Delete stages the removal of the entry for rel.
+func (c *Catalog) Delete(rel string) {
+	old, ok := c.entries[rel]
+	if !ok {
+		return
+	}
+	c.unhash(old)
+	delete(c.entries, rel)
+	c.staged = append(c.staged, record{Op: "delete", Path: rel})
+}
+
+func (c *Catalog) unhash(e Entry) {
+	paths := c.byHash[e.Hash]
+	for i, p := range paths {
+		if p == e.Path {
+			c.byHash[e.Hash] = append(paths[:i:i], paths[i+1:]...)
+			break
+		}
+	}
+}
+
+// Get returns the entry for rel.
+func (c *Catalog) Get(rel string) (Entry, bool) {
+	e, ok := c.entries[rel]
+	return e, ok
+}
+
+// Lookup returns the absolute path of a catalogued file with the given
+// content hash. Files changed or removed since they were catalogued are
+// not returned.
+func (c *Catalog) Lookup(hash string) (string, bool) {
+	for _, rel := range c.byHash[hash] {
+		e := c.entries[rel]
+		path := c.Abs(e)
+		info, err := os.Stat(path)
+		if err == nil && info.Size() == e.Size && info.ModTime().Equal(e.ModTime) {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// Entries returns every catalogued file, ordered by path.
+func (c *Catalog) Entries() []Entry {
+	out := make([]Entry, 0, len(c.entries))
+	for _, e := range c.entries {
+		out = append(out, e)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Path < out[j].Path })
+	return out
+}
+
+// Pending returns how many staged changes Commit would write.
+func (c *Catalog) Pending() int {
+	return len(c.staged)
+}
+
+// Commit appends the staged changes as one batch and syncs it to disk.
+// Until its commit line is written, the batch is not part of the catalog.
+func (c *Catalog) Commit() error {
+	if len(c.staged) == 0 {
+		return nil
+	}
+	var b strings.Builder
+	batch := append([]record{{Op: "begin"}}, c.staged...)
+	for _, r := range append(batch, record{Op: "commit"}) {
+		line, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		b.Write(line)
+		b.WriteByte('\n')
+	}
+	f, err := os.OpenFile(c.path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("writing catalog: %w", err)
+	}
+	data := b.String()
+	// A batch torn mid-line must not run into this one.
+	if info, err := f.Stat(); err == nil && info.Size() > 0 {
+		last := make([]byte, 1)
+		if _, err := f.ReadAt(last, info.Size()-1); err == nil && last[0] != '\n' {
+			data = "\n" + data
+		}
+	}
+	_, err = f.WriteString(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("writing catalog: %w", err)
+	}
+	c.staged = nil
+	return nil
+}
+
+// Rebuild returns an empty catalog of the archive at root whose commits go
+// to a scratch file, so a rebuild can commit in batches while the current
+// catalog stays in place. Replace then makes it the archive's catalog.
+func Rebuild(root string) (*Catalog, error) {
+	c := &Catalog{root: root, path: filepath.Join(root, RebuildFileName), entries: map[string]Entry{}}
+	c.reindex()
+	// A rebuild that was interrupted starts over.
+	if err := os.Remove(c.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("rebuilding catalog: %w", err)
+	}
+	return c, nil
+}
+
+// Compact rewrites the catalog of the archive at root as one batch holding
+// its current entries, dropping replaced and deleted entries and torn
+// batches. Like a rebuild, it writes a scratch file that then replaces the
+// catalog, so an interrupted compaction leaves the old one in place.
+func Compact(root string) (*Catalog, error) {
+	old, err := Open(root)
+	if err != nil {
+		return nil, err
+	}
+	c, err := Rebuild(root)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range old.Entries() {
+		c.Put(e)
+	}
+	if err := c.Replace(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Replace commits the staged changes of a rebuilt catalog and renames it
+// over the archive's catalog.
+func (c *Catalog) Replace() error {
+	if err := c.Commit(); err != nil {
+		return err
+	}
+	if c.path == Path(c.root) {
+		return nil
+	}
+	// An empty archive still gets its (empty) catalog.
+	f, err := os.OpenFile(c.path, os.O_CREATE|os.O_WRONLY, 0o644)
+	if err == nil {
+		err = f.Close()
+	}
+	if err == nil {
+		err = os.Rename(c.path, Path(c.root))
+	}
+	if err != nil {
+		return fmt.Errorf("replacing catalog: %w", err)
+	}
+	c.path = Path(c.root)
+	return nil
+}
@@ -0,0 +1,197 @@
+package catalog
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// archived writes content at rel below root and returns its entry.
+func archived(t *testing.T, root, rel, content, hash string) Entry {
+	t.Helper()
+	path := filepath.Join(root, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Entry{Path: rel, Size: info.Size(), ModTime: info.ModTime(), Hash: hash, Camera: "Canon EOS R5"}
+}
+
+func TestCatalog_CommitAndReopen(t *testing.T) {
+	root := t.TempDir()
+	c, err := Open(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Put(archived(t, root, "2025/01/a.jpg", "a", "ha"))
+	c.Put(archived(t, root, "2025/01/b.jpg", "b", "hb"))
+	if path, ok := c.Lookup("ha"); !ok || path != filepath.Join(root, "2025/01/a.jpg") {
+		t.Errorf("staged entries should be looked up at once, got %q, %v", path, ok)
+	}
+	if err := c.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	c.Delete("2025/01/b.jpg")
+	if err := c.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err = Open(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Len() != 1 {
+		t.Fatalf("expected 1 entry after the delete, got %v", c.Entries())
+	}
+	if e, ok := c.Get("2025/01/a.jpg"); !ok || e.Camera != "Canon EOS R5" {
+		t.Errorf("entry = %+v, %v", e, ok)
+	}
+	if _, ok := c.Lookup("hb"); ok {
+		t.Error("a deleted entry should not be looked up")
+	}
+}
+
+func TestCatalog_IgnoresUncommittedBatch(t *testing.T) {
+	root := t.TempDir()
+	c, _ := Open(root)
+	c.Put(archived(t, root, "a.jpg", "a", "ha"))
+	if err := c.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	// A crash mid-batch leaves a begin, a put and half a line.
+	f, err := os.OpenFile(Path(root), os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"op":"begin"}` + "\n" + `{"op":"put","entry":{"path":"lost.jpg","sha256":"hl"}}` + "\n" + `{"op":"put","ent`)
+	f.Close()
+
+	c, err = Open(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.Get("lost.jpg"); ok || c.Len() != 1 {
+		t.Fatalf("the interrupted batch should be ignored, got %v", c.Entries())
+	}
+
+	c.Put(archived(t, root, "b.jpg", "b", "hb"))
+	if err := c.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	c, err = Open(root)
+	if err != nil {
+		t.Fatalf("a batch after a torn one should read: %v", err)
+	}
+	if _, ok := c.Get("b.jpg"); !ok || c.Len() != 2 {
+		t.Errorf("entries = %v", c.Entries())
+	}
+}
+
+func TestCatalog_ReadsSHA256Key(t *testing.T) {
+	root := t.TempDir()
+	line := `{"op":"put","entry":{"path":"old.jpg","sha256":"ho"}}`
+	if err := os.WriteFile(Path(root), []byte(`{"op":"begin"}`+"\n"+line+"\n"+`{"op":"commit"}`+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c, err := Open(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e, ok := c.Get("old.jpg"); !ok || e.Hash != "ho" {
+		t.Errorf("entry of an older catalog = %+v, %v", e, ok)
+	}
+	data, err := json.Marshal(Entry{Path: "new.jpg", Hash: "xxh64:00"})
+	if err != nil || !strings.Contains(string(data), `"hash":"xxh64:00"`) {
+		t.Errorf("entries should be written with a hash key, got %s", data)
+	}
+}
+
+func TestCatalog_LookupSkipsChangedFiles(t *testing.T) {
+	root := t.TempDir()
+	c, _ := Open(root)
+	c.Put(archived(t, root, "a.jpg", "a", "ha"))
+	if err := os.WriteFile(filepath.Join(root, "a.jpg"), []byte("edited"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.Lookup("ha"); ok {
+		t.Error("a file changed since it was catalogued should not match")
+	}
+}
+
+func TestCatalog_Rebuild(t *testing.T) {
+	root := t.TempDir()
+	c, _ := Open(root)
+	c.Put(archived(t, root, "old.jpg", "old", "ho"))
+	if err := c.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := Rebuild(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Put(archived(t, root, "new.jpg", "new", "hn"))
+	if err := r.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if c, _ := Open(root); c.Len() != 1 {
+		t.Fatalf("the catalog should be untouched until Replace, got %v", c.Entries())
+	}
+	if err := r.Replace(); err != nil {
+		t.Fatal(err)
+	}
+	c, err = Open(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.Get("new.jpg"); !ok || c.Len() != 1 {
+		t.Errorf("entries after rebuild = %v", c.Entries())
+	}
+	if _, err := os.Stat(filepath.Join(root, RebuildFileName)); !os.IsNotExist(err) {
+		t.Errorf("expected the scratch file to be gone, got %v", err)
+	}
+}
+
+func TestCompact(t *testing.T) {
+	root := t.TempDir()
+	c, _ := Open(root)
+	c.Put(archived(t, root, "a.jpg", "a", "ha"))
+	c.Put(archived(t, root, "b.jpg", "b", "hb"))
+	if err := c.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	c.Put(archived(t, root, "a.jpg", "a2", "ha2"))
+	c.Delete("b.jpg")
+	if err := c.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Compact(root); err != nil {
+		t.Fatalf("Compact: %v", err)
+	}
+	data, err := os.ReadFile(Path(root))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 3 {
+		t.Errorf("compacted catalog has %d lines, want begin, put and commit:\n%s", lines, data)
+	}
+	c, err = Open(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e, ok := c.Get("a.jpg"); !ok || e.Hash != "ha2" || c.Len() != 1 {
+		t.Errorf("entries = %v, want only the replaced a.jpg", c.Entries())
+	}
+	if _, err := os.Stat(filepath.Join(root, RebuildFileName)); !os.IsNotExist(err) {
+		t.Errorf("scratch file left behind: %v", err)
+	}
+}
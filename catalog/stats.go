@@ -0,0 +1,97 @@
+package catalog
+
+import (
+	"sort"
+	"strconv"
+)
+
+// Undated is the year of files without a capture date, and Unknown the
+// camera of files without Make or Model tags, in Stats.
+const (
+	Undated = "undated"
+	Unknown = "unknown"
+)
+
+// Bucket counts the files of one month, camera or year.
+type Bucket struct {
+	Key   string `json:"key"`
+	Files int    `json:"files"`
+	Bytes int64  `json:"bytes"`
+	// TotalFiles and TotalBytes are the running totals up to and
+	// including the bucket, for growth over time.
+	TotalFiles int   `json:"total_files,omitempty"`
+	TotalBytes int64 `json:"total_bytes,omitempty"`
+}
+
+// Stats summarises a catalog.
+type Stats struct {
+	Files int   `json:"files"`
+	Bytes int64 `json:"bytes"`
+	// Months is how the archive grew, by month archived, oldest first.
+	Months []Bucket `json:"months"`
+	// Cameras are the cameras with the most files, most first.
+	Cameras []Bucket `json:"cameras"`
+	// Years is the storage per capture year, oldest first, with undated
+	// files last.
+	Years []Bucket `json:"years"`
+}
+
+// Summarize computes the stats of entries, keeping the top cameras.
+func Summarize(entries []Entry, top int) Stats {
+	var s Stats
+	months, cameras, years := map[string]*Bucket{}, map[string]*Bucket{}, map[string]*Bucket{}
+	count := func(m map[string]*Bucket, key string, e Entry) {
+		b, ok := m[key]
+		if !ok {
+			b = &Bucket{Key: key}
+			m[key] = b
+		}
+		b.Files++
+		b.Bytes += e.Size
+	}
+	for _, e := range entries {
+		s.Files++
+		s.Bytes += e.Size
+		count(months, e.Archived.Format("2006-01"), e)
+		camera := e.Camera
+		if camera == "" {
+			camera = Unknown
+		}
+		count(cameras, camera, e)
+		year := Undated
+		if !e.Captured.IsZero() {
+			year = strconv.Itoa(e.Captured.Year())
+		}
+		count(years, year, e)
+	}
+
+	s.Months = sorted(months, func(a, b Bucket) bool { return a.Key < b.Key })
+	for i := range s.Months {
+		s.Months[i].TotalFiles, s.Months[i].TotalBytes = s.Months[i].Files, s.Months[i].Bytes
+		if i > 0 {
+			s.Months[i].TotalFiles += s.Months[i-1].TotalFiles
+			s.Months[i].TotalBytes += s.Months[i-1].TotalBytes
+		}
+	}
+	s.Cameras = sorted(cameras, func(a, b Bucket) bool {
+		if a.Files != b.Files {
+			return a.Files > b.Files
+		}
+		return a.Key < b.Key
+	})
+	if top > 0 && len(s.Cameras) > top {
+		s.Cameras = s.Cameras[:top]
+	}
+	// "undated" sorts after the years.
+	s.Years = sorted(years, func(a, b Bucket) bool { return a.Key < b.Key })
+	return s
+}
+
+func sorted(m map[string]*Bucket, less func(a, b Bucket) bool) []Bucket {
+	out := make([]Bucket, 0, len(m))
+	for _, b := range m {
+		out = append(out, *b)
+	}
+	sort.Slice(out, func(i, j int) bool { return less(out[i], out[j]) })
+	return out
+}
@@ -0,0 +1,42 @@
+package catalog
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSummarize(t *testing.T) {
+	day := func(s string) time.Time {
+		d, _ := time.Parse("2006-01-02", s)
+		return d
+	}
+	entries := []Entry{
+		{Path: "a", Size: 10, Camera: "Canon EOS R5", Captured: day("2024-12-30"), Archived: day("2025-01-02")},
+		{Path: "b", Size: 20, Camera: "Canon EOS R5", Captured: day("2025-01-01"), Archived: day("2025-01-03")},
+		{Path: "c", Size: 30, Camera: "FUJIFILM X-T5", Captured: day("2025-03-01"), Archived: day("2025-03-04")},
+		{Path: "d", Size: 40, Archived: day("2025-03-05")},
+	}
+
+	s := Summarize(entries, 2)
+
+	if s.Files != 4 || s.Bytes != 100 {
+		t.Errorf("totals = %d files, %d bytes", s.Files, s.Bytes)
+	}
+	wantMonths := []Bucket{
+		{Key: "2025-01", Files: 2, Bytes: 30, TotalFiles: 2, TotalBytes: 30},
+		{Key: "2025-03", Files: 2, Bytes: 70, TotalFiles: 4, TotalBytes: 100},
+	}
+	if len(s.Months) != 2 || s.Months[0] != wantMonths[0] || s.Months[1] != wantMonths[1] {
+		t.Errorf("months = %+v", s.Months)
+	}
+	if len(s.Cameras) != 2 || s.Cameras[0].Key != "Canon EOS R5" || s.Cameras[0].Files != 2 || s.Cameras[1].Key != "FUJIFILM X-T5" {
+		t.Errorf("cameras = %+v", s.Cameras)
+	}
+	var years []string
+	for _, y := range s.Years {
+		years = append(years, y.Key)
+	}
+	if len(years) != 3 || years[0] != "2024" || years[1] != "2025" || years[2] != Undated {
+		t.Errorf("years = %v", years)
+	}
+}
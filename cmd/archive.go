@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/Tmunayyer/gocamelpack/config"
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/vfs"
+	"github.com/spf13/cobra"
+)
+
+// archiveGuard refuses move sources the config's destinations.deny
+// protects, and those inside a marked archive unless --allow-archive-source
+// was given.
+type archiveGuard struct {
+	fsys         vfs.FS
+	destinations config.Destinations
+	// archives refuses sources inside an archive.
+	archives bool
+	// roots caches the archive root of each folder looked at; "" when it
+	// is not in an archive.
+	roots map[string]string
+}
+
+func newArchiveGuard(fsys vfs.FS, destinations config.Destinations, archives bool) *archiveGuard {
+	return &archiveGuard{fsys: fsys, destinations: destinations, archives: archives, roots: map[string]string{}}
+}
+
+// check fails when src is protected or lies in an archive. A nil guard
+// allows everything.
+func (g *archiveGuard) check(src string) error {
+	if g == nil {
+		return nil
+	}
+	if err := g.destinations.CheckRemove(src); err != nil {
+		return err
+	}
+	if !g.archives {
+		return nil
+	}
+	if root := g.root(filepath.Dir(src)); root != "" {
+		return fmt.Errorf("%s is in the archive %s, which move does not take files out of; use --allow-archive-source to move it anyway", src, root)
+	}
+	return nil
+}
+
+// checkAll checks every source before anything is moved.
+func (g *archiveGuard) checkAll(sources []string) error {
+	for _, src := range sources {
+		if err := g.check(src); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// root returns the archive root dir is in, or "".
+func (g *archiveGuard) root(dir string) string {
+	if root, ok := g.roots[dir]; ok {
+		return root
+	}
+	root := ""
+	if files.IsArchive(g.fsys, dir) {
+		root = dir
+	} else if parent := filepath.Dir(dir); parent != dir {
+		root = g.root(parent)
+	}
+	g.roots[dir] = root
+	return root
+}
+
+func createMarkArchiveCmd(d *deps.AppDeps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "mark-archive [archive-root]",
+		Short: "Mark a folder as an archive that move will not take files out of",
+		Long: `Writes a ` + files.ArchiveMarker + ` marker into the folder. move then refuses
+sources in it or below it unless --allow-archive-source is given, so the
+canonical library is not reshuffled by accident. copy, migrate and sync are
+unaffected. --remove takes the marker away again.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			root, err := filepath.Abs(args[0])
+			if err != nil {
+				return err
+			}
+			fsys := files.FSOf(d.Files)
+			if remove, _ := cmd.Flags().GetBool("remove"); remove {
+				if err := files.UnmarkArchive(fsys, root); err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "%s is no longer marked as an archive\n", root)
+				return nil
+			}
+			if err := files.MarkArchive(fsys, root); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Marked %s as an archive\n", root)
+			return nil
+		},
+	}
+	cmd.Flags().Bool("remove", false, "Remove the marker instead")
+	return cmd
+}
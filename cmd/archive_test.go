@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/config"
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/testutil"
+	"github.com/Tmunayyer/gocamelpack/vfs"
+)
+
+func TestMoveCmd_ArchiveSource(t *testing.T) {
+	tmp := filepath.Join(testutil.TempDir(t), "archive-source")
+	library := filepath.Join(tmp, "library")
+	metadata := writeCard(t, library, 2)
+	run := func(args ...string) (string, error) {
+		var out bytes.Buffer
+		root := newCLI(&deps.AppDeps{Files: createTestFilesService(metadata), Config: &config.Config{}, Streams: deps.Streams{Out: &out, Err: &out}})
+		root.SetArgs(args)
+		err := root.Execute()
+		return out.String(), err
+	}
+
+	if out, err := run("mark-archive", library); err != nil {
+		t.Fatalf("mark-archive: %v\n%s", err, out)
+	}
+	dst := filepath.Join(tmp, "elsewhere")
+	src := library
+	out, err := run("move", src, dst)
+	if err == nil || !contains(err.Error(), "--allow-archive-source") {
+		t.Fatalf("move out of an archive: got %v, want a refusal\n%s", err, out)
+	}
+	if _, err := os.Stat(filepath.Join(src, "IMG_0000.jpg")); err != nil {
+		t.Errorf("refused move still moved a file: %v", err)
+	}
+	if out, err := run("copy", src, dst); err != nil {
+		t.Fatalf("copy out of an archive: %v\n%s", err, out)
+	}
+
+	dst = filepath.Join(tmp, "again")
+	if out, err := run("move", "--allow-archive-source", src, dst); err != nil {
+		t.Fatalf("move --allow-archive-source: %v\n%s", err, out)
+	}
+	if !files.IsArchive(vfs.OS, library) {
+		t.Error("the marker was moved with the files")
+	}
+
+	if out, err := run("mark-archive", "--remove", library); err != nil {
+		t.Fatalf("mark-archive --remove: %v\n%s", err, out)
+	}
+	if _, err := os.Stat(filepath.Join(library, files.ArchiveMarker)); !os.IsNotExist(err) {
+		t.Errorf("marker left behind: %v", err)
+	}
+}
+
+func TestDestinationsDeny_ProtectsExistingFiles(t *testing.T) {
+	tmp := filepath.Join(testutil.TempDir(t), "deny")
+	protected := filepath.Join(tmp, "protected")
+	metadata := writeCard(t, protected, 1)
+	cfg := &config.Config{Destinations: config.Destinations{Deny: []string{protected + "/**"}}}
+	run := func(args ...string) (string, error) {
+		var out bytes.Buffer
+		root := newCLI(&deps.AppDeps{Files: createTestFilesService(metadata), Config: cfg, Streams: deps.Streams{Out: &out, Err: &out}})
+		root.SetArgs(args)
+		err := root.Execute()
+		return out.String(), err
+	}
+
+	out, err := run("move", protected, filepath.Join(tmp, "dst"))
+	if err == nil || !contains(err.Error(), "destinations.deny") {
+		t.Fatalf("move out of a denied tree: got %v, want a refusal\n%s", err, out)
+	}
+	if _, err := os.Stat(filepath.Join(protected, "IMG_0000.jpg")); err != nil {
+		t.Errorf("refused move still moved a file: %v", err)
+	}
+	if out, err := run("copy", protected, filepath.Join(tmp, "dst")); err != nil {
+		t.Fatalf("copy out of a denied tree: %v\n%s", err, out)
+	}
+	for _, args := range [][]string{{"clean", protected}, {"rollback-status", "session", protected}} {
+		if _, err := run(args...); err == nil || !contains(err.Error(), "destinations.deny") {
+			t.Errorf("%s in a denied tree: got %v, want a refusal", args[0], err)
+		}
+	}
+}
@@ -0,0 +1,283 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/Tmunayyer/gocamelpack/burst"
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/output"
+	"github.com/Tmunayyer/gocamelpack/rules"
+	"github.com/spf13/cobra"
+)
+
+// misplacedFile is an archived file whose metadata places it elsewhere.
+type misplacedFile struct {
+	Path     string `json:"path"`
+	Expected string `json:"expected"`
+	// Conflict is set when the expected location is already taken, by an
+	// existing file or an earlier relocation.
+	Conflict bool `json:"conflict,omitempty"`
+}
+
+// uncheckedFile is an archived file whose location could not be checked,
+// usually because it has no usable capture date.
+type uncheckedFile struct {
+	Path  string `json:"path"`
+	Error string `json:"error"`
+}
+
+// auditReport is the result of auditing an archive.
+type auditReport struct {
+	Root      string          `json:"root"`
+	Checked   int             `json:"checked"`
+	Misplaced []misplacedFile `json:"misplaced"`
+	Unchecked []uncheckedFile `json:"unchecked"`
+}
+
+func createAuditCmd(d *deps.AppDeps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "audit [archive-root]",
+		Short: "Check that archived files sit where their metadata says they belong",
+		Long: `Walks an archive created by copy or move and recomputes every file's
+destination from its metadata, using the same template, rules and
+normalization a new import would. Files in a different folder are reported as
+misplaced; file names are not compared, so renamed duplicates and burst
+folders are accepted. Hidden files and folders are ignored.
+
+With --fix-plan, a shell script that moves the misplaced files into place is
+written for review; nothing in the archive is changed by the audit itself.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			root, err := filepath.Abs(args[0])
+			if err != nil {
+				return err
+			}
+			if !d.Files.IsDirectory(root) {
+				return fmt.Errorf("%s is not a directory", root)
+			}
+
+			cfg, err := loadConfig(cmd, d)
+			if err != nil {
+				return err
+			}
+			engine, err := routingEngine(cmd, cfg)
+			if err != nil {
+				return err
+			}
+			normalize, _ := cmd.Flags().GetString("normalize")
+			if normalize == "" {
+				normalize = cfg.Normalize
+			}
+			norm, err := files.ParseNormalization(normalize)
+			if err != nil {
+				return err
+			}
+
+			report, err := auditArchive(d.Files, root, engine, norm)
+			if err != nil {
+				return err
+			}
+
+			if plan, _ := cmd.Flags().GetString("fix-plan"); plan != "" {
+				if err := writeFixPlanFile(plan, report); err != nil {
+					return err
+				}
+			}
+
+			if outputFormat(cmd) == "json" {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				return enc.Encode(report)
+			}
+			printAuditReport(cmd.OutOrStdout(), report)
+			return nil
+		},
+	}
+
+	cmd.Flags().String("template", "", "Template the archive was built with (default from config, else the built-in layout)")
+	cmd.Flags().String("locale", "", "Language of {month_name} and {weekday} in the template, e.g. de or fr (default from config, else English)")
+	cmd.Flags().String("normalize", "", "Unicode normalization the archive was built with: nfc, nfd or none (default from config, else nfc)")
+	cmd.Flags().String("fix-plan", "", "Write a shell script that relocates the misplaced files to this path")
+	return cmd
+}
+
+// placement is an archived file and where its metadata places it today.
+type placement struct {
+	Path     string
+	Expected string
+}
+
+// placeArchive recomputes the destination of every non-hidden file below
+// root. Files already in a burst folder keep it at their new location.
+// Files excluded by a skip rule are left out; files without a usable
+// destination are returned as unchecked.
+func placeArchive(fs files.FilesService, root string, engine *rules.Engine, norm files.Normalization) ([]placement, []uncheckedFile, error) {
+	placed := []placement{}
+	unchecked := []uncheckedFile{}
+
+	err := walkArchive(root, func(path string) error {
+		s, err := ruleSubject(fs, path, engine.NeedsSize())
+		if err != nil {
+			unchecked = append(unchecked, uncheckedFile{Path: path, Error: err.Error()})
+			return nil
+		}
+		expected, decision, err := engine.Destination(s, root, fs.DestinationFromMetadata)
+		if err != nil {
+			unchecked = append(unchecked, uncheckedFile{Path: path, Error: err.Error()})
+			return nil
+		}
+		if decision.Action == rules.ActionSkip {
+			// A rule keeps such files out of new imports; there is no
+			// right place to compare against.
+			return nil
+		}
+
+		expected = files.NormalizeBelow(root, expected, norm)
+		if id := burstID(filepath.Dir(path)); id != "" && decision.Action != rules.ActionUnsorted {
+			expected = files.NormalizeBelow(root, burst.Destination(expected, path, id), norm)
+		}
+		placed = append(placed, placement{Path: path, Expected: expected})
+		return nil
+	})
+	return placed, unchecked, err
+}
+
+// auditArchive reports the files below root that are not in the folder
+// engine would put them in today.
+func auditArchive(fs files.FilesService, root string, engine *rules.Engine, norm files.Normalization) (auditReport, error) {
+	placed, unchecked, err := placeArchive(fs, root, engine, norm)
+	if err != nil {
+		return auditReport{}, err
+	}
+
+	report := auditReport{Root: root, Checked: len(placed), Misplaced: []misplacedFile{}, Unchecked: unchecked}
+	taken := map[string]bool{}
+	for _, p := range placed {
+		actual := files.NormalizeBelow(root, p.Path, norm)
+		if filepath.Dir(actual) == filepath.Dir(p.Expected) {
+			continue
+		}
+		m := misplacedFile{Path: p.Path, Expected: p.Expected}
+		if _, err := os.Lstat(p.Expected); err == nil || taken[p.Expected] {
+			m.Conflict = true
+		}
+		taken[p.Expected] = true
+		report.Misplaced = append(report.Misplaced, m)
+	}
+	return report, nil
+}
+
+// walkArchive calls fn for every regular file below root, skipping hidden
+// files and folders such as the destination lock.
+func walkArchive(root string, fn func(path string) error) error {
+	return filepath.WalkDir(root, func(path string, e os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(e.Name(), ".") && path != root {
+			if e.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if e.IsDir() || !e.Type().IsRegular() {
+			return nil
+		}
+		return fn(path)
+	})
+}
+
+// burstID returns the burst ID when dir is a burst folder.
+func burstID(dir string) string {
+	if filepath.Base(filepath.Dir(dir)) == burst.Dir {
+		return filepath.Base(dir)
+	}
+	return ""
+}
+
+func printAuditReport(w io.Writer, r auditReport) {
+	p := output.New(w)
+	if len(r.Misplaced) > 0 {
+		ms := make([]output.Mapping, len(r.Misplaced))
+		for i, m := range r.Misplaced {
+			ms[i] = output.Mapping{Source: m.Path, Destination: m.Expected, Conflict: m.Conflict}
+		}
+		p.Mappings("misplaced", ms)
+	}
+	if len(r.Unchecked) > 0 {
+		errs := make([]error, len(r.Unchecked))
+		for i, u := range r.Unchecked {
+			errs[i] = fmt.Errorf("%s: %s", u.Path, u.Error)
+		}
+		p.ErrorList(fmt.Sprintf("Could not check %d file(s):", len(r.Unchecked)), errs)
+	}
+
+	summary := fmt.Sprintf("Audited %d file(s): %d misplaced", r.Checked, len(r.Misplaced))
+	if len(r.Unchecked) > 0 {
+		summary += fmt.Sprintf(", %d unchecked", len(r.Unchecked))
+	}
+	if len(r.Misplaced) > 0 {
+		p.Println(output.Warning, "%s", summary)
+		return
+	}
+	p.Success("%s", summary)
+}
+
+func writeFixPlanFile(path string, r auditReport) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("creating fix plan: %w", err)
+	}
+	err = writeFixPlan(f, r)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("writing fix plan: %w", err)
+	}
+	return nil
+}
+
+// writeFixPlan writes a POSIX shell script relocating r's misplaced files.
+// Relocations whose target is taken are left as comments for a human to
+// resolve, and mv -n never overwrites should the archive change meanwhile.
+func writeFixPlan(w io.Writer, r auditReport) error {
+	// bufio keeps the first write error for Flush to return.
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "#!/bin/sh")
+	fmt.Fprintf(bw, "# Relocates %d misplaced file(s) in %s; review before running.\n", len(r.Misplaced), commentSafe(r.Root))
+	fmt.Fprintln(bw, "set -e")
+	for _, m := range r.Misplaced {
+		if m.Conflict {
+			fmt.Fprintf(bw, "# target exists: mv -n %s %s\n", commentSafe(shellQuote(m.Path)), commentSafe(shellQuote(m.Expected)))
+			continue
+		}
+		fmt.Fprintf(bw, "mkdir -p %s\n", shellQuote(filepath.Dir(m.Expected)))
+		fmt.Fprintf(bw, "mv -n %s %s\n", shellQuote(m.Path), shellQuote(m.Expected))
+	}
+	return bw.Flush()
+}
+
+// shellQuote quotes s as a single POSIX shell word.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// commentSafe escapes the control characters of s, Go style, so a path
+// with a newline cannot end a comment line and run the rest of itself.
+func commentSafe(s string) string {
+	if strings.IndexFunc(s, unicode.IsControl) < 0 {
+		return s
+	}
+	q := strconv.Quote(s)
+	return q[1 : len(q)-1]
+}
@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/config"
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+func TestAuditCmd(t *testing.T) {
+	tmp := testutil.TempDir(t)
+	root := filepath.Join(tmp, "archive")
+	write := func(rel string) string {
+		p := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	// Every file is dated 2025-01-27 15:30 by the test service.
+	write("2025/01/27/15_30.jpg")
+	write("2025/01/27/15_30_1.jpg")
+	write("2025/01/27/bursts/IMG_0001/IMG_0002.jpg")
+	write(files.LockName)
+	misplaced := write("2024/12/31/IMG_0100.jpg")
+	strayBurst := write("2019/06/01/bursts/IMG_0200/IMG_0201.jpg")
+	undated := write("unsorted/scan.png")
+
+	fs := createTestFilesService(map[string]files.FileMetadata{
+		undated: {Filepath: undated, Tags: map[string]string{"FileType": "PNG"}},
+	})
+	run := func(args ...string) string {
+		t.Helper()
+		var out bytes.Buffer
+		root := newCLI(&deps.AppDeps{Files: fs, Config: &config.Config{}, Streams: deps.Streams{Out: &out, Err: &out}})
+		root.SetArgs(append([]string{"audit"}, args...))
+		if err := root.Execute(); err != nil {
+			t.Fatalf("audit %v: %v\n%s", args, err, out.String())
+		}
+		return out.String()
+	}
+
+	plan := filepath.Join(tmp, "fix.sh")
+	var report auditReport
+	if err := json.Unmarshal([]byte(run("--output", "json", "--fix-plan", plan, root)), &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Checked != 5 {
+		t.Errorf("checked %d file(s), want 5", report.Checked)
+	}
+	want := []misplacedFile{
+		{Path: strayBurst, Expected: filepath.Join(root, "2025/01/27/bursts/IMG_0200/IMG_0201.jpg")},
+		{Path: misplaced, Expected: filepath.Join(root, "2025/01/27/15_30.jpg"), Conflict: true},
+	}
+	if len(report.Misplaced) != len(want) {
+		t.Fatalf("misplaced = %+v, want %+v", report.Misplaced, want)
+	}
+	for i := range want {
+		if report.Misplaced[i] != want[i] {
+			t.Errorf("misplaced[%d] = %+v, want %+v", i, report.Misplaced[i], want[i])
+		}
+	}
+	if len(report.Unchecked) != 1 || report.Unchecked[0].Path != undated {
+		t.Errorf("unchecked = %+v, want only %s", report.Unchecked, undated)
+	}
+
+	script, err := os.ReadFile(plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		"# target exists: mv -n '" + misplaced + "'",
+		"mv -n '" + strayBurst + "' '" + want[0].Expected + "'",
+	} {
+		if !strings.Contains(string(script), line) {
+			t.Errorf("fix plan lacks %q:\n%s", line, script)
+		}
+	}
+	if _, err := os.Stat(strayBurst); err != nil {
+		t.Errorf("audit must not move files: %v", err)
+	}
+
+	if out := run(root); !strings.Contains(out, "Audited 5 file(s): 2 misplaced, 1 unchecked") {
+		t.Errorf("unexpected text report:\n%s", out)
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	if got := shellQuote("it's here"); got != `'it'\''s here'` {
+		t.Errorf("shellQuote = %s", got)
+	}
+}
+
+func TestWriteFixPlan_EscapesComments(t *testing.T) {
+	var b strings.Builder
+	r := auditReport{Root: "/archive\nrm -rf ~", Misplaced: []misplacedFile{
+		{Path: "/archive/a\nrm -rf ~\n.jpg", Expected: "/archive/b.jpg", Conflict: true},
+	}}
+	if err := writeFixPlan(&b, r); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(b.String(), "\n") {
+		if strings.HasPrefix(line, "rm") {
+			t.Fatalf("a path escaped its comment:\n%s", b.String())
+		}
+	}
+	if !strings.Contains(b.String(), `# target exists: mv -n '/archive/a\nrm -rf ~\n.jpg'`) {
+		t.Errorf("expected the control characters escaped:\n%s", b.String())
+	}
+}
@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Tmunayyer/gocamelpack/bench"
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/output"
+	"github.com/Tmunayyer/gocamelpack/units"
+	"github.com/spf13/cobra"
+)
+
+// defaultBenchSample is how much data bench copies per setting.
+const defaultBenchSample = 256 << 20
+
+func createBenchCmd(d *deps.AppDeps) *cobra.Command {
+	sample := units.ByteSize(defaultBenchSample)
+	cmd := &cobra.Command{
+		Use:   "bench [dir]",
+		Short: "Measure copy throughput to tune --jobs and --buffer-size",
+		Long: `Copies a sample of the files in dir (or, without dir, generated camera-like
+files) into a temporary folder below --to at each combination of --jobs and
+--buffer-sizes, and reports the throughput of each. Point --to at the
+archive's volume, such as a NAS mount, to tune imports for it. The fastest
+setting is printed as config entries to persist; settings within 5% of it
+count as equally fast and the cheapest of them is recommended.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			settings, err := benchSettings(cmd)
+			if err != nil {
+				return err
+			}
+			to, _ := cmd.Flags().GetString("to")
+			if to == "" {
+				to = os.TempDir()
+			}
+			scratch, err := os.MkdirTemp(to, ".gocamelpack-bench-")
+			if err != nil {
+				return err
+			}
+			defer os.RemoveAll(scratch)
+
+			var w bench.Workload
+			if len(args) == 1 {
+				w, err = bench.Sample(args[0], int64(sample))
+			} else {
+				w, err = bench.Generate(filepath.Join(scratch, "sample"), int64(sample))
+			}
+			if err != nil {
+				return err
+			}
+			if err := w.Warm(); err != nil {
+				return err
+			}
+
+			p := output.New(cmd.ErrOrStderr())
+			results := make([]bench.Result, 0, len(settings))
+			for _, s := range settings {
+				p.Println(output.Plain, "copying %d file(s), %s, with %s…", len(w.Files), units.ByteSize(w.Bytes), s)
+				r, err := bench.Run(w, scratch, s)
+				if err != nil {
+					return fmt.Errorf("%s: %w", s, err)
+				}
+				results = append(results, r)
+			}
+			return printBench(cmd, results, bench.Best(results))
+		},
+	}
+
+	cmd.Flags().String("to", "", "Directory to copy into, ideally on the archive's volume (default the system temp directory)")
+	cmd.Flags().IntSlice("jobs", []int{1, 2, 4, 8}, "Worker counts to try")
+	cmd.Flags().StringSlice("buffer-sizes", []string{"default", "128KiB", "1MiB", "4MiB"}, "Copy buffer sizes to try; default lets the OS choose")
+	cmd.Flags().Var(&sample, "sample-size", "How much data to copy per setting")
+	return cmd
+}
+
+// benchSettings combines --jobs and --buffer-sizes, cheapest first.
+func benchSettings(cmd *cobra.Command) ([]bench.Setting, error) {
+	jobs, _ := cmd.Flags().GetIntSlice("jobs")
+	sizes, _ := cmd.Flags().GetStringSlice("buffer-sizes")
+	var settings []bench.Setting
+	for _, j := range jobs {
+		if j < 1 {
+			return nil, fmt.Errorf("--jobs must be positive")
+		}
+		for _, s := range sizes {
+			var size units.ByteSize
+			if s != "default" {
+				var err error
+				if size, err = units.ParseByteSize(s); err != nil {
+					return nil, fmt.Errorf("--buffer-sizes: %w", err)
+				}
+			}
+			settings = append(settings, bench.Setting{Jobs: j, BufferSize: size})
+		}
+	}
+	if len(settings) == 0 {
+		return nil, fmt.Errorf("nothing to measure: --jobs and --buffer-sizes must not be empty")
+	}
+	return settings, nil
+}
+
+// benchResult is the JSON shape of one measured setting.
+type benchResult struct {
+	Jobs           int     `json:"jobs"`
+	BufferSize     int64   `json:"buffer_size"`
+	Files          int     `json:"files"`
+	Bytes          int64   `json:"bytes"`
+	Seconds        float64 `json:"seconds"`
+	BytesPerSecond float64 `json:"bytes_per_second"`
+}
+
+// printBench reports the results and the recommended config entries.
+func printBench(cmd *cobra.Command, results []bench.Result, best bench.Result) error {
+	if outputFormat(cmd) == "json" {
+		var obj struct {
+			Results     []benchResult `json:"results"`
+			Recommended benchResult   `json:"recommended"`
+		}
+		convert := func(r bench.Result) benchResult {
+			return benchResult{r.Jobs, int64(r.BufferSize), r.Files, r.Bytes, r.Elapsed.Seconds(), r.BytesPerSecond()}
+		}
+		for _, r := range results {
+			obj.Results = append(obj.Results, convert(r))
+		}
+		obj.Recommended = convert(best)
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		return enc.Encode(obj)
+	}
+
+	p := output.New(cmd.OutOrStdout())
+	for _, r := range results {
+		style := output.Plain
+		if r.Setting == best.Setting {
+			style = output.Success
+		}
+		p.Println(style, "%-28s %10s/s  %s", r.Setting, units.ByteSize(r.BytesPerSecond()), r.Elapsed.Round(time.Millisecond))
+	}
+	entries := []string{fmt.Sprintf(`"jobs": %d`, best.Jobs)}
+	if best.BufferSize > 0 {
+		entries = append(entries, fmt.Sprintf(`"buffer_size": %q`, strings.ReplaceAll(best.BufferSize.String(), " ", "")))
+	}
+	p.Success("Recommended: %s. Add to your config file:", best.Setting)
+	p.Println(output.Plain, "  %s", strings.Join(entries, ",\n  "))
+	return nil
+}
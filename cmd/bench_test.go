@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/config"
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+func TestBenchCmd(t *testing.T) {
+	tmp := testutil.TempDir(t)
+	src := filepath.Join(tmp, "card")
+	if err := os.MkdirAll(src, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a.jpg", "b.jpg"} {
+		if err := os.WriteFile(filepath.Join(src, name), make([]byte, 64<<10), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	to := filepath.Join(tmp, "nas")
+	if err := os.MkdirAll(to, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	var out, errOut bytes.Buffer
+	root := newCLI(&deps.AppDeps{Files: createTestFilesService(nil), Config: &config.Config{}, Streams: deps.Streams{Out: &out, Err: &errOut}})
+	root.SetArgs([]string{"bench", "--output", "json", "--to", to, "--jobs", "1,2", "--buffer-sizes", "default,32KiB", src})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("bench: %v\n%s", err, errOut.String())
+	}
+
+	var got struct {
+		Results     []benchResult `json:"results"`
+		Recommended benchResult   `json:"recommended"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, out.String())
+	}
+	if len(got.Results) != 4 || got.Results[3].Jobs != 2 || got.Results[3].BufferSize != 32<<10 {
+		t.Fatalf("unexpected results %+v", got.Results)
+	}
+	if got.Results[0].Files != 2 || got.Results[0].Bytes != 128<<10 || got.Recommended.Jobs == 0 {
+		t.Errorf("unexpected result %+v, recommended %+v", got.Results[0], got.Recommended)
+	}
+	if entries, _ := os.ReadDir(to); len(entries) != 0 {
+		t.Errorf("bench should clean up after itself, found %d entries", len(entries))
+	}
+}
+
+func TestTransferOptions_BufferSize(t *testing.T) {
+	cmd := createCopyCmd(&deps.AppDeps{Config: &config.Config{BufferSize: 1 << 20}})
+	opts, err := transferOptionsFromFlags(cmd, &deps.AppDeps{Config: &config.Config{BufferSize: 1 << 20}}, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer opts.lock.Release()
+	if opts.bufferSize != 1<<20 {
+		t.Errorf("bufferSize = %d, want the config's 1 MiB", opts.bufferSize)
+	}
+
+	cmd.Flags().Set("buffer-size", "64KiB")
+	cmd.Flags().Set("no-lock", "true")
+	if opts, err = transferOptionsFromFlags(cmd, &deps.AppDeps{Config: &config.Config{BufferSize: 1 << 20}}, t.TempDir()); err != nil || opts.bufferSize != 64<<10 {
+		t.Errorf("--buffer-size should win over the config: %d, %v", opts.bufferSize, err)
+	}
+	cmd.Flags().Set("buffer-size", "lots")
+	if _, err := transferOptionsFromFlags(cmd, &deps.AppDeps{Config: &config.Config{}}, t.TempDir()); err == nil {
+		t.Error("expected an error for an invalid --buffer-size")
+	}
+}
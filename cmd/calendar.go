@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Tmunayyer/gocamelpack/output"
+	"github.com/Tmunayyer/gocamelpack/pathtmpl"
+	"github.com/spf13/cobra"
+)
+
+// calendarGlyphs shade a day by its share of the busiest day's files,
+// from none to most; calendarASCII stands in where block characters
+// cannot be shown.
+var (
+	calendarGlyphs = []string{"·", "░", "▒", "▓", "█"}
+	calendarASCII  = []string{".", ":", "+", "#", "@"}
+)
+
+// previewCalendar counts the planned files of a dry run by capture day,
+// for --preview-calendar, so wrong dates stand out before the run.
+type previewCalendar struct {
+	mu      sync.Mutex
+	days    map[time.Time]int
+	undated int
+}
+
+func newPreviewCalendar() *previewCalendar {
+	return &previewCalendar{days: map[time.Time]int{}}
+}
+
+// plan counts a planned file under its capture day.
+func (c *previewCalendar) plan(p routed) {
+	if p.skip {
+		return
+	}
+	t, _, ok := pathtmpl.CaptureTime(p.md)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !ok {
+		c.undated++
+		return
+	}
+	c.days[time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)]++
+}
+
+// print draws one row per month with files, a cell per day shaded by how
+// many files it has, and the month's total.
+func (c *previewCalendar) print(cmd *cobra.Command) {
+	glyphs := calendarGlyphs
+	if output.ASCIIOnly() {
+		glyphs = calendarASCII
+	}
+	months := map[time.Time]int{}
+	busiest := 0
+	for day, n := range c.days {
+		months[time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC)] += n
+		busiest = max(busiest, n)
+	}
+	order := make([]time.Time, 0, len(months))
+	for m := range months {
+		order = append(order, m)
+	}
+	sort.Slice(order, func(i, j int) bool { return order[i].Before(order[j]) })
+
+	out := cmd.OutOrStdout()
+	p := output.New(out)
+	fmt.Fprintln(out)
+	p.Println(output.Plain, "Files per capture day:")
+	fmt.Fprintf(out, "%-9s%-9s%-10s%-10s%s\n", "", "1", "10", "20", "30")
+	for _, m := range order {
+		var row strings.Builder
+		last := m.AddDate(0, 1, -1).Day()
+		for d := 1; d <= 31; d++ {
+			switch n := c.days[m.AddDate(0, 0, d-1)]; {
+			case d > last:
+				row.WriteByte(' ')
+			case n == 0:
+				row.WriteString(glyphs[0])
+			default:
+				// Shade 1 to 4 by the share of the busiest day.
+				row.WriteString(glyphs[(n*4+busiest-1)/busiest])
+			}
+		}
+		fmt.Fprintf(out, "%s  %s  %6d\n", m.Format("2006-01"), row.String(), months[m])
+	}
+	if c.undated > 0 {
+		fmt.Fprintf(out, "undated: %d\n", c.undated)
+	}
+	if busiest > 0 {
+		p.Println(output.Dim, "%s none, %s fewer to more files; the busiest day has %d", glyphs[0], strings.Join(glyphs[1:], ""), busiest)
+	}
+}
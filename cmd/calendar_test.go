@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/config"
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+func TestCopyCmd_PreviewCalendar(t *testing.T) {
+	t.Setenv("GOCAMELPACK_ASCII", "1")
+	tmp := filepath.Join(testutil.TempDir(t), "calendar")
+	card := filepath.Join(tmp, "card")
+	if err := os.MkdirAll(card, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	md := map[string]files.FileMetadata{}
+	dates := map[string]string{
+		"a.jpg": "2025:01:02 10:00:00",
+		"b.jpg": "2025:01:02 11:00:00",
+		"c.jpg": "2025:01:02 12:00:00",
+		"d.jpg": "2025:01:02 13:00:00",
+		"e.jpg": "2025:01:31 10:00:00",
+		"f.jpg": "1970:01:01 00:00:00",
+		"g.jpg": "",
+	}
+	for name, date := range dates {
+		path := filepath.Join(card, name)
+		if err := os.WriteFile(path, []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		md[path] = files.FileMetadata{Filepath: path, Tags: map[string]string{"CreationDate": date}}
+	}
+
+	var out bytes.Buffer
+	root := newCLI(&deps.AppDeps{Files: createTestFilesService(md), Config: &config.Config{Template: "{if year}{year}/{month}{else}undated{end}/{orig}"}, Streams: deps.Streams{Out: &out, Err: &out}})
+	root.SetArgs([]string{"copy", "--dry-run", "--preview-calendar", card, filepath.Join(tmp, "dst")})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("copy: %v\n%s", err, out.String())
+	}
+	for _, want := range []string{
+		"1970-01  :" + strings.Repeat(".", 30) + "       1",
+		"2025-01  .@" + strings.Repeat(".", 28) + ":       5",
+		"undated: 1",
+		"the busiest day has 4",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected %q in:\n%s", want, out.String())
+		}
+	}
+	if strings.Index(out.String(), "1970-01") > strings.Index(out.String(), "2025-01") {
+		t.Errorf("months should be in order:\n%s", out.String())
+	}
+
+	root = newCLI(&deps.AppDeps{Files: createTestFilesService(md), Config: &config.Config{}, Streams: deps.Streams{Out: &out, Err: &out}})
+	root.SetArgs([]string{"copy", "--preview-calendar", card, filepath.Join(tmp, "dst")})
+	if err := root.Execute(); err == nil || !strings.Contains(err.Error(), "requires --dry-run") {
+		t.Errorf("expected --preview-calendar to require --dry-run, got %v", err)
+	}
+}
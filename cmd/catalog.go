@@ -0,0 +1,430 @@
+package cmd
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Tmunayyer/gocamelpack/catalog"
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/hashindex"
+	"github.com/Tmunayyer/gocamelpack/output"
+	"github.com/Tmunayyer/gocamelpack/pathtmpl"
+	"github.com/Tmunayyer/gocamelpack/progress"
+	"github.com/spf13/cobra"
+)
+
+// catalogTags are the tags read for the catalog besides the capture dates.
+var catalogTags = []string{"Make", "Model"}
+
+// archiveCatalog records the files a run archives in the destination's
+// catalog (--catalog), committing them as one batch when the run ends.
+// Files a rule places under its own root go in that root's catalog.
+type archiveCatalog struct {
+	cat *catalog.Catalog
+	// ruleRoots are the catalogs of the rules' own roots.
+	ruleRoots []*catalog.Catalog
+	session   string
+	tags      map[string]string
+	// hashOf returns a source's content hash when dedupe already read it.
+	hashOf func(src string) (string, bool)
+	// algo hashes the files dedupe did not.
+	algo hashindex.Algo
+	// now dates the entries.
+	now func() time.Time
+
+	// mu guards the fields below and the catalog, for parallel copies.
+	mu     sync.Mutex
+	md     map[string]files.FileMetadata // source -> planned metadata
+	failed []string
+}
+
+// openArchiveCatalog opens the catalogs of dstRoot and of the rules' own
+// roots for a run.
+func openArchiveCatalog(dstRoot string, ruleRoots []string, session string, tags map[string]string, algo hashindex.Algo) (*archiveCatalog, error) {
+	cat, err := catalog.Open(dstRoot)
+	if err != nil {
+		return nil, err
+	}
+	c := &archiveCatalog{cat: cat, session: session, tags: tags, algo: algo, now: time.Now, md: map[string]files.FileMetadata{}}
+	for _, root := range ruleRoots {
+		rc, err := catalog.Open(root)
+		if err != nil {
+			return nil, err
+		}
+		c.ruleRoots = append(c.ruleRoots, rc)
+	}
+	return c, nil
+}
+
+// catalogs returns the archive's catalog followed by the rule roots'.
+func (c *archiveCatalog) catalogs() []*catalog.Catalog {
+	return append([]*catalog.Catalog{c.cat}, c.ruleRoots...)
+}
+
+// catalogOf returns the catalog path belongs in, with the path relative
+// to it; of nested roots the innermost wins.
+func (c *archiveCatalog) catalogOf(path string) (*catalog.Catalog, string, error) {
+	var (
+		best *catalog.Catalog
+		rel  string
+	)
+	for _, cat := range c.catalogs() {
+		r, err := cat.Rel(path)
+		if err == nil && (best == nil || len(cat.Root()) > len(best.Root())) {
+			best, rel = cat, r
+		}
+	}
+	if best == nil {
+		_, err := c.cat.Rel(path)
+		return nil, "", err
+	}
+	return best, rel, nil
+}
+
+// plan keeps the metadata planning read for src, for its entry.
+func (c *archiveCatalog) plan(src string, md files.FileMetadata) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.md[src] = md
+}
+
+// OnOperationComplete stages the entry of a newly archived file.
+func (c *archiveCatalog) OnOperationComplete(op files.Operation) {
+	c.mu.Lock()
+	md := c.md[op.Source()]
+	c.mu.Unlock()
+	cat, rel, err := c.catalogOf(op.Destination())
+	if err != nil {
+		c.mu.Lock()
+		c.failed = append(c.failed, op.Destination())
+		c.mu.Unlock()
+		return
+	}
+	e, err := catalogEntry(op.Destination(), rel, md, c.hashOf, op.Source(), c.algo)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err != nil {
+		c.failed = append(c.failed, op.Destination())
+		return
+	}
+	e.Source, e.Session, e.Archived, e.Tags = op.Source(), c.session, c.now().UTC(), c.tags
+	cat.Put(e)
+}
+
+// catalogEntry describes the file at path, reusing a known hash of src or
+// else hashing it with algo.
+func catalogEntry(path, rel string, md files.FileMetadata, hashOf func(string) (string, bool), src string, algo hashindex.Algo) (catalog.Entry, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return catalog.Entry{}, err
+	}
+	hash, ok := "", false
+	if hashOf != nil {
+		hash, ok = hashOf(src)
+	}
+	if !ok {
+		if hash, err = algo.HashFile(path); err != nil {
+			return catalog.Entry{}, err
+		}
+	}
+	e := catalog.Entry{Path: rel, Size: info.Size(), ModTime: info.ModTime(), Hash: hash, Camera: cameraOf(md)}
+	e.Captured, _, _ = pathtmpl.CaptureTime(md)
+	return e, nil
+}
+
+// cameraOf joins the Make and Model tags, without repeating the make when
+// the model already starts with it ("Canon" and "Canon EOS R5").
+func cameraOf(md files.FileMetadata) string {
+	brand, model := strings.TrimSpace(md.Tags["Make"]), strings.TrimSpace(md.Tags["Model"])
+	if brand == "" || strings.HasPrefix(strings.ToLower(model), strings.ToLower(brand)) {
+		return model
+	}
+	return strings.TrimSpace(brand + " " + model)
+}
+
+// close commits the run's entries to the catalog.
+func (c *archiveCatalog) close(cmd *cobra.Command) {
+	p := output.New(cmd.ErrOrStderr())
+	if len(c.failed) > 0 {
+		p.Warn("%d archived file(s) could not be catalogued; catalog rebuild adds them:\n  %s", len(c.failed), strings.Join(c.failed, "\n  "))
+	}
+	for _, cat := range c.catalogs() {
+		n := cat.Pending()
+		if err := cat.Commit(); err != nil {
+			p.Warn("%v", err)
+			continue
+		}
+		if n > 0 {
+			p.Println(output.Dim, "Catalogued %d file(s) in %s", n, catalog.Path(cat.Root()))
+		}
+	}
+}
+
+// catalogIndex lets --dedupe-against-archive look files up in the catalog
+// instead of indexing the archive. The catalog records new files itself.
+type catalogIndex struct {
+	c *archiveCatalog
+}
+
+func (ix catalogIndex) Lookup(hash string) (string, bool) {
+	ix.c.mu.Lock()
+	defer ix.c.mu.Unlock()
+	for _, cat := range ix.c.catalogs() {
+		if path, ok := cat.Lookup(hash); ok {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+func (catalogIndex) Add(path, hash string) error { return nil }
+func (catalogIndex) Save() error                 { return nil }
+
+func createCatalogCmd(d *deps.AppDeps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "catalog",
+		Short: "Maintain the archive's catalog of imported files",
+		Long: `The catalog, .gocamelpack-catalog.jsonl at the archive root, records every
+file copy and move archive with --catalog (or into an archive that has one):
+its checksum, capture date, camera, source, session and tags.
+
+It is an append-only JSON Lines log, not a database: each import appends its
+entries, and replaced or removed files leave their old lines behind. Reading
+it, as search, stats and every import into the archive do, replays the whole
+log, and search then scans every entry. "catalog compact" rewrites the log
+with only the current entries once it has grown large.`,
+	}
+
+	cmd.AddCommand(createCatalogRebuildCmd(d))
+	cmd.AddCommand(createCatalogCompactCmd(d))
+	return cmd
+}
+
+func createCatalogRebuildCmd(d *deps.AppDeps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rebuild [archive-root]",
+		Short: "Recreate the catalog by scanning the archive",
+		Long: `Walks archive-root and writes a new catalog of its files, for when the
+catalog was lost, damaged or has gone stale. New and changed files are hashed
+and their capture date and camera read again; files unchanged since the old
+catalog recorded them keep their entry, with the source, session and tags only
+an import knows, unless they were hashed with another --hash-algo. --rehash
+reads every file again. Files without an entry in
+the old catalog are dated as archived when they were last modified. Hidden
+files and folders are not catalogued.
+
+Entries are committed in batches of --batch-size to a scratch file that
+replaces the catalog at the end, so an interrupted rebuild leaves the old
+catalog in place.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			root, err := filepath.Abs(args[0])
+			if err != nil {
+				return err
+			}
+			if !d.Files.IsDirectory(root) {
+				return fmt.Errorf("%s is not a directory", root)
+			}
+			opts := catalogRebuild{}
+			opts.rehash, _ = cmd.Flags().GetBool("rehash")
+			cfg, err := loadConfig(cmd, d)
+			if err != nil {
+				return err
+			}
+			if opts.algo, err = hashAlgo(cmd, cfg.HashAlgo); err != nil {
+				return err
+			}
+			if opts.batchSize, _ = cmd.Flags().GetInt("batch-size"); opts.batchSize < 1 {
+				return fmt.Errorf("--batch-size must be at least 1")
+			}
+			opts.reporter = progress.NewNoOpReporter()
+			if showProgress, _ := cmd.Flags().GetBool("progress"); showProgress {
+				style, err := progressStyle(cmd, cfg)
+				if err != nil {
+					return err
+				}
+				opts.reporter = newProgressDisplay(cmd, style)
+			}
+			if noLock, _ := cmd.Flags().GetBool("no-lock"); !noLock {
+				lock, err := files.LockDir(root)
+				if err != nil {
+					return fmt.Errorf("%w; use --no-lock to bypass", err)
+				}
+				defer lock.Release()
+			}
+
+			old, err := catalog.Open(root)
+			if err != nil {
+				output.New(cmd.ErrOrStderr()).Warn("%v; rebuilding from the archive alone", err)
+				old = nil
+			}
+			fs := files.WithTags(d.Files, append(append([]string(nil), pathtmpl.DateTags...), catalogTags...))
+			n, read, err := opts.run(fs, root, old)
+			if err != nil {
+				return err
+			}
+			output.New(cmd.OutOrStdout()).Success("Catalogued %d file(s) in %s (%d read, %d unchanged).", n, catalog.Path(root), read, n-read)
+			return nil
+		},
+	}
+
+	cmd.Flags().Int("batch-size", 500, "Commit the new catalog every this many files")
+	cmd.Flags().Bool("rehash", false, "Hash and read every file, even those unchanged since the old catalog")
+	cmd.Flags().String("hash-algo", "", "Checksum algorithm: sha256 or xxhash64 (faster, not tamper-proof) (default from config, else sha256)")
+	cmd.Flags().BoolP("progress", "p", false, "Show progress bar while scanning")
+	cmd.Flags().Bool("no-lock", false, "Do not lock the archive while rebuilding")
+	return cmd
+}
+
+func createCatalogCompactCmd(d *deps.AppDeps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "compact [archive-root]",
+		Short: "Rewrite the catalog with only its current entries",
+		Long: `Rewrites the catalog of archive-root as a single batch of its current
+entries, dropping the lines of replaced and removed files and of imports cut
+short. The archive itself is not read. The new catalog is written to a
+scratch file that replaces the old one at the end, so an interrupted compact
+leaves the old catalog in place.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			root, err := filepath.Abs(args[0])
+			if err != nil {
+				return err
+			}
+			if !catalog.Exists(root) {
+				return fmt.Errorf("%s has no catalog", root)
+			}
+			if noLock, _ := cmd.Flags().GetBool("no-lock"); !noLock {
+				lock, err := files.LockDir(root)
+				if err != nil {
+					return fmt.Errorf("%w; use --no-lock to bypass", err)
+				}
+				defer lock.Release()
+			}
+
+			before, err := os.Stat(catalog.Path(root))
+			if err != nil {
+				return err
+			}
+			cat, err := catalog.Compact(root)
+			if err != nil {
+				return err
+			}
+			after, err := os.Stat(catalog.Path(root))
+			if err != nil {
+				return err
+			}
+			output.New(cmd.OutOrStdout()).Success("Compacted %s: %d file(s) catalogued, %d to %d bytes.", catalog.Path(root), cat.Len(), before.Size(), after.Size())
+			return nil
+		},
+	}
+
+	cmd.Flags().Bool("no-lock", false, "Do not lock the archive while compacting")
+	return cmd
+}
+
+// catalogRebuild is how catalog rebuild scans an archive.
+type catalogRebuild struct {
+	batchSize int
+	rehash    bool
+	algo      hashindex.Algo
+	reporter  progress.ProgressReporter
+}
+
+// run writes a new catalog of root, reusing the entries of old (which may
+// be nil) for unchanged files. It returns how many files were catalogued
+// and how many of them had to be read.
+func (r catalogRebuild) run(fs files.FilesService, root string, old *catalog.Catalog) (n, read int, err error) {
+	paths, err := archiveFiles(root)
+	if err != nil {
+		return 0, 0, err
+	}
+	cat, err := catalog.Rebuild(root)
+	if err != nil {
+		return 0, 0, err
+	}
+	r.reporter.SetTotal(len(paths))
+	r.reporter.SetMessage("Cataloguing " + root)
+	defer r.reporter.Finish()
+
+	for start := 0; start < len(paths); start += r.batchSize {
+		batch := paths[start:min(start+r.batchSize, len(paths))]
+		prev := map[string]catalog.Entry{}
+		var stale []string
+		for _, path := range batch {
+			rel, err := cat.Rel(path)
+			if err != nil {
+				return n, read, err
+			}
+			e, known := catalog.Entry{}, false
+			if old != nil {
+				e, known = old.Get(rel)
+			}
+			if known {
+				prev[path] = e
+				if info, err := os.Stat(path); err == nil && !r.rehash && info.Size() == e.Size && info.ModTime().Equal(e.ModTime) && r.algo.Recorded(e.Hash) {
+					cat.Put(e)
+					n++
+					r.reporter.Increment()
+					continue
+				}
+			}
+			stale = append(stale, path)
+		}
+
+		mds := map[string]files.FileMetadata{}
+		if len(stale) > 0 {
+			for _, md := range fs.GetFileTags(stale) {
+				mds[md.Filepath] = md
+			}
+		}
+		for _, path := range stale {
+			rel, _ := cat.Rel(path)
+			e, err := catalogEntry(path, rel, mds[path], nil, "", r.algo)
+			if err != nil {
+				return n, read, fmt.Errorf("cataloguing %s: %w", path, err)
+			}
+			if p, ok := prev[path]; ok {
+				e.Source, e.Session, e.Archived, e.Tags = p.Source, p.Session, p.Archived, p.Tags
+			} else {
+				e.Archived = e.ModTime.UTC()
+			}
+			cat.Put(e)
+			n++
+			read++
+			r.reporter.Increment()
+		}
+		if err := cat.Commit(); err != nil {
+			return n, read, err
+		}
+	}
+	return n, read, cat.Replace()
+}
+
+// archiveFiles lists the regular files below root, leaving out hidden
+// files and folders such as the catalog and journals.
+func archiveFiles(root string) ([]string, error) {
+	var paths []string
+	err := filepath.WalkDir(root, func(path string, e fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(e.Name(), ".") && path != root {
+			if e.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if e.Type().IsRegular() {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	return paths, err
+}
@@ -0,0 +1,213 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/catalog"
+	"github.com/Tmunayyer/gocamelpack/config"
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/hashindex"
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+func TestCopyCmd_Catalog(t *testing.T) {
+	tempDir := testutil.TempDir(t)
+	srcDir := filepath.Join(tempDir, "src")
+	dstDir := filepath.Join(tempDir, "dst")
+	if err := os.MkdirAll(srcDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	src := filepath.Join(srcDir, "a.jpg")
+	if err := os.WriteFile(src, []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	md := map[string]files.FileMetadata{src: {Filepath: src, Tags: map[string]string{
+		"CreationDate": "2025:01:27 15:30:45-06:00", "Make": "Canon", "Model": "Canon EOS R5",
+	}}}
+
+	run := func(args ...string) string {
+		cmd := createCopyCmd(&deps.AppDeps{Files: createTestFilesService(md), Config: &config.Config{}})
+		cmd.SetArgs(args)
+		cmd.SetOut(&bytes.Buffer{})
+		var stderr bytes.Buffer
+		cmd.SetErr(&stderr)
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("copy %v failed: %v", args, err)
+		}
+		return stderr.String()
+	}
+
+	if got := run("--catalog", "--tag", "trip=Iceland2025", srcDir, dstDir); !strings.Contains(got, "Catalogued 1 file(s)") {
+		t.Errorf("expected the catalogued files to be reported, got %q", got)
+	}
+	cat, err := catalog.Open(dstDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, ok := cat.Get("2025/01/27/15_30.jpg")
+	hash, _ := hashindex.HashFile(src)
+	if !ok || e.Hash != hash || e.Camera != "Canon EOS R5" || e.Source != src || e.Tags["trip"] != "Iceland2025" || e.Captured.IsZero() {
+		t.Fatalf("entry = %+v, %v", e, ok)
+	}
+
+	// The archive now has a catalog, which dedupe uses instead of an index.
+	got := run("--dedupe-against-archive", srcDir, dstDir)
+	if !strings.Contains(got, "already in the archive") {
+		t.Errorf("expected the copy to be found in the catalog, got %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(dstDir, hashindex.FileName)); !os.IsNotExist(err) {
+		t.Errorf("expected no hash index next to the catalog, got %v", err)
+	}
+}
+
+func TestCopyCmd_CatalogRuleRoots(t *testing.T) {
+	tempDir := testutil.TempDir(t)
+	srcDir := filepath.Join(tempDir, "src")
+	dstDir := filepath.Join(tempDir, "dst")
+	bulk := filepath.Join(tempDir, "bulk")
+	if err := os.MkdirAll(srcDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a.jpg", "clip.mov"} {
+		if err := os.WriteFile(filepath.Join(srcDir, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfgPath := filepath.Join(tempDir, "config.json")
+	cfg := `{"rules": [{"name": "videos", "match": {"ext": ["mov"]}, "template": "video/{year}", "root": "` + bulk + `"}]}`
+	if err := os.WriteFile(cfgPath, []byte(cfg), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	root := newCLI(&deps.AppDeps{Files: createTestFilesService(nil), Streams: deps.Streams{Out: &out, Err: &out}})
+	root.SetArgs([]string{"--config", cfgPath, "copy", "--catalog", srcDir, dstDir})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("copy failed: %v\n%s", err, out.String())
+	}
+
+	for dir, rel := range map[string]string{dstDir: "2025/01/27/15_30.jpg", bulk: "video/2025.mov"} {
+		cat, err := catalog.Open(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := cat.Get(rel); !ok || cat.Len() != 1 {
+			t.Errorf("catalog of %s: entries %+v, want only %s", dir, cat.Entries(), rel)
+		}
+		// Every root was locked, and every lock released.
+		if _, err := os.Stat(filepath.Join(dir, files.LockName)); !os.IsNotExist(err) {
+			t.Errorf("lock of %s left behind: %v", dir, err)
+		}
+	}
+}
+
+func TestCatalogRebuildCmd(t *testing.T) {
+	root := testutil.TempDir(t)
+	kept := filepath.Join(root, "2025/01/kept.jpg")
+	found := filepath.Join(root, "2025/02/found.jpg")
+	for _, path := range []string{kept, found, filepath.Join(root, ".thumbnails/x.jpg")} {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(path), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old, _ := catalog.Open(root)
+	e, err := catalogEntry(kept, "2025/01/kept.jpg", files.FileMetadata{}, nil, "", hashindex.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.Session = "s1"
+	old.Put(e)
+	old.Put(catalog.Entry{Path: "gone.jpg", Hash: "h"})
+	if err := old.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	md := map[string]files.FileMetadata{found: {Filepath: found, Tags: map[string]string{"CreationDate": "2025:02:01 10:00:00", "Make": "FUJIFILM", "Model": "X-T5"}}}
+	var stdout bytes.Buffer
+	cli := newCLI(&deps.AppDeps{Files: createTestFilesService(md), Config: &config.Config{}, Streams: deps.Streams{Out: &stdout, Err: &bytes.Buffer{}}})
+	cli.SetArgs([]string{"catalog", "rebuild", "--batch-size", "1", root})
+	if err := cli.Execute(); err != nil {
+		t.Fatalf("catalog rebuild failed: %v", err)
+	}
+	if !strings.Contains(stdout.String(), "Catalogued 2 file(s)") || !strings.Contains(stdout.String(), "1 read, 1 unchanged") {
+		t.Errorf("unexpected output %q", stdout.String())
+	}
+
+	cat, err := catalog.Open(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cat.Len() != 2 {
+		t.Fatalf("entries = %v", cat.Entries())
+	}
+	if e, _ := cat.Get("2025/01/kept.jpg"); e.Session != "s1" {
+		t.Errorf("an unchanged file should keep its entry, got %+v", e)
+	}
+	if e, _ := cat.Get("2025/02/found.jpg"); e.Camera != "FUJIFILM X-T5" || e.Hash == "" || e.Captured.IsZero() || e.Archived.IsZero() {
+		t.Errorf("a new file should be read, got %+v", e)
+	}
+}
+
+func TestStatsCmd(t *testing.T) {
+	root := testutil.TempDir(t)
+	cat, _ := catalog.Open(root)
+	cat.Put(catalog.Entry{Path: "2025/a.jpg", Size: 2048, Hash: "a", Camera: "Canon EOS R5"})
+	if err := cat.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout bytes.Buffer
+	cli := newCLI(&deps.AppDeps{Streams: deps.Streams{Out: &stdout, Err: &bytes.Buffer{}}})
+	cli.SetArgs([]string{"stats", "--archive", root})
+	if err := cli.Execute(); err != nil {
+		t.Fatalf("stats failed: %v", err)
+	}
+	for _, want := range []string{"1 file(s), 2.0 KiB", "Canon EOS R5", catalog.Undated} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("expected %q in %q", want, stdout.String())
+		}
+	}
+
+	cli = newCLI(&deps.AppDeps{Streams: deps.Streams{Out: &bytes.Buffer{}, Err: &bytes.Buffer{}}})
+	cli.SetArgs([]string{"stats", "--archive", filepath.Join(root, "2025")})
+	if err := cli.Execute(); err == nil || !strings.Contains(err.Error(), "has no catalog") {
+		t.Errorf("expected a missing catalog error, got %v", err)
+	}
+}
+
+func TestCatalogCompactCmd(t *testing.T) {
+	root := testutil.TempDir(t)
+	cat, _ := catalog.Open(root)
+	for i := 0; i < 3; i++ {
+		cat.Put(catalog.Entry{Path: "a.jpg", Hash: fmt.Sprint("h", i)})
+		if err := cat.Commit(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var stdout bytes.Buffer
+	cli := newCLI(&deps.AppDeps{Files: createTestFilesService(nil), Config: &config.Config{}, Streams: deps.Streams{Out: &stdout, Err: &bytes.Buffer{}}})
+	cli.SetArgs([]string{"catalog", "compact", root})
+	if err := cli.Execute(); err != nil {
+		t.Fatalf("catalog compact failed: %v", err)
+	}
+	if !strings.Contains(stdout.String(), "1 file(s) catalogued") {
+		t.Errorf("unexpected output %q", stdout.String())
+	}
+	cat, err := catalog.Open(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e, _ := cat.Get("a.jpg"); e.Hash != "h2" || cat.Len() != 1 {
+		t.Errorf("entries = %v, want the last a.jpg", cat.Entries())
+	}
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/journal"
+	"github.com/Tmunayyer/gocamelpack/output"
+	"github.com/spf13/cobra"
+)
+
+// executeTransaction runs a planned and validated transaction, then the
+// hooks of its operations. With --chunk-size the operations are committed
+// in consecutive transactions of that size instead, each recorded in the
+// session's journal once committed. A failing chunk is rolled back; the
+// chunks before it stay committed. Rolled-back chunks are journaled too,
+// so rollback-status can verify them.
+func (o transferOptions) executeTransaction(fs files.FilesService, tx files.Transaction, dstRoot string, cmd *cobra.Command) error {
+	ops := tx.Operations()
+	j := o.journal(fs, dstRoot)
+	if o.chunkSize == 0 || len(ops) <= o.chunkSize {
+		if err := o.runTransaction(tx, cmd); err != nil {
+			o.recordRollback(j, 1, 1, ops, err, dstRoot, cmd)
+			return err
+		}
+		// Tagged runs are journaled even in one transaction.
+		if o.runTags != nil {
+			if err := j.Record(1, 1, journalOperations(tx.Completed())); err != nil {
+				return err
+			}
+			o.printJournaled(cmd, len(tx.Completed()), j)
+		}
+		for _, op := range tx.Completed() {
+			files.RunHooks(o.hooks, op)
+		}
+		return nil
+	}
+
+	var chunks []files.Transaction
+	for start := 0; start < len(ops); start += o.chunkSize {
+		chunk := fs.NewTransaction(o.overwrite)
+		for _, op := range ops[start:min(start+o.chunkSize, len(ops))] {
+			if err := chunk.Add(op); err != nil {
+				return err
+			}
+		}
+		chunks = append(chunks, chunk)
+	}
+
+	for i, chunk := range chunks {
+		if err := o.runTransaction(chunk, cmd); err != nil {
+			o.recordRollback(j, i+1, len(chunks), chunk.Operations(), err, dstRoot, cmd)
+			if i == 0 {
+				return err
+			}
+			return fmt.Errorf("chunk %d of %d was rolled back; chunks 1-%d stay committed (session %s, journal %s): %w", i+1, len(chunks), i, o.session, j.Path(), err)
+		}
+		completed := chunk.Completed()
+		if err := j.Record(i+1, len(chunks), journalOperations(completed)); err != nil {
+			return err
+		}
+		for _, op := range completed {
+			files.RunHooks(o.hooks, op)
+		}
+	}
+	output.New(cmd.ErrOrStderr()).Println(output.Dim, "Committed %d chunk(s) as session %s (journal %s)", len(chunks), o.session, j.Path())
+	return nil
+}
+
+// recordRollback journals the planned operations of a chunk whose
+// execution failed and was rolled back, and points to rollback-status.
+// Failures before or after execution roll nothing back and are ignored.
+func (o transferOptions) recordRollback(j *journal.Journal, chunk, chunks int, ops []files.Operation, err error, dstRoot string, cmd *cobra.Command) {
+	var txErr *files.TransactionError
+	if !errors.As(err, &txErr) || txErr.Phase != "execution" {
+		return
+	}
+	p := output.New(cmd.ErrOrStderr())
+	if err := j.RecordRollback(chunk, chunks, o.overwrite, journalOperations(ops)); err != nil {
+		p.Warn("the rollback could not be journaled: %v", err)
+		return
+	}
+	if o.simulate == nil {
+		p.Println(output.Dim, "Rolled back; verify with: gocamelpack rollback-status %s %s", o.session, dstRoot)
+	}
+}
+
+// runTransaction executes tx, with progress if requested.
+func (o transferOptions) runTransaction(tx files.Transaction, cmd *cobra.Command) error {
+	if o.reportsProgress() {
+		return tx.ExecuteWithProgress(o.reporter(cmd))
+	}
+	return tx.Execute()
+}
+
+func journalOperations(ops []files.Operation) []journal.Operation {
+	out := make([]journal.Operation, len(ops))
+	for i, op := range ops {
+		out[i] = journal.Operation{Type: op.Type().String(), Source: op.Source(), Destination: op.Destination()}
+	}
+	return out
+}
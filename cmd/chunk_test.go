@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/config"
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/journal"
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+// failingCopies fails the copy of one source.
+type failingCopies struct {
+	files.FilesService
+	fail string
+}
+
+func (f *failingCopies) Copy(src, dst string) error {
+	if src == f.fail {
+		return errors.New("disk on fire")
+	}
+	return f.FilesService.Copy(src, dst)
+}
+
+func (f *failingCopies) NewTransaction(overwrite bool) files.Transaction {
+	return files.NewTransaction(f, overwrite)
+}
+
+func TestCopyCmd_ChunkSize(t *testing.T) {
+	tmp := testutil.TempDir(t)
+	card := filepath.Join(tmp, "card")
+	metadata := writeCard(t, card, 5)
+	run := func(fs files.FilesService, args ...string) (string, error) {
+		var out bytes.Buffer
+		root := newCLI(&deps.AppDeps{Files: fs, Config: &config.Config{}, Streams: deps.Streams{Out: &out, Err: &out}})
+		root.SetArgs(args)
+		err := root.Execute()
+		return out.String(), err
+	}
+
+	dst := filepath.Join(tmp, "archive")
+	out, err := run(createTestFilesService(metadata), "copy", "--atomic", "--chunk-size", "2", card, dst)
+	if err != nil {
+		t.Fatalf("copy --chunk-size: %v\n%s", err, out)
+	}
+	journals, _ := filepath.Glob(filepath.Join(dst, journal.Dir, "*.jsonl"))
+	if len(journals) != 1 {
+		t.Fatalf("want one session journal, found %v", journals)
+	}
+	entries, err := journal.Read(journals[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 || len(entries[0].Operations) != 2 || len(entries[2].Operations) != 1 || entries[2].Chunks != 3 {
+		t.Errorf("unexpected journal %+v", entries)
+	}
+
+	// The third file fails: the first chunk stays, the second is undone.
+	failing := &failingCopies{FilesService: createTestFilesService(metadata), fail: filepath.Join(card, "IMG_0002.jpg")}
+	dst = filepath.Join(tmp, "partial")
+	out, err = run(failing, "copy", "--atomic", "--chunk-size", "2", card, dst)
+	if err == nil || !contains(err.Error(), "chunk 2 of 3 was rolled back") {
+		t.Fatalf("expected chunk 2 to fail, got %v\n%s", err, out)
+	}
+	for name, want := range map[string]bool{"15_00.jpg": true, "15_01.jpg": true, "15_02.jpg": false, "15_03.jpg": false} {
+		if _, err := os.Stat(filepath.Join(dst, "2025/01/27", name)); (err == nil) != want {
+			t.Errorf("%s exists = %v, want %v", name, err == nil, want)
+		}
+	}
+
+	if _, err := run(createTestFilesService(metadata), "copy", "--chunk-size", "2", card, filepath.Join(tmp, "x")); err == nil {
+		t.Error("expected --chunk-size without --atomic to be rejected")
+	}
+}
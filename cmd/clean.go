@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Tmunayyer/gocamelpack/catalog"
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/journal"
+	"github.com/spf13/cobra"
+)
+
+// defaultJournalRetention is how long clean keeps completed journals.
+const defaultJournalRetention = 30 * 24 * time.Hour
+
+// stagingPrefixes name the scratch files and folders runs create in a
+// destination root and remove when they finish; any found while no run
+// holds the lock were left by one that crashed.
+var stagingPrefixes = []string{".gocamelpack-index-", ".gocamelpack-case-probe-", catalog.RebuildFileName}
+
+// benchPrefix names the scratch folders of bench, which does not lock the
+// folder it measures; only those untouched for benchStaleAfter are taken
+// to be left by a crash.
+const (
+	benchPrefix     = ".gocamelpack-bench-"
+	benchStaleAfter = 24 * time.Hour
+)
+
+// cleanItem is something clean removes.
+type cleanItem struct {
+	Kind string `json:"kind"` // "staging", "journal" or "lock"
+	Path string `json:"path"`
+}
+
+func createCleanCmd(d *deps.AppDeps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "clean [archive-root]",
+		Short: "Remove what crashed or finished runs left in an archive",
+		Long: `Removes from archive-root the scratch folders and files of runs that did not
+finish (bench folders untouched for a day, half-written index files and
+catalog rebuilds, case probes), the journals of completed sessions older than
+--journal-retention, and a lock left behind by a process that no longer
+exists. Journals of
+sessions with rolled-back chunks or failed files are kept for rollback-status
+and retry, and those of sessions run with --tag as their record; quarantined
+files and backups of overwritten files are never touched. A live run's lock
+makes clean refuse, since the scratch files are its own. Use --dry-run to only
+list what would go.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			root, err := filepath.Abs(args[0])
+			if err != nil {
+				return err
+			}
+			if info, err := os.Stat(root); err != nil || !info.IsDir() {
+				return files.Errorf(files.ErrSourceMissing, "%s is not a directory", root)
+			}
+			cfg, err := loadConfig(cmd, d)
+			if err != nil {
+				return err
+			}
+			if err := cfg.Destinations.CheckRoot(root); err != nil {
+				return err
+			}
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			retention, _ := cmd.Flags().GetDuration("journal-retention")
+
+			var items []cleanItem
+			stale, err := files.StaleLock(root)
+			if err != nil {
+				return err
+			}
+			if stale {
+				items = append(items, cleanItem{Kind: "lock", Path: filepath.Join(root, files.LockName)})
+			}
+			if dryRun {
+				if _, err := os.Stat(filepath.Join(root, files.LockName)); err == nil && !stale {
+					return files.Errorf(files.ErrLocked, "%s is locked by a running transfer; its scratch files are in use", root)
+				}
+			} else {
+				// Holding the lock keeps runs out while we clean, and takes
+				// over a stale one.
+				lock, err := files.LockDir(root)
+				if err != nil {
+					return err
+				}
+				defer lock.Release()
+			}
+
+			found, err := cleanCandidates(root, time.Now(), retention)
+			if err != nil {
+				return err
+			}
+			items = append(items, found...)
+
+			var failed error
+			if !dryRun {
+				for _, it := range items {
+					if it.Kind == "lock" {
+						continue // taken over by LockDir
+					}
+					if err := os.RemoveAll(it.Path); err != nil && failed == nil {
+						failed = fmt.Errorf("removing %s: %w", it.Path, err)
+					}
+				}
+			}
+
+			out := cmd.OutOrStdout()
+			if outputFormat(cmd) == "json" {
+				if items == nil {
+					items = []cleanItem{}
+				}
+				if err := json.NewEncoder(out).Encode(map[string]any{"dry_run": dryRun, "removed": items}); err != nil {
+					return err
+				}
+				return failed
+			}
+			verb := "Removed"
+			if dryRun {
+				verb = "Would remove"
+			}
+			for _, it := range items {
+				fmt.Fprintf(out, "%s %s %s\n", verb, it.Kind, it.Path)
+			}
+			if len(items) == 0 {
+				fmt.Fprintln(out, "Nothing to clean.")
+			}
+			return failed
+		},
+	}
+	cmd.Flags().Bool("dry-run", false, "List what would be removed without removing it")
+	cmd.Flags().Duration("journal-retention", defaultJournalRetention, "Keep journals of completed sessions younger than this")
+	return cmd
+}
+
+// cleanCandidates lists the scratch entries at the top of root and the
+// completed journals last written longer than retention before now.
+func cleanCandidates(root string, now time.Time, retention time.Duration) ([]cleanItem, error) {
+	cutoff := now.Add(-retention)
+	var items []cleanItem
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), benchPrefix) {
+			if info, err := e.Info(); err == nil && info.ModTime().Before(now.Add(-benchStaleAfter)) {
+				items = append(items, cleanItem{Kind: "staging", Path: filepath.Join(root, e.Name())})
+			}
+			continue
+		}
+		for _, prefix := range stagingPrefixes {
+			if strings.HasPrefix(e.Name(), prefix) {
+				items = append(items, cleanItem{Kind: "staging", Path: filepath.Join(root, e.Name())})
+				break
+			}
+		}
+	}
+
+	dir := filepath.Join(root, journal.Dir)
+	journals, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, e := range journals {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".jsonl" {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		entries, err := journal.Read(path)
+		if err != nil {
+			// A journal that cannot be read is left for a human to look at.
+			continue
+		}
+		if journal.Complete(entries) && !journal.Tagged(entries) && entries[len(entries)-1].Time.Before(cutoff) {
+			items = append(items, cleanItem{Kind: "journal", Path: path})
+		}
+	}
+	return items, nil
+}
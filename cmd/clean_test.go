@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Tmunayyer/gocamelpack/config"
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/journal"
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+func TestCleanCmd(t *testing.T) {
+	root := filepath.Join(testutil.TempDir(t), "clean")
+	write := func(rel string, data []byte) string {
+		path := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	writeJournal := func(session string, age time.Duration, entries ...journal.Entry) string {
+		var data []byte
+		for _, e := range entries {
+			e.Session, e.Time = session, time.Now().Add(-age)
+			line, _ := json.Marshal(e)
+			data = append(append(data, line...), '\n')
+		}
+		return write(filepath.Join(journal.Dir, session+".jsonl"), data)
+	}
+
+	host, _ := os.Hostname()
+	// A PID far above any real one stands in for an exited process.
+	write(files.LockName, []byte(`{"pid": 1073741824, "host": "`+host+`"}`))
+	bench := write(filepath.Join(".gocamelpack-bench-123", "sample.jpg"), []byte("x"))
+	past := time.Now().Add(-48 * time.Hour)
+	if err := os.Chtimes(filepath.Dir(bench), past, past); err != nil {
+		t.Fatal(err)
+	}
+	// A recent bench folder may belong to a bench still running.
+	liveBench := write(filepath.Join(".gocamelpack-bench-456", "sample.jpg"), []byte("x"))
+	photo := write(filepath.Join("2024", "01", "photo.jpg"), []byte("x"))
+	old := writeJournal("old", 60*24*time.Hour, journal.Entry{Chunk: 1, Chunks: 2}, journal.Entry{Chunk: 2, Chunks: 2})
+	recent := writeJournal("recent", time.Hour, journal.Entry{Chunk: 1, Chunks: 1})
+	unfinished := writeJournal("unfinished", 60*24*time.Hour, journal.Entry{Chunk: 1, Chunks: 2})
+	rolledBack := writeJournal("rolled-back", 60*24*time.Hour, journal.Entry{Chunk: 1, Chunks: 1, RolledBack: true})
+
+	run := func(args ...string) string {
+		var out bytes.Buffer
+		cli := newCLI(&deps.AppDeps{Files: createTestFilesService(nil), Config: &config.Config{}, Streams: deps.Streams{Out: &out, Err: &out}})
+		cli.SetArgs(args)
+		if err := cli.Execute(); err != nil {
+			t.Fatalf("%v: %v\n%s", args, err, out.String())
+		}
+		return out.String()
+	}
+
+	out := run("clean", "--dry-run", root)
+	for _, want := range []string{"Would remove lock", "Would remove staging", "Would remove journal " + old} {
+		if !contains(out, want) {
+			t.Errorf("dry run output lacks %q:\n%s", want, out)
+		}
+	}
+	if _, err := os.Stat(bench); err != nil {
+		t.Fatalf("dry run removed a scratch file: %v", err)
+	}
+
+	run("clean", root)
+	for _, gone := range []string{filepath.Dir(bench), old, filepath.Join(root, files.LockName)} {
+		if _, err := os.Stat(gone); !os.IsNotExist(err) {
+			t.Errorf("%s was not removed: %v", gone, err)
+		}
+	}
+	if _, err := os.Stat(liveBench); err != nil {
+		t.Errorf("a recent bench folder was removed: %v", err)
+	}
+	for _, kept := range []string{photo, recent, unfinished, rolledBack} {
+		if _, err := os.Stat(kept); err != nil {
+			t.Errorf("%s was removed: %v", kept, err)
+		}
+	}
+
+	if out := run("clean", root); !contains(out, "Nothing to clean.") {
+		t.Errorf("second clean found more:\n%s", out)
+	}
+}
+
+func TestCleanCmd_RefusesLiveLock(t *testing.T) {
+	root := filepath.Join(testutil.TempDir(t), "clean-locked")
+	lock, err := files.LockDir(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lock.Release()
+
+	for _, args := range [][]string{{"clean", root}, {"clean", "--dry-run", root}} {
+		var out bytes.Buffer
+		cli := newCLI(&deps.AppDeps{Files: createTestFilesService(nil), Config: &config.Config{}, Streams: deps.Streams{Out: &out, Err: &out}})
+		cli.SetArgs(args)
+		if err := cli.Execute(); err == nil {
+			t.Errorf("%v: expected a refusal while a run holds the lock", args)
+		}
+	}
+}
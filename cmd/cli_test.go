@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/journal"
+	"github.com/Tmunayyer/gocamelpack/testutil"
+	"github.com/Tmunayyer/gocamelpack/vfs"
+)
+
+func TestNewCLI_UsesConfiguredStreams(t *testing.T) {
+	tempDir := testutil.TempDir(t)
+	testFile := filepath.Join(tempDir, "test.jpg")
+	if err := os.WriteFile(testFile, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	d := &deps.AppDeps{
+		Files:   createTestFilesService(nil),
+		Streams: deps.Streams{Out: &stdout, Err: &stderr},
+	}
+
+	root := newCLI(d)
+	root.SetArgs([]string{"read", testFile})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !strings.Contains(stdout.String(), `"CreationDate"`) {
+		t.Errorf("expected read output on the configured stdout, got %q", stdout.String())
+	}
+}
+
+func TestNewCLI_ErrorsNotPrintedByCobra(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	d := &deps.AppDeps{
+		Files:   createTestFilesService(nil),
+		Streams: deps.Streams{Out: &stdout, Err: &stderr},
+	}
+
+	root := newCLI(d)
+	root.SetArgs([]string{"read", filepath.Join(testutil.TempDir(t), "missing.jpg")})
+	err := root.Execute()
+	if err == nil || err.Error() != "src is not a file" {
+		t.Fatalf("expected 'src is not a file', got %v", err)
+	}
+
+	if !strings.Contains(stdout.String()+stderr.String(), "Usage:") {
+		t.Errorf("expected usage on the configured streams, got %q / %q", stdout.String(), stderr.String())
+	}
+	if strings.Contains(stdout.String()+stderr.String(), "src is not a file") {
+		t.Errorf("errors are reported once by Execute, not by cobra, got %q / %q", stdout.String(), stderr.String())
+	}
+}
+
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time { return time.Time(c) }
+
+func TestNewCLI_UsesInjectedClockAndFS(t *testing.T) {
+	tempDir := testutil.TempDir(t)
+	src := filepath.Join(tempDir, "src", "photo.jpg")
+	dstDir := filepath.Join(tempDir, "dst")
+	if err := os.MkdirAll(filepath.Dir(src), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(src, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	mem := vfs.NewMem(vfs.OS)
+	d := &deps.AppDeps{
+		Files:   createTestFilesService(nil),
+		Streams: deps.Streams{Out: &out, Err: &out},
+		Clock:   fixedClock(time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)),
+		FS:      mem,
+	}
+	root := newCLI(d)
+	root.SetArgs([]string{"copy", "--no-lock", "--tag", "trip=test", src, dstDir})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("copy: %v\n%s", err, out.String())
+	}
+
+	dst := filepath.Join(dstDir, "2025", "01", "27", "15_30.jpg")
+	if _, err := mem.Stat(dst); err != nil {
+		t.Errorf("expected the copy in the injected file system: %v", err)
+	}
+	if _, err := os.Stat(dstDir); !os.IsNotExist(err) {
+		t.Errorf("nothing should be written to disk, stat err = %v", err)
+	}
+	entries, err := mem.ReadDir(filepath.Join(dstDir, journal.Dir))
+	if err != nil || len(entries) != 1 || !strings.HasPrefix(entries[0].Name(), "20250301-090000-") {
+		t.Fatalf("expected a journal named after the injected clock, got %v, %v", entries, err)
+	}
+}
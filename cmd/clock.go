@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/Tmunayyer/gocamelpack/config"
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/output"
+	"github.com/Tmunayyer/gocamelpack/pathtmpl"
+	"github.com/spf13/cobra"
+)
+
+// clockOffsets are the config's per-camera clock corrections, keyed as
+// cameraOf names cameras.
+type clockOffsets map[string]time.Duration
+
+// parseClockOffsets reads the config's clock_offsets.
+func parseClockOffsets(raw map[string]string) (clockOffsets, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	offsets := clockOffsets{}
+	for camera, s := range raw {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return nil, fmt.Errorf("clock_offsets: %s: %w", camera, err)
+		}
+		offsets[camera] = d
+	}
+	return offsets, nil
+}
+
+// shift returns md with the capture dates moved by its camera's offset.
+func (c clockOffsets) shift(md files.FileMetadata) files.FileMetadata {
+	d, ok := c[cameraOf(md)]
+	if !ok || d == 0 {
+		return md
+	}
+	tags := make(map[string]string, len(md.Tags))
+	for k, v := range md.Tags {
+		tags[k] = v
+	}
+	for _, tag := range pathtmpl.DateTags {
+		if shifted, err := files.ShiftExifDate(tags[tag], d); err == nil {
+			tags[tag] = shifted
+		}
+	}
+	return files.FileMetadata{Filepath: md.Filepath, Tags: tags}
+}
+
+// wallClock is t as read off the camera's clock, whatever its zone.
+func wallClock(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+}
+
+func createSyncClockCmd(d *deps.AppDeps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sync-clock --reference <file> --target <file>",
+		Short: "Measure how far one camera's clock is off from another's",
+		Long: `Takes two shots of the same moment, one from the camera whose clock is
+right (--reference) and one from the camera to correct (--target), e.g. both
+photographing a phone's clock, and saves the difference between their capture
+times as the target camera's offset in the config's clock_offsets. Copy and
+move then add the offset to that camera's capture dates before placing its
+files. A reference camera with an offset of its own is corrected first.
+Cameras are told apart by their Make and Model tags.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			refPath, _ := cmd.Flags().GetString("reference")
+			targetPath, _ := cmd.Flags().GetString("target")
+			if refPath == "" || targetPath == "" {
+				return fmt.Errorf("--reference and --target are required")
+			}
+			cfg, err := loadConfig(cmd, d)
+			if err != nil {
+				return err
+			}
+			offsets, err := parseClockOffsets(cfg.ClockOffsets)
+			if err != nil {
+				return err
+			}
+
+			shot := func(path string) (files.FileMetadata, time.Time, error) {
+				if !d.Files.IsFile(path) {
+					return files.FileMetadata{}, time.Time{}, files.Errorf(files.ErrSourceMissing, "%s is not a file", path)
+				}
+				tags := d.Files.GetFileTags([]string{path})
+				if len(tags) == 0 {
+					return files.FileMetadata{}, time.Time{}, fmt.Errorf("no metadata for %s", path)
+				}
+				t, _, ok := pathtmpl.CaptureTime(tags[0])
+				if !ok {
+					return files.FileMetadata{}, time.Time{}, files.Errorf(files.ErrNoDate, "%s has no capture date", path)
+				}
+				return tags[0], wallClock(t), nil
+			}
+			ref, refTime, err := shot(refPath)
+			if err != nil {
+				return err
+			}
+			target, targetTime, err := shot(targetPath)
+			if err != nil {
+				return err
+			}
+			camera := cameraOf(target)
+			switch {
+			case camera == "":
+				return fmt.Errorf("%s has no Make or Model tag to tell its camera by", targetPath)
+			case camera == cameraOf(ref):
+				return fmt.Errorf("both shots are from %s; the reference must come from another camera", camera)
+			}
+			offset := refTime.Add(offsets[cameraOf(ref)]).Sub(targetTime)
+
+			out := output.New(cmd.OutOrStdout())
+			switch {
+			case offset > 0:
+				out.Println(output.Plain, "%s runs %s behind the reference.", camera, offset)
+			case offset < 0:
+				out.Println(output.Plain, "%s runs %s ahead of the reference.", camera, -offset)
+			default:
+				out.Println(output.Plain, "%s agrees with the reference.", camera)
+			}
+			if dryRun, _ := cmd.Flags().GetBool("dry-run"); dryRun {
+				return nil
+			}
+
+			path, _ := cmd.Flags().GetString("config")
+			if path == "" {
+				if path, err = config.DefaultPath(); err != nil {
+					return err
+				}
+			}
+			saved := map[string]string{}
+			for k, v := range cfg.ClockOffsets {
+				saved[k] = v
+			}
+			if offset == 0 {
+				delete(saved, camera)
+			} else {
+				saved[camera] = offset.String()
+			}
+			if err := config.Set(path, "clock_offsets", saved); err != nil {
+				return err
+			}
+			cfg.ClockOffsets = saved
+			abs, _ := filepath.Abs(path)
+			out.Success("Saved the offset of %s in %s.", camera, abs)
+			return nil
+		},
+	}
+	cmd.Flags().String("reference", "", "Shot from the camera whose clock is right")
+	cmd.Flags().String("target", "", "Shot of the same moment from the camera to correct")
+	cmd.Flags().Bool("dry-run", false, "Print the offset without saving it")
+	return cmd
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/config"
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+func TestSyncClockCmd(t *testing.T) {
+	tmp := filepath.Join(testutil.TempDir(t), "clock")
+	if err := os.MkdirAll(tmp, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	ref, target := filepath.Join(tmp, "ref.jpg"), filepath.Join(tmp, "target.jpg")
+	for _, path := range []string{ref, target} {
+		if err := os.WriteFile(path, []byte(path), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	md := map[string]files.FileMetadata{
+		ref:    {Filepath: ref, Tags: map[string]string{"CreationDate": "2025:01:27 15:30:45-06:00", "Make": "SONY", "Model": "ILCE-7M3"}},
+		target: {Filepath: target, Tags: map[string]string{"CreationDate": "2025:01:27 14:28:40", "Make": "Canon", "Model": "Canon EOS R5"}},
+	}
+	cfgPath := filepath.Join(tmp, "config.json")
+	if err := os.WriteFile(cfgPath, []byte(`{"template": "{year}/{month}/{day}/{hour}_{minute}"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(args ...string) (string, error) {
+		var out bytes.Buffer
+		root := newCLI(&deps.AppDeps{Files: createTestFilesService(md), Streams: deps.Streams{Out: &out, Err: &out}})
+		root.SetArgs(append([]string{"sync-clock", "--config", cfgPath, "--reference", ref}, args...))
+		err := root.Execute()
+		return out.String(), err
+	}
+	out, err := run("--target", target)
+	if err != nil {
+		t.Fatalf("sync-clock: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "Canon EOS R5 runs 1h2m5s behind the reference.") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+	cfg, err := config.Load(cfgPath, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.ClockOffsets["Canon EOS R5"]; got != "1h2m5s" {
+		t.Errorf("saved offset = %q, want 1h2m5s", got)
+	}
+	if cfg.Template == "" {
+		t.Error("the rest of the config should be kept")
+	}
+
+	if _, err := run("--target", ref); err == nil || !strings.Contains(err.Error(), "another camera") {
+		t.Errorf("expected an error for two shots from one camera, got %v", err)
+	}
+}
+
+func TestCopyCmd_ClockOffsets(t *testing.T) {
+	tmp := filepath.Join(testutil.TempDir(t), "offsets")
+	card, dst := filepath.Join(tmp, "card"), filepath.Join(tmp, "dst")
+	if err := os.MkdirAll(card, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	canon, sony := filepath.Join(card, "canon.jpg"), filepath.Join(card, "sony.jpg")
+	md := map[string]files.FileMetadata{
+		canon: {Filepath: canon, Tags: map[string]string{"CreationDate": "2025:01:27 23:30:00", "Make": "Canon", "Model": "Canon EOS R5"}},
+		sony:  {Filepath: sony, Tags: map[string]string{"CreationDate": "2025:01:27 23:30:00", "Make": "SONY", "Model": "ILCE-7M3"}},
+	}
+	for path := range md {
+		if err := os.WriteFile(path, []byte(path), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var out bytes.Buffer
+	cfg := config.Config{ClockOffsets: map[string]string{"Canon EOS R5": "1h2m5s"}}
+	root := newCLI(&deps.AppDeps{Files: createTestFilesService(md), Config: &cfg, Streams: deps.Streams{Out: &out, Err: &out}})
+	root.SetArgs([]string{"copy", "--dry-run", card, dst})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("copy: %v\n%s", err, out.String())
+	}
+	for _, want := range []string{filepath.Join(dst, "2025", "01", "28", "00_32.jpg"), filepath.Join(dst, "2025", "01", "27", "23_30.jpg")} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected %q in:\n%s", want, out.String())
+		}
+	}
+}
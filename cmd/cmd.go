@@ -5,16 +5,15 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/Tmunayyer/gocamelpack/deps"
 	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/output"
 	"github.com/Tmunayyer/gocamelpack/progress"
 	"github.com/spf13/cobra"
 )
 
-// dirPerm represents rwxr-xr-x — used when creating target directories during moves.
-const dirPerm = 0o755
-
 func createRootCmd(dependencies *deps.AppDeps) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:     "gocamelpack",
@@ -24,56 +23,113 @@ func createRootCmd(dependencies *deps.AppDeps) *cobra.Command {
 
 Version: %s`, Version()),
 		Run: func(cmd *cobra.Command, args []string) {
-			fmt.Println("Hello from Cobra!")
+			fmt.Fprintln(cmd.OutOrStdout(), "Hello from Cobra!")
 		},
 	}
 	
 	// Add custom version template that shows detailed build info
 	cmd.SetVersionTemplate(BuildInfo() + "\n")
+
+	cmd.PersistentFlags().String("output", "text", "Output format: text, json, or for copy and move ndjson (a JSON line per file event)")
+	cmd.PersistentFlags().String("config", "", "Config file (default $XDG_CONFIG_HOME/gocamelpack/config.json)")
+	cmd.PersistentFlags().String("progress-style", "", "How --progress is drawn: bar, or plain for screen readers and dumb terminals (default from config, else bar)")
+	cmd.PersistentFlags().String("pprof", "", "Serve net/http/pprof on this address, e.g. localhost:6060")
+	cmd.PersistentFlags().String("trace", "", "Write a runtime trace of the run to this file")
+	cmd.PersistentFlags().MarkHidden("pprof")
+	cmd.PersistentFlags().MarkHidden("trace")
+	cmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		if err := validateOutputFormat(cmd, outputFormat(cmd)); err != nil {
+			return err
+		}
+		return startProfiling(cmd)
+	}
 	
 	return cmd
 }
 
 func createReadCmd(d *deps.AppDeps) *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "read [source]",
 		Short: "This will read a specified file and print the metadata.",
-		Long:  "Source must be a filepath.",
-		Args:  cobra.ExactArgs(1),
+		Long: `Source must be a filepath.
+
+Use --groups to list tags from specific exiftool groups (EXIF, XMP, QuickTime,
+Composite, …) with group-qualified names, --raw to show machine-readable
+values instead of human-readable conversions, and --tags to extract only the
+named tags, which is much faster on large files.`,
+		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			src := args[0]
 			if !d.Files.IsFile(src) {
-				return fmt.Errorf("src is not a file")
+				return files.Errorf(files.ErrSourceMissing, "src is not a file")
 			}
 
-			metadata := d.Files.GetFileTags([]string{src})
+			groups, _ := cmd.Flags().GetStringSlice("groups")
+			raw, _ := cmd.Flags().GetBool("raw")
+			opts := files.ReadOptions{Groups: groups, Raw: raw}
+			if tags, _ := cmd.Flags().GetStringSlice("tags"); len(tags) > 0 {
+				opts.Tags = tags
+			}
+
+			var metadata []files.FileMetadata
+			if opts.IsDefault() {
+				metadata = d.Files.GetFileTags([]string{src})
+			} else {
+				reader, ok := d.Files.(files.TagReader)
+				if !ok {
+					return fmt.Errorf("--groups, --raw and --tags are not supported by this files service")
+				}
+				var err error
+				metadata, err = reader.ReadTags([]string{src}, opts)
+				if err != nil {
+					return err
+				}
+			}
 
 			jsonBytes, err := json.MarshalIndent(metadata, "", "  ")
 			if err != nil {
 				return fmt.Errorf("failed to marshal metadata: %w", err)
 			}
-			fmt.Println(string(jsonBytes))
+			fmt.Fprintln(cmd.OutOrStdout(), string(jsonBytes))
 
 			return nil
 		},
 	}
+
+	cmd.Flags().StringSlice("groups", nil, "Only show tags from these exiftool groups, e.g. EXIF,XMP,QuickTime")
+	cmd.Flags().Bool("raw", false, "Show raw numeric values instead of human-readable conversions")
+	cmd.Flags().StringSlice("tags", nil, "Only extract these tags, e.g. CreationDate,Model")
+
+	return cmd
 }
 
 func createCopyCmd(d *deps.AppDeps) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "copy [source] [destination]",
 		Short: "Copy files from source to destination",
-		Long:  "Source may be a file or directory. Destination is the root directory under which files will be placed according to their metadata.",
-		Args:  cobra.ExactArgs(2),
-		RunE: func(cmd *cobra.Command, args []string) error {
+		Long:  "Source may be a file or directory, or be left out for the files listed with --from-file. Destination is the root directory under which files will be placed according to their metadata.",
+		Args:  transferArgs,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
 			srcInput := args[0]
-			dstRoot := args[1] // base directory passed to DestinationFromMetadata
-			// flags
-			// jobs, _ := cmd.Flags().GetUint("jobs") // not yet used
-			dryRun, _ := cmd.Flags().GetBool("dry-run")
-			overwrite, _ := cmd.Flags().GetBool("overwrite")
-			atomic, _ := cmd.Flags().GetBool("atomic")
-			showProgress, _ := cmd.Flags().GetBool("progress")
+			dstRoot := args[len(args)-1] // base directory passed to DestinationFromMetadata
+			opts, err := transferOptionsFromFlags(cmd, d, dstRoot)
+			if err != nil {
+				return err
+			}
+			// post-run goes last, after the report it may read; the
+			// report is written once thumbnails are done.
+			defer func() { opts.scripts.postRun(cmd, err, opts.events.count(eventCopied, eventMoved)) }()
+			defer func() { opts.writeReport(cmd, err) }()
+			defer opts.close(cmd)
+			if err := opts.mirrorFrom(d.Files, srcInput); err != nil {
+				return err
+			}
+			if err := opts.preRun(srcInput); err != nil {
+				return err
+			}
+			if opts.stream {
+				return opts.destDirs.reveal(cmd, performStreamingTransfer(opts.files(d.Files), srcInput, dstRoot, opts, cmd, files.OperationCopy))
+			}
 
 			// resolve source to an absolute path so tests expecting "abs/..." match
 			src, err := filepath.Abs(srcInput)
@@ -82,23 +138,27 @@ func createCopyCmd(d *deps.AppDeps) *cobra.Command {
 			}
 
 			var sources []string
-			if showProgress {
+			collectStart := time.Now()
+			if opts.fromFile != "" {
+				sources, err = opts.walk.readSourceList(d.Files, opts.fromFile)
+			} else if opts.showProgress {
 				// Show collection progress 
-				collectionReporter := progress.NewSimpleProgressBar(cmd.ErrOrStderr())
-				sources, err = collectSourcesWithProgress(d.Files, src, collectionReporter)
+				collectionReporter := newProgressDisplay(cmd, opts.progressStyle)
+				sources, err = opts.walk.collect(d.Files, src, collectionReporter)
 			} else {
-				sources, err = collectSources(d.Files, src)
+				sources, err = opts.walk.collect(d.Files, src, progress.NewNoOpReporter())
 			}
 			if err != nil {
 				return err
 			}
-
-			if atomic {
-				return performTransactionalCopy(d.Files, sources, dstRoot, dryRun, overwrite, showProgress, cmd)
-			}
-
-			// Original non-transactional behavior with progress
-			return performNonTransactionalCopy(d.Files, sources, dstRoot, dryRun, overwrite, showProgress, cmd)
+			opts.profile.collected(collectStart)
+			sources = opts.sortSources(d.Files, sources)
+			opts.events.collected(sources...)
+			sources = opts.dropUnstable(cmd, sources)
+			opts.detectBursts(d.Files, sources)
+			opts.geotag.addSources(cmd, sources)
+
+			return opts.destDirs.reveal(cmd, performTransfer(opts.files(d.Files), sources, dstRoot, opts, cmd, files.OperationCopy))
 		},
 		// flag definitions added after struct literal
 	}
@@ -107,8 +167,13 @@ func createCopyCmd(d *deps.AppDeps) *cobra.Command {
 	cmd.Flags().Bool("dry-run", false, "Show what would be copied without doing it")
 	cmd.Flags().Bool("overwrite", false, "Allow overwriting existing files in destination")
 	cmd.Flags().Bool("atomic", false, "Perform all-or-nothing copy with rollback on failure")
+	cmd.Flags().Bool("no-atomic", false, "Transfer file by file even when the config's default_mode is atomic")
 	cmd.Flags().Bool("progress", false, "Show progress bar during copy operations")
-	cmd.Flags().Uint("jobs", 1, "Number of concurrent copy workers (currently only 1 is used)")
+	cmd.Flags().Uint("jobs", 1, "Number of files to copy at once without --atomic (default from config, else 1)")
+	cmd.Flags().String("schedule", "largest-first", "Order in which parallel jobs take files: largest-first or planned")
+	cmd.Flags().String("link", "", "Place hard links (hard) or symbolic links (symlink) to the sources instead of copies")
+	cmd.Flags().Bool("verify", false, "Read every copy back and compare its --hash-algo checksum with the source's, taken while copying; a copy that differs fails")
+	addTransferFlags(cmd)
 
 	return cmd
 }
@@ -117,16 +182,30 @@ func createMoveCmd(d *deps.AppDeps) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "move [source] [destination]",
 		Short: "Move files from source to destination (original files are renamed)",
-		Long:  "Source may be a file or directory. Destination is the root directory under which files will be placed according to their metadata.",
-		Args:  cobra.ExactArgs(2),
-		RunE: func(cmd *cobra.Command, args []string) error {
+		Long:  "Source may be a file or directory, or be left out for the files listed with --from-file. Destination is the root directory under which files will be placed according to their metadata.",
+		Args:  transferArgs,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
 			srcInput := args[0]
-			dstRoot := args[1]
+			dstRoot := args[len(args)-1]
 
-			dryRun, _ := cmd.Flags().GetBool("dry-run")
-			overwrite, _ := cmd.Flags().GetBool("overwrite")
-			atomic, _ := cmd.Flags().GetBool("atomic")
-			showProgress, _ := cmd.Flags().GetBool("progress")
+			opts, err := transferOptionsFromFlags(cmd, d, dstRoot)
+			if err != nil {
+				return err
+			}
+			// post-run goes last, after the report it may read; the
+			// report is written once thumbnails are done.
+			defer func() { opts.scripts.postRun(cmd, err, opts.events.count(eventCopied, eventMoved)) }()
+			defer func() { opts.writeReport(cmd, err) }()
+			defer opts.close(cmd)
+			if err := opts.mirrorFrom(d.Files, srcInput); err != nil {
+				return err
+			}
+			if err := opts.preRun(srcInput); err != nil {
+				return err
+			}
+			if opts.stream {
+				return opts.destDirs.reveal(cmd, performStreamingTransfer(opts.files(d.Files), srcInput, dstRoot, opts, cmd, files.OperationMove))
+			}
 
 			srcAbs, err := filepath.Abs(srcInput)
 			if err != nil {
@@ -134,254 +213,98 @@ func createMoveCmd(d *deps.AppDeps) *cobra.Command {
 			}
 
 			var sources []string
-			if showProgress {
+			collectStart := time.Now()
+			if opts.fromFile != "" {
+				sources, err = opts.walk.readSourceList(d.Files, opts.fromFile)
+			} else if opts.showProgress {
 				// Show collection progress
-				collectionReporter := progress.NewSimpleProgressBar(cmd.ErrOrStderr())
-				sources, err = collectSourcesWithProgress(d.Files, srcAbs, collectionReporter)
+				collectionReporter := newProgressDisplay(cmd, opts.progressStyle)
+				sources, err = opts.walk.collect(d.Files, srcAbs, collectionReporter)
 			} else {
-				sources, err = collectSources(d.Files, srcAbs)
+				sources, err = opts.walk.collect(d.Files, srcAbs, progress.NewNoOpReporter())
 			}
 			if err != nil {
 				return err
 			}
-
-			if atomic {
-				return performTransactionalMove(d.Files, sources, dstRoot, dryRun, overwrite, showProgress, cmd)
-			}
-
-			// Original non-transactional behavior with progress
-			return performNonTransactionalMove(d.Files, sources, dstRoot, dryRun, overwrite, showProgress, cmd)
+			opts.profile.collected(collectStart)
+			sources = opts.sortSources(d.Files, sources)
+			opts.events.collected(sources...)
+			sources = opts.dropUnstable(cmd, sources)
+			opts.detectBursts(d.Files, sources)
+			opts.geotag.addSources(cmd, sources)
+
+			return opts.destDirs.reveal(cmd, performTransfer(opts.files(d.Files), sources, dstRoot, opts, cmd, files.OperationMove))
 		},
 	}
 
 	cmd.Flags().Bool("dry-run", false, "Show what would be moved without doing it")
 	cmd.Flags().Bool("overwrite", false, "Allow overwriting existing files in destination")
 	cmd.Flags().Bool("atomic", false, "Perform all-or-nothing move with rollback on failure")
+	cmd.Flags().Bool("no-atomic", false, "Transfer file by file even when the config's default_mode is atomic")
 	cmd.Flags().Bool("progress", false, "Show progress bar during move operations")
+	cmd.Flags().Bool("allow-archive-source", false, "Move files out of a folder marked with mark-archive")
+	addTransferFlags(cmd)
 
 	return cmd
 }
 
-// performTransactionalCopy handles atomic copy operations using transactions.
-func performTransactionalCopy(fs files.FilesService, sources []string, dstRoot string, dryRun, overwrite, showProgress bool, cmd *cobra.Command) error {
-	// Create a new transaction
-	tx := fs.NewTransaction(overwrite)
-
-	// Plan all operations with optional progress for metadata extraction
-	var planningReporter progress.ProgressReporter
-	if showProgress {
-		planningReporter = progress.NewSimpleProgressBar(cmd.ErrOrStderr())
-		planningReporter.SetTotal(len(sources))
-		planningReporter.SetMessage("Planning operations")
-	} else {
-		planningReporter = progress.NewNoOpReporter()
-	}
-
-	for i, src := range sources {
-		planningReporter.SetMessage(fmt.Sprintf("Planning copy for %s", src))
-		dst, err := destFromMetadata(fs, src, dstRoot)
-		if err != nil {
-			return err
-		}
-
-		if err := tx.AddCopy(src, dst); err != nil {
-			return err
-		}
-		planningReporter.SetCurrent(i + 1)
-	}
-	planningReporter.Finish()
-
-	// Validate all operations
-	if err := tx.Validate(); err != nil {
-		return err
-	}
-
-	// Handle dry-run mode
-	if dryRun {
-		for _, op := range tx.Operations() {
-			fmt.Fprintf(cmd.OutOrStdout(), "Would copy %s → %s\n", op.Source(), op.Destination())
-		}
-		return nil
-	}
-
-	// Execute the transaction with progress if requested
-	if showProgress {
-		reporter := progress.NewSimpleProgressBar(cmd.ErrOrStderr())
-		if err := tx.ExecuteWithProgress(reporter); err != nil {
-			return err
-		}
-	} else {
-		if err := tx.Execute(); err != nil {
-			return err
-		}
-	}
-
-	fmt.Fprintf(cmd.OutOrStdout(), "Atomically copied %d file(s).\n", len(sources))
-	return nil
-}
-
-// performTransactionalMove handles atomic move operations using transactions.
-func performTransactionalMove(fs files.FilesService, sources []string, dstRoot string, dryRun, overwrite, showProgress bool, cmd *cobra.Command) error {
-	// Create a new transaction
-	tx := fs.NewTransaction(overwrite)
-
-	// Plan all operations with optional progress for metadata extraction
-	var planningReporter progress.ProgressReporter
-	if showProgress {
-		planningReporter = progress.NewSimpleProgressBar(cmd.ErrOrStderr())
-		planningReporter.SetTotal(len(sources))
-		planningReporter.SetMessage("Planning operations")
-	} else {
-		planningReporter = progress.NewNoOpReporter()
-	}
-
-	for i, src := range sources {
-		planningReporter.SetMessage(fmt.Sprintf("Planning move for %s", src))
-		dst, err := destFromMetadata(fs, src, dstRoot)
-		if err != nil {
-			return err
-		}
-
-		if err := tx.AddMove(src, dst); err != nil {
-			return err
-		}
-		planningReporter.SetCurrent(i + 1)
-	}
-	planningReporter.Finish()
-
-	// Validate all operations
-	if err := tx.Validate(); err != nil {
-		return err
+// newCLI assembles the root command with all subcommands, wired to the
+// output streams and file system configured in dependencies.
+func newCLI(dependencies *deps.AppDeps) *cobra.Command {
+	if dependencies.FS != nil {
+		d := *dependencies
+		d.Files = files.WithFS(d.Files, d.FS)
+		dependencies = &d
 	}
+	rootCmd := createRootCmd(dependencies)
 
-	// Handle dry-run mode
-	if dryRun {
-		for _, op := range tx.Operations() {
-			fmt.Fprintf(cmd.OutOrStdout(), "Would move %s → %s\n", op.Source(), op.Destination())
-		}
-		return nil
+	rootCmd.AddCommand(createReadCmd(dependencies))
+	rootCmd.AddCommand(createTagsCmd(dependencies))
+	rootCmd.AddCommand(createRulesCmd(dependencies))
+	rootCmd.AddCommand(createWhereCmd(dependencies))
+	rootCmd.AddCommand(createTemplateCmd(dependencies))
+	rootCmd.AddCommand(createSyncClockCmd(dependencies))
+	rootCmd.AddCommand(createCopyCmd(dependencies))
+	rootCmd.AddCommand(createMoveCmd(dependencies))
+	rootCmd.AddCommand(createAuditCmd(dependencies))
+	rootCmd.AddCommand(createMigrateCmd(dependencies))
+	rootCmd.AddCommand(createMarkArchiveCmd(dependencies))
+	rootCmd.AddCommand(createCleanCmd(dependencies))
+	rootCmd.AddCommand(createRollbackStatusCmd(dependencies))
+	rootCmd.AddCommand(createRetryCmd(dependencies))
+	rootCmd.AddCommand(createSearchCmd())
+	rootCmd.AddCommand(createCatalogCmd(dependencies))
+	rootCmd.AddCommand(createStatsCmd())
+	rootCmd.AddCommand(createExportCmd(dependencies))
+	rootCmd.AddCommand(createSyncCmd(dependencies))
+	rootCmd.AddCommand(createBenchCmd(dependencies))
+	rootCmd.AddCommand(createDaemonCmd(dependencies))
+	rootCmd.AddCommand(createStatusCmd())
+	rootCmd.AddCommand(createScheduleCmd(dependencies))
+	rootCmd.AddCommand(createServiceCmd())
+
+	// Execute reports errors itself so they are only printed once.
+	rootCmd.SilenceErrors = true
+
+	if dependencies.Streams.Out != nil {
+		rootCmd.SetOut(dependencies.Streams.Out)
 	}
-
-	// Execute the transaction with progress if requested
-	if showProgress {
-		reporter := progress.NewSimpleProgressBar(cmd.ErrOrStderr())
-		if err := tx.ExecuteWithProgress(reporter); err != nil {
-			return err
-		}
-	} else {
-		if err := tx.Execute(); err != nil {
-			return err
-		}
+	if dependencies.Streams.Err != nil {
+		rootCmd.SetErr(dependencies.Streams.Err)
 	}
 
-	fmt.Fprintf(cmd.OutOrStdout(), "Atomically moved %d file(s).\n", len(sources))
-	return nil
-}
-
-// performNonTransactionalCopy handles non-atomic copy operations with progress reporting.
-func performNonTransactionalCopy(fs files.FilesService, sources []string, dstRoot string, dryRun, overwrite, showProgress bool, cmd *cobra.Command) error {
-	// Create progress reporter based on flag
-	var reporter progress.ProgressReporter
-	if showProgress {
-		reporter = progress.NewSimpleProgressBar(cmd.ErrOrStderr())
-	} else {
-		reporter = progress.NewNoOpReporter()
-	}
-	reporter.SetTotal(len(sources))
-	
-	for i, src := range sources {
-		dst, err := destFromMetadata(fs, src, dstRoot)
-		if err != nil {
-			return err
-		}
-		
-		reporter.SetMessage(fmt.Sprintf("copy %s", src))
-		
-		if dryRun {
-			fmt.Fprintf(cmd.OutOrStdout(), "Would copy %s → %s\n", src, dst)
-			reporter.Increment()
-			continue
-		}
-		
-		if !overwrite {
-			if err := fs.ValidateCopyArgs(src, dst); err != nil {
-				return err
-			}
-		}
-		
-		if err := fs.Copy(src, dst); err != nil {
-			reporter.SetError(err)
-			return err
-		}
-		
-		reporter.SetCurrent(i + 1)
-	}
-	
-	reporter.Finish()
-	fmt.Fprintf(cmd.OutOrStdout(), "Copied %d file(s).\n", len(sources))
-	return nil
-}
-
-// performNonTransactionalMove handles non-atomic move operations with progress reporting.
-func performNonTransactionalMove(fs files.FilesService, sources []string, dstRoot string, dryRun, overwrite, showProgress bool, cmd *cobra.Command) error {
-	// Create progress reporter based on flag
-	var reporter progress.ProgressReporter
-	if showProgress {
-		reporter = progress.NewSimpleProgressBar(cmd.ErrOrStderr())
-	} else {
-		reporter = progress.NewNoOpReporter()
-	}
-	reporter.SetTotal(len(sources))
-	
-	for i, src := range sources {
-		dst, err := destFromMetadata(fs, src, dstRoot)
-		if err != nil {
-			return err
-		}
-		
-		reporter.SetMessage(fmt.Sprintf("move %s", src))
-		
-		if dryRun {
-			fmt.Fprintf(cmd.OutOrStdout(), "Would move %s → %s\n", src, dst)
-			reporter.Increment()
-			continue
-		}
-		
-		// Validate unless overwrite flag is set
-		if !overwrite {
-			if err := fs.ValidateCopyArgs(src, dst); err != nil {
-				return err
-			}
-		}
-		
-		// Ensure destination directory exists
-		if err := fs.EnsureDir(filepath.Dir(dst), dirPerm); err != nil {
-			return err
-		}
-		
-		// Perform the move (rename)
-		if err := os.Rename(src, dst); err != nil {
-			reporter.SetError(err)
-			return err
-		}
-		
-		reporter.SetCurrent(i + 1)
-	}
-	
-	reporter.Finish()
-	fmt.Fprintf(cmd.OutOrStdout(), "Moved %d file(s).\n", len(sources))
-	return nil
+	return rootCmd
 }
 
 func Execute(dependencies *deps.AppDeps) {
-	rootCmd := createRootCmd(dependencies)
+	rootCmd := newCLI(dependencies)
 
-	rootCmd.AddCommand(createReadCmd(dependencies))
-	rootCmd.AddCommand(createCopyCmd(dependencies))
-	rootCmd.AddCommand(createMoveCmd(dependencies))
-
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+	err := rootCmd.Execute()
+	if perr := stopProfiling(); perr != nil {
+		output.New(rootCmd.ErrOrStderr()).Warn("the trace could not be written: %v", perr)
+	}
+	if err != nil {
+		reportError(rootCmd, err)
+		os.Exit(exitCode(err))
 	}
 }
@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+// taggingFilesService records tags instead of invoking exiftool.
+type taggingFilesService struct {
+	*testFilesService
+	written map[string]map[string]string
+}
+
+func newTaggingFilesService(metadata map[string]files.FileMetadata) *taggingFilesService {
+	return &taggingFilesService{
+		testFilesService: createTestFilesService(metadata),
+		written:          make(map[string]map[string]string),
+	}
+}
+
+func (t *taggingFilesService) WriteTags(path string, tags map[string]string) error {
+	t.written[path] = tags
+	return nil
+}
+
+func (t *taggingFilesService) NewTransaction(overwrite bool) files.Transaction {
+	return files.NewTransaction(t, overwrite)
+}
+
+func TestCopyCmd_ArchiveID(t *testing.T) {
+	idPattern := regexp.MustCompile(`^\d{8}-\d{6}-[0-9a-f]{4}-[12]$`)
+
+	for _, atomic := range []bool{false, true} {
+		name := "non-atomic"
+		if atomic {
+			name = "atomic"
+		}
+		t.Run(name, func(t *testing.T) {
+			tempDir := testutil.TempDir(t)
+			srcDir := filepath.Join(tempDir, "src")
+			dstDir := filepath.Join(tempDir, "dst")
+			if err := os.MkdirAll(srcDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+
+			metadata := map[string]files.FileMetadata{}
+			dates := map[string]string{
+				"a.jpg": "2025:01:27 10:00:00-06:00",
+				"b.jpg": "2025:01:27 11:00:00-06:00",
+			}
+			for name, date := range dates {
+				p := filepath.Join(srcDir, name)
+				if err := os.WriteFile(p, []byte(name), 0644); err != nil {
+					t.Fatal(err)
+				}
+				metadata[p] = files.FileMetadata{
+					Filepath: p,
+					Tags:     map[string]string{"CreationDate": date},
+				}
+			}
+
+			fs := newTaggingFilesService(metadata)
+			cmd := createCopyCmd(&deps.AppDeps{Files: fs})
+			args := []string{"--archive-id", srcDir, dstDir}
+			if atomic {
+				args = append([]string{"--atomic"}, args...)
+			}
+			cmd.SetArgs(args)
+
+			var out bytes.Buffer
+			cmd.SetOut(&out)
+			cmd.SetErr(&out)
+
+			if err := cmd.Execute(); err != nil {
+				t.Fatalf("unexpected error: %v\nOutput: %s", err, out.String())
+			}
+
+			if len(fs.written) != 2 {
+				t.Fatalf("expected tags for 2 files, got %d", len(fs.written))
+			}
+			seen := map[string]bool{}
+			for path, tags := range fs.written {
+				id := tags[defaultArchiveIDTag]
+				if !idPattern.MatchString(id) {
+					t.Errorf("%s: unexpected archive ID %q", path, id)
+				}
+				seen[id] = true
+			}
+			if len(seen) != 2 {
+				t.Errorf("archive IDs must be unique per file, got %v", seen)
+			}
+		})
+	}
+}
+
+func TestCopyCmd_ArchiveIDCustomTag(t *testing.T) {
+	tempDir := testutil.TempDir(t)
+	src := filepath.Join(tempDir, "a.jpg")
+	if err := os.WriteFile(src, []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	fs := newTaggingFilesService(nil)
+	cmd := createCopyCmd(&deps.AppDeps{Files: fs})
+	cmd.SetArgs([]string{"--archive-id", "--archive-id-tag", "XMP-xmp:Label", src, filepath.Join(tempDir, "dst")})
+	cmd.SetOut(&bytes.Buffer{})
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, tags := range fs.written {
+		if _, ok := tags["XMP-xmp:Label"]; !ok {
+			t.Errorf("expected custom tag to be written, got %v", tags)
+		}
+	}
+}
+
+func TestCopyCmd_ArchiveIDRequiresTagWriter(t *testing.T) {
+	tempDir := testutil.TempDir(t)
+	src := filepath.Join(tempDir, "a.jpg")
+	if err := os.WriteFile(src, []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := createCopyCmd(&deps.AppDeps{Files: createTestFilesService(nil)})
+	cmd.SetArgs([]string{"--archive-id", src, filepath.Join(tempDir, "dst")})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+
+	if err := cmd.Execute(); err == nil {
+		t.Fatal("expected error when the files service cannot write tags")
+	}
+}
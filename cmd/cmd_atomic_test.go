@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/Tmunayyer/gocamelpack/config"
 	"github.com/Tmunayyer/gocamelpack/deps"
 	"github.com/Tmunayyer/gocamelpack/files"
 	"github.com/Tmunayyer/gocamelpack/testutil"
@@ -453,4 +454,41 @@ func TestAtomicCmd_DryRun(t *testing.T) {
 	if len(entries) != 0 {
 		t.Errorf("dry-run should not copy files, but found %d entries", len(entries))
 	}
-}
\ No newline at end of file
+}
+func TestCopyCmd_DefaultMode(t *testing.T) {
+	tmp := filepath.Join(testutil.TempDir(t), "default-mode")
+	card := filepath.Join(tmp, "card")
+	metadata := writeCard(t, card, 2)
+	run := func(cfg *config.Config, args ...string) (string, error) {
+		var out bytes.Buffer
+		root := newCLI(&deps.AppDeps{Files: createTestFilesService(metadata), Config: cfg, Streams: deps.Streams{Out: &out, Err: &out}})
+		root.SetArgs(args)
+		err := root.Execute()
+		return out.String(), err
+	}
+	atomic := &config.Config{DefaultMode: "atomic"}
+
+	out, err := run(atomic, "copy", card, filepath.Join(tmp, "a"))
+	if err != nil || !contains(out, "Atomically copied 2 file(s)") {
+		t.Errorf("default_mode atomic: %v\n%s", err, out)
+	}
+	out, err = run(atomic, "copy", "--no-atomic", card, filepath.Join(tmp, "b"))
+	if err != nil || !contains(out, "Copied 2 file(s)") {
+		t.Errorf("--no-atomic: %v\n%s", err, out)
+	}
+	// Streaming is never atomic, whatever the default.
+	if out, err := run(atomic, "copy", "--stream", card, filepath.Join(tmp, "c")); err != nil {
+		t.Errorf("--stream with default_mode atomic: %v\n%s", err, out)
+	}
+	// --chunk-size only needs the run to be atomic.
+	if out, err := run(atomic, "copy", "--chunk-size", "1", card, filepath.Join(tmp, "d")); err != nil {
+		t.Errorf("--chunk-size with default_mode atomic: %v\n%s", err, out)
+	}
+
+	if _, err := run(&config.Config{}, "copy", "--atomic", "--no-atomic", card, filepath.Join(tmp, "e")); err == nil {
+		t.Error("expected --atomic --no-atomic to fail")
+	}
+	if _, err := run(&config.Config{DefaultMode: "sometimes"}, "copy", card, filepath.Join(tmp, "e")); err == nil {
+		t.Error("expected an unknown default_mode to fail")
+	}
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+func TestCopyCmd_BurstsDryRun(t *testing.T) {
+	srcDir := testutil.TempDir(t)
+	dstDir := testutil.TempDir(t)
+
+	times := map[string]string{
+		"IMG_0001.jpg": "2025:01:27 15:30:45.100-06:00",
+		"IMG_0002.jpg": "2025:01:27 15:30:45.300-06:00",
+		"IMG_0003.jpg": "2025:01:27 15:30:45.500-06:00",
+		"IMG_0004.jpg": "2025:01:27 15:31:10.000-06:00",
+	}
+	metadata := map[string]files.FileMetadata{}
+	for name, ts := range times {
+		p := filepath.Join(srcDir, name)
+		if err := os.WriteFile(p, []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		metadata[p] = files.FileMetadata{Filepath: p, Tags: map[string]string{
+			"CreationDate":           ts[:19] + ts[23:],
+			"SubSecDateTimeOriginal": ts,
+		}}
+	}
+
+	var out bytes.Buffer
+	root := newCLI(&deps.AppDeps{Files: createTestFilesService(metadata), Streams: deps.Streams{Out: &out, Err: &out}})
+	root.SetArgs([]string{"copy", "--dry-run", "--bursts", srcDir, dstDir})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("copy --bursts: %v\n%s", err, out.String())
+	}
+
+	day := filepath.Join(dstDir, "2025", "01", "27")
+	for _, want := range []string{
+		filepath.Join(day, "bursts", "IMG_0001", "IMG_0001.jpg"),
+		filepath.Join(day, "bursts", "IMG_0001", "IMG_0002.jpg"),
+		filepath.Join(day, "bursts", "IMG_0001", "IMG_0003.jpg"),
+		filepath.Join(day, "15_31.jpg"),
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected %s in plan:\n%s", want, out.String())
+		}
+	}
+}
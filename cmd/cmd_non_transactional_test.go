@@ -2,14 +2,19 @@ package cmd
 
 import (
 	"bytes"
+	"encoding/json"
 	"os"
+	"path/filepath"
 	"strings"
+	"syscall"
 	"testing"
+	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/Tmunayyer/gocamelpack/files"
 	"github.com/Tmunayyer/gocamelpack/progress"
+	"github.com/Tmunayyer/gocamelpack/testutil"
 )
 
 // mockFilesServiceForCmd implements FilesService for testing command functions
@@ -96,9 +101,9 @@ func TestPerformNonTransactionalCopy_Success(t *testing.T) {
 	buf := &bytes.Buffer{}
 	cmd.SetOut(buf)
 
-	err := performNonTransactionalCopy(mockFS, sources, dstRoot, false, false, false, cmd)
+	err := performTransfer(mockFS, sources, dstRoot, transferOptions{}, cmd, files.OperationCopy)
 	if err != nil {
-		t.Fatalf("performNonTransactionalCopy failed: %v", err)
+		t.Fatalf("performTransfer failed: %v", err)
 	}
 
 	// Verify Copy was called for each file
@@ -118,9 +123,9 @@ func TestPerformNonTransactionalCopy_DryRun(t *testing.T) {
 	buf := &bytes.Buffer{}
 	cmd.SetOut(buf)
 
-	err := performNonTransactionalCopy(mockFS, sources, dstRoot, true, false, false, cmd)
+	err := performTransfer(mockFS, sources, dstRoot, transferOptions{dryRun: true}, cmd, files.OperationCopy)
 	if err != nil {
-		t.Fatalf("performNonTransactionalCopy dry-run failed: %v", err)
+		t.Fatalf("performTransfer dry-run failed: %v", err)
 	}
 
 	// Verify no actual copies were performed
@@ -148,9 +153,9 @@ func TestPerformNonTransactionalCopy_ValidationError(t *testing.T) {
 
 	cmd := &cobra.Command{}
 
-	err := performNonTransactionalCopy(mockFS, sources, dstRoot, false, false, false, cmd)
+	err := performTransfer(mockFS, sources, dstRoot, transferOptions{}, cmd, files.OperationCopy)
 	if err == nil {
-		t.Fatal("Expected performNonTransactionalCopy to fail with validation error")
+		t.Fatal("Expected performTransfer to fail with validation error")
 	}
 
 	// Should not have performed any copies due to validation failure
@@ -174,11 +179,11 @@ func TestPerformNonTransactionalMove_Success(t *testing.T) {
 	// Note: This test will actually try to call os.Rename, which will fail
 	// because the files don't exist. In a real scenario, we'd need a more
 	// sophisticated mock or integration test with real files.
-	err := performNonTransactionalMove(mockFS, sources, dstRoot, false, false, false, cmd)
+	err := performTransfer(mockFS, sources, dstRoot, transferOptions{}, cmd, files.OperationMove)
 	
 	// We expect this to fail because os.Rename tries to move real files
 	if err == nil {
-		t.Fatal("Expected performNonTransactionalMove to fail without real files")
+		t.Fatal("Expected performTransfer to fail without real files")
 	}
 }
 
@@ -192,9 +197,9 @@ func TestPerformNonTransactionalMove_DryRun(t *testing.T) {
 	buf := &bytes.Buffer{}
 	cmd.SetOut(buf)
 
-	err := performNonTransactionalMove(mockFS, sources, dstRoot, true, false, false, cmd)
+	err := performTransfer(mockFS, sources, dstRoot, transferOptions{dryRun: true}, cmd, files.OperationMove)
 	if err != nil {
-		t.Fatalf("performNonTransactionalMove dry-run failed: %v", err)
+		t.Fatalf("performTransfer dry-run failed: %v", err)
 	}
 
 	// Verify dry-run output
@@ -222,9 +227,9 @@ func TestPerformNonTransactionalCopy_WithProgressReporter(t *testing.T) {
 
 	// Test that the function completes without error
 	// (Progress reporting is currently using NoOpReporter)
-	err := performNonTransactionalCopy(mockFS, sources, dstRoot, false, false, false, cmd)
+	err := performTransfer(mockFS, sources, dstRoot, transferOptions{}, cmd, files.OperationCopy)
 	if err != nil {
-		t.Fatalf("performNonTransactionalCopy with progress failed: %v", err)
+		t.Fatalf("performTransfer with progress failed: %v", err)
 	}
 
 	// Verify that operations were performed
@@ -246,9 +251,9 @@ func TestPerformNonTransactionalMove_WithProgressReporter(t *testing.T) {
 	cmd.SetOut(buf)
 
 	// Test dry-run mode (to avoid os.Rename complications)
-	err := performNonTransactionalMove(mockFS, sources, dstRoot, true, false, false, cmd)
+	err := performTransfer(mockFS, sources, dstRoot, transferOptions{dryRun: true}, cmd, files.OperationMove)
 	if err != nil {
-		t.Fatalf("performNonTransactionalMove dry-run with progress failed: %v", err)
+		t.Fatalf("performTransfer dry-run with progress failed: %v", err)
 	}
 
 	// Verify dry-run worked
@@ -283,4 +288,164 @@ func TestProgressReporterUsage_CurrentlyNoOp(t *testing.T) {
 	if reporter.IsComplete() {
 		t.Error("NoOpReporter.IsComplete() should return false")
 	}
-}
\ No newline at end of file
+}
+func TestPerformNonTransactionalCopy_DryRunFlagsConflicts(t *testing.T) {
+	mockFS := newMockFilesServiceForCmd()
+	mockFS.addFile("/dst/file1.txt") // destination already present
+
+	sources := []string{"/src/file1.txt", "/src/file22.txt"}
+
+	cmd := &cobra.Command{}
+	buf := &bytes.Buffer{}
+	cmd.SetOut(buf)
+
+	if err := performTransfer(mockFS, sources, "/dst", transferOptions{dryRun: true}, cmd, files.OperationCopy); err != nil {
+		t.Fatalf("dry-run failed: %v", err)
+	}
+
+	want := "Would copy /src/file1.txt  → /dst/file1.txt (exists)\n" +
+		"Would copy /src/file22.txt → /dst/file22.txt\n"
+	if buf.String() != want {
+		t.Errorf("unexpected dry-run output:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestPerformNonTransactionalCopy_ProgressFile(t *testing.T) {
+	mockFS := newMockFilesServiceForCmd()
+	statusPath := filepath.Join(testutil.TempDir(t), "status.json")
+
+	cmd := &cobra.Command{}
+	cmd.SetOut(&bytes.Buffer{})
+
+	sources := []string{"/src/file1.txt", "/src/file2.txt"}
+	if err := performTransfer(mockFS, sources, "/dst", transferOptions{progressOptions: progressOptions{progressFile: statusPath}}, cmd, files.OperationCopy); err != nil {
+		t.Fatalf("copy failed: %v", err)
+	}
+
+	data, err := os.ReadFile(statusPath)
+	if err != nil {
+		t.Fatalf("status file not written: %v", err)
+	}
+	var st progress.Status
+	if err := json.Unmarshal(data, &st); err != nil {
+		t.Fatalf("decode status: %v", err)
+	}
+	if st.State != progress.StatusDone || st.Current != 2 || st.Total != 2 {
+		t.Errorf("unexpected status %+v", st)
+	}
+}
+
+func TestPerformNonTransactionalCopy_ProgressFileRecordsError(t *testing.T) {
+	mockFS := newMockFilesServiceForCmd()
+	mockFS.setValidationError(os.ErrExist)
+	statusPath := filepath.Join(testutil.TempDir(t), "status.json")
+
+	cmd := &cobra.Command{}
+	err := performTransfer(mockFS, []string{"/src/file1.txt"}, "/dst", transferOptions{progressOptions: progressOptions{progressFile: statusPath}}, cmd, files.OperationCopy)
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+
+	data, _ := os.ReadFile(statusPath)
+	var st progress.Status
+	if json.Unmarshal(data, &st) != nil || st.State != progress.StatusError {
+		t.Errorf("status should record the failure, got %s", data)
+	}
+}
+
+func TestPerformNonTransactionalCopy_CaseCollision(t *testing.T) {
+	mockFS := newMockFilesServiceForCmd()
+	mockFS.addFile("/src/IMG_0001.JPG")
+	mockFS.addFile("/src/img_0001.jpg")
+
+	cmd := &cobra.Command{}
+	cmd.SetOut(&bytes.Buffer{})
+
+	opts := transferOptions{collisions: files.NewCollisionTracker(true)}
+	err := performTransfer(mockFS, []string{"/src/IMG_0001.JPG", "/src/img_0001.jpg"}, "/dst", opts, cmd, files.OperationCopy)
+	if files.ErrorCode(err) != files.CodeConflict {
+		t.Fatalf("expected case collision conflict, got %v", err)
+	}
+	if mockFS.copyCallCount != 1 {
+		t.Errorf("expected the colliding file not to be copied, got %d copies", mockFS.copyCallCount)
+	}
+
+	// Without case folding both names are distinct.
+	mockFS.copyCallCount = 0
+	if err := performTransfer(mockFS, []string{"/src/IMG_0001.JPG", "/src/img_0001.jpg"}, "/dst", transferOptions{}, cmd, files.OperationCopy); err != nil {
+		t.Fatalf("unexpected error on case-sensitive destination: %v", err)
+	}
+}
+
+func TestPerformNonTransactionalCopy_RetriesTransientErrors(t *testing.T) {
+	mockFS := newMockFilesServiceForCmd()
+	mockFS.copyError = &os.PathError{Op: "write", Path: "/dst/file1.txt", Err: syscall.EIO}
+
+	cmd := &cobra.Command{}
+	cmd.SetOut(&bytes.Buffer{})
+
+	opts := transferOptions{ioOptions: ioOptions{retry: files.RetryPolicy{Retries: 2}, retries: &files.RetryStats{}}}
+	err := performTransfer(mockFS, []string{"/src/file1.txt"}, "/dst", opts, cmd, files.OperationCopy)
+	if err == nil || !strings.Contains(err.Error(), "after 3 attempts") {
+		t.Fatalf("expected failure after exhausting retries, got %v", err)
+	}
+	if mockFS.copyCallCount != 3 {
+		t.Errorf("expected 3 copy attempts, got %d", mockFS.copyCallCount)
+	}
+
+	// Permanent errors fail on the first attempt.
+	mockFS.copyCallCount = 0
+	mockFS.copyError = os.ErrPermission
+	if err := performTransfer(mockFS, []string{"/src/file1.txt"}, "/dst", opts, cmd, files.OperationCopy); err == nil {
+		t.Fatal("expected permission error")
+	}
+	if mockFS.copyCallCount != 1 {
+		t.Errorf("permanent error retried: %d copy attempts", mockFS.copyCallCount)
+	}
+}
+
+func TestPrintSummary_Retries(t *testing.T) {
+	var buf bytes.Buffer
+	cmd := &cobra.Command{}
+	cmd.SetOut(&buf)
+
+	printSummary(cmd, "Copied", 5, 1, &files.RetryStats{Retried: 2, Attempts: 3}, nil)
+	if !strings.Contains(buf.String(), "Copied 5 file(s), skipped 1, retried 2 (3 extra attempt(s)).") {
+		t.Errorf("unexpected summary %q", buf.String())
+	}
+}
+
+func TestPrintSummary_IOStats(t *testing.T) {
+	var buf bytes.Buffer
+	cmd := &cobra.Command{}
+	cmd.SetOut(&buf)
+
+	stats := &files.IOStats{
+		Files:        4,
+		BytesRead:    8 << 20,
+		BytesWritten: 8 << 20,
+		Busy:         2 * time.Second,
+		Slowest:      []files.FileTiming{{Path: "/nas/big.mov", Bytes: 6 << 20, Duration: 1500 * time.Millisecond}},
+	}
+	printSummary(cmd, "Copied", 4, 0, nil, stats)
+	for _, want := range []string{"read 8.0 MiB, wrote 8.0 MiB in 2s (4.0 MiB/s, 2.0 files/s)", "slow: /nas/big.mov (6.0 MiB, 1.5s)"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("summary %q lacks %q", buf.String(), want)
+		}
+	}
+}
+
+func TestDestinationCaseInsensitive(t *testing.T) {
+	if got, _ := destinationCaseInsensitive("on", "/nowhere", false); !got {
+		t.Error("on should force case folding")
+	}
+	if got, _ := destinationCaseInsensitive("off", "/nowhere", false); got {
+		t.Error("off should disable case folding")
+	}
+	if got, _ := destinationCaseInsensitive("auto", "/nowhere", true); got != files.CaseInsensitiveByDefault() {
+		t.Error("dry-run auto should use the OS default")
+	}
+	if _, err := destinationCaseInsensitive("maybe", "/nowhere", false); err == nil {
+		t.Error("expected error for invalid mode")
+	}
+}
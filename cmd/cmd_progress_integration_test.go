@@ -7,6 +7,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/Tmunayyer/gocamelpack/config"
 	"github.com/Tmunayyer/gocamelpack/deps"
 	"github.com/Tmunayyer/gocamelpack/testutil"
 )
@@ -337,4 +338,104 @@ func TestCopyCmd_DryRunWithProgress(t *testing.T) {
 	if !strings.Contains(stderrOutput, "✓") {
 		t.Error("Expected completion checkmark even during dry-run")
 	}
-}
\ No newline at end of file
+}
+func TestCopyCmd_WithTUI(t *testing.T) {
+	tempDir := testutil.TempDir(t)
+	srcDir := filepath.Join(tempDir, "tui-src")
+	dstDir := filepath.Join(tempDir, "tui-dst")
+	if err := os.MkdirAll(srcDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, filename := range []string{"test1.jpg", "test2.jpg", "test3.jpg"} {
+		if err := os.WriteFile(filepath.Join(srcDir, filename), []byte("test content"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cmd := createCopyCmd(&deps.AppDeps{Files: createTestFilesService(nil)})
+	cmd.SetArgs([]string{"--tui", "--jobs", "2", "--overwrite", srcDir, dstDir})
+	var out, stderr bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&stderr)
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("copy command with --tui failed: %v", err)
+	}
+
+	stderrOutput := stderr.String()
+	for _, want := range []string{"worker 1: test", "worker 2: idle", "throughput ", "errors: none", "3/3 (100%)", "✓"} {
+		if !strings.Contains(stderrOutput, want) {
+			t.Errorf("Expected %q in the dashboard output, got: %q", want, stderrOutput)
+		}
+	}
+	if !strings.Contains(out.String(), "Copied") {
+		t.Errorf("Expected completion message in stdout, got: %q", out.String())
+	}
+}
+
+func TestCopyCmd_PlainProgress(t *testing.T) {
+	tempDir := testutil.TempDir(t)
+	srcDir := filepath.Join(tempDir, "plain-src")
+	if err := os.MkdirAll(srcDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, filename := range []string{"test1.jpg", "test2.jpg"} {
+		if err := os.WriteFile(filepath.Join(srcDir, filename), []byte("test content"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	run := func(args ...string) (string, error) {
+		dep := &deps.AppDeps{Files: createTestFilesService(nil), Config: &config.Config{ProgressStyle: "plain"}}
+		cmd := createCopyCmd(dep)
+		cmd.SetArgs(args)
+		var out, stderr bytes.Buffer
+		cmd.SetOut(&out)
+		cmd.SetErr(&stderr)
+		err := cmd.Execute()
+		return stderr.String(), err
+	}
+
+	stderrOutput, err := run("--progress", "--atomic", "--overwrite", srcDir, filepath.Join(tempDir, "plain-dst"))
+	if err != nil {
+		t.Fatalf("copy command with plain progress failed: %v", err)
+	}
+	if !strings.Contains(stderrOutput, "Done: 2/2 (100%)\n") {
+		t.Errorf("Expected a plain completion line, got: %q", stderrOutput)
+	}
+	if strings.ContainsAny(stderrOutput, "\r\x1b█░✓") {
+		t.Errorf("Plain progress should have no control or block characters, got: %q", stderrOutput)
+	}
+
+	if _, err := run("--tui", srcDir, filepath.Join(tempDir, "plain-tui")); err == nil || !strings.Contains(err.Error(), "--tui") {
+		t.Errorf("Expected --tui to be refused with the plain style, got: %v", err)
+	}
+}
+
+func TestCopyCmd_ASCIIProgress(t *testing.T) {
+	t.Setenv("GOCAMELPACK_ASCII", "1")
+	tempDir := testutil.TempDir(t)
+	srcDir := filepath.Join(tempDir, "ascii-src")
+	if err := os.MkdirAll(srcDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(srcDir, "test1.jpg"), []byte("test content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := createCopyCmd(&deps.AppDeps{Files: createTestFilesService(nil), Config: &config.Config{}})
+	cmd.SetArgs([]string{"--progress", "--overwrite", srcDir, filepath.Join(tempDir, "ascii-dst")})
+	var out, stderr bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&stderr)
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("copy command with ASCII progress failed: %v", err)
+	}
+
+	stderrOutput := stderr.String()
+	if !strings.Contains(stderrOutput, "########] 1/1 (100%)") || !strings.HasSuffix(stderrOutput, " OK\n") {
+		t.Errorf("Expected an ASCII completion bar, got: %q", stderrOutput)
+	}
+	if strings.ContainsAny(stderrOutput, "█░✓") {
+		t.Errorf("ASCII progress should have no block characters or check marks, got: %q", stderrOutput)
+	}
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+// readOptionsFilesService records the options passed to ReadTags.
+type readOptionsFilesService struct {
+	*testFilesService
+	got *files.ReadOptions
+}
+
+func (r *readOptionsFilesService) ReadTags(paths []string, opts files.ReadOptions) ([]files.FileMetadata, error) {
+	r.got = &opts
+	return files.FilterGroups([]files.FileMetadata{{
+		Filepath: paths[0],
+		Tags:     map[string]string{"EXIF:Model": "X100V", "XMP:Rating": "5"},
+	}}, opts.Groups), nil
+}
+
+func TestReadCmd_GroupsAndRaw(t *testing.T) {
+	tempDir := testutil.TempDir(t)
+	testFile := filepath.Join(tempDir, "test.jpg")
+	if err := os.WriteFile(testFile, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	fs := &readOptionsFilesService{testFilesService: createTestFilesService(nil)}
+	cmd := createReadCmd(&deps.AppDeps{Files: fs})
+	cmd.SetArgs([]string{"--groups", "EXIF,QuickTime", "--raw", testFile})
+	cmd.SetOut(&bytes.Buffer{})
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := files.ReadOptions{Groups: []string{"EXIF", "QuickTime"}, Raw: true}
+	if fs.got == nil || !reflect.DeepEqual(*fs.got, want) {
+		t.Errorf("expected ReadTags called with %+v, got %+v", want, fs.got)
+	}
+}
+
+func TestReadCmd_DefaultUsesGetFileTags(t *testing.T) {
+	tempDir := testutil.TempDir(t)
+	testFile := filepath.Join(tempDir, "test.jpg")
+	if err := os.WriteFile(testFile, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	fs := &readOptionsFilesService{testFilesService: createTestFilesService(nil)}
+	cmd := createReadCmd(&deps.AppDeps{Files: fs})
+	cmd.SetArgs([]string{testFile})
+	cmd.SetOut(&bytes.Buffer{})
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fs.got != nil {
+		t.Errorf("ReadTags should not be used without --groups/--raw, got %+v", fs.got)
+	}
+}
+
+func TestReadCmd_OptionsUnsupported(t *testing.T) {
+	tempDir := testutil.TempDir(t)
+	testFile := filepath.Join(tempDir, "test.jpg")
+	if err := os.WriteFile(testFile, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := createReadCmd(&deps.AppDeps{Files: createTestFilesService(nil)})
+	cmd.SetArgs([]string{"--raw", testFile})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+
+	if err := cmd.Execute(); err == nil {
+		t.Fatal("expected error when the files service cannot honour --raw")
+	}
+}
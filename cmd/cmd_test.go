@@ -2,11 +2,16 @@ package cmd
 
 import (
 	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/Tmunayyer/gocamelpack/config"
 	"github.com/Tmunayyer/gocamelpack/deps"
 	"github.com/Tmunayyer/gocamelpack/files"
 	"github.com/Tmunayyer/gocamelpack/testutil"
@@ -88,7 +93,7 @@ func (t *testFilesService) ReadDirectory(dirPath string) ([]string, error) {
 	var filePaths []string
 	for _, entry := range entries {
 		if !entry.IsDir() {
-			// Return just the entry name, collectSources will join it with the dir path
+			// Return just the entry name, collect will join it with the dir path
 			filePaths = append(filePaths, entry.Name())
 		}
 	}
@@ -189,9 +194,13 @@ func TestReadCmd_ValidFile(t *testing.T) {
 		t.Fatalf("expected no error, got %v", err)
 	}
 
-	// Since the command uses fmt.Println which goes to os.Stdout directly,
-	// we can't capture it with cmd.SetOut(). Just verify no error occurred.
-	// The JSON output visible in test results shows the metadata is working correctly.
+	var got []files.FileMetadata
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("output is not metadata JSON: %v\n%s", err, out.String())
+	}
+	if len(got) != 1 || got[0].Tags["ImageWidth"] != "1920" {
+		t.Errorf("unexpected metadata output: %+v", got)
+	}
 }
 
 func TestReadCmd_InvalidFile(t *testing.T) {
@@ -761,3 +770,456 @@ func TestCopyCmd_Overwrite(t *testing.T) {
 		t.Errorf("file was not overwritten, content: %s", content)
 	}
 }
+
+func TestCopyCmd_DestinationLock(t *testing.T) {
+	srcDir := testutil.TempDir(t)
+	dstDir := testutil.TempDir(t)
+	src := filepath.Join(srcDir, "photo.jpg")
+	if err := os.WriteFile(src, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(args ...string) error {
+		var out bytes.Buffer
+		root := newCLI(&deps.AppDeps{Files: createTestFilesService(nil), Streams: deps.Streams{Out: &out, Err: &out}})
+		root.SetArgs(append([]string{"copy"}, args...))
+		return root.Execute()
+	}
+
+	held, err := files.LockDir(dstDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := run(src, dstDir); files.ErrorCode(err) != files.CodeLocked {
+		t.Fatalf("expected locked error while another run holds the lock, got %v", err)
+	}
+	if err := run("--dry-run", src, dstDir); err != nil {
+		t.Fatalf("dry runs should not need the lock: %v", err)
+	}
+	if err := run("--no-lock", src, dstDir); err != nil {
+		t.Fatalf("--no-lock should bypass the lock: %v", err)
+	}
+	held.Release()
+
+	if err := run("--overwrite", src, dstDir); err != nil {
+		t.Fatalf("copy after release: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dstDir, files.LockName)); !os.IsNotExist(err) {
+		t.Errorf("lock file should be removed after the run, stat err = %v", err)
+	}
+}
+
+func TestCopyCmd_StableWaitKeepsFinishedFiles(t *testing.T) {
+	srcDir := testutil.TempDir(t)
+	dstDir := testutil.TempDir(t)
+	src := filepath.Join(srcDir, "photo.jpg")
+	if err := os.WriteFile(src, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	root := newCLI(&deps.AppDeps{Files: createTestFilesService(nil), Streams: deps.Streams{Out: &out, Err: &out}})
+	root.SetArgs([]string{"copy", "--stable-wait", "10ms", srcDir, dstDir})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("copy: %v\n%s", err, out.String())
+	}
+	if contains(out.String(), "still being written") {
+		t.Errorf("unchanged file reported as still being written:\n%s", out.String())
+	}
+	if _, err := os.Stat(filepath.Join(dstDir, "2025/01/27/15_30.jpg")); err != nil {
+		t.Errorf("finished file should be copied: %v", err)
+	}
+}
+
+func TestCopyCmd_PermissionFlags(t *testing.T) {
+	srcDir := testutil.TempDir(t)
+	dstDir := testutil.TempDir(t)
+	src := filepath.Join(srcDir, "photo.jpg")
+	if err := os.WriteFile(src, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	root := newCLI(&deps.AppDeps{Files: createTestFilesService(nil), Streams: deps.Streams{Out: &out, Err: &out}})
+	root.SetArgs([]string{"copy", "--chmod", "0600", "--dirmode", "0700", src, dstDir})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("copy: %v\n%s", err, out.String())
+	}
+
+	dst := filepath.Join(dstDir, "2025", "01", "27", "15_30.jpg")
+	if info, err := os.Stat(dst); err != nil {
+		t.Fatal(err)
+	} else if info.Mode().Perm() != 0o600 {
+		t.Errorf("file mode = %v, want 0600", info.Mode().Perm())
+	}
+	if info, err := os.Stat(filepath.Join(dstDir, "2025")); err != nil {
+		t.Fatal(err)
+	} else if info.Mode().Perm() != 0o700 {
+		t.Errorf("directory mode = %v, want 0700", info.Mode().Perm())
+	}
+
+	root.SetArgs([]string{"copy", "--chmod", "rw-r--r--", src, dstDir})
+	if err := root.Execute(); err == nil {
+		t.Error("expected invalid --chmod to be rejected")
+	}
+}
+
+func TestCopyCmd_PermissionsFromConfig(t *testing.T) {
+	srcDir := testutil.TempDir(t)
+	dstDir := testutil.TempDir(t)
+	src := filepath.Join(srcDir, "photo.jpg")
+	if err := os.WriteFile(src, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	cfg := &config.Config{FileMode: "0640", DirMode: "0750"}
+	root := newCLI(&deps.AppDeps{Files: createTestFilesService(nil), Config: cfg, Streams: deps.Streams{Out: &out, Err: &out}})
+	// The flag overrides the configured file mode; the directory mode
+	// comes from the config.
+	root.SetArgs([]string{"copy", "--chmod", "0600", src, dstDir})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("copy: %v\n%s", err, out.String())
+	}
+
+	if info, err := os.Stat(filepath.Join(dstDir, "2025", "01", "27", "15_30.jpg")); err != nil {
+		t.Fatal(err)
+	} else if info.Mode().Perm() != 0o600 {
+		t.Errorf("file mode = %v, want 0600", info.Mode().Perm())
+	}
+	if info, err := os.Stat(filepath.Join(dstDir, "2025")); err != nil {
+		t.Fatal(err)
+	} else if info.Mode().Perm() != 0o750 {
+		t.Errorf("directory mode = %v, want 0750", info.Mode().Perm())
+	}
+}
+
+func TestCopyCmd_DestinationsFromConfig(t *testing.T) {
+	tempDir := testutil.TempDir(t)
+	srcDir := filepath.Join(tempDir, "src")
+	dstDir := filepath.Join(tempDir, "dst")
+	if err := os.MkdirAll(srcDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	src := filepath.Join(srcDir, "photo.jpg")
+	if err := os.WriteFile(src, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		dst  config.Destinations
+		want string
+	}{
+		{"denied root", config.Destinations{Deny: []string{dstDir}}, "may not be used as a destination"},
+		{"denied tree", config.Destinations{Deny: []string{filepath.Join(dstDir, "2025") + "/**"}}, "destinations.deny protects"},
+		{"not allowed", config.Destinations{Allow: []string{srcDir}}, "outside the config's destinations.allow"},
+		{"allowed", config.Destinations{Allow: []string{tempDir}, Deny: []string{"/", "~"}}, ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cfg := &config.Config{Destinations: tc.dst}
+			root := newCLI(&deps.AppDeps{Files: createTestFilesService(nil), Config: cfg, Streams: deps.Streams{Out: &out, Err: &out}})
+			root.SetArgs([]string{"copy", "--dry-run", src, dstDir})
+			err := root.Execute()
+			if tc.want == "" {
+				if err != nil {
+					t.Fatalf("copy: %v\n%s", err, out.String())
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("expected %q, got %v\n%s", tc.want, err, out.String())
+			}
+		})
+	}
+}
+
+func TestCopyCmd_PreserveFlagValidation(t *testing.T) {
+	dir := testutil.TempDir(t)
+	for _, args := range [][]string{
+		{"copy", "--preserve", "acl", dir, dir},
+		{"copy", "--preserve", "basic", "--link", "hard", dir, dir},
+		{"copy", "--preserve", "all", "--simulate", dir, dir},
+	} {
+		var out bytes.Buffer
+		root := newCLI(&deps.AppDeps{Files: createTestFilesService(nil), Streams: deps.Streams{Out: &out, Err: &out}})
+		root.SetArgs(args)
+		if err := root.Execute(); err == nil || !contains(err.Error(), "preserve") {
+			t.Errorf("%v: expected a --preserve error, got %v", args, err)
+		}
+	}
+}
+
+func TestCopyCmd_Link(t *testing.T) {
+	tmp := testutil.TempDir(t)
+	src := filepath.Join(tmp, "library", "IMG_0001.jpg")
+	if err := os.MkdirAll(filepath.Dir(src), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(src, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	run := func(args ...string) error {
+		var out bytes.Buffer
+		root := newCLI(&deps.AppDeps{Files: createTestFilesService(nil), Config: &config.Config{}, Streams: deps.Streams{Out: &out, Err: &out}})
+		root.SetArgs(append([]string{"copy"}, args...))
+		return root.Execute()
+	}
+
+	view := filepath.Join(tmp, "by-date")
+	if err := run("--link", "hard", src, view); err != nil {
+		t.Fatalf("copy --link hard: %v", err)
+	}
+	a, _ := os.Stat(src)
+	b, err := os.Stat(filepath.Join(view, "2025/01/27/15_30.jpg"))
+	if err != nil || !os.SameFile(a, b) {
+		t.Errorf("expected a hard link to the source (err %v)", err)
+	}
+
+	if err := run("--link", "copy", src, view); err == nil {
+		t.Error("expected an error for an unknown link mode")
+	}
+	if err := run("--link", "hard", "--archive-id", src, filepath.Join(tmp, "tagged")); err == nil {
+		t.Error("expected --archive-id to be rejected with --link")
+	}
+}
+
+func TestCopyCmd_JSONSummary(t *testing.T) {
+	tmp := testutil.TempDir(t)
+	src := filepath.Join(tmp, "card", "IMG_0001.jpg")
+	if err := os.MkdirAll(filepath.Dir(src), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(src, []byte("12345"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out, errOut bytes.Buffer
+	root := newCLI(&deps.AppDeps{Files: createTestFilesService(nil), Config: &config.Config{}, Streams: deps.Streams{Out: &out, Err: &errOut}})
+	root.SetArgs([]string{"copy", "--output", "json", src, filepath.Join(tmp, "archive")})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("copy: %v", err)
+	}
+
+	var got summaryObject
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("summary is not JSON: %v\n%s", err, out.String())
+	}
+	if got.Summary.Verb != "Copied" || got.Summary.Files != 1 || got.Summary.IO == nil {
+		t.Fatalf("unexpected summary %s", out.String())
+	}
+	if io := got.Summary.IO; io.Files != 1 || io.BytesRead != 5 || io.BytesWritten != 5 || len(io.Slowest) != 1 || io.Slowest[0].Path != src {
+		t.Errorf("unexpected I/O stats %+v", *io)
+	}
+}
+
+func TestCopyCmd_Jobs(t *testing.T) {
+	tmp := testutil.TempDir(t)
+	metadata := map[string]files.FileMetadata{}
+	var sources []string
+	for i := range 6 {
+		src := filepath.Join(tmp, "card", fmt.Sprintf("IMG_%04d.jpg", i))
+		if err := os.MkdirAll(filepath.Dir(src), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(src, make([]byte, (i+1)*1024), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		metadata[src] = files.FileMetadata{Filepath: src, Tags: map[string]string{
+			"CreationDate": fmt.Sprintf("2025:01:27 15:%02d:00-06:00", i),
+			"FileType":     "JPEG",
+		}}
+		sources = append(sources, src)
+	}
+
+	var out bytes.Buffer
+	dst := filepath.Join(tmp, "archive")
+	root := newCLI(&deps.AppDeps{Files: createTestFilesService(metadata), Config: &config.Config{}, Streams: deps.Streams{Out: &out, Err: &out}})
+	root.SetArgs([]string{"copy", "--jobs", "3", filepath.Join(tmp, "card"), dst})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("copy --jobs 3: %v\n%s", err, out.String())
+	}
+	for i := range sources {
+		if _, err := os.Stat(filepath.Join(dst, "2025/01/27", fmt.Sprintf("15_%02d.jpg", i))); err != nil {
+			t.Errorf("file %d not copied: %v", i, err)
+		}
+	}
+	if !contains(out.String(), "Copied 6 file(s)") {
+		t.Errorf("unexpected summary %q", out.String())
+	}
+
+	root.SetArgs([]string{"copy", "--jobs", "2", "--schedule", "random", filepath.Join(tmp, "card"), filepath.Join(tmp, "other")})
+	if err := root.Execute(); err == nil {
+		t.Error("expected an error for an unknown schedule")
+	}
+}
+
+func TestTransferCmd_Simulate(t *testing.T) {
+	tmp := filepath.Join(testutil.TempDir(t), "simulate")
+	card := filepath.Join(tmp, "card")
+	metadata := writeCard(t, card, 3)
+	run := func(args ...string) (string, error) {
+		var out bytes.Buffer
+		root := newCLI(&deps.AppDeps{Files: createTestFilesService(metadata), Config: &config.Config{}, Streams: deps.Streams{Out: &out, Err: &out}})
+		root.SetArgs(args)
+		err := root.Execute()
+		return out.String(), err
+	}
+
+	dst := filepath.Join(tmp, "archive")
+	for _, args := range [][]string{
+		{"copy", "--simulate", card, dst},
+		{"copy", "--simulate", "--atomic", "--chunk-size", "2", card, dst},
+		{"move", "--simulate", card, dst},
+		{"move", "--simulate", "--atomic", card, dst},
+	} {
+		out, err := run(args...)
+		if err != nil {
+			t.Fatalf("%v: %v\n%s", args, err, out)
+		}
+		if !contains(out, "3 file(s)") || !contains(out, "nothing was written") {
+			t.Errorf("%v: unexpected output %q", args, out)
+		}
+	}
+	if _, err := os.Stat(dst); !os.IsNotExist(err) {
+		t.Errorf("simulation created the destination: %v", err)
+	}
+	if entries, _ := os.ReadDir(card); len(entries) != 3 {
+		t.Errorf("simulated move changed the source: %d file(s) left", len(entries))
+	}
+
+	if _, err := run("copy", "--simulate", "--archive-id", card, dst); err == nil {
+		t.Error("expected --simulate to reject --archive-id")
+	}
+}
+
+func TestTransferCmd_SimulateFailure(t *testing.T) {
+	tmp := filepath.Join(testutil.TempDir(t), "simulate-failure")
+	card := filepath.Join(tmp, "card")
+	metadata := writeCard(t, card, 3)
+	run := func(args ...string) (string, error) {
+		var out bytes.Buffer
+		root := newCLI(&deps.AppDeps{Files: createTestFilesService(metadata), Config: &config.Config{}, Streams: deps.Streams{Out: &out, Err: &out}})
+		root.SetArgs(args)
+		err := root.Execute()
+		return out.String(), err
+	}
+
+	dst := filepath.Join(tmp, "archive")
+	for _, args := range [][]string{
+		{"copy", "--simulate", "--simulate-failure=after:2", card, dst},
+		{"move", "--simulate", "--atomic", "--simulate-failure=after:2", card, dst},
+	} {
+		out, err := run(args...)
+		if !errors.Is(err, files.ErrInjected) {
+			t.Errorf("%v: err = %v, want the injected failure\n%s", args, err, out)
+		}
+	}
+	if _, err := os.Stat(dst); !os.IsNotExist(err) {
+		t.Errorf("simulation created the destination: %v", err)
+	}
+
+	if _, err := run("copy", "--simulate-failure=after:1", card, dst); err == nil {
+		t.Error("expected --simulate-failure to require --simulate")
+	}
+	if _, err := run("copy", "--simulate", "--simulate-failure=before:1", card, dst); err == nil {
+		t.Error("expected an error for an invalid spec")
+	}
+}
+
+func TestCopyCmd_Report(t *testing.T) {
+	tmp := filepath.Join(testutil.TempDir(t), "report")
+	card := filepath.Join(tmp, "card")
+	metadata := writeCard(t, card, 2)
+	run := func(fs files.FilesService, args ...string) (string, error) {
+		var out bytes.Buffer
+		root := newCLI(&deps.AppDeps{Files: fs, Config: &config.Config{}, Streams: deps.Streams{Out: &out, Err: &out}})
+		root.SetArgs(args)
+		err := root.Execute()
+		return out.String(), err
+	}
+
+	page := filepath.Join(tmp, "report.html")
+	if out, err := run(createTestFilesService(metadata), "copy", "--report", page, card, filepath.Join(tmp, "archive")); err != nil {
+		t.Fatalf("copy --report: %v\n%s", err, out)
+	}
+	html, err := os.ReadFile(page)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"complete", "IMG_0001.jpg", "15_01.jpg", "2025/01/27"} {
+		if !contains(string(html), want) {
+			t.Errorf("report lacks %q", want)
+		}
+	}
+
+	failing := &failingCopies{FilesService: createTestFilesService(metadata), fail: filepath.Join(card, "IMG_0001.jpg")}
+	if _, err := run(failing, "copy", "--atomic", "--report", page, card, filepath.Join(tmp, "failed")); err == nil {
+		t.Fatal("expected the copy to fail")
+	}
+	html, _ = os.ReadFile(page)
+	if !contains(string(html), "disk on fire") {
+		t.Error("report of a failed run should list its error")
+	}
+
+	if _, err := run(createTestFilesService(metadata), "copy", "--dry-run", "--report", page, card, tmp); err == nil {
+		t.Error("expected --report to be rejected with --dry-run")
+	}
+}
+
+func TestCopyCmd_ReportCSV(t *testing.T) {
+	tmp := filepath.Join(testutil.TempDir(t), "report-csv")
+	card := filepath.Join(tmp, "card")
+	metadata := writeCard(t, card, 2)
+	run := func(fs files.FilesService, args ...string) (string, error) {
+		var out bytes.Buffer
+		root := newCLI(&deps.AppDeps{Files: fs, Config: &config.Config{}, Streams: deps.Streams{Out: &out, Err: &out}})
+		root.SetArgs(args)
+		err := root.Execute()
+		return out.String(), err
+	}
+	rows := func(path string) [][]string {
+		t.Helper()
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		records, err := csv.NewReader(f).ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return records
+	}
+
+	sheet := filepath.Join(tmp, "plan.csv")
+	archive := filepath.Join(tmp, "archive")
+	if out, err := run(createTestFilesService(metadata), "copy", "--dry-run", "--report-csv", sheet, card, archive); err != nil {
+		t.Fatalf("copy --dry-run --report-csv: %v\n%s", err, out)
+	}
+	got := rows(sheet)
+	if len(got) != 3 || got[1][5] != "planned" || got[1][4] != "CreationDate" || got[1][3] == "" {
+		t.Fatalf("unexpected plan %v", got)
+	}
+
+	if out, err := run(createTestFilesService(metadata), "copy", "--report-csv", sheet, card, archive); err != nil {
+		t.Fatalf("copy --report-csv: %v\n%s", err, out)
+	}
+	for _, r := range rows(sheet)[1:] {
+		if r[5] != "copied" || !contains(r[1], "2025/01/27") || r[2] == "0" {
+			t.Errorf("unexpected row %v", r)
+		}
+	}
+
+	failing := &failingCopies{FilesService: createTestFilesService(metadata), fail: filepath.Join(card, "IMG_0001.jpg")}
+	if _, err := run(failing, "copy", "--atomic", "--report-csv", sheet, card, filepath.Join(tmp, "failed")); err == nil {
+		t.Fatal("expected the copy to fail")
+	}
+	for _, r := range rows(sheet)[1:] {
+		if r[5] != "not transferred" {
+			t.Errorf("rolled-back file reported as %q", r[5])
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+func TestCopyCmd_Thumbnails(t *testing.T) {
+	for _, atomic := range []bool{false, true} {
+		name := "non-atomic"
+		if atomic {
+			name = "atomic"
+		}
+		t.Run(name, func(t *testing.T) {
+			tempDir := testutil.TempDir(t)
+			srcDir := filepath.Join(tempDir, "src")
+			dstDir := filepath.Join(tempDir, "dst")
+			thumbDir := filepath.Join(tempDir, "thumbs")
+			if err := os.MkdirAll(srcDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+
+			var buf bytes.Buffer
+			if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 512, 256))); err != nil {
+				t.Fatal(err)
+			}
+			srcFile := filepath.Join(srcDir, "photo.png")
+			if err := os.WriteFile(srcFile, buf.Bytes(), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			dep := &deps.AppDeps{Files: createTestFilesService(nil)}
+			cmd := createCopyCmd(dep)
+			args := []string{"--thumbnails", thumbDir, "--thumbnail-size", "32", srcFile, dstDir}
+			if atomic {
+				args = append([]string{"--atomic"}, args...)
+			}
+			cmd.SetArgs(args)
+
+			var out bytes.Buffer
+			cmd.SetOut(&out)
+			cmd.SetErr(&out)
+
+			if err := cmd.Execute(); err != nil {
+				t.Fatalf("unexpected error: %v\nOutput: %s", err, out.String())
+			}
+
+			thumb := filepath.Join(thumbDir, "2025", "01", "27", "15_30.png.jpg")
+			if _, err := os.Stat(thumb); err != nil {
+				t.Errorf("expected thumbnail at %s: %v", thumb, err)
+			}
+		})
+	}
+}
+
+func TestCopyCmd_ThumbnailsForRuleRoots(t *testing.T) {
+	tempDir := testutil.TempDir(t)
+	srcFile := filepath.Join(tempDir, "src", "photo.png")
+	dstDir := filepath.Join(tempDir, "dst")
+	bulk := filepath.Join(tempDir, "bulk")
+	thumbDir := filepath.Join(tempDir, "thumbs")
+	if err := os.MkdirAll(filepath.Dir(srcFile), 0755); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 64, 64))); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(srcFile, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfgPath := filepath.Join(tempDir, "config.json")
+	cfg := `{"rules": [{"name": "pngs", "match": {"ext": ["png"]}, "template": "{year}/{orig}", "root": "` + bulk + `"}]}`
+	if err := os.WriteFile(cfgPath, []byte(cfg), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	root := newCLI(&deps.AppDeps{Files: createTestFilesService(nil), Streams: deps.Streams{Out: &out, Err: &out}})
+	root.SetArgs([]string{"--config", cfgPath, "copy", "--thumbnails", thumbDir, srcFile, dstDir})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v\nOutput: %s", err, out.String())
+	}
+
+	if _, err := os.Stat(filepath.Join(bulk, "2025", "photo.png")); err != nil {
+		t.Fatalf("rule root not used: %v", err)
+	}
+	thumb := filepath.Join(ruleRootThumbs(thumbDir, bulk), "2025", "photo.png.jpg")
+	if _, err := os.Stat(thumb); err != nil {
+		t.Errorf("expected thumbnail at %s: %v\nOutput: %s", thumb, err, out.String())
+	}
+}
+
+func TestCopyCmd_ThumbnailsSkippedOnDryRun(t *testing.T) {
+	tempDir := testutil.TempDir(t)
+	srcFile := filepath.Join(tempDir, "photo.png")
+	thumbDir := filepath.Join(tempDir, "thumbs")
+	if err := os.WriteFile(srcFile, []byte("not really a png"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	dep := &deps.AppDeps{Files: createTestFilesService(nil)}
+	cmd := createCopyCmd(dep)
+	cmd.SetArgs([]string{"--dry-run", "--thumbnails", thumbDir, srcFile, filepath.Join(tempDir, "dst")})
+
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat(thumbDir); !os.IsNotExist(err) {
+		t.Errorf("dry-run should not create thumbnails, stat err = %v", err)
+	}
+}
@@ -11,7 +11,7 @@ import (
 	"github.com/Tmunayyer/gocamelpack/testutil"
 )
 
-func TestCollectSourcesWithProgress_SingleFile(t *testing.T) {
+func TestSourceWalkCollect_SingleFile(t *testing.T) {
 	tempDir := testutil.TempDir(t)
 	testFile := filepath.Join(tempDir, "test.jpg")
 	if err := os.WriteFile(testFile, []byte("test content"), 0644); err != nil {
@@ -26,9 +26,9 @@ func TestCollectSourcesWithProgress_SingleFile(t *testing.T) {
 	reporter.SetBarChar('=')
 	reporter.SetEmptyChar('-')
 
-	sources, err := collectSourcesWithProgress(filesService, testFile, reporter)
+	sources, err := (*sourceWalk)(nil).collect(filesService, testFile, reporter)
 	if err != nil {
-		t.Fatalf("collectSourcesWithProgress failed: %v", err)
+		t.Fatalf("collect failed: %v", err)
 	}
 
 	// Verify correct source collected
@@ -51,7 +51,7 @@ func TestCollectSourcesWithProgress_SingleFile(t *testing.T) {
 	}
 }
 
-func TestCollectSourcesWithProgress_Directory(t *testing.T) {
+func TestSourceWalkCollect_Directory(t *testing.T) {
 	tempDir := testutil.TempDir(t)
 	srcDir := filepath.Join(tempDir, "src")
 	if err := os.MkdirAll(srcDir, 0755); err != nil {
@@ -75,9 +75,9 @@ func TestCollectSourcesWithProgress_Directory(t *testing.T) {
 	reporter.SetBarChar('=')
 	reporter.SetEmptyChar('-')
 
-	sources, err := collectSourcesWithProgress(filesService, srcDir, reporter)
+	sources, err := (*sourceWalk)(nil).collect(filesService, srcDir, reporter)
 	if err != nil {
-		t.Fatalf("collectSourcesWithProgress failed: %v", err)
+		t.Fatalf("collect failed: %v", err)
 	}
 
 	// Verify correct sources collected
@@ -91,8 +91,8 @@ func TestCollectSourcesWithProgress_Directory(t *testing.T) {
 		t.Error("Expected 'Reading directory' message in progress output")
 	}
 
-	if !strings.Contains(output, "Collecting files from directory") {
-		t.Error("Expected 'Collecting files from directory' message in progress output")
+	if !strings.Contains(output, "Collecting files from") {
+		t.Error("Expected 'Collecting files from' message in progress output")
 	}
 
 	if !strings.Contains(output, "✓") {
@@ -105,7 +105,7 @@ func TestCollectSourcesWithProgress_Directory(t *testing.T) {
 	}
 }
 
-func TestCollectSourcesWithProgress_EmptyDirectory(t *testing.T) {
+func TestSourceWalkCollect_EmptyDirectory(t *testing.T) {
 	tempDir := testutil.TempDir(t)
 	srcDir := filepath.Join(tempDir, "empty")
 	if err := os.MkdirAll(srcDir, 0755); err != nil {
@@ -118,9 +118,9 @@ func TestCollectSourcesWithProgress_EmptyDirectory(t *testing.T) {
 	buf := &bytes.Buffer{}
 	reporter := progress.NewProgressBar(buf, 20)
 
-	sources, err := collectSourcesWithProgress(filesService, srcDir, reporter)
+	sources, err := (*sourceWalk)(nil).collect(filesService, srcDir, reporter)
 	if err != nil {
-		t.Fatalf("collectSourcesWithProgress failed: %v", err)
+		t.Fatalf("collect failed: %v", err)
 	}
 
 	// Verify no sources collected
@@ -139,32 +139,7 @@ func TestCollectSourcesWithProgress_EmptyDirectory(t *testing.T) {
 	}
 }
 
-func TestCollectSources_BackwardCompatibility(t *testing.T) {
-	tempDir := testutil.TempDir(t)
-	testFile := filepath.Join(tempDir, "test.jpg")
-	if err := os.WriteFile(testFile, []byte("test content"), 0644); err != nil {
-		t.Fatal(err)
-	}
-
-	filesService := createTestFilesService(nil)
-	
-	// Test that original function still works (uses NoOpReporter internally)
-	sources, err := collectSources(filesService, testFile)
-	if err != nil {
-		t.Fatalf("collectSources failed: %v", err)
-	}
-
-	// Verify correct source collected
-	if len(sources) != 1 {
-		t.Fatalf("Expected 1 source, got %d", len(sources))
-	}
-
-	if !strings.Contains(sources[0], "test.jpg") {
-		t.Errorf("Expected source to contain test.jpg, got %s", sources[0])
-	}
-}
-
-func TestCollectSourcesWithProgress_NoOpReporter(t *testing.T) {
+func TestSourceWalkCollect_NoOpReporter(t *testing.T) {
 	tempDir := testutil.TempDir(t)
 	testFile := filepath.Join(tempDir, "test.jpg")
 	if err := os.WriteFile(testFile, []byte("test content"), 0644); err != nil {
@@ -175,9 +150,9 @@ func TestCollectSourcesWithProgress_NoOpReporter(t *testing.T) {
 	
 	// Test with NoOpReporter - should work without issues
 	reporter := progress.NewNoOpReporter()
-	sources, err := collectSourcesWithProgress(filesService, testFile, reporter)
+	sources, err := (*sourceWalk)(nil).collect(filesService, testFile, reporter)
 	if err != nil {
-		t.Fatalf("collectSourcesWithProgress with NoOpReporter failed: %v", err)
+		t.Fatalf("collect with NoOpReporter failed: %v", err)
 	}
 
 	// Verify correct source collected
@@ -191,16 +166,16 @@ func TestCollectSourcesWithProgress_NoOpReporter(t *testing.T) {
 	}
 }
 
-func TestCollectSourcesWithProgress_InvalidPath(t *testing.T) {
+func TestSourceWalkCollect_InvalidPath(t *testing.T) {
 	filesService := createTestFilesService(nil)
 	
 	buf := &bytes.Buffer{}
 	reporter := progress.NewProgressBar(buf, 20)
 
 	// Test with non-existent path
-	_, err := collectSourcesWithProgress(filesService, "/nonexistent/path", reporter)
+	_, err := (*sourceWalk)(nil).collect(filesService, "/nonexistent/path", reporter)
 	if err == nil {
-		t.Error("Expected collectSourcesWithProgress to fail with invalid path")
+		t.Error("Expected collect to fail with invalid path")
 	}
 
 	// Error should occur before any progress reporting
@@ -209,4 +184,47 @@ func TestCollectSourcesWithProgress_InvalidPath(t *testing.T) {
 	if strings.Contains(output, "✓") {
 		t.Error("Should not show completion checkmark for failed operation")
 	}
-}
\ No newline at end of file
+}
+func TestSourceWalkCollect_IgnoreFiles(t *testing.T) {
+	card := filepath.Join(testutil.TempDir(t), "card")
+	for _, name := range []string{"a.jpg", "b.jpg", "c.tmp", "DCIM/d.jpg", "DCIM/e.jpg", "@eaDir/f.jpg"} {
+		path := filepath.Join(card, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ignores := map[string]string{
+		".gocamelpackignore":      "*.tmp\nb.jpg\n@eaDir/\n",
+		"DCIM/.gocamelpackignore": "e.jpg\n",
+	}
+	for name, rules := range ignores {
+		if err := os.WriteFile(filepath.Join(card, name), []byte(rules), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		walk *sourceWalk
+		want []string
+	}{
+		{nil, []string{"a.jpg"}},
+		{&sourceWalk{}, []string{"a.jpg", "DCIM/d.jpg"}},
+	}
+	for _, tc := range tests {
+		got, err := tc.walk.collect(createTestFilesService(nil), card, progress.NewNoOpReporter())
+		if err != nil {
+			t.Fatalf("collect(%v): %v", tc.walk, err)
+		}
+		var rel []string
+		for _, path := range got {
+			r, _ := filepath.Rel(card, path)
+			rel = append(rel, filepath.ToSlash(r))
+		}
+		if strings.Join(rel, ",") != strings.Join(tc.want, ",") {
+			t.Errorf("collect(%v) = %v, want %v", tc.walk, rel, tc.want)
+		}
+	}
+}
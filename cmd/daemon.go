@@ -0,0 +1,415 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/output"
+	"github.com/Tmunayyer/gocamelpack/progress"
+	"github.com/Tmunayyer/gocamelpack/queue"
+	"github.com/spf13/cobra"
+)
+
+// defaultPollInterval is how often an idle daemon re-reads the queue for
+// jobs submitted by other processes.
+const defaultPollInterval = 2 * time.Second
+
+func createDaemonCmd(d *deps.AppDeps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "daemon",
+		Short: "Run queued copy and move jobs in the background",
+		Long: `The daemon works through a persistent job queue, running up to --concurrency
+jobs at a time; jobs writing into the same destination never run together.
+Jobs are added with "daemon submit", from any process, while the daemon runs
+or not, and by schedules created with "schedule". While it runs, the daemon
+also takes jobs posted to its HTTP API (--listen, loopback only) and queues
+a move job for each file dropped into a watch folder (--watch
+<folder>=<destination>) once the file has stopped changing.
+
+The queue survives restarts: jobs interrupted by a restart are run again,
+and how far they had got is kept for "status". Each job's output is written
+to logs/<job id>.log and its progress to progress/<job id>.json next to the
+queue, unless it was submitted with its own --progress-file.
+
+The HTTP API has no authentication:
+  POST /jobs        {"command": "copy", "source": "/abs", "destination": "/abs", "flags": ["--atomic"]}
+  GET  /jobs        lists the jobs
+  GET  /jobs/{id}   shows one job`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			q, err := openQueue(cmd)
+			if err != nil {
+				return err
+			}
+			concurrency, _ := cmd.Flags().GetInt("concurrency")
+			if concurrency < 1 {
+				return fmt.Errorf("--concurrency must be at least 1")
+			}
+			poll, _ := cmd.Flags().GetDuration("poll")
+			if poll <= 0 {
+				return fmt.Errorf("--poll must be positive")
+			}
+			drain, _ := cmd.Flags().GetBool("drain")
+			listen, _ := cmd.Flags().GetString("listen")
+			watchArgs, _ := cmd.Flags().GetStringArray("watch")
+			if drain && (listen != "" || len(watchArgs) > 0) {
+				return fmt.Errorf("--drain cannot be combined with --listen or --watch")
+			}
+			var watches []*watchFolder
+			for _, v := range watchArgs {
+				w, err := parseWatch(v)
+				if err != nil {
+					return err
+				}
+				watches = append(watches, w)
+			}
+
+			// Jobs share the daemon's config rather than each reading it.
+			if _, err := loadConfig(cmd, d); err != nil {
+				return err
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			if listen != "" {
+				ln, err := listenLoopback(listen)
+				if err != nil {
+					return err
+				}
+				srv := &http.Server{Handler: jobAPI(d, q), ReadHeaderTimeout: 10 * time.Second}
+				go srv.Serve(ln)
+				defer srv.Close()
+				fmt.Fprintf(cmd.OutOrStdout(), "accepting jobs on http://%s/jobs\n", ln.Addr())
+			}
+			return runDaemon(ctx, cmd, d, q, concurrency, poll, drain, watches)
+		},
+	}
+
+	cmd.PersistentFlags().String("queue", "", "Queue file (default $XDG_CONFIG_HOME/gocamelpack/queue.json)")
+	cmd.Flags().Int("concurrency", 1, "Maximum number of jobs run at the same time")
+	cmd.Flags().Duration("poll", defaultPollInterval, "How often to check the queue for new jobs")
+	cmd.Flags().Bool("drain", false, "Exit once the queue has no pending jobs instead of waiting for more")
+	cmd.Flags().String("listen", "", "Also accept jobs over HTTP on this loopback address, e.g. 127.0.0.1:8765")
+	cmd.Flags().StringArray("watch", nil, "Queue a move job for each file dropped into a folder, as <folder>=<destination> (repeatable)")
+
+	cmd.AddCommand(createDaemonSubmitCmd(d))
+	cmd.AddCommand(createDaemonJobsCmd())
+	return cmd
+}
+
+func createDaemonSubmitCmd(d *deps.AppDeps) *cobra.Command {
+	return &cobra.Command{
+		Use:   "submit (copy|move) [source] [destination] [-- flags]",
+		Short: "Add a copy or move job to the daemon's queue",
+		Long: `Flags after "--" are passed to the copy or move command when the job runs,
+e.g. "daemon submit copy /mnt/card /archive -- --atomic --bursts". They are
+checked now so typos are reported at submission. Paths are made absolute.`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			args, _ = splitAtDash(cmd, args)
+			return cobra.ExactArgs(3)(cmd, args)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			args, flags := splitAtDash(cmd, args)
+			job, err := jobFromArgs(d, args, flags)
+			if err != nil {
+				return err
+			}
+
+			q, err := openQueue(cmd)
+			if err != nil {
+				return err
+			}
+			job, err = q.Submit(job)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), job.ID)
+			return nil
+		},
+	}
+}
+
+func createDaemonJobsCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "jobs",
+		Short: "List the jobs in the daemon's queue",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			q, err := openQueue(cmd)
+			if err != nil {
+				return err
+			}
+			jobs, err := q.Jobs()
+			if err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			if outputFormat(cmd) == "json" {
+				enc := json.NewEncoder(out)
+				enc.SetIndent("", "  ")
+				return enc.Encode(jobs)
+			}
+			if len(jobs) == 0 {
+				fmt.Fprintln(out, "No jobs queued.")
+				return nil
+			}
+			tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(tw, "ID\tSTATE\tCOMMAND\tSOURCE\tDESTINATION\tERROR")
+			for _, j := range jobs {
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", j.ID, j.State, j.Command, j.Source, j.Destination, j.Error)
+			}
+			return tw.Flush()
+		},
+	}
+}
+
+// openQueue opens the queue named by --queue or the default location.
+func openQueue(cmd *cobra.Command) (*queue.Queue, error) {
+	path, _ := cmd.Flags().GetString("queue")
+	if path == "" {
+		var err error
+		if path, err = queue.DefaultPath(); err != nil {
+			return nil, fmt.Errorf("locating queue: %w", err)
+		}
+	}
+	return queue.Open(path), nil
+}
+
+// splitAtDash separates positional arguments from those after "--".
+func splitAtDash(cmd *cobra.Command, args []string) (positional, rest []string) {
+	if n := cmd.ArgsLenAtDash(); n >= 0 {
+		return args[:n], args[n:]
+	}
+	return args, nil
+}
+
+// jobFromArgs builds a job from "(copy|move) source destination" and the
+// flags given after "--", checking it as far as possible without running it.
+func jobFromArgs(d *deps.AppDeps, args, flags []string) (queue.Job, error) {
+	job := queue.Job{Command: args[0], Flags: flags}
+	var err error
+	if job.Source, err = filepath.Abs(args[1]); err != nil {
+		return job, err
+	}
+	if job.Destination, err = filepath.Abs(args[2]); err != nil {
+		return job, err
+	}
+	if err := job.Validate(); err != nil {
+		return job, err
+	}
+	return job, checkJobFlags(d, job)
+}
+
+// checkJobFlags parses a job's flags with the command it will run, without
+// running it.
+func checkJobFlags(d *deps.AppDeps, job queue.Job) error {
+	var c *cobra.Command
+	if job.Command == "move" {
+		c = createMoveCmd(d)
+	} else {
+		c = createCopyCmd(d)
+	}
+	if err := c.ParseFlags(job.Flags); err != nil {
+		return fmt.Errorf("invalid %s flags: %w", job.Command, err)
+	}
+	if extra := c.Flags().Args(); len(extra) > 0 {
+		return fmt.Errorf("unexpected arguments after --: %v", extra)
+	}
+	return nil
+}
+
+// jobResult is sent by a finished job's goroutine.
+type jobResult struct {
+	job queue.Job
+	err error
+}
+
+// runDaemon claims and runs jobs until ctx is cancelled or, with drain,
+// until nothing is left to run. Running jobs are always waited for. The
+// watch folders are scanned on every poll.
+func runDaemon(ctx context.Context, cmd *cobra.Command, d *deps.AppDeps, q *queue.Queue, concurrency int, poll time.Duration, drain bool, watches []*watchFolder) error {
+	p := output.New(cmd.OutOrStdout())
+	progressDir := jobProgressDir(q)
+	if n, err := q.RecoverWith(func(j queue.Job) *queue.Progress { return lastProgress(j, progressDir) }); err != nil {
+		return err
+	} else if n > 0 {
+		p.Warn("requeued %d job(s) interrupted by a previous shutdown", n)
+	}
+	logDir := filepath.Join(filepath.Dir(q.Path()), "logs")
+
+	running := map[string]queue.Job{}
+	// The heartbeat tells "status" the daemon is alive; it is best-effort
+	// and never stops the daemon.
+	host, _ := os.Hostname()
+	beat := queue.Daemon{PID: os.Getpid(), Host: host, Started: time.Now(), Poll: poll}
+	heartbeat := func() {
+		beat.Running = beat.Running[:0]
+		for id := range running {
+			beat.Running = append(beat.Running, id)
+		}
+		sort.Strings(beat.Running)
+		q.Heartbeat(beat)
+	}
+	defer q.Stopped()
+	busy := func(j queue.Job) bool {
+		for _, r := range running {
+			if r.Destination == j.Destination {
+				return true
+			}
+		}
+		return false
+	}
+	done := make(chan jobResult)
+	finish := func(r jobResult) error {
+		delete(running, r.job.ID)
+		if r.err != nil {
+			p.Error("job %s failed: %v", r.job.ID, r.err)
+		} else {
+			p.Success("job %s done", r.job.ID)
+		}
+		return q.Finish(r.job.ID, r.err)
+	}
+
+	ticker := time.NewTicker(poll)
+	defer ticker.Stop()
+	for {
+		runs, err := q.RunDue(time.Now())
+		if err != nil {
+			return err
+		}
+		for _, r := range runs {
+			if r.Skipped != "" {
+				p.Warn("scheduled run skipped: %s", r.Skipped)
+			}
+		}
+		// A watch folder that cannot be read is reported, not fatal.
+		for _, w := range watches {
+			queued, err := w.scan(q)
+			for _, j := range queued {
+				fmt.Fprintf(cmd.OutOrStdout(), "job %s queued from watch folder %s\n", j.ID, w.dir)
+			}
+			if err != nil {
+				p.Warn("watch folder %s: %v", w.dir, err)
+			}
+		}
+
+		for len(running) < concurrency {
+			job, ok, err := q.Claim(busy)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				break
+			}
+			running[job.ID] = job
+			fmt.Fprintf(cmd.OutOrStdout(), "job %s: %s %s %s %s\n", job.ID, job.Command, job.Source, output.New(cmd.OutOrStdout()).Arrow(), job.Destination)
+			go func() { done <- jobResult{job, runJob(d, job, logDir, progressDir)} }()
+		}
+		if drain && len(running) == 0 {
+			return nil
+		}
+		heartbeat()
+
+		select {
+		case r := <-done:
+			if err := finish(r); err != nil {
+				return err
+			}
+		case <-ticker.C:
+		case <-ctx.Done():
+			if len(running) > 0 {
+				p.Warn("waiting for %d running job(s) to finish", len(running))
+			}
+			for len(running) > 0 {
+				if err := finish(<-done); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+}
+
+// runJob runs one job through a fresh command tree, with its output going
+// to the job's log file and its progress to its progress file.
+func runJob(d *deps.AppDeps, job queue.Job, logDir, progressDir string) error {
+	if err := os.MkdirAll(logDir, 0o755); err != nil {
+		return err
+	}
+	args := job.Args()
+	if path, own := jobProgressFile(job, progressDir); !own {
+		if err := os.MkdirAll(progressDir, 0o755); err != nil {
+			return err
+		}
+		args = append([]string{args[0], "--progress-file", path}, args[1:]...)
+	}
+	log, err := os.Create(filepath.Join(logDir, job.ID+".log"))
+	if err != nil {
+		return err
+	}
+	defer log.Close()
+
+	root := newCLI(&deps.AppDeps{
+		Files:   d.Files,
+		Config:  d.Config,
+		Streams: deps.Streams{Out: log, Err: log},
+	})
+	root.SetArgs(args)
+	err = root.Execute()
+	if err != nil {
+		fmt.Fprintf(log, "error: %v\n", err)
+	}
+	return err
+}
+
+// jobProgressDir is where the daemon keeps the progress files of jobs.
+func jobProgressDir(q *queue.Queue) string {
+	return filepath.Join(filepath.Dir(q.Path()), "progress")
+}
+
+// jobProgressFile returns where job keeps its progress snapshot: the
+// --progress-file it was submitted with (own is true), else its file in
+// dir.
+func jobProgressFile(job queue.Job, dir string) (path string, own bool) {
+	for i, f := range job.Flags {
+		if v, ok := strings.CutPrefix(f, "--progress-file="); ok {
+			return v, true
+		}
+		if f == "--progress-file" && i+1 < len(job.Flags) {
+			return job.Flags[i+1], true
+		}
+	}
+	return filepath.Join(dir, job.ID+".json"), false
+}
+
+// readJobProgress returns the last progress snapshot of job, or nil.
+func readJobProgress(job queue.Job, dir string) *progress.Status {
+	path, _ := jobProgressFile(job, dir)
+	st, err := progress.ReadStatus(path)
+	if err != nil {
+		return nil
+	}
+	return &st
+}
+
+// lastProgress is how far an interrupted job had got, for the queue.
+func lastProgress(job queue.Job, dir string) *queue.Progress {
+	st := readJobProgress(job, dir)
+	if st == nil {
+		return nil
+	}
+	return &queue.Progress{Current: st.Current, Total: st.Total, BytesDone: st.BytesDone, BytesTotal: st.BytesTotal, UpdatedAt: st.UpdatedAt}
+}
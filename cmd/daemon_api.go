@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"path/filepath"
+
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/queue"
+)
+
+// jobRequest is the body of a POST /jobs.
+type jobRequest struct {
+	Command     string   `json:"command"`
+	Source      string   `json:"source"`
+	Destination string   `json:"destination"`
+	Flags       []string `json:"flags,omitempty"`
+}
+
+// jobAPI serves the daemon's queue over HTTP (--listen): POST /jobs
+// submits a job as "daemon submit" does, GET /jobs lists the jobs and
+// GET /jobs/{id} shows one. There is no authentication, so it only
+// listens on loopback addresses.
+func jobAPI(d *deps.AppDeps, q *queue.Queue) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /jobs", func(w http.ResponseWriter, r *http.Request) {
+		var req jobRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeAPIError(w, http.StatusBadRequest, fmt.Errorf("decoding job: %w", err))
+			return
+		}
+		// The daemon's working directory means nothing to the client.
+		if !filepath.IsAbs(req.Source) || !filepath.IsAbs(req.Destination) {
+			writeAPIError(w, http.StatusBadRequest, errors.New("source and destination must be absolute paths"))
+			return
+		}
+		job, err := jobFromArgs(d, []string{req.Command, req.Source, req.Destination}, req.Flags)
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, err)
+			return
+		}
+		if job, err = q.Submit(job); err != nil {
+			writeAPIError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeAPIJSON(w, http.StatusCreated, job)
+	})
+	mux.HandleFunc("GET /jobs", func(w http.ResponseWriter, r *http.Request) {
+		jobs, err := q.Jobs()
+		if err != nil {
+			writeAPIError(w, http.StatusInternalServerError, err)
+			return
+		}
+		if jobs == nil {
+			jobs = []queue.Job{}
+		}
+		writeAPIJSON(w, http.StatusOK, jobs)
+	})
+	mux.HandleFunc("GET /jobs/{id}", func(w http.ResponseWriter, r *http.Request) {
+		jobs, err := q.Jobs()
+		if err != nil {
+			writeAPIError(w, http.StatusInternalServerError, err)
+			return
+		}
+		for _, j := range jobs {
+			if j.ID == r.PathValue("id") {
+				writeAPIJSON(w, http.StatusOK, j)
+				return
+			}
+		}
+		writeAPIError(w, http.StatusNotFound, fmt.Errorf("no job %s", r.PathValue("id")))
+	})
+	return mux
+}
+
+func writeAPIJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeAPIError(w http.ResponseWriter, status int, err error) {
+	writeAPIJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// listenLoopback listens on addr, which must name a loopback host, e.g.
+// 127.0.0.1:8765 or localhost:8765.
+func listenLoopback(addr string) (net.Listener, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("--listen %q: %w", addr, err)
+	}
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return nil, fmt.Errorf("--listen %q: only loopback addresses are served, since the API has no authentication", addr)
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("--listen: %w", err)
+	}
+	return ln, nil
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/config"
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/queue"
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+func TestJobAPI(t *testing.T) {
+	q := queue.Open(filepath.Join(testutil.TempDir(t), "queue.json"))
+	d := &deps.AppDeps{Files: createTestFilesService(nil), Config: &config.Config{}}
+	srv := httptest.NewServer(jobAPI(d, q))
+	defer srv.Close()
+
+	post := func(body string) *http.Response {
+		t.Helper()
+		resp, err := http.Post(srv.URL+"/jobs", "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	resp := post(`{"command":"copy","source":"/mnt/card","destination":"/archive","flags":["--atomic"]}`)
+	var job queue.Job
+	json.NewDecoder(resp.Body).Decode(&job)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated || job.ID == "" || job.State != queue.Pending {
+		t.Fatalf("POST /jobs = %d %+v, want 201 and a pending job", resp.StatusCode, job)
+	}
+
+	for _, body := range []string{
+		`{"command":"rename","source":"/a","destination":"/b"}`,
+		`{"command":"copy","source":"/a","destination":"/b","flags":["--atomc"]}`,
+		`{"command":"copy","source":"a","destination":"/b"}`,
+		`not json`,
+	} {
+		resp := post(body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("POST /jobs %s = %d, want 400", body, resp.StatusCode)
+		}
+	}
+
+	resp, err := http.Get(srv.URL + "/jobs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var jobs []queue.Job
+	json.NewDecoder(resp.Body).Decode(&jobs)
+	resp.Body.Close()
+	if len(jobs) != 1 || jobs[0].ID != job.ID || jobs[0].Flags[0] != "--atomic" {
+		t.Errorf("GET /jobs = %+v, want only the accepted job", jobs)
+	}
+
+	resp, err = http.Get(srv.URL + "/jobs/" + job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("GET /jobs/%s = %d, want 200", job.ID, resp.StatusCode)
+	}
+	resp, err = http.Get(srv.URL + "/jobs/nope")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("GET /jobs/nope = %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestListenLoopback(t *testing.T) {
+	for _, addr := range []string{":0", "0.0.0.0:0", "192.0.2.1:0", "example.com:0", "127.0.0.1"} {
+		if ln, err := listenLoopback(addr); err == nil {
+			ln.Close()
+			t.Errorf("listenLoopback(%q) succeeded, want it refused", addr)
+		}
+	}
+	ln, err := listenLoopback("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listenLoopback: %v", err)
+	}
+	ln.Close()
+}
@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Tmunayyer/gocamelpack/config"
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/queue"
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+func runDaemonCLI(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	var out bytes.Buffer
+	root := newCLI(&deps.AppDeps{Files: createTestFilesService(nil), Config: &config.Config{}, Streams: deps.Streams{Out: &out, Err: &out}})
+	root.SetArgs(args)
+	err := root.Execute()
+	return out.String(), err
+}
+
+func TestDaemon_SubmitAndDrain(t *testing.T) {
+	srcDir := testutil.TempDir(t)
+	dstDir := testutil.TempDir(t)
+	queuePath := filepath.Join(testutil.TempDir(t), "queue.json")
+	src := filepath.Join(srcDir, "photo.jpg")
+	if err := os.WriteFile(src, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := runDaemonCLI(t, "daemon", "submit", "--queue", queuePath, "copy", src, dstDir, "--", "--atomic"); err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	// Copying the same file again fails on the existing destination.
+	if _, err := runDaemonCLI(t, "daemon", "submit", "--queue", queuePath, "copy", src, dstDir); err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+
+	out, err := runDaemonCLI(t, "daemon", "--queue", queuePath, "--drain", "--concurrency", "2")
+	if err != nil {
+		t.Fatalf("daemon: %v\n%s", err, out)
+	}
+	if _, err := os.Stat(filepath.Join(dstDir, "2025", "01", "27", "15_30.jpg")); err != nil {
+		t.Errorf("job did not copy the file: %v\n%s", err, out)
+	}
+
+	out, err = runDaemonCLI(t, "--output", "json", "daemon", "jobs", "--queue", queuePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var jobs []queue.Job
+	if err := json.Unmarshal([]byte(out), &jobs); err != nil {
+		t.Fatalf("decode jobs: %v\n%s", err, out)
+	}
+	if len(jobs) != 2 || jobs[0].State != queue.Done || jobs[1].State != queue.Failed {
+		t.Fatalf("unexpected job states: %+v", jobs)
+	}
+	if !strings.Contains(jobs[1].Error, "already exists") {
+		t.Errorf("failed job should record its error, got %q", jobs[1].Error)
+	}
+
+	log, err := os.ReadFile(filepath.Join(filepath.Dir(queuePath), "logs", jobs[0].ID+".log"))
+	if err != nil || !strings.Contains(string(log), "Atomically copied 1 file(s).") {
+		t.Errorf("job log missing summary: %v\n%s", err, log)
+	}
+}
+
+func TestDaemonSubmit_RejectsBadFlags(t *testing.T) {
+	queuePath := filepath.Join(testutil.TempDir(t), "queue.json")
+
+	if _, err := runDaemonCLI(t, "daemon", "submit", "--queue", queuePath, "copy", "/a", "/b", "--", "--atomc"); err == nil {
+		t.Error("expected unknown job flag to be rejected")
+	}
+	if _, err := runDaemonCLI(t, "daemon", "submit", "--queue", queuePath, "rename", "/a", "/b"); err == nil {
+		t.Error("expected unsupported command to be rejected")
+	}
+	if _, err := os.Stat(queuePath); !os.IsNotExist(err) {
+		t.Error("rejected submissions must not create the queue")
+	}
+}
+
+func TestDaemon_ResumedProgress(t *testing.T) {
+	tmp := filepath.Join(testutil.TempDir(t), "resumed")
+	srcDir := filepath.Join(tmp, "src")
+	dstDir := filepath.Join(tmp, "dst")
+	queuePath := filepath.Join(tmp, "state", "queue.json")
+	if err := os.MkdirAll(srcDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(srcDir, "photo.jpg"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// A daemon claimed the job and got 3 of 10 files in before it stopped.
+	q := queue.Open(queuePath)
+	job, err := q.Submit(queue.Job{Command: "copy", Source: srcDir, Destination: dstDir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, err := q.Claim(nil); !ok || err != nil {
+		t.Fatalf("claim: %v, %v", ok, err)
+	}
+	progressFile := filepath.Join(filepath.Dir(queuePath), "progress", job.ID+".json")
+	if err := os.MkdirAll(filepath.Dir(progressFile), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(progressFile, []byte(`{"state": "running", "current": 3, "total": 10}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if out, err := runDaemonCLI(t, "daemon", "--queue", queuePath, "--drain"); err != nil {
+		t.Fatalf("daemon: %v\n%s", err, out)
+	}
+
+	out, err := runDaemonCLI(t, "--output", "json", "status", "--queue", queuePath, job.ID)
+	if err != nil {
+		t.Fatalf("status: %v\n%s", err, out)
+	}
+	var statuses []jobStatus
+	if err := json.Unmarshal([]byte(out), &statuses); err != nil {
+		t.Fatalf("decode status: %v\n%s", err, out)
+	}
+	if len(statuses) != 1 {
+		t.Fatalf("got %d jobs, want 1:\n%s", len(statuses), out)
+	}
+	s := statuses[0]
+	if s.Resumed == nil || s.Resumed.Current != 3 || s.Resumed.Total != 10 {
+		t.Errorf("resumed = %+v, want 3/10", s.Resumed)
+	}
+	if s.Progress == nil || s.Progress.State != "done" || s.Progress.Current != 1 {
+		t.Errorf("progress = %+v, want the finished rerun", s.Progress)
+	}
+
+	out, err = runDaemonCLI(t, "status", "--queue", queuePath)
+	if err != nil || !strings.Contains(out, "1/1 (100%)") || !strings.Contains(out, "3/10") {
+		t.Errorf("status table: %v\n%s", err, out)
+	}
+}
+
+func TestStatus_Overview(t *testing.T) {
+	queuePath := filepath.Join(testutil.TempDir(t), "overview", "queue.json")
+	q := queue.Open(queuePath)
+	for _, src := range []string{"/a", "/b", "/c"} {
+		if _, err := q.Submit(queue.Job{Command: "copy", Source: src, Destination: "/dst" + src}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	done, _, _ := q.Claim(nil)
+	if err := q.Finish(done.ID, nil); err != nil {
+		t.Fatal(err)
+	}
+	active, _, _ := q.Claim(nil)
+
+	out, err := runDaemonCLI(t, "status", "--queue", queuePath)
+	if err != nil || !strings.Contains(out, "Daemon:  not running") || !strings.Contains(out, "1 pending, 1 running") {
+		t.Errorf("status without a daemon: %v\n%s", err, out)
+	}
+
+	if err := q.Heartbeat(queue.Daemon{PID: 7, Host: "box", Poll: time.Minute, Running: []string{active.ID}}); err != nil {
+		t.Fatal(err)
+	}
+	out, err = runDaemonCLI(t, "--output", "json", "status", "--queue", queuePath)
+	if err != nil {
+		t.Fatalf("status: %v\n%s", err, out)
+	}
+	var st daemonStatus
+	if err := json.Unmarshal([]byte(out), &st); err != nil {
+		t.Fatalf("decode status: %v\n%s", err, out)
+	}
+	if !st.Running || st.Pending != 1 || len(st.Active) != 1 || st.Active[0].ID != active.ID || len(st.Recent) != 1 || st.Recent[0].ID != done.ID {
+		t.Errorf("unexpected overview: %+v", st)
+	}
+}
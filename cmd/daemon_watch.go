@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Tmunayyer/gocamelpack/queue"
+)
+
+// watchFolder queues a move job for each file dropped into dir (--watch).
+type watchFolder struct {
+	dir         string
+	destination string
+
+	// seen is the size and modification time each file had on the
+	// previous scan; a file is queued once they stop changing.
+	seen map[string]fileStamp
+}
+
+type fileStamp struct {
+	size    int64
+	modTime time.Time
+}
+
+// parseWatch parses a --watch value, <dir>=<destination>.
+func parseWatch(v string) (*watchFolder, error) {
+	dir, dst, ok := strings.Cut(v, "=")
+	if !ok || dir == "" || dst == "" {
+		return nil, fmt.Errorf("--watch %q: want <folder>=<destination>", v)
+	}
+	w := &watchFolder{seen: map[string]fileStamp{}}
+	var err error
+	if w.dir, err = filepath.Abs(dir); err != nil {
+		return nil, err
+	}
+	if w.destination, err = filepath.Abs(dst); err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(w.dir); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("--watch %q: %s is not a folder", v, w.dir)
+	}
+	return w, nil
+}
+
+// scan queues a move job for each file directly in the folder that has
+// not changed since the previous scan, unless the queue already has a job
+// for it that is pending, running or newer than the file. Subfolders and
+// hidden files, often partial downloads, are left alone.
+func (w *watchFolder) scan(q *queue.Queue) ([]queue.Job, error) {
+	entries, err := os.ReadDir(w.dir)
+	if err != nil {
+		return nil, err
+	}
+	jobs, err := q.Jobs()
+	if err != nil {
+		return nil, err
+	}
+	last := map[string]queue.Job{}
+	for _, j := range jobs {
+		last[j.Source] = j
+	}
+
+	var queued []queue.Job
+	seen := map[string]fileStamp{}
+	for _, e := range entries {
+		if !e.Type().IsRegular() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue // gone since it was listed
+		}
+		path := filepath.Join(w.dir, e.Name())
+		stamp := fileStamp{size: info.Size(), modTime: info.ModTime()}
+		seen[path] = stamp
+		if prev, ok := w.seen[path]; !ok || prev != stamp {
+			continue // still being written
+		}
+		if j, ok := last[path]; ok && (j.State == queue.Pending || j.State == queue.Running || j.Submitted.After(stamp.modTime)) {
+			continue
+		}
+		job, err := q.Submit(queue.Job{Command: "move", Source: path, Destination: w.destination})
+		if err != nil {
+			return queued, err
+		}
+		queued = append(queued, job)
+	}
+	w.seen = seen
+	return queued, nil
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Tmunayyer/gocamelpack/queue"
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+func TestWatchFolder_QueuesSettledFiles(t *testing.T) {
+	dir := testutil.TempDir(t)
+	watched := filepath.Join(dir, "inbox")
+	if err := os.MkdirAll(filepath.Join(watched, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	photo := filepath.Join(watched, "photo.jpg")
+	for _, p := range []string{photo, filepath.Join(watched, ".partial.jpg")} {
+		if err := os.WriteFile(p, []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	q := queue.Open(filepath.Join(dir, "queue.json"))
+	w, err := parseWatch(watched + "=" + filepath.Join(dir, "archive"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	scan := func(want int) []queue.Job {
+		t.Helper()
+		queued, err := w.scan(q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(queued) != want {
+			t.Fatalf("scan queued %+v, want %d job(s)", queued, want)
+		}
+		return queued
+	}
+
+	scan(0) // first sighting: the file may still be being written
+	jobs := scan(1)
+	if j := jobs[0]; j.Command != "move" || j.Source != photo || j.Destination != filepath.Join(dir, "archive") {
+		t.Errorf("queued %+v, want photo.jpg moved into the archive", j)
+	}
+	scan(0) // its job is pending
+
+	job, _, err := q.Claim(func(queue.Job) bool { return false })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Finish(job.ID, errors.New("refused")); err != nil {
+		t.Fatal(err)
+	}
+	scan(0) // failed, and the file has not changed since
+
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(photo, later, later); err != nil {
+		t.Fatal(err)
+	}
+	scan(0)
+	scan(1) // replaced after the failure, and settled again
+}
+
+func TestParseWatch(t *testing.T) {
+	dir := testutil.TempDir(t)
+	for _, v := range []string{dir, dir + "=", "=" + dir, filepath.Join(dir, "missing") + "=" + dir} {
+		if _, err := parseWatch(v); err == nil {
+			t.Errorf("parseWatch(%q) succeeded, want an error", v)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Tmunayyer/gocamelpack/config"
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/output"
+	"github.com/Tmunayyer/gocamelpack/pathtmpl"
+	"github.com/spf13/cobra"
+)
+
+// reviewDir is the folder, below the destination root, that files with a
+// suspicious capture date are held in.
+const reviewDir = "review"
+
+// defaultMinYear is the earliest plausible capture year without
+// --min-year; cameras with a dead clock battery report 1970 or their
+// firmware's release year.
+const defaultMinYear = 1995
+
+// What --suspect-dates does with a file dated outside the range.
+const (
+	suspectReview = "review"
+	suspectAsk    = "ask"
+	suspectKeep   = "keep"
+)
+
+// dateCheck holds files whose capture year is outside [min, max] for
+// review instead of filing them under, say, 1970/01/01.
+type dateCheck struct {
+	min, max int
+	// ask, when set, asks what to do with each suspect file.
+	ask *datePrompt
+
+	mu     sync.Mutex
+	review int
+}
+
+// datePrompt asks on the terminal whether to keep, review or skip a file.
+type datePrompt struct {
+	mu  sync.Mutex
+	in  *bufio.Reader
+	out io.Writer
+}
+
+// dateCheckFromFlags reads --min-year, --max-year and --suspect-dates,
+// falling back to the config; nil when the check is off.
+func dateCheckFromFlags(cmd *cobra.Command, cfg config.SuspectDates, now time.Time) (*dateCheck, error) {
+	c := &dateCheck{min: cfg.MinYear, max: cfg.MaxYear}
+	if cmd.Flags().Changed("min-year") {
+		c.min, _ = cmd.Flags().GetInt("min-year")
+	}
+	if cmd.Flags().Changed("max-year") {
+		c.max, _ = cmd.Flags().GetInt("max-year")
+	}
+	if c.min == 0 {
+		c.min = defaultMinYear
+	}
+	if c.max == 0 {
+		// A year ahead allows for cameras set to another time zone.
+		c.max = now.Year() + 1
+	}
+	if c.max < c.min {
+		return nil, fmt.Errorf("--max-year %d is before --min-year %d", c.max, c.min)
+	}
+	action := cfg.Action
+	if cmd.Flags().Changed("suspect-dates") || action == "" {
+		action, _ = cmd.Flags().GetString("suspect-dates")
+	}
+	switch action {
+	case suspectKeep:
+		return nil, nil
+	case suspectReview:
+	case suspectAsk:
+		// Without a terminal to answer on, files are held for review.
+		if f, ok := cmd.InOrStdin().(*os.File); ok && output.IsTerminal(f) {
+			c.ask = &datePrompt{in: bufio.NewReader(f), out: cmd.ErrOrStderr()}
+		}
+	default:
+		return nil, fmt.Errorf("--suspect-dates: unknown action %q (want %s, %s or %s)", action, suspectReview, suspectAsk, suspectKeep)
+	}
+	return c, nil
+}
+
+// check returns why md's capture date is suspicious, or "" when it is
+// plausible or unknown.
+func (c *dateCheck) check(md files.FileMetadata) string {
+	if c == nil {
+		return ""
+	}
+	t, tag, ok := pathtmpl.CaptureTime(md)
+	if !ok || (t.Year() >= c.min && t.Year() <= c.max) {
+		return ""
+	}
+	return fmt.Sprintf("%s %s is outside %d-%d", tag, t.Format("2006-01-02"), c.min, c.max)
+}
+
+// hold plans a file with a suspicious date: under review/, skipped, or
+// (ok false) placed by its date anyway, as the user answered.
+func (c *dateCheck) hold(src, reason, dstRoot string, md files.FileMetadata) (p routed, ok bool) {
+	p = routed{md: md, suspectDate: reason}
+	choice := suspectReview
+	if c.ask != nil {
+		choice = c.ask.ask(src, reason)
+	}
+	switch choice {
+	case suspectKeep:
+		return routed{}, false
+	case "skip":
+		p.skip = true
+	default:
+		p.dst = filepath.Join(dstRoot, reviewDir, filepath.Base(src))
+		c.mu.Lock()
+		c.review++
+		c.mu.Unlock()
+	}
+	return p, true
+}
+
+// ask prompts for one file; an empty or unreadable answer holds it for
+// review.
+func (a *datePrompt) ask(src, reason string) string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for {
+		fmt.Fprintf(a.out, "%s: %s. [k]eep, [r]eview or [s]kip? ", src, reason)
+		line, err := a.in.ReadString('\n')
+		switch strings.ToLower(strings.TrimSpace(line)) {
+		case "k", "keep":
+			return suspectKeep
+		case "s", "skip":
+			return "skip"
+		case "r", "review":
+			return suspectReview
+		case "":
+			return suspectReview
+		}
+		if err != nil {
+			return suspectReview
+		}
+	}
+}
+
+// close reports the files held for review.
+func (c *dateCheck) close(cmd *cobra.Command, dryRun bool) {
+	if c == nil || c.review == 0 {
+		return
+	}
+	verb := "were"
+	if dryRun {
+		verb = "would be"
+	}
+	output.New(cmd.ErrOrStderr()).Warn("%d file(s) with an implausible capture date %s placed in %s/ for review", c.review, verb, reviewDir)
+}
+
+// held finishes the plan of a file held for review; skipped files need no
+// destination.
+func (o transferOptions) held(fs files.FilesService, src string, p routed, dstRoot string) routed {
+	if p.skip {
+		return p
+	}
+	return o.finalize(fs, src, p, dstRoot, false)
+}
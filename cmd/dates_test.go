@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/config"
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+func TestCopyCmd_SuspectDates(t *testing.T) {
+	tmp := filepath.Join(testutil.TempDir(t), "dates")
+	card, dst := filepath.Join(tmp, "card"), filepath.Join(tmp, "dst")
+	if err := os.MkdirAll(card, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	epoch, good := filepath.Join(card, "epoch.jpg"), filepath.Join(card, "good.jpg")
+	md := map[string]files.FileMetadata{
+		epoch: {Filepath: epoch, Tags: map[string]string{"CreationDate": "1970:01:01 00:00:00"}},
+		good:  {Filepath: good, Tags: map[string]string{"CreationDate": "2025:01:27 15:30:45"}},
+	}
+	for path := range md {
+		if err := os.WriteFile(path, []byte(path), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	run := func(cfg config.Config, args ...string) string {
+		t.Helper()
+		var out bytes.Buffer
+		root := newCLI(&deps.AppDeps{Files: createTestFilesService(md), Config: &cfg, Streams: deps.Streams{Out: &out, Err: &out}})
+		root.SetArgs(append(append([]string{"copy", "--dry-run"}, args...), card, dst))
+		if err := root.Execute(); err != nil {
+			t.Fatalf("copy %v: %v\n%s", args, err, out.String())
+		}
+		return out.String()
+	}
+	review := filepath.Join(dst, reviewDir, "epoch.jpg")
+	dated := filepath.Join(dst, "1970", "01", "01", "00_00.jpg")
+
+	out := run(config.Config{}, "--explain")
+	for _, want := range []string{review, "CreationDate 1970-01-01 is outside 1995-", "1 file(s) with an implausible capture date would be placed in review/", filepath.Join(dst, "2025", "01", "27", "15_30.jpg")} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in:\n%s", want, out)
+		}
+	}
+	// Without a terminal, ask holds the file for review too.
+	if out := run(config.Config{}, "--suspect-dates", "ask"); !strings.Contains(out, review) {
+		t.Errorf("ask without a terminal should hold the file for review:\n%s", out)
+	}
+	if out := run(config.Config{}, "--suspect-dates", "keep"); !strings.Contains(out, dated) {
+		t.Errorf("keep should place the file by its date:\n%s", out)
+	}
+	if out := run(config.Config{SuspectDates: config.SuspectDates{MinYear: 1960}}); !strings.Contains(out, dated) {
+		t.Errorf("a configured min_year of 1960 should accept 1970:\n%s", out)
+	}
+	if out := run(config.Config{SuspectDates: config.SuspectDates{MinYear: 1960}}, "--min-year", "1990"); !strings.Contains(out, review) {
+		t.Errorf("--min-year should override the config:\n%s", out)
+	}
+}
+
+func TestDatePrompt(t *testing.T) {
+	var out bytes.Buffer
+	p := &datePrompt{in: bufio.NewReader(strings.NewReader("what\nk\ns\n\n")), out: &out}
+	for _, want := range []string{suspectKeep, "skip", suspectReview, suspectReview} {
+		if got := p.ask("a.jpg", "CreationDate 1970-01-01 is outside 1995-2027"); got != want {
+			t.Errorf("ask = %q, want %q", got, want)
+		}
+	}
+	if n := strings.Count(out.String(), "[k]eep, [r]eview or [s]kip?"); n != 5 {
+		t.Errorf("expected 5 prompts, one repeated after the unknown answer, got %d:\n%s", n, out.String())
+	}
+}
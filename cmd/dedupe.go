@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/hashindex"
+	"github.com/Tmunayyer/gocamelpack/output"
+	"github.com/spf13/cobra"
+)
+
+// dedupeIndex finds archived files by content hash: the archive's hash
+// index, or its catalog with --catalog.
+type dedupeIndex interface {
+	Lookup(hash string) (string, bool)
+	Add(path, hash string) error
+	Save() error
+}
+
+// archiveDedupe recognises sources whose content is already somewhere in
+// the destination archive (--dedupe-against-archive). It also keeps the
+// archive's hash index up to date with the files the run adds.
+type archiveDedupe struct {
+	index dedupeIndex
+	algo  hashindex.Algo
+	// link places a hard link to the archived copy at the planned
+	// destination instead of skipping the source.
+	link bool
+
+	// mu guards the fields below and the index, for parallel copies.
+	mu     sync.Mutex
+	hashes map[string]string // source -> content hash
+	found  []output.Mapping  // source -> archived copy
+}
+
+// newArchiveDedupe loads and refreshes the hash index of dstRoot in
+// hashes of algo, or with a catalog looks files up in it without reading
+// the archive.
+func newArchiveDedupe(dstRoot string, link bool, cat *archiveCatalog, algo hashindex.Algo) (*archiveDedupe, error) {
+	if cat != nil {
+		return &archiveDedupe{index: catalogIndex{cat}, algo: algo, link: link, hashes: map[string]string{}}, nil
+	}
+	ix, err := hashindex.Load(dstRoot, algo)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := ix.Refresh(); err != nil {
+		return nil, err
+	}
+	return &archiveDedupe{index: ix, algo: algo, link: link, hashes: map[string]string{}}, nil
+}
+
+// existing returns the archived copy of src, if any. Sources that cannot
+// be read are never duplicates; the transfer itself reports the error.
+func (a *archiveDedupe) existing(src string) (string, bool) {
+	a.mu.Lock()
+	hash, ok := a.hashes[src]
+	a.mu.Unlock()
+	if !ok {
+		var err error
+		if hash, err = a.algo.HashFile(src); err != nil {
+			return "", false
+		}
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.hashes[src] = hash
+	path, ok := a.index.Lookup(hash)
+	if ok {
+		a.found = append(a.found, output.Mapping{Source: src, Destination: path})
+	}
+	return path, ok
+}
+
+// hashOf returns the content hash read for src, if any.
+func (a *archiveDedupe) hashOf(src string) (string, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	hash, ok := a.hashes[src]
+	return hash, ok
+}
+
+// OnOperationComplete indexes each newly archived file, so later sources
+// in the same run are checked against it too.
+func (a *archiveDedupe) OnOperationComplete(op files.Operation) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if hash, ok := a.hashes[op.Source()]; ok {
+		// A file that cannot be indexed now is hashed on the next refresh.
+		_ = a.index.Add(op.Destination(), hash)
+	}
+}
+
+// close reports the duplicates found and saves the index.
+func (a *archiveDedupe) close(cmd *cobra.Command, dryRun bool) {
+	p := output.New(cmd.ErrOrStderr())
+	if len(a.found) > 0 {
+		what := "skipped"
+		if a.link {
+			what = "linked to their archived copy"
+		}
+		p.Warn("%d file(s) already in the archive were %s:", len(a.found), what)
+		p.Mappings("duplicate", a.found)
+	}
+	if dryRun {
+		return
+	}
+	if err := a.index.Save(); err != nil {
+		p.Warn("%v", err)
+	}
+}
+
+// parseDedupeMode validates --dedupe-against-archive.
+func parseDedupeMode(mode string) (enabled, link bool, err error) {
+	switch mode {
+	case "":
+		return false, false, nil
+	case "skip":
+		return true, false, nil
+	case "link":
+		return true, true, nil
+	}
+	return false, false, fmt.Errorf("invalid --dedupe-against-archive %q (want skip or link)", mode)
+}
+
+// hashAlgo reads --hash-algo, falling back to the config's hash_algo.
+func hashAlgo(cmd *cobra.Command, configured string) (hashindex.Algo, error) {
+	s := configured
+	if cmd.Flags().Changed("hash-algo") {
+		s, _ = cmd.Flags().GetString("hash-algo")
+	}
+	algo, err := hashindex.ParseAlgo(s)
+	if err != nil {
+		return "", fmt.Errorf("--hash-algo: %w", err)
+	}
+	return algo, nil
+}
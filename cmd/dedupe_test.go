@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/config"
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/hashindex"
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+func TestCopyCmd_DedupeAgainstArchive(t *testing.T) {
+	setup := func(t *testing.T) (srcDir, dstDir, archived string) {
+		tmp := testutil.TempDir(t)
+		srcDir = filepath.Join(tmp, "card")
+		dstDir = filepath.Join(tmp, "archive")
+		archived = filepath.Join(dstDir, "2019/05/01/old_name.jpg")
+		for path, content := range map[string]string{
+			filepath.Join(srcDir, "IMG_0001.jpg"): "already archived",
+			filepath.Join(srcDir, "IMG_0002.jpg"): "new",
+			archived:                              "already archived",
+		} {
+			if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return srcDir, dstDir, archived
+	}
+	run := func(args ...string) (string, error) {
+		var out bytes.Buffer
+		root := newCLI(&deps.AppDeps{Files: createTestFilesService(nil), Config: &config.Config{}, Streams: deps.Streams{Out: &out, Err: &out}})
+		root.SetArgs(append([]string{"copy"}, args...))
+		err := root.Execute()
+		return out.String(), err
+	}
+	// Every source gets the same destination from the test service, so
+	// without deduplication the two would conflict.
+	dst := "2025/01/27/15_30.jpg"
+
+	t.Run("skip", func(t *testing.T) {
+		srcDir, dstDir, _ := setup(t)
+		out, err := run("--dedupe-against-archive", srcDir, dstDir)
+		if err != nil {
+			t.Fatalf("copy: %v\n%s", err, out)
+		}
+		if !contains(out, "Copied 1 file(s), skipped 1") || !contains(out, "1 file(s) already in the archive were skipped") {
+			t.Errorf("unexpected output:\n%s", out)
+		}
+		if b, err := os.ReadFile(filepath.Join(dstDir, dst)); err != nil || string(b) != "new" {
+			t.Errorf("new file should be copied, got %q, %v", b, err)
+		}
+
+		// The new file was indexed, so importing it again is a no-op.
+		ix, err := hashindex.Load(dstDir, hashindex.SHA256)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ix.Len() != 2 {
+			t.Errorf("index has %d entries, want 2", ix.Len())
+		}
+		out, err = run("--dedupe-against-archive", srcDir, dstDir)
+		if err != nil {
+			t.Fatalf("second copy: %v\n%s", err, out)
+		}
+		if !contains(out, "Copied 0 file(s), skipped 2") {
+			t.Errorf("unexpected output:\n%s", out)
+		}
+	})
+
+	t.Run("xxhash64", func(t *testing.T) {
+		srcDir, dstDir, _ := setup(t)
+		out, err := run("--dedupe-against-archive", "--hash-algo", "xxhash64", srcDir, dstDir)
+		if err != nil {
+			t.Fatalf("copy: %v\n%s", err, out)
+		}
+		if !contains(out, "Copied 1 file(s), skipped 1") {
+			t.Errorf("unexpected output:\n%s", out)
+		}
+		b, err := os.ReadFile(filepath.Join(dstDir, hashindex.FileName))
+		if err != nil || !contains(string(b), `"xxh64:`) {
+			t.Errorf("the index should record xxh64 hashes, got %s, %v", b, err)
+		}
+		if _, err := run("--hash-algo", "md5", srcDir, dstDir); err == nil {
+			t.Error("expected an error for an unknown algorithm")
+		}
+	})
+
+	t.Run("link", func(t *testing.T) {
+		srcDir, dstDir, archived := setup(t)
+		src := filepath.Join(srcDir, "IMG_0001.jpg")
+		out, err := run("--dedupe-against-archive=link", src, dstDir)
+		if err != nil {
+			t.Fatalf("copy: %v\n%s", err, out)
+		}
+		a, err := os.Stat(archived)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := os.Stat(filepath.Join(dstDir, dst))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !os.SameFile(a, b) {
+			t.Error("duplicate should be hard-linked to the archived copy")
+		}
+	})
+
+	t.Run("link is copy only", func(t *testing.T) {
+		srcDir, dstDir, _ := setup(t)
+		var out bytes.Buffer
+		root := newCLI(&deps.AppDeps{Files: createTestFilesService(nil), Config: &config.Config{}, Streams: deps.Streams{Out: &out, Err: &out}})
+		root.SetArgs([]string{"move", "--dedupe-against-archive=link", srcDir, dstDir})
+		if err := root.Execute(); err == nil {
+			t.Error("expected move to reject link mode")
+		}
+		if _, err := os.Stat(filepath.Join(dstDir, ".gocamelpack.lock")); !os.IsNotExist(err) {
+			t.Errorf("lock should be released on a flag error, stat err = %v", err)
+		}
+	})
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/output"
+	"github.com/spf13/cobra"
+)
+
+// Exit statuses returned by the CLI. Scripts can rely on these to tell
+// failure classes apart without parsing messages.
+const (
+	exitOK            = 0
+	exitError         = 1
+	exitConflict      = 3
+	exitNoDate        = 4
+	exitSourceMissing = 5
+	exitLocked        = 6
+	exitReadOnly      = 7
+)
+
+// exitCode maps err to the process exit status.
+func exitCode(err error) int {
+	if err == nil {
+		return exitOK
+	}
+	switch files.ErrorCode(err) {
+	case files.CodeConflict:
+		return exitConflict
+	case files.CodeNoDate:
+		return exitNoDate
+	case files.CodeSourceMissing:
+		return exitSourceMissing
+	case files.CodeLocked:
+		return exitLocked
+	case files.CodeReadOnly:
+		return exitReadOnly
+	default:
+		return exitError
+	}
+}
+
+// errorObject is the JSON shape of a failure when --output=json.
+type errorObject struct {
+	Error struct {
+		Code     files.Code `json:"code"`
+		Message  string     `json:"message"`
+		ExitCode int        `json:"exit_code"`
+	} `json:"error"`
+}
+
+// writeErrorJSON writes err as a single JSON object followed by a newline.
+func writeErrorJSON(w io.Writer, err error) error {
+	var obj errorObject
+	obj.Error.Code = files.ErrorCode(err)
+	obj.Error.Message = err.Error()
+	obj.Error.ExitCode = exitCode(err)
+	return json.NewEncoder(w).Encode(obj)
+}
+
+// outputFormat returns the value of the persistent --output flag, or "text"
+// for commands built without the root command.
+func outputFormat(cmd *cobra.Command) string {
+	if f := cmd.Flag("output"); f != nil {
+		return f.Value.String()
+	}
+	return "text"
+}
+
+// jsonLines reports whether results go to stdout as single-line JSON
+// objects: with --output=json, and with ndjson after the events.
+func jsonLines(cmd *cobra.Command) bool {
+	f := outputFormat(cmd)
+	return f == "json" || f == "ndjson"
+}
+
+// validateOutputFormat checks --output for cmd. ndjson streams the file
+// events of copy and move, so only they accept it.
+func validateOutputFormat(cmd *cobra.Command, format string) error {
+	switch format {
+	case "text", "json":
+		return nil
+	case "ndjson":
+		if cmd.Name() == "copy" || cmd.Name() == "move" {
+			return nil
+		}
+		return fmt.Errorf("--output ndjson is only supported by copy and move")
+	default:
+		return fmt.Errorf("invalid --output %q: must be text, json or ndjson", format)
+	}
+}
+
+// reportError prints a failed command's error in the selected format: a JSON
+// object on stdout for --output=json or ndjson, a coloured line on stderr
+// otherwise.
+func reportError(cmd *cobra.Command, err error) {
+	if jsonLines(cmd) {
+		if jerr := writeErrorJSON(cmd.OutOrStdout(), err); jerr == nil {
+			return
+		}
+	}
+	output.New(cmd.ErrOrStderr()).Error("%v", err)
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"nil", nil, exitOK},
+		{"plain", errors.New("boom"), exitError},
+		{"conflict", files.Errorf(files.ErrConflict, "x"), exitConflict},
+		{"no date", files.Errorf(files.ErrNoDate, "x"), exitNoDate},
+		{"source missing", files.Errorf(files.ErrSourceMissing, "x"), exitSourceMissing},
+		{"locked", files.Errorf(files.ErrLocked, "x"), exitLocked},
+		{"read-only", files.Errorf(files.ErrReadOnly, "x"), exitReadOnly},
+		{"wrapped in transaction", &files.TransactionError{Phase: "planning", Err: files.Errorf(files.ErrConflict, "x")}, exitConflict},
+	}
+	for _, tc := range tests {
+		if got := exitCode(tc.err); got != tc.want {
+			t.Errorf("%s: exitCode = %d, want %d", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestWriteErrorJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeErrorJSON(&buf, files.Errorf(files.ErrNoDate, "CreationDate is missing")); err != nil {
+		t.Fatal(err)
+	}
+
+	var got errorObject
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON %q: %v", buf.String(), err)
+	}
+	if got.Error.Code != files.CodeNoDate || got.Error.Message != "CreationDate is missing" || got.Error.ExitCode != exitNoDate {
+		t.Errorf("unexpected error object %+v", got)
+	}
+}
+
+func TestReportError_JSONThroughCLI(t *testing.T) {
+	tempDir := testutil.TempDir(t)
+
+	var stdout, stderr bytes.Buffer
+	root := newCLI(&deps.AppDeps{
+		Files:   createTestFilesService(nil),
+		Streams: deps.Streams{Out: &stdout, Err: &stderr},
+	})
+	root.SetArgs([]string{"--output", "json", "copy", filepath.Join(tempDir, "missing.jpg"), filepath.Join(tempDir, "dst")})
+
+	err := root.Execute()
+	if !errors.Is(err, files.ErrSourceMissing) {
+		t.Fatalf("expected ErrSourceMissing, got %v", err)
+	}
+
+	stdout.Reset()
+	reportError(root, err)
+
+	var got errorObject
+	if err := json.Unmarshal(stdout.Bytes(), &got); err != nil {
+		t.Fatalf("expected JSON error on stdout, got %q: %v", stdout.String(), err)
+	}
+	if got.Error.Code != files.CodeSourceMissing || got.Error.Message != "unknown src argument" || got.Error.ExitCode != exitSourceMissing {
+		t.Errorf("unexpected error object %+v", got)
+	}
+}
+
+func TestReportError_TextGoesToStderr(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	root := newCLI(&deps.AppDeps{
+		Files:   createTestFilesService(nil),
+		Streams: deps.Streams{Out: &stdout, Err: &stderr},
+	})
+
+	reportError(root, errors.New("boom"))
+	if stdout.Len() != 0 || stderr.String() != "Error: boom\n" {
+		t.Errorf("unexpected output stdout=%q stderr=%q", stdout.String(), stderr.String())
+	}
+}
+
+func TestOutputFlagValidation(t *testing.T) {
+	root := newCLI(&deps.AppDeps{
+		Files:   createTestFilesService(nil),
+		Streams: deps.Streams{Out: &bytes.Buffer{}, Err: &bytes.Buffer{}},
+	})
+	root.SetArgs([]string{"--output", "yaml", "read", "/nope"})
+
+	if err := root.Execute(); err == nil || !strings.Contains(err.Error(), "invalid --output") {
+		t.Fatalf("expected invalid --output error, got %v", err)
+	}
+}
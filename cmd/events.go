@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/pathtmpl"
+)
+
+// eventKind is a step of a file through a copy or move.
+type eventKind string
+
+const (
+	eventCollected eventKind = "collected"
+	eventPlanned   eventKind = "planned"
+	eventSkipped   eventKind = "skipped"
+	eventValidated eventKind = "validated"
+	eventCopied    eventKind = "copied"
+	eventMoved     eventKind = "moved"
+	eventFailed    eventKind = "failed"
+)
+
+// fileEvent is one step of one file. Every per-file output of copy and
+// move is fed from these: --output ndjson, the --report and --report-csv
+// files and the summary counts.
+type fileEvent struct {
+	Time        time.Time `json:"time"`
+	Event       eventKind `json:"event"`
+	Source      string    `json:"source"`
+	Destination string    `json:"destination,omitempty"`
+	// DateTag is the tag a planned file's date came from.
+	DateTag string `json:"date_tag,omitempty"`
+	// Reason is why a file was skipped.
+	Reason string `json:"reason,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// eventSink consumes the events of a run.
+type eventSink interface {
+	onEvent(e fileEvent)
+}
+
+// eventLog hands each event of a run to its sinks, one event at a time,
+// and counts them. A nil eventLog drops events. As a
+// files.PostOperationHook it emits copied and moved.
+type eventLog struct {
+	mu     sync.Mutex
+	sinks  []eventSink
+	counts map[eventKind]int
+	now    func() time.Time
+}
+
+func newEventLog() *eventLog {
+	return &eventLog{counts: map[eventKind]int{}, now: time.Now}
+}
+
+// add registers a sink for the events emitted from now on.
+func (l *eventLog) add(s eventSink) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sinks = append(l.sinks, s)
+}
+
+func (l *eventLog) emit(e fileEvent) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	e.Time = l.now()
+	l.counts[e.Event]++
+	for _, s := range l.sinks {
+		s.onEvent(e)
+	}
+}
+
+// count returns how many events of the kinds were emitted.
+func (l *eventLog) count(kinds ...eventKind) int {
+	if l == nil {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	n := 0
+	for _, k := range kinds {
+		n += l.counts[k]
+	}
+	return n
+}
+
+// withEvents returns o with an event log, for options not built by
+// transferOptionsFromFlags; the summary is counted from the events.
+func (o transferOptions) withEvents() transferOptions {
+	if o.events == nil {
+		o.events = newEventLog()
+		o.hooks = append(o.hooks[:len(o.hooks):len(o.hooks)], o.events)
+	}
+	return o
+}
+
+// collected records the sources found for the run.
+func (l *eventLog) collected(sources ...string) {
+	for _, src := range sources {
+		l.emit(fileEvent{Event: eventCollected, Source: src})
+	}
+}
+
+// planned records where src is going, or why it is not, from route.
+func (l *eventLog) planned(src string, p routed) {
+	e := fileEvent{Event: eventPlanned, Source: src, Destination: p.dst}
+	_, e.DateTag, _ = pathtmpl.CaptureTime(p.md)
+	if p.skip {
+		e.Event, e.Reason = eventSkipped, p.skipReason()
+	}
+	l.emit(e)
+}
+
+// validated records whether src may go to dst; err is why not.
+func (l *eventLog) validated(src, dst string, err error) {
+	if err != nil {
+		l.failed(src, dst, err)
+		return
+	}
+	l.emit(fileEvent{Event: eventValidated, Source: src, Destination: dst})
+}
+
+// failed records that src could not be planned, validated or transferred.
+func (l *eventLog) failed(src, dst string, err error) {
+	l.emit(fileEvent{Event: eventFailed, Source: src, Destination: dst, Error: err.Error()})
+}
+
+// OnOperationComplete records a transferred file.
+func (l *eventLog) OnOperationComplete(op files.Operation) {
+	kind := eventCopied
+	if op.Type() == files.OperationMove {
+		kind = eventMoved
+	}
+	l.emit(fileEvent{Event: kind, Source: op.Source(), Destination: op.Destination()})
+}
+
+// skipReason says why route left the file out of the run.
+func (p routed) skipReason() string {
+	switch {
+	case p.duplicateOf != "":
+		return fmt.Sprintf("already archived as %s", p.duplicateOf)
+	case p.decision != nil:
+		return fmt.Sprintf("rule %q", p.decision.RuleName())
+	case p.suspectDate != "":
+		return "suspicious capture date: " + p.suspectDate
+	case p.other != "":
+		return "not a photo or video"
+	}
+	return ""
+}
+
+// ndjsonEvents writes every event as a line of JSON, for --output ndjson.
+type ndjsonEvents struct {
+	enc *json.Encoder
+}
+
+func newNDJSONEvents(w io.Writer) *ndjsonEvents {
+	return &ndjsonEvents{enc: json.NewEncoder(w)}
+}
+
+func (n *ndjsonEvents) onEvent(e fileEvent) {
+	n.enc.Encode(e)
+}
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+// ndjsonLines runs root with args and decodes every line it printed.
+func ndjsonLines(t *testing.T, args ...string) []map[string]any {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	root := newCLI(&deps.AppDeps{
+		Files:   createTestFilesService(nil),
+		Streams: deps.Streams{Out: &stdout, Err: &stderr},
+	})
+	root.SetArgs(args)
+	if err := root.Execute(); err != nil {
+		t.Fatalf("%v failed: %v (stderr %q)", args, err, stderr.String())
+	}
+
+	var lines []map[string]any
+	sc := bufio.NewScanner(&stdout)
+	for sc.Scan() {
+		var line map[string]any
+		if err := json.Unmarshal(sc.Bytes(), &line); err != nil {
+			t.Fatalf("invalid JSON line %q: %v", sc.Text(), err)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func TestCopyCmd_NDJSONEvents(t *testing.T) {
+	tempDir := testutil.TempDir(t)
+	srcDir := filepath.Join(tempDir, "src")
+	dstDir := filepath.Join(tempDir, "dst")
+	if err := os.MkdirAll(srcDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a.jpg", "b.jpg"} {
+		if err := os.WriteFile(filepath.Join(srcDir, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	lines := ndjsonLines(t, "--output", "ndjson", "copy", "--template", "{year}/{orig_noext}", srcDir, dstDir)
+
+	counts := map[string]int{}
+	for _, line := range lines[:len(lines)-1] {
+		event, _ := line["event"].(string)
+		counts[event]++
+		if line["source"] == "" || line["time"] == nil {
+			t.Errorf("event without source or time: %v", line)
+		}
+		if event == "planned" && line["date_tag"] != "CreationDate" {
+			t.Errorf("planned event without its date tag: %v", line)
+		}
+	}
+	for _, event := range []string{"collected", "planned", "validated", "copied"} {
+		if counts[event] != 2 {
+			t.Errorf("expected 2 %s events, got %d (%v)", event, counts[event], counts)
+		}
+	}
+	if _, ok := lines[len(lines)-1]["summary"]; !ok {
+		t.Errorf("expected the summary as the last line, got %v", lines[len(lines)-1])
+	}
+}
+
+func TestCopyCmd_NDJSONDryRunPlansOnly(t *testing.T) {
+	tempDir := testutil.TempDir(t)
+	srcDir := filepath.Join(tempDir, "dry-src")
+	if err := os.MkdirAll(srcDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(srcDir, "a.jpg"), []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := ndjsonLines(t, "--output", "ndjson", "copy", "--dry-run", srcDir, filepath.Join(tempDir, "dry-dst"))
+
+	for _, line := range lines {
+		if line["event"] == "copied" {
+			t.Errorf("dry run emitted a copied event: %v", line)
+		}
+	}
+	var planned bool
+	for _, line := range lines {
+		planned = planned || line["event"] == "planned"
+	}
+	if !planned {
+		t.Errorf("expected a planned event, got %v", lines)
+	}
+}
+
+func TestOutputNDJSONOnlyForTransfers(t *testing.T) {
+	root := newCLI(&deps.AppDeps{
+		Files:   createTestFilesService(nil),
+		Streams: deps.Streams{Out: &bytes.Buffer{}, Err: &bytes.Buffer{}},
+	})
+	root.SetArgs([]string{"--output", "ndjson", "read", "/nope"})
+
+	if err := root.Execute(); err == nil || !strings.Contains(err.Error(), "only supported by copy and move") {
+		t.Fatalf("expected an unsupported --output error, got %v", err)
+	}
+}
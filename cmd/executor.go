@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/Tmunayyer/gocamelpack/config"
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/internal/engine"
+	"github.com/Tmunayyer/gocamelpack/output"
+	"github.com/Tmunayyer/gocamelpack/progress"
+	"github.com/spf13/cobra"
+)
+
+// transferMode is how copy and move carry out their plan.
+type transferMode int
+
+const (
+	// modeDirect transfers each file as soon as it is planned; files
+	// transferred before a failure stay in place.
+	modeDirect transferMode = iota
+	// modeAtomic plans every file into a transaction that is rolled back
+	// if any of them fails.
+	modeAtomic
+)
+
+// parseTransferMode reads the config's default_mode: atomic, or direct
+// (the default).
+func parseTransferMode(s string) (transferMode, error) {
+	switch s {
+	case "", "direct":
+		return modeDirect, nil
+	case "atomic":
+		return modeAtomic, nil
+	}
+	return modeDirect, fmt.Errorf("default_mode %q: want atomic or direct", s)
+}
+
+// transferModeFromFlags picks the mode from --atomic or --no-atomic, else
+// from the config's default_mode. Streaming runs are never atomic, so
+// --stream overrides a configured atomic default.
+func transferModeFromFlags(cmd *cobra.Command, cfg *config.Config) (transferMode, error) {
+	atomic, _ := cmd.Flags().GetBool("atomic")
+	noAtomic, _ := cmd.Flags().GetBool("no-atomic")
+	switch {
+	case atomic && noAtomic:
+		return modeDirect, fmt.Errorf("--atomic cannot be combined with --no-atomic")
+	case atomic:
+		return modeAtomic, nil
+	case noAtomic:
+		return modeDirect, nil
+	}
+	if stream, _ := cmd.Flags().GetBool("stream"); stream {
+		return modeDirect, nil
+	}
+	return parseTransferMode(cfg.DefaultMode)
+}
+
+// performTransfer plans sources into dstRoot and copies or moves them in
+// opts' mode, then prints the plan or the summary.
+func performTransfer(fs files.FilesService, sources []string, dstRoot string, opts transferOptions, cmd *cobra.Command, kind files.OperationType) error {
+	opts = opts.withEvents()
+	if err := opts.limits.check(files.FSOf(fs), sources); err != nil {
+		return err
+	}
+	if err := opts.archive.checkAll(sources); err != nil {
+		return err
+	}
+	verb, dryVerb := "copied", "Would copy"
+	if kind == files.OperationMove {
+		verb, dryVerb = "moved", "Would move"
+	}
+
+	var mode engine.Mode
+	if opts.mode == modeAtomic {
+		verb = "Atomically " + verb
+		var planning progress.ProgressReporter = progress.NewNoOpReporter()
+		if opts.showProgress {
+			planning = newProgressDisplay(cmd, opts.progressStyle)
+			planning.SetTotal(len(sources))
+			planning.SetMessage("Planning operations")
+		}
+		mode = engine.NewAtomic(planning, engine.AtomicOptions{
+			Kind:     kind,
+			DryRun:   opts.dryRun,
+			Tx:       fs.NewTransaction(opts.overwrite),
+			Decorate: opts.decorate,
+			// In chunks with --chunk-size.
+			Execute: func(tx files.Transaction) error { return opts.executeTransaction(fs, tx, dstRoot, cmd) },
+		})
+	} else {
+		verb = strings.ToUpper(verb[:1]) + verb[1:]
+		// Fail fast on a read-only destination instead of file by file.
+		if err := opts.checkWritable(dstRoot); err != nil {
+			return err
+		}
+		direct := engine.DirectOptions{
+			Kind:   kind,
+			DryRun: opts.dryRun,
+			Transfer: func(it engine.Item) (files.Operation, error) {
+				op, err := opts.place(fs, kind, it.Source, it.Destination)
+				if err != nil {
+					opts.events.failed(it.Source, it.Destination, err)
+				}
+				return op, err
+			},
+			Done: func(op files.Operation) error {
+				err := opts.finish(fs, op)
+				if err != nil {
+					opts.events.failed(op.Source(), op.Destination(), err)
+				}
+				return err
+			},
+			Jobs:     opts.jobs,
+			Schedule: opts.schedule,
+			Size: func(src string) int64 {
+				if info, err := files.FSOf(fs).Stat(src); err == nil {
+					return info.Size()
+				}
+				return 0
+			},
+		}
+		if !opts.overwrite {
+			direct.Check = func(it engine.Item) error {
+				err := fs.ValidateCopyArgs(it.Source, it.Destination)
+				if err != nil {
+					opts.events.failed(it.Source, it.Destination, err)
+				}
+				return err
+			}
+		}
+		if opts.failures != nil {
+			direct.Failed = opts.skipFailed
+		}
+		mode = engine.NewDirect(opts.reporter(cmd), len(sources), direct)
+	}
+
+	plan, safe, err := opts.safely(func(src string) (string, bool, error) { return opts.destination(fs, src, dstRoot) }, sources, kind, dstRoot)
+	if err != nil {
+		mode.Fail(err)
+		return err
+	}
+	pipeline := engine.Pipeline{
+		Plan: plan,
+		Validate: func(it engine.Item) error {
+			err := opts.checkDestination(it.Destination)
+			opts.events.validated(it.Source, it.Destination, err)
+			return err
+		},
+		Mode: mode,
+	}
+	if opts.failures != nil {
+		pipeline.Failed = opts.skipFailed
+	}
+	if _, err := pipeline.Run(sources); err != nil {
+		return err
+	}
+
+	if opts.dryRun {
+		planned := mode.Planned()
+		ms := make([]output.Mapping, len(planned))
+		for i, it := range planned {
+			ms[i] = output.Mapping{Source: it.Source, Destination: it.Destination, Conflict: fs.IsFile(it.Destination)}
+		}
+		opts.printPlan(cmd, dryVerb, dstRoot, ms)
+		safe.printConfirmation(cmd)
+		return opts.reportFailures(cmd, fs, dstRoot, kind)
+	}
+	opts.summarize(cmd, verb)
+	if err := opts.journalTransferred(cmd, fs, dstRoot); err != nil {
+		return err
+	}
+	return opts.reportFailures(cmd, fs, dstRoot, kind)
+}
+
+// place copies or renames src to dst for non-atomic runs, applying the
+// permission policy to renamed files, and returns the operation done.
+func (o transferOptions) place(fs files.FilesService, kind files.OperationType, src, dst string) (files.Operation, error) {
+	if kind == files.OperationCopy {
+		if err := o.transfer(files.OperationCopy, src, func() error { return fs.Copy(src, dst) }); err != nil {
+			return nil, err
+		}
+		return files.NewCopyOperation(src, dst), nil
+	}
+	if err := fs.EnsureDir(filepath.Dir(dst), o.perms.DirPerm()); err != nil {
+		return nil, err
+	}
+	if err := o.transfer(files.OperationMove, src, func() error { return files.FSOf(fs).Rename(src, dst) }); err != nil {
+		return nil, err
+	}
+	if err := o.perms.ApplyFile(dst); err != nil {
+		return nil, err
+	}
+	return files.NewMoveOperation(src, dst), nil
+}
@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/Tmunayyer/gocamelpack/output"
+	"github.com/Tmunayyer/gocamelpack/pathtmpl"
+	"github.com/Tmunayyer/gocamelpack/rules"
+	"github.com/spf13/cobra"
+)
+
+// explanation collects, for --explain, why every file of a dry run goes
+// where it does: the tag its date came from, the rule or template that
+// placed it, and the steps that moved it from there.
+type explanation struct {
+	overwrite bool
+
+	mu      sync.Mutex
+	notes   map[string][]string
+	skipped []skippedFile
+}
+
+// skippedFile is a file left out of the run and the reason.
+type skippedFile struct {
+	src, reason string
+}
+
+func newExplanation(overwrite bool) *explanation {
+	return &explanation{overwrite: overwrite, notes: map[string][]string{}}
+}
+
+// plan records why src was placed as p.
+func (e *explanation) plan(src string, p routed) {
+	var notes []string
+	var skip string
+	switch {
+	case p.duplicateOf != "":
+		skip = "its content is already archived at " + p.duplicateOf
+	case p.decision != nil && p.decision.Action == rules.ActionSkip:
+		skip = "excluded by " + describeRule(p.decision)
+	case p.suspectDate != "" && p.skip:
+		skip = "its capture date looks wrong: " + p.suspectDate
+	case p.other != "" && p.skip:
+		skip = "it is not a photo or video (--others skip)"
+	case p.damaged != "":
+		notes = append(notes, "quarantine: "+p.damaged)
+	case p.suspectDate != "":
+		notes = append(notes, fmt.Sprintf("review: %s, so the file is held in %s/", p.suspectDate, reviewDir))
+	case p.other == othersUnsorted:
+		notes = append(notes, fmt.Sprintf("others: not a photo or video, so the file is placed in %s/", rules.UnsortedDir))
+	case p.other == othersAsIs:
+		notes = append(notes, "others: not a photo or video, so the file keeps its path below the source folder")
+	default:
+		notes = append(notes, describeDate(p))
+		if p.resolvedBy != "" {
+			notes = append(notes, fmt.Sprintf("layout: chosen by plugin %s", p.resolvedBy))
+		} else {
+			notes = append(notes, describeLayout(p.decision))
+		}
+		if p.burst != "" {
+			notes = append(notes, fmt.Sprintf("burst: grouped with the shots of burst %s", p.burst))
+		}
+		if p.fixedType != "" {
+			notes = append(notes, fmt.Sprintf("extension: the content is %s, so the name ends in %s", p.fixedType, filepath.Ext(p.dst)))
+		}
+		normalized := p.dst
+		if p.geotag != "" {
+			notes = append(notes, "geotag: no location, so it is placed at "+p.geotag)
+		}
+		if p.flattenedFrom != "" {
+			notes = append(notes, fmt.Sprintf("flatten: %s was already taken, so the name is numbered", p.flattenedFrom))
+			normalized = filepath.Join(filepath.Dir(p.dst), p.flattenedFrom)
+		}
+		if p.unnormalized != normalized {
+			notes = append(notes, "normalized: the name was rewritten to the configured Unicode form")
+		}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if skip != "" {
+		e.skipped = append(e.skipped, skippedFile{src: src, reason: skip})
+		return
+	}
+	e.notes[src] = notes
+}
+
+// annotate attaches the recorded reasons to planned, including how a
+// destination that already exists is dealt with.
+func (e *explanation) annotate(planned []output.Mapping) []output.Mapping {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	out := make([]output.Mapping, len(planned))
+	for i, m := range planned {
+		m.Notes = append([]string(nil), e.notes[m.Source]...)
+		if m.Conflict {
+			if e.overwrite {
+				m.Notes = append(m.Notes, "conflict: the destination exists and is replaced because of --overwrite")
+			} else {
+				m.Notes = append(m.Notes, "conflict: the destination exists; the file fails without --overwrite")
+			}
+		}
+		out[i] = m
+	}
+	return out
+}
+
+// printSkipped lists the files left out of the run and why.
+func (e *explanation) printSkipped(cmd *cobra.Command) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	p := output.New(cmd.OutOrStdout())
+	for _, s := range e.skipped {
+		p.Println(output.Dim, "Would skip %s: %s", s.src, s.reason)
+	}
+}
+
+// describeDate names the tag the file's date came from.
+func describeDate(p routed) string {
+	if p.decision != nil && p.decision.Action == rules.ActionUnsorted {
+		return "date: not used for unsorted files"
+	}
+	t, tag, ok := pathtmpl.CaptureTime(p.md)
+	if !ok {
+		return "date: none of " + strings.Join(pathtmpl.DateTags, ", ") + " is usable"
+	}
+	return fmt.Sprintf("date: %s (%s)", tag, t.Format("2006-01-02 15:04:05 -07:00"))
+}
+
+// describeLayout names the rule or template that computed the destination.
+func describeLayout(d *rules.Decision) string {
+	switch {
+	case d == nil:
+		return "layout: built-in " + pathtmpl.Default
+	case d.Action == rules.ActionUnsorted:
+		return "layout: " + describeRule(d) + " puts the file under " + rules.UnsortedDir + "/"
+	case d.Rule == nil && d.Template == nil:
+		return "layout: no rule matched; built-in " + pathtmpl.Default
+	case d.Rule == nil:
+		return "layout: no rule matched; default template " + d.Template.String()
+	}
+	return "layout: " + describeRule(d) + " with template " + d.Template.String()
+}
+
+func describeRule(d *rules.Decision) string {
+	return fmt.Sprintf("rule %q (%s)", d.RuleName(), d.Rule.Describe())
+}
+
+// printPlan prints a dry run's planned mappings, with their explanations
+// under --explain. With --output ndjson the planned events are the plan.
+func (o transferOptions) printPlan(cmd *cobra.Command, verb, dstRoot string, planned []output.Mapping) {
+	if outputFormat(cmd) == "ndjson" {
+		return
+	}
+	if o.explain == nil {
+		printDryRun(cmd, verb, dstRoot, planned, o.tree)
+	} else {
+		printDryRun(cmd, verb, dstRoot, o.explain.annotate(planned), false)
+		o.explain.printSkipped(cmd)
+	}
+	if o.calendar != nil {
+		o.calendar.print(cmd)
+	}
+}
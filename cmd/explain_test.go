@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/config"
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+func TestCopyCmd_Explain(t *testing.T) {
+	tmp := filepath.Join(testutil.TempDir(t), "explain")
+	card, dst := filepath.Join(tmp, "card"), filepath.Join(tmp, "archive")
+	if err := os.MkdirAll(card, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	shot := filepath.Join(card, "shot.png")
+	clip := filepath.Join(card, "clip.mov")
+	scan := filepath.Join(card, "scan.jpg")
+	photo := filepath.Join(card, "photo.jpg")
+	for _, p := range []string{shot, clip, scan, photo} {
+		if err := os.WriteFile(p, []byte(p), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	taken := filepath.Join(dst, "2025", "01", "27", "15_30.jpg")
+	if err := os.MkdirAll(filepath.Dir(taken), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(taken, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	fs := createTestFilesService(map[string]files.FileMetadata{
+		shot:  {Filepath: shot, Tags: map[string]string{"Software": "Screenshot", "CreationDate": "2025:01:27 15:30:45-06:00"}},
+		clip:  {Filepath: clip, Tags: map[string]string{"DateTimeOriginal": "2024:03:09 10:00:00"}},
+		scan:  {Filepath: scan, Tags: map[string]string{}},
+		photo: {Filepath: photo, Tags: map[string]string{"CreationDate": "2025:01:27 15:30:45-06:00"}},
+	})
+	run := func(args ...string) (string, error) {
+		var out bytes.Buffer
+		root := newCLI(&deps.AppDeps{Files: fs, Config: &config.Config{Rules: testRules}, Streams: deps.Streams{Out: &out, Err: &out}})
+		root.SetArgs(args)
+		err := root.Execute()
+		return out.String(), err
+	}
+
+	out, err := run("copy", "--dry-run", "--explain", card, dst)
+	if err != nil {
+		t.Fatalf("copy --dry-run --explain: %v\n%s", err, out)
+	}
+	for _, want := range []string{
+		"date: DateTimeOriginal (2024-03-09 10:00:00",
+		`layout: rule "videos" (ext in [mov]) with template video/{year}/{month}`,
+		`layout: rule "undated"`,
+		"layout: no rule matched; built-in " + "{year}/{month}/{day}/{hour}_{minute}",
+		"date: CreationDate (2025-01-27 15:30:45 -06:00)",
+		"conflict: the destination exists; the file fails without --overwrite",
+		"Would skip " + shot + `: excluded by rule "screenshots"`,
+	} {
+		if !contains(out, want) {
+			t.Errorf("explanation lacks %q:\n%s", want, out)
+		}
+	}
+
+	if _, err := run("copy", "--explain", card, dst); err == nil {
+		t.Error("expected --explain to require --dry-run")
+	}
+}
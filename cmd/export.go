@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/export"
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/hashindex"
+	"github.com/Tmunayyer/gocamelpack/output"
+	"github.com/Tmunayyer/gocamelpack/pathtmpl"
+	"github.com/Tmunayyer/gocamelpack/progress"
+	"github.com/spf13/cobra"
+)
+
+func createExportCmd(d *deps.AppDeps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export [archive-root]",
+		Short: "Pack archived files from a date range into a zip or tar file",
+		Long: `Selects the files below archive-root captured between --since and --until
+(inclusive, YYYY-MM-DD, or a whole YYYY-MM or YYYY; either may be left
+open) and streams them into a zip, tar or tar.gz file with their paths
+relative to the root. A file's date
+comes from its metadata or, when it has none, from date folders such as
+YYYY/MM/DD in its path; files dated neither way are left out. A SHA256SUMS
+manifest of the exported files is added last, for "sha256sum -c" after
+extraction, or an XXH64SUMS one for "xxhsum -c" with --hash-algo xxhash64.
+
+Use "-o -" with --format to write the archive to standard output.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			root, err := filepath.Abs(args[0])
+			if err != nil {
+				return err
+			}
+			if !d.Files.IsDirectory(root) {
+				return fmt.Errorf("%s is not a directory", root)
+			}
+
+			since, _ := cmd.Flags().GetString("since")
+			until, _ := cmd.Flags().GetString("until")
+			r, err := export.ParseRange(since, until)
+			if err != nil {
+				return err
+			}
+			out, _ := cmd.Flags().GetString("out")
+			if out == "" {
+				return fmt.Errorf("-o/--out is required")
+			}
+			format, err := exportFormat(cmd, out)
+			if err != nil {
+				return err
+			}
+			cfg, err := loadConfig(cmd, d)
+			if err != nil {
+				return err
+			}
+			algo, err := hashAlgo(cmd, cfg.HashAlgo)
+			if err != nil {
+				return err
+			}
+
+			var exclude string
+			if out != "-" {
+				if exclude, err = filepath.Abs(out); err != nil {
+					return err
+				}
+			}
+			// Only the capture date is needed to select files.
+			selected, err := selectForExport(files.WithTags(d.Files, pathtmpl.DateTags), root, r, exclude)
+			if err != nil {
+				return err
+			}
+
+			w, summary := cmd.OutOrStdout(), cmd.OutOrStdout()
+			var file *os.File
+			if out == "-" {
+				summary = cmd.ErrOrStderr()
+			} else {
+				if file, err = os.Create(out); err != nil {
+					return err
+				}
+				w = file
+			}
+
+			var reporter progress.ProgressReporter = progress.NewNoOpReporter()
+			if showProgress, _ := cmd.Flags().GetBool("progress"); showProgress {
+				cfg, err := loadConfig(cmd, d)
+				if err != nil {
+					return err
+				}
+				style, err := progressStyle(cmd, cfg)
+				if err != nil {
+					return err
+				}
+				reporter = newProgressDisplay(cmd, style)
+			}
+			err = writeExport(w, format, algo, root, selected, reporter)
+			if file != nil {
+				if cerr := file.Close(); err == nil {
+					err = cerr
+				}
+				if err != nil {
+					os.Remove(out)
+				}
+			}
+			if err != nil {
+				return err
+			}
+			output.New(summary).Success("Exported %d file(s) to %s.", len(selected), out)
+			return nil
+		},
+	}
+
+	cmd.Flags().String("since", "", "First capture day to export, YYYY-MM-DD (or YYYY-MM, YYYY)")
+	cmd.Flags().String("until", "", "Last capture day to export, YYYY-MM-DD (or YYYY-MM, YYYY)")
+	cmd.Flags().StringP("out", "o", "", "Archive to write, or - for standard output")
+	cmd.Flags().String("format", "", "Archive format: zip, tar or tar.gz (default from the -o extension)")
+	cmd.Flags().BoolP("progress", "p", false, "Show progress bar while exporting")
+	cmd.Flags().String("hash-algo", "", "Checksum algorithm of the manifest: sha256 or xxhash64 (default from config, else sha256)")
+	return cmd
+}
+
+// exportFormat resolves --format, falling back to the output file's
+// extension.
+func exportFormat(cmd *cobra.Command, out string) (export.Format, error) {
+	if f, _ := cmd.Flags().GetString("format"); f != "" {
+		return export.ParseFormat(f)
+	}
+	if out == "-" {
+		return "", fmt.Errorf("--format is required when writing to standard output")
+	}
+	return export.FormatFor(out)
+}
+
+// selectForExport returns the files below root, other than exclude, whose
+// capture date falls within r.
+func selectForExport(fs files.FilesService, root string, r export.Range, exclude string) ([]string, error) {
+	var selected []string
+	err := walkArchive(root, func(path string) error {
+		if path == exclude {
+			return nil
+		}
+		if tags := fs.GetFileTags([]string{path}); len(tags) > 0 {
+			if t, _, ok := pathtmpl.CaptureTime(tags[0]); ok {
+				if r.Contains(t) {
+					selected = append(selected, path)
+				}
+				return nil
+			}
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		if first, last, ok := export.FolderPeriod(filepath.ToSlash(rel)); ok && r.Overlaps(first, last) {
+			selected = append(selected, path)
+		}
+		return nil
+	})
+	return selected, err
+}
+
+// writeExport streams paths into an archive of the given format on w.
+func writeExport(w io.Writer, format export.Format, algo hashindex.Algo, root string, paths []string, reporter progress.ProgressReporter) (err error) {
+	defer func() {
+		if err != nil {
+			reporter.SetError(err)
+		}
+	}()
+	a, err := export.New(w, format, algo)
+	if err != nil {
+		return err
+	}
+
+	reporter.SetTotal(len(paths))
+	for _, p := range paths {
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		reporter.SetMessage(fmt.Sprintf("Exporting %s", rel))
+		if err := a.AddFile(p, filepath.ToSlash(rel)); err != nil {
+			return err
+		}
+		reporter.Increment()
+	}
+	if err := a.Close(); err != nil {
+		return err
+	}
+	reporter.Finish()
+	return nil
+}
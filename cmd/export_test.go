@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/config"
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/export"
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+func TestExportCmd(t *testing.T) {
+	tmp := testutil.TempDir(t)
+	root := filepath.Join(tmp, "archive")
+	metadata := map[string]files.FileMetadata{}
+	write := func(rel, date string) {
+		p := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(rel), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		tags := map[string]string{}
+		if date != "" {
+			tags["CreationDate"] = date
+		}
+		metadata[p] = files.FileMetadata{Filepath: p, Tags: tags}
+	}
+	write("2025/06/01/10_00.jpg", "2025:06:01 10:00:00")
+	write("2025/06/30/23_59.jpg", "2025:06:30 23:59:00-06:00")
+	write("2025/07/01/08_00.jpg", "2025:07:01 08:00:00")
+	write("2025/06/15/scan.png", "")                        // dated by its folders only
+	write("2025/07/02/scan.png", "")                        // outside the range by its folders
+	write("misc/notes.txt", "")                             // no date at all
+	write("2025/05/31/misfiled.jpg", "2025:06:10 12:00:00") // metadata wins
+
+	out := filepath.Join(tmp, "june.zip")
+	var log bytes.Buffer
+	cli := newCLI(&deps.AppDeps{Files: createTestFilesService(metadata), Config: &config.Config{}, Streams: deps.Streams{Out: &log, Err: &log}})
+	cli.SetArgs([]string{"export", root, "--since", "2025-06-01", "--until", "2025-06-30", "-o", out})
+	if err := cli.Execute(); err != nil {
+		t.Fatalf("export: %v\n%s", err, log.String())
+	}
+	if !contains(log.String(), "Exported 4 file(s)") {
+		t.Errorf("unexpected summary:\n%s", log.String())
+	}
+
+	zr, err := zip.OpenReader(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	sort.Strings(names)
+	want := []string{"2025/05/31/misfiled.jpg", "2025/06/01/10_00.jpg", "2025/06/15/scan.png", "2025/06/30/23_59.jpg", export.ManifestName}
+	sort.Strings(want)
+	if len(names) != len(want) {
+		t.Fatalf("archive entries = %v, want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Errorf("archive entries = %v, want %v", names, want)
+			break
+		}
+	}
+}
+
+func TestExportCmd_FormatRequiredForStdout(t *testing.T) {
+	root := testutil.TempDir(t)
+	var log bytes.Buffer
+	cli := newCLI(&deps.AppDeps{Files: createTestFilesService(nil), Config: &config.Config{}, Streams: deps.Streams{Out: &log, Err: &log}})
+	cli.SetArgs([]string{"export", root, "-o", "-"})
+	if err := cli.Execute(); err == nil || !contains(err.Error(), "--format") {
+		t.Errorf("expected a --format error, got %v", err)
+	}
+}
@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/internal/engine"
+	"github.com/Tmunayyer/gocamelpack/journal"
+	"github.com/Tmunayyer/gocamelpack/output"
+	"github.com/Tmunayyer/gocamelpack/progress"
+	"github.com/spf13/cobra"
+)
+
+// failedFile is a file a --skip-errors run left behind.
+type failedFile struct {
+	Source      string `json:"source"`
+	Destination string `json:"destination,omitempty"`
+	Error       string `json:"error"`
+}
+
+// failureList collects the failed events of a --skip-errors run.
+type failureList struct {
+	files []failedFile
+}
+
+func (f *failureList) onEvent(e fileEvent) {
+	if e.Event == eventFailed {
+		f.files = append(f.files, failedFile{Source: e.Source, Destination: e.Destination, Error: e.Error})
+	}
+}
+
+// skipFailed is the engine's Failed for --skip-errors: the file's failed
+// event is already recorded, so the run carries on without it.
+func (o transferOptions) skipFailed(engine.Item, error) error { return nil }
+
+// failedObject is the JSON shape of the failure list when --output is
+// json or ndjson.
+type failedObject struct {
+	Failed []failedFile `json:"failed"`
+}
+
+// reportFailures ends a --skip-errors run that left files behind: it lists
+// every failed file with its error after the summary, records them in the
+// session's journal for the retry command, writes the sources to the
+// --retry-list file and returns an error for the exit status.
+func (o transferOptions) reportFailures(cmd *cobra.Command, fs files.FilesService, dstRoot string, kind files.OperationType) error {
+	if o.failures == nil || len(o.failures.files) == 0 {
+		return nil
+	}
+	failed := o.failures.files
+	if jsonLines(cmd) {
+		json.NewEncoder(cmd.OutOrStdout()).Encode(failedObject{Failed: failed})
+	} else {
+		errs := make([]error, len(failed))
+		for i, f := range failed {
+			errs[i] = fmt.Errorf("%s: %s", f.Source, f.Error)
+		}
+		output.New(cmd.OutOrStdout()).ErrorList(fmt.Sprintf("Failed %d file(s):", len(failed)), errs)
+	}
+	if !o.dryRun {
+		if err := o.journal(fs, dstRoot).RecordFailures(journalFailures(kind, failed)); err != nil {
+			return fmt.Errorf("%d file(s) failed, and they could not be journaled: %w", len(failed), err)
+		}
+	}
+	if o.retryList != "" {
+		if err := writeRetryList(o.retryList, failed); err != nil {
+			return fmt.Errorf("%d file(s) failed, and the retry list could not be written: %w", len(failed), err)
+		}
+		return fmt.Errorf("%d file(s) failed; retry them with --from-file %s", len(failed), o.retryList)
+	}
+	if o.dryRun {
+		return fmt.Errorf("%d file(s) failed", len(failed))
+	}
+	return fmt.Errorf("%d file(s) failed; retry them with: gocamelpack retry %s %s", len(failed), o.session, dstRoot)
+}
+
+func journalFailures(kind files.OperationType, failed []failedFile) []journal.Failure {
+	out := make([]journal.Failure, len(failed))
+	for i, f := range failed {
+		out[i] = journal.Failure{Operation: journal.Operation{Type: kind.String(), Source: f.Source, Destination: f.Destination}, Error: f.Error}
+	}
+	return out
+}
+
+// writeRetryList writes the failed sources one per line, the format
+// --from-file reads.
+func writeRetryList(path string, failed []failedFile) error {
+	var b strings.Builder
+	for _, f := range failed {
+		b.WriteString(f.Source + "\n")
+	}
+	return os.WriteFile(path, []byte(b.String()), 0o644)
+}
+
+// transferArgs accepts a source and a destination, or only the
+// destination with --from-file.
+func transferArgs(cmd *cobra.Command, args []string) error {
+	if from, _ := cmd.Flags().GetString("from-file"); from != "" {
+		if len(args) != 1 {
+			return fmt.Errorf("with --from-file, accepts only the destination, received %d arg(s)", len(args))
+		}
+		return nil
+	}
+	return cobra.ExactArgs(2)(cmd, args)
+}
+
+// readSourceList collects the sources listed in path, one file or
+// directory per line. Blank lines and lines starting with # are ignored;
+// relative paths are relative to the working directory.
+func (w *sourceWalk) readSourceList(fs files.FilesService, path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("--from-file: %w", err)
+	}
+	defer f.Close()
+
+	var sources []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		found, err := w.collect(fs, line, progress.NewNoOpReporter())
+		if errors.Is(err, files.ErrSourceMissing) {
+			return nil, files.Errorf(files.ErrSourceMissing, "%s (listed in %s) does not exist", line, path)
+		}
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, found...)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("--from-file: %w", err)
+	}
+	return sources, nil
+}
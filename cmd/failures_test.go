@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/config"
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+// failingSources writes good1.jpg, nodate.jpg and good2.jpg to dir; only
+// nodate.jpg cannot be placed.
+func failingSources(t *testing.T, dir string) map[string]files.FileMetadata {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	md := map[string]files.FileMetadata{}
+	for name, date := range map[string]string{"good1.jpg": "2025:01:27 15:30:45-06:00", "nodate.jpg": "", "good2.jpg": "2025:01:28 09:10:00-06:00"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+		md[path] = files.FileMetadata{Filepath: path, Tags: map[string]string{"CreationDate": date}}
+	}
+	return md
+}
+
+func TestCopyCmd_SkipErrors(t *testing.T) {
+	tempDir := testutil.TempDir(t)
+	srcDir := filepath.Join(tempDir, "src")
+	retryList := filepath.Join(tempDir, "retry.txt")
+	md := failingSources(t, srcDir)
+
+	cmd := createCopyCmd(&deps.AppDeps{Files: createTestFilesService(md), Config: &config.Config{}})
+	cmd.SetArgs([]string{"--skip-errors", "--retry-list", retryList, srcDir, filepath.Join(tempDir, "dst")})
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&bytes.Buffer{})
+
+	err := cmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "1 file(s) failed") {
+		t.Fatalf("expected the run to fail with 1 file, got %v", err)
+	}
+	bad := filepath.Join(srcDir, "nodate.jpg")
+	got := out.String()
+	if !strings.Contains(got, "Copied 2 file(s)") {
+		t.Errorf("expected the other files to be copied, got %q", got)
+	}
+	if !strings.Contains(got, "Failed 1 file(s):") || !strings.Contains(got, bad+": CreationDate is missing") {
+		t.Errorf("expected the failed file with its error, got %q", got)
+	}
+
+	list, err := os.ReadFile(retryList)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(list) != bad+"\n" {
+		t.Errorf("retry list = %q", list)
+	}
+}
+
+func TestCopyCmd_SkipErrorsJSON(t *testing.T) {
+	tempDir := testutil.TempDir(t)
+	srcDir := filepath.Join(tempDir, "src")
+	md := failingSources(t, srcDir)
+
+	var stdout bytes.Buffer
+	root := newCLI(&deps.AppDeps{
+		Files:   createTestFilesService(md),
+		Streams: deps.Streams{Out: &stdout, Err: &bytes.Buffer{}},
+	})
+	root.SetArgs([]string{"--output", "json", "copy", "--skip-errors", srcDir, filepath.Join(tempDir, "dst")})
+	if err := root.Execute(); err == nil {
+		t.Fatal("expected the run to fail")
+	}
+
+	var got failedObject
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		if strings.HasPrefix(line, `{"failed"`) {
+			if err := json.Unmarshal([]byte(line), &got); err != nil {
+				t.Fatalf("invalid failure list %q: %v", line, err)
+			}
+		}
+	}
+	if len(got.Failed) != 1 || got.Failed[0].Source != filepath.Join(srcDir, "nodate.jpg") || got.Failed[0].Error == "" {
+		t.Errorf("failed = %+v", got.Failed)
+	}
+}
+
+func TestCopyCmd_FromFile(t *testing.T) {
+	tempDir := testutil.TempDir(t)
+	srcDir := filepath.Join(tempDir, "src")
+	md := failingSources(t, srcDir)
+	list := filepath.Join(tempDir, "list.txt")
+	content := "# retry\n" + filepath.Join(srcDir, "good1.jpg") + "\n\n" + filepath.Join(srcDir, "good2.jpg") + "\n"
+	if err := os.WriteFile(list, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := createCopyCmd(&deps.AppDeps{Files: createTestFilesService(md), Config: &config.Config{}})
+	cmd.SetArgs([]string{"--from-file", list, filepath.Join(tempDir, "dst")})
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&bytes.Buffer{})
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("copy --from-file failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "Copied 2 file(s)") {
+		t.Errorf("expected the listed files to be copied, got %q", out.String())
+	}
+}
+
+func TestSkipErrorsFlagValidation(t *testing.T) {
+	tempDir := testutil.TempDir(t)
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"--skip-errors", "--atomic", tempDir, tempDir}, "--skip-errors cannot be combined with --atomic"},
+		{[]string{"--retry-list", "r.txt", tempDir, tempDir}, "--retry-list requires --skip-errors"},
+		{[]string{"--from-file", "list.txt", tempDir, tempDir}, "only the destination"},
+	}
+	for _, tc := range tests {
+		cmd := createCopyCmd(&deps.AppDeps{Files: createTestFilesService(nil), Config: &config.Config{}})
+		cmd.SetArgs(tc.args)
+		cmd.SetOut(&bytes.Buffer{})
+		cmd.SetErr(&bytes.Buffer{})
+		if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%v: expected %q, got %v", tc.args, tc.want, err)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/Tmunayyer/gocamelpack/vfs"
+)
+
+// flattener places every file directly under the destination root
+// (--flatten), with the name the layout gives it. Names another file of the
+// run, or a file already there, has taken are numbered: IMG_0001.jpg,
+// IMG_0001_1.jpg, IMG_0001_2.jpg and so on.
+type flattener struct {
+	// mu guards taken, for streamed runs.
+	mu    sync.Mutex
+	taken map[string]bool
+}
+
+func newFlattener() *flattener {
+	return &flattener{taken: map[string]bool{}}
+}
+
+// claim returns the first free variant of dst. With overwrite, files
+// already on disk may be replaced, so only the run's own names count.
+func (f *flattener) claim(fsys vfs.FS, dst string, overwrite bool) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ext := filepath.Ext(dst)
+	stem := strings.TrimSuffix(dst, ext)
+	for n := 0; ; n++ {
+		candidate := dst
+		if n > 0 {
+			candidate = fmt.Sprintf("%s_%d%s", stem, n, ext)
+		}
+		if f.taken[candidate] {
+			continue
+		}
+		if _, err := fsys.Lstat(candidate); err == nil && !overwrite {
+			continue
+		}
+		f.taken[candidate] = true
+		return candidate
+	}
+}
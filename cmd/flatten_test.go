@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/config"
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+func TestCopyCmd_Flatten(t *testing.T) {
+	tempDir := testutil.TempDir(t)
+	srcDir := filepath.Join(tempDir, "card")
+	dstDir := filepath.Join(tempDir, "dst")
+	for _, rel := range []string{"a/IMG_0001.jpg", "b/IMG_0001.jpg", "c/IMG_0001.jpg"} {
+		path := filepath.Join(srcDir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(rel), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A file already in the destination keeps its name too.
+	if err := os.MkdirAll(dstDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dstDir, "IMG_0001.jpg"), []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(args ...string) error {
+		cmd := createCopyCmd(&deps.AppDeps{Files: createTestFilesService(nil), Config: &config.Config{}})
+		cmd.SetArgs(args)
+		cmd.SetOut(&bytes.Buffer{})
+		cmd.SetErr(&bytes.Buffer{})
+		return cmd.Execute()
+	}
+	if err := run("--flatten", "--recursive", "--template", "{year}/{month}/{orig}", srcDir, dstDir); err != nil {
+		t.Fatalf("copy: %v", err)
+	}
+	want := map[string]string{
+		"IMG_0001.jpg":   "old",
+		"IMG_0001_1.jpg": "a/IMG_0001.jpg",
+		"IMG_0001_2.jpg": "b/IMG_0001.jpg",
+		"IMG_0001_3.jpg": "c/IMG_0001.jpg",
+	}
+	for name, content := range want {
+		if data, err := os.ReadFile(filepath.Join(dstDir, name)); err != nil || string(data) != content {
+			t.Errorf("%s: got %q, %v; want %q", name, data, err, content)
+		}
+	}
+	if entries, _ := os.ReadDir(dstDir); len(entries) != len(want) {
+		t.Errorf("expected only files directly under the destination, got %v", entries)
+	}
+
+	if err := run("--flatten", "--preserve-structure", srcDir, dstDir); err == nil || !strings.Contains(err.Error(), "cannot be combined") {
+		t.Errorf("expected --preserve-structure to be refused, got %v", err)
+	}
+}
@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/geotag"
+	"github.com/Tmunayyer/gocamelpack/output"
+	"github.com/Tmunayyer/gocamelpack/pathtmpl"
+	"github.com/spf13/cobra"
+)
+
+// geotagTags are the tags read to tell whether a photo has a location.
+var geotagTags = []string{"GPSLatitude"}
+
+// geotagger writes the position of the GPX tracks at their capture time
+// into imported photos that have no location (--geotag).
+type geotagger struct {
+	tracks geotag.Tracks
+	maxGap time.Duration
+	// loc is the zone of capture dates without an offset: the camera's
+	// clock is taken to be on local time, as exiftool does.
+	loc *time.Location
+
+	mu      sync.Mutex
+	tags    map[string]map[string]string // source -> GPS tags
+	missing int
+}
+
+// geotaggerFromFlags reads --geotag, --gpx and --geotag-max-gap; nil
+// without --geotag.
+func geotaggerFromFlags(cmd *cobra.Command) (*geotagger, error) {
+	enabled, _ := cmd.Flags().GetBool("geotag")
+	if !enabled {
+		for _, name := range []string{"gpx", "geotag-max-gap"} {
+			if cmd.Flags().Changed(name) {
+				return nil, fmt.Errorf("--%s requires --geotag", name)
+			}
+		}
+		return nil, nil
+	}
+	g := &geotagger{loc: time.Local, tags: map[string]map[string]string{}}
+	if g.maxGap, _ = cmd.Flags().GetDuration("geotag-max-gap"); g.maxGap <= 0 {
+		return nil, fmt.Errorf("--geotag-max-gap must be positive")
+	}
+	paths, _ := cmd.Flags().GetStringSlice("gpx")
+	for _, path := range paths {
+		if err := g.tracks.Load(path); err != nil {
+			return nil, fmt.Errorf("--gpx: %w", err)
+		}
+	}
+	return g, nil
+}
+
+// addSources loads the GPX tracks among the run's sources, such as the
+// logs a camera or GPS unit left on the card. A track that cannot be read
+// is warned about and ignored.
+func (g *geotagger) addSources(cmd *cobra.Command, sources []string) {
+	if g == nil {
+		return
+	}
+	for _, src := range sources {
+		if !geotag.IsGPX(src) {
+			continue
+		}
+		if err := g.tracks.Load(src); err != nil {
+			output.New(cmd.ErrOrStderr()).Warn("geotag: %v", err)
+		}
+	}
+}
+
+// plan finds where the photo src was taken when it has no location,
+// returning the position for --explain, or "" when it is left alone.
+func (g *geotagger) plan(src string, md files.FileMetadata) string {
+	if g == nil || files.MediaKindOf(src) != files.MediaImage || strings.TrimSpace(md.Tags["GPSLatitude"]) != "" {
+		return ""
+	}
+	t, tag, ok := pathtmpl.CaptureTime(md)
+	if !ok {
+		return ""
+	}
+	if raw := strings.TrimSpace(md.Tags[tag]); len(raw) <= len("2006:01:02 15:04:05") {
+		t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), g.loc)
+	}
+	p, ok := g.tracks.Locate(t, g.maxGap)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !ok {
+		g.missing++
+		return ""
+	}
+	g.tags[src] = p.Tags()
+	return fmt.Sprintf("%s from %s", p, p.Track)
+}
+
+// tagsFor returns the GPS tags planned for src.
+func (g *geotagger) tagsFor(src string) map[string]string {
+	if g == nil {
+		return nil
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.tags[src]
+}
+
+// close reports how many photos without a location were placed on the
+// tracks.
+func (g *geotagger) close(cmd *cobra.Command, dryRun bool) {
+	if g == nil {
+		return
+	}
+	p := output.New(cmd.ErrOrStderr())
+	if g.tracks.Len() == 0 {
+		p.Warn("geotag: no GPX track points found; pass tracks with --gpx")
+		return
+	}
+	verb := "Geotagged"
+	if dryRun {
+		verb = "Would geotag"
+	}
+	if len(g.tags) > 0 {
+		p.Println(output.Dim, "%s %d photo(s) from %d track point(s)", verb, len(g.tags), g.tracks.Len())
+	}
+	if g.missing > 0 {
+		p.Warn("%d photo(s) without a location have no track point within %s of their capture time", g.missing, g.maxGap)
+	}
+}
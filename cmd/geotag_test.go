@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/config"
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+const geotagTrack = `<?xml version="1.0"?>
+<gpx version="1.1" xmlns="http://www.topografix.com/GPX/1/1"><trk><trkseg>
+  <trkpt lat="48.0" lon="2.0"><time>2025-01-27T21:30:00Z</time></trkpt>
+  <trkpt lat="48.2" lon="2.4"><time>2025-01-27T21:31:30Z</time></trkpt>
+</trkseg></trk></gpx>`
+
+func TestCopyCmd_Geotag(t *testing.T) {
+	tmp := filepath.Join(testutil.TempDir(t), "geotag")
+	card, dst := filepath.Join(tmp, "card"), filepath.Join(tmp, "dst")
+	if err := os.MkdirAll(card, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	bare, located, track := filepath.Join(card, "bare.jpg"), filepath.Join(card, "located.jpg"), filepath.Join(card, "walk.gpx")
+	md := map[string]files.FileMetadata{
+		bare:    {Filepath: bare, Tags: map[string]string{"CreationDate": "2025:01:27 15:30:45-06:00"}},
+		located: {Filepath: located, Tags: map[string]string{"CreationDate": "2025:01:27 15:31:00-06:00", "GPSLatitude": "51.5"}},
+	}
+	for path, content := range map[string]string{bare: "bare", located: "located", track: geotagTrack} {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	run := func(fs files.FilesService, args ...string) string {
+		t.Helper()
+		var out bytes.Buffer
+		root := newCLI(&deps.AppDeps{Files: fs, Config: &config.Config{}, Streams: deps.Streams{Out: &out, Err: &out}})
+		root.SetArgs(append(append([]string{"copy", "--geotag", "--others", "skip"}, args...), card, dst))
+		if err := root.Execute(); err != nil {
+			t.Fatalf("copy %v: %v\n%s", args, err, out.String())
+		}
+		return out.String()
+	}
+
+	out := run(createTestFilesService(md), "--dry-run", "--explain")
+	for _, want := range []string{"geotag: no location, so it is placed at 48.10000, 2.20000 from walk.gpx", "Would geotag 1 photo(s) from 2 track point(s)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in:\n%s", want, out)
+		}
+	}
+
+	fs := newTaggingFilesService(md)
+	run(fs)
+	tags := fs.written[filepath.Join(dst, "2025", "01", "27", "15_30.jpg")]
+	if tags["GPSLatitude"] != "48.100000" || tags["GPSLongitudeRef"] != "E" {
+		t.Errorf("expected the photo without a location to be geotagged, got %v", fs.written)
+	}
+	if _, ok := fs.written[filepath.Join(dst, "2025", "01", "27", "15_31.jpg")]; ok {
+		t.Error("a photo with a location should be left alone")
+	}
+}
+
+func TestCopyCmd_GeotagFlags(t *testing.T) {
+	tmp := testutil.TempDir(t)
+	for _, tc := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"--gpx", "walk.gpx"}, "--gpx requires --geotag"},
+		{[]string{"--geotag", "--gpx", filepath.Join(tmp, "missing.gpx")}, "--gpx:"},
+		{[]string{"--geotag", "--link", "hard"}, "--geotag cannot be combined with --link"},
+	} {
+		var out bytes.Buffer
+		root := newCLI(&deps.AppDeps{Files: createTestFilesService(nil), Config: &config.Config{}, Streams: deps.Streams{Out: &out, Err: &out}})
+		root.SetArgs(append(append([]string{"copy"}, tc.args...), tmp, filepath.Join(tmp, "dst")))
+		if err := root.Execute(); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("copy %v: expected an error containing %q, got %v", tc.args, tc.want, err)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/Tmunayyer/gocamelpack/units"
+	"github.com/Tmunayyer/gocamelpack/vfs"
+)
+
+// runLimits aborts a run whose sources exceed --max-files or --max-bytes,
+// e.g. when a backup drive was mounted where a card was expected. A zero
+// limit is off.
+type runLimits struct {
+	maxFiles int
+	maxBytes units.ByteSize
+
+	files int
+	bytes units.ByteSize
+}
+
+// add counts src, read through fsys, towards the limits and fails once
+// one is exceeded.
+func (l *runLimits) add(fsys vfs.FS, src string) error {
+	if l == nil {
+		return nil
+	}
+	l.files++
+	if l.maxFiles > 0 && l.files > l.maxFiles {
+		return fmt.Errorf("more than --max-files %d source file(s)", l.maxFiles)
+	}
+	if l.maxBytes > 0 {
+		if info, err := fsys.Stat(src); err == nil {
+			l.bytes += units.ByteSize(info.Size())
+		}
+		if l.bytes > l.maxBytes {
+			return fmt.Errorf("sources exceed --max-bytes %s", l.maxBytes)
+		}
+	}
+	return nil
+}
+
+// check counts every source before planning, failing if they exceed the
+// limits.
+func (l *runLimits) check(fsys vfs.FS, sources []string) error {
+	for _, src := range sources {
+		if err := l.add(fsys, src); err != nil {
+			return fmt.Errorf("aborted before planning: %w (%d file(s) collected)", err, len(sources))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/config"
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/testutil"
+	"github.com/Tmunayyer/gocamelpack/units"
+	"github.com/Tmunayyer/gocamelpack/vfs"
+)
+
+func TestCopyCmd_Limits(t *testing.T) {
+	tempDir := testutil.TempDir(t)
+	srcDir := filepath.Join(tempDir, "src")
+	dstDir := filepath.Join(tempDir, "dst")
+	if err := os.MkdirAll(srcDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a.jpg", "b.jpg", "c.jpg"} {
+		if err := os.WriteFile(filepath.Join(srcDir, name), bytes.Repeat([]byte("x"), 1024), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		cfg  config.Config
+		args []string
+		want string
+	}{
+		{"files", config.Config{}, []string{"--max-files", "2"}, "more than --max-files 2"},
+		{"bytes", config.Config{}, []string{"--max-bytes", "2KiB"}, "exceed --max-bytes 2.0 KiB"},
+		{"config", config.Config{MaxBytes: units.ByteSize(2048)}, nil, "exceed --max-bytes"},
+		{"stream", config.Config{}, []string{"--stream", "--max-files", "1"}, "stopped: more than --max-files 1"},
+		{"within", config.Config{MaxFiles: 1}, []string{"--max-files", "3", "--max-bytes", "3KiB", "--dry-run"}, ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cmd := createCopyCmd(&deps.AppDeps{Files: createTestFilesService(nil), Config: &tc.cfg})
+			cmd.SetArgs(append(append([]string{"--template", "{orig_noext}"}, tc.args...), srcDir, dstDir))
+			cmd.SetOut(&bytes.Buffer{})
+			cmd.SetErr(&bytes.Buffer{})
+			err := cmd.Execute()
+			if tc.want == "" {
+				if err != nil {
+					t.Fatalf("expected the run within its limits to pass, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("expected %q, got %v", tc.want, err)
+			}
+			if tc.name != "stream" {
+				if copied, _ := filepath.Glob(filepath.Join(dstDir, "*.jpg")); len(copied) > 0 {
+					t.Errorf("nothing should be transferred, got %v", copied)
+				}
+			}
+		})
+	}
+}
+
+func TestRunLimits_ReadsThroughFS(t *testing.T) {
+	// The sources exist only in memory, as with --simulate.
+	mem := vfs.NewMem(nil)
+	if err := mem.MkdirAll("/card", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"/card/a.jpg", "/card/b.jpg"} {
+		f, err := mem.OpenFile(name, os.O_CREATE|os.O_WRONLY, 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write(bytes.Repeat([]byte("x"), 1024))
+		f.Close()
+	}
+	limits := &runLimits{maxBytes: 1536}
+	if err := limits.check(mem, []string{"/card/a.jpg", "/card/b.jpg"}); err == nil || !strings.Contains(err.Error(), "--max-bytes") {
+		t.Errorf("check = %v, want the sizes in memory to exceed --max-bytes", err)
+	}
+}
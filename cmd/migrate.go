@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/output"
+	"github.com/spf13/cobra"
+)
+
+func createMigrateCmd(d *deps.AppDeps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrate [archive-root]",
+		Short: "Move archived files to where a new template places them",
+		Long: `Recomputes the destination of every file in an archive from its metadata,
+with --template (or the configured template and rules), and moves the files
+that are not already there. The moves form one transaction: if any fails,
+the ones already made are undone. Nothing is moved when two files would end
+up at the same place or a target is already taken. Folders emptied by the
+migration are removed; files without a usable date are left where they are.
+
+Remember to update the template in the config file afterwards so new imports
+use the same layout.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			root, err := filepath.Abs(args[0])
+			if err != nil {
+				return err
+			}
+			if !d.Files.IsDirectory(root) {
+				return fmt.Errorf("%s is not a directory", root)
+			}
+
+			var opts transferOptions
+			opts.dryRun, _ = cmd.Flags().GetBool("dry-run")
+			opts.showProgress, _ = cmd.Flags().GetBool("progress")
+			opts.tree, _ = cmd.Flags().GetBool("tree")
+			if opts.tree && !opts.dryRun {
+				return fmt.Errorf("--tree requires --dry-run")
+			}
+
+			cfg, err := loadConfig(cmd, d)
+			if err != nil {
+				return err
+			}
+			if opts.routing, err = routingEngine(cmd, cfg); err != nil {
+				return err
+			}
+			if opts.progressStyle, err = progressStyle(cmd, cfg); err != nil {
+				return err
+			}
+			normalize, _ := cmd.Flags().GetString("normalize")
+			if normalize == "" {
+				normalize = cfg.Normalize
+			}
+			if opts.normalization, err = files.ParseNormalization(normalize); err != nil {
+				return err
+			}
+			insensitive, err := destinationCaseInsensitive("auto", root, opts.dryRun)
+			if err != nil {
+				return err
+			}
+			opts.collisions = files.NewCollisionTracker(insensitive)
+			opts.destinations = cfg.Destinations
+			if err := opts.destinations.CheckRoot(root); err != nil {
+				return err
+			}
+			if noLock, _ := cmd.Flags().GetBool("no-lock"); !noLock && !opts.dryRun {
+				if opts.lock, err = files.LockDirs([]string{root}); err != nil {
+					return fmt.Errorf("%w; use --no-lock to bypass", err)
+				}
+			}
+			defer opts.close(cmd)
+
+			return performMigration(d.Files, root, opts, cmd)
+		},
+	}
+
+	cmd.Flags().String("template", "", "Template to migrate to (default from config, else the built-in layout)")
+	cmd.Flags().String("locale", "", "Language of {month_name} and {weekday} in the template, e.g. de or fr (default from config, else English)")
+	cmd.Flags().String("normalize", "", "Unicode normalization of created names: nfc, nfd or none (default from config, else nfc)")
+	cmd.Flags().Bool("dry-run", false, "Show the moves without making them")
+	cmd.Flags().Bool("tree", false, "With --dry-run, show the migrated hierarchy as a tree with file counts")
+	cmd.Flags().BoolP("progress", "p", false, "Show progress bar while moving")
+	cmd.Flags().Bool("no-lock", false, "Do not lock the archive against concurrent runs")
+	return cmd
+}
+
+// performMigration moves every file below root that is not at its
+// recomputed destination, as one transaction.
+func performMigration(fs files.FilesService, root string, opts transferOptions, cmd *cobra.Command) error {
+	placed, unchecked, err := placeArchive(fs, root, opts.routing, opts.normalization)
+	if err != nil {
+		return err
+	}
+	if len(unchecked) > 0 {
+		output.New(cmd.ErrOrStderr()).Warn("leaving %d file(s) without a usable destination in place", len(unchecked))
+	}
+
+	tx := fs.NewTransaction(false)
+	targets := map[string]string{}
+	inPlace := 0
+	for _, p := range placed {
+		if files.NormalizeBelow(root, p.Path, opts.normalization) == p.Expected {
+			inPlace++
+			continue
+		}
+		// Two files mapping to the same place are only caught by
+		// validation once one of them exists there; check up front.
+		if prev, ok := targets[p.Expected]; ok {
+			return files.Errorf(files.ErrConflict, "%q and %q would both move to %q", prev, p.Path, p.Expected)
+		}
+		targets[p.Expected] = p.Path
+		if err := opts.checkDestination(p.Expected); err != nil {
+			return err
+		}
+		if err := tx.Add(files.NewMoveOperation(p.Path, p.Expected)); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Validate(); err != nil {
+		return err
+	}
+	if opts.dryRun {
+		printDryRun(cmd, "Would move", root, plannedMappings(fs, tx.Operations()), opts.tree)
+		return nil
+	}
+
+	if opts.reportsProgress() {
+		err = tx.ExecuteWithProgress(opts.reporter(cmd))
+	} else {
+		err = tx.Execute()
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, op := range tx.Completed() {
+		removeEmptyDirs(filepath.Dir(op.Source()), root)
+	}
+	printSummary(cmd, "Migrated", len(tx.Operations()), inPlace, nil, nil)
+	return nil
+}
+
+// removeEmptyDirs removes dir and its parents below root for as long as
+// they are empty.
+func removeEmptyDirs(dir, root string) {
+	for dir != root && len(dir) > len(root) {
+		if os.Remove(dir) != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/config"
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+func TestMigrateCmd(t *testing.T) {
+	tmp := testutil.TempDir(t)
+	metadata := map[string]files.FileMetadata{}
+	write := func(root, rel, date string) string {
+		p := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(rel), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		metadata[p] = files.FileMetadata{Filepath: p, Tags: map[string]string{"CreationDate": date}}
+		return p
+	}
+	run := func(args ...string) (string, error) {
+		var out bytes.Buffer
+		root := newCLI(&deps.AppDeps{Files: createTestFilesService(metadata), Config: &config.Config{}, Streams: deps.Streams{Out: &out, Err: &out}})
+		root.SetArgs(append([]string{"migrate", "--template", "{year}/{month}/{hour}_{minute}"}, args...))
+		err := root.Execute()
+		return out.String(), err
+	}
+
+	t.Run("moves files into the new layout", func(t *testing.T) {
+		root := filepath.Join(tmp, "archive")
+		write(root, "2025/01/27/15_30.jpg", "2025:01:27 15:30:00")
+		write(root, "2025/01/28/09_00.jpg", "2025:01:28 09:00:00")
+		write(root, "2025/01/09_45.jpg", "2025:01:05 09:45:00")
+
+		out, err := run("--dry-run", root)
+		if err != nil {
+			t.Fatalf("dry run: %v\n%s", err, out)
+		}
+		if _, err := os.Stat(filepath.Join(root, "2025/01/27/15_30.jpg")); err != nil {
+			t.Fatalf("dry run moved files: %v", err)
+		}
+
+		out, err = run(root)
+		if err != nil {
+			t.Fatalf("migrate: %v\n%s", err, out)
+		}
+		if !contains(out, "Migrated 2 file(s), skipped 1") {
+			t.Errorf("unexpected summary:\n%s", out)
+		}
+		for _, rel := range []string{"2025/01/15_30.jpg", "2025/01/09_00.jpg", "2025/01/09_45.jpg"} {
+			if _, err := os.Stat(filepath.Join(root, rel)); err != nil {
+				t.Errorf("%s missing after migration: %v", rel, err)
+			}
+		}
+		for _, rel := range []string{"2025/01/27", "2025/01/28"} {
+			if _, err := os.Stat(filepath.Join(root, rel)); !os.IsNotExist(err) {
+				t.Errorf("emptied folder %s should be removed, stat err = %v", rel, err)
+			}
+		}
+		if _, err := os.Stat(filepath.Join(root, files.LockName)); !os.IsNotExist(err) {
+			t.Errorf("lock should be released, stat err = %v", err)
+		}
+	})
+
+	t.Run("moves nothing when files would collide", func(t *testing.T) {
+		root := filepath.Join(tmp, "colliding")
+		a := write(root, "2025/01/27/15_30.jpg", "2025:01:27 15:30:00")
+		b := write(root, "2025/01/29/15_30.jpg", "2025:01:29 15:30:00")
+
+		_, err := run(root)
+		if files.ErrorCode(err) != files.CodeConflict {
+			t.Fatalf("expected conflict, got %v", err)
+		}
+		for _, p := range []string{a, b} {
+			if _, err := os.Stat(p); err != nil {
+				t.Errorf("%s should not have moved: %v", p, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,1580 @@
+package cmd
+
+import (
+	"fmt"
+	"maps"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Tmunayyer/gocamelpack/burst"
+	"github.com/Tmunayyer/gocamelpack/catalog"
+	"github.com/Tmunayyer/gocamelpack/config"
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/geotag"
+	"github.com/Tmunayyer/gocamelpack/hashindex"
+	"github.com/Tmunayyer/gocamelpack/output"
+	"github.com/Tmunayyer/gocamelpack/pathtmpl"
+	"github.com/Tmunayyer/gocamelpack/plugins"
+	"github.com/Tmunayyer/gocamelpack/progress"
+	"github.com/Tmunayyer/gocamelpack/report"
+	"github.com/Tmunayyer/gocamelpack/rules"
+	"github.com/Tmunayyer/gocamelpack/sched"
+	"github.com/Tmunayyer/gocamelpack/session"
+	"github.com/Tmunayyer/gocamelpack/thumbnail"
+	"github.com/Tmunayyer/gocamelpack/units"
+	"github.com/Tmunayyer/gocamelpack/vfs"
+	"github.com/spf13/cobra"
+)
+
+// transferOptions carries the flag-driven settings shared by copy and move.
+// Each group of related settings is its own struct with its own
+// constructor; they are embedded so the settings read as one.
+type transferOptions struct {
+	dryRun    bool
+	overwrite bool
+
+	// safe, with --safe, makes the run a dry run that prints a token for
+	// its plan, unless confirm is the token of the plan it makes.
+	safe    bool
+	confirm string
+	// fromFile lists the sources instead of the source argument.
+	fromFile string
+
+	// session identifies the run, e.g. in archive IDs and the journal.
+	session string
+	// now is the clock of the run's timestamps; nil means the system's.
+	now func() time.Time
+	// runTags are the --tag key/values written with the session's
+	// journal entries.
+	runTags map[string]string
+	// mode is whether the run is atomic, from --atomic, --no-atomic or
+	// the config's default_mode.
+	mode transferMode
+	// chunkSize splits an atomic run into transactions of at most this
+	// many files (--chunk-size); 0 runs one transaction.
+	chunkSize int
+	// order is the order collected files are planned in (--sort).
+	order sourceOrder
+
+	// walk descends into subdirectories of the source (--recursive).
+	walk *sourceWalk
+	// stream runs collect, plan and execute as a pipeline instead of
+	// collecting every source first (--stream).
+	stream bool
+	// limits, from --max-files and --max-bytes or the config, abort runs
+	// with more sources than expected; nil without limits.
+	limits *runLimits
+
+	// simulate, set with --simulate, is the in-memory file system the run
+	// writes to instead of the disk it reads from.
+	simulate *vfs.Mem
+	// faults fails transfers after a number of them
+	// (--simulate-failure=after:N) to rehearse the error handling.
+	faults *files.FaultInjector
+
+	// hooks run after each operation has been applied (after commit in
+	// atomic mode).
+	hooks []files.PostOperationHook
+	// scripts are the hook scripts run before, during and after the run;
+	// nil when there are none or --no-hooks.
+	scripts *hookScripts
+
+	// link, set with copy --link, places links to the sources instead of
+	// copies.
+	link files.LinkMode
+
+	// collisions catches destinations that differ only in Unicode
+	// normalization or, on case-insensitive volumes, in case.
+	collisions *files.CollisionTracker
+	// destinations are the configured folders files may and may not be
+	// written to.
+	destinations config.Destinations
+	// archive refuses move sources in a marked archive; nil for copy and
+	// with --allow-archive-source.
+	archive *archiveGuard
+
+	// bursts is set with --bursts; burstOf maps each source in a detected
+	// burst to its burst ID once detectBursts has run.
+	bursts  *burst.Options
+	burstOf map[string]string
+
+	// jobs is how many files a non-atomic copy transfers at once, taken
+	// from the queue in schedule's order.
+	jobs     int
+	schedule sched.Strategy
+
+	// lock is held on the destination root, and the rules' own roots, for
+	// the whole run unless --no-lock, --dry-run or --simulate was given.
+	lock files.Locks
+
+	progressOptions
+	eventOptions
+	layoutOptions
+	planningOptions
+	archiveOptions
+	accessOptions
+	ioOptions
+}
+
+// progressOptions is how the run shows its progress.
+type progressOptions struct {
+	showProgress bool
+	tui          bool   // live dashboard instead of the progress bar
+	progressFile string // JSON status snapshot path for external monitors
+	// progressStyle is how --progress is drawn.
+	progressStyle progress.Style
+}
+
+// eventOptions are the records of the run built from its events.
+type eventOptions struct {
+	// events is the step-by-step record of every file, which the
+	// outputs below and --output ndjson are built from.
+	events *eventLog
+	// report collects the run for --report, csv its files for
+	// --report-csv.
+	report *runReport
+	csv    *csvReport
+	// failures, with --skip-errors, collects the files the run carries on
+	// without; retryList is where their sources are written for
+	// --from-file.
+	failures  *failureList
+	retryList string
+	// manifest collects the files a tagged non-atomic run transferred for
+	// its journal entry.
+	manifest *transferred
+	tree     bool // render dry-run plans as a directory tree
+	// explain collects why each file of a --dry-run goes where it does.
+	explain *explanation
+	// calendar counts a --dry-run's files by capture day, for
+	// --preview-calendar.
+	calendar *previewCalendar
+	// destDirs collects the folders files were placed in, for
+	// --open-dest and --print-dest-dirs.
+	destDirs *destinationDirs
+}
+
+// layoutOptions is where files are placed and what they are named.
+type layoutOptions struct {
+	// routing picks each file's destination from the configured template
+	// and rules; nil keeps the built-in layout.
+	routing *rules.Engine
+	// normalization is applied to the part of each destination below the
+	// destination root.
+	normalization files.Normalization
+	// preserveStructure places each file at its path below sourceRoot,
+	// the source folder, instead of where the layout puts it
+	// (--preserve-structure).
+	preserveStructure bool
+	sourceRoot        string
+	// flat places files directly under the destination root (--flatten).
+	flat *flattener
+	// keepName replaces the file name the layout computes with the
+	// source's own (--keep-name).
+	keepName bool
+	// fixExt gives destinations the extension their content calls for
+	// (--fix-ext).
+	fixExt bool
+}
+
+// planningOptions are the other settings consulted while planning each
+// file.
+type planningOptions struct {
+	// others places the files that are not media (--others); nil lays
+	// them out like the rest.
+	others *otherFiles
+	// clocks are the cameras' clock offsets from the config.
+	clocks clockOffsets
+	// dates holds files with an implausible capture date for review; nil
+	// with --suspect-dates keep.
+	dates *dateCheck
+	// plugins are the configured metadata providers and destination
+	// resolvers consulted while planning.
+	plugins *plugins.Host
+}
+
+// archiveOptions is what the run adds to the archive beside the files.
+type archiveOptions struct {
+	// hashAlgo checksums files for dedupe, the catalog and --report-csv
+	// (--hash-algo).
+	hashAlgo hashindex.Algo
+	// copied hashes sources while copying them, for --verify and the
+	// outputs above; nil when nothing needs the hashes.
+	copied *copyHashes
+	// dedupe, with --dedupe-against-archive, skips or links sources whose
+	// content is already in the destination archive.
+	dedupe *archiveDedupe
+	// catalog records the archived files in the destination's catalog,
+	// with --catalog or once the archive has one.
+	catalog *archiveCatalog
+
+	thumbnails *thumbnail.Generator
+	archiveIDs *archiveIDTagger
+	// geotag writes GPS tags from GPX tracks into photos without a
+	// location (--geotag).
+	geotag *geotagger
+	// quarantine diverts damaged files to the quarantine folder.
+	quarantine *quarantine
+}
+
+// accessOptions is who owns the files the run creates and with what
+// modes and attributes.
+type accessOptions struct {
+	// perms is the mode and ownership policy for created files and
+	// directories (--chmod, --dirmode, --chown).
+	perms files.Permissions
+	// runAs is the user a root run writes for (--run-as), who also owns
+	// what it creates.
+	runAs *runAs
+	// preserve is which extended attributes copies carry over
+	// (--preserve).
+	preserve files.Preserve
+}
+
+// ioOptions is how files are read and written.
+type ioOptions struct {
+	// retry re-attempts each file's transfer on transient I/O errors;
+	// retries counts what it took for the summary.
+	retry   files.RetryPolicy
+	retries *files.RetryStats
+	// io measures each file's transfer for the summary.
+	io *files.IOStats
+	// profile collects phase timings for --profile-ops.
+	profile *opProfile
+	// stability, when set, drops sources that are still being written
+	// before planning.
+	stability *files.StabilityCheck
+	// bufferSize is the copy buffer from --buffer-size or the config;
+	// 0 leaves copying to the operating system.
+	bufferSize int
+}
+
+// simulateUnsupported are the flags whose work would happen outside the
+// file system a simulated run writes to.
+var simulateUnsupported = []string{"link", "chmod", "dirmode", "chown", "preserve", "archive-id", "thumbnails", "dedupe-against-archive", "catalog", "run-as", "geotag", "verify"}
+
+// defaultArchiveIDTag is a standard, writable XMP tag; exiftool rejects
+// custom XMP names unless they are declared in its config file.
+const defaultArchiveIDTag = "XMP-dc:Identifier"
+
+// archiveIDTagger hands out "<session>-<n>" identifiers for one run.
+type archiveIDTagger struct {
+	tag     string
+	session string
+	n       int
+}
+
+func (a *archiveIDTagger) next() map[string]string {
+	a.n++
+	return map[string]string{a.tag: fmt.Sprintf("%s-%d", a.session, a.n)}
+}
+
+// addTransferFlags registers the flags common to copy and move that are not
+// worded per command.
+func addTransferFlags(cmd *cobra.Command) {
+	cmd.Flags().String("case-fold", "auto", "Treat destination names as case-insensitive: auto (probe the destination), on, or off")
+	cmd.Flags().Bool("no-hooks", false, "Do not run the pre-run, post-run and post-file scripts from the hooks folder")
+	cmd.Flags().Bool("no-lock", false, "Do not take the destination's lock file (allows concurrent runs into the same destination)")
+	cmd.Flags().String("chmod", "", "Mode for created files, e.g. 0644 (default from config, else the source file's mode)")
+	cmd.Flags().String("dirmode", "", "Mode for created directories, e.g. 0755 (default from config, else 0777 less the umask)")
+	cmd.Flags().String("preserve", "", "Also copy extended attributes: basic for Finder tags and labels (macOS) or user.* attributes (Linux), all for every attribute including POSIX ACLs")
+	cmd.Flags().String("chown", "", "Owner for created files and directories as user:group, user or :group (usually requires root)")
+	cmd.Flags().String("run-as", "", "When run as root (e.g. with sudo), give created files to this user and refuse destinations the user could not write; the run itself keeps root's rights")
+	cmd.Flags().Bool("open-dest", false, "After a successful run, open the top-level folders files were placed in with the desktop's file manager")
+	cmd.Flags().Bool("print-dest-dirs", false, "After a successful run, print the top-level folders files were placed in, one per line")
+	cmd.Flags().String("sort", "path", "Order files are planned and transferred in: path, date (capture time, undated last) or none (as collected or listed)")
+	cmd.Flags().String("normalize", "", "Unicode normalization of created names: nfc, nfd or none (default from config, else nfc)")
+	cmd.Flags().Duration("stable-wait", 0, "Skip files whose size or modification time changes within this time, e.g. 2s (0 disables)")
+	cmd.Flags().Bool("stable-probe", false, "Also skip files another process holds open (lsof) or locked (flock)")
+	cmd.Flags().String("dedupe-against-archive", "", "Skip (or with =link, hard-link) files whose content is already anywhere in the destination, using a hash index kept there")
+	cmd.Flags().Lookup("dedupe-against-archive").NoOptDefVal = "skip"
+	cmd.Flags().Bool("catalog", false, "Record the archived files, with checksum, capture date, camera and session, in a catalog kept in the destination; an archive with a catalog keeps it up to date without the flag (default from config)")
+	cmd.Flags().Int("chunk-size", 0, "With --atomic, commit in transactions of at most this many files, journaled as one session (0 = one transaction)")
+	cmd.Flags().Bool("simulate", false, "Run the transfer against an in-memory overlay of the disk: sources are read, nothing is written")
+	cmd.Flags().String("simulate-failure", "", "With --simulate, fail every transfer after the first N, given as after:N")
+	cmd.Flags().MarkHidden("simulate-failure")
+	cmd.Flags().BoolP("recursive", "r", false, "Also transfer the files in subdirectories of the source directory")
+	cmd.Flags().Int("max-depth", 0, "With --recursive, read at most this many levels of subdirectories (0 for no limit)")
+	cmd.Flags().StringSlice("exclude-dir", nil, "With --recursive, skip subdirectories whose name matches this pattern, e.g. @eaDir or '.*' (repeatable)")
+	cmd.Flags().Int("profile-ops", 0, "Print phase timings (collection, metadata, execution) and the slowest N operations to stderr")
+	cmd.Flags().Bool("stream", false, "Plan and transfer files while the source directory is still being read, using little memory for huge directories (non-atomic runs only)")
+	cmd.Flags().Int("max-files", 0, "Abort before transferring anything if there are more than this many source files (default from config, else no limit)")
+	cmd.Flags().String("max-bytes", "", "Abort before transferring anything if the source files add up to more than this, e.g. 64GiB (default from config, else no limit)")
+	cmd.Flags().String("buffer-size", "", "Copy through a buffer of this size, e.g. 1MiB (default from config, else chosen by the OS; see bench)")
+	cmd.Flags().Int("retries", 0, "Retry a file's transfer this many times on transient I/O errors (EIO, timeouts)")
+	cmd.Flags().Duration("retry-delay", time.Second, "Wait before the first retry; doubles for each further retry")
+	cmd.Flags().String("progress-file", "", "Periodically write a JSON progress snapshot to this file for external monitors")
+	cmd.Flags().Bool("tui", false, "Show a live dashboard of the files each job is transferring, overall progress, throughput and errors instead of the progress bar")
+	cmd.Flags().String("template", "", "Destination layout, e.g. {year}/{month}/{day}/{hour}_{minute} (see the tags command for tokens)")
+	cmd.Flags().String("locale", "", "Language of {month_name} and {weekday} in the template, e.g. de or fr (default from config, else English)")
+	cmd.Flags().Bool("keep-name", false, "Keep each file's original name in the folder the layout picks, e.g. 2025/01/27/IMG_0001.jpg (default from config)")
+	cmd.Flags().Bool("quarantine", false, "Check that JPEG, PNG, HEIF and MP4/MOV files are intact and put damaged ones in quarantine/ below the destination, listed in quarantine/report.jsonl")
+	cmd.Flags().Bool("flatten", false, "Place every file directly under the destination with the name the layout gives it, numbering names already taken")
+	cmd.Flags().Bool("preserve-structure", false, "Mirror the source's folders and file names below the destination instead of laying files out by date")
+	cmd.Flags().Bool("fix-ext", false, "Detect each file's format from its content and give the destination the matching extension when the source's is missing or wrong")
+	cmd.Flags().Bool("tree", false, "With --dry-run, show the planned destination hierarchy as a tree with file counts")
+	cmd.Flags().Int("min-year", 0, "Earliest plausible capture year; earlier files are handled per --suspect-dates (default from config, else 1995)")
+	cmd.Flags().Int("max-year", 0, "Latest plausible capture year (default from config, else next year)")
+	cmd.Flags().String("suspect-dates", suspectReview, "What to do with files dated outside --min-year/--max-year: review (place them under review/), ask (on a terminal) or keep (default from config)")
+	cmd.Flags().String("others", "", "What to do with files that are not photos or videos, such as GPX logs and firmware: skip (leave them in the source), unsorted (place them under unsorted/) or copy-as-is (keep their path below the source folder) (default from config, else lay them out like the rest)")
+	cmd.Flags().Bool("geotag", false, "Write the position of GPX tracks at each photo's capture time into photos without a location; tracks come from the source and --gpx")
+	cmd.Flags().StringSlice("gpx", nil, "GPX track to geotag with, besides the .gpx files among the sources (repeatable)")
+	cmd.Flags().Duration("geotag-max-gap", geotag.DefaultMaxGap, "Largest time between a photo and the track points its position is taken from")
+	cmd.Flags().Bool("preview-calendar", false, "With --dry-run, draw a month-by-month calendar of how many files each capture day would get, to spot wrong dates")
+	cmd.Flags().Bool("explain", false, "With --dry-run, show for each file the tag its date came from, the rule or template that placed it, and how a taken destination is handled")
+	cmd.Flags().String("thumbnails", "", "Write JPEG previews of imported media into this directory, mirroring the destination tree")
+	cmd.Flags().Int("thumbnail-size", thumbnail.DefaultSize, "Longest edge of generated thumbnails in pixels")
+	cmd.Flags().Bool("bursts", false, "Place bursts and bracketed sequences in a bursts/<id>/ folder next to their regular destination")
+	cmd.Flags().Duration("burst-window", burst.DefaultWindow, "Largest gap between consecutive shots of a burst")
+	cmd.Flags().Bool("skip-errors", false, "Carry on past files that cannot be planned or transferred, listing them with their errors at the end (non-atomic runs only)")
+	cmd.Flags().String("retry-list", "", "With --skip-errors, write the sources of the failed files to this file, one per line, for --from-file")
+	cmd.Flags().String("from-file", "", "Transfer the files and directories listed in this file, one per line, instead of a source argument")
+	cmd.Flags().Bool("safe", false, "Only show the plan, with a token for it, unless --confirm passes that token back; the run then goes ahead only if the plan is unchanged")
+	cmd.Flags().String("confirm", "", "With --safe, carry out the plan the dry run printed this token for")
+	cmd.Flags().StringArray("tag", nil, "Attach key=value to the run, e.g. trip=Iceland2025 (repeatable); the session's journal then records the files transferred with the tags")
+	cmd.Flags().String("report", "", "Write a self-contained HTML report of the run to this file")
+	cmd.Flags().String("hash-algo", "", "Checksum algorithm for --dedupe-against-archive, the catalog and --report-csv: sha256 or xxhash64 (faster, not tamper-proof) (default from config, else sha256)")
+	cmd.Flags().String("report-csv", "", "Write source, destination, size, checksum, date tag and status of every planned file to this CSV file (works with --dry-run)")
+	cmd.Flags().Bool("archive-id", false, "Write a <session>-<n> archive ID tag into every destination file")
+	cmd.Flags().String("archive-id-tag", defaultArchiveIDTag, "Tag that receives the archive ID")
+}
+
+// transferOptionsFromFlags reads the copy/move flags into a transferOptions.
+// Callers must defer close so background work (e.g. thumbnails) is drained.
+func transferOptionsFromFlags(cmd *cobra.Command, d *deps.AppDeps, dstRoot string) (transferOptions, error) {
+	opts, err := runFromFlags(cmd, d)
+	if err != nil {
+		return opts, err
+	}
+	if opts.eventOptions, err = opts.eventsFromFlags(cmd, d, dstRoot); err != nil {
+		return opts, err
+	}
+	opts.hooks = append(opts.hooks, opts.events)
+
+	cfg, err := loadConfig(cmd, d)
+	if err != nil {
+		return opts, err
+	}
+	if opts.mode, err = transferModeFromFlags(cmd, cfg); err != nil {
+		return opts, err
+	}
+	switch {
+	case opts.chunkSize != 0 && opts.mode != modeAtomic:
+		return opts, fmt.Errorf("--chunk-size requires --atomic")
+	case opts.failures != nil && opts.mode == modeAtomic:
+		return opts, fmt.Errorf("--skip-errors cannot be combined with --atomic, which rolls back on the first error")
+	}
+	if opts.progressOptions, err = progressFromFlags(cmd, cfg); err != nil {
+		return opts, err
+	}
+	if opts.ioOptions, err = ioFromFlags(cmd, cfg); err != nil {
+		return opts, err
+	}
+	if opts.limits, err = runLimitsFromFlags(cmd, cfg); err != nil {
+		return opts, err
+	}
+	if opts.archiveOptions, err = opts.archiveFromFlags(cmd, d, cfg, dstRoot); err != nil {
+		return opts, err
+	}
+	if opts.quarantine != nil {
+		opts.hooks = append(opts.hooks, opts.quarantine)
+	}
+	if opts.accessOptions, err = accessFromFlags(cmd, cfg, dstRoot, opts.simulate != nil, opts.link); err != nil {
+		return opts, err
+	}
+	if opts.jobs, opts.schedule, err = jobsFromFlags(cmd, cfg, opts.order); err != nil {
+		return opts, err
+	}
+	if opts.bursts, err = burstsFromFlags(cmd); err != nil {
+		return opts, err
+	}
+
+	caseFold, _ := cmd.Flags().GetString("case-fold")
+	insensitive, err := destinationCaseInsensitive(caseFold, dstRoot, opts.dryRun || opts.simulate != nil)
+	if err != nil {
+		return opts, err
+	}
+	opts.collisions = files.NewCollisionTracker(insensitive)
+
+	if opts.layoutOptions, err = layoutFromFlags(cmd, cfg); err != nil {
+		return opts, err
+	}
+	if opts.planningOptions, err = planningFromFlags(cmd, cfg, d.Now()); err != nil {
+		return opts, err
+	}
+	opts.destinations = cfg.Destinations
+	if cmd.Name() == "move" {
+		allow, _ := cmd.Flags().GetBool("allow-archive-source")
+		opts.archive = newArchiveGuard(files.FSOf(d.Files), cfg.Destinations, !allow)
+	}
+	for _, root := range opts.roots(dstRoot) {
+		if err := opts.destinations.CheckRoot(root); err != nil {
+			return opts, err
+		}
+		if opts.runAs != nil {
+			if err := opts.runAs.CheckWritable(root); err != nil {
+				return opts, fmt.Errorf("--run-as: %w", err)
+			}
+		}
+	}
+
+	if opts.scripts = opts.hookScriptsFromFlags(cmd, cfg, dstRoot); opts.scripts != nil {
+		opts.events.add(opts.scripts)
+	}
+	if opts.destDirs, err = destinationDirsFromFlags(cmd, opts.roots(dstRoot)); err != nil {
+		return opts, err
+	}
+	if opts.destDirs != nil {
+		opts.events.add(opts.destDirs)
+	}
+	if err := opts.checkStream(cmd); err != nil {
+		return opts, err
+	}
+
+	// Taken last so no later validation error can leave it behind.
+	if noLock, _ := cmd.Flags().GetBool("no-lock"); !noLock && !opts.dryRun && opts.simulate == nil {
+		if opts.lock, err = files.LockDirs(opts.roots(dstRoot)); err != nil {
+			return opts, fmt.Errorf("%w; use --no-lock to bypass", err)
+		}
+		if opts.runAs != nil {
+			// The destinations are checked as the user, below the folders
+			// the lock created.
+			if err = opts.runAs.Chown(opts.runAs.created); err != nil {
+				opts.lock.Release()
+				return opts, err
+			}
+		}
+	}
+
+	// Indexing reads the whole archive, so it waits for the lock.
+	if err := opts.openArchive(cmd, cfg, dstRoot); err != nil {
+		opts.lock.Release()
+		return opts, err
+	}
+	if opts.dedupe != nil {
+		opts.hooks = append(opts.hooks, opts.dedupe)
+	}
+	if opts.catalog != nil {
+		opts.hooks = append(opts.hooks, opts.catalog)
+	}
+
+	// The generator starts its workers at once, so it waits until nothing
+	// can fail.
+	if opts.thumbnails = opts.thumbnailsFromFlags(cmd, dstRoot); opts.thumbnails != nil {
+		opts.hooks = append(opts.hooks, opts.thumbnails)
+		if opts.report != nil {
+			opts.report.thumbs = opts.thumbnails
+		}
+	}
+	return opts, nil
+}
+
+// runFromFlags reads the settings of the run as a whole: whether it is a
+// dry run or a simulation, its session, sources and order.
+func runFromFlags(cmd *cobra.Command, d *deps.AppDeps) (transferOptions, error) {
+	var opts transferOptions
+	opts.dryRun, _ = cmd.Flags().GetBool("dry-run")
+	opts.overwrite, _ = cmd.Flags().GetBool("overwrite")
+	opts.safe, _ = cmd.Flags().GetBool("safe")
+	opts.confirm, _ = cmd.Flags().GetString("confirm")
+	switch {
+	case opts.confirm != "" && !opts.safe:
+		return opts, fmt.Errorf("--confirm requires --safe")
+	case opts.safe && opts.confirm == "":
+		opts.dryRun = true
+	}
+	opts.fromFile, _ = cmd.Flags().GetString("from-file")
+
+	if simulate, _ := cmd.Flags().GetBool("simulate"); simulate {
+		if opts.dryRun {
+			return opts, fmt.Errorf("--simulate cannot be combined with --dry-run")
+		}
+		for _, name := range simulateUnsupported {
+			if cmd.Flags().Changed(name) {
+				return opts, fmt.Errorf("--simulate cannot be combined with --%s", name)
+			}
+		}
+		// Only the sizes of written files matter to a simulation.
+		opts.simulate = vfs.NewMem(files.FSOf(d.Files))
+		opts.simulate.Discard = true
+	}
+	if spec, _ := cmd.Flags().GetString("simulate-failure"); spec != "" {
+		if opts.simulate == nil {
+			return opts, fmt.Errorf("--simulate-failure requires --simulate")
+		}
+		var err error
+		if opts.faults, err = parseFaults(spec); err != nil {
+			return opts, fmt.Errorf("--simulate-failure: %w", err)
+		}
+	}
+
+	var err error
+	if opts.walk, err = sourceWalkFromFlags(cmd); err != nil {
+		return opts, err
+	}
+	opts.now = d.Now
+	sortBy, _ := cmd.Flags().GetString("sort")
+	if opts.order, err = parseSourceOrder(sortBy); err != nil {
+		return opts, err
+	}
+	opts.session = session.NewID(d.Now())
+	specs, _ := cmd.Flags().GetStringArray("tag")
+	if opts.runTags, err = parseRunTags(specs); err != nil {
+		return opts, err
+	}
+	opts.chunkSize, _ = cmd.Flags().GetInt("chunk-size")
+	if opts.chunkSize < 0 {
+		return opts, fmt.Errorf("--chunk-size must not be negative")
+	}
+	opts.stream, _ = cmd.Flags().GetBool("stream")
+
+	// Only copy defines --link.
+	if mode, _ := cmd.Flags().GetString("link"); mode != "" {
+		if opts.link, err = files.ParseLinkMode(mode); err != nil {
+			return opts, fmt.Errorf("--link: %w", err)
+		}
+	}
+	return opts, nil
+}
+
+// eventsFromFlags sets up the records kept of the run's events: --output
+// ndjson, --skip-errors, --tag, --report, --report-csv and the dry-run
+// views.
+func (o transferOptions) eventsFromFlags(cmd *cobra.Command, d *deps.AppDeps, dstRoot string) (eventOptions, error) {
+	var e eventOptions
+	e.events = newEventLog()
+	if outputFormat(cmd) == "ndjson" {
+		e.events.add(newNDJSONEvents(cmd.OutOrStdout()))
+	}
+	if skip, _ := cmd.Flags().GetBool("skip-errors"); skip {
+		e.failures = &failureList{}
+		e.events.add(e.failures)
+	}
+	e.retryList, _ = cmd.Flags().GetString("retry-list")
+	if e.retryList != "" && e.failures == nil {
+		return e, fmt.Errorf("--retry-list requires --skip-errors")
+	}
+	if o.runTags != nil {
+		e.manifest = &transferred{}
+		e.events.add(e.manifest)
+	}
+
+	e.tree, _ = cmd.Flags().GetBool("tree")
+	if e.tree && !o.dryRun {
+		return e, fmt.Errorf("--tree requires --dry-run")
+	}
+	if explain, _ := cmd.Flags().GetBool("explain"); explain {
+		switch {
+		case !o.dryRun:
+			return e, fmt.Errorf("--explain requires --dry-run")
+		case e.tree:
+			return e, fmt.Errorf("--explain cannot be combined with --tree")
+		}
+		e.explain = newExplanation(o.overwrite)
+	}
+	if preview, _ := cmd.Flags().GetBool("preview-calendar"); preview {
+		if !o.dryRun {
+			return e, fmt.Errorf("--preview-calendar requires --dry-run")
+		}
+		e.calendar = newPreviewCalendar()
+	}
+
+	if path, _ := cmd.Flags().GetString("report"); path != "" {
+		if o.dryRun {
+			return e, fmt.Errorf("--report describes what was archived and cannot be combined with --dry-run")
+		}
+		source := cmd.Flags().Arg(0)
+		if o.fromFile != "" {
+			source = o.fromFile
+		}
+		e.report = newRunReport(path, report.Run{
+			Command:     cmd.Name(),
+			Session:     o.session,
+			Source:      absOrSelf(source),
+			Destination: absOrSelf(dstRoot),
+			Simulated:   o.simulate != nil,
+			Started:     d.Now(),
+		})
+		e.report.fsys = files.FSOf(d.Files)
+		if o.simulate != nil {
+			e.report.fsys = o.simulate
+		}
+		e.events.add(e.report)
+	}
+	if path, _ := cmd.Flags().GetString("report-csv"); path != "" {
+		e.csv = newCSVReport(path, o.dryRun, o.simulate != nil)
+		e.events.add(e.csv)
+	}
+	return e, nil
+}
+
+// progressFromFlags reads --progress, --tui and --progress-file.
+func progressFromFlags(cmd *cobra.Command, cfg *config.Config) (progressOptions, error) {
+	var p progressOptions
+	p.showProgress, _ = cmd.Flags().GetBool("progress")
+	p.tui, _ = cmd.Flags().GetBool("tui")
+	p.progressFile, _ = cmd.Flags().GetString("progress-file")
+	var err error
+	if p.progressStyle, err = progressStyle(cmd, cfg); err != nil {
+		return p, err
+	}
+	if p.tui && p.progressStyle == progress.StylePlain {
+		return p, fmt.Errorf("--tui redraws the screen in place; use --progress with the plain progress style")
+	}
+	return p, nil
+}
+
+// ioFromFlags reads how files are transferred: --retries, --retry-delay,
+// --profile-ops, --stable-wait, --stable-probe and --buffer-size.
+func ioFromFlags(cmd *cobra.Command, cfg *config.Config) (ioOptions, error) {
+	var o ioOptions
+	stableWait, _ := cmd.Flags().GetDuration("stable-wait")
+	stableProbe, _ := cmd.Flags().GetBool("stable-probe")
+	if stableWait < 0 {
+		return o, fmt.Errorf("--stable-wait must not be negative")
+	}
+	if stableWait > 0 || stableProbe {
+		o.stability = &files.StabilityCheck{Wait: stableWait, Probe: stableProbe}
+	}
+
+	o.retry.Retries, _ = cmd.Flags().GetInt("retries")
+	o.retry.Delay, _ = cmd.Flags().GetDuration("retry-delay")
+	if o.retry.Retries < 0 || o.retry.Delay < 0 {
+		return o, fmt.Errorf("--retries and --retry-delay must not be negative")
+	}
+	o.retries = &files.RetryStats{}
+	o.io = &files.IOStats{}
+	if top, _ := cmd.Flags().GetInt("profile-ops"); top != 0 {
+		if top < 0 {
+			return o, fmt.Errorf("--profile-ops must not be negative")
+		}
+		o.io.Keep = top
+		o.profile = newOpProfile()
+	}
+
+	bufferSize := cfg.BufferSize
+	if s, _ := cmd.Flags().GetString("buffer-size"); s != "" {
+		var err error
+		if bufferSize, err = units.ParseByteSize(s); err != nil {
+			return o, fmt.Errorf("--buffer-size: %w", err)
+		}
+	}
+	o.bufferSize = int(bufferSize)
+	return o, nil
+}
+
+// runLimitsFromFlags reads --max-files and --max-bytes, falling back to
+// the config; nil without limits.
+func runLimitsFromFlags(cmd *cobra.Command, cfg *config.Config) (*runLimits, error) {
+	limits := runLimits{maxFiles: cfg.MaxFiles, maxBytes: cfg.MaxBytes}
+	if f := cmd.Flags().Lookup("max-files"); f.Changed {
+		limits.maxFiles, _ = cmd.Flags().GetInt("max-files")
+	}
+	if s, _ := cmd.Flags().GetString("max-bytes"); s != "" {
+		var err error
+		if limits.maxBytes, err = units.ParseByteSize(s); err != nil {
+			return nil, fmt.Errorf("--max-bytes: %w", err)
+		}
+	}
+	if limits.maxFiles < 0 {
+		return nil, fmt.Errorf("--max-files must not be negative")
+	}
+	if limits.maxFiles == 0 && limits.maxBytes == 0 {
+		return nil, nil
+	}
+	return &limits, nil
+}
+
+// archiveFromFlags reads what the run adds to the archive: --hash-algo
+// and --verify, --archive-id, --geotag and --quarantine. The catalog and
+// dedupe index are opened by openArchive once the lock is held, and the
+// thumbnails started by thumbnailsFromFlags.
+func (o transferOptions) archiveFromFlags(cmd *cobra.Command, d *deps.AppDeps, cfg *config.Config, dstRoot string) (archiveOptions, error) {
+	var a archiveOptions
+	thumbDir, _ := cmd.Flags().GetString("thumbnails")
+	thumbSize, _ := cmd.Flags().GetInt("thumbnail-size")
+	if thumbDir != "" && !o.dryRun && thumbSize <= 0 {
+		return a, fmt.Errorf("--thumbnail-size must be positive")
+	}
+
+	if check, _ := cmd.Flags().GetBool("quarantine"); check {
+		fsys := files.FSOf(d.Files)
+		if o.simulate != nil {
+			fsys = o.simulate
+		}
+		a.quarantine = newQuarantine(dstRoot, o.session, fsys)
+		a.quarantine.now = o.clock
+	}
+
+	if tagIDs, _ := cmd.Flags().GetBool("archive-id"); tagIDs {
+		tag, _ := cmd.Flags().GetString("archive-id-tag")
+		if tag == "" {
+			return a, fmt.Errorf("--archive-id-tag must not be empty")
+		}
+		a.archiveIDs = &archiveIDTagger{tag: tag, session: o.session}
+	}
+	var err error
+	if a.geotag, err = geotaggerFromFlags(cmd); err != nil {
+		return a, err
+	}
+	if o.link != "" {
+		if a.archiveIDs != nil {
+			return a, fmt.Errorf("--archive-id cannot be combined with --link: tagging a link would modify its source")
+		}
+		if a.geotag != nil {
+			return a, fmt.Errorf("--geotag cannot be combined with --link: tagging a link would modify its source")
+		}
+	}
+
+	if a.hashAlgo, err = hashAlgo(cmd, cfg.HashAlgo); err != nil {
+		return a, err
+	}
+	// Only copy defines --verify.
+	verify, _ := cmd.Flags().GetBool("verify")
+	if verify && o.link != "" {
+		return a, fmt.Errorf("--verify cannot be combined with --link: a link shares its source's data")
+	}
+	if verify || o.csv != nil || cfg.Catalog || catalog.Exists(dstRoot) || cmd.Flags().Changed("catalog") {
+		a.copied = newCopyHashes(a.hashAlgo, verify)
+	}
+	if o.csv != nil {
+		o.csv.algo = a.hashAlgo
+		o.csv.hashOf = a.copied.hashOf
+	}
+	return a, nil
+}
+
+// openArchive opens the destination's catalog, with --catalog or once the
+// archive has one, and the index of --dedupe-against-archive.
+func (o *transferOptions) openArchive(cmd *cobra.Command, cfg *config.Config, dstRoot string) error {
+	var err error
+	if useCatalog, _ := cmd.Flags().GetBool("catalog"); (useCatalog || cfg.Catalog || catalog.Exists(dstRoot)) && o.simulate == nil {
+		if o.catalog, err = openArchiveCatalog(dstRoot, o.ruleRoots(dstRoot), o.session, o.runTags, o.hashAlgo); err != nil {
+			return err
+		}
+	}
+	mode, _ := cmd.Flags().GetString("dedupe-against-archive")
+	dedupe, link, err := parseDedupeMode(mode)
+	if err != nil {
+		return err
+	}
+	if link && cmd.Name() == "move" {
+		return fmt.Errorf("--dedupe-against-archive=link is only supported by copy")
+	}
+	if dedupe {
+		if o.dedupe, err = newArchiveDedupe(dstRoot, link, o.catalog, o.hashAlgo); err != nil {
+			return err
+		}
+	}
+	if o.catalog != nil {
+		o.catalog.now = o.clock
+		o.catalog.hashOf = knownHash(o.dedupe, o.copied)
+	}
+	return nil
+}
+
+// thumbnailsFromFlags starts the generator of --thumbnails; nil without
+// it or on a dry run.
+func (o transferOptions) thumbnailsFromFlags(cmd *cobra.Command, dstRoot string) *thumbnail.Generator {
+	thumbDir, _ := cmd.Flags().GetString("thumbnails")
+	if thumbDir == "" || o.dryRun {
+		return nil
+	}
+	thumbSize, _ := cmd.Flags().GetInt("thumbnail-size")
+	g := thumbnail.NewGenerator(dstRoot, thumbDir, thumbnail.Options{Size: thumbSize})
+	for _, root := range o.ruleRoots(dstRoot) {
+		g.AddRoot(root, ruleRootThumbs(thumbDir, root))
+	}
+	return g
+}
+
+// accessFromFlags reads the modes, owner and attributes of created files:
+// --chmod, --dirmode, --chown, --run-as and --preserve. A simulated run
+// applies none of them.
+func accessFromFlags(cmd *cobra.Command, cfg *config.Config, dstRoot string, simulated bool, link files.LinkMode) (accessOptions, error) {
+	var a accessOptions
+	preserve, _ := cmd.Flags().GetString("preserve")
+	var err error
+	if a.preserve, err = files.ParsePreserve(preserve); err != nil {
+		return a, fmt.Errorf("--preserve: %w", err)
+	}
+	if a.preserve != files.PreserveNone && link != "" {
+		return a, fmt.Errorf("--preserve cannot be combined with --link: a link already shares its source's attributes")
+	}
+	if a.perms, err = permissionsFromFlags(cmd, cfg); err != nil {
+		return a, err
+	}
+	if simulated {
+		// Modes and owners only exist on disk; the configured policy is
+		// not simulated.
+		a.perms = files.Permissions{}
+	}
+	if name, _ := cmd.Flags().GetString("run-as"); name != "" {
+		if cmd.Flags().Changed("chown") {
+			return a, fmt.Errorf("--run-as cannot be combined with --chown; created files belong to the --run-as user")
+		}
+		if a.runAs, err = newRunAs(name, dstRoot); err != nil {
+			return a, err
+		}
+		a.perms.Owner = &a.runAs.Owner
+	}
+	return a, nil
+}
+
+// jobsFromFlags reads --jobs and --schedule, which only copy defines;
+// other commands transfer one file at a time.
+func jobsFromFlags(cmd *cobra.Command, cfg *config.Config, order sourceOrder) (int, sched.Strategy, error) {
+	f := cmd.Flags().Lookup("jobs")
+	if f == nil {
+		return 1, sched.Planned, nil
+	}
+	jobs, _ := cmd.Flags().GetUint("jobs")
+	if !f.Changed && cfg.Jobs > 0 {
+		jobs = uint(cfg.Jobs)
+	}
+	if jobs < 1 {
+		return 0, nil, fmt.Errorf("--jobs must be at least 1")
+	}
+	name, _ := cmd.Flags().GetString("schedule")
+	schedule, err := sched.Parse(name)
+	if err != nil {
+		return 0, nil, fmt.Errorf("--schedule: %w", err)
+	}
+	// Files sorted by date start in capture order, so jobs take them as
+	// planned unless a schedule was asked for.
+	if order == orderDate {
+		if f := cmd.Flags().Lookup("schedule"); f.Changed && name != "planned" {
+			return 0, nil, fmt.Errorf("--sort date cannot be combined with --schedule %s, which reorders files; use --schedule planned", name)
+		}
+		schedule = sched.Planned
+	}
+	return int(jobs), schedule, nil
+}
+
+// burstsFromFlags reads --bursts and --burst-window; nil without --bursts.
+func burstsFromFlags(cmd *cobra.Command) (*burst.Options, error) {
+	if groupBursts, _ := cmd.Flags().GetBool("bursts"); !groupBursts {
+		return nil, nil
+	}
+	window, _ := cmd.Flags().GetDuration("burst-window")
+	if window <= 0 {
+		return nil, fmt.Errorf("--burst-window must be positive")
+	}
+	return &burst.Options{Window: window}, nil
+}
+
+// planningFromFlags reads --others, --suspect-dates and the config's clock
+// offsets and plugins.
+func planningFromFlags(cmd *cobra.Command, cfg *config.Config, now time.Time) (planningOptions, error) {
+	var p planningOptions
+	var err error
+	if p.others, err = otherFilesFromFlags(cmd, cfg.Others); err != nil {
+		return p, err
+	}
+	if p.clocks, err = parseClockOffsets(cfg.ClockOffsets); err != nil {
+		return p, err
+	}
+	if p.dates, err = dateCheckFromFlags(cmd, cfg.SuspectDates, now); err != nil {
+		return p, err
+	}
+	if p.plugins, err = plugins.New(cfg.Plugins, cmd.ErrOrStderr()); err != nil {
+		return p, err
+	}
+	return p, nil
+}
+
+// destinationDirsFromFlags reads --open-dest and --print-dest-dirs; nil
+// without either. With --print-dest-dirs only the folders go to stdout,
+// for piping, and the rest of the output moves to stderr.
+func destinationDirsFromFlags(cmd *cobra.Command, roots []string) (*destinationDirs, error) {
+	openDest, _ := cmd.Flags().GetBool("open-dest")
+	printDest, _ := cmd.Flags().GetBool("print-dest-dirs")
+	if printDest && outputFormat(cmd) != "text" {
+		return nil, fmt.Errorf("--print-dest-dirs cannot be combined with --output %s", outputFormat(cmd))
+	}
+	if !openDest && !printDest {
+		return nil, nil
+	}
+	dirs := newDestinationDirs(roots, openDest, printDest)
+	if printDest {
+		dirs.out = cmd.OutOrStdout()
+		cmd.SetOut(cmd.ErrOrStderr())
+	}
+	return dirs, nil
+}
+
+// hookScriptsFromFlags finds the hook scripts to run; nil when there are
+// none or with --no-hooks. Scripts act on real transfers only.
+func (o transferOptions) hookScriptsFromFlags(cmd *cobra.Command, cfg *config.Config, dstRoot string) *hookScripts {
+	if noHooks, _ := cmd.Flags().GetBool("no-hooks"); noHooks || o.dryRun || o.simulate != nil {
+		return nil
+	}
+	return newHookScripts(hooksDir(cfg.HooksDir), cmd.ErrOrStderr(),
+		"GOCAMELPACK_COMMAND="+cmd.Name(), "GOCAMELPACK_SESSION="+o.session, "GOCAMELPACK_DESTINATION="+absOrSelf(dstRoot))
+}
+
+// checkStream refuses --stream with the settings that need every file up
+// front.
+func (o transferOptions) checkStream(cmd *cobra.Command) error {
+	if !o.stream {
+		return nil
+	}
+	switch {
+	case o.mode == modeAtomic:
+		return fmt.Errorf("--stream cannot be combined with --atomic")
+	case o.bursts != nil:
+		return fmt.Errorf("--stream cannot be combined with --bursts, which needs every file's metadata up front")
+	case o.jobs > 1:
+		return fmt.Errorf("--stream cannot be combined with --jobs, which schedules every file up front")
+	case o.fromFile != "":
+		return fmt.Errorf("--stream cannot be combined with --from-file")
+	case o.safe:
+		return fmt.Errorf("--stream cannot be combined with --safe, which plans every file before confirming")
+	case cmd.Flags().Changed("sort"):
+		return fmt.Errorf("--stream cannot be combined with --sort; streamed files are planned as they are found")
+	}
+	return nil
+}
+
+// layoutFromFlags reads how destinations are laid out and named from
+// --template, --locale, --normalize, --keep-name, --fix-ext, --flatten and
+// --preserve-structure, falling back to the config. routing stays nil when
+// neither a template nor rules are configured.
+func layoutFromFlags(cmd *cobra.Command, cfg *config.Config) (layoutOptions, error) {
+	var o layoutOptions
+	normalize, _ := cmd.Flags().GetString("normalize")
+	if normalize == "" {
+		normalize = cfg.Normalize
+	}
+	var err error
+	if o.normalization, err = files.ParseNormalization(normalize); err != nil {
+		return o, err
+	}
+
+	o.keepName = cfg.KeepName
+	if cmd.Flags().Changed("keep-name") {
+		o.keepName, _ = cmd.Flags().GetBool("keep-name")
+	}
+	o.fixExt, _ = cmd.Flags().GetBool("fix-ext")
+
+	if flatten, _ := cmd.Flags().GetBool("flatten"); flatten {
+		for _, name := range []string{"preserve-structure", "bursts"} {
+			if cmd.Flags().Changed(name) {
+				return o, fmt.Errorf("--flatten cannot be combined with --%s", name)
+			}
+		}
+		o.flat = newFlattener()
+	}
+
+	if o.preserveStructure, _ = cmd.Flags().GetBool("preserve-structure"); o.preserveStructure {
+		for _, name := range []string{"template", "locale", "keep-name", "bursts", "from-file"} {
+			if cmd.Flags().Changed(name) {
+				return o, fmt.Errorf("--preserve-structure cannot be combined with --%s", name)
+			}
+		}
+		// The configured template and rules are not applied either.
+		o.keepName = false
+		return o, nil
+	}
+
+	template, _ := cmd.Flags().GetString("template")
+	if template != "" || cfg.Template != "" || len(cfg.Rules) > 0 {
+		o.routing, err = routingEngine(cmd, cfg)
+		return o, err
+	}
+	_, err = localeFromFlags(cmd)
+	return o, err
+}
+
+// routingEngine builds the rules engine from the config, with --template
+// and --locale overriding it when given.
+func routingEngine(cmd *cobra.Command, cfg *config.Config) (*rules.Engine, error) {
+	template, _ := cmd.Flags().GetString("template")
+	engine, err := cfg.Engine(template)
+	if err != nil {
+		return nil, err
+	}
+	l, err := localeFromFlags(cmd)
+	if err != nil {
+		return nil, err
+	}
+	if l != nil {
+		engine.SetLocale(l)
+	}
+	return engine, nil
+}
+
+// localeFromFlags reads --locale; nil when it is not given.
+func localeFromFlags(cmd *cobra.Command) (*pathtmpl.Locale, error) {
+	name, _ := cmd.Flags().GetString("locale")
+	if name == "" {
+		return nil, nil
+	}
+	l, err := pathtmpl.ParseLocale(name)
+	if err != nil {
+		return nil, fmt.Errorf("--locale: %w", err)
+	}
+	return l, nil
+}
+
+// parseFaults reads a --simulate-failure spec of the form after:N.
+func parseFaults(spec string) (*files.FaultInjector, error) {
+	n, ok := strings.CutPrefix(spec, "after:")
+	after, err := strconv.Atoi(n)
+	if !ok || err != nil || after < 0 {
+		return nil, fmt.Errorf("invalid spec %q (want after:N)", spec)
+	}
+	return &files.FaultInjector{After: after}, nil
+}
+
+// permissionsFromFlags reads --chmod, --dirmode and --chown, falling back to
+// the config file for each.
+func permissionsFromFlags(cmd *cobra.Command, cfg *config.Config) (files.Permissions, error) {
+	var p files.Permissions
+	setting := func(flag, configured string) string {
+		if s, _ := cmd.Flags().GetString(flag); s != "" {
+			return s
+		}
+		return configured
+	}
+
+	var err error
+	if s := setting("chmod", cfg.FileMode); s != "" {
+		if p.FileMode, err = files.ParseMode(s); err != nil {
+			return p, fmt.Errorf("file mode: %w", err)
+		}
+	}
+	if s := setting("dirmode", cfg.DirMode); s != "" {
+		if p.DirMode, err = files.ParseMode(s); err != nil {
+			return p, fmt.Errorf("directory mode: %w", err)
+		}
+	}
+	if s := setting("chown", cfg.Owner); s != "" {
+		if p.Owner, err = files.ParseOwner(s); err != nil {
+			return p, fmt.Errorf("owner: %w", err)
+		}
+	}
+	return p, nil
+}
+
+// files returns fs with the run's copy buffer, --preserve and permission
+// policy applied and, with --dedupe-against-archive=link, duplicates linked
+// instead of copied. With --link every file is linked; the policy then only
+// applies to directories, as changing a link's mode or owner would change
+// its source.
+// With --simulate, fs writes to the simulation's file system.
+func (o transferOptions) files(fs files.FilesService) files.FilesService {
+	fs = files.WithTags(fs, o.planTags())
+	if o.simulate != nil {
+		fs = files.WithFS(fs, o.simulate)
+	}
+	fs = files.WithBufferSize(fs, o.bufferSize)
+	if o.copied != nil {
+		fs = files.WithCopyHash(fs, files.CopyHash{New: o.hashAlgo.New, Verify: o.copied.verify, Record: o.copied.record})
+	}
+	// Attributes are set before the permission policy can make the file
+	// read-only.
+	fs = files.WithPreserve(fs, o.preserve)
+	if o.dedupe != nil && o.dedupe.link {
+		fs = files.WithLinks(fs, o.dedupe.existing)
+	}
+	if o.profile != nil {
+		fs = files.WithTagTiming(fs, &o.profile.tags)
+	}
+	fs = files.WithPermissions(fs, o.perms)
+	if o.link != "" {
+		fs = files.Linked(fs, o.link)
+	}
+	return fs
+}
+
+// roots returns the destination roots files may be placed under: dstRoot
+// and the roots of the routing rules.
+func (o transferOptions) roots(dstRoot string) []string {
+	roots := []string{dstRoot}
+	if o.routing != nil {
+		for _, r := range o.routing.Rules() {
+			if r.Root != "" {
+				roots = append(roots, r.Root)
+			}
+		}
+	}
+	return roots
+}
+
+// ruleRoots returns the rules' own roots other than dstRoot, each once.
+func (o transferOptions) ruleRoots(dstRoot string) []string {
+	var roots []string
+	seen := map[string]bool{filepath.Clean(dstRoot): true}
+	for _, root := range o.roots(dstRoot)[1:] {
+		if !seen[root] {
+			seen[root] = true
+			roots = append(roots, root)
+		}
+	}
+	return roots
+}
+
+// ruleRootThumbs is where the thumbnails of files under a rule's own root
+// go: the root's path mirrored under _roots in thumbDir, which keeps them
+// apart from the main archive's and from each other.
+func ruleRootThumbs(thumbDir, root string) string {
+	return filepath.Join(thumbDir, "_roots", strings.TrimPrefix(root, filepath.VolumeName(root)))
+}
+
+// checkWritable fails fast when any destination root is read-only.
+func (o transferOptions) checkWritable(dstRoot string) error {
+	for _, root := range o.roots(dstRoot) {
+		if err := files.CheckWritable(root); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// planTags are the metadata tags planning reads: the capture dates, which
+// the built-in layout, --explain and the report use, and whatever the
+// template and rules consult. Extracting only these is much faster than
+// extracting every tag.
+func (o transferOptions) planTags() []string {
+	tags := append([]string(nil), pathtmpl.DateTags...)
+	if o.routing != nil {
+		tags = append(tags, o.routing.Tags()...)
+	}
+	if o.catalog != nil || len(o.clocks) > 0 {
+		tags = append(tags, catalogTags...)
+	}
+	if o.geotag != nil {
+		tags = append(tags, geotagTags...)
+	}
+	return append(tags, o.plugins.Tags()...)
+}
+
+// decorate wraps a planned operation with the per-file steps requested on
+// the command line.
+func (o transferOptions) decorate(op files.Operation) files.Operation {
+	if o.faults != nil {
+		op = files.NewFaultyOperation(op, o.faults)
+	}
+	if o.retry.Retries > 0 {
+		op = files.NewRetryOperation(op, o.retry, o.retries)
+	}
+	if o.io != nil {
+		op = files.NewMeasuredOperation(op, o.io)
+	}
+	if tags := o.fileTags(op.Source()); len(tags) > 0 {
+		op = files.NewTaggedOperation(op, tags)
+	}
+	return op
+}
+
+// fileTags returns the tags to write into the destination of src: the
+// next archive ID and the position --geotag found.
+func (o transferOptions) fileTags(src string) map[string]string {
+	tags := map[string]string{}
+	if o.archiveIDs != nil {
+		maps.Copy(tags, o.archiveIDs.next())
+	}
+	maps.Copy(tags, o.geotag.tagsFor(src))
+	return tags
+}
+
+// destinationCaseInsensitive resolves --case-fold. In auto mode the
+// destination is probed, except during dry runs, which must not write and
+// fall back to the operating system's usual default.
+func destinationCaseInsensitive(mode, dstRoot string, dryRun bool) (bool, error) {
+	switch mode {
+	case "on":
+		return true, nil
+	case "off":
+		return false, nil
+	case "auto", "":
+		if dryRun {
+			return files.CaseInsensitiveByDefault(), nil
+		}
+		insensitive, err := files.CaseInsensitive(dstRoot)
+		if err != nil {
+			return files.CaseInsensitiveByDefault(), nil
+		}
+		return insensitive, nil
+	default:
+		return false, fmt.Errorf("invalid --case-fold %q (want auto, on or off)", mode)
+	}
+}
+
+// clock returns the time for the run's timestamps.
+func (o transferOptions) clock() time.Time {
+	if o.now == nil {
+		return time.Now()
+	}
+	return o.now()
+}
+
+// checkCollision reports a planned destination that would collide with an
+// earlier one on a case-insensitive destination.
+func (o transferOptions) checkCollision(dst string) error {
+	if o.collisions == nil {
+		return nil
+	}
+	return o.collisions.Add(dst)
+}
+
+// checkDestination validates a planned destination: the config's
+// destinations must allow writing there, the --run-as user must be able to
+// write it, and it must not collide.
+func (o transferOptions) checkDestination(dst string) error {
+	if err := o.destinations.CheckFile(dst); err != nil {
+		return err
+	}
+	if o.runAs != nil {
+		if err := o.runAs.CheckPath(dst); err != nil {
+			return fmt.Errorf("--run-as: %w", err)
+		}
+	}
+	return o.checkCollision(dst)
+}
+
+// reportsProgress reports whether execution progress is shown or recorded.
+func (o transferOptions) reportsProgress() bool {
+	return o.showProgress || o.tui || o.progressFile != ""
+}
+
+// reporter builds the execution progress reporter: a bar on stderr with
+// --progress or the dashboard with --tui, plus a status file with
+// --progress-file.
+func (o transferOptions) reporter(cmd *cobra.Command) progress.ProgressReporter {
+	var r progress.ProgressReporter = progress.NewNoOpReporter()
+	switch {
+	case o.tui:
+		r = progress.NewDashboard(cmd.ErrOrStderr(), o.jobs)
+		if output.ASCIIOnly() {
+			progress.SetGlyphs(r, progress.ASCIIGlyphs)
+		}
+	case o.showProgress:
+		r = newProgressDisplay(cmd, o.progressStyle)
+	}
+	if o.progressFile != "" {
+		r = progress.NewStatusFileReporter(r, o.progressFile, progress.DefaultStatusInterval)
+	}
+	return r
+}
+
+// progressStyle returns the --progress-style, else the config's
+// progress_style, else the bar.
+func progressStyle(cmd *cobra.Command, cfg *config.Config) (progress.Style, error) {
+	s := cfg.ProgressStyle
+	if f := cmd.Flag("progress-style"); f != nil && f.Value.String() != "" {
+		s = f.Value.String()
+	}
+	style, err := progress.ParseStyle(s)
+	if err != nil {
+		return "", fmt.Errorf("--progress-style: %w", err)
+	}
+	return style, nil
+}
+
+// newProgressDisplay creates the --progress display in style on stderr,
+// drawn in ASCII when the terminal cannot show UTF-8.
+func newProgressDisplay(cmd *cobra.Command, style progress.Style) progress.ProgressReporter {
+	r := progress.NewStyled(style, cmd.ErrOrStderr())
+	if output.ASCIIOnly() {
+		progress.SetGlyphs(r, progress.ASCIIGlyphs)
+	}
+	return r
+}
+
+// destination plans where src goes under dstRoot. skip is true when a rule
+// excludes the file from the run or its content is already archived.
+func (o transferOptions) destination(fs files.FilesService, src, dstRoot string) (dst string, skip bool, err error) {
+	p, err := o.route(fs, src, dstRoot)
+	if err != nil {
+		o.events.failed(src, "", err)
+		return "", false, err
+	}
+	if !p.skip {
+		p.geotag = o.geotag.plan(src, p.md)
+	}
+	o.events.planned(src, p)
+	if o.catalog != nil {
+		o.catalog.plan(src, p.md)
+	}
+	if o.explain != nil {
+		o.explain.plan(src, p)
+	}
+	if o.calendar != nil {
+		o.calendar.plan(p)
+	}
+	return p.dst, p.skip, nil
+}
+
+// routed is where route put a file, and what decided it.
+type routed struct {
+	dst  string
+	skip bool
+
+	// md is the file's metadata; empty when it was never read.
+	md files.FileMetadata
+	// duplicateOf is the archived copy that made dedupe skip the file.
+	duplicateOf string
+	// damaged is why --quarantine found the file damaged.
+	damaged string
+	// decision is the routing rules' verdict; nil without routing.
+	decision *rules.Decision
+	// burst is the ID of the burst the file was grouped into.
+	burst string
+	// fixedType is the format found by --fix-ext when it changed the
+	// extension.
+	fixedType string
+	// unnormalized is dst before name normalization.
+	unnormalized string
+	// flattenedFrom is the name --flatten numbered because it was taken.
+	flattenedFrom string
+	// resolvedBy is the plugin that chose dst, if one did.
+	resolvedBy string
+	// suspectDate is why the capture date looked wrong, for files held
+	// for review or skipped.
+	suspectDate string
+	// other is the --others action that placed a file that is not media.
+	other string
+	// geotag is the position --geotag found for a photo without one.
+	geotag string
+}
+
+// route picks the destination of src: a resolver plugin's choice, the
+// rules' choice, or the built-in layout without routing.
+func (o transferOptions) route(fs files.FilesService, src, dstRoot string) (routed, error) {
+	if o.quarantine != nil {
+		if dst, reason, damaged := o.quarantine.check(files.FSOf(fs), src); damaged {
+			return o.finalize(fs, src, routed{dst: dst, damaged: reason}, dstRoot, false), nil
+		}
+	}
+	if o.dedupe != nil && !o.dedupe.link {
+		if archived, dup := o.dedupe.existing(src); dup {
+			return routed{skip: true, duplicateOf: archived}, nil
+		}
+	}
+	if o.others.applies(src) {
+		return o.placeOther(fs, src, dstRoot)
+	}
+	if o.preserveStructure {
+		dst, err := o.mirrored(src, dstRoot)
+		if err != nil {
+			return routed{}, err
+		}
+		return o.finalize(fs, src, routed{dst: dst}, dstRoot, false), nil
+	}
+	if o.routing == nil {
+		tags := fs.GetFileTags([]string{src})
+		if len(tags) == 0 {
+			return routed{}, fmt.Errorf("no metadata for %s", src)
+		}
+		md, err := o.plugins.Metadata(o.clocks.shift(tags[0]))
+		if err != nil {
+			return routed{}, err
+		}
+		if reason := o.dates.check(md); reason != "" {
+			if p, held := o.dates.hold(src, reason, dstRoot, md); held {
+				return o.held(fs, src, p, dstRoot), nil
+			}
+		}
+		resolved, ok, err := o.resolve(md, dstRoot)
+		if err != nil {
+			return routed{}, err
+		}
+		if ok {
+			return o.finalize(fs, src, resolved, dstRoot, true), nil
+		}
+		dst, err := fs.DestinationFromMetadata(md, dstRoot)
+		if err != nil {
+			return routed{}, err
+		}
+		return o.finalize(fs, src, routed{dst: dst, md: md}, dstRoot, true), nil
+	}
+
+	s, err := ruleSubject(fs, src, o.routing.NeedsSize())
+	if err != nil {
+		return routed{}, err
+	}
+	if s.Metadata, err = o.plugins.Metadata(o.clocks.shift(s.Metadata)); err != nil {
+		return routed{}, err
+	}
+	if reason := o.dates.check(s.Metadata); reason != "" {
+		if p, held := o.dates.hold(src, reason, dstRoot, s.Metadata); held {
+			return o.held(fs, src, p, dstRoot), nil
+		}
+	}
+	resolved, ok, err := o.resolve(s.Metadata, dstRoot)
+	if err != nil {
+		return routed{}, err
+	}
+	if ok {
+		return o.finalize(fs, src, resolved, dstRoot, true), nil
+	}
+	dst, decision, err := o.routing.Destination(s, dstRoot, fs.DestinationFromMetadata)
+	if err != nil {
+		return routed{}, err
+	}
+	p := routed{dst: dst, md: s.Metadata, decision: &decision}
+	// A rule with its own root places the file below it.
+	dstRoot = decision.Root(dstRoot)
+	switch decision.Action {
+	case rules.ActionSkip:
+		p.skip = true
+		return p, nil
+	case rules.ActionUnsorted:
+		return o.finalize(fs, src, p, dstRoot, false), nil
+	}
+	return o.finalize(fs, src, p, dstRoot, true), nil
+}
+
+// finalize applies the placement steps common to every planned file:
+// --keep-name, burst folders (unless the file is unsorted), --fix-ext,
+// name normalization and --flatten, which quarantined files, files held
+// for review and non-media files copied as is are exempt from.
+func (o transferOptions) finalize(fs files.FilesService, src string, p routed, dstRoot string, bursts bool) routed {
+	if o.keepName {
+		p.dst = filepath.Join(filepath.Dir(p.dst), filepath.Base(src))
+	}
+	if id, ok := o.burstOf[src]; ok && bursts {
+		p.burst = id
+		p.dst = burst.Destination(p.dst, src, id)
+	}
+	if o.fixExt {
+		if ft, ok := files.DetectType(files.FSOf(fs), src); ok {
+			name := filepath.Base(p.dst)
+			if fixed := files.CorrectExt(name, ft); fixed != name {
+				p.dst = filepath.Join(filepath.Dir(p.dst), fixed)
+				p.fixedType = ft.Name
+			}
+		}
+	}
+	flatten := o.flat != nil && p.damaged == "" && p.suspectDate == "" && p.other != othersAsIs
+	if flatten {
+		p.dst = filepath.Join(dstRoot, filepath.Base(p.dst))
+	}
+	p.unnormalized = p.dst
+	p.dst = files.NormalizeBelow(dstRoot, p.dst, o.normalization)
+	if flatten {
+		name := p.dst
+		if p.dst = o.flat.claim(files.FSOf(fs), name, o.overwrite); p.dst != name {
+			p.flattenedFrom = filepath.Base(name)
+		}
+	}
+	return p
+}
+
+// dropUnstable removes sources that are still being written, warning about
+// each, when --stable-wait or --stable-probe was given.
+func (o transferOptions) dropUnstable(cmd *cobra.Command, sources []string) []string {
+	if o.stability == nil || len(sources) == 0 {
+		return sources
+	}
+	stable, unstable := o.stability.Split(sources)
+	if len(unstable) > 0 {
+		output.New(cmd.ErrOrStderr()).Warn("skipping %d file(s) still being written:\n  %s", len(unstable), strings.Join(unstable, "\n  "))
+	}
+	return stable
+}
+
+// detectBursts reads the metadata of all sources up front and records which
+// of them belong to a burst. It is a no-op unless --bursts was given.
+func (o *transferOptions) detectBursts(fs files.FilesService, sources []string) {
+	if o.bursts == nil || len(sources) == 0 {
+		return
+	}
+	mds := fs.GetFileTags(sources)
+	shots := make([]burst.Shot, len(mds))
+	for i, md := range mds {
+		shots[i] = burst.ShotFromMetadata(md)
+	}
+	o.burstOf = burst.Detect(shots, *o.bursts)
+}
+
+// transfer runs one file's copy or rename of src under the retry policy,
+// measuring it for the summary. With --simulate-failure it may fail first.
+func (o transferOptions) transfer(kind files.OperationType, src string, fn func() error) error {
+	if err := o.faults.Next(kind, src); err != nil {
+		return err
+	}
+	return o.io.Measure(kind, src, func() error { return o.retries.Run(o.retry, fn) })
+}
+
+// tagDestination writes the tags of src into its destination for
+// non-atomic runs.
+func (o transferOptions) tagDestination(fs files.FilesService, op files.Operation) error {
+	tags := o.fileTags(op.Source())
+	if len(tags) == 0 {
+		return nil
+	}
+	return files.WriteTags(fs, op.Destination(), tags)
+}
+
+// applyCopy copies src to dst for non-atomic runs, then finishes the file.
+func (o transferOptions) applyCopy(fs files.FilesService, src, dst string) error {
+	return o.apply(fs, files.OperationCopy, src, dst)
+}
+
+// applyMove renames src to dst for non-atomic runs, applying the
+// permission policy, then finishes the file.
+func (o transferOptions) applyMove(fs files.FilesService, src, dst string) error {
+	return o.apply(fs, files.OperationMove, src, dst)
+}
+
+func (o transferOptions) apply(fs files.FilesService, kind files.OperationType, src, dst string) error {
+	op, err := o.place(fs, kind, src, dst)
+	if err != nil {
+		return err
+	}
+	return o.finish(fs, op)
+}
+
+// finish tags a transferred file and runs the hooks for it.
+func (o transferOptions) finish(fs files.FilesService, op files.Operation) error {
+	if err := o.tagDestination(fs, op); err != nil {
+		return err
+	}
+	files.RunHooks(o.hooks, op)
+	return nil
+}
+
+// close drains background work started for the run and releases the
+// destination lock. Failures here never fail the import itself; they are
+// reported as warnings on stderr.
+func (o transferOptions) close(cmd *cobra.Command) {
+	if o.thumbnails != nil {
+		if err := o.thumbnails.Close(); err != nil {
+			output.New(cmd.ErrOrStderr()).Warn("some thumbnails could not be generated:\n%v", err)
+			o.report.fail(err)
+		}
+	}
+	if o.dedupe != nil {
+		o.dedupe.close(cmd, o.dryRun)
+	}
+	if o.catalog != nil && !o.dryRun {
+		o.catalog.close(cmd)
+	}
+	if o.quarantine != nil {
+		o.quarantine.close(cmd)
+	}
+	o.dates.close(cmd, o.dryRun)
+	o.others.close(cmd, o.dryRun)
+	o.geotag.close(cmd, o.dryRun)
+	o.copied.close(cmd)
+	if err := o.plugins.Close(); err != nil {
+		output.New(cmd.ErrOrStderr()).Warn("%v", err)
+	}
+	if err := o.lock.Release(); err != nil {
+		output.New(cmd.ErrOrStderr()).Warn("%v", err)
+	}
+	if o.runAs != nil && !o.dryRun {
+		o.runAs.close(cmd)
+	}
+	if o.simulate != nil {
+		output.New(cmd.ErrOrStderr()).Println(output.Dim, "Simulated run: nothing was written to disk.")
+	}
+	if o.profile != nil {
+		o.profile.print(cmd, o.io)
+	}
+}
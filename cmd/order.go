@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/pathtmpl"
+)
+
+// sourceOrder is the order collected files are planned in (--sort), so
+// dry runs and the plans they print are the same from run to run.
+type sourceOrder string
+
+const (
+	// orderPath plans files by path.
+	orderPath sourceOrder = "path"
+	// orderDate plans files by capture time, undated files last.
+	orderDate sourceOrder = "date"
+	// orderNone keeps the order files were collected or listed in.
+	orderNone sourceOrder = "none"
+)
+
+func parseSourceOrder(s string) (sourceOrder, error) {
+	switch o := sourceOrder(s); o {
+	case orderPath, orderDate, orderNone:
+		return o, nil
+	}
+	return "", fmt.Errorf("invalid --sort %q (want path, date or none)", s)
+}
+
+// sortSources orders sources for planning. Sorting by date reads every
+// file's capture time first; files with the same time, or none, keep path
+// order.
+func (o transferOptions) sortSources(fs files.FilesService, sources []string) []string {
+	switch o.order {
+	case orderPath:
+		sort.Strings(sources)
+	case orderDate:
+		sort.Strings(sources)
+		if len(sources) == 0 {
+			return sources
+		}
+		captured := make(map[string]time.Time, len(sources))
+		for _, md := range fs.GetFileTags(sources) {
+			if t, _, ok := pathtmpl.CaptureTime(md); ok {
+				captured[md.Filepath] = t
+			}
+		}
+		sort.SliceStable(sources, func(i, j int) bool {
+			ti, iok := captured[sources[i]]
+			tj, jok := captured[sources[j]]
+			if iok != jok {
+				return iok
+			}
+			return ti.Before(tj)
+		})
+	}
+	return sources
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/config"
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/sched"
+)
+
+func TestSortSources(t *testing.T) {
+	md := func(path, date string) files.FileMetadata {
+		tags := map[string]string{}
+		if date != "" {
+			tags["DateTimeOriginal"] = date
+		}
+		return files.FileMetadata{Filepath: path, Tags: tags}
+	}
+	fs := createTestFilesService(map[string]files.FileMetadata{
+		"/card/b.jpg": md("/card/b.jpg", "2025:01:02 10:00:00"),
+		"/card/a.jpg": md("/card/a.jpg", "2025:01:03 10:00:00"),
+		"/card/c.jpg": md("/card/c.jpg", ""),
+		"/card/d.jpg": md("/card/d.jpg", "2025:01:02 10:00:00"),
+	})
+	collected := []string{"/card/d.jpg", "/card/c.jpg", "/card/a.jpg", "/card/b.jpg"}
+
+	tests := []struct {
+		order sourceOrder
+		want  []string
+	}{
+		{orderPath, []string{"/card/a.jpg", "/card/b.jpg", "/card/c.jpg", "/card/d.jpg"}},
+		// Same capture time: by path; undated last.
+		{orderDate, []string{"/card/b.jpg", "/card/d.jpg", "/card/a.jpg", "/card/c.jpg"}},
+		{orderNone, collected},
+	}
+	for _, tc := range tests {
+		got := transferOptions{order: tc.order}.sortSources(fs, append([]string(nil), collected...))
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("--sort %s: got %v, want %v", tc.order, got, tc.want)
+		}
+	}
+}
+
+func TestCopyCmd_SortValidation(t *testing.T) {
+	for _, args := range [][]string{{"--sort", "size"}, {"--sort", "date", "--stream"}} {
+		cmd := createCopyCmd(&deps.AppDeps{Files: createTestFilesService(nil), Config: &config.Config{}})
+		cmd.SetArgs(append(args, t.TempDir(), t.TempDir()))
+		cmd.SetOut(&bytes.Buffer{})
+		cmd.SetErr(&bytes.Buffer{})
+		if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "--sort") {
+			t.Errorf("%v: expected a --sort error, got %v", args, err)
+		}
+	}
+}
+
+func TestTransferOptions_SortDateKeepsPlannedOrder(t *testing.T) {
+	d := &deps.AppDeps{Config: &config.Config{}}
+	cmd := createCopyCmd(d)
+	cmd.Flags().Set("no-lock", "true")
+	cmd.Flags().Set("jobs", "4")
+	cmd.Flags().Set("sort", "date")
+	opts, err := transferOptionsFromFlags(cmd, d, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tasks := []sched.Task{{Index: 0, Size: 1}, {Index: 1, Size: 100}}
+	opts.schedule.Order(tasks)
+	if tasks[0].Index != 0 {
+		t.Errorf("--sort date should start files in capture order, got %v", tasks)
+	}
+
+	cmd.Flags().Set("schedule", "largest-first")
+	if _, err := transferOptionsFromFlags(cmd, d, t.TempDir()); err == nil || !strings.Contains(err.Error(), "reorders files") {
+		t.Errorf("expected --sort date to refuse an explicit --schedule, got %v", err)
+	}
+}
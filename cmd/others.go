@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/output"
+	"github.com/Tmunayyer/gocamelpack/rules"
+	"github.com/spf13/cobra"
+)
+
+// What --others does with files that are neither photos nor videos.
+const (
+	othersSkip     = "skip"
+	othersUnsorted = "unsorted"
+	othersAsIs     = "copy-as-is"
+)
+
+// otherFiles places the files of a card that are not media, such as GPX
+// logs, MISC folders and firmware, instead of laying them out by a
+// capture date they do not have.
+type otherFiles struct {
+	action string
+
+	mu      sync.Mutex
+	skipped []string
+}
+
+// otherFilesFromFlags reads --others, falling back to the config; nil
+// when non-media files are laid out like the rest.
+func otherFilesFromFlags(cmd *cobra.Command, configured string) (*otherFiles, error) {
+	action := configured
+	if cmd.Flags().Changed("others") {
+		action, _ = cmd.Flags().GetString("others")
+	}
+	switch action {
+	case "":
+		return nil, nil
+	case othersAsIs:
+		// Paths are kept relative to the source folder, which a list of
+		// files does not have.
+		if cmd.Flags().Changed("from-file") {
+			return nil, fmt.Errorf("--others %s cannot be combined with --from-file", othersAsIs)
+		}
+		return &otherFiles{action: action}, nil
+	case othersSkip, othersUnsorted:
+		return &otherFiles{action: action}, nil
+	}
+	return nil, fmt.Errorf("--others: unknown action %q (want %s, %s or %s)", action, othersSkip, othersUnsorted, othersAsIs)
+}
+
+// applies reports whether src is left to --others.
+func (f *otherFiles) applies(src string) bool {
+	return f != nil && files.MediaKindOf(src) == files.MediaUnknown
+}
+
+// placeOther plans a non-media file: left in the source, under unsorted/ by
+// name, or at its path below the source folder.
+func (o transferOptions) placeOther(fs files.FilesService, src, dstRoot string) (routed, error) {
+	f := o.others
+	p := routed{other: f.action}
+	switch f.action {
+	case othersSkip:
+		p.skip = true
+		f.mu.Lock()
+		f.skipped = append(f.skipped, src)
+		f.mu.Unlock()
+		return p, nil
+	case othersUnsorted:
+		p.dst = filepath.Join(dstRoot, rules.UnsortedDir, filepath.Base(src))
+	default:
+		dst, err := o.mirrored(src, dstRoot)
+		if err != nil {
+			return routed{}, err
+		}
+		p.dst = dst
+	}
+	return o.finalize(fs, src, p, dstRoot, false), nil
+}
+
+// close lists the non-media files left in the source, so none go unnoticed
+// before a card is wiped.
+func (f *otherFiles) close(cmd *cobra.Command, dryRun bool) {
+	if f == nil || len(f.skipped) == 0 {
+		return
+	}
+	verb := "were"
+	if dryRun {
+		verb = "would be"
+	}
+	output.New(cmd.ErrOrStderr()).Warn("%d file(s) that are not photos or videos %s left in the source (--others %s):\n  %s", len(f.skipped), verb, othersSkip, strings.Join(f.skipped, "\n  "))
+}
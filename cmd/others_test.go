@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/config"
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+func TestMoveCmd_Others(t *testing.T) {
+	base := testutil.TempDir(t)
+	setup := func(name string) (card, dst string) {
+		t.Helper()
+		card, dst = filepath.Join(base, name, "card"), filepath.Join(base, name, "dst")
+		for _, rel := range []string{"IMG_0001.jpg", "track.gpx", filepath.Join("MISC", "firmware.bin")} {
+			path := filepath.Join(card, rel)
+			if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, []byte(rel), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return card, dst
+	}
+	run := func(cfg config.Config, card, dst string, args ...string) string {
+		t.Helper()
+		var out bytes.Buffer
+		root := newCLI(&deps.AppDeps{Files: createTestFilesService(nil), Config: &cfg, Streams: deps.Streams{Out: &out, Err: &out}})
+		root.SetArgs(append(append([]string{"move", "--recursive"}, args...), card, dst))
+		if err := root.Execute(); err != nil {
+			t.Fatalf("move %v: %v\n%s", args, err, out.String())
+		}
+		return out.String()
+	}
+	exists := func(path string) bool {
+		_, err := os.Stat(path)
+		return err == nil
+	}
+	photo := filepath.Join("2025", "01", "27", "15_30.jpg")
+
+	card, dst := setup("skip")
+	out := run(config.Config{}, card, dst, "--others", "skip")
+	for _, path := range []string{filepath.Join(card, "track.gpx"), filepath.Join(card, "MISC", "firmware.bin"), filepath.Join(dst, photo)} {
+		if !exists(path) {
+			t.Errorf("skip: expected %s to exist", path)
+		}
+	}
+	if !strings.Contains(out, "2 file(s) that are not photos or videos were left in the source") {
+		t.Errorf("skip: expected the files left behind to be listed:\n%s", out)
+	}
+
+	card, dst = setup("unsorted")
+	run(config.Config{Others: othersUnsorted}, card, dst)
+	for _, path := range []string{filepath.Join(dst, "unsorted", "track.gpx"), filepath.Join(dst, "unsorted", "firmware.bin"), filepath.Join(dst, photo)} {
+		if !exists(path) {
+			t.Errorf("unsorted: expected %s to exist", path)
+		}
+	}
+
+	card, dst = setup("asis")
+	run(config.Config{Others: othersUnsorted}, card, dst, "--others", "copy-as-is")
+	for _, path := range []string{filepath.Join(dst, "track.gpx"), filepath.Join(dst, "MISC", "firmware.bin"), filepath.Join(dst, photo)} {
+		if !exists(path) {
+			t.Errorf("copy-as-is: expected %s to exist", path)
+		}
+	}
+	if exists(filepath.Join(card, "track.gpx")) {
+		t.Error("copy-as-is: moved files should leave the card")
+	}
+}
+
+func TestMoveCmd_OthersInvalid(t *testing.T) {
+	var out bytes.Buffer
+	root := newCLI(&deps.AppDeps{Files: createTestFilesService(nil), Config: &config.Config{}, Streams: deps.Streams{Out: &out, Err: &out}})
+	root.SetArgs([]string{"move", "--others", "delete", testutil.TempDir(t), testutil.TempDir(t)})
+	if err := root.Execute(); err == nil || !strings.Contains(err.Error(), "unknown action") {
+		t.Errorf("expected an unknown action error, got %v", err)
+	}
+}
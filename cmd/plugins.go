@@ -0,0 +1,13 @@
+package cmd
+
+import "github.com/Tmunayyer/gocamelpack/files"
+
+// resolve asks the resolver plugins where md goes; ok is false when none
+// chose a destination.
+func (o transferOptions) resolve(md files.FileMetadata, dstRoot string) (p routed, ok bool, err error) {
+	dst, by, err := o.plugins.Resolve(md, dstRoot)
+	if err != nil || by == "" {
+		return routed{}, false, err
+	}
+	return routed{dst: dst, md: md, resolvedBy: by}, true, nil
+}
@@ -0,0 +1,65 @@
+//go:build !windows
+
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/config"
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/plugins"
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+func TestCopyCmd_PluginResolvesDestination(t *testing.T) {
+	tempDir := testutil.TempDir(t)
+	srcDir := filepath.Join(tempDir, "card")
+	dstDir := filepath.Join(tempDir, "dst")
+	if err := os.MkdirAll(srcDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"alice.jpg", "other.jpg"} {
+		if err := os.WriteFile(filepath.Join(srcDir, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	plugin := func(name, body string) string {
+		path := filepath.Join(tempDir, name)
+		if err := os.WriteFile(path, []byte("#!/bin/sh\nwhile read line; do\n"+body+"\ndone\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	faces := plugin("faces", `case "$line" in
+  *alice.jpg*) echo '{"tags":{"Person":"alice"}}' ;;
+  *) echo '{}' ;;
+  esac`)
+	people := plugin("people", `case "$line" in
+  *'"Person":"alice"'*) echo '{"destination":"people/alice.jpg"}' ;;
+  *) echo '{}' ;;
+  esac`)
+
+	cfg := &config.Config{Plugins: []plugins.Spec{
+		{Name: "faces", Kind: plugins.KindMetadata, Command: []string{faces}},
+		{Name: "people", Kind: plugins.KindResolver, Command: []string{people}},
+	}}
+	var out bytes.Buffer
+	root := newCLI(&deps.AppDeps{Files: createTestFilesService(nil), Config: cfg, Streams: deps.Streams{Out: &out, Err: &out}})
+	root.SetArgs([]string{"copy", "--dry-run", "--explain", srcDir, dstDir})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("copy: %v\n%s", err, out.String())
+	}
+	for _, want := range []string{
+		filepath.Join(dstDir, "people", "alice.jpg"),
+		"chosen by plugin people",
+		filepath.Join(dstDir, "2025", "01", "27", "15_30.jpg"),
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected %q in the plan:\n%s", want, out.String())
+		}
+	}
+}
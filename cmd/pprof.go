@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"runtime/trace"
+	"sync"
+
+	"github.com/Tmunayyer/gocamelpack/output"
+	"github.com/spf13/cobra"
+)
+
+// profiling is the --pprof server and --trace capture of the running
+// command. There is at most one of each per process.
+var profiling struct {
+	mu       sync.Mutex
+	listener net.Listener
+	trace    *os.File
+}
+
+// startProfiling serves net/http/pprof on the --pprof address and starts
+// writing a runtime trace to the --trace file. Both flags are hidden: they
+// are for profiling large imports without rebuilding, e.g.
+//
+//	gocamelpack --pprof localhost:6060 copy /mnt/card /archive
+//	go tool pprof http://localhost:6060/debug/pprof/profile?seconds=30
+func startProfiling(cmd *cobra.Command) error {
+	addr, _ := cmd.Flags().GetString("pprof")
+	tracePath, _ := cmd.Flags().GetString("trace")
+	if addr == "" && tracePath == "" {
+		return nil
+	}
+
+	profiling.mu.Lock()
+	defer profiling.mu.Unlock()
+	if addr != "" && profiling.listener == nil {
+		ln, err := net.Listen("tcp", addr)
+		if err != nil {
+			return fmt.Errorf("--pprof: %w", err)
+		}
+		mux := http.NewServeMux()
+		mux.HandleFunc("/debug/pprof/", pprof.Index)
+		mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+		go http.Serve(ln, mux)
+		profiling.listener = ln
+		output.New(cmd.ErrOrStderr()).Println(output.Dim, "pprof: http://%s/debug/pprof/", ln.Addr())
+	}
+	if tracePath != "" && profiling.trace == nil {
+		f, err := os.Create(tracePath)
+		if err != nil {
+			return fmt.Errorf("--trace: %w", err)
+		}
+		if err := trace.Start(f); err != nil {
+			f.Close()
+			return fmt.Errorf("--trace: %w", err)
+		}
+		profiling.trace = f
+	}
+	return nil
+}
+
+// stopProfiling ends the trace, flushing it to its file, and closes the
+// pprof listener.
+func stopProfiling() error {
+	profiling.mu.Lock()
+	defer profiling.mu.Unlock()
+	var err error
+	if profiling.trace != nil {
+		trace.Stop()
+		err = profiling.trace.Close()
+		profiling.trace = nil
+	}
+	if profiling.listener != nil {
+		profiling.listener.Close()
+		profiling.listener = nil
+	}
+	return err
+}
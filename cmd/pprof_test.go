@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/config"
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+func TestRootCmd_Profiling(t *testing.T) {
+	tracePath := filepath.Join(testutil.TempDir(t), "run.trace")
+	var out bytes.Buffer
+	root := newCLI(&deps.AppDeps{Config: &config.Config{}, Streams: deps.Streams{Out: &out, Err: &out}})
+	root.SetArgs([]string{"--pprof", "127.0.0.1:0", "--trace", tracePath, "rules", "list"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("rules list: %v\n%s", err, out.String())
+	}
+	t.Cleanup(func() { stopProfiling() })
+	if !contains(out.String(), "pprof: http://127.0.0.1:") {
+		t.Errorf("the pprof address is not printed:\n%s", out.String())
+	}
+
+	resp, err := http.Get("http://" + profiling.listener.Addr().String() + "/debug/pprof/goroutine?debug=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !bytes.Contains(body, []byte("goroutine profile")) {
+		t.Errorf("pprof responded %s:\n%s", resp.Status, body)
+	}
+
+	if err := stopProfiling(); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(tracePath); err != nil || info.Size() == 0 {
+		t.Errorf("no trace written: %v", err)
+	}
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/output"
+	"github.com/Tmunayyer/gocamelpack/units"
+	"github.com/spf13/cobra"
+)
+
+// opProfile collects the phase timings --profile-ops prints, to show
+// whether collecting sources, reading metadata (exiftool) or the disk is
+// what makes a run slow.
+type opProfile struct {
+	start time.Time
+	// collection is how long reading the source directory took.
+	collection time.Duration
+	tags       files.TagTiming
+}
+
+func newOpProfile() *opProfile {
+	return &opProfile{start: time.Now()}
+}
+
+// collected records the time since start as the collection phase.
+func (p *opProfile) collected(start time.Time) {
+	if p != nil {
+		p.collection = time.Since(start)
+	}
+}
+
+// print writes the phase timings and the slowest operations of stats to
+// stderr.
+func (p *opProfile) print(cmd *cobra.Command, stats *files.IOStats) {
+	out := output.New(cmd.ErrOrStderr())
+	round := func(d time.Duration) time.Duration { return d.Round(time.Millisecond) }
+	out.Println(output.Plain, "Profile (%s wall time):", round(time.Since(p.start)))
+	out.Println(output.Plain, "  collection  %s", round(p.collection))
+	out.Println(output.Plain, "  metadata    %s (%d file(s) in %d call(s), %s per file)",
+		round(p.tags.Duration), p.tags.Files, p.tags.Calls, round(p.tags.PerFile()))
+	if stats == nil {
+		return
+	}
+	out.Println(output.Plain, "  execution   %s (%d transfer(s), summed over jobs)", round(stats.Busy), stats.Files)
+	if len(stats.Slowest) == 0 {
+		return
+	}
+	out.Println(output.Plain, "Slowest %d operation(s):", len(stats.Slowest))
+	for _, f := range stats.Slowest {
+		out.Println(output.Plain, "  %10s  %10s  %s", round(f.Duration), units.ByteSize(f.Bytes), f.Path)
+	}
+}
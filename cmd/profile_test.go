@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/config"
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+func TestCopyCmd_ProfileOps(t *testing.T) {
+	tmp := filepath.Join(testutil.TempDir(t), "profile-ops")
+	card := filepath.Join(tmp, "card")
+	metadata := writeCard(t, card, 8)
+	var stdout, stderr bytes.Buffer
+	root := newCLI(&deps.AppDeps{Files: createTestFilesService(metadata), Config: &config.Config{}, Streams: deps.Streams{Out: &stdout, Err: &stderr}})
+	root.SetArgs([]string{"copy", "--profile-ops", "7", card, filepath.Join(tmp, "archive")})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("copy --profile-ops: %v\n%s%s", err, stdout.String(), stderr.String())
+	}
+
+	prof := stderr.String()
+	for _, want := range []string{"Profile (", "collection", "metadata", "(8 file(s) in 8 call(s)", "execution", "(8 transfer(s)", "Slowest 7 operation(s):"} {
+		if !contains(prof, want) {
+			t.Errorf("profile lacks %q:\n%s", want, prof)
+		}
+	}
+	// The summary still lists only the usual few slow files.
+	if n := bytes.Count(stdout.Bytes(), []byte("slow: ")); n != 5 {
+		t.Errorf("summary lists %d slow file(s), want 5:\n%s", n, stdout.String())
+	}
+}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/output"
+	"github.com/Tmunayyer/gocamelpack/vfs"
+	"github.com/spf13/cobra"
+)
+
+// quarantineDir is where --quarantine puts damaged files, below the
+// destination root.
+const quarantineDir = "quarantine"
+
+// quarantineReport is the file in quarantineDir listing what was put
+// there and why, one JSON object per line.
+const quarantineReport = "report.jsonl"
+
+// quarantineEntry is one line of the quarantine report.
+type quarantineEntry struct {
+	Time        time.Time `json:"time"`
+	Session     string    `json:"session,omitempty"`
+	Source      string    `json:"source"`
+	Destination string    `json:"destination"`
+	Reason      string    `json:"reason"`
+}
+
+// quarantine validates sources for --quarantine and records the damaged
+// ones that were transferred into the quarantine folder.
+type quarantine struct {
+	dir     string
+	session string
+	fsys    vfs.FS
+	now     func() time.Time
+
+	// mu guards the fields below, for parallel copies.
+	mu      sync.Mutex
+	reasons map[string]string // quarantined destination -> reason
+	moved   int
+	err     error
+}
+
+func newQuarantine(dstRoot, session string, fsys vfs.FS) *quarantine {
+	return &quarantine{dir: filepath.Join(dstRoot, quarantineDir), session: session, fsys: fsys, now: time.Now, reasons: map[string]string{}}
+}
+
+// check validates src and returns its destination in the quarantine folder
+// when it is damaged.
+func (q *quarantine) check(fsys vfs.FS, src string) (dst, reason string, damaged bool) {
+	if err := files.ValidateMedia(fsys, src); errors.Is(err, files.ErrCorrupt) {
+		dst = filepath.Join(q.dir, filepath.Base(src))
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		q.reasons[dst] = err.Error()
+		return dst, err.Error(), true
+	}
+	// Files that cannot be read fail in the transfer itself.
+	return "", "", false
+}
+
+// OnOperationComplete appends a damaged file that reached the quarantine
+// folder to the report.
+func (q *quarantine) OnOperationComplete(op files.Operation) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	reason, ok := q.reasons[op.Destination()]
+	if !ok {
+		return
+	}
+	q.moved++
+	line, _ := json.Marshal(quarantineEntry{Time: q.now(), Session: q.session, Source: op.Source(), Destination: op.Destination(), Reason: reason})
+	f, err := q.fsys.OpenFile(filepath.Join(q.dir, quarantineReport), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err == nil {
+		_, err = f.Write(append(line, '\n'))
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if err != nil && q.err == nil {
+		q.err = err
+	}
+}
+
+// close warns about the files the run quarantined.
+func (q *quarantine) close(cmd *cobra.Command) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	p := output.New(cmd.ErrOrStderr())
+	if q.moved > 0 {
+		p.Warn("%d damaged file(s) were put in %s; see %s", q.moved, q.dir, quarantineReport)
+	}
+	if q.err != nil {
+		p.Warn("the quarantine report could not be written: %v", q.err)
+	}
+}
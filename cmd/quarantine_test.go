@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/config"
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+func TestCopyCmd_Quarantine(t *testing.T) {
+	tmp := filepath.Join(testutil.TempDir(t), "quarantine")
+	card := filepath.Join(tmp, "card")
+	metadata := writeCard(t, card, 2)
+	// IMG_0000 is a complete JPEG; IMG_0001 stays a one-byte stub.
+	good := filepath.Join(card, "IMG_0000.jpg")
+	if err := os.WriteFile(good, []byte("\xff\xd8\xff\xda\x00\x02\x12\x34\xff\xd9"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	run := func(args ...string) (string, error) {
+		var out bytes.Buffer
+		root := newCLI(&deps.AppDeps{Files: createTestFilesService(metadata), Config: &config.Config{}, Streams: deps.Streams{Out: &out, Err: &out}})
+		root.SetArgs(args)
+		err := root.Execute()
+		return out.String(), err
+	}
+
+	archive := filepath.Join(tmp, "archive")
+	out, err := run("copy", "--dry-run", "--explain", "--quarantine", card, archive)
+	if err != nil {
+		t.Fatalf("copy --dry-run --quarantine: %v\n%s", err, out)
+	}
+	if !contains(out, "quarantine: ") || !contains(out, "not a JPEG file") {
+		t.Errorf("dry run does not explain the quarantine:\n%s", out)
+	}
+	if _, err := os.Stat(archive); !os.IsNotExist(err) {
+		t.Fatalf("dry run wrote to the archive: %v", err)
+	}
+
+	if out, err := run("copy", "--quarantine", card, archive); err != nil {
+		t.Fatalf("copy --quarantine: %v\n%s", err, out)
+	}
+	if _, err := os.Stat(filepath.Join(archive, "2025/01/27/15_00.jpg")); err != nil {
+		t.Errorf("intact file not archived: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(archive, quarantineDir, "IMG_0001.jpg")); err != nil {
+		t.Errorf("damaged file not quarantined: %v", err)
+	}
+
+	f, err := os.Open(filepath.Join(archive, quarantineDir, quarantineReport))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var entries []quarantineEntry
+	for sc := bufio.NewScanner(f); sc.Scan(); {
+		var e quarantineEntry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, e)
+	}
+	if len(entries) != 1 || entries[0].Source != filepath.Join(card, "IMG_0001.jpg") || entries[0].Reason == "" {
+		t.Errorf("unexpected report %+v", entries)
+	}
+}
@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/hashindex"
+	"github.com/Tmunayyer/gocamelpack/output"
+	"github.com/Tmunayyer/gocamelpack/report"
+	"github.com/Tmunayyer/gocamelpack/thumbnail"
+	"github.com/Tmunayyer/gocamelpack/vfs"
+	"github.com/spf13/cobra"
+)
+
+// runReport collects what a run archived for --report from the copied and
+// moved events.
+type runReport struct {
+	path   string
+	fsys   vfs.FS
+	thumbs *thumbnail.Generator
+
+	mu  sync.Mutex
+	run report.Run
+}
+
+func newRunReport(path string, run report.Run) *runReport {
+	return &runReport{path: path, fsys: vfs.OS, run: run}
+}
+
+// onEvent records an archived file.
+func (r *runReport) onEvent(e fileEvent) {
+	if e.Event != eventCopied && e.Event != eventMoved {
+		return
+	}
+	f := report.File{Source: e.Source, Destination: e.Destination}
+	if info, err := r.fsys.Stat(f.Destination); err == nil {
+		f.Size = info.Size()
+	}
+	if r.thumbs != nil && files.MediaKindOf(f.Destination) != files.MediaUnknown {
+		f.Thumbnail, _ = r.thumbs.ThumbnailPath(f.Destination)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.run.Files = append(r.run.Files, f)
+}
+
+// fail records an error of the run; conflicts get a section of their own.
+func (r *runReport) fail(err error) {
+	if r == nil || err == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if errors.Is(err, files.ErrConflict) {
+		r.run.Conflicts = append(r.run.Conflicts, err.Error())
+	} else {
+		r.run.Errors = append(r.run.Errors, err.Error())
+	}
+}
+
+// csvReport collects a row per planned file for --report-csv from the
+// events. Rows are added as files are planned and marked once their
+// transfer is final.
+type csvReport struct {
+	path      string
+	dryRun    bool
+	simulated bool
+	// algo checksums the files; hashOf returns the hashes taken while
+	// copying them.
+	algo   hashindex.Algo
+	hashOf func(src string) (string, bool)
+
+	mu    sync.Mutex
+	rows  []report.Row
+	index map[string]int
+}
+
+func newCSVReport(path string, dryRun, simulated bool) *csvReport {
+	return &csvReport{path: path, dryRun: dryRun, simulated: simulated, algo: hashindex.SHA256, hashOf: func(string) (string, bool) { return "", false }, index: map[string]int{}}
+}
+
+// onEvent adds the row of a planned or skipped file, noting the tag its
+// date comes from, and marks transferred files.
+func (c *csvReport) onEvent(e fileEvent) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch e.Event {
+	case eventPlanned, eventSkipped:
+		c.index[e.Source] = len(c.rows)
+		c.rows = append(c.rows, report.Row{Source: e.Source, Destination: e.Destination, Status: string(e.Event), DateTag: e.DateTag})
+	case eventCopied, eventMoved:
+		i, ok := c.index[e.Source]
+		if !ok {
+			i = len(c.rows)
+			c.index[e.Source] = i
+			c.rows = append(c.rows, report.Row{Source: e.Source})
+		}
+		c.rows[i].Destination = e.Destination
+		c.rows[i].Status = string(e.Event)
+	}
+}
+
+// write sizes and checksums every row and writes the file. A transferred
+// file is read at its destination, anything else at its source; simulated
+// destinations only exist in memory, so their source is read instead.
+func (c *csvReport) write() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i := range c.rows {
+		r := &c.rows[i]
+		path := r.Source
+		switch r.Status {
+		case "copied", "moved":
+			if !c.simulated {
+				path = r.Destination
+			}
+		case "planned":
+			if !c.dryRun {
+				r.Status = "not transferred"
+			}
+		}
+		if info, err := os.Stat(path); err == nil {
+			r.Size = info.Size()
+		}
+		if hash, ok := c.hashOf(r.Source); ok && r.Status == "copied" {
+			r.Checksum = hash
+			continue
+		}
+		r.Checksum, _ = c.algo.HashFile(path)
+	}
+	return report.WriteCSVFile(c.path, c.rows)
+}
+
+// writeReport renders the --report and --report-csv once the run is over
+// and its background work drained; runErr is how the run ended.
+func (o transferOptions) writeReport(cmd *cobra.Command, runErr error) {
+	p := output.New(cmd.ErrOrStderr())
+	if o.csv != nil {
+		if err := o.csv.write(); err != nil {
+			p.Warn("the CSV report could not be written: %v", err)
+		} else {
+			p.Println(output.Dim, "CSV report written to %s", o.csv.path)
+		}
+	}
+	r := o.report
+	if r == nil {
+		return
+	}
+	r.fail(runErr)
+	r.run.Finished = o.clock()
+	if o.retries != nil {
+		r.run.Retried, r.run.RetryAttempts = o.retries.Retried, o.retries.Attempts
+	}
+	if o.io != nil {
+		r.run.BytesWritten = o.io.BytesWritten
+	}
+	if err := report.WriteFile(r.path, r.run); err != nil {
+		p.Warn("the report could not be written: %v", err)
+		return
+	}
+	p.Println(output.Dim, "Report written to %s", r.path)
+}
+
+// summarize prints the run's summary, counted from its events, and records
+// the skipped files for the report.
+func (o transferOptions) summarize(cmd *cobra.Command, verb string) {
+	done, skipped := o.events.count(eventCopied, eventMoved), o.events.count(eventSkipped)
+	if o.report != nil {
+		o.report.run.Skipped = skipped
+	}
+	printSummary(cmd, verb, done, skipped, o.retries, o.io)
+}
+
+// absOrSelf resolves path, keeping it as given when that fails.
+func absOrSelf(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
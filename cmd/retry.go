@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/internal/engine"
+	"github.com/Tmunayyer/gocamelpack/journal"
+	"github.com/Tmunayyer/gocamelpack/output"
+	"github.com/Tmunayyer/gocamelpack/progress"
+	"github.com/Tmunayyer/gocamelpack/session"
+	"github.com/spf13/cobra"
+)
+
+func createRetryCmd(d *deps.AppDeps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "retry [session] [archive-root]",
+		Short: "Transfer again the files a previous copy or move failed",
+		Long: `Reads the session's journal in archive-root (default the current directory)
+and transfers again the files that did not go through: those a --skip-errors
+run failed and those of chunks an atomic run rolled back. Each file goes to the
+destination it was planned for in that session.
+
+Files that failed before a destination was planned are listed but not retried;
+transfer them again with copy or move --from-file. Files that fail again are
+journaled under a new session, which can be retried in turn.`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			root := "."
+			if len(args) == 2 {
+				root = args[1]
+			}
+			root, err := filepath.Abs(root)
+			if err != nil {
+				return err
+			}
+			entries, err := journal.Read(journal.Path(root, args[0]))
+			if err != nil {
+				return fmt.Errorf("reading journal of session %s: %w", args[0], err)
+			}
+
+			p := output.New(cmd.OutOrStdout())
+			if unplanned := unplannedFailures(entries); len(unplanned) > 0 {
+				output.New(cmd.ErrOrStderr()).Warn("%d file(s) failed before a destination was planned and are not retried; use --from-file for: %v", len(unplanned), unplanned)
+			}
+			ops := journal.Retryable(entries)
+			if len(ops) == 0 {
+				p.Println(output.Plain, "Nothing to retry in session %s.", args[0])
+				return nil
+			}
+			kind, err := retryKind(ops)
+			if err != nil {
+				return err
+			}
+
+			opts := transferOptions{session: session.NewID(d.Now()), now: d.Now, ioOptions: ioOptions{retries: &files.RetryStats{}, io: &files.IOStats{}}}
+			opts.dryRun, _ = cmd.Flags().GetBool("dry-run")
+			opts.overwrite, _ = cmd.Flags().GetBool("overwrite")
+			opts = opts.withEvents()
+			opts.failures = &failureList{}
+			opts.events.add(opts.failures)
+			if noLock, _ := cmd.Flags().GetBool("no-lock"); !noLock && !opts.dryRun {
+				if opts.lock, err = files.LockDirs([]string{root}); err != nil {
+					return fmt.Errorf("%w; use --no-lock to bypass", err)
+				}
+				defer opts.lock.Release()
+			}
+			return opts.retrySession(cmd, d.Files, root, kind, ops)
+		},
+	}
+
+	cmd.Flags().Bool("dry-run", false, "Show what would be retried without doing it")
+	cmd.Flags().Bool("overwrite", false, "Allow overwriting existing files in destination")
+	cmd.Flags().Bool("no-lock", false, "Do not lock the archive while retrying")
+	return cmd
+}
+
+// retrySession transfers each of ops to its journaled destination,
+// carrying on past files that fail again, and reports them as a
+// --skip-errors run does.
+func (o transferOptions) retrySession(cmd *cobra.Command, fs files.FilesService, root string, kind files.OperationType, ops []journal.Operation) error {
+	sources := make([]string, len(ops))
+	dsts := make(map[string]string, len(ops))
+	for i, op := range ops {
+		sources[i], dsts[op.Source] = op.Source, op.Destination
+	}
+
+	direct := engine.DirectOptions{
+		Kind:   kind,
+		DryRun: o.dryRun,
+		Transfer: func(it engine.Item) (files.Operation, error) {
+			op, err := o.place(fs, kind, it.Source, it.Destination)
+			if err != nil {
+				o.events.failed(it.Source, it.Destination, err)
+			}
+			return op, err
+		},
+		Done:   func(op files.Operation) error { return o.finish(fs, op) },
+		Failed: o.skipFailed,
+	}
+	if !o.overwrite {
+		direct.Check = func(it engine.Item) error {
+			err := fs.ValidateCopyArgs(it.Source, it.Destination)
+			if err != nil {
+				o.events.failed(it.Source, it.Destination, err)
+			}
+			return err
+		}
+	}
+	mode := engine.NewDirect(progress.NewNoOpReporter(), len(sources), direct)
+	pipeline := engine.Pipeline{
+		Plan: func(src string) (string, bool, error) { return dsts[src], false, nil },
+		Mode: mode,
+	}
+	if _, err := pipeline.Run(sources); err != nil {
+		return err
+	}
+
+	if o.dryRun {
+		var ms []output.Mapping
+		for _, it := range mode.Planned() {
+			ms = append(ms, output.Mapping{Source: it.Source, Destination: it.Destination, Conflict: fs.IsFile(it.Destination)})
+		}
+		printDryRun(cmd, "Would retry", root, ms, false)
+		return nil
+	}
+	o.summarize(cmd, "Retried")
+	return o.reportFailures(cmd, fs, root, kind)
+}
+
+// retryKind is whether ops are copies or moves; a session has one kind.
+func retryKind(ops []journal.Operation) (files.OperationType, error) {
+	kinds := map[string]files.OperationType{files.OperationCopy.String(): files.OperationCopy, files.OperationMove.String(): files.OperationMove}
+	kind, ok := kinds[ops[0].Type]
+	for _, op := range ops {
+		if op.Type != ops[0].Type || !ok {
+			return kind, fmt.Errorf("journal mixes or has unknown operation types (%q, %q)", ops[0].Type, op.Type)
+		}
+	}
+	return kind, nil
+}
+
+// unplannedFailures lists the sources that failed without a destination.
+func unplannedFailures(entries []journal.Entry) []string {
+	var srcs []string
+	for _, e := range entries {
+		for _, f := range e.Failed {
+			if f.Destination == "" {
+				srcs = append(srcs, f.Source)
+			}
+		}
+	}
+	return srcs
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/journal"
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+func TestRetryCmd(t *testing.T) {
+	tempDir := testutil.TempDir(t)
+	root := filepath.Join(tempDir, "archive")
+	src := filepath.Join(tempDir, "a.jpg")
+	if err := os.WriteFile(src, []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(tempDir, "gone.jpg")
+	failed := []journal.Failure{
+		{Operation: journal.Operation{Type: "copy", Source: src, Destination: filepath.Join(root, "2025", "a.jpg")}, Error: "input/output error"},
+		{Operation: journal.Operation{Type: "copy", Source: missing, Destination: filepath.Join(root, "2025", "gone.jpg")}, Error: "input/output error"},
+		{Operation: journal.Operation{Type: "copy", Source: filepath.Join(tempDir, "nodate.jpg")}, Error: "CreationDate is missing"},
+	}
+	if err := journal.Open(root, "s1").RecordFailures(failed); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cli := newCLI(&deps.AppDeps{
+		Files:   createTestFilesService(nil),
+		Streams: deps.Streams{Out: &stdout, Err: &stderr},
+	})
+	cli.SetArgs([]string{"retry", "s1", root})
+	err := cli.Execute()
+	if err == nil || !strings.Contains(err.Error(), "1 file(s) failed; retry them with: gocamelpack retry ") {
+		t.Fatalf("expected the missing source to fail again, got %v", err)
+	}
+
+	if got, err := os.ReadFile(filepath.Join(root, "2025", "a.jpg")); err != nil || string(got) != "a" {
+		t.Errorf("retried file = %q, %v", got, err)
+	}
+	if !strings.Contains(stdout.String(), "Retried 1 file(s)") || !strings.Contains(stdout.String(), missing) {
+		t.Errorf("unexpected output %q", stdout.String())
+	}
+	if !strings.Contains(stderr.String(), "nodate.jpg") {
+		t.Errorf("expected the unplanned file to be named, got %q", stderr.String())
+	}
+
+	// The file that failed again is journaled under the new session.
+	session := strings.Fields(err.Error()[strings.Index(err.Error(), "retry them with:"):])[5]
+	entries, err := journal.Read(journal.Path(root, session))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ops := journal.Retryable(entries); len(ops) != 1 || ops[0].Source != missing {
+		t.Errorf("new session retryable = %+v", ops)
+	}
+}
+
+func TestRetryCmd_NothingToRetry(t *testing.T) {
+	root := testutil.TempDir(t)
+	if err := journal.Open(root, "done").Record(1, 1, []journal.Operation{{Type: "copy", Source: "/a", Destination: root + "/a"}}); err != nil {
+		t.Fatal(err)
+	}
+	cmd := createRetryCmd(&deps.AppDeps{Files: createTestFilesService(nil)})
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"done", root})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "Nothing to retry") {
+		t.Errorf("unexpected output %q", out.String())
+	}
+}
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Tmunayyer/gocamelpack/output"
+	"github.com/spf13/cobra"
+)
+
+// openDir shows a folder in the desktop's file manager; tests replace it.
+var openDir = func(dir string) error {
+	var c *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		c = exec.Command("open", dir)
+	case "windows":
+		c = exec.Command("explorer", dir)
+	default:
+		c = exec.Command("xdg-open", dir)
+	}
+	if err := c.Start(); err != nil {
+		return err
+	}
+	// Explorer exits non-zero even when it opened the folder.
+	go c.Wait()
+	return nil
+}
+
+// destinationDirs collects the top-level folders a run placed files in, the
+// first folder below the destination root or a rule's root, for
+// --open-dest and --print-dest-dirs.
+type destinationDirs struct {
+	roots []string
+	open  bool
+	print bool
+	// out is where --print-dest-dirs prints the folders.
+	out io.Writer
+
+	mu   sync.Mutex
+	dirs map[string]bool
+}
+
+func newDestinationDirs(roots []string, open, print bool) *destinationDirs {
+	// The deepest root a file is below is the one it was placed under.
+	roots = append([]string(nil), roots...)
+	sort.Slice(roots, func(i, j int) bool { return len(roots[i]) > len(roots[j]) })
+	return &destinationDirs{roots: roots, open: open, print: print, dirs: map[string]bool{}}
+}
+
+func (r *destinationDirs) onEvent(e fileEvent) {
+	if e.Event != eventCopied && e.Event != eventMoved {
+		return
+	}
+	dir := r.topLevel(e.Destination)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.dirs[dir] = true
+}
+
+// topLevel returns the folder below a root that holds dst, or the root
+// when dst is directly in it.
+func (r *destinationDirs) topLevel(dst string) string {
+	parent := filepath.Dir(dst)
+	for _, root := range r.roots {
+		rel, err := filepath.Rel(root, parent)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		if rel == "." {
+			return root
+		}
+		first, _, _ := strings.Cut(rel, string(filepath.Separator))
+		return filepath.Join(root, first)
+	}
+	return parent
+}
+
+// reveal prints or opens the folders once the run succeeded; runErr is
+// the run's result, returned as is.
+func (r *destinationDirs) reveal(cmd *cobra.Command, runErr error) error {
+	if r == nil || runErr != nil {
+		return runErr
+	}
+	r.mu.Lock()
+	dirs := make([]string, 0, len(r.dirs))
+	for dir := range r.dirs {
+		dirs = append(dirs, dir)
+	}
+	r.mu.Unlock()
+	sort.Strings(dirs)
+
+	for _, dir := range dirs {
+		if r.print {
+			fmt.Fprintln(r.out, dir)
+		}
+		if r.open {
+			if err := openDir(dir); err != nil {
+				output.New(cmd.ErrOrStderr()).Warn("could not open %s: %v", dir, err)
+			}
+		}
+	}
+	return nil
+}
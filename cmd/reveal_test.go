@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/config"
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+func TestCopyCmd_PrintAndOpenDestDirs(t *testing.T) {
+	tempDir := testutil.TempDir(t)
+	srcDir := filepath.Join(tempDir, "card")
+	dstDir := filepath.Join(tempDir, "dst")
+	if err := os.MkdirAll(srcDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a.jpg", "b.jpg"} {
+		if err := os.WriteFile(filepath.Join(srcDir, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var opened []string
+	defer func(orig func(string) error) { openDir = orig }(openDir)
+	openDir = func(dir string) error {
+		opened = append(opened, dir)
+		return nil
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := createCopyCmd(&deps.AppDeps{Files: createTestFilesService(nil), Config: &config.Config{}})
+	cmd.SetArgs([]string{"--print-dest-dirs", "--open-dest", "--template", "{year}/{month}/{orig}", srcDir, dstDir})
+	cmd.SetOut(&stdout)
+	cmd.SetErr(&stderr)
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("copy: %v\n%s", err, stderr.String())
+	}
+
+	want := filepath.Join(dstDir, "2025")
+	if stdout.String() != want+"\n" {
+		t.Errorf("stdout = %q, want only %q", stdout.String(), want)
+	}
+	if len(opened) != 1 || opened[0] != want {
+		t.Errorf("opened %v, want [%s]", opened, want)
+	}
+	if stderr.Len() == 0 {
+		t.Error("expected the summary on stderr")
+	}
+}
@@ -0,0 +1,281 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/hashindex"
+	"github.com/Tmunayyer/gocamelpack/journal"
+	"github.com/Tmunayyer/gocamelpack/output"
+	"github.com/Tmunayyer/gocamelpack/vfs"
+	"github.com/spf13/cobra"
+)
+
+// rollbackIssue is something a rollback left behind: a file of one of its
+// operations, or an emptied folder (Type "folder").
+type rollbackIssue struct {
+	Type        string `json:"type"`
+	Source      string `json:"source,omitempty"`
+	Destination string `json:"destination"`
+	Problem     string `json:"problem"`
+	// Repaired is set once the problem was fixed. Problems without a safe
+	// repair are only reported.
+	Repaired bool   `json:"repaired"`
+	Error    string `json:"error,omitempty"`
+	repair   func() error
+}
+
+// rollbackReport is the result of verifying a session's rollbacks.
+type rollbackReport struct {
+	Session string          `json:"session"`
+	Journal string          `json:"journal"`
+	Chunks  int             `json:"rolled_back_chunks"`
+	Checked int             `json:"checked"`
+	Issues  []rollbackIssue `json:"issues"`
+}
+
+// unresolved counts the issues that still need attention.
+func (r rollbackReport) unresolved() int {
+	n := 0
+	for _, is := range r.Issues {
+		if !is.Repaired {
+			n++
+		}
+	}
+	return n
+}
+
+func createRollbackStatusCmd(d *deps.AppDeps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rollback-status [session] [archive-root]",
+		Short: "Verify that the failed chunks of an atomic run were fully rolled back",
+		Long: `Reads the session's journal in archive-root (default the current directory)
+and checks every operation of its rolled-back chunks: moved files must be back
+at their source, copies must be gone from the destination, destinations
+replaced with --overwrite must have their original back instead of a
+.gocamelpack-prev backup, and no empty folders may be left behind.
+
+Discrepancies are repaired where that is safe: a file is only deleted from the
+destination when it has the same content as its source. Everything else is
+reported for review. With --dry-run nothing is changed.`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			root := "."
+			if len(args) == 2 {
+				root = args[1]
+			}
+			root, err := filepath.Abs(root)
+			if err != nil {
+				return err
+			}
+			cfg, err := loadConfig(cmd, d)
+			if err != nil {
+				return err
+			}
+			if err := cfg.Destinations.CheckRoot(root); err != nil {
+				return err
+			}
+			algo, err := hashAlgo(cmd, cfg.HashAlgo)
+			if err != nil {
+				return err
+			}
+
+			path := journal.Path(root, args[0])
+			entries, err := journal.Read(path)
+			if err != nil {
+				return fmt.Errorf("reading journal of session %s: %w", args[0], err)
+			}
+
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			if noLock, _ := cmd.Flags().GetBool("no-lock"); !noLock && !dryRun {
+				lock, err := files.LockDir(root)
+				if err != nil {
+					return fmt.Errorf("%w; use --no-lock to bypass", err)
+				}
+				defer lock.Release()
+			}
+
+			report := rollbackReport{Session: args[0], Journal: path, Issues: []rollbackIssue{}}
+			resolve := func(is rollbackIssue) {
+				if !dryRun && is.repair != nil {
+					err := cfg.Destinations.CheckRemove(is.Destination)
+					if err == nil {
+						err = is.repair()
+					}
+					if err != nil {
+						is.Error = err.Error()
+					} else {
+						is.Repaired = true
+					}
+				}
+				report.Issues = append(report.Issues, is)
+			}
+			var dirs []string
+			for _, e := range entries {
+				if !e.RolledBack {
+					continue
+				}
+				report.Chunks++
+				for _, op := range e.Operations {
+					report.Checked++
+					for _, is := range checkRollback(op, e.Overwrite, algo) {
+						resolve(is)
+					}
+					dirs = append(dirs, filepath.Dir(op.Destination))
+				}
+			}
+			// Folders can only be judged once every file is back in place.
+			for _, is := range checkEmptyDirs(dirs, root) {
+				resolve(is)
+			}
+
+			if outputFormat(cmd) == "json" {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				if err := enc.Encode(report); err != nil {
+					return err
+				}
+			} else {
+				printRollbackReport(cmd, report, dryRun)
+			}
+			if n := report.unresolved(); n > 0 {
+				return fmt.Errorf("%d rollback problem(s) need attention", n)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().Bool("dry-run", false, "Only report discrepancies; do not repair them")
+	cmd.Flags().Bool("no-lock", false, "Do not lock the archive while repairing")
+	cmd.Flags().String("hash-algo", "", "Checksum algorithm comparing a destination with its source: sha256 or xxhash64 (default from config, else sha256)")
+	return cmd
+}
+
+// checkRollback compares the disk with what rolling op back should have
+// left, in the order repairs must be applied. algo compares contents.
+func checkRollback(op journal.Operation, overwrite bool, algo hashindex.Algo) []rollbackIssue {
+	var issues []rollbackIssue
+	add := func(problem string, repair func() error) {
+		issues = append(issues, rollbackIssue{Type: op.Type, Source: op.Source, Destination: op.Destination, Problem: problem, repair: repair})
+	}
+	src, dst := op.Source, op.Destination
+	dstExists := exists(dst)
+	backups := files.Backups(vfs.OS, dst)
+
+	if op.Type == files.OperationMove.String() && !exists(src) {
+		if !dstExists {
+			add("the file is neither at its source nor at its destination", nil)
+			return issues
+		}
+		add("the file was not moved back to its source", func() error {
+			if err := os.MkdirAll(filepath.Dir(src), files.DefaultDirPerm); err != nil {
+				return err
+			}
+			return os.Rename(dst, src)
+		})
+		// Once moved back, the destination is free for its original.
+		dstExists = false
+	}
+
+	switch {
+	case len(backups) > 0:
+		if dstExists && !sameContent(dst, src, algo) {
+			add(fmt.Sprintf("the original destination is still set aside as %s, and the destination holds other content", backups[0]), nil)
+		} else {
+			add(fmt.Sprintf("the original destination is still set aside as %s", backups[0]), func() error {
+				return os.Rename(backups[0], dst)
+			})
+		}
+		for _, b := range backups[1:] {
+			add(fmt.Sprintf("an intermediate backup %s was left behind", b), nil)
+		}
+	case dstExists && !overwrite && sameContent(dst, src, algo):
+		add("a copy of the source was left at the destination", func() error {
+			return os.Remove(dst)
+		})
+	}
+	return issues
+}
+
+// checkEmptyDirs reports the destination folders below root that were left
+// empty, deepest first; repairing one also removes its emptied parents.
+func checkEmptyDirs(dirs []string, root string) []rollbackIssue {
+	slices.Sort(dirs)
+	dirs = slices.Compact(dirs)
+	slices.Reverse(dirs)
+	var issues []rollbackIssue
+	for _, dir := range dirs {
+		if !strings.HasPrefix(dir, root+string(filepath.Separator)) || !emptyDir(dir) {
+			continue
+		}
+		issues = append(issues, rollbackIssue{Type: "folder", Destination: dir, Problem: "an empty folder was left behind", repair: func() error {
+			removeEmptyDirs(dir, root)
+			return nil
+		}})
+	}
+	return issues
+}
+
+func exists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
+
+func emptyDir(dir string) bool {
+	entries, err := os.ReadDir(dir)
+	return err == nil && len(entries) == 0
+}
+
+// sameContent reports whether a and b are files with equal content, by
+// their algo hashes.
+func sameContent(a, b string, algo hashindex.Algo) bool {
+	ai, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	bi, err := os.Stat(b)
+	if err != nil || ai.Size() != bi.Size() {
+		return false
+	}
+	ha, err := algo.HashFile(a)
+	if err != nil {
+		return false
+	}
+	hb, err := algo.HashFile(b)
+	return err == nil && ha == hb
+}
+
+func printRollbackReport(cmd *cobra.Command, r rollbackReport, dryRun bool) {
+	p := output.New(cmd.OutOrStdout())
+	if r.Chunks == 0 {
+		p.Success("Session %s has no rolled-back chunks.", r.Session)
+		return
+	}
+	for _, is := range r.Issues {
+		line := fmt.Sprintf("%s %s %s %s: %s", is.Type, is.Source, p.Arrow(), is.Destination, is.Problem)
+		if is.Source == "" {
+			line = fmt.Sprintf("%s %s: %s", is.Type, is.Destination, is.Problem)
+		}
+		switch {
+		case is.Repaired:
+			p.Println(output.Success, "%-10s %s", "repaired", line)
+		case is.Error != "":
+			p.Println(output.Error, "%-10s %s (%s)", "failed", line, is.Error)
+		case dryRun && is.repair != nil:
+			p.Println(output.Plain, "%-10s %s", "repairable", line)
+		default:
+			p.Println(output.Warning, "%-10s %s", "attention", line)
+		}
+	}
+	if len(r.Issues) == 0 {
+		p.Success("All %d operation(s) of %d rolled-back chunk(s) were fully rolled back.", r.Checked, r.Chunks)
+		return
+	}
+	p.Println(output.Plain, "Checked %d operation(s) of %d rolled-back chunk(s): %d problem(s), %d repaired.", r.Checked, r.Chunks, len(r.Issues), len(r.Issues)-r.unresolved())
+}
@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/config"
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/journal"
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+func TestRollbackStatus_AfterFailedRun(t *testing.T) {
+	tmp := filepath.Join(testutil.TempDir(t), "rollback-run")
+	card := filepath.Join(tmp, "card")
+	metadata := writeCard(t, card, 3)
+	run := func(fs files.FilesService, args ...string) (string, error) {
+		var out bytes.Buffer
+		root := newCLI(&deps.AppDeps{Files: fs, Config: &config.Config{}, Streams: deps.Streams{Out: &out, Err: &out}})
+		root.SetArgs(args)
+		err := root.Execute()
+		return out.String(), err
+	}
+
+	dst := filepath.Join(tmp, "archive")
+	failing := &failingCopies{FilesService: createTestFilesService(metadata), fail: filepath.Join(card, "IMG_0002.jpg")}
+	out, err := run(failing, "copy", "--atomic", card, dst)
+	if err == nil || !contains(out, "rollback-status") {
+		t.Fatalf("expected a rolled-back run pointing to rollback-status, got %v\n%s", err, out)
+	}
+	journals, _ := filepath.Glob(filepath.Join(dst, journal.Dir, "*.jsonl"))
+	if len(journals) != 1 {
+		t.Fatalf("want one session journal, found %v", journals)
+	}
+	entries, err := journal.Read(journals[0])
+	if err != nil || len(entries) != 1 || !entries[0].RolledBack || len(entries[0].Operations) != 3 {
+		t.Fatalf("unexpected journal %+v (%v)", entries, err)
+	}
+
+	session := strings.TrimSuffix(filepath.Base(journals[0]), ".jsonl")
+	out, err = run(createTestFilesService(metadata), "rollback-status", session, dst)
+	if err != nil || !contains(out, "fully rolled back") {
+		t.Errorf("rollback-status: %v\n%s", err, out)
+	}
+}
+
+func TestRollbackStatus_Repairs(t *testing.T) {
+	tmp := filepath.Join(testutil.TempDir(t), "rollback-repair")
+	card, dst := filepath.Join(tmp, "card"), filepath.Join(tmp, "archive")
+	write := func(path, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A copy left at its destination, a move not undone and an original
+	// still set aside.
+	write(filepath.Join(card, "a.jpg"), "a")
+	write(filepath.Join(dst, "2025/a.jpg"), "a")
+	write(filepath.Join(dst, "2025/b.jpg"), "b")
+	write(filepath.Join(card, "c.jpg"), "c")
+	write(filepath.Join(dst, "2024/c.jpg.gocamelpack-prev"), "original")
+	ops := []journal.Operation{
+		{Type: "copy", Source: filepath.Join(card, "a.jpg"), Destination: filepath.Join(dst, "2025/a.jpg")},
+		{Type: "move", Source: filepath.Join(card, "b.jpg"), Destination: filepath.Join(dst, "2025/b.jpg")},
+		{Type: "copy", Source: filepath.Join(card, "c.jpg"), Destination: filepath.Join(dst, "2024/c.jpg")},
+	}
+	if err := journal.Open(dst, "s1").RecordRollback(1, 1, false, ops); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(args ...string) (string, error) {
+		var out bytes.Buffer
+		root := newCLI(&deps.AppDeps{Files: createTestFilesService(nil), Config: &config.Config{}, Streams: deps.Streams{Out: &out, Err: &out}})
+		root.SetArgs(args)
+		err := root.Execute()
+		return out.String(), err
+	}
+
+	out, err := run("rollback-status", "--dry-run", "s1", dst)
+	if err == nil || !contains(out, "repairable") {
+		t.Fatalf("dry run should report problems: %v\n%s", err, out)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "2025/a.jpg")); err != nil {
+		t.Fatal("dry run changed the archive")
+	}
+
+	if out, err := run("rollback-status", "s1", dst); err != nil {
+		t.Fatalf("repair: %v\n%s", err, out)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "2025")); !os.IsNotExist(err) {
+		t.Errorf("stub copy or its folder left behind: %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(card, "b.jpg")); err != nil || string(data) != "b" {
+		t.Errorf("moved file not restored to its source: %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dst, "2024/c.jpg")); err != nil || string(data) != "original" {
+		t.Errorf("original destination not restored: %v", err)
+	}
+
+	out, err = run("rollback-status", "s1", dst)
+	if err != nil || !contains(out, "fully rolled back") {
+		t.Errorf("second check: %v\n%s", err, out)
+	}
+}
@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/Tmunayyer/gocamelpack/config"
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/rules"
+	"github.com/spf13/cobra"
+)
+
+// loadConfig returns the injected config, or reads the file named by
+// --config (which must exist) or the default location (which may not).
+func loadConfig(cmd *cobra.Command, d *deps.AppDeps) (*config.Config, error) {
+	if d.Config != nil {
+		return d.Config, nil
+	}
+
+	path, _ := cmd.Flags().GetString("config")
+	optional := path == ""
+	if optional {
+		var err error
+		if path, err = config.DefaultPath(); err != nil {
+			return &config.Config{}, nil
+		}
+	}
+
+	cfg, err := config.Load(path, optional)
+	if err != nil {
+		return nil, err
+	}
+	d.Config = cfg
+	return cfg, nil
+}
+
+func createRulesCmd(d *deps.AppDeps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rules",
+		Short: "Inspect the routing rules from the config file",
+		Long: `Rules are read from the "rules" list of the config file and evaluated in
+order for every file during planning; the first matching rule decides whether
+the file is placed with its own template, skipped, or put under unsorted/.
+Files that match no rule use the default template.`,
+	}
+
+	cmd.AddCommand(createRulesListCmd(d))
+	cmd.AddCommand(createRulesTestCmd(d))
+	return cmd
+}
+
+func createRulesListCmd(d *deps.AppDeps) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List configured rules in evaluation order",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig(cmd, d)
+			if err != nil {
+				return err
+			}
+			engine, err := cfg.Engine("")
+			if err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			if len(engine.Rules()) == 0 {
+				fmt.Fprintln(out, "No rules configured.")
+				return nil
+			}
+
+			tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(tw, "#\tNAME\tWHEN\tACTION")
+			for i, r := range engine.Rules() {
+				action := string(r.Action)
+				if r.Action == rules.ActionTemplate {
+					action = r.Template
+				}
+				if r.Root != "" {
+					action += " in " + r.Root
+				}
+				fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", i+1, r.Name, r.Describe(), action)
+			}
+			return tw.Flush()
+		},
+	}
+}
+
+func createRulesTestCmd(d *deps.AppDeps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "test [file] [destination]",
+		Short: "Show which rule matches a file and where it would go",
+		Long:  "Evaluates the configured rules against one file without touching it. The destination root defaults to a relative path.",
+		Args:  cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			src := args[0]
+			var dstRoot string
+			if len(args) == 2 {
+				dstRoot = args[1]
+			}
+			if !d.Files.IsFile(src) {
+				return files.Errorf(files.ErrSourceMissing, "src is not a file")
+			}
+
+			cfg, err := loadConfig(cmd, d)
+			if err != nil {
+				return err
+			}
+			template, _ := cmd.Flags().GetString("template")
+			engine, err := cfg.Engine(template)
+			if err != nil {
+				return err
+			}
+
+			s, err := ruleSubject(d.Files, src, engine.NeedsSize())
+			if err != nil {
+				return err
+			}
+			dst, decision, err := engine.Destination(s, dstRoot, d.Files.DestinationFromMetadata)
+
+			out := cmd.OutOrStdout()
+			fmt.Fprintf(out, "File:        %s\n", src)
+			if decision.Rule != nil {
+				fmt.Fprintf(out, "Rule:        %s (%s)\n", decision.RuleName(), decision.Rule.Describe())
+			} else {
+				fmt.Fprintln(out, "Rule:        none matched, using default")
+			}
+			fmt.Fprintf(out, "Action:      %s\n", decision.Action)
+			if err != nil {
+				return err
+			}
+			if decision.Action != rules.ActionSkip {
+				fmt.Fprintf(out, "Destination: %s\n", dst)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().String("template", "", "Default template to test with instead of the configured one")
+	return cmd
+}
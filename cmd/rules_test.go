@@ -0,0 +1,214 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/config"
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/rules"
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+var testRules = []rules.Rule{
+	{Name: "screenshots", Match: rules.Match{Tags: map[string]string{"Software": "*screenshot*"}}, Action: rules.ActionSkip},
+	{Name: "videos", Match: rules.Match{Ext: []string{"mov"}}, Template: "video/{year}/{month}"},
+	{Name: "undated", Match: rules.Match{Missing: []string{"CreationDate"}}, Action: rules.ActionUnsorted},
+}
+
+func TestCopy_RulesRouteFiles(t *testing.T) {
+	srcDir := testutil.TempDir(t)
+	dstDir := testutil.TempDir(t)
+
+	shot := filepath.Join(srcDir, "shot.png")
+	clip := filepath.Join(srcDir, "clip.mov")
+	scan := filepath.Join(srcDir, "scan.jpg")
+	photo := filepath.Join(srcDir, "photo.jpg")
+	for _, p := range []string{shot, clip, scan, photo} {
+		if err := os.WriteFile(p, []byte(p), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fs := createTestFilesService(map[string]files.FileMetadata{
+		shot: {Filepath: shot, Tags: map[string]string{"Software": "Screenshot", "CreationDate": "2025:01:27 15:30:45-06:00"}},
+		clip: {Filepath: clip, Tags: map[string]string{"CreationDate": "2024:03:09 10:00:00-06:00"}},
+		scan: {Filepath: scan, Tags: map[string]string{}},
+	})
+
+	engine, err := rules.New(testRules, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := createCopyCmd(&deps.AppDeps{Files: fs})
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+
+	sources := []string{shot, clip, scan, photo}
+	if err := performTransfer(fs, sources, dstDir, transferOptions{layoutOptions: layoutOptions{routing: engine}}, cmd, files.OperationCopy); err != nil {
+		t.Fatalf("copy failed: %v", err)
+	}
+
+	for _, want := range []string{
+		filepath.Join(dstDir, "video", "2024", "03.mov"),
+		filepath.Join(dstDir, rules.UnsortedDir, "scan.jpg"),
+		filepath.Join(dstDir, "2025", "01", "27", "15_30.jpg"),
+	} {
+		if _, err := os.Stat(want); err != nil {
+			t.Errorf("expected %s: %v", want, err)
+		}
+	}
+	if !strings.Contains(out.String(), "Copied 3 file(s), skipped 1.") {
+		t.Errorf("unexpected summary: %q", out.String())
+	}
+}
+
+func TestCopy_RuleRoots(t *testing.T) {
+	tmp := testutil.TempDir(t)
+	srcDir := filepath.Join(tmp, "src")
+	ssd := filepath.Join(tmp, "ssd")
+	bulk := filepath.Join(tmp, "bulk")
+	if err := os.MkdirAll(srcDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	clip := filepath.Join(srcDir, "clip.mov")
+	photo := filepath.Join(srcDir, "photo.jpg")
+	for _, p := range []string{clip, photo} {
+		if err := os.WriteFile(p, []byte(p), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fs := createTestFilesService(map[string]files.FileMetadata{
+		clip: {Filepath: clip, Tags: map[string]string{"CreationDate": "2024:03:09 10:00:00-06:00"}},
+	})
+
+	engine, err := rules.New([]rules.Rule{
+		{Name: "videos", Match: rules.Match{Ext: []string{"mov"}}, Template: "video/{year}", Root: bulk},
+	}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd := createCopyCmd(&deps.AppDeps{Files: fs})
+	cmd.SetOut(&bytes.Buffer{})
+	if err := performTransfer(fs, []string{clip, photo}, ssd, transferOptions{layoutOptions: layoutOptions{routing: engine}}, cmd, files.OperationCopy); err != nil {
+		t.Fatalf("copy failed: %v", err)
+	}
+
+	for _, want := range []string{
+		filepath.Join(bulk, "video", "2024.mov"),
+		filepath.Join(ssd, "2025", "01", "27", "15_30.jpg"),
+	} {
+		if _, err := os.Stat(want); err != nil {
+			t.Errorf("expected %s: %v", want, err)
+		}
+	}
+}
+
+func TestCopyCmd_ConfigFileAndTemplateFlag(t *testing.T) {
+	srcDir := testutil.TempDir(t)
+	dstDir := testutil.TempDir(t)
+	src := filepath.Join(srcDir, "photo.jpg")
+	if err := os.WriteFile(src, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfgPath := filepath.Join(testutil.TempDir(t), "config.json")
+	if err := os.WriteFile(cfgPath, []byte(`{"template": "{year}/{month}/{day}"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(args ...string) string {
+		t.Helper()
+		var out bytes.Buffer
+		root := newCLI(&deps.AppDeps{Files: createTestFilesService(nil), Streams: deps.Streams{Out: &out, Err: &out}})
+		root.SetArgs(args)
+		if err := root.Execute(); err != nil {
+			t.Fatalf("%v: %v\n%s", args, err, out.String())
+		}
+		return out.String()
+	}
+
+	got := run("--config", cfgPath, "copy", "--dry-run", src, dstDir)
+	if want := filepath.Join(dstDir, "2025", "01", "27.jpg"); !strings.Contains(got, want) {
+		t.Errorf("config template not applied, want %s in:\n%s", want, got)
+	}
+
+	got = run("--config", cfgPath, "copy", "--dry-run", "--template", "{year}/{filetype}", src, dstDir)
+	if want := filepath.Join(dstDir, "2025", "JPEG.jpg"); !strings.Contains(got, want) {
+		t.Errorf("--template should override config, want %s in:\n%s", want, got)
+	}
+}
+
+func TestRulesTestCmd(t *testing.T) {
+	dir := testutil.TempDir(t)
+	clip := filepath.Join(dir, "clip.mov")
+	if err := os.WriteFile(clip, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	d := &deps.AppDeps{Files: createTestFilesService(nil), Config: &config.Config{Rules: testRules}}
+	cmd := createRulesCmd(d)
+	cmd.SetArgs([]string{"test", clip, "/archive"})
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("rules test: %v", err)
+	}
+
+	for _, want := range []string{
+		"Rule:        videos (ext in [mov])",
+		"Action:      template",
+		"Destination: " + filepath.Join("/archive", "video", "2025", "01.mov"),
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected %q in:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestRulesListCmd(t *testing.T) {
+	d := &deps.AppDeps{Files: createTestFilesService(nil), Config: &config.Config{Rules: testRules}}
+	cmd := createRulesCmd(d)
+	cmd.SetArgs([]string{"list"})
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("rules list: %v", err)
+	}
+	for _, want := range []string{"screenshots", "skip", "video/{year}/{month}", "missing CreationDate"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected %q in:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestCopyCmd_NormalizesDestinationNames(t *testing.T) {
+	srcDir := testutil.TempDir(t)
+	dstDir := testutil.TempDir(t)
+	src := filepath.Join(srcDir, "photo.jpg")
+	if err := os.WriteFile(src, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	const nfd, nfc = "Café", "Café"
+	fs := createTestFilesService(map[string]files.FileMetadata{
+		src: {Filepath: src, Tags: map[string]string{"CreationDate": "2025:01:27 15:30:45-06:00", "Model": nfd}},
+	})
+
+	for _, tt := range []struct{ flag, want string }{{"nfc", nfc}, {"nfd", nfd}} {
+		var out bytes.Buffer
+		root := newCLI(&deps.AppDeps{Files: fs, Config: &config.Config{}, Streams: deps.Streams{Out: &out, Err: &out}})
+		root.SetArgs([]string{"copy", "--dry-run", "--normalize", tt.flag, "--template", "{model}/{year}", src, dstDir})
+		if err := root.Execute(); err != nil {
+			t.Fatalf("copy: %v", err)
+		}
+		if want := filepath.Join(dstDir, tt.want, "2025.jpg"); !strings.Contains(out.String(), want) {
+			t.Errorf("--normalize %s: want %q in:\n%s", tt.flag, want, out.String())
+		}
+	}
+}
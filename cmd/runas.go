@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Tmunayyer/gocamelpack/catalog"
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/hashindex"
+	"github.com/Tmunayyer/gocamelpack/journal"
+	"github.com/Tmunayyer/gocamelpack/output"
+	"github.com/spf13/cobra"
+)
+
+// runAs is the user a root run writes for (--run-as). The permission
+// policy gives it the transferred files and their folders; close gives it
+// what the run wrote besides them.
+type runAs struct {
+	*files.RunAs
+	root string
+	// created is the topmost folder of root the run had to create, if any.
+	created string
+}
+
+// newRunAs looks the user up for a run into dstRoot. Only root can give
+// files away, so --run-as is refused otherwise.
+func newRunAs(name, dstRoot string) (*runAs, error) {
+	if os.Geteuid() != 0 {
+		return nil, fmt.Errorf("--run-as needs root, e.g. sudo gocamelpack ... --run-as $SUDO_USER")
+	}
+	user, err := files.LookupRunAs(name)
+	if err != nil {
+		return nil, err
+	}
+	r := &runAs{RunAs: user, root: dstRoot}
+	for dir := dstRoot; ; {
+		if _, err := os.Stat(dir); err == nil {
+			break
+		}
+		r.created = dir
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	return r, nil
+}
+
+// close hands the folders the lock created for the destination, and the
+// journal, catalog, hash index and quarantine, to the user.
+func (r *runAs) close(cmd *cobra.Command) {
+	paths := []string{r.created}
+	if r.created == "" {
+		paths = nil
+		for _, name := range []string{journal.Dir, catalog.FileName, hashindex.FileName, quarantineDir} {
+			paths = append(paths, filepath.Join(r.root, name))
+		}
+	}
+	for _, path := range paths {
+		if err := r.Chown(path); err != nil {
+			output.New(cmd.ErrOrStderr()).Warn("%v", err)
+		}
+	}
+}
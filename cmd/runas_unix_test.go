@@ -0,0 +1,99 @@
+//go:build !windows
+
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+func TestCopyCmd_RunAs(t *testing.T) {
+	tempDir := testutil.TempDir(t)
+	srcDir := filepath.Join(tempDir, "src")
+	// A shared folder the user may create the destination in.
+	shared := filepath.Join(tempDir, "shared")
+	dstDir := filepath.Join(shared, "dst")
+	for _, dir := range []string{srcDir, shared} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Chmod(shared, 0o777); err != nil {
+		t.Fatal(err)
+	}
+	src := filepath.Join(srcDir, "photo.jpg")
+	if err := os.WriteFile(src, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The test root is private to root: the user may not enter it.
+	if err := os.Chmod(filepath.Dir(tempDir), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	root := newCLI(&deps.AppDeps{Files: createTestFilesService(nil), Streams: deps.Streams{Out: &out, Err: &out}})
+	root.SetArgs([]string{"copy", "--run-as", "nobody", "--tag", "event=test", src, dstDir})
+	err := root.Execute()
+	if os.Geteuid() != 0 {
+		if err == nil || !strings.Contains(err.Error(), "--run-as needs root") {
+			t.Fatalf("expected --run-as to need root, got %v", err)
+		}
+		return
+	}
+	if _, lookupErr := files.LookupRunAs("nobody"); lookupErr != nil {
+		t.Skip(lookupErr)
+	}
+	if err == nil || !strings.Contains(err.Error(), "may not enter") {
+		t.Fatalf("expected a destination below a folder the user may not enter to be refused, got %v", err)
+	}
+	// Let the user through.
+	if err := os.Chmod(filepath.Dir(tempDir), 0o711); err != nil {
+		t.Fatal(err)
+	}
+	root.SetArgs([]string{"copy", "--run-as", "nobody", "--tag", "event=test", src, dstDir})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("copy: %v\n%s", err, out.String())
+	}
+	nobody, _ := files.LookupRunAs("nobody")
+	for _, path := range []string{dstDir, filepath.Join(dstDir, "2025", "01", "27", "15_30.jpg"), filepath.Join(dstDir, ".gocamelpack-journal")} {
+		info, err := os.Lstat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if st := info.Sys().(*syscall.Stat_t); int(st.Uid) != nobody.UID {
+			t.Errorf("%s is owned by %d, want nobody", path, st.Uid)
+		}
+	}
+
+	// The user may not write into a root-owned 0755 folder.
+	locked := filepath.Join(tempDir, "locked")
+	if err := os.Mkdir(locked, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	root.SetArgs([]string{"copy", "--run-as", "nobody", src, locked})
+	if err := root.Execute(); err == nil || !strings.Contains(err.Error(), "may not write to") {
+		t.Fatalf("expected an unwritable destination to be refused, got %v", err)
+	}
+
+	// A link the user planted in the shared folder must not lead root
+	// into the locked one.
+	if err := os.Symlink(locked, filepath.Join(shared, "planted")); err != nil {
+		t.Fatal(err)
+	}
+	root.SetArgs([]string{"copy", "--run-as", "nobody", src, filepath.Join(shared, "planted")})
+	if err := root.Execute(); err == nil || !strings.Contains(err.Error(), "symbolic link") {
+		t.Fatalf("expected a planted symbolic link to be refused, got %v", err)
+	}
+	if entries, _ := os.ReadDir(locked); len(entries) != 0 {
+		t.Errorf("expected nothing written through the link, got %v", entries)
+	}
+}
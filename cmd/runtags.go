@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/journal"
+	"github.com/Tmunayyer/gocamelpack/output"
+	"github.com/spf13/cobra"
+)
+
+// parseRunTags parses --tag key=value flags.
+func parseRunTags(specs []string) (map[string]string, error) {
+	if len(specs) == 0 {
+		return nil, nil
+	}
+	tags := make(map[string]string, len(specs))
+	for _, spec := range specs {
+		key, value, ok := strings.Cut(spec, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid --tag %q (want key=value, e.g. trip=Iceland2025)", spec)
+		}
+		tags[key] = strings.TrimSpace(value)
+	}
+	return tags, nil
+}
+
+// formatRunTags renders tags as sorted key=value pairs.
+func formatRunTags(tags map[string]string) string {
+	pairs := make([]string, 0, len(tags))
+	for _, k := range slices.Sorted(maps.Keys(tags)) {
+		pairs = append(pairs, k+"="+tags[k])
+	}
+	return strings.Join(pairs, " ")
+}
+
+// journal opens the session's journal in dstRoot, writing the run's tags
+// with every entry.
+func (o transferOptions) journal(fs files.FilesService, dstRoot string) *journal.Journal {
+	j := journal.OpenIn(files.FSOf(fs), dstRoot, o.session)
+	j.SetTags(o.runTags)
+	j.SetClock(o.now)
+	return j
+}
+
+// transferred collects the files a tagged run copied or moved, for its
+// journal entry.
+type transferred struct {
+	mu  sync.Mutex
+	ops []journal.Operation
+}
+
+func (t *transferred) onEvent(e fileEvent) {
+	if e.Event != eventCopied && e.Event != eventMoved {
+		return
+	}
+	kind := files.OperationCopy
+	if e.Event == eventMoved {
+		kind = files.OperationMove
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.ops = append(t.ops, journal.Operation{Type: kind.String(), Source: e.Source, Destination: e.Destination})
+}
+
+// journalTransferred records the files a tagged non-atomic run
+// transferred in its journal, so the session can be found by its tags.
+// Atomic runs journal their transactions themselves.
+func (o transferOptions) journalTransferred(cmd *cobra.Command, fs files.FilesService, dstRoot string) error {
+	if o.manifest == nil || o.dryRun || o.mode == modeAtomic {
+		return nil
+	}
+	o.manifest.mu.Lock()
+	ops := o.manifest.ops
+	o.manifest.mu.Unlock()
+	if len(ops) == 0 {
+		return nil
+	}
+	j := o.journal(fs, dstRoot)
+	if err := j.Record(1, 1, ops); err != nil {
+		return err
+	}
+	o.printJournaled(cmd, len(ops), j)
+	return nil
+}
+
+// printJournaled tells where a tagged run was recorded.
+func (o transferOptions) printJournaled(cmd *cobra.Command, n int, j *journal.Journal) {
+	output.New(cmd.ErrOrStderr()).Println(output.Dim, "Journaled %d file(s) as session %s with %s (journal %s)", n, o.session, formatRunTags(o.runTags), j.Path())
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/config"
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/journal"
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+func TestCopyCmd_TagsJournalTheRun(t *testing.T) {
+	for _, mode := range []string{"--no-atomic", "--atomic"} {
+		t.Run(mode, func(t *testing.T) {
+			tempDir := testutil.TempDir(t)
+			srcDir := filepath.Join(tempDir, "src")
+			dstDir := filepath.Join(tempDir, "dst")
+			if err := os.MkdirAll(srcDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(srcDir, "a.jpg"), []byte("a"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			cmd := createCopyCmd(&deps.AppDeps{Files: createTestFilesService(nil), Config: &config.Config{}})
+			cmd.SetArgs([]string{mode, "--tag", "trip=Iceland2025", "--tag", "photographer=Sam", srcDir, dstDir})
+			cmd.SetOut(&bytes.Buffer{})
+			var stderr bytes.Buffer
+			cmd.SetErr(&stderr)
+			if err := cmd.Execute(); err != nil {
+				t.Fatalf("copy failed: %v", err)
+			}
+			if !strings.Contains(stderr.String(), "photographer=Sam trip=Iceland2025") {
+				t.Errorf("expected the journal to be announced, got %q", stderr.String())
+			}
+
+			paths, _ := filepath.Glob(filepath.Join(dstDir, journal.Dir, "*.jsonl"))
+			if len(paths) != 1 {
+				t.Fatalf("expected one journal, got %v", paths)
+			}
+			entries, err := journal.Read(paths[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 1 || len(entries[0].Operations) != 1 {
+				t.Fatalf("expected one entry with the copied file, got %+v", entries)
+			}
+			if tags := entries[0].Tags; tags["trip"] != "Iceland2025" || tags["photographer"] != "Sam" {
+				t.Errorf("tags = %v", tags)
+			}
+		})
+	}
+}
+
+func TestParseRunTags(t *testing.T) {
+	tags, err := parseRunTags([]string{"trip=Iceland2025", "note="})
+	if err != nil || tags["trip"] != "Iceland2025" || len(tags) != 2 {
+		t.Errorf("parseRunTags = %v, %v", tags, err)
+	}
+	for _, bad := range []string{"trip", "=x"} {
+		if _, err := parseRunTags([]string{bad}); err == nil || !strings.Contains(err.Error(), "invalid --tag") {
+			t.Errorf("%q: expected an invalid --tag error, got %v", bad, err)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/internal/engine"
+	"github.com/Tmunayyer/gocamelpack/output"
+	"github.com/spf13/cobra"
+)
+
+// safePlan records what a --safe run planned. Its token, printed by the
+// dry run, must be passed back with --confirm to carry the plan out.
+type safePlan struct {
+	header  string
+	items   []engine.Item
+	planned map[string]plannedFile
+}
+
+// plannedFile is the recorded answer of Plan for one source.
+type plannedFile struct {
+	dst  string
+	skip bool
+	err  error
+}
+
+func newSafePlan(kind files.OperationType, dstRoot string, overwrite bool) *safePlan {
+	return &safePlan{
+		header:  fmt.Sprintf("%s %s overwrite=%t\n", kind, dstRoot, overwrite),
+		planned: map[string]plannedFile{},
+	}
+}
+
+// record wraps plan so every file it plans is recorded.
+func (s *safePlan) record(plan func(src string) (string, bool, error)) func(src string) (string, bool, error) {
+	return func(src string) (string, bool, error) {
+		dst, skip, err := plan(src)
+		s.planned[src] = plannedFile{dst: dst, skip: skip, err: err}
+		if err == nil && !skip {
+			s.items = append(s.items, engine.Item{Source: src, Destination: dst})
+		}
+		return dst, skip, err
+	}
+}
+
+// replay answers Plan from the recorded plan, so the confirmed run does
+// exactly what the token was computed from.
+func (s *safePlan) replay(src string) (string, bool, error) {
+	p := s.planned[src]
+	return p.dst, p.skip, p.err
+}
+
+// token hashes the kind of run, its destination and every planned source
+// and destination, in order.
+func (s *safePlan) token() string {
+	h := sha256.New()
+	h.Write([]byte(s.header))
+	for _, it := range s.items {
+		fmt.Fprintf(h, "%s\x00%s\n", it.Source, it.Destination)
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// safely returns the Plan function of a --safe run. Unconfirmed, the run
+// is a dry run whose plan is recorded for the token. Confirmed, every
+// source is planned up front and the run goes ahead only if the plan still
+// matches the token.
+func (o transferOptions) safely(plan func(src string) (string, bool, error), sources []string, kind files.OperationType, dstRoot string) (func(src string) (string, bool, error), *safePlan, error) {
+	if !o.safe {
+		return plan, nil, nil
+	}
+	s := newSafePlan(kind, dstRoot, o.overwrite)
+	if o.dryRun {
+		return s.record(plan), s, nil
+	}
+	record := s.record(plan)
+	for _, src := range sources {
+		if _, _, err := record(src); err != nil && o.failures == nil {
+			return nil, nil, err
+		}
+	}
+	if token := s.token(); token != o.confirm {
+		return nil, nil, fmt.Errorf("--confirm %s does not match the plan: files or destinations changed since the dry run; review the plan again with --safe alone, which prints a new token", o.confirm)
+	}
+	return s.replay, s, nil
+}
+
+// printConfirmation tells how to carry out the plan a --safe dry run
+// printed.
+func (s *safePlan) printConfirmation(cmd *cobra.Command) {
+	if s == nil {
+		return
+	}
+	p := output.New(cmd.ErrOrStderr())
+	if len(s.items) == 0 {
+		p.Println(output.Dim, "Safe mode: nothing to do.")
+		return
+	}
+	p.Warn("Safe mode: nothing was changed. To carry out this exact plan, run the same command again with --confirm %s", s.token())
+}
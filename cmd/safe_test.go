@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/config"
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+func TestCopyCmd_Safe(t *testing.T) {
+	tempDir := testutil.TempDir(t)
+	srcDir := filepath.Join(tempDir, "src")
+	dstDir := filepath.Join(tempDir, "dst")
+	if err := os.MkdirAll(srcDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(srcDir, "a.jpg"), []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	run := func(args ...string) (string, error) {
+		cmd := createCopyCmd(&deps.AppDeps{Files: createTestFilesService(nil), Config: &config.Config{}})
+		cmd.SetArgs(args)
+		cmd.SetOut(&bytes.Buffer{})
+		var stderr bytes.Buffer
+		cmd.SetErr(&stderr)
+		err := cmd.Execute()
+		return stderr.String(), err
+	}
+	copied := filepath.Join(dstDir, "2025/01/27/15_30.jpg")
+
+	stderr, err := run("--safe", srcDir, dstDir)
+	if err != nil {
+		t.Fatalf("copy --safe failed: %v", err)
+	}
+	m := regexp.MustCompile(`--confirm ([0-9a-f]+)`).FindStringSubmatch(stderr)
+	if m == nil {
+		t.Fatalf("expected a confirmation token, got %q", stderr)
+	}
+	if _, err := os.Stat(copied); !os.IsNotExist(err) {
+		t.Fatalf("an unconfirmed run should not copy, got %v", err)
+	}
+
+	if _, err := run("--safe", "--confirm", "0000", srcDir, dstDir); err == nil || !strings.Contains(err.Error(), "does not match the plan") {
+		t.Fatalf("expected a wrong token to be refused, got %v", err)
+	}
+	if _, err := os.Stat(copied); !os.IsNotExist(err) {
+		t.Fatalf("a refused run should not copy, got %v", err)
+	}
+
+	if _, err := run("--safe", "--confirm", m[1], srcDir, dstDir); err != nil {
+		t.Fatalf("confirmed copy failed: %v", err)
+	}
+	if _, err := os.Stat(copied); err != nil {
+		t.Errorf("the confirmed plan should be carried out: %v", err)
+	}
+}
+
+func TestSafeFlagValidation(t *testing.T) {
+	tempDir := testutil.TempDir(t)
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"--confirm", "abc", tempDir, tempDir}, "--confirm requires --safe"},
+		{[]string{"--safe", "--stream", tempDir, tempDir}, "--stream cannot be combined with --safe"},
+	}
+	for _, tc := range tests {
+		cmd := createCopyCmd(&deps.AppDeps{Files: createTestFilesService(nil), Config: &config.Config{}})
+		cmd.SetArgs(tc.args)
+		cmd.SetOut(&bytes.Buffer{})
+		cmd.SetErr(&bytes.Buffer{})
+		if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%v: expected %q, got %v", tc.args, tc.want, err)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/output"
+	"github.com/Tmunayyer/gocamelpack/queue"
+	"github.com/spf13/cobra"
+)
+
+func createScheduleCmd(d *deps.AppDeps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   `schedule "<cron>" (copy|move) [source] [destination] [-- flags]`,
+		Short: "Queue a copy or move periodically from the daemon",
+		Long: `Adds a schedule that the daemon turns into a queued job whenever the cron
+expression fires, e.g.
+
+  gocamelpack schedule "0 2 * * *" copy /mnt/camera /archive -- --atomic
+
+The expression has five fields (minute hour day-of-month month day-of-week)
+and accepts *, lists, ranges, steps, month/weekday names and @daily-style
+shorthands. A run is skipped while the previous one is still queued or
+running. Times are in the daemon's local time zone.`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			args, _ = splitAtDash(cmd, args)
+			return cobra.ExactArgs(4)(cmd, args)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			args, flags := splitAtDash(cmd, args)
+			job, err := jobFromArgs(d, args[1:], flags)
+			if err != nil {
+				return err
+			}
+			q, err := openQueue(cmd)
+			if err != nil {
+				return err
+			}
+			s, err := q.AddSchedule(args[0], job)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "%s (next run %s)\n", s.ID, s.Next.Format(time.RFC3339))
+			return nil
+		},
+	}
+
+	cmd.PersistentFlags().String("queue", "", "Queue file (default $XDG_CONFIG_HOME/gocamelpack/queue.json)")
+	cmd.AddCommand(createScheduleListCmd())
+	cmd.AddCommand(createScheduleRemoveCmd())
+	return cmd
+}
+
+func createScheduleListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List schedules with their next and recent runs",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			q, err := openQueue(cmd)
+			if err != nil {
+				return err
+			}
+			schedules, err := q.Schedules()
+			if err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			if outputFormat(cmd) == "json" {
+				enc := json.NewEncoder(out)
+				enc.SetIndent("", "  ")
+				return enc.Encode(schedules)
+			}
+			if len(schedules) == 0 {
+				fmt.Fprintln(out, "No schedules.")
+				return nil
+			}
+
+			jobs, err := q.Jobs()
+			if err != nil {
+				return err
+			}
+			states := make(map[string]queue.State, len(jobs))
+			for _, j := range jobs {
+				states[j.ID] = j.State
+			}
+
+			runs, _ := cmd.Flags().GetInt("runs")
+			for i, s := range schedules {
+				if i > 0 {
+					fmt.Fprintln(out)
+				}
+				fmt.Fprintf(out, "%s  %q  %s %s %s %s\n", s.ID, s.Cron, s.Job.Command, s.Job.Source, output.New(out).Arrow(), s.Job.Destination)
+				fmt.Fprintf(out, "  next run: %s\n", s.Next.Format(time.RFC3339))
+
+				history := s.History
+				if len(history) > runs {
+					history = history[len(history)-runs:]
+				}
+				tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+				for _, r := range history {
+					if r.Skipped != "" {
+						fmt.Fprintf(tw, "  %s\tskipped\t%s\n", r.At.Format(time.RFC3339), r.Skipped)
+					} else {
+						fmt.Fprintf(tw, "  %s\t%s\tjob %s\n", r.At.Format(time.RFC3339), states[r.Job], r.Job)
+					}
+				}
+				if err := tw.Flush(); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+	cmd.Flags().Int("runs", 5, "Number of recent runs to show per schedule")
+	return cmd
+}
+
+func createScheduleRemoveCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "remove [id]",
+		Short: "Delete a schedule (jobs it already queued are kept)",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			q, err := openQueue(cmd)
+			if err != nil {
+				return err
+			}
+			if err := q.RemoveSchedule(args[0]); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Removed schedule %s.\n", args[0])
+			return nil
+		},
+	}
+}
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+func TestScheduleCmd_AddListRemove(t *testing.T) {
+	queuePath := filepath.Join(testutil.TempDir(t), "queue.json")
+	dst := testutil.TempDir(t)
+
+	out, err := runDaemonCLI(t, "schedule", "--queue", queuePath, "0 2 * * *", "copy", "/mnt/camera", dst, "--", "--atomic")
+	if err != nil {
+		t.Fatalf("schedule: %v\n%s", err, out)
+	}
+	id, _, _ := strings.Cut(out, " ")
+	if !strings.Contains(out, "(next run ") {
+		t.Errorf("expected next run in output, got %q", out)
+	}
+
+	out, err = runDaemonCLI(t, "schedule", "list", "--queue", queuePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, id) || !strings.Contains(out, `"0 2 * * *"  copy /mnt/camera → `+dst) {
+		t.Errorf("unexpected list output:\n%s", out)
+	}
+
+	if _, err := runDaemonCLI(t, "schedule", "remove", "--queue", queuePath, id); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	out, _ = runDaemonCLI(t, "schedule", "list", "--queue", queuePath)
+	if !strings.Contains(out, "No schedules.") {
+		t.Errorf("schedule not removed:\n%s", out)
+	}
+}
+
+func TestScheduleCmd_RejectsBadInput(t *testing.T) {
+	queuePath := filepath.Join(testutil.TempDir(t), "queue.json")
+
+	if _, err := runDaemonCLI(t, "schedule", "--queue", queuePath, "0 25 * * *", "copy", "/a", "/b"); err == nil {
+		t.Error("expected invalid hour to be rejected")
+	}
+	if _, err := runDaemonCLI(t, "schedule", "--queue", queuePath, "@daily", "copy", "/a", "/b", "--", "--nope"); err == nil {
+		t.Error("expected unknown job flag to be rejected")
+	}
+}
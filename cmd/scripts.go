@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+
+	"github.com/Tmunayyer/gocamelpack/config"
+	"github.com/Tmunayyer/gocamelpack/output"
+	"github.com/spf13/cobra"
+)
+
+// The hook scripts copy and move run from the hooks folder.
+const (
+	hookPreRun   = "pre-run"
+	hookPostRun  = "post-run"
+	hookPostFile = "post-file"
+)
+
+// hookScripts runs the user's executables from the hooks folder: pre-run
+// before anything is transferred, whose failure cancels the run, post-file
+// after each copied or moved file, and post-run at the end. They learn
+// about the run from GOCAMELPACK_* environment variables.
+type hookScripts struct {
+	dir string
+	// env describes the run to every script.
+	env []string
+	// out receives the scripts' output, so stdout stays the run's.
+	out io.Writer
+	// started is set once pre-run passed, so post-run only follows runs
+	// that began.
+	started bool
+}
+
+// newHookScripts returns the scripts in dir, or nil when it has none.
+func newHookScripts(dir string, out io.Writer, env ...string) *hookScripts {
+	h := &hookScripts{dir: dir, env: env, out: out}
+	for _, name := range []string{hookPreRun, hookPostRun, hookPostFile} {
+		if _, ok := h.script(name); ok {
+			return h
+		}
+	}
+	return nil
+}
+
+// script returns the path of the named script when it is an executable
+// file.
+func (h *hookScripts) script(name string) (string, bool) {
+	path := filepath.Join(h.dir, name)
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return "", false
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0o111 == 0 {
+		return "", false
+	}
+	return path, true
+}
+
+// run runs the named script, if there is one, with extra variables.
+func (h *hookScripts) run(name string, extra ...string) error {
+	path, ok := h.script(name)
+	if !ok {
+		return nil
+	}
+	c := exec.Command(path)
+	c.Env = append(append(append(os.Environ(), "GOCAMELPACK_HOOK="+name), h.env...), extra...)
+	c.Stdout, c.Stderr = h.out, h.out
+	if err := c.Run(); err != nil {
+		return fmt.Errorf("hook %s: %w", path, err)
+	}
+	return nil
+}
+
+// preRun runs pre-run for the source; an error cancels the run.
+func (h *hookScripts) preRun(source string) error {
+	if h == nil {
+		return nil
+	}
+	h.env = append(h.env, "GOCAMELPACK_SOURCE="+source)
+	if err := h.run(hookPreRun); err != nil {
+		return fmt.Errorf("%w; the run was cancelled", err)
+	}
+	h.started = true
+	return nil
+}
+
+// onEvent runs post-file for each transferred file. A failing script is
+// reported without stopping the run.
+func (h *hookScripts) onEvent(e fileEvent) {
+	if e.Event != eventCopied && e.Event != eventMoved {
+		return
+	}
+	op := "copy"
+	if e.Event == eventMoved {
+		op = "move"
+	}
+	if err := h.run(hookPostFile, "GOCAMELPACK_OPERATION="+op, "GOCAMELPACK_FILE_SOURCE="+e.Source, "GOCAMELPACK_FILE_DESTINATION="+e.Destination); err != nil {
+		output.New(h.out).Warn("%v", err)
+	}
+}
+
+// postRun runs post-run with the run's outcome and number of transferred
+// files.
+func (h *hookScripts) postRun(cmd *cobra.Command, runErr error, transferred int) {
+	if h == nil || !h.started {
+		return
+	}
+	status, msg := "success", ""
+	if runErr != nil {
+		status, msg = "failure", runErr.Error()
+	}
+	err := h.run(hookPostRun, "GOCAMELPACK_STATUS="+status, "GOCAMELPACK_ERROR="+msg, "GOCAMELPACK_FILES="+strconv.Itoa(transferred))
+	if err != nil {
+		output.New(cmd.ErrOrStderr()).Warn("%v", err)
+	}
+}
+
+// hooksDir returns the folder hook scripts are read from.
+func hooksDir(configured string) string {
+	if configured != "" {
+		return configured
+	}
+	dir, err := config.DefaultHooksDir()
+	if err != nil {
+		// Without a config folder there are no scripts to run.
+		return ""
+	}
+	return dir
+}
+
+// preRun runs the pre-run script for the run's source: the --from-file
+// list, or the absolute source path.
+func (o transferOptions) preRun(srcInput string) error {
+	if o.scripts == nil {
+		return nil
+	}
+	source := o.fromFile
+	if source == "" {
+		abs, err := filepath.Abs(srcInput)
+		if err != nil {
+			return fmt.Errorf("resolving %q: %w", srcInput, err)
+		}
+		source = abs
+	}
+	return o.scripts.preRun(source)
+}
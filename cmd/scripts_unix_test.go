@@ -0,0 +1,82 @@
+//go:build !windows
+
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/config"
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+func TestCopyCmd_RunsHookScripts(t *testing.T) {
+	tempDir := testutil.TempDir(t)
+	srcDir := filepath.Join(tempDir, "card")
+	hooks := filepath.Join(tempDir, "hooks")
+	logFile := filepath.Join(tempDir, "hooks.log")
+	for _, dir := range []string{srcDir, hooks} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(srcDir, "a.jpg"), []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	script := func(name, body string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(hooks, name), []byte("#!/bin/sh\n"+body+"\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	script("pre-run", `echo "$GOCAMELPACK_HOOK $GOCAMELPACK_COMMAND $GOCAMELPACK_SOURCE" >> `+logFile)
+	script("post-file", `echo "$GOCAMELPACK_HOOK $GOCAMELPACK_OPERATION $GOCAMELPACK_FILE_DESTINATION" >> `+logFile)
+	script("post-run", `echo "$GOCAMELPACK_HOOK $GOCAMELPACK_STATUS $GOCAMELPACK_FILES" >> `+logFile)
+
+	copyWith := func(dstDir string, extra ...string) error {
+		var out bytes.Buffer
+		cmd := createCopyCmd(&deps.AppDeps{Files: createTestFilesService(nil), Config: &config.Config{HooksDir: hooks}})
+		cmd.SetArgs(append(extra, srcDir, dstDir))
+		cmd.SetOut(&out)
+		cmd.SetErr(&out)
+		return cmd.Execute()
+	}
+
+	if err := copyWith(filepath.Join(tempDir, "dst")); err != nil {
+		t.Fatalf("copy: %v", err)
+	}
+	data, _ := os.ReadFile(logFile)
+	want := strings.Join([]string{
+		"pre-run copy " + srcDir,
+		"post-file copy " + filepath.Join(tempDir, "dst", "2025", "01", "27", "15_30.jpg"),
+		"post-run success 1",
+	}, "\n") + "\n"
+	if string(data) != want {
+		t.Errorf("hook log = %q, want %q", data, want)
+	}
+
+	os.Remove(logFile)
+	if err := copyWith(filepath.Join(tempDir, "dry"), "--dry-run"); err != nil {
+		t.Fatalf("copy --dry-run: %v", err)
+	}
+	if _, err := os.Stat(logFile); !os.IsNotExist(err) {
+		t.Error("hooks should not run for a dry run")
+	}
+
+	script("pre-run", "exit 3")
+	dstDir := filepath.Join(tempDir, "cancelled")
+	err := copyWith(dstDir)
+	if err == nil || !strings.Contains(err.Error(), "cancelled") {
+		t.Fatalf("expected a failing pre-run to cancel the run, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dstDir, "2025")); !os.IsNotExist(err) {
+		t.Error("no file should be copied when pre-run fails")
+	}
+	if _, err := os.Stat(logFile); !os.IsNotExist(err) {
+		t.Error("post-run should not follow a cancelled run")
+	}
+}
@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Tmunayyer/gocamelpack/catalog"
+	"github.com/Tmunayyer/gocamelpack/export"
+	"github.com/Tmunayyer/gocamelpack/journal"
+	"github.com/Tmunayyer/gocamelpack/output"
+	"github.com/spf13/cobra"
+)
+
+func createSearchCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "search [archive-root]",
+		Short: "List archived files by the tags and dates of their runs",
+		Long: `Reads the session journals in archive-root (default the current directory)
+and prints, one per line, the archived path of every file a run transferred
+whose tags match all --tag filters and which was archived between --since and
+--until (inclusive, YYYY-MM-DD, or a whole YYYY-MM or YYYY). A filter without
+a value, e.g. --tag trip, matches any value of the key; values are compared
+ignoring case.
+
+Runs journal their files when given --tag, or with --atomic --chunk-size, and
+an archive's catalog (see copy --catalog) lists every file imported since it
+was created; search reads both. Neither is indexed: every search reads them
+in full. Files rolled back or failed are left out.`,
+		Args: cobra.RangeArgs(0, 1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			root := "."
+			if len(args) == 1 {
+				root = args[0]
+			}
+			specs, _ := cmd.Flags().GetStringArray("tag")
+			var q searchQuery
+			var err error
+			if q.tags, err = parseSearchTags(specs); err != nil {
+				return err
+			}
+			since, _ := cmd.Flags().GetString("since")
+			until, _ := cmd.Flags().GetString("until")
+			if q.period, err = export.ParseRange(since, until); err != nil {
+				return err
+			}
+
+			found, err := searchJournals(root, q)
+			if err != nil {
+				return err
+			}
+			if outputFormat(cmd) == "json" {
+				return json.NewEncoder(cmd.OutOrStdout()).Encode(searchResult{Files: found})
+			}
+			if len(found) == 0 {
+				output.New(cmd.ErrOrStderr()).Println(output.Dim, "No journaled files match.")
+				return nil
+			}
+			for _, f := range found {
+				fmt.Fprintln(cmd.OutOrStdout(), f.Path)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringArray("tag", nil, "Only files of runs tagged key=value, or with the key at all (repeatable; all must match)")
+	cmd.Flags().String("since", "", "First day files were archived, YYYY-MM-DD (or YYYY-MM, YYYY)")
+	cmd.Flags().String("until", "", "Last day files were archived, YYYY-MM-DD (or YYYY-MM, YYYY)")
+	return cmd
+}
+
+// searchQuery selects journal entries; an empty tag value matches any.
+type searchQuery struct {
+	tags   map[string]string
+	period export.Range
+}
+
+// searchHit is an archived file search found.
+type searchHit struct {
+	Path     string            `json:"path"`
+	Source   string            `json:"source"`
+	Session  string            `json:"session"`
+	Archived time.Time         `json:"archived"`
+	Tags     map[string]string `json:"tags,omitempty"`
+}
+
+type searchResult struct {
+	Files []searchHit `json:"files"`
+}
+
+// parseSearchTags parses --tag filters, key=value or just key.
+func parseSearchTags(specs []string) (map[string]string, error) {
+	tags := map[string]string{}
+	for _, spec := range specs {
+		key, value, _ := strings.Cut(spec, "=")
+		if key = strings.TrimSpace(key); key == "" {
+			return nil, fmt.Errorf("invalid --tag %q (want key=value or key)", spec)
+		}
+		tags[key] = strings.TrimSpace(value)
+	}
+	return tags, nil
+}
+
+// matches reports whether e's files are selected by q.
+func (q searchQuery) matches(e journal.Entry) bool {
+	return !e.RolledBack && q.selects(e.Tags, e.Time)
+}
+
+// selects reports whether a file archived at archived by a run with tags
+// is selected by q.
+func (q searchQuery) selects(tags map[string]string, archived time.Time) bool {
+	if !q.period.Contains(archived) {
+		return false
+	}
+	for k, want := range q.tags {
+		got, ok := tags[k]
+		if !ok || (want != "" && !strings.EqualFold(got, want)) {
+			return false
+		}
+	}
+	return true
+}
+
+// searchJournals returns the files of the journal entries and catalog in
+// root that q selects, oldest first. A file recorded again, e.g. by a retry
+// or in both, is listed once, from its latest record.
+func searchJournals(root string, q searchQuery) ([]searchHit, error) {
+	dir := filepath.Join(root, journal.Dir)
+	journals, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	var hits []searchHit
+	index := map[string]int{}
+	add := func(hit searchHit) {
+		if i, ok := index[hit.Path]; ok {
+			if !hit.Archived.Before(hits[i].Archived) {
+				hits[i] = hit
+			}
+			return
+		}
+		index[hit.Path] = len(hits)
+		hits = append(hits, hit)
+	}
+	for _, de := range journals {
+		if de.IsDir() || filepath.Ext(de.Name()) != ".jsonl" {
+			continue
+		}
+		entries, err := journal.Read(filepath.Join(dir, de.Name()))
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			if !q.matches(e) {
+				continue
+			}
+			for _, op := range e.Operations {
+				add(searchHit{Path: op.Destination, Source: op.Source, Session: e.Session, Archived: e.Time, Tags: e.Tags})
+			}
+		}
+	}
+	if catalog.Exists(root) {
+		cat, err := catalog.Open(root)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range cat.Entries() {
+			if q.selects(e.Tags, e.Archived) {
+				add(searchHit{Path: cat.Abs(e), Source: e.Source, Session: e.Session, Archived: e.Archived, Tags: e.Tags})
+			}
+		}
+	}
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Archived.Before(hits[j].Archived) })
+	return hits, nil
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/journal"
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+// searchArchive journals a tagged, an untagged and a rolled-back session
+// in a new archive root.
+func searchArchive(t *testing.T) string {
+	t.Helper()
+	root := testutil.TempDir(t)
+	iceland := journal.Open(root, "s1")
+	iceland.SetTags(map[string]string{"trip": "Iceland2025", "photographer": "Sam"})
+	if err := iceland.Record(1, 1, []journal.Operation{{Type: "copy", Source: "/card/a.jpg", Destination: root + "/2025/a.jpg"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := iceland.RecordRollback(1, 1, false, []journal.Operation{{Type: "copy", Source: "/card/b.jpg", Destination: root + "/2025/b.jpg"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := journal.Open(root, "s2").Record(1, 1, []journal.Operation{{Type: "copy", Source: "/card/c.jpg", Destination: root + "/2025/c.jpg"}}); err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+func runSearch(t *testing.T, args ...string) string {
+	t.Helper()
+	var stdout bytes.Buffer
+	root := newCLI(&deps.AppDeps{Streams: deps.Streams{Out: &stdout, Err: &bytes.Buffer{}}})
+	root.SetArgs(append([]string{"search"}, args...))
+	if err := root.Execute(); err != nil {
+		t.Fatalf("search %v failed: %v", args, err)
+	}
+	return stdout.String()
+}
+
+func TestSearchCmd(t *testing.T) {
+	root := searchArchive(t)
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"--tag", "trip=iceland2025", root}, []string{root + "/2025/a.jpg"}},
+		{[]string{"--tag", "photographer", "--tag", "trip=Iceland2025", root}, []string{root + "/2025/a.jpg"}},
+		{[]string{"--tag", "trip=Norway", root}, nil},
+		{[]string{root}, []string{root + "/2025/a.jpg", root + "/2025/c.jpg"}},
+		{[]string{"--until", "2000", root}, nil},
+	}
+	for _, tc := range tests {
+		got := strings.Fields(runSearch(t, tc.args...))
+		if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+			t.Errorf("search %v = %v, want %v", tc.args, got, tc.want)
+		}
+	}
+}
+
+func TestSearchCmd_JSON(t *testing.T) {
+	root := searchArchive(t)
+	var got searchResult
+	if err := json.Unmarshal([]byte(runSearch(t, "--output", "json", "--tag", "trip", root)), &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Files) != 1 || got.Files[0].Session != "s1" || got.Files[0].Source != "/card/a.jpg" || got.Files[0].Tags["trip"] != "Iceland2025" {
+		t.Errorf("files = %+v", got.Files)
+	}
+}
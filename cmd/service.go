@@ -0,0 +1,222 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Tmunayyer/gocamelpack/config"
+	"github.com/Tmunayyer/gocamelpack/output"
+	"github.com/Tmunayyer/gocamelpack/service"
+	"github.com/spf13/cobra"
+)
+
+// runCommand runs an init-system command; replaced in tests.
+var runCommand = func(argv []string) ([]byte, error) {
+	return exec.Command(argv[0], argv[1:]...).CombinedOutput()
+}
+
+func createServiceCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "service",
+		Short: "Install the daemon as a systemd user unit or launchd agent",
+		Long: `Writes a definition that runs "gocamelpack daemon" with the current config
+file and queue, so queued imports run without a terminal. Linux uses a systemd
+user unit, macOS a launchd agent.`,
+	}
+
+	cmd.PersistentFlags().String("init", "auto", "Init system: auto, systemd or launchd")
+	cmd.PersistentFlags().String("dir", "", "Directory for the unit or plist (default: the user's systemd or LaunchAgents directory)")
+
+	install := &cobra.Command{
+		Use:   "install",
+		Short: "Write and activate the service definition",
+		Args:  cobra.NoArgs,
+		RunE:  runServiceInstall,
+	}
+	install.Flags().String("queue", "", "Queue file for the daemon (default: the daemon's default)")
+	install.Flags().Int("concurrency", 1, "Maximum number of jobs the daemon runs at the same time")
+	install.Flags().Bool("no-activate", false, "Only write the definition; do not enable or start it")
+
+	cmd.AddCommand(install)
+	cmd.AddCommand(&cobra.Command{
+		Use:   "uninstall",
+		Short: "Stop the service and remove its definition",
+		Args:  cobra.NoArgs,
+		RunE:  runServiceUninstall,
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "status",
+		Short: "Show whether the service is installed and running",
+		Args:  cobra.NoArgs,
+		RunE:  runServiceStatus,
+	})
+	return cmd
+}
+
+// servicePath resolves --init and --dir to the definition's path.
+func servicePath(cmd *cobra.Command) (service.Init, string, error) {
+	initFlag, _ := cmd.Flags().GetString("init")
+	init, err := service.ParseInit(initFlag)
+	if err != nil {
+		return "", "", err
+	}
+	dir, _ := cmd.Flags().GetString("dir")
+	if dir == "" {
+		if dir, err = service.DefaultDir(init); err != nil {
+			return "", "", err
+		}
+	}
+	return init, filepath.Join(dir, service.FileName(init)), nil
+}
+
+func runServiceInstall(cmd *cobra.Command, args []string) error {
+	init, path, err := servicePath(cmd)
+	if err != nil {
+		return err
+	}
+	spec, err := daemonSpec(cmd, init)
+	if err != nil {
+		return err
+	}
+	def, err := service.Render(init, spec)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, []byte(def), 0o644); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	p := output.New(cmd.OutOrStdout())
+	p.Success("Wrote %s", path)
+
+	if noActivate, _ := cmd.Flags().GetBool("no-activate"); noActivate {
+		for _, argv := range service.CommandsFor(init, path).Enable {
+			fmt.Fprintf(cmd.OutOrStdout(), "Activate with: %s\n", strings.Join(argv, " "))
+		}
+		return nil
+	}
+	for _, argv := range service.CommandsFor(init, path).Enable {
+		if out, err := runCommand(argv); err != nil {
+			return fmt.Errorf("%s: %w\n%s", strings.Join(argv, " "), err, out)
+		}
+	}
+	p.Success("Service %s enabled and started.", service.Name)
+	return nil
+}
+
+// daemonSpec builds the daemon command line from this binary, the current
+// config file and the install flags.
+func daemonSpec(cmd *cobra.Command, init service.Init) (service.Spec, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return service.Spec{}, fmt.Errorf("locating gocamelpack binary: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+	spec := service.Spec{Executable: exe, Args: []string{"daemon"}}
+
+	cfgPath, _ := cmd.Flags().GetString("config")
+	if cfgPath == "" {
+		if def, err := config.DefaultPath(); err == nil {
+			if _, err := os.Stat(def); err == nil {
+				cfgPath = def
+			}
+		}
+	}
+	if cfgPath != "" {
+		abs, err := filepath.Abs(cfgPath)
+		if err != nil {
+			return spec, err
+		}
+		if _, err := os.Stat(abs); err != nil {
+			return spec, fmt.Errorf("config file: %w", err)
+		}
+		spec.Args = append(spec.Args, "--config", abs)
+	}
+
+	if queuePath, _ := cmd.Flags().GetString("queue"); queuePath != "" {
+		abs, err := filepath.Abs(queuePath)
+		if err != nil {
+			return spec, err
+		}
+		spec.Args = append(spec.Args, "--queue", abs)
+	}
+	concurrency, _ := cmd.Flags().GetInt("concurrency")
+	if concurrency < 1 {
+		return spec, fmt.Errorf("--concurrency must be at least 1")
+	}
+	if concurrency > 1 {
+		spec.Args = append(spec.Args, "--concurrency", strconv.Itoa(concurrency))
+	}
+
+	if init == service.Launchd {
+		if home, err := os.UserHomeDir(); err == nil {
+			spec.LogDir = filepath.Join(home, "Library", "Logs", "gocamelpack")
+		}
+	}
+	return spec, nil
+}
+
+func runServiceUninstall(cmd *cobra.Command, args []string) error {
+	init, path, err := servicePath(cmd)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		fmt.Fprintf(cmd.OutOrStdout(), "Service is not installed (%s not found).\n", path)
+		return nil
+	}
+
+	p := output.New(cmd.OutOrStdout())
+	for _, argv := range service.CommandsFor(init, path).Disable {
+		// The definition is removed regardless, so a service that was never
+		// activated can still be uninstalled.
+		if out, err := runCommand(argv); err != nil {
+			p.Warn("%s: %v %s", strings.Join(argv, " "), err, strings.TrimSpace(string(out)))
+		}
+	}
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+	p.Success("Removed %s", path)
+	return nil
+}
+
+func runServiceStatus(cmd *cobra.Command, args []string) error {
+	init, path, err := servicePath(cmd)
+	if err != nil {
+		return err
+	}
+	out := cmd.OutOrStdout()
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		fmt.Fprintf(out, "Installed: no (%s)\n", path)
+		return nil
+	}
+	fmt.Fprintf(out, "Installed: yes (%s)\n", path)
+
+	argv := service.CommandsFor(init, path).Status
+	res, err := runCommand(argv)
+	state := strings.TrimSpace(string(res))
+	if err != nil && state == "" {
+		state = err.Error()
+	}
+	if init == service.Launchd {
+		// launchctl list prints the job's details when it is loaded.
+		if err == nil {
+			state = "loaded"
+		} else {
+			state = "not loaded"
+		}
+	}
+	fmt.Fprintf(out, "Running:   %s\n", state)
+	return nil
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+// fakeInitCommands records the init-system commands run during a test.
+func fakeInitCommands(t *testing.T) *[]string {
+	t.Helper()
+	var ran []string
+	orig := runCommand
+	runCommand = func(argv []string) ([]byte, error) {
+		ran = append(ran, strings.Join(argv, " "))
+		return []byte("active\n"), nil
+	}
+	t.Cleanup(func() { runCommand = orig })
+	return &ran
+}
+
+func runServiceCLI(t *testing.T, args ...string) string {
+	t.Helper()
+	var out bytes.Buffer
+	root := newCLI(&deps.AppDeps{Files: createTestFilesService(nil), Streams: deps.Streams{Out: &out, Err: &out}})
+	root.SetArgs(args)
+	if err := root.Execute(); err != nil {
+		t.Fatalf("%v: %v\n%s", args, err, out.String())
+	}
+	return out.String()
+}
+
+func TestServiceInstallStatusUninstall(t *testing.T) {
+	ran := fakeInitCommands(t)
+	dir := testutil.TempDir(t)
+	cfgPath := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(cfgPath, []byte("{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	unit := filepath.Join(dir, "units", "gocamelpack.service")
+
+	runServiceCLI(t, "--config", cfgPath, "service", "install", "--init", "systemd", "--dir", filepath.Join(dir, "units"), "--concurrency", "2")
+	data, err := os.ReadFile(unit)
+	if err != nil {
+		t.Fatalf("unit not written: %v", err)
+	}
+	if !strings.Contains(string(data), " daemon --config "+cfgPath+" --concurrency 2\n") {
+		t.Errorf("unexpected ExecStart in:\n%s", data)
+	}
+	if len(*ran) != 2 || (*ran)[1] != "systemctl --user enable --now gocamelpack.service" {
+		t.Errorf("unexpected activation commands %q", *ran)
+	}
+
+	out := runServiceCLI(t, "service", "status", "--init", "systemd", "--dir", filepath.Join(dir, "units"))
+	if !strings.Contains(out, "Installed: yes") || !strings.Contains(out, "Running:   active") {
+		t.Errorf("unexpected status:\n%s", out)
+	}
+
+	runServiceCLI(t, "service", "uninstall", "--init", "systemd", "--dir", filepath.Join(dir, "units"))
+	if _, err := os.Stat(unit); !os.IsNotExist(err) {
+		t.Errorf("unit still present after uninstall: %v", err)
+	}
+	out = runServiceCLI(t, "service", "status", "--init", "systemd", "--dir", filepath.Join(dir, "units"))
+	if !strings.Contains(out, "Installed: no") {
+		t.Errorf("unexpected status after uninstall:\n%s", out)
+	}
+}
+
+func TestServiceInstall_NoActivate(t *testing.T) {
+	ran := fakeInitCommands(t)
+	dir := testutil.TempDir(t)
+
+	out := runServiceCLI(t, "service", "install", "--init", "launchd", "--dir", dir, "--no-activate")
+	if len(*ran) != 0 {
+		t.Errorf("--no-activate ran %q", *ran)
+	}
+	if !strings.Contains(out, "Activate with: launchctl load -w ") {
+		t.Errorf("expected activation hint, got:\n%s", out)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "io.github.tmunayyer.gocamelpack.plist")); err != nil {
+		t.Errorf("plist not written: %v", err)
+	}
+}
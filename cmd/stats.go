@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/Tmunayyer/gocamelpack/catalog"
+	"github.com/Tmunayyer/gocamelpack/output"
+	"github.com/Tmunayyer/gocamelpack/units"
+	"github.com/spf13/cobra"
+)
+
+func createStatsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "stats --archive <archive-root>",
+		Short: "Show how an archive grew, its top cameras and storage per year",
+		Long: `Summarises the catalog of the archive given with --archive, without reading
+the archived files: how many files and bytes were archived each month with
+the running total, the cameras with the most files, and the storage taken by
+each capture year. The archive needs a catalog; see copy --catalog and
+catalog rebuild.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			root, _ := cmd.Flags().GetString("archive")
+			if root == "" {
+				return fmt.Errorf("--archive is required")
+			}
+			root, err := filepath.Abs(root)
+			if err != nil {
+				return err
+			}
+			if !catalog.Exists(root) {
+				return fmt.Errorf("%s has no catalog; create it with: gocamelpack catalog rebuild %s", root, root)
+			}
+			cat, err := catalog.Open(root)
+			if err != nil {
+				return err
+			}
+			top, _ := cmd.Flags().GetInt("top")
+			s := catalog.Summarize(cat.Entries(), top)
+
+			if outputFormat(cmd) == "json" {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				return enc.Encode(s)
+			}
+			printStats(cmd, root, s)
+			return nil
+		},
+	}
+
+	cmd.Flags().String("archive", "", "Archive root whose catalog to summarise")
+	cmd.Flags().Int("top", 5, "Number of cameras to list")
+	return cmd
+}
+
+// printStats writes the stats as three tables.
+func printStats(cmd *cobra.Command, root string, s catalog.Stats) {
+	out := cmd.OutOrStdout()
+	p := output.New(out)
+	p.Println(output.Plain, "%s: %d file(s), %s", root, s.Files, units.ByteSize(s.Bytes))
+
+	table := func(title, header string, rows []catalog.Bucket, total bool) {
+		fmt.Fprintln(out)
+		p.Println(output.Plain, "%s", title)
+		tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, header)
+		for _, b := range rows {
+			if total {
+				fmt.Fprintf(tw, "%s\t%d\t%s\t%d\t%s\n", b.Key, b.Files, units.ByteSize(b.Bytes), b.TotalFiles, units.ByteSize(b.TotalBytes))
+			} else {
+				fmt.Fprintf(tw, "%s\t%d\t%s\n", b.Key, b.Files, units.ByteSize(b.Bytes))
+			}
+		}
+		tw.Flush()
+	}
+	table("Growth per month archived", "MONTH\tFILES\tSIZE\tTOTAL FILES\tTOTAL SIZE", s.Months, true)
+	table("Top cameras", "CAMERA\tFILES\tSIZE", s.Cameras, false)
+	table("Storage per capture year", "YEAR\tFILES\tSIZE", s.Years, false)
+}
@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Tmunayyer/gocamelpack/progress"
+	"github.com/Tmunayyer/gocamelpack/queue"
+	"github.com/spf13/cobra"
+)
+
+// defaultRecentJobs is how many finished jobs status lists.
+const defaultRecentJobs = 5
+
+// jobStatus is a queued job with the last snapshot of its progress.
+type jobStatus struct {
+	queue.Job
+	Progress *progress.Status `json:"progress,omitempty"`
+}
+
+// daemonStatus is the overview status prints without a job ID.
+type daemonStatus struct {
+	// Daemon is the last heartbeat of the daemon; nil when none is
+	// running or the last one exited.
+	Daemon  *queue.Daemon `json:"daemon,omitempty"`
+	Running bool          `json:"running"`
+	Pending int           `json:"pending"`
+	// Active are the running jobs, Recent the last finished ones, most
+	// recent first.
+	Active []jobStatus `json:"active"`
+	Recent []jobStatus `json:"recent"`
+}
+
+func createStatusCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "status [job-id]",
+		Short: "Show whether the daemon runs and how far its jobs have got",
+		Long: `Reports whether a daemon is working through the queue, how many jobs wait,
+the progress of the running jobs, from the snapshots they keep, and the last
+jobs to finish (--recent). A job interrupted by a daemon restart also shows
+where it had got to before it was queued again. With a job ID only that job
+is shown.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			q, err := openQueue(cmd)
+			if err != nil {
+				return err
+			}
+			jobs, err := q.Jobs()
+			if err != nil {
+				return err
+			}
+			dir := jobProgressDir(q)
+			withProgress := func(j queue.Job) jobStatus {
+				s := jobStatus{Job: j}
+				// A pending job's snapshot is from an interrupted run.
+				if j.State != queue.Pending {
+					s.Progress = readJobProgress(j, dir)
+				}
+				return s
+			}
+			out := cmd.OutOrStdout()
+
+			if len(args) == 1 {
+				for _, j := range jobs {
+					if j.ID != args[0] {
+						continue
+					}
+					statuses := []jobStatus{withProgress(j)}
+					if outputFormat(cmd) == "json" {
+						enc := json.NewEncoder(out)
+						enc.SetIndent("", "  ")
+						return enc.Encode(statuses)
+					}
+					return printActiveJobs(out, statuses)
+				}
+				return fmt.Errorf("no job %q in queue", args[0])
+			}
+
+			recent, _ := cmd.Flags().GetInt("recent")
+			st := daemonStatus{Active: []jobStatus{}, Recent: []jobStatus{}}
+			if d, ok, err := q.Daemon(); err != nil {
+				return err
+			} else if ok {
+				st.Daemon = &d
+				st.Running = d.Alive(time.Now())
+			}
+			var finished []queue.Job
+			for _, j := range jobs {
+				switch j.State {
+				case queue.Pending:
+					st.Pending++
+				case queue.Running:
+					st.Active = append(st.Active, withProgress(j))
+				default:
+					finished = append(finished, j)
+				}
+			}
+			sort.SliceStable(finished, func(a, b int) bool { return finished[a].Finished.After(finished[b].Finished) })
+			for _, j := range finished[:min(recent, len(finished))] {
+				st.Recent = append(st.Recent, withProgress(j))
+			}
+
+			if outputFormat(cmd) == "json" {
+				enc := json.NewEncoder(out)
+				enc.SetIndent("", "  ")
+				return enc.Encode(st)
+			}
+			return printDaemonStatus(out, st)
+		},
+	}
+	cmd.Flags().String("queue", "", "Queue file (default $XDG_CONFIG_HOME/gocamelpack/queue.json)")
+	cmd.Flags().Int("recent", defaultRecentJobs, "Number of finished jobs to list")
+	return cmd
+}
+
+func printDaemonStatus(w io.Writer, st daemonStatus) error {
+	switch {
+	case st.Running:
+		d := st.Daemon
+		fmt.Fprintf(w, "Daemon:  running (pid %d on %s, since %s)\n", d.PID, d.Host, d.Started.Format(time.RFC3339))
+	case st.Daemon != nil:
+		fmt.Fprintf(w, "Daemon:  not running (last seen %s; it did not shut down cleanly)\n", st.Daemon.UpdatedAt.Format(time.RFC3339))
+	default:
+		fmt.Fprintln(w, "Daemon:  not running")
+	}
+	fmt.Fprintf(w, "Queue:   %d pending, %d running\n", st.Pending, len(st.Active))
+
+	if len(st.Active) > 0 {
+		fmt.Fprintln(w, "\nRunning:")
+		if err := printActiveJobs(w, st.Active); err != nil {
+			return err
+		}
+	}
+	if len(st.Recent) > 0 {
+		fmt.Fprintln(w, "\nRecently finished:")
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tSTATE\tFINISHED\tPROGRESS\tRESUMED FROM\tCOMMAND\tSOURCE\tDESTINATION\tERROR")
+		for _, s := range st.Recent {
+			prog, resumed := s.progressColumns()
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", s.ID, s.State, s.Finished.Format(time.RFC3339), prog, resumed, s.Command, s.Source, s.Destination, s.Error)
+		}
+		return tw.Flush()
+	}
+	return nil
+}
+
+// printActiveJobs lists jobs with their progress.
+func printActiveJobs(w io.Writer, statuses []jobStatus) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tSTATE\tPROGRESS\tRESUMED FROM\tCOMMAND\tSOURCE\tDESTINATION")
+	for _, s := range statuses {
+		prog, resumed := s.progressColumns()
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", s.ID, s.State, prog, resumed, s.Command, s.Source, s.Destination)
+	}
+	return tw.Flush()
+}
+
+// progressColumns formats the job's progress and where it resumed from,
+// "-" when unknown.
+func (s jobStatus) progressColumns() (prog, resumed string) {
+	prog, resumed = "-", "-"
+	if s.Progress != nil {
+		prog = fmt.Sprintf("%d/%d (%d%%)", s.Progress.Current, s.Progress.Total, s.Progress.Percent)
+	}
+	if s.Resumed != nil {
+		resumed = fmt.Sprintf("%d/%d", s.Resumed.Current, s.Resumed.Total)
+	}
+	return prog, resumed
+}
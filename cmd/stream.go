@@ -0,0 +1,212 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/ignore"
+	"github.com/Tmunayyer/gocamelpack/output"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// streamBuffer bounds how far each stage of a streaming run may get
+	// ahead of the next.
+	streamBuffer = 256
+	// streamBatch is how many sources are checked for stability at once.
+	streamBatch = 1024
+)
+
+// errStreamStopped ends the collecting and planning stages once the run
+// has stopped.
+var errStreamStopped = errors.New("stream stopped")
+
+// streamItem is one planned file on its way to execution.
+type streamItem struct {
+	src, dst string
+	skip     bool
+	// seen is how many sources have been collected so far.
+	seen int
+	err  error
+}
+
+// performStreamingTransfer copies or moves the files in srcPath (--stream)
+// through a pipeline that collects, plans and executes them concurrently
+// over bounded channels, so memory use does not grow with the size of the
+// source directory. Files are transferred in directory order as soon as
+// they are planned; an error stops the run, leaving earlier files in
+// place as in other non-atomic runs.
+func performStreamingTransfer(fs files.FilesService, srcPath, dstRoot string, opts transferOptions, cmd *cobra.Command, kind files.OperationType) (err error) {
+	opts = opts.withEvents()
+	verb, dryVerb, apply := "Copied", "Would copy", opts.applyCopy
+	if kind == files.OperationMove {
+		verb, dryVerb, apply = "Moved", "Would move", opts.applyMove
+	}
+	reporter := opts.reporter(cmd)
+	defer func() {
+		if err != nil {
+			reporter.SetError(err)
+		}
+	}()
+	if !opts.dryRun {
+		if err := opts.checkWritable(dstRoot); err != nil {
+			return err
+		}
+	}
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	defer func() {
+		close(done)
+		wg.Wait()
+	}()
+	sources := make(chan string, streamBuffer)
+	items := make(chan streamItem, streamBuffer)
+	wg.Add(2)
+	var collectErr error
+	go func() {
+		defer wg.Done()
+		defer close(sources)
+		collectErr = streamSources(fs, srcPath, opts.walk, sources, done)
+	}()
+	go func() {
+		defer wg.Done()
+		defer close(items)
+		// Once planning has drained sources, collecting is over too.
+		if opts.planStream(cmd, fs, dstRoot, sources, items, done) == nil && collectErr != nil {
+			send(items, streamItem{err: collectErr}, done)
+		}
+	}()
+
+	var planned []output.Mapping
+	for item := range items {
+		if item.err != nil {
+			// Collecting errors have no file to leave out.
+			if opts.failures == nil || item.src == "" {
+				return item.err
+			}
+			reporter.Increment()
+			continue
+		}
+		reporter.SetTotal(item.seen)
+		if item.skip {
+			reporter.Increment()
+			continue
+		}
+		reporter.SetMessage(fmt.Sprintf("%s %s", kind, item.src))
+		if opts.dryRun {
+			planned = append(planned, output.Mapping{Source: item.src, Destination: item.dst, Conflict: fs.IsFile(item.dst)})
+		} else if err := apply(fs, item.src, item.dst); err != nil {
+			opts.events.failed(item.src, item.dst, err)
+			if opts.failures == nil {
+				return err
+			}
+		}
+		reporter.Increment()
+	}
+
+	reporter.Finish()
+	if opts.dryRun {
+		opts.printPlan(cmd, dryVerb, dstRoot, planned)
+		return opts.reportFailures(cmd, fs, dstRoot, kind)
+	}
+	opts.summarize(cmd, verb)
+	if err := opts.journalTransferred(cmd, fs, dstRoot); err != nil {
+		return err
+	}
+	return opts.reportFailures(cmd, fs, dstRoot, kind)
+}
+
+// streamSources sends the absolute path of srcPath, or of each file in it
+// and in the subdirectories walk reaches, until done is closed. Files left
+// out by .gocamelpackignore files are not sent.
+func streamSources(fs files.FilesService, srcPath string, walk *sourceWalk, out chan<- string, done <-chan struct{}) error {
+	abs, err := filepath.Abs(srcPath)
+	if err != nil {
+		return fmt.Errorf("resolve %q: %w", srcPath, err)
+	}
+	if fs.IsFile(abs) {
+		return send(out, abs, done)
+	}
+	if !fs.IsDirectory(abs) {
+		return files.Errorf(files.ErrSourceMissing, "unknown src argument")
+	}
+	ign := ignore.NewTree(files.FSOf(fs), abs)
+	streamDir := func(dir string) error {
+		return files.StreamDirectory(fs, dir, func(name string) error {
+			path := filepath.Join(dir, name)
+			if ignored, err := ign.Ignored(path, false); err != nil || ignored {
+				return err
+			}
+			return send(out, path, done)
+		})
+	}
+	if walk == nil {
+		return streamDir(abs)
+	}
+	return walk.walk(files.FSOf(fs), abs, ign, streamDir)
+}
+
+// planStream plans each source from in, in batches so unstable files can
+// be dropped, and sends the results to out. It stops at the first planning
+// error, which it sends on, and then returns errStreamStopped; with
+// --skip-errors it carries on past them.
+func (o transferOptions) planStream(cmd *cobra.Command, fs files.FilesService, dstRoot string, in <-chan string, out chan<- streamItem, done <-chan struct{}) error {
+	seen := 0
+	batch := make([]string, 0, streamBatch)
+	plan := func() error {
+		stable := o.dropUnstable(cmd, batch)
+		batch = batch[:0]
+		for _, src := range stable {
+			item := streamItem{src: src, seen: seen}
+			if item.err = o.archive.check(src); item.err != nil {
+				o.events.failed(src, "", item.err)
+			} else {
+				item.dst, item.skip, item.err = o.destination(fs, src, dstRoot)
+			}
+			if item.err == nil && !item.skip {
+				item.err = o.checkDestination(item.dst)
+				if item.err == nil && !o.dryRun && !o.overwrite {
+					item.err = fs.ValidateCopyArgs(src, item.dst)
+				}
+				o.events.validated(src, item.dst, item.err)
+			}
+			if err := send(out, item, done); err != nil || (item.err != nil && o.failures == nil) {
+				return errStreamStopped
+			}
+		}
+		return nil
+	}
+
+	for src := range in {
+		// Streamed files are transferred as they come, so the run stops
+		// where the limit is reached.
+		if err := o.limits.add(files.FSOf(fs), src); err != nil {
+			send(out, streamItem{err: fmt.Errorf("stopped: %w", err)}, done)
+			return errStreamStopped
+		}
+		o.events.collected(src)
+		seen++
+		batch = append(batch, src)
+		// Without a stability check there is nothing to wait for.
+		if len(batch) == streamBatch || o.stability == nil {
+			if err := plan(); err != nil {
+				return err
+			}
+		}
+	}
+	return plan()
+}
+
+// send delivers v on ch unless done is closed first.
+func send[T any](ch chan<- T, v T, done <-chan struct{}) error {
+	select {
+	case ch <- v:
+		return nil
+	case <-done:
+		return errStreamStopped
+	}
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/config"
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+// writeCard creates n files in dir, each with its own capture minute.
+func writeCard(t *testing.T, dir string, n int) map[string]files.FileMetadata {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	metadata := map[string]files.FileMetadata{}
+	for i := range n {
+		src := filepath.Join(dir, fmt.Sprintf("IMG_%04d.jpg", i))
+		if err := os.WriteFile(src, []byte{byte(i)}, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		metadata[src] = files.FileMetadata{Filepath: src, Tags: map[string]string{
+			"CreationDate": fmt.Sprintf("2025:01:27 15:%02d:00-06:00", i),
+			"FileType":     "JPEG",
+		}}
+	}
+	return metadata
+}
+
+func TestCopyCmd_Stream(t *testing.T) {
+	tmp := testutil.TempDir(t)
+	card := filepath.Join(tmp, "card")
+	metadata := writeCard(t, card, 40)
+	run := func(args ...string) (string, error) {
+		var out bytes.Buffer
+		root := newCLI(&deps.AppDeps{Files: createTestFilesService(metadata), Config: &config.Config{}, Streams: deps.Streams{Out: &out, Err: &out}})
+		root.SetArgs(args)
+		err := root.Execute()
+		return out.String(), err
+	}
+
+	dst := filepath.Join(tmp, "archive")
+	out, err := run("copy", "--stream", card, dst)
+	if err != nil {
+		t.Fatalf("copy --stream: %v\n%s", err, out)
+	}
+	if !contains(out, "Copied 40 file(s)") {
+		t.Errorf("unexpected summary %q", out)
+	}
+	for i := range 40 {
+		if _, err := os.Stat(filepath.Join(dst, "2025/01/27", fmt.Sprintf("15_%02d.jpg", i))); err != nil {
+			t.Fatalf("file %d not copied: %v", i, err)
+		}
+	}
+
+	// Every destination now exists, so planning fails on the first file.
+	if _, err := run("copy", "--stream", card, dst); err == nil {
+		t.Error("expected a conflict on the second run")
+	}
+
+	moved := filepath.Join(tmp, "moved")
+	if out, err := run("move", "--stream", card, moved); err != nil || !contains(out, "Moved 40 file(s)") {
+		t.Fatalf("move --stream: %v\n%s", err, out)
+	}
+	if entries, _ := os.ReadDir(card); len(entries) != 0 {
+		t.Errorf("%d file(s) left in the source after move", len(entries))
+	}
+
+	for _, args := range [][]string{
+		{"copy", "--stream", "--atomic", card, dst},
+		{"copy", "--stream", "--jobs", "2", card, dst},
+		{"move", "--stream", "--bursts", card, dst},
+	} {
+		if _, err := run(args...); err == nil {
+			t.Errorf("%v: expected the combination to be rejected", args)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/Tmunayyer/gocamelpack/files"
+)
+
+// mirrorFrom records the folder --preserve-structure and --others
+// copy-as-is mirror: the source directory, or the folder of a single
+// source file, which then lands directly under the destination.
+func (o *transferOptions) mirrorFrom(fs files.FilesService, src string) error {
+	if !o.preserveStructure && (o.others == nil || o.others.action != othersAsIs) {
+		return nil
+	}
+	abs, err := filepath.Abs(src)
+	if err != nil {
+		return fmt.Errorf("resolving %q: %w", src, err)
+	}
+	if !fs.IsDirectory(abs) {
+		abs = filepath.Dir(abs)
+	}
+	o.sourceRoot = abs
+	return nil
+}
+
+// mirrored returns where src goes below dstRoot when keeping its place
+// below the source folder.
+func (o transferOptions) mirrored(src, dstRoot string) (string, error) {
+	rel, err := filepath.Rel(o.sourceRoot, src)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside the source folder %s", src, o.sourceRoot)
+	}
+	return filepath.Join(dstRoot, rel), nil
+}
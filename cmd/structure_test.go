@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/config"
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+func TestCopyCmd_PreserveStructure(t *testing.T) {
+	tempDir := testutil.TempDir(t)
+	srcDir := filepath.Join(tempDir, "card")
+	dstDir := filepath.Join(tempDir, "dst")
+	for _, rel := range []string{"a.jpg", "DCIM/100CANON/b.jpg"} {
+		path := filepath.Join(srcDir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(rel), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	run := func(args ...string) error {
+		cmd := createCopyCmd(&deps.AppDeps{Files: createTestFilesService(nil), Config: &config.Config{Template: "{year}"}})
+		cmd.SetArgs(args)
+		cmd.SetOut(&bytes.Buffer{})
+		cmd.SetErr(&bytes.Buffer{})
+		return cmd.Execute()
+	}
+	if err := run("--preserve-structure", "--recursive", srcDir, dstDir); err != nil {
+		t.Fatalf("copy: %v", err)
+	}
+	for _, rel := range []string{"a.jpg", "DCIM/100CANON/b.jpg"} {
+		if data, err := os.ReadFile(filepath.Join(dstDir, filepath.FromSlash(rel))); err != nil || string(data) != rel {
+			t.Errorf("%s: got %q, %v", rel, data, err)
+		}
+	}
+
+	// A single file lands directly under the destination.
+	single := filepath.Join(tempDir, "single")
+	if err := run("--preserve-structure", filepath.Join(srcDir, "DCIM", "100CANON", "b.jpg"), single); err != nil {
+		t.Fatalf("copy single file: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(single, "b.jpg")); err != nil {
+		t.Error(err)
+	}
+
+	if err := run("--preserve-structure", "--template", "{year}", srcDir, dstDir); err == nil || !strings.Contains(err.Error(), "cannot be combined with --template") {
+		t.Errorf("expected --template to be refused, got %v", err)
+	}
+}
@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/mirror"
+	"github.com/Tmunayyer/gocamelpack/output"
+	"github.com/Tmunayyer/gocamelpack/progress"
+	"github.com/spf13/cobra"
+)
+
+func createSyncCmd(d *deps.AppDeps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sync [source-root] [destination-root]",
+		Short: "Make one archive an exact copy of another",
+		Long: `Copies files that are new or changed in source-root to the same place below
+destination-root, so the destination keeps the source's organized layout.
+Files are compared by size and modification time, or with --checksum by
+content; copied files keep the source's modification time. With --delete,
+destination files missing from the source are removed. Files matching an
+--exclude pattern are left alone on both sides, as are hidden files.
+
+Replaced files are restored if their copy fails; with --atomic, every copy
+is undone when one fails. Deletions happen once all copies have succeeded.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			srcRoot, err := filepath.Abs(args[0])
+			if err != nil {
+				return err
+			}
+			dstRoot, err := filepath.Abs(args[1])
+			if err != nil {
+				return err
+			}
+			if !d.Files.IsDirectory(srcRoot) {
+				return files.Errorf(files.ErrSourceMissing, "%s is not a directory", srcRoot)
+			}
+			if srcRoot == dstRoot {
+				return fmt.Errorf("source and destination are the same directory")
+			}
+
+			var o mirror.Options
+			o.Checksum, _ = cmd.Flags().GetBool("checksum")
+			o.Delete, _ = cmd.Flags().GetBool("delete")
+			o.Exclude, _ = cmd.Flags().GetStringArray("exclude")
+			if err := o.Validate(); err != nil {
+				return err
+			}
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			cfg, err := loadConfig(cmd, d)
+			if err != nil {
+				return err
+			}
+			if err := cfg.Destinations.CheckRoot(dstRoot); err != nil {
+				return err
+			}
+			if o.Algo, err = hashAlgo(cmd, cfg.HashAlgo); err != nil {
+				return err
+			}
+
+			if noLock, _ := cmd.Flags().GetBool("no-lock"); !noLock && !dryRun {
+				lock, err := files.LockDir(dstRoot)
+				if err != nil {
+					return fmt.Errorf("%w; use --no-lock to bypass", err)
+				}
+				defer lock.Release()
+			}
+
+			changes, err := mirror.Plan(srcRoot, dstRoot, o)
+			if err != nil {
+				return err
+			}
+			if dryRun {
+				printSyncPlan(cmd, changes)
+				return nil
+			}
+
+			var reporter progress.ProgressReporter = progress.NewNoOpReporter()
+			if showProgress, _ := cmd.Flags().GetBool("progress"); showProgress {
+				style, err := progressStyle(cmd, cfg)
+				if err != nil {
+					return err
+				}
+				reporter = newProgressDisplay(cmd, style)
+			}
+			atomic, _ := cmd.Flags().GetBool("atomic")
+			return performSync(d.Files, srcRoot, dstRoot, changes, atomic, reporter, cmd)
+		},
+	}
+
+	cmd.Flags().Bool("dry-run", false, "Show what would be added, updated and deleted without doing it")
+	cmd.Flags().Bool("checksum", false, "Compare files of equal size by content instead of modification time")
+	cmd.Flags().String("hash-algo", "", "Checksum algorithm for --checksum: sha256 or xxhash64 (faster, not tamper-proof) (default from config, else sha256)")
+	cmd.Flags().Bool("delete", false, "Delete destination files that are missing from the source")
+	cmd.Flags().StringArray("exclude", nil, "Skip files and folders matching this glob (path, folder or name); repeatable")
+	cmd.Flags().Bool("atomic", false, "Undo every copy if one fails")
+	cmd.Flags().Bool("progress", false, "Show progress bar while copying")
+	cmd.Flags().Bool("no-lock", false, "Do not lock the destination against concurrent runs")
+	return cmd
+}
+
+// printSyncPlan lists planned changes and their totals.
+func printSyncPlan(cmd *cobra.Command, changes []mirror.Change) {
+	p := output.New(cmd.OutOrStdout())
+	counts := map[mirror.Action]int{}
+	for _, c := range changes {
+		counts[c.Action]++
+		style := output.Plain
+		if c.Action == mirror.Delete {
+			style = output.Warning
+		}
+		p.Println(style, "%-6s %s", c.Action, c.Rel)
+	}
+	p.Println(output.Plain, "Would add %d, update %d and delete %d file(s).", counts[mirror.Add], counts[mirror.Update], counts[mirror.Delete])
+}
+
+// performSync applies changes: copies first, as one transaction with atomic
+// or one per file otherwise, then deletions.
+func performSync(fs files.FilesService, srcRoot, dstRoot string, changes []mirror.Change, atomic bool, reporter progress.ProgressReporter, cmd *cobra.Command) (err error) {
+	defer func() {
+		if err != nil {
+			reporter.SetError(err)
+		}
+	}()
+
+	var copies, deletes []mirror.Change
+	for _, c := range changes {
+		if c.Action == mirror.Delete {
+			deletes = append(deletes, c)
+		} else {
+			copies = append(copies, c)
+		}
+	}
+	paths := func(c mirror.Change) (string, string) {
+		rel := filepath.FromSlash(c.Rel)
+		return filepath.Join(srcRoot, rel), filepath.Join(dstRoot, rel)
+	}
+
+	// Updates replace existing files, which only transactions can undo.
+	tx := fs.NewTransaction(true)
+	reporter.SetTotal(len(copies))
+	for i, c := range copies {
+		src, dst := paths(c)
+		if err := tx.Add(files.NewCopyOperation(src, dst)); err != nil {
+			return err
+		}
+		if atomic {
+			continue
+		}
+		reporter.SetMessage(fmt.Sprintf("sync %s", c.Rel))
+		if err := tx.Execute(); err != nil {
+			return err
+		}
+		if err := keepModTime(src, dst); err != nil {
+			return err
+		}
+		tx = fs.NewTransaction(true)
+		reporter.SetCurrent(i + 1)
+	}
+	if atomic {
+		if err := tx.ExecuteWithProgress(reporter); err != nil {
+			return err
+		}
+		for _, c := range copies {
+			if err := keepModTime(paths(c)); err != nil {
+				return err
+			}
+		}
+	}
+	reporter.Finish()
+
+	for _, c := range deletes {
+		_, dst := paths(c)
+		if err := os.Remove(dst); err != nil {
+			return err
+		}
+		removeEmptyDirs(filepath.Dir(dst), dstRoot)
+	}
+
+	added := len(copies)
+	for _, c := range copies {
+		if c.Action == mirror.Update {
+			added--
+		}
+	}
+	output.New(cmd.OutOrStdout()).Success("Synced: added %d, updated %d, deleted %d file(s).", added, len(copies)-added, len(deletes))
+	return nil
+}
+
+// keepModTime gives dst the modification time of src, so the next sync
+// recognises it as unchanged.
+func keepModTime(src, dst string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	return os.Chtimes(dst, info.ModTime(), info.ModTime())
+}
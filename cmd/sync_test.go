@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Tmunayyer/gocamelpack/config"
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+func TestSyncCmd(t *testing.T) {
+	tmp := testutil.TempDir(t)
+	src := filepath.Join(tmp, "primary")
+	dst := filepath.Join(tmp, "backup")
+	write := func(root, rel, content string) string {
+		p := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	run := func(args ...string) string {
+		t.Helper()
+		var out bytes.Buffer
+		root := newCLI(&deps.AppDeps{Files: createTestFilesService(nil), Config: &config.Config{}, Streams: deps.Streams{Out: &out, Err: &out}})
+		root.SetArgs(append([]string{"sync"}, args...))
+		if err := root.Execute(); err != nil {
+			t.Fatalf("sync %v: %v\n%s", args, err, out.String())
+		}
+		return out.String()
+	}
+
+	write(src, "2025/01/27/15_30.jpg", "new")
+	edited := write(src, "2025/01/28/09_00.jpg", "edited")
+	write(dst, "2025/01/28/09_00.jpg", "original")
+	stale := write(dst, "2024/12/31/23_59.jpg", "deleted from the primary")
+	write(src, "@eaDir/thumb.jpg", "excluded")
+
+	out := run("--dry-run", "--delete", "--exclude", "@eaDir", src, dst)
+	if !contains(out, "Would add 1, update 1 and delete 1 file(s).") {
+		t.Errorf("unexpected dry run:\n%s", out)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "2025/01/27/15_30.jpg")); !os.IsNotExist(err) {
+		t.Fatalf("dry run copied files, stat err = %v", err)
+	}
+
+	out = run("--delete", "--exclude", "@eaDir", src, dst)
+	if !contains(out, "Synced: added 1, updated 1, deleted 1 file(s).") {
+		t.Errorf("unexpected summary:\n%s", out)
+	}
+	if b, _ := os.ReadFile(filepath.Join(dst, "2025/01/28/09_00.jpg")); string(b) != "edited" {
+		t.Errorf("updated file has %q", b)
+	}
+	if _, err := os.Stat(filepath.Dir(stale)); !os.IsNotExist(err) {
+		t.Errorf("folder emptied by deletion should be removed, stat err = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "@eaDir")); !os.IsNotExist(err) {
+		t.Errorf("excluded folder should not be copied, stat err = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, files.LockName)); !os.IsNotExist(err) {
+		t.Errorf("lock should be released, stat err = %v", err)
+	}
+
+	// Copies keep the source's modification time, so a second run is a
+	// no-op even without checksums.
+	a, _ := os.Stat(edited)
+	b, _ := os.Stat(filepath.Join(dst, "2025/01/28/09_00.jpg"))
+	if !a.ModTime().Equal(b.ModTime()) {
+		t.Errorf("mtime %v, want %v", b.ModTime(), a.ModTime())
+	}
+	if out := run("--atomic", "--exclude", "@eaDir", src, dst); !contains(out, "added 0, updated 0, deleted 0") {
+		t.Errorf("second sync should change nothing:\n%s", out)
+	}
+
+	later := time.Now().Add(time.Hour)
+	os.Chtimes(edited, later, later)
+	if out := run("--checksum", "--exclude", "@eaDir", src, dst); !contains(out, "updated 0") {
+		t.Errorf("touched but identical file should not be copied with --checksum:\n%s", out)
+	}
+}
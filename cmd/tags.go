@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/pathtmpl"
+	"github.com/spf13/cobra"
+)
+
+func createTagsCmd(d *deps.AppDeps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tags [file]",
+		Short: "Show which template tokens a file can provide",
+		Long:  "Reads the file's metadata and lists every template token with the value it would produce and the tag it comes from. Tokens the file cannot provide are flagged as missing.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			src := args[0]
+			if !d.Files.IsFile(src) {
+				return files.Errorf(files.ErrSourceMissing, "src is not a file")
+			}
+			showAll, _ := cmd.Flags().GetBool("all")
+
+			metadata := d.Files.GetFileTags([]string{src})
+			if len(metadata) == 0 {
+				return fmt.Errorf("no metadata for %s", src)
+			}
+			md := metadata[0]
+
+			out := cmd.OutOrStdout()
+			tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(tw, "TOKEN\tVALUE\tSOURCE")
+
+			used := map[string]bool{}
+			missing := 0
+			for _, tok := range pathtmpl.Tokens() {
+				for _, tag := range tok.Tags {
+					used[tag] = true
+				}
+				value, source, ok := tok.Resolve(md)
+				if !ok {
+					missing++
+					fmt.Fprintf(tw, "%s\t-\tMISSING (needs %s)\n", tok.Placeholder(), describeTags(tok.Tags))
+					continue
+				}
+				fmt.Fprintf(tw, "%s\t%s\t%s\n", tok.Placeholder(), value, source)
+			}
+			if err := tw.Flush(); err != nil {
+				return err
+			}
+
+			total := len(pathtmpl.Tokens())
+			fmt.Fprintf(out, "\n%d of %d tokens available", total-missing, total)
+			if missing > 0 {
+				fmt.Fprintf(out, "; missing tokens cannot be used for this file")
+			}
+			fmt.Fprintln(out)
+
+			if showAll {
+				var other []string
+				for tag := range md.Tags {
+					if !used[tag] {
+						other = append(other, tag)
+					}
+				}
+				sort.Strings(other)
+
+				fmt.Fprintln(out, "\nOther tags present:")
+				tw = tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+				for _, tag := range other {
+					fmt.Fprintf(tw, "  %s\t%s\n", tag, md.Tags[tag])
+				}
+				return tw.Flush()
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().Bool("all", false, "Also list tags that do not feed any token")
+
+	return cmd
+}
+
+// describeTags renders a preference list such as "LensModel or LensID".
+func describeTags(tags []string) string {
+	switch len(tags) {
+	case 0:
+		return "a file extension"
+	case 1:
+		return tags[0]
+	}
+	s := tags[0]
+	for _, t := range tags[1:] {
+		s += " or " + t
+	}
+	return s
+}
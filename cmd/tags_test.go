@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+func TestTagsCmd(t *testing.T) {
+	tempDir := testutil.TempDir(t)
+	testFile := filepath.Join(tempDir, "IMG_0001.jpg")
+	if err := os.WriteFile(testFile, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	metadata := map[string]files.FileMetadata{
+		testFile: {
+			Filepath: testFile,
+			Tags: map[string]string{
+				"CreationDate": "2025:01:27 15:30:45-06:00",
+				"Model":        "X100V",
+				"ISO":          "200",
+			},
+		},
+	}
+
+	cmd := createTagsCmd(&deps.AppDeps{Files: createTestFilesService(metadata)})
+	cmd.SetArgs([]string{"--all", testFile})
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	output := out.String()
+	for _, want := range []string{
+		"{year}", "2025", "CreationDate",
+		"{model}", "X100V",
+		"{make}", "MISSING (needs Make)",
+		"{lens}", "MISSING (needs LensModel or LensID)",
+		"tokens available",
+		"Other tags present:", "ISO",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, output)
+		}
+	}
+}
+
+func TestTagsCmd_NotAFile(t *testing.T) {
+	cmd := createTagsCmd(&deps.AppDeps{Files: createTestFilesService(nil)})
+	cmd.SetArgs([]string{filepath.Join(testutil.TempDir(t), "missing.jpg")})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+
+	if err := cmd.Execute(); err == nil || err.Error() != "src is not a file" {
+		t.Fatalf("expected 'src is not a file', got %v", err)
+	}
+}
@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/output"
+	"github.com/Tmunayyer/gocamelpack/pathtmpl"
+	"github.com/spf13/cobra"
+)
+
+// syntheticSample is the metadata a template is rendered against when no
+// sample file is given: every token has a value.
+var syntheticSample = files.FileMetadata{
+	Filepath: "IMG_0001.JPG",
+	Tags: map[string]string{
+		"CreationDate": "2025:07:14 09:30:05+02:00",
+		"Make":         "Canon",
+		"Model":        "EOS R5",
+		"LensModel":    "RF24-105mm F4 L IS USM",
+		"FileType":     "JPEG",
+	},
+}
+
+// templateCheck is what template validate found, as printed.
+type templateCheck struct {
+	Template string   `json:"template"`
+	Tokens   []string `json:"tokens"`
+	Tags     []string `json:"tags"`
+	// Sample is the file rendered against; empty for synthetic metadata.
+	Sample   string `json:"sample,omitempty"`
+	Rendered string `json:"rendered,omitempty"`
+	// Missing are the tokens the sample has no value for.
+	Missing []string `json:"missing,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+func createTemplateCmd(d *deps.AppDeps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "template",
+		Short: "Check destination templates",
+	}
+	cmd.AddCommand(createTemplateValidateCmd(d))
+	return cmd
+}
+
+func createTemplateValidateCmd(d *deps.AppDeps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "validate <template> [sample-file]",
+		Short: "Parse a template, list the tags it needs and render it",
+		Long: `Parses the template, pointing at the position of any error, lists the
+tokens it uses and the metadata tags they read, and renders it against the
+sample file's metadata, or against synthetic metadata in which every token has
+a value. Catches typos before a long run; see "where" to plan a file with the
+config's rules as well.`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tpl, err := pathtmpl.Parse(args[0])
+			if err != nil {
+				var pe *pathtmpl.ParseError
+				if errors.As(err, &pe) && outputFormat(cmd) != "json" {
+					fmt.Fprintf(cmd.ErrOrStderr(), "  %s\n  %s^\n", pe.Template, strings.Repeat(" ", pe.Pos))
+				}
+				return err
+			}
+			l, err := localeFromFlags(cmd)
+			if err != nil {
+				return err
+			}
+			if l == nil {
+				cfg, err := loadConfig(cmd, d)
+				if err != nil {
+					return err
+				}
+				if cfg.Locale != "" {
+					if l, err = pathtmpl.ParseLocale(cfg.Locale); err != nil {
+						return err
+					}
+				}
+			}
+			tpl.SetLocale(l)
+
+			md := syntheticSample
+			res := templateCheck{Template: tpl.String(), Tokens: tpl.Tokens(), Tags: tpl.Tags()}
+			if len(args) == 2 {
+				if res.Sample, err = filepath.Abs(args[1]); err != nil {
+					return err
+				}
+				if !d.Files.IsFile(res.Sample) {
+					return files.Errorf(files.ErrSourceMissing, "%s is not a file", args[1])
+				}
+				tags := d.Files.GetFileTags([]string{res.Sample})
+				if len(tags) == 0 {
+					return fmt.Errorf("no metadata for %s", res.Sample)
+				}
+				md = tags[0]
+			}
+			for _, name := range res.Tokens {
+				tok, _ := pathtmpl.Lookup(name)
+				if _, _, ok := tok.ResolveIn(md, l); !ok && !slices.Contains(res.Missing, tok.Placeholder()) {
+					res.Missing = append(res.Missing, tok.Placeholder())
+				}
+			}
+			rendered, renderErr := tpl.Render(md)
+			if renderErr != nil {
+				res.Error = renderErr.Error()
+			} else {
+				res.Rendered = filepath.ToSlash(rendered)
+			}
+
+			if outputFormat(cmd) == "json" {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				if err := enc.Encode(res); err != nil {
+					return err
+				}
+				return renderErr
+			}
+			printTemplateCheck(cmd, res)
+			return renderErr
+		},
+	}
+	cmd.Flags().String("locale", "", "Language of {month_name} and {weekday} in the template, e.g. de or fr (default from config, else English)")
+	return cmd
+}
+
+// printTemplateCheck writes the result of template validate.
+func printTemplateCheck(cmd *cobra.Command, res templateCheck) {
+	out := cmd.OutOrStdout()
+	p := output.New(out)
+	p.Success("Template %s parses.", res.Template)
+	fmt.Fprintf(out, "Tokens: %s\n", strings.Join(res.Tokens, ", "))
+	fmt.Fprintf(out, "Tags:   %s\n", strings.Join(res.Tags, ", "))
+	sample := "synthetic metadata"
+	if res.Sample != "" {
+		sample = res.Sample
+	}
+	fmt.Fprintf(out, "Sample: %s\n", sample)
+	if len(res.Missing) > 0 {
+		fmt.Fprintf(out, "No value for: %s\n", strings.Join(res.Missing, ", "))
+	}
+	if res.Rendered != "" {
+		fmt.Fprintf(out, "Renders: %s\n", res.Rendered)
+	}
+}
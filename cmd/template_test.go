@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/config"
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+func TestTemplateValidateCmd(t *testing.T) {
+	tmp := filepath.Join(testutil.TempDir(t), "template")
+	if err := os.MkdirAll(tmp, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	photo := filepath.Join(tmp, "photo.jpg")
+	if err := os.WriteFile(photo, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	fs := createTestFilesService(map[string]files.FileMetadata{
+		photo: {Filepath: photo, Tags: map[string]string{"CreationDate": "2025:01:27 15:30:45-06:00"}},
+	})
+	run := func(args ...string) (string, error) {
+		var out bytes.Buffer
+		root := newCLI(&deps.AppDeps{Files: fs, Config: &config.Config{}, Streams: deps.Streams{Out: &out, Err: &out}})
+		root.SetArgs(append([]string{"template", "validate"}, args...))
+		err := root.Execute()
+		return out.String(), err
+	}
+
+	out, err := run("{year}/{model|lower}/{orig}")
+	if err != nil {
+		t.Fatalf("validate: %v\n%s", err, out)
+	}
+	for _, want := range []string{"Tokens: year, model, orig", "Model", "synthetic metadata", "Renders: 2025/eos r5/IMG_0001.JPG"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in:\n%s", want, out)
+		}
+	}
+
+	out, err = run("{year}/{model}/{orig}", photo)
+	if err == nil || !strings.Contains(out, "No value for: {model}") {
+		t.Errorf("a sample without a model should fail and say so, got %v:\n%s", err, out)
+	}
+	if out, err := run("{year}/{if model}{model}{else}unknown{end}/{orig}", photo); err != nil || !strings.Contains(out, "Renders: 2025/unknown/photo.jpg") {
+		t.Errorf("validate with a fallback: %v\n%s", err, out)
+	}
+
+	out, err = run("{year}/{modle}")
+	if err == nil || !strings.Contains(out, "\n  "+strings.Repeat(" ", 7)+"^") {
+		t.Errorf("expected a caret under the typo, got %v:\n%s", err, out)
+	}
+}
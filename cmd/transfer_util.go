@@ -1,63 +1,150 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
-	"path/filepath"
+	"io"
+	"time"
 
 	"github.com/Tmunayyer/gocamelpack/files"
-	"github.com/Tmunayyer/gocamelpack/progress"
+	"github.com/Tmunayyer/gocamelpack/output"
+	"github.com/Tmunayyer/gocamelpack/rules"
+	"github.com/Tmunayyer/gocamelpack/units"
+	"github.com/spf13/cobra"
 )
 
-// collectSources expands a user-supplied path into absolute file paths.
-//
-// * file  → []{abs(file)}
-// * dir   → []{abs(dir/entry1), abs(dir/entry2), …}
-func collectSources(fs files.FilesService, userPath string) ([]string, error) {
-	return collectSourcesWithProgress(fs, userPath, progress.NewNoOpReporter())
+// ruleSubject gathers what the rules engine needs to know about src. The
+// file is only stat'ed, through fs, when a rule has a size condition.
+func ruleSubject(fs files.FilesService, src string, withSize bool) (rules.Subject, error) {
+	tags := fs.GetFileTags([]string{src})
+	if len(tags) == 0 {
+		return rules.Subject{}, fmt.Errorf("no metadata for %s", src)
+	}
+
+	s := rules.Subject{Path: src, Metadata: tags[0], Size: -1}
+	if withSize {
+		info, err := files.FSOf(fs).Stat(src)
+		if err != nil {
+			return s, err
+		}
+		s.Size = info.Size()
+	}
+	return s, nil
 }
 
-// collectSourcesWithProgress expands a user-supplied path into absolute file paths with progress reporting.
-func collectSourcesWithProgress(fs files.FilesService, userPath string, reporter progress.ProgressReporter) ([]string, error) {
-	abs, err := filepath.Abs(userPath)
-	if err != nil {
-		return nil, fmt.Errorf("resolve %q: %w", userPath, err)
+// printSummary reports how many files were transferred, with the run's
+// retries and I/O statistics; with --output=json it writes them as a single
+// summary object instead.
+func printSummary(cmd *cobra.Command, verb string, done, skipped int, retries *files.RetryStats, stats *files.IOStats) {
+	if jsonLines(cmd) {
+		if err := writeSummaryJSON(cmd.OutOrStdout(), verb, done, skipped, retries, stats); err == nil {
+			return
+		}
 	}
 
-	if fs.IsFile(abs) {
-		reporter.SetMessage("Collecting single file")
-		reporter.SetTotal(1)
-		reporter.SetCurrent(1)
-		reporter.Finish()
-		return []string{abs}, nil
+	msg := fmt.Sprintf("%s %d file(s)", verb, done)
+	if skipped > 0 {
+		msg += fmt.Sprintf(", skipped %d", skipped)
 	}
-	if fs.IsDirectory(abs) {
-		reporter.SetMessage("Reading directory")
-		entries, err := fs.ReadDirectory(abs)
-		if err != nil {
-			reporter.SetError(err)
-			return nil, err
+	if retries != nil && retries.Retried > 0 {
+		msg += fmt.Sprintf(", retried %d (%d extra attempt(s))", retries.Retried, retries.Attempts)
+	}
+	p := output.New(cmd.OutOrStdout())
+	p.Success("%s.", msg)
+
+	if stats == nil || stats.Files == 0 {
+		return
+	}
+	p.Println(output.Plain, "I/O: read %s, wrote %s in %s (%s/s, %.1f files/s)",
+		units.ByteSize(stats.BytesRead), units.ByteSize(stats.BytesWritten),
+		stats.Busy.Round(time.Millisecond), units.ByteSize(stats.BytesPerSecond()), stats.FilesPerSecond())
+	// --profile-ops may keep more; the summary lists the usual few.
+	for _, f := range stats.Slowest[:min(len(stats.Slowest), files.SlowestKept)] {
+		p.Println(output.Plain, "  slow: %s (%s, %s)", f.Path, units.ByteSize(f.Bytes), f.Duration.Round(time.Millisecond))
+	}
+}
+
+// summaryObject is the JSON shape of a successful transfer when
+// --output=json.
+type summaryObject struct {
+	Summary struct {
+		Verb          string     `json:"verb"`
+		Files         int        `json:"files"`
+		Skipped       int        `json:"skipped"`
+		Retried       int        `json:"retried"`
+		RetryAttempts int        `json:"retry_attempts"`
+		IO            *ioSummary `json:"io,omitempty"`
+	} `json:"summary"`
+}
+
+type ioSummary struct {
+	Files          int        `json:"files"`
+	BytesRead      int64      `json:"bytes_read"`
+	BytesWritten   int64      `json:"bytes_written"`
+	Seconds        float64    `json:"seconds"`
+	BytesPerSecond float64    `json:"bytes_per_second"`
+	FilesPerSecond float64    `json:"files_per_second"`
+	Slowest        []slowFile `json:"slowest"`
+}
+
+type slowFile struct {
+	Path    string  `json:"path"`
+	Bytes   int64   `json:"bytes"`
+	Seconds float64 `json:"seconds"`
+}
+
+// writeSummaryJSON writes the summary as a single JSON object followed by a
+// newline.
+func writeSummaryJSON(w io.Writer, verb string, done, skipped int, retries *files.RetryStats, stats *files.IOStats) error {
+	var obj summaryObject
+	obj.Summary.Verb = verb
+	obj.Summary.Files = done
+	obj.Summary.Skipped = skipped
+	if retries != nil {
+		obj.Summary.Retried = retries.Retried
+		obj.Summary.RetryAttempts = retries.Attempts
+	}
+	if stats != nil {
+		obj.Summary.IO = &ioSummary{
+			Files:          stats.Files,
+			BytesRead:      stats.BytesRead,
+			BytesWritten:   stats.BytesWritten,
+			Seconds:        stats.Busy.Seconds(),
+			BytesPerSecond: stats.BytesPerSecond(),
+			FilesPerSecond: stats.FilesPerSecond(),
+			Slowest:        []slowFile{},
 		}
-		
-		reporter.SetMessage("Collecting files from directory")
-		reporter.SetTotal(len(entries))
-		
-		out := make([]string, len(entries))
-		for i, e := range entries {
-			reporter.SetMessage(fmt.Sprintf("Collecting %s", e))
-			out[i] = filepath.Join(abs, e)
-			reporter.SetCurrent(i + 1)
+		for _, f := range stats.Slowest[:min(len(stats.Slowest), files.SlowestKept)] {
+			obj.Summary.IO.Slowest = append(obj.Summary.IO.Slowest, slowFile{Path: f.Path, Bytes: f.Bytes, Seconds: f.Duration.Seconds()})
 		}
-		reporter.Finish()
-		return out, nil
 	}
-	return nil, fmt.Errorf("unknown src argument")
+	return json.NewEncoder(w).Encode(obj)
 }
 
-// destFromMetadata returns the destination path for a single source file.
-func destFromMetadata(fs files.FilesService, src, dstRoot string) (string, error) {
-	tags := fs.GetFileTags([]string{src})
-	if len(tags) == 0 {
-		return "", fmt.Errorf("no metadata for %s", src)
+// plannedMappings converts transaction operations into output mappings,
+// flagging destinations that already exist.
+func plannedMappings(fs files.FilesService, ops []files.Operation) []output.Mapping {
+	ms := make([]output.Mapping, len(ops))
+	for i, op := range ops {
+		ms[i] = output.Mapping{
+			Source:      op.Source(),
+			Destination: op.Destination(),
+			Conflict:    fs.IsFile(op.Destination()),
+		}
+	}
+	return ms
+}
+
+// printDryRun reports a plan either as aligned src → dst lines or, with
+// tree set, as the resulting directory hierarchy.
+func printDryRun(cmd *cobra.Command, verb, dstRoot string, planned []output.Mapping, tree bool) {
+	if tree {
+		dsts := make([]string, len(planned))
+		for i, m := range planned {
+			dsts[i] = m.Destination
+		}
+		renderTree(cmd.OutOrStdout(), dstRoot, dsts)
+		return
 	}
-	return fs.DestinationFromMetadata(tags[0], dstRoot)
+	output.New(cmd.OutOrStdout()).Mappings(verb, planned)
 }
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// treeNode is a directory in a planned destination hierarchy.
+type treeNode struct {
+	name     string
+	files    int // files anywhere below this directory
+	children map[string]*treeNode
+}
+
+func newTreeNode(name string) *treeNode {
+	return &treeNode{name: name, children: make(map[string]*treeNode)}
+}
+
+// buildTree groups destination paths by directory relative to root.
+func buildTree(root string, paths []string) *treeNode {
+	top := newTreeNode(root)
+	for _, p := range paths {
+		rel, err := filepath.Rel(root, filepath.Dir(p))
+		if err != nil || strings.HasPrefix(rel, "..") {
+			rel = filepath.Dir(p)
+		}
+
+		node := top
+		node.files++
+		if rel == "." {
+			continue
+		}
+		for _, part := range strings.Split(filepath.ToSlash(rel), "/") {
+			if part == "" {
+				continue
+			}
+			child, ok := node.children[part]
+			if !ok {
+				child = newTreeNode(part)
+				node.children[part] = child
+			}
+			child.files++
+			node = child
+		}
+	}
+	return top
+}
+
+// renderTree writes the directory hierarchy implied by paths as an indented
+// tree with per-folder file counts, e.g.
+//
+//	/archive (3 files)
+//	└── 2025 (3)
+//	    ├── 01 (2)
+//	    └── 02 (1)
+func renderTree(w io.Writer, root string, paths []string) {
+	top := buildTree(root, paths)
+	fmt.Fprintf(w, "%s (%d %s)\n", top.name, top.files, plural(top.files, "file", "files"))
+	renderChildren(w, top, "")
+}
+
+func renderChildren(w io.Writer, n *treeNode, prefix string) {
+	names := make([]string, 0, len(n.children))
+	for name := range n.children {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for i, name := range names {
+		child := n.children[name]
+		branch, indent := "├── ", "│   "
+		if i == len(names)-1 {
+			branch, indent = "└── ", "    "
+		}
+		fmt.Fprintf(w, "%s%s%s (%d)\n", prefix, branch, child.name, child.files)
+		renderChildren(w, child, prefix+indent)
+	}
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
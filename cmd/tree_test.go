@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+func TestRenderTree(t *testing.T) {
+	root := "/archive"
+	paths := []string{
+		filepath.Join(root, "2025", "01", "27", "10_00.jpg"),
+		filepath.Join(root, "2025", "01", "27", "11_00.jpg"),
+		filepath.Join(root, "2025", "02", "01", "09_00.jpg"),
+		filepath.Join(root, "2024", "12", "31", "23_59.mov"),
+	}
+
+	var buf bytes.Buffer
+	renderTree(&buf, root, paths)
+
+	want := `/archive (4 files)
+├── 2024 (1)
+│   └── 12 (1)
+│       └── 31 (1)
+└── 2025 (3)
+    ├── 01 (2)
+    │   └── 27 (2)
+    └── 02 (1)
+        └── 01 (1)
+`
+	if buf.String() != want {
+		t.Errorf("unexpected tree:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestRenderTree_FilesAtRoot(t *testing.T) {
+	var buf bytes.Buffer
+	renderTree(&buf, "/flat", []string{"/flat/a.jpg"})
+
+	if want := "/flat (1 file)\n"; buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestCopyCmd_DryRunTree(t *testing.T) {
+	for _, atomic := range []bool{false, true} {
+		tempDir := testutil.TempDir(t)
+		srcDir := filepath.Join(tempDir, fmt.Sprintf("src-%v", atomic))
+		dstDir := filepath.Join(tempDir, "dst")
+		if err := os.MkdirAll(srcDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		metadata := map[string]files.FileMetadata{}
+		for name, date := range map[string]string{
+			"a.jpg": "2025:01:27 10:00:00-06:00",
+			"b.jpg": "2025:01:27 11:00:00-06:00",
+			"c.jpg": "2025:03:02 09:00:00-06:00",
+		} {
+			p := filepath.Join(srcDir, name)
+			if err := os.WriteFile(p, []byte(name), 0644); err != nil {
+				t.Fatal(err)
+			}
+			metadata[p] = files.FileMetadata{Filepath: p, Tags: map[string]string{"CreationDate": date}}
+		}
+
+		cmd := createCopyCmd(&deps.AppDeps{Files: createTestFilesService(metadata)})
+		args := []string{"--dry-run", "--tree", srcDir, dstDir}
+		if atomic {
+			args = append(args, "--atomic")
+		}
+		cmd.SetArgs(args)
+		var out bytes.Buffer
+		cmd.SetOut(&out)
+
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("atomic=%v: unexpected error: %v", atomic, err)
+		}
+
+		output := out.String()
+		if strings.Contains(output, "Would copy") {
+			t.Errorf("atomic=%v: tree mode should replace per-file lines, got:\n%s", atomic, output)
+		}
+		for _, want := range []string{dstDir + " (3 files)", "2025 (3)", "01 (2)", "03 (1)", "27 (2)"} {
+			if !strings.Contains(output, want) {
+				t.Errorf("atomic=%v: expected %q in output:\n%s", atomic, want, output)
+			}
+		}
+		if _, err := os.Stat(dstDir); !os.IsNotExist(err) {
+			t.Errorf("atomic=%v: dry-run must not create the destination", atomic)
+		}
+	}
+}
+
+func TestMoveCmd_TreeRequiresDryRun(t *testing.T) {
+	tempDir := testutil.TempDir(t)
+	src := filepath.Join(tempDir, "a.jpg")
+	if err := os.WriteFile(src, []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := createMoveCmd(&deps.AppDeps{Files: createTestFilesService(nil)})
+	cmd.SetArgs([]string{"--tree", src, filepath.Join(tempDir, "dst")})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "--tree requires --dry-run") {
+		t.Fatalf("expected --tree validation error, got %v", err)
+	}
+	if _, err := os.Stat(src); err != nil {
+		t.Errorf("source must not be moved: %v", err)
+	}
+}
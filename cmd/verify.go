@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"sync"
+
+	"github.com/Tmunayyer/gocamelpack/hashindex"
+	"github.com/Tmunayyer/gocamelpack/output"
+	"github.com/spf13/cobra"
+)
+
+// copyHashes keeps the hashes of sources taken while they were copied, so
+// --verify, the catalog and --report-csv need not read them again.
+type copyHashes struct {
+	algo hashindex.Algo
+	// verify reads every copy back and compares it with its source
+	// (--verify).
+	verify bool
+
+	mu       sync.Mutex
+	hashes   map[string]string
+	verified int
+}
+
+func newCopyHashes(algo hashindex.Algo, verify bool) *copyHashes {
+	return &copyHashes{algo: algo, verify: verify, hashes: map[string]string{}}
+}
+
+// record keeps the hash of src after it was copied and, with verify,
+// checked.
+func (c *copyHashes) record(src string, sum []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.hashes[src] = c.algo.Record(sum)
+	if c.verify {
+		c.verified++
+	}
+}
+
+// hashOf returns the hash src was copied with, if it was.
+func (c *copyHashes) hashOf(src string) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	hash, ok := c.hashes[src]
+	return hash, ok
+}
+
+// knownHash returns the hash of a source read by dedupe or taken while
+// copying it, either of which may be nil.
+func knownHash(dedupe *archiveDedupe, copied *copyHashes) func(src string) (string, bool) {
+	return func(src string) (string, bool) {
+		if dedupe != nil {
+			if hash, ok := dedupe.hashOf(src); ok {
+				return hash, true
+			}
+		}
+		return copied.hashOf(src)
+	}
+}
+
+// close reports how many copies --verify checked.
+func (c *copyHashes) close(cmd *cobra.Command) {
+	if c == nil || !c.verify || c.verified == 0 {
+		return
+	}
+	output.New(cmd.ErrOrStderr()).Println(output.Dim, "Verified %d copied file(s) against their source (%s)", c.verified, c.algo)
+}
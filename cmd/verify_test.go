@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/config"
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/hashindex"
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+func TestCopyCmd_Verify(t *testing.T) {
+	tempDir := testutil.TempDir(t)
+	srcDir := filepath.Join(tempDir, "src")
+	dstDir := filepath.Join(tempDir, "dst")
+	if err := os.MkdirAll(srcDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	src := filepath.Join(srcDir, "a.jpg")
+	if err := os.WriteFile(src, []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	md := map[string]files.FileMetadata{src: {Filepath: src, Tags: map[string]string{"CreationDate": "2025:01:27 15:30:45-06:00"}}}
+
+	run := func(args ...string) (string, error) {
+		cmd := createCopyCmd(&deps.AppDeps{Files: createTestFilesService(md), Config: &config.Config{}})
+		cmd.SetArgs(args)
+		cmd.SetOut(&bytes.Buffer{})
+		var stderr bytes.Buffer
+		cmd.SetErr(&stderr)
+		err := cmd.Execute()
+		return stderr.String(), err
+	}
+
+	got, err := run("--verify", "--hash-algo", "xxhash64", srcDir, dstDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(got, "Verified 1 copied file(s) against their source (xxh64)") {
+		t.Errorf("expected the verified copies to be reported, got %q", got)
+	}
+	if data, err := os.ReadFile(filepath.Join(dstDir, "2025/01/27/15_30.jpg")); err != nil || string(data) != "a" {
+		t.Errorf("copy = %q, %v", data, err)
+	}
+
+	if _, err := run("--verify", "--link", "hard", srcDir, filepath.Join(tempDir, "links")); err == nil || !strings.Contains(err.Error(), "--link") {
+		t.Errorf("expected --verify with --link to be refused, got %v", err)
+	}
+}
+
+func TestCopyHashes(t *testing.T) {
+	c := newCopyHashes(hashindex.SHA256, false)
+	if _, ok := (*copyHashes)(nil).hashOf("a"); ok {
+		t.Error("a nil copyHashes should know no hashes")
+	}
+	h := hashindex.SHA256.New()
+	h.Write([]byte("a"))
+	c.record("a", h.Sum(nil))
+	want := hashindex.SHA256.Record(h.Sum(nil))
+	if got, ok := knownHash(nil, c)("a"); !ok || got != want {
+		t.Errorf("knownHash = %q, %v, want %q", got, ok, want)
+	}
+	if c.verified != 0 {
+		t.Errorf("copies are only counted as verified with verify, got %d", c.verified)
+	}
+}
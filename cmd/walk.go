@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/ignore"
+	"github.com/Tmunayyer/gocamelpack/progress"
+	"github.com/Tmunayyer/gocamelpack/vfs"
+	"github.com/spf13/cobra"
+)
+
+// sourceWalk descends into the subdirectories of a source directory for
+// --recursive. A nil *sourceWalk reads the source directory alone.
+type sourceWalk struct {
+	// maxDepth is how many levels of subdirectories are read; 0 is no
+	// limit.
+	maxDepth int
+	// excludeDirs are filepath.Match patterns for the names of
+	// directories to leave out, with everything below them.
+	excludeDirs []string
+}
+
+// sourceWalkFromFlags returns the walk --recursive, --max-depth and
+// --exclude-dir ask for, or nil without --recursive.
+func sourceWalkFromFlags(cmd *cobra.Command) (*sourceWalk, error) {
+	recursive, _ := cmd.Flags().GetBool("recursive")
+	if !recursive {
+		for _, name := range []string{"max-depth", "exclude-dir"} {
+			if cmd.Flags().Changed(name) {
+				return nil, fmt.Errorf("--%s requires --recursive", name)
+			}
+		}
+		return nil, nil
+	}
+	var w sourceWalk
+	w.maxDepth, _ = cmd.Flags().GetInt("max-depth")
+	if w.maxDepth < 0 {
+		return nil, fmt.Errorf("--max-depth must not be negative")
+	}
+	w.excludeDirs, _ = cmd.Flags().GetStringSlice("exclude-dir")
+	for _, pattern := range w.excludeDirs {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("--exclude-dir %q: %w", pattern, err)
+		}
+	}
+	return &w, nil
+}
+
+// excluded reports whether the directory called name is left out.
+func (w *sourceWalk) excluded(name string) bool {
+	for _, pattern := range w.excludeDirs {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// walk calls visit with root and then each directory below it that is
+// neither excluded, by pattern or by ign, nor deeper than maxDepth, parents
+// before their subdirectories and siblings in name order. Symbolic links to
+// directories are not followed.
+func (w *sourceWalk) walk(fsys vfs.FS, root string, ign *ignore.Tree, visit func(dir string) error) error {
+	var descend func(dir string, depth int) error
+	descend = func(dir string, depth int) error {
+		if err := visit(dir); err != nil {
+			return err
+		}
+		if w.maxDepth > 0 && depth == w.maxDepth {
+			return nil
+		}
+		entries, err := fsys.ReadDir(dir)
+		if err != nil {
+			return fmt.Errorf("failed to read directory: %w", err)
+		}
+		for _, e := range entries {
+			if !e.IsDir() || w.excluded(e.Name()) {
+				continue
+			}
+			sub := filepath.Join(dir, e.Name())
+			ignored, err := ign.Ignored(sub, true)
+			if err != nil {
+				return err
+			}
+			if ignored {
+				continue
+			}
+			if err := descend(sub, depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return descend(root, 0)
+}
+
+// collect expands userPath into absolute file paths: a file is itself, a
+// directory the files in it and in the subdirectories the walk reaches,
+// less those its .gocamelpackignore files leave out.
+func (w *sourceWalk) collect(fs files.FilesService, userPath string, reporter progress.ProgressReporter) ([]string, error) {
+	abs, err := filepath.Abs(userPath)
+	if err != nil {
+		return nil, fmt.Errorf("resolve %q: %w", userPath, err)
+	}
+	if fs.IsFile(abs) {
+		reporter.SetMessage("Collecting single file")
+		reporter.SetTotal(1)
+		reporter.SetCurrent(1)
+		reporter.Finish()
+		return []string{abs}, nil
+	}
+	if !fs.IsDirectory(abs) {
+		return nil, files.Errorf(files.ErrSourceMissing, "unknown src argument")
+	}
+
+	if w == nil {
+		reporter.SetMessage("Reading directory")
+	} else {
+		reporter.SetMessage("Reading directories")
+	}
+	var out []string
+	ign := ignore.NewTree(files.FSOf(fs), abs)
+	visit := func(dir string) error {
+		entries, err := files.ReadDirectoryEntries(fs, dir)
+		if err != nil {
+			return err
+		}
+		reporter.SetMessage(fmt.Sprintf("Collecting files from %s", dir))
+		for _, e := range entries {
+			path := filepath.Join(dir, e.Name)
+			ignored, err := ign.Ignored(path, false)
+			if err != nil {
+				return err
+			}
+			if !ignored {
+				out = append(out, path)
+			}
+		}
+		reporter.SetTotal(len(out))
+		reporter.SetCurrent(len(out))
+		return nil
+	}
+	if w == nil {
+		err = visit(abs)
+	} else {
+		err = w.walk(files.FSOf(fs), abs, ign, visit)
+	}
+	if err != nil {
+		reporter.SetError(err)
+		return nil, err
+	}
+	reporter.Finish()
+	return out, nil
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/config"
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+func TestCopyCmd_Recursive(t *testing.T) {
+	card := filepath.Join(testutil.TempDir(t), "recursive", "card")
+	for _, name := range []string{"a.jpg", "DCIM/b.jpg", "DCIM/100CANON/c.jpg", "@eaDir/d.jpg", "DCIM/.thumbnails/e.jpg"} {
+		path := filepath.Join(card, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	run := func(args ...string) (string, error) {
+		var out bytes.Buffer
+		root := newCLI(&deps.AppDeps{Files: createTestFilesService(nil), Config: &config.Config{}, Streams: deps.Streams{Out: &out, Err: &out}})
+		root.SetArgs(append(append([]string{"copy", "--dry-run", "--keep-name"}, args...), card, filepath.Join(card, "..", "archive")))
+		err := root.Execute()
+		return out.String(), err
+	}
+
+	tests := []struct {
+		args []string
+		want string // the files found, of abcde
+	}{
+		{nil, "a"},
+		{[]string{"-r"}, "abcde"},
+		{[]string{"-r", "--exclude-dir", "@eaDir", "--exclude-dir", ".*"}, "abc"},
+		{[]string{"-r", "--max-depth", "1"}, "abd"},
+		{[]string{"-r", "--stream", "--exclude-dir", "@eaDir"}, "abce"},
+	}
+	for _, tc := range tests {
+		out, err := run(tc.args...)
+		if err != nil {
+			t.Fatalf("%v: %v\n%s", tc.args, err, out)
+		}
+		got := ""
+		for _, f := range "abcde" {
+			if contains(out, "/"+string(f)+".jpg") {
+				got += string(f)
+			}
+		}
+		if got != tc.want {
+			t.Errorf("%v: found %q, want %q\n%s", tc.args, got, tc.want, out)
+		}
+	}
+
+	// A .gocamelpackignore file leaves files and folders out.
+	if err := os.WriteFile(filepath.Join(card, ".gocamelpackignore"), []byte("# NAS folders\n@eaDir/\n.thumbnails/\nDCIM/b.jpg\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{{"-r"}, {"-r", "--stream"}} {
+		out, err := run(args...)
+		if err != nil {
+			t.Fatalf("%v: %v\n%s", args, err, out)
+		}
+		for f, want := range map[string]bool{"a": true, "b": false, "c": true, "d": false, "e": false} {
+			if got := contains(out, "/"+f+".jpg"); got != want {
+				t.Errorf("%v: %s.jpg found %v, want %v\n%s", args, f, got, want, out)
+			}
+		}
+		if contains(out, ".gocamelpackignore") {
+			t.Errorf("%v: the ignore file itself was collected\n%s", args, out)
+		}
+	}
+
+	if _, err := run("--max-depth", "2"); err == nil || !contains(err.Error(), "requires --recursive") {
+		t.Errorf("--max-depth without --recursive: got %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/spf13/cobra"
+)
+
+// whereResult is where a file would be placed, as printed by where.
+type whereResult struct {
+	Source      string `json:"source"`
+	Destination string `json:"destination,omitempty"`
+	Skipped     bool   `json:"skipped"`
+	// Exists is set when the destination is already taken, so copy and
+	// move would report a conflict without --overwrite.
+	Exists bool `json:"exists"`
+}
+
+func createWhereCmd(d *deps.AppDeps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "where [source-file] [destination]",
+		Short: "Print the destination copy and move would compute for one file",
+		Long: `Plans a single file exactly as copy and move would, with the template and
+rules of the config file, --template and --normalize, and prints where it would
+go. Nothing is read but the file's metadata and nothing is written, which makes
+it a quick way to debug a template without a dry run over a whole directory.
+Use "rules test" to see which rule matched.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			src, err := filepath.Abs(args[0])
+			if err != nil {
+				return fmt.Errorf("resolving %q: %w", args[0], err)
+			}
+			if !d.Files.IsFile(src) {
+				return files.Errorf(files.ErrSourceMissing, "src is not a file")
+			}
+			cfg, err := loadConfig(cmd, d)
+			if err != nil {
+				return err
+			}
+			var opts transferOptions
+			if opts.layoutOptions, err = layoutFromFlags(cmd, cfg); err != nil {
+				return err
+			}
+
+			dst, skip, err := opts.destination(d.Files, src, args[1])
+			if err != nil {
+				return err
+			}
+			res := whereResult{Source: src, Destination: dst, Skipped: skip, Exists: !skip && d.Files.IsFile(dst)}
+
+			out := cmd.OutOrStdout()
+			if outputFormat(cmd) == "json" {
+				return json.NewEncoder(out).Encode(res)
+			}
+			switch {
+			case res.Skipped:
+				fmt.Fprintln(out, "skipped: a routing rule excludes this file")
+			case res.Exists:
+				fmt.Fprintf(out, "%s (already exists)\n", res.Destination)
+			default:
+				fmt.Fprintln(out, res.Destination)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().String("template", "", "Destination layout to use instead of the configured one")
+	cmd.Flags().String("locale", "", "Language of {month_name} and {weekday} in the template, e.g. de or fr (default from config, else English)")
+	cmd.Flags().Bool("keep-name", false, "Keep the file's original name in the folder the layout picks (default from config)")
+	cmd.Flags().Bool("fix-ext", false, "Give the destination the extension the file's content calls for")
+	cmd.Flags().String("normalize", "", "Unicode normalization of created names: nfc, nfd or none (default from config, else nfc)")
+	return cmd
+}
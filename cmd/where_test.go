@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/config"
+	"github.com/Tmunayyer/gocamelpack/deps"
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+func TestWhereCmd(t *testing.T) {
+	tmp := filepath.Join(testutil.TempDir(t), "where")
+	if err := os.MkdirAll(tmp, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	photo := filepath.Join(tmp, "photo.jpg")
+	shot := filepath.Join(tmp, "shot.png")
+	for _, p := range []string{photo, shot} {
+		if err := os.WriteFile(p, []byte(p), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fs := createTestFilesService(map[string]files.FileMetadata{
+		photo: {Filepath: photo, Tags: map[string]string{"CreationDate": "2025:01:27 15:30:45-06:00"}},
+		shot:  {Filepath: shot, Tags: map[string]string{"Software": "Screenshot", "CreationDate": "2025:01:27 15:30:45-06:00"}},
+	})
+	dst := filepath.Join(tmp, "archive")
+	run := func(cfg *config.Config, args ...string) string {
+		t.Helper()
+		var out bytes.Buffer
+		root := newCLI(&deps.AppDeps{Files: fs, Config: cfg, Streams: deps.Streams{Out: &out, Err: &out}})
+		root.SetArgs(args)
+		if err := root.Execute(); err != nil {
+			t.Fatalf("%v: %v\n%s", args, err, out.String())
+		}
+		return strings.TrimSpace(out.String())
+	}
+
+	if got, want := run(&config.Config{}, "where", photo, dst), filepath.Join(dst, "2025", "01", "27", "15_30.jpg"); got != want {
+		t.Errorf("default layout: got %q, want %q", got, want)
+	}
+	if got, want := run(&config.Config{}, "where", "--template", "{year}/{month}", photo, dst), filepath.Join(dst, "2025", "01.jpg"); got != want {
+		t.Errorf("--template: got %q, want %q", got, want)
+	}
+	if got, want := run(&config.Config{}, "where", "--template", "{month}-{month_name}/{weekday}", "--locale", "de", photo, dst), filepath.Join(dst, "01-Januar", "Montag.jpg"); got != want {
+		t.Errorf("--locale: got %q, want %q", got, want)
+	}
+	if got, want := run(&config.Config{}, "where", "--keep-name", photo, dst), filepath.Join(dst, "2025", "01", "27", "photo.jpg"); got != want {
+		t.Errorf("--keep-name: got %q, want %q", got, want)
+	}
+	if got, want := run(&config.Config{KeepName: true}, "where", "--keep-name=false", photo, dst), filepath.Join(dst, "2025", "01", "27", "15_30.jpg"); got != want {
+		t.Errorf("--keep-name=false over the config: got %q, want %q", got, want)
+	}
+	if got, want := run(&config.Config{}, "where", "--fix-ext", shot, dst), filepath.Join(dst, "2025", "01", "27", "15_30.png"); got != want {
+		t.Errorf("--fix-ext on unknown content: got %q, want %q", got, want)
+	}
+	if err := os.WriteFile(shot, []byte("\xff\xd8\xff\xe0"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := run(&config.Config{}, "where", "--fix-ext", shot, dst), filepath.Join(dst, "2025", "01", "27", "15_30.jpg"); got != want {
+		t.Errorf("--fix-ext on a JPEG named .png: got %q, want %q", got, want)
+	}
+	withRules := &config.Config{Rules: testRules}
+	if got := run(withRules, "where", shot, dst); !strings.HasPrefix(got, "skipped") {
+		t.Errorf("rule skip: got %q", got)
+	}
+
+	taken := filepath.Join(dst, "2025", "01", "27", "15_30.jpg")
+	if err := os.MkdirAll(filepath.Dir(taken), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(taken, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var res whereResult
+	if err := json.Unmarshal([]byte(run(&config.Config{}, "where", "--output", "json", photo, dst)), &res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Destination != taken || !res.Exists || res.Skipped {
+		t.Errorf("json: %+v", res)
+	}
+}
@@ -0,0 +1,195 @@
+// Package config loads the optional gocamelpack configuration file.
+//
+// The file is JSON and lives at $XDG_CONFIG_HOME/gocamelpack/config.json
+// (or the platform equivalent) unless --config points elsewhere:
+//
+//	{
+//	  "template": "{year}/{month}/{day}/{hour}_{minute}",
+//	  "normalize": "nfc",
+//	  "locale": "de",
+//	  "keep_name": false,
+//	  "file_mode": "0644",
+//	  "dir_mode": "0755",
+//	  "jobs": 4,
+//	  "buffer_size": "1MiB",
+//	  "default_mode": "atomic",
+//	  "progress_style": "plain",
+//	  "rules": [
+//	    {"name": "screenshots", "match": {"tags": {"Software": "*screenshot*"}}, "action": "skip"},
+//	    {"name": "videos", "match": {"ext": ["mp4", "mov"]}, "template": "video/{year}/{month}"}
+//	  ]
+//	}
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/Tmunayyer/gocamelpack/pathtmpl"
+	"github.com/Tmunayyer/gocamelpack/plugins"
+	"github.com/Tmunayyer/gocamelpack/rules"
+	"github.com/Tmunayyer/gocamelpack/units"
+)
+
+// Config is the decoded configuration file. The zero value is a valid,
+// empty configuration.
+type Config struct {
+	// Template is the default destination layout; empty means the
+	// built-in YYYY/MM/DD/HH_mm layout.
+	Template string `json:"template,omitempty"`
+	// Rules route individual files, first match wins.
+	Rules []rules.Rule `json:"rules,omitempty"`
+	// Normalize is the Unicode form of created names: nfc (default), nfd
+	// or none.
+	Normalize string `json:"normalize,omitempty"`
+	// Locale is the language of {month_name} and {weekday}, e.g. "de";
+	// empty means English.
+	Locale string `json:"locale,omitempty"`
+	// KeepName is the default for --keep-name: files keep their original
+	// name in the folder the layout picks.
+	KeepName bool `json:"keep_name,omitempty"`
+	// FileMode and DirMode are octal modes for created files and
+	// directories, used when --chmod or --dirmode is not given. Empty keeps
+	// the source file's mode and creates directories per the umask.
+	FileMode string `json:"file_mode,omitempty"`
+	DirMode  string `json:"dir_mode,omitempty"`
+	// Owner is the default for --chown ("user:group").
+	Owner string `json:"owner,omitempty"`
+	// Jobs is the default for --jobs; BufferSize the default for
+	// --buffer-size. `gocamelpack bench` recommends values for both.
+	Jobs       int            `json:"jobs,omitempty"`
+	BufferSize units.ByteSize `json:"buffer_size,omitempty"`
+	// DefaultMode is how copy and move run without --atomic or
+	// --no-atomic: "atomic" or "direct" (the default).
+	DefaultMode string `json:"default_mode,omitempty"`
+	// ProgressStyle is the default for --progress-style: "bar" (the
+	// default) or "plain".
+	ProgressStyle string `json:"progress_style,omitempty"`
+	// MaxFiles and MaxBytes are the defaults for --max-files and
+	// --max-bytes, e.g. for scheduled runs.
+	MaxFiles int            `json:"max_files,omitempty"`
+	MaxBytes units.ByteSize `json:"max_bytes,omitempty"`
+	// Destinations limits where copy, move and sync may write.
+	Destinations Destinations `json:"destinations,omitzero"`
+	// Catalog makes copy and move record archived files in the
+	// destination's catalog, as --catalog does.
+	Catalog bool `json:"catalog,omitempty"`
+	// HashAlgo is the default --hash-algo: "sha256" or "xxhash64".
+	HashAlgo string `json:"hash_algo,omitempty"`
+	// HooksDir holds the pre-run, post-run and post-file scripts copy and
+	// move run; empty means DefaultHooksDir.
+	HooksDir string `json:"hooks_dir,omitempty"`
+	// Plugins are the external metadata providers and destination
+	// resolvers copy and move consult while planning.
+	Plugins []plugins.Spec `json:"plugins,omitempty"`
+	// SuspectDates are the defaults for --min-year, --max-year and
+	// --suspect-dates.
+	SuspectDates SuspectDates `json:"suspect_dates,omitzero"`
+	// ClockOffsets corrects cameras whose clock was off: the duration,
+	// e.g. "-1h2m5s", added to the capture dates of each camera, keyed by
+	// make and model as in "Canon EOS R5". sync-clock measures them.
+	ClockOffsets map[string]string `json:"clock_offsets,omitempty"`
+	// Others is the default --others: what copy and move do with files
+	// that are neither photos nor videos.
+	Others string `json:"others,omitempty"`
+}
+
+// SuspectDates is the range of plausible capture years and what copy and
+// move do with files dated outside it: "review" (the default), "ask" or
+// "keep". Zero years use the built-in range.
+type SuspectDates struct {
+	MinYear int    `json:"min_year,omitempty"`
+	MaxYear int    `json:"max_year,omitempty"`
+	Action  string `json:"action,omitempty"`
+}
+
+// DefaultPath returns the per-user config file location.
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gocamelpack", "config.json"), nil
+}
+
+// DefaultHooksDir returns the per-user hook scripts folder, next to the
+// config file.
+func DefaultHooksDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gocamelpack", "hooks"), nil
+}
+
+// Load reads the config file at path. A missing file yields an empty
+// config when optional is true and an error otherwise.
+func Load(path string, optional bool) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if optional && errors.Is(err, fs.ErrNotExist) {
+			return &Config{}, nil
+		}
+		return nil, fmt.Errorf("reading config: %w", err)
+	}
+
+	var c Config
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("parsing config %s: %w", path, err)
+	}
+	return &c, nil
+}
+
+// Set writes value under key in the config file at path, creating the
+// file when it is missing. The other settings are kept as they are.
+func Set(path, key string, value any) error {
+	settings := map[string]json.RawMessage{}
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &settings); err != nil {
+			return fmt.Errorf("parsing config %s: %w", path, err)
+		}
+	case !errors.Is(err, fs.ErrNotExist):
+		return fmt.Errorf("reading config: %w", err)
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	settings[key] = raw
+	if data, err = json.MarshalIndent(settings, "", "  "); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("writing config: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("writing config: %w", err)
+	}
+	return nil
+}
+
+// Engine builds the rules engine for c, with template overriding the
+// configured default when non-empty.
+func (c *Config) Engine(template string) (*rules.Engine, error) {
+	if template == "" {
+		template = c.Template
+	}
+	e, err := rules.New(c.Rules, template)
+	if err != nil {
+		return nil, err
+	}
+	if c.Locale != "" {
+		l, err := pathtmpl.ParseLocale(c.Locale)
+		if err != nil {
+			return nil, err
+		}
+		e.SetLocale(l)
+	}
+	return e, nil
+}
@@ -0,0 +1,165 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/rules"
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+func TestLoad(t *testing.T) {
+	dir := testutil.TempDir(t)
+	path := filepath.Join(dir, "config.json")
+	data := `{
+  "template": "{year}/{month}",
+  "rules": [
+    {"name": "big", "match": {"ext": ["mov"], "min_size": "1GiB"}, "action": "unsorted"}
+  ]
+}`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := Load(path, false)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if c.Template != "{year}/{month}" {
+		t.Errorf("template = %q", c.Template)
+	}
+	if len(c.Rules) != 1 || c.Rules[0].Action != rules.ActionUnsorted || c.Rules[0].Match.MinSize != 1<<30 {
+		t.Fatalf("unexpected rules: %+v", c.Rules)
+	}
+	if _, err := c.Engine(""); err != nil {
+		t.Fatalf("Engine: %v", err)
+	}
+}
+
+func TestConfig_EngineLocale(t *testing.T) {
+	c := &Config{Template: "{month_name}", Locale: "fr"}
+	e, err := c.Engine("")
+	if err != nil {
+		t.Fatalf("Engine: %v", err)
+	}
+	s := rules.Subject{Path: "/card/a.jpg", Metadata: files.FileMetadata{Filepath: "/card/a.jpg", Tags: map[string]string{"CreationDate": "2025:08:01 10:00:00"}}}
+	dst, _, err := e.Destination(s, "/archive", nil)
+	if err != nil || dst != filepath.Join("/archive", "août.jpg") {
+		t.Errorf("Destination = %q, %v", dst, err)
+	}
+
+	c.Locale = "xx-invalid-"
+	if _, err := c.Engine(""); err == nil {
+		t.Error("expected an invalid locale to be rejected")
+	}
+}
+
+func TestLoad_Missing(t *testing.T) {
+	path := filepath.Join(testutil.TempDir(t), "nope.json")
+
+	c, err := Load(path, true)
+	if err != nil || c == nil || len(c.Rules) != 0 {
+		t.Fatalf("optional missing config: %v, %+v", err, c)
+	}
+	if _, err := Load(path, false); err == nil {
+		t.Fatal("expected error for missing explicit config")
+	}
+}
+
+func TestSet(t *testing.T) {
+	path := filepath.Join(testutil.TempDir(t), "set", "config.json")
+	if err := Set(path, "clock_offsets", map[string]string{"Canon EOS R5": "-1h"}); err != nil {
+		t.Fatalf("Set on a missing file: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(`{"template": "{year}", "clock_offsets": {}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Set(path, "clock_offsets", map[string]string{"Sony A7": "30s"}); err != nil {
+		t.Fatal(err)
+	}
+	c, err := Load(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Template != "{year}" || len(c.ClockOffsets) != 1 || c.ClockOffsets["Sony A7"] != "30s" {
+		t.Errorf("config after Set = %+v", c)
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	path := filepath.Join(testutil.TempDir(t), "config.json")
+	if err := os.WriteFile(path, []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path, false); err == nil {
+		t.Fatal("expected parse error")
+	}
+}
+
+func TestDestinations(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip(err)
+	}
+	archive := testutil.TempDir(t)
+	d := Destinations{Deny: []string{"/", "~", "/etc/**"}}
+	for _, root := range []string{"/", home} {
+		if err := d.CheckRoot(root); err == nil {
+			t.Errorf("expected %s to be refused as a destination root", root)
+		}
+	}
+	if err := d.CheckRoot(filepath.Join(home, "Pictures")); err != nil {
+		t.Errorf("a folder below a plain deny entry should be allowed: %v", err)
+	}
+	if err := d.CheckFile("/etc/ssl/a.jpg"); err == nil {
+		t.Error("expected a write below /etc/** to be refused")
+	}
+	if err := d.CheckRemove("/etc/ssl/a.jpg"); err == nil {
+		t.Error("expected a move out of /etc/** to be refused")
+	}
+	if err := d.CheckRemove(filepath.Join(home, "a.jpg")); err != nil {
+		t.Errorf("a plain deny entry only protects the folder as a root: %v", err)
+	}
+
+	d.Allow = []string{archive}
+	if err := d.CheckFile(filepath.Join(archive, "2025", "a.jpg")); err != nil {
+		t.Errorf("CheckFile inside the allowlist: %v", err)
+	}
+	if err := d.CheckFile(archive + "-other/a.jpg"); err == nil {
+		t.Error("expected a sibling with a shared prefix to be outside the allowlist")
+	}
+}
+
+func TestDestinations_Symlinks(t *testing.T) {
+	dir := testutil.TempDir(t)
+	archive := filepath.Join(dir, "archive")
+	private := filepath.Join(dir, "private")
+	for _, p := range []string{archive, private} {
+		if err := os.MkdirAll(p, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	link := filepath.Join(archive, "link")
+	if err := os.Symlink(private, link); err != nil {
+		t.Skip(err)
+	}
+
+	d := Destinations{Allow: []string{archive}, Deny: []string{private + "/**"}}
+	// The file does not exist yet; its deepest existing folder is the link.
+	if err := d.CheckFile(filepath.Join(link, "2025", "a.jpg")); err == nil {
+		t.Error("expected a write through a link into a denied tree to be refused")
+	}
+	if err := d.CheckRoot(link); err == nil {
+		t.Error("expected a link into a denied tree to be refused as a root")
+	}
+
+	d = Destinations{Allow: []string{link}}
+	if err := d.CheckFile(filepath.Join(private, "a.jpg")); err != nil {
+		t.Errorf("the folder an allowed link points to should be allowed: %v", err)
+	}
+	if err := d.CheckFile(filepath.Join(archive, "a.jpg")); err == nil {
+		t.Error("expected the folder holding an allowed link to be outside the allowlist")
+	}
+}
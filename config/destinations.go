@@ -0,0 +1,147 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Destinations limits where copy, move and sync may write, so a typo'd
+// destination is refused instead of filled, and where move, clean and
+// rollback-status may remove files. Paths may start with ~ and
+// use $VARIABLES.
+type Destinations struct {
+	// Allow, when set, lists the only folders files may be written below.
+	Allow []string `json:"allow,omitempty"`
+	// Deny lists folders that may not be used as a destination root, e.g.
+	// "/" or "~"; with a trailing /**, e.g. "/etc/**", nothing may be
+	// written anywhere below them either.
+	Deny []string `json:"deny,omitempty"`
+}
+
+// CheckRoot reports whether root may be a destination root.
+func (d Destinations) CheckRoot(root string) error {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return err
+	}
+	for _, entry := range d.Deny {
+		base, tree, err := expandDeny(entry)
+		if err != nil {
+			return err
+		}
+		if resolve(root) == resolve(base) || (tree && within(root, base)) {
+			return fmt.Errorf("%s may not be used as a destination: the config's destinations.deny lists %q", root, entry)
+		}
+	}
+	return d.checkAllowed(root)
+}
+
+// CheckFile reports whether a file may be written at path.
+func (d Destinations) CheckFile(path string) error {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	for _, entry := range d.Deny {
+		base, tree, err := expandDeny(entry)
+		if err != nil {
+			return err
+		}
+		if tree && within(path, base) {
+			return fmt.Errorf("refusing to write %s: the config's destinations.deny protects %q", path, entry)
+		}
+	}
+	return d.checkAllowed(path)
+}
+
+// CheckRemove reports whether the file at path may be removed, e.g. as the
+// source of a move: nothing below a denied tree may be.
+func (d Destinations) CheckRemove(path string) error {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	for _, entry := range d.Deny {
+		base, tree, err := expandDeny(entry)
+		if err != nil {
+			return err
+		}
+		if tree && within(path, base) {
+			return fmt.Errorf("refusing to remove %s: the config's destinations.deny protects %q", path, entry)
+		}
+	}
+	return nil
+}
+
+func (d Destinations) checkAllowed(path string) error {
+	if len(d.Allow) == 0 {
+		return nil
+	}
+	for _, entry := range d.Allow {
+		base, err := expandPath(entry)
+		if err != nil {
+			return err
+		}
+		if within(path, base) {
+			return nil
+		}
+	}
+	return fmt.Errorf("refusing to write %s: it is outside the config's destinations.allow (%s)", path, strings.Join(d.Allow, ", "))
+}
+
+// expandDeny expands a deny entry; tree is set for entries ending in /**.
+func expandDeny(entry string) (base string, tree bool, err error) {
+	if trimmed, ok := strings.CutSuffix(entry, "/**"); ok {
+		entry, tree = trimmed, true
+		if entry == "" {
+			entry = "/"
+		}
+	}
+	base, err = expandPath(entry)
+	return base, tree, err
+}
+
+// expandPath resolves ~, $VARIABLES and relative parts of a configured
+// path.
+func expandPath(p string) (string, error) {
+	p = os.ExpandEnv(p)
+	if p == "~" || strings.HasPrefix(p, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("destinations: expanding %q: %w", p, err)
+		}
+		p = filepath.Join(home, p[1:])
+	}
+	return filepath.Abs(p)
+}
+
+// within reports whether path is base or below it once symlinks are
+// resolved, so a link inside an allowed folder pointing into a denied one
+// does not slip through.
+func within(path, base string) bool {
+	rel, err := filepath.Rel(resolve(base), resolve(path))
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// resolve evaluates the symlinks in the deepest part of the absolute path p
+// that exists; the rest, yet to be created, is kept as it is.
+func resolve(p string) string {
+	p = filepath.Clean(p)
+	var rest []string
+	for dir := p; ; {
+		if real, err := filepath.EvalSymlinks(dir); err == nil {
+			return filepath.Join(append([]string{real}, rest...)...)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return p
+		}
+		rest = append([]string{filepath.Base(dir)}, rest...)
+		dir = parent
+	}
+}
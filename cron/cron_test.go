@@ -0,0 +1,70 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func mustParse(t *testing.T, s string) Expr {
+	t.Helper()
+	e, err := Parse(s)
+	if err != nil {
+		t.Fatalf("Parse(%q): %v", s, err)
+	}
+	return e
+}
+
+func TestNext(t *testing.T) {
+	// Monday 27 January 2025, 15:30:45.
+	from := time.Date(2025, 1, 27, 15, 30, 45, 0, time.UTC)
+
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2025, 1, 27, 15, 31, 0, 0, time.UTC)},
+		{"0 2 * * *", time.Date(2025, 1, 28, 2, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2025, 1, 27, 15, 45, 0, 0, time.UTC)},
+		{"0 9-17/4 * * *", time.Date(2025, 1, 27, 17, 0, 0, 0, time.UTC)},
+		{"30 8 * * sat,sun", time.Date(2025, 2, 1, 8, 30, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 feb *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2025, 2, 2, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2025, 1, 27, 16, 0, 0, 0, time.UTC)},
+		// Both day fields restricted: either matches (the 1st, or a Friday).
+		{"0 12 1 * 5", time.Date(2025, 1, 31, 12, 0, 0, 0, time.UTC)},
+		// A stepped "*" day of month does not widen it: odd days that are
+		// Mondays, not odd days or Mondays.
+		{"0 0 */2 * 1", time.Date(2025, 2, 3, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tc := range tests {
+		if got := mustParse(t, tc.expr).Next(from); !got.Equal(tc.want) {
+			t.Errorf("%q.Next = %v, want %v", tc.expr, got, tc.want)
+		}
+	}
+}
+
+func TestNext_Impossible(t *testing.T) {
+	if got := mustParse(t, "0 0 31 2 *").Next(time.Now()); !got.IsZero() {
+		t.Errorf("expected no next time for Feb 31, got %v", got)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"* * * smarch *",
+	} {
+		if _, err := Parse(s); err == nil {
+			t.Errorf("Parse(%q): expected error", s)
+		}
+	}
+}
@@ -1,8 +1,53 @@
 package deps
 
-import "github.com/Tmunayyer/gocamelpack/files"
+import (
+	"io"
+	"time"
+
+	"github.com/Tmunayyer/gocamelpack/config"
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/vfs"
+)
+
+// Streams holds the writers commands print to. Nil writers fall back to the
+// process's stdout and stderr, so tests and embedding programs can capture
+// output without touching the real terminal.
+type Streams struct {
+	Out io.Writer
+	Err io.Writer
+}
+
+// Clock tells commands the time of day. Session IDs and the timestamps of
+// journals, catalogs, quarantine logs and reports are taken from it, so
+// tests and embedding programs can fix them.
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock is the real clock.
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time { return time.Now() }
 
 type AppDeps struct {
-	Files files.FilesService
-	// Logger, Config, DB, etc.
+	Files   files.FilesService
+	Streams Streams
+	// Config overrides the config file when set; nil means load it from
+	// --config or the default location on first use.
+	Config *config.Config
+	// Clock is the time commands use; nil means SystemClock.
+	Clock Clock
+	// FS replaces the disk for the file operations of Files, e.g. a
+	// vfs.Mem in tests; nil means the real disk. Locks, the catalog and
+	// hash index still live on disk.
+	FS vfs.FS
+	// Logger, DB, etc.
+}
+
+// Now returns the time from the Clock.
+func (d *AppDeps) Now() time.Time {
+	if d.Clock == nil {
+		return time.Now()
+	}
+	return d.Clock.Now()
 }
@@ -0,0 +1,168 @@
+// Package export streams files from an archive into a single zip or tar
+// file together with a checksum manifest.
+package export
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/Tmunayyer/gocamelpack/hashindex"
+)
+
+// Format is the container written by an Archive.
+type Format string
+
+const (
+	Zip   Format = "zip"
+	Tar   Format = "tar"
+	TarGz Format = "tar.gz"
+)
+
+// ManifestName is the entry, written last, that lists every exported file
+// in "sha256sum -c" format; XXH64ManifestName replaces it with
+// hashindex.XXH64, in "xxhsum -c" format.
+const (
+	ManifestName      = "SHA256SUMS"
+	XXH64ManifestName = "XXH64SUMS"
+)
+
+// ManifestFor returns the name of the manifest of hashes of algo.
+func ManifestFor(algo hashindex.Algo) string {
+	if algo == hashindex.XXH64 {
+		return XXH64ManifestName
+	}
+	return ManifestName
+}
+
+// ParseFormat accepts "zip", "tar", "tar.gz" and "tgz".
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(s) {
+	case "zip":
+		return Zip, nil
+	case "tar":
+		return Tar, nil
+	case "tar.gz", "tgz":
+		return TarGz, nil
+	}
+	return "", fmt.Errorf("unknown archive format %q (want zip, tar or tar.gz)", s)
+}
+
+// FormatFor picks the format from a file name's extension.
+func FormatFor(name string) (Format, error) {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		return Zip, nil
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return TarGz, nil
+	case strings.HasSuffix(lower, ".tar"):
+		return Tar, nil
+	}
+	return "", fmt.Errorf("cannot tell the archive format of %q; use a .zip, .tar or .tar.gz name or --format", name)
+}
+
+// Archive writes entries to an underlying writer in one of the supported
+// formats, hashing each file as it is streamed.
+type Archive struct {
+	zw       *zip.Writer
+	tw       *tar.Writer
+	gz       *gzip.Writer
+	algo     hashindex.Algo
+	manifest strings.Builder
+}
+
+// New starts an archive of format f on w whose manifest lists hashes of
+// algo. Close must be called to write the manifest and flush the
+// container; it does not close w.
+func New(w io.Writer, f Format, algo hashindex.Algo) (*Archive, error) {
+	a := &Archive{algo: algo}
+	switch f {
+	case Zip:
+		a.zw = zip.NewWriter(w)
+	case Tar:
+		a.tw = tar.NewWriter(w)
+	case TarGz:
+		a.gz = gzip.NewWriter(w)
+		a.tw = tar.NewWriter(a.gz)
+	default:
+		return nil, fmt.Errorf("unknown archive format %q", f)
+	}
+	return a, nil
+}
+
+// AddFile streams the file at src into the archive as name, a slash
+// separated path relative to the archive's root.
+func (a *Archive) AddFile(src, name string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	w, err := a.create(name, info.Size(), info.Mode().Perm(), info.ModTime())
+	if err != nil {
+		return fmt.Errorf("adding %s: %w", name, err)
+	}
+	h := a.algo.New()
+	if _, err := io.Copy(io.MultiWriter(w, h), f); err != nil {
+		return fmt.Errorf("adding %s: %w", name, err)
+	}
+	fmt.Fprintf(&a.manifest, "%s  %s\n", hex.EncodeToString(h.Sum(nil)), name)
+	return nil
+}
+
+func (a *Archive) create(name string, size int64, mode os.FileMode, mtime time.Time) (io.Writer, error) {
+	name = path.Clean(name)
+	if a.zw != nil {
+		hdr := &zip.FileHeader{Name: name, Method: zip.Deflate, Modified: mtime}
+		hdr.SetMode(mode)
+		return a.zw.CreateHeader(hdr)
+	}
+	hdr := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Size:     size,
+		Mode:     int64(mode),
+		ModTime:  mtime,
+		Format:   tar.FormatPAX,
+	}
+	if err := a.tw.WriteHeader(hdr); err != nil {
+		return nil, err
+	}
+	return a.tw, nil
+}
+
+// Close writes the checksum manifest and finishes the container.
+func (a *Archive) Close() error {
+	manifest := a.manifest.String()
+	w, err := a.create(ManifestFor(a.algo), int64(len(manifest)), 0o644, time.Now())
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, manifest); err != nil {
+		return err
+	}
+
+	if a.zw != nil {
+		return a.zw.Close()
+	}
+	if err := a.tw.Close(); err != nil {
+		return err
+	}
+	if a.gz != nil {
+		return a.gz.Close()
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+package export
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Tmunayyer/gocamelpack/hashindex"
+)
+
+func TestFormatFor(t *testing.T) {
+	tests := map[string]Format{
+		"june.zip":    Zip,
+		"june.TAR.GZ": TarGz,
+		"june.tgz":    TarGz,
+		"june.tar":    Tar,
+	}
+	for name, want := range tests {
+		if got, err := FormatFor(name); err != nil || got != want {
+			t.Errorf("FormatFor(%q) = %q, %v; want %q", name, got, err, want)
+		}
+	}
+	if _, err := FormatFor("june.rar"); err == nil {
+		t.Error("expected an error for an unknown extension")
+	}
+}
+
+// readEntries returns the entries of an archive written by Archive.
+func readEntries(t *testing.T, f Format, data []byte) map[string]string {
+	t.Helper()
+	entries := map[string]string{}
+	if f == Zip {
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, zf := range zr.File {
+			rc, err := zf.Open()
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, _ := io.ReadAll(rc)
+			rc.Close()
+			entries[zf.Name] = string(b)
+		}
+		return entries
+	}
+
+	var r io.Reader = bytes.NewReader(data)
+	if f == TarGz {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r = gz
+	}
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return entries
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := io.ReadAll(tr)
+		entries[hdr.Name] = string(b)
+	}
+}
+
+func TestArchive_RoundTrip(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "15_30.jpg")
+	if err := os.WriteFile(src, []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, f := range []Format{Zip, Tar, TarGz} {
+		t.Run(string(f), func(t *testing.T) {
+			var buf bytes.Buffer
+			a, err := New(&buf, f, hashindex.SHA256)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := a.AddFile(src, "2025/06/01/15_30.jpg"); err != nil {
+				t.Fatal(err)
+			}
+			if err := a.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			entries := readEntries(t, f, buf.Bytes())
+			if entries["2025/06/01/15_30.jpg"] != "hello" {
+				t.Errorf("entries = %v", entries)
+			}
+			// sha256("hello")
+			want := "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824  2025/06/01/15_30.jpg\n"
+			if entries[ManifestName] != want {
+				t.Errorf("manifest = %q, want %q", entries[ManifestName], want)
+			}
+		})
+	}
+}
+
+func TestArchive_XXH64Manifest(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "a.jpg")
+	if err := os.WriteFile(src, []byte("abc"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	a, err := New(&buf, Tar, hashindex.XXH64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.AddFile(src, "a.jpg"); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Close(); err != nil {
+		t.Fatal(err)
+	}
+	entries := readEntries(t, Tar, buf.Bytes())
+	// xxhsum prints bare hex digests.
+	if want := "44bc2cf5ad770999  a.jpg\n"; entries[XXH64ManifestName] != want {
+		t.Errorf("manifest = %q, want %q", entries[XXH64ManifestName], want)
+	}
+}
+
+func TestRange(t *testing.T) {
+	r, err := ParseRange("2025-06-01", "2025-06-30")
+	if err != nil {
+		t.Fatal(err)
+	}
+	day := func(s string) time.Time {
+		d, _ := time.Parse(time.RFC3339, s)
+		return d
+	}
+	if !r.Contains(day("2025-06-30T23:59:00-06:00")) {
+		t.Error("the last day should be included whatever the time and zone")
+	}
+	if r.Contains(day("2025-07-01T00:10:00+02:00")) {
+		t.Error("July should be excluded")
+	}
+	if _, err := ParseRange("2025-06-30", "2025-06-01"); err == nil {
+		t.Error("expected an error for an inverted range")
+	}
+	month, err := ParseRange("2025-06", "2025-06")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if month != r {
+		t.Errorf("a month should span its days, got %v", month)
+	}
+	if year, err := ParseRange("", "2024"); err != nil || !year.Contains(day("2024-12-31T12:00:00Z")) || year.Contains(day("2025-01-01T12:00:00Z")) {
+		t.Errorf("a year should end on December 31, got %v, %v", year, err)
+	}
+	if _, err := ParseRange("June", ""); err == nil {
+		t.Error("expected an error for a malformed date")
+	}
+}
+
+func TestFolderPeriod(t *testing.T) {
+	tests := []struct {
+		rel         string
+		first, last string
+		ok          bool
+	}{
+		{"2025/06/15/15_30.jpg", "2025-06-15", "2025-06-15", true},
+		{"2025/06/15_30.jpg", "2025-06-01", "2025-06-30", true},
+		{"video/2024/clip.mp4", "2024-01-01", "2024-12-31", true},
+		{"2025/02/31/x.jpg", "2025-02-01", "2025-02-28", true},
+		{"unsorted/scan.png", "", "", false},
+		{"2025.jpg", "", "", false},
+	}
+	for _, tt := range tests {
+		first, last, ok := FolderPeriod(tt.rel)
+		if ok != tt.ok {
+			t.Errorf("FolderPeriod(%q) ok = %v, want %v", tt.rel, ok, tt.ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		got := first.Format(DateLayout) + " " + last.Format(DateLayout)
+		if want := tt.first + " " + tt.last; got != want {
+			t.Errorf("FolderPeriod(%q) = %s, want %s", tt.rel, got, want)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package export
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DateLayout is the format of the dates bounding a Range.
+const DateLayout = "2006-01-02"
+
+// Range is an inclusive range of calendar days. A zero bound is open.
+type Range struct {
+	Since time.Time
+	Until time.Time
+}
+
+// ParseRange parses YYYY-MM-DD bounds; either may be empty. A bound may
+// also be a whole month (YYYY-MM) or year (YYYY): since then starts on its
+// first day and until ends on its last.
+func ParseRange(since, until string) (Range, error) {
+	var r Range
+	var err error
+	if since != "" {
+		if r.Since, err = parseBound(since, false); err != nil {
+			return r, fmt.Errorf("invalid --since %q: want YYYY-MM-DD, YYYY-MM or YYYY", since)
+		}
+	}
+	if until != "" {
+		if r.Until, err = parseBound(until, true); err != nil {
+			return r, fmt.Errorf("invalid --until %q: want YYYY-MM-DD, YYYY-MM or YYYY", until)
+		}
+	}
+	if !r.Since.IsZero() && !r.Until.IsZero() && r.Until.Before(r.Since) {
+		return r, fmt.Errorf("--until %s is before --since %s", until, since)
+	}
+	return r, nil
+}
+
+// parseBound parses a day, month or year; end selects its last day
+// instead of its first.
+func parseBound(s string, end bool) (time.Time, error) {
+	for _, p := range []struct {
+		layout     string
+		years, mos int
+	}{{DateLayout, 0, 0}, {"2006-01", 0, 1}, {"2006", 1, 0}} {
+		t, err := time.Parse(p.layout, s)
+		if err != nil {
+			continue
+		}
+		if end && p.layout != DateLayout {
+			t = t.AddDate(p.years, p.mos, -1)
+		}
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", s)
+}
+
+// day drops the time and zone of t, keeping its calendar date as written
+// in the metadata.
+func day(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// Contains reports whether t falls on a day within the range.
+func (r Range) Contains(t time.Time) bool {
+	return r.Overlaps(t, t)
+}
+
+// Overlaps reports whether any day from first to last is within the range.
+func (r Range) Overlaps(first, last time.Time) bool {
+	if !r.Since.IsZero() && day(last).Before(r.Since) {
+		return false
+	}
+	if !r.Until.IsZero() && day(first).After(r.Until) {
+		return false
+	}
+	return true
+}
+
+// FolderPeriod reads the days a file's folders stand for in a date-based
+// layout such as YYYY/MM/DD or YYYY/MM: the first four-digit year segment,
+// optionally followed by month and day segments. rel is slash separated and
+// relative to the archive root.
+func FolderPeriod(rel string) (first, last time.Time, ok bool) {
+	segs := strings.Split(rel, "/")
+	segs = segs[:len(segs)-1] // the file name itself
+	for i, s := range segs {
+		year, isYear := number(s, 4, 1, 9999)
+		if !isYear {
+			continue
+		}
+		first = time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+		last = first.AddDate(1, 0, -1)
+		if i+1 >= len(segs) {
+			return first, last, true
+		}
+		month, isMonth := number(segs[i+1], 2, 1, 12)
+		if !isMonth {
+			return first, last, true
+		}
+		first = time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+		last = first.AddDate(0, 1, -1)
+		if i+2 >= len(segs) {
+			return first, last, true
+		}
+		if d, isDay := number(segs[i+2], 2, 1, last.Day()); isDay {
+			first = time.Date(year, time.Month(month), d, 0, 0, 0, 0, time.UTC)
+			last = first
+		}
+		return first, last, true
+	}
+	return time.Time{}, time.Time{}, false
+}
+
+// number parses s when it is exactly digits long and within [lo, hi].
+func number(s string, digits, lo, hi int) (int, bool) {
+	if len(s) != digits {
+		return 0, false
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < lo || n > hi {
+		return 0, false
+	}
+	return n, true
+}
@@ -0,0 +1,41 @@
+package files
+
+import (
+	"encoding/binary"
+	"io/fs"
+)
+
+// The tags of POSIX ACL entries.
+const (
+	aclUserObj  = 0x01
+	aclUser     = 0x02
+	aclGroupObj = 0x04
+	aclGroup    = 0x08
+	aclMask     = 0x10
+	aclOther    = 0x20
+)
+
+// aclEntry is one entry of a POSIX ACL; id names the user or group of
+// aclUser and aclGroup entries.
+type aclEntry struct {
+	tag  int
+	perm fs.FileMode
+	id   int
+}
+
+// parseACL decodes the system.posix_acl_access attribute: a version 2
+// header and 8-byte little-endian entries of tag, permissions and ID.
+func parseACL(b []byte) ([]aclEntry, bool) {
+	if len(b) < 4 || binary.LittleEndian.Uint32(b) != 2 || (len(b)-4)%8 != 0 {
+		return nil, false
+	}
+	var acl []aclEntry
+	for b = b[4:]; len(b) > 0; b = b[8:] {
+		acl = append(acl, aclEntry{
+			tag:  int(binary.LittleEndian.Uint16(b)),
+			perm: fs.FileMode(binary.LittleEndian.Uint16(b[2:]) & 0o7),
+			id:   int(binary.LittleEndian.Uint32(b[4:])),
+		})
+	}
+	return acl, true
+}
@@ -0,0 +1,11 @@
+package files
+
+// accessACL returns the POSIX ACL of path, if it has one beyond its mode
+// bits.
+func accessACL(path string) ([]aclEntry, bool) {
+	b, err := getXattr(path, "system.posix_acl_access")
+	if err != nil {
+		return nil, false
+	}
+	return parseACL(b)
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package files
+
+// accessACL is only read on Linux; elsewhere the mode bits decide.
+func accessACL(string) ([]aclEntry, bool) {
+	return nil, false
+}
@@ -0,0 +1,45 @@
+package files
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func TestACL(t *testing.T) {
+	entry := func(tag, perm, id int) []byte {
+		b := make([]byte, 8)
+		binary.LittleEndian.PutUint16(b, uint16(tag))
+		binary.LittleEndian.PutUint16(b[2:], uint16(perm))
+		binary.LittleEndian.PutUint32(b[4:], uint32(id))
+		return b
+	}
+	// rwxrwxrwx by its mode bits, but user 1000 is named with read only.
+	b := []byte{2, 0, 0, 0}
+	for _, e := range [][]byte{
+		entry(aclUserObj, 7, 0), entry(aclUser, 4, 1000), entry(aclGroupObj, 7, 0),
+		entry(aclGroup, 7, 2000), entry(aclMask, 7, 0), entry(aclOther, 7, 0),
+	} {
+		b = append(b, e...)
+	}
+	acl, ok := parseACL(b)
+	if !ok || len(acl) != 6 {
+		t.Fatalf("parseACL = %v, %v", acl, ok)
+	}
+	if _, ok := parseACL([]byte{1, 0, 0, 0}); ok {
+		t.Error("expected an unknown version to be refused")
+	}
+
+	named := &RunAs{Name: "named", Owner: Owner{UID: 1000, GID: 100}, groups: map[int]bool{100: true}}
+	if named.aclAllows(acl, 0, 0, permWrite) {
+		t.Error("a named user entry should override the other bits")
+	}
+	other := &RunAs{Name: "other", Owner: Owner{UID: 1001, GID: 100}, groups: map[int]bool{100: true}}
+	if !other.aclAllows(acl, 0, 0, permWrite) {
+		t.Error("an unnamed user should get the other bits")
+	}
+	grouped := &RunAs{Name: "grouped", Owner: Owner{UID: 1002, GID: 100}, groups: map[int]bool{100: true, 2000: true}}
+	acl[4].perm = 5 // the mask now withholds write from the group entries
+	if grouped.aclAllows(acl, 0, 0, permWrite) {
+		t.Error("the mask should limit a named group entry")
+	}
+}
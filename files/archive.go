@@ -0,0 +1,49 @@
+package files
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/Tmunayyer/gocamelpack/vfs"
+)
+
+// ArchiveMarker is the file that marks a folder as the root of an archive,
+// the canonical library, which move refuses to take files out of.
+const ArchiveMarker = ".gocamelpack-archive"
+
+// archiveMarkerNote is written into the marker for whoever finds it.
+const archiveMarkerNote = "This folder is a gocamelpack archive. gocamelpack move refuses to move files out of it without --allow-archive-source.\n"
+
+// IsArchive reports whether dir is marked as an archive root.
+func IsArchive(fsys vfs.FS, dir string) bool {
+	info, err := fsys.Stat(filepath.Join(dir, ArchiveMarker))
+	return err == nil && !info.IsDir()
+}
+
+// MarkArchive marks dir as an archive root. Marking a marked folder again
+// is not an error.
+func MarkArchive(fsys vfs.FS, dir string) error {
+	if info, err := fsys.Stat(dir); err != nil || !info.IsDir() {
+		return Errorf(ErrSourceMissing, "%s is not a directory", dir)
+	}
+	f, err := fsys.OpenFile(filepath.Join(dir, ArchiveMarker), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return fmt.Errorf("marking %s: %w", dir, err)
+	}
+	_, err = f.Write([]byte(archiveMarkerNote))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// UnmarkArchive removes the archive marker from dir, if it has one.
+func UnmarkArchive(fsys vfs.FS, dir string) error {
+	if err := fsys.Remove(filepath.Join(dir, ArchiveMarker)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("unmarking %s: %w", dir, err)
+	}
+	return nil
+}
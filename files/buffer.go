@@ -0,0 +1,52 @@
+package files
+
+import "io"
+
+// BufferedCopier is implemented by services that can copy through a buffer
+// of a chosen size.
+type BufferedCopier interface {
+	CopyBuffer(src, dst string, size int) error
+}
+
+// CopyBuffered copies src to dst through a buffer of size bytes, or lets
+// io.Copy pick its fastest path, such as copy_file_range, when size <= 0.
+func CopyBuffered(dst io.Writer, src io.Reader, size int) (int64, error) {
+	if size <= 0 {
+		return io.Copy(dst, src)
+	}
+	// Hiding ReadFrom and WriteTo makes io.CopyBuffer really use the buffer.
+	return io.CopyBuffer(struct{ io.Writer }{dst}, struct{ io.Reader }{src}, make([]byte, size))
+}
+
+// WithBufferSize returns fs with Copy moving data through a size-byte
+// buffer when fs is a BufferedCopier. It must wrap the base service, before
+// any other decorator but WithFS. fs is returned unchanged for size <= 0.
+func WithBufferSize(fs FilesService, size int) FilesService {
+	if size <= 0 {
+		return fs
+	}
+	return &bufferedFiles{wrapped: wrapped{fs}, size: size}
+}
+
+// bufferedFiles decorates a FilesService with a copy buffer size.
+type bufferedFiles struct {
+	wrapped
+	size int
+}
+
+func (bf *bufferedFiles) Copy(src, dst string) error {
+	if bc, ok := bf.FilesService.(BufferedCopier); ok {
+		return bc.CopyBuffer(src, dst, bf.size)
+	}
+	return bf.FilesService.Copy(src, dst)
+}
+
+// CopyTee copies through the buffer whatever size is asked for, so
+// WithCopyHash can wrap it.
+func (bf *bufferedFiles) CopyTee(src, dst string, _ int, tee io.Writer) error {
+	return copyTee(bf.FilesService, src, dst, bf.size, tee)
+}
+
+func (bf *bufferedFiles) NewTransaction(overwrite bool) Transaction {
+	return NewTransaction(bf, overwrite)
+}
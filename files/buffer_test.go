@@ -0,0 +1,35 @@
+package files
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+func TestWithBufferSize(t *testing.T) {
+	tmp := testutil.TempDir(t)
+	src := filepath.Join(tmp, "src.bin")
+	data := bytes.Repeat([]byte("0123456789"), 10000)
+	if err := os.WriteFile(src, data, filePermRW); err != nil {
+		t.Fatal(err)
+	}
+
+	fs := WithBufferSize(newFiles(), 4096)
+	dst := filepath.Join(tmp, "out", "dst.bin")
+	if err := fs.Copy(src, dst); err != nil {
+		t.Fatalf("Copy: %v", err)
+	}
+	if got, _ := os.ReadFile(dst); !bytes.Equal(got, data) {
+		t.Error("buffered copy differs from the source")
+	}
+	if err := fs.Copy(src, dst); err == nil {
+		t.Error("buffered copy should still refuse to overwrite")
+	}
+
+	if base := newFiles(); WithBufferSize(base, 0) != FilesService(base) {
+		t.Error("a zero size should return the service unchanged")
+	}
+}
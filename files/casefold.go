@@ -0,0 +1,141 @@
+package files
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// CaseInsensitive probes whether names under dir are compared without
+// regard to case, as on default macOS and Windows volumes. dir need not
+// exist yet; its nearest existing ancestor is probed by creating and
+// removing a small temporary file.
+func CaseInsensitive(dir string) (bool, error) {
+	probeDir, err := existingDir(dir)
+	if err != nil {
+		return false, err
+	}
+
+	f, err := os.CreateTemp(probeDir, ".gocamelpack-case-probe-")
+	if err != nil {
+		return false, err
+	}
+	name := f.Name()
+	f.Close()
+	defer os.Remove(name)
+
+	upper := filepath.Join(probeDir, strings.ToUpper(filepath.Base(name)))
+	_, err = os.Lstat(upper)
+	return err == nil, nil
+}
+
+// existingDir returns dir or its nearest ancestor that exists.
+func existingDir(dir string) (string, error) {
+	probeDir := dir
+	for {
+		if info, err := os.Stat(probeDir); err == nil && info.IsDir() {
+			return probeDir, nil
+		}
+		parent := filepath.Dir(probeDir)
+		if parent == probeDir {
+			return "", fmt.Errorf("no existing directory above %q", dir)
+		}
+		probeDir = parent
+	}
+}
+
+// CaseInsensitiveByDefault guesses from the operating system, for when the
+// destination cannot be probed (e.g. during a dry run).
+func CaseInsensitiveByDefault() bool {
+	return runtime.GOOS == "darwin" || runtime.GOOS == "windows"
+}
+
+// CollisionTracker detects planned destinations that are different strings
+// but name the same file in practice: names that differ only in Unicode
+// normalization (always) or only in case (on case-insensitive volumes). It
+// also catches such variants already present on disk.
+type CollisionTracker struct {
+	foldCase bool
+	seen     map[string]string            // key -> first path planned
+	dirs     map[string]map[string]string // dir -> key of entry -> entry name
+}
+
+// NewCollisionTracker returns an empty tracker; foldCase also treats names
+// that differ only in case as the same.
+func NewCollisionTracker(foldCase bool) *CollisionTracker {
+	return &CollisionTracker{
+		foldCase: foldCase,
+		seen:     map[string]string{},
+		dirs:     map[string]map[string]string{},
+	}
+}
+
+// Add records dst and returns a conflict error if an earlier destination,
+// or an existing file next to it, is a variant of the same name. Identical
+// paths are not reported here; they are ordinary conflicts handled by
+// validation.
+func (c *CollisionTracker) Add(dst string) error {
+	key := c.key(dst)
+	if prev, ok := c.seen[key]; ok {
+		if prev != dst {
+			return Errorf(ErrConflict, "destinations %q and %q %s and would collide", prev, dst, c.why(prev, dst))
+		}
+		return nil
+	}
+
+	dir, name := filepath.Split(dst)
+	if existing, ok := c.entries(dir)[c.key(name)]; ok && existing != name {
+		return Errorf(ErrConflict, "destination %q would collide with existing %q (%s)", dst, filepath.Join(dir, existing), c.why(existing, name))
+	}
+	c.seen[key] = dst
+	return nil
+}
+
+// entries lists dir once and indexes its names by key.
+func (c *CollisionTracker) entries(dir string) map[string]string {
+	if m, ok := c.dirs[dir]; ok {
+		return m
+	}
+	m := map[string]string{}
+	if list, err := os.ReadDir(dir); err == nil {
+		for _, e := range list {
+			m[c.key(e.Name())] = e.Name()
+		}
+	}
+	c.dirs[dir] = m
+	return m
+}
+
+func (c *CollisionTracker) key(p string) string {
+	p = norm.NFC.String(p)
+	if c.foldCase {
+		p = foldPath(p)
+	}
+	return p
+}
+
+func (c *CollisionTracker) why(a, b string) string {
+	if norm.NFC.String(a) == norm.NFC.String(b) {
+		return "differ only in Unicode normalization"
+	}
+	return "differ only in case on a case-insensitive volume"
+}
+
+// foldPath maps every rune to a canonical member of its case-folding orbit,
+// so two paths fold equal exactly when strings.EqualFold reports them equal.
+func foldPath(p string) string {
+	return strings.Map(func(r rune) rune {
+		min := r
+		for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+			if f < min {
+				min = f
+			}
+		}
+		return min
+	}, p)
+}
@@ -0,0 +1,51 @@
+package files
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+func TestCollisionTracker(t *testing.T) {
+	c := NewCollisionTracker(true)
+	for _, p := range []string{"/a/2025/IMG_0001.JPG", "/a/2025/IMG_0002.jpg", "/a/2025/IMG_0001.JPG"} {
+		if err := c.Add(p); err != nil {
+			t.Fatalf("Add(%q): unexpected error %v", p, err)
+		}
+	}
+
+	err := c.Add("/a/2025/img_0001.jpg")
+	if ErrorCode(err) != CodeConflict {
+		t.Fatalf("expected conflict, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "IMG_0001.JPG") {
+		t.Errorf("error should name the earlier destination: %v", err)
+	}
+
+	// Folding is Unicode-aware, not just ASCII.
+	if err := c.Add("/a/Straße/ÄRGER.jpg"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Add("/a/straße/ärger.JPG"); err == nil {
+		t.Error("expected non-ASCII case collision to be reported")
+	}
+}
+
+func TestCaseInsensitive(t *testing.T) {
+	dir := testutil.TempDir(t)
+	if _, err := CaseInsensitive(filepath.Join(dir, "not", "yet", "created")); err != nil {
+		t.Fatalf("CaseInsensitive: %v", err)
+	}
+	// The answer depends on the machine's filesystem, but the probe must
+	// clean up after itself.
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("probe left files behind: %v", entries)
+	}
+}
@@ -0,0 +1,40 @@
+package files
+
+import (
+	"strings"
+	"time"
+)
+
+// ParseExifDate parses an exiftool date such as "2025:01:27 07:31:15-06:00".
+// Values without a zone offset (common for DateTimeOriginal) are returned
+// as wall-clock times in UTC so their date parts are preserved.
+func ParseExifDate(raw string) (time.Time, error) {
+	// Normalize to RFC3339-like format
+	// From: 2025:01:27 07:31:15-06:00
+	// To:   2025-01-27T07:31:15-06:00
+	rfcish := strings.Replace(raw, ":", "-", 2)
+	rfcish = strings.Replace(rfcish, " ", "T", 1)
+
+	t, err := time.Parse(time.RFC3339, rfcish)
+	if err == nil {
+		return t, nil
+	}
+	if t, err2 := time.Parse("2006-01-02T15:04:05", rfcish); err2 == nil {
+		return t, nil
+	}
+	return time.Time{}, err
+}
+
+// ShiftExifDate moves the exiftool date raw by d, keeping its form: with
+// or without a zone offset.
+func ShiftExifDate(raw string, d time.Duration) (string, error) {
+	t, err := ParseExifDate(raw)
+	if err != nil {
+		return "", err
+	}
+	layout := "2006:01:02 15:04:05"
+	if len(raw) > len(layout) {
+		layout += "Z07:00"
+	}
+	return t.Add(d).Format(layout), nil
+}
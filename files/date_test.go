@@ -0,0 +1,56 @@
+package files
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseExifDate(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    time.Time
+		wantErr bool
+	}{
+		{"2025:01:27 07:31:15-06:00", time.Date(2025, 1, 27, 7, 31, 15, 0, time.FixedZone("", -6*3600)), false},
+		{"2025:01:27 07:31:15Z", time.Date(2025, 1, 27, 7, 31, 15, 0, time.UTC), false},
+		{"2025:01:27 07:31:15", time.Date(2025, 1, 27, 7, 31, 15, 0, time.UTC), false},
+		{"not a date", time.Time{}, true},
+		{"", time.Time{}, true},
+	}
+	for _, tc := range tests {
+		got, err := ParseExifDate(tc.raw)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("ParseExifDate(%q) expected error", tc.raw)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseExifDate(%q) unexpected error: %v", tc.raw, err)
+			continue
+		}
+		if !got.Equal(tc.want) || got.Hour() != tc.want.Hour() {
+			t.Errorf("ParseExifDate(%q) = %v, want %v", tc.raw, got, tc.want)
+		}
+	}
+}
+
+func TestShiftExifDate(t *testing.T) {
+	tests := []struct {
+		raw  string
+		d    time.Duration
+		want string
+	}{
+		{"2025:01:27 23:31:15-06:00", time.Hour, "2025:01:28 00:31:15-06:00"},
+		{"2025:01:27 07:31:15Z", -90 * time.Second, "2025:01:27 07:29:45Z"},
+		{"2025:01:01 00:00:10", -time.Minute, "2024:12:31 23:59:10"},
+	}
+	for _, tc := range tests {
+		if got, err := ShiftExifDate(tc.raw, tc.d); err != nil || got != tc.want {
+			t.Errorf("ShiftExifDate(%q, %v) = %q, %v; want %q", tc.raw, tc.d, got, err, tc.want)
+		}
+	}
+	if _, err := ShiftExifDate("not a date", time.Hour); err == nil {
+		t.Error("expected an error for an unparseable date")
+	}
+}
@@ -0,0 +1,84 @@
+package files
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// DirEntry describes one entry of a directory as it was read, so callers
+// need no further Stat to filter or plan by size, date or type.
+type DirEntry struct {
+	Name    string
+	Size    int64
+	ModTime time.Time
+	// Mode holds the entry's type bits and permissions.
+	Mode fs.FileMode
+}
+
+// IsDir reports whether the entry is a directory.
+func (e DirEntry) IsDir() bool {
+	return e.Mode.IsDir()
+}
+
+// IsRegular reports whether the entry is a regular file.
+func (e DirEntry) IsRegular() bool {
+	return e.Mode.IsRegular()
+}
+
+// EntryReader is implemented by services that can list a directory with
+// each entry's metadata.
+type EntryReader interface {
+	ReadDirectoryEntries(dirPath string) ([]DirEntry, error)
+}
+
+// ReadDirectoryEntries returns the files in dirPath, sorted by name, with
+// their metadata. Services without an EntryReader are listed through
+// ReadDirectory and each file is stat'ed; files that cannot be stat'ed
+// keep only their name.
+func ReadDirectoryEntries(fsvc FilesService, dirPath string) ([]DirEntry, error) {
+	if er, ok := fsvc.(EntryReader); ok {
+		return er.ReadDirectoryEntries(dirPath)
+	}
+	names, err := fsvc.ReadDirectory(dirPath)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]DirEntry, len(names))
+	for i, name := range names {
+		entries[i] = DirEntry{Name: name}
+		if info, err := FSOf(fsvc).Lstat(filepath.Join(dirPath, name)); err == nil {
+			entries[i] = entryFromInfo(info)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries, nil
+}
+
+// ReadDirectoryEntries is ReadDirectory with each file's metadata.
+// Subdirectories are left out, as in ReadDirectory.
+func (f *Files) ReadDirectoryEntries(dirPath string) ([]DirEntry, error) {
+	dirents, err := f.FS().ReadDir(dirPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory: %w", err)
+	}
+	var entries []DirEntry
+	for _, d := range dirents {
+		if d.IsDir() {
+			continue
+		}
+		info, err := d.Info()
+		if err != nil {
+			// Removed since the directory was read.
+			continue
+		}
+		entries = append(entries, entryFromInfo(info))
+	}
+	return entries, nil
+}
+
+func entryFromInfo(info fs.FileInfo) DirEntry {
+	return DirEntry{Name: info.Name(), Size: info.Size(), ModTime: info.ModTime(), Mode: info.Mode()}
+}
@@ -0,0 +1,49 @@
+package files
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+// namesOnly hides the EntryReader of a Files so the fallback is used.
+type namesOnly struct{ FilesService }
+
+func TestReadDirectoryEntries(t *testing.T) {
+	dir := testutil.TempDir(t)
+	mtime := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for name, size := range map[string]int{"b.jpg": 3, "a.mov": 10} {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, make([]byte, size), filePermRW); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(p, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, fs := range map[string]FilesService{"entry reader": newFiles(), "fallback": namesOnly{newFiles()}} {
+		t.Run(name, func(t *testing.T) {
+			entries, err := ReadDirectoryEntries(fs, dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 2 || entries[0].Name != "a.mov" || entries[1].Name != "b.jpg" {
+				t.Fatalf("entries = %+v, want a.mov and b.jpg", entries)
+			}
+			if e := entries[0]; e.Size != 10 || !e.ModTime.Equal(mtime) || !e.IsRegular() || e.IsDir() {
+				t.Errorf("unexpected metadata %+v", e)
+			}
+		})
+	}
+
+	if _, err := ReadDirectoryEntries(newFiles(), filepath.Join(dir, "missing")); err == nil {
+		t.Error("expected an error for a missing directory")
+	}
+}
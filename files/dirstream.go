@@ -0,0 +1,79 @@
+package files
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/Tmunayyer/gocamelpack/vfs"
+)
+
+// dirBatch is how many entries StreamDirectory reads at a time.
+const dirBatch = 1024
+
+// DirectoryStreamer is implemented by services that can list a directory
+// without holding all of its entries in memory.
+type DirectoryStreamer interface {
+	StreamDirectory(dirPath string, fn func(name string) error) error
+}
+
+// StreamDirectory calls fn with the name of each file in dirPath, like
+// ReadDirectory but through fs's DirectoryStreamer when it has one. It stops
+// at fn's first error and returns it.
+func StreamDirectory(fs FilesService, dirPath string, fn func(name string) error) error {
+	if ds, ok := fs.(DirectoryStreamer); ok {
+		return ds.StreamDirectory(dirPath, fn)
+	}
+	names, err := fs.ReadDirectory(dirPath)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if err := fn(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StreamDirectory reads dirPath in batches, so even directories with
+// hundreds of thousands of files use little memory. Unlike ReadDirectory,
+// names come in directory order rather than sorted. Off the real disk the
+// directory is listed in one go, in name order.
+func (f *Files) StreamDirectory(dirPath string, fn func(name string) error) error {
+	if !vfs.OnDisk(f.FS()) {
+		names, err := f.ReadDirectory(dirPath)
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			if err := fn(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	d, err := os.Open(dirPath)
+	if err != nil {
+		return fmt.Errorf("failed to read directory: %w", err)
+	}
+	defer d.Close()
+	for {
+		entries, err := d.ReadDir(dirBatch)
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+			if err := fn(entry.Name()); err != nil {
+				return err
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read directory: %w", err)
+		}
+	}
+}
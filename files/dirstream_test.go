@@ -0,0 +1,53 @@
+package files
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+func TestStreamDirectory(t *testing.T) {
+	dir := testutil.TempDir(t)
+	var want []string
+	for i := range dirBatch + 5 {
+		name := fmt.Sprintf("f%05d", i)
+		if err := os.WriteFile(filepath.Join(dir, name), nil, filePermRW); err != nil {
+			t.Fatal(err)
+		}
+		want = append(want, name)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	f := newFiles()
+	var got []string
+	if err := StreamDirectory(f, dir, func(name string) error {
+		got = append(got, name)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(got)
+	if !slices.Equal(got, want) {
+		t.Errorf("streamed %d names, want %d files without the subdirectory", len(got), len(want))
+	}
+
+	stop := errors.New("stop")
+	calls := 0
+	err := f.StreamDirectory(dir, func(string) error {
+		calls++
+		return stop
+	})
+	if err != stop || calls != 1 {
+		t.Errorf("err = %v after %d call(s); want stop after 1", err, calls)
+	}
+	if err := f.StreamDirectory(filepath.Join(dir, "missing"), func(string) error { return nil }); err == nil {
+		t.Error("expected an error for a missing directory")
+	}
+}
@@ -0,0 +1,70 @@
+package files
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Sentinel errors classify failures so callers can react with errors.Is
+// instead of matching message text.
+var (
+	// ErrConflict means the destination already exists.
+	ErrConflict = errors.New("destination conflict")
+	// ErrNoDate means no usable capture date was found in the metadata.
+	ErrNoDate = errors.New("missing capture date")
+	// ErrSourceMissing means the source does not exist or is not a file.
+	ErrSourceMissing = errors.New("source missing")
+	// ErrLocked means another run holds the destination's lock.
+	ErrLocked = errors.New("destination locked")
+	// ErrReadOnly means the destination is on a read-only file system.
+	ErrReadOnly = errors.New("destination read-only")
+	// ErrCorrupt means a media file is damaged, e.g. truncated.
+	ErrCorrupt = errors.New("corrupt file")
+)
+
+// Code is a stable, machine-readable identifier for an error class.
+type Code string
+
+const (
+	CodeConflict      Code = "conflict"
+	CodeNoDate        Code = "no_date"
+	CodeSourceMissing Code = "source_missing"
+	CodeLocked        Code = "locked"
+	CodeReadOnly      Code = "read_only"
+	CodeUnknown       Code = "error"
+)
+
+// codedError carries a human-readable message while unwrapping to one of the
+// sentinel errors above.
+type codedError struct {
+	kind error
+	msg  string
+}
+
+func (e *codedError) Error() string { return e.msg }
+func (e *codedError) Unwrap() error { return e.kind }
+
+// Errorf formats a message like fmt.Errorf and classifies it as kind. The
+// resulting error matches kind with errors.Is; %w verbs in format are not
+// unwrapped.
+func Errorf(kind error, format string, args ...any) error {
+	return &codedError{kind: kind, msg: fmt.Sprintf(format, args...)}
+}
+
+// ErrorCode returns the code for err's class, or CodeUnknown.
+func ErrorCode(err error) Code {
+	switch {
+	case errors.Is(err, ErrConflict):
+		return CodeConflict
+	case errors.Is(err, ErrNoDate):
+		return CodeNoDate
+	case errors.Is(err, ErrSourceMissing):
+		return CodeSourceMissing
+	case errors.Is(err, ErrLocked):
+		return CodeLocked
+	case errors.Is(err, ErrReadOnly) || isReadOnlyErr(err):
+		return CodeReadOnly
+	default:
+		return CodeUnknown
+	}
+}
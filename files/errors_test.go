@@ -0,0 +1,71 @@
+package files
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+func TestErrorf_KeepsMessageAndKind(t *testing.T) {
+	err := Errorf(ErrConflict, "destination %q already exists", "/a")
+
+	if err.Error() != `destination "/a" already exists` {
+		t.Errorf("unexpected message %q", err.Error())
+	}
+	if !errors.Is(err, ErrConflict) {
+		t.Error("expected error to match ErrConflict")
+	}
+	if errors.Is(err, ErrNoDate) {
+		t.Error("error must not match other kinds")
+	}
+}
+
+func TestErrorCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want Code
+	}{
+		{Errorf(ErrConflict, "x"), CodeConflict},
+		{Errorf(ErrNoDate, "x"), CodeNoDate},
+		{Errorf(ErrSourceMissing, "x"), CodeSourceMissing},
+		{fmt.Errorf("wrapped: %w", Errorf(ErrNoDate, "x")), CodeNoDate},
+		{&TransactionError{Phase: "planning", Err: Errorf(ErrConflict, "x")}, CodeConflict},
+		{errors.New("plain"), CodeUnknown},
+	}
+	for _, tc := range tests {
+		if got := ErrorCode(tc.err); got != tc.want {
+			t.Errorf("ErrorCode(%v) = %q, want %q", tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestFilesErrorsAreClassified(t *testing.T) {
+	f := newFiles()
+	tmp := testutil.TempDir(t)
+
+	src := filepath.Join(tmp, "src.txt")
+	dst := filepath.Join(tmp, "dst.txt")
+	if err := os.WriteFile(src, []byte("x"), filePermRW); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dst, []byte("y"), filePermRW); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := f.ValidateCopyArgs(src, dst); !errors.Is(err, ErrConflict) {
+		t.Errorf("existing destination: expected ErrConflict, got %v", err)
+	}
+	if err := f.ValidateCopyArgs(filepath.Join(tmp, "nope"), dst); !errors.Is(err, ErrSourceMissing) {
+		t.Errorf("missing source: expected ErrSourceMissing, got %v", err)
+	}
+	if _, err := f.DestinationFromMetadata(FileMetadata{Tags: map[string]string{}}, "/x"); !errors.Is(err, ErrNoDate) {
+		t.Errorf("missing date: expected ErrNoDate, got %v", err)
+	}
+	if _, err := f.DestinationFromMetadata(FileMetadata{Tags: map[string]string{"CreationDate": "garbage"}}, "/x"); !errors.Is(err, ErrNoDate) {
+		t.Errorf("unparseable date: expected ErrNoDate, got %v", err)
+	}
+}
@@ -0,0 +1,130 @@
+package files
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// exiftoolBin is the exiftool executable tag sessions run.
+var exiftoolBin = "exiftool"
+
+// tagNamePattern accepts exiftool tag names, optionally group-qualified,
+// so a tag can never be taken for another option.
+var tagNamePattern = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_:-]*$`)
+
+// tagSession is an exiftool process in -stay_open mode that takes its
+// arguments per request. The go-exiftool process fixes them at start, so
+// it cannot extract a chosen set of tags.
+type tagSession struct {
+	mu     sync.Mutex
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Scanner
+}
+
+func startTagSession() (*tagSession, error) {
+	cmd := exec.Command(exiftoolBin, "-stay_open", "True", "-@", "-")
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("error intializing exiftool: %v", err)
+	}
+	sc := bufio.NewScanner(stdout)
+	sc.Buffer(make([]byte, 64<<10), 64<<20)
+	return &tagSession{cmd: cmd, stdin: stdin, stdout: sc}, nil
+}
+
+// tagArgs turns tag names into exiftool -TAG arguments.
+func tagArgs(tags []string) ([]string, error) {
+	args := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		if !tagNamePattern.MatchString(tag) {
+			return nil, fmt.Errorf("invalid tag name %q", tag)
+		}
+		args = append(args, "-"+tag)
+	}
+	return args, nil
+}
+
+// extract runs exiftool with args over paths and returns one FileMetadata
+// per path, in order. Files exiftool reports nothing for get empty tags.
+func (s *tagSession) extract(args, paths []string) ([]FileMetadata, error) {
+	var req bytes.Buffer
+	req.WriteString("-j\n")
+	for _, a := range append(args, paths...) {
+		if strings.ContainsAny(a, "\r\n") {
+			return nil, fmt.Errorf("exiftool argument %q contains a line break", a)
+		}
+		req.WriteString(a + "\n")
+	}
+	req.WriteString("-execute\n")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.stdin.Write(req.Bytes()); err != nil {
+		return nil, fmt.Errorf("exiftool: %w", err)
+	}
+	var out bytes.Buffer
+	for {
+		if !s.stdout.Scan() {
+			if err := s.stdout.Err(); err != nil {
+				return nil, fmt.Errorf("exiftool: %w", err)
+			}
+			return nil, fmt.Errorf("exiftool exited unexpectedly")
+		}
+		line := s.stdout.Bytes()
+		if string(bytes.TrimSpace(line)) == "{ready}" {
+			break
+		}
+		out.Write(line)
+		out.WriteByte('\n')
+	}
+
+	var raw []map[string]any
+	if len(bytes.TrimSpace(out.Bytes())) > 0 {
+		if err := json.Unmarshal(out.Bytes(), &raw); err != nil {
+			return nil, fmt.Errorf("exiftool: decoding output: %w", err)
+		}
+	}
+	byFile := make(map[string]map[string]string, len(raw))
+	for _, r := range raw {
+		tags := make(map[string]string)
+		for k, v := range r {
+			tags[k] = fmt.Sprintf("%v", v)
+		}
+		if file, ok := r["SourceFile"].(string); ok {
+			byFile[file] = tags
+		}
+	}
+	result := make([]FileMetadata, len(paths))
+	for i, p := range paths {
+		tags := byFile[p]
+		if tags == nil {
+			tags = map[string]string{}
+		}
+		result[i] = FileMetadata{Filepath: p, Tags: tags}
+	}
+	return result, nil
+}
+
+// close ends the exiftool process.
+func (s *tagSession) close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	io.WriteString(s.stdin, "-stay_open\nFalse\n")
+	s.stdin.Close()
+	return s.cmd.Wait()
+}
@@ -0,0 +1,55 @@
+package files
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrInjected marks a failure injected on purpose by a FaultInjector.
+var ErrInjected = errors.New("injected failure")
+
+// FaultInjector fails every operation after the first After ones, so the
+// handling of an error at a given point of a real plan can be rehearsed,
+// e.g. an atomic run's rollback. A nil FaultInjector never fails. It is
+// safe for concurrent use.
+type FaultInjector struct {
+	After int
+
+	mu sync.Mutex
+	n  int
+}
+
+// Next counts one more operation of kind on src and fails it once After
+// operations have gone through.
+func (fi *FaultInjector) Next(kind OperationType, src string) error {
+	if fi == nil {
+		return nil
+	}
+	fi.mu.Lock()
+	defer fi.mu.Unlock()
+	fi.n++
+	if fi.n <= fi.After {
+		return nil
+	}
+	return fmt.Errorf("%s %s: %w after %d operation(s)", kind, src, ErrInjected, fi.After)
+}
+
+// FaultyOperation decorates an operation so its Execute fails when a
+// FaultInjector says so, before anything is done.
+type FaultyOperation struct {
+	WrappedOperation
+	faults *FaultInjector
+}
+
+// NewFaultyOperation wraps op; faults may be nil.
+func NewFaultyOperation(op Operation, faults *FaultInjector) *FaultyOperation {
+	return &FaultyOperation{WrappedOperation: WrappedOperation{Operation: op}, faults: faults}
+}
+
+func (fo *FaultyOperation) Execute(fs FilesService) error {
+	if err := fo.faults.Next(fo.Type(), fo.Source()); err != nil {
+		return err
+	}
+	return fo.Operation.Execute(fs)
+}
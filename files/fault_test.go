@@ -0,0 +1,45 @@
+package files
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/testutil"
+	"github.com/Tmunayyer/gocamelpack/vfs"
+)
+
+func TestFaultyOperation_RollsBackTransaction(t *testing.T) {
+	dir := filepath.Join(testutil.TempDir(t), "faults")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	var srcs []string
+	for _, name := range []string{"a.jpg", "b.jpg", "c.jpg"} {
+		src := filepath.Join(dir, name)
+		if err := os.WriteFile(src, []byte(name), filePermRW); err != nil {
+			t.Fatal(err)
+		}
+		srcs = append(srcs, src)
+	}
+
+	fs := WithFS(newFiles(), vfs.NewMem(vfs.OS))
+	faults := &FaultInjector{After: 2}
+	tx := fs.NewTransaction(false)
+	for _, src := range srcs {
+		tx.Add(NewFaultyOperation(NewCopyOperation(src, filepath.Join(dir, "out", filepath.Base(src))), faults))
+	}
+	err := tx.Execute()
+	if !errors.Is(err, ErrInjected) {
+		t.Fatalf("Execute = %v, want the injected failure", err)
+	}
+	if fs.IsDirectory(filepath.Join(dir, "out")) {
+		t.Error("rollback left the destination behind")
+	}
+
+	var nilFaults *FaultInjector
+	if err := nilFaults.Next(OperationCopy, "x"); err != nil {
+		t.Errorf("nil injector failed: %v", err)
+	}
+}
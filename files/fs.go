@@ -0,0 +1,76 @@
+package files
+
+import (
+	"io"
+	"os"
+
+	"github.com/Tmunayyer/gocamelpack/vfs"
+)
+
+// FSOf returns the file system fs reads and writes through, or the real
+// disk when fs does not say.
+func FSOf(fs FilesService) vfs.FS {
+	if h, ok := fs.(interface{ FS() vfs.FS }); ok {
+		return h.FS()
+	}
+	return vfs.OS
+}
+
+// WithFS returns fs with every file operation going through fsys instead of
+// the real disk, e.g. a vfs.Mem for tests or simulated runs. Metadata is
+// still read by fs, so with an overlay over the disk, sources keep their
+// tags. Writing tags is not supported, since exiftool only works on disk.
+// It must wrap the base service, before any other decorator.
+func WithFS(fs FilesService, fsys vfs.FS) FilesService {
+	return &fsFiles{FilesService: fs, files: &Files{pr: StdPath{}, fsys: fsys}}
+}
+
+// fsFiles decorates a FilesService with another file system.
+type fsFiles struct {
+	FilesService
+	files *Files
+}
+
+func (ff *fsFiles) FS() vfs.FS { return ff.files.FS() }
+
+func (ff *fsFiles) IsFile(path string) bool      { return ff.files.IsFile(path) }
+func (ff *fsFiles) IsDirectory(path string) bool { return ff.files.IsDirectory(path) }
+
+func (ff *fsFiles) ReadDirectory(dirPath string) ([]string, error) {
+	return ff.files.ReadDirectory(dirPath)
+}
+
+func (ff *fsFiles) ReadDirectoryEntries(dirPath string) ([]DirEntry, error) {
+	return ff.files.ReadDirectoryEntries(dirPath)
+}
+
+func (ff *fsFiles) StreamDirectory(dirPath string, fn func(name string) error) error {
+	return ff.files.StreamDirectory(dirPath, fn)
+}
+
+func (ff *fsFiles) EnsureDir(path string, perm os.FileMode) error {
+	return ff.files.EnsureDir(path, perm)
+}
+
+func (ff *fsFiles) ValidateCopyArgs(src, dst string) error {
+	return ff.files.ValidateCopyArgs(src, dst)
+}
+
+func (ff *fsFiles) Copy(src, dst string) error { return ff.files.Copy(src, dst) }
+
+func (ff *fsFiles) CopyBuffer(src, dst string, size int) error {
+	return ff.files.CopyBuffer(src, dst, size)
+}
+
+func (ff *fsFiles) CopyTee(src, dst string, size int, tee io.Writer) error {
+	return ff.files.CopyTee(src, dst, size, tee)
+}
+
+func (ff *fsFiles) NewTransaction(overwrite bool) Transaction {
+	return NewTransaction(ff, overwrite)
+}
+
+// ReadTags reads the tags of sources on disk, like the other metadata.
+func (ff *fsFiles) ReadTags(paths []string, opts ReadOptions) ([]FileMetadata, error) {
+	return ReadTags(ff.FilesService, paths, opts)
+}
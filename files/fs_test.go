@@ -0,0 +1,79 @@
+package files
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/testutil"
+	"github.com/Tmunayyer/gocamelpack/vfs"
+)
+
+func TestWithFS_TransactionStaysInMemory(t *testing.T) {
+	dir := filepath.Join(testutil.TempDir(t), "withfs")
+	src := filepath.Join(dir, "src.jpg")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(src, []byte("jpeg"), filePermRW); err != nil {
+		t.Fatal(err)
+	}
+
+	mem := vfs.NewMem(vfs.OS)
+	fs := WithFS(newFiles(), mem)
+	copied := filepath.Join(dir, "out", "2025", "copy.jpg")
+	moved := filepath.Join(dir, "out", "2025", "move.jpg")
+	tx := fs.NewTransaction(false)
+	tx.AddCopy(src, copied)
+	tx.AddMove(src+".missing", moved)
+	if err := tx.Validate(); err == nil {
+		t.Fatal("validated a move of a missing source")
+	}
+
+	tx = fs.NewTransaction(false)
+	tx.AddCopy(src, copied)
+	tx.AddMove(src, moved)
+	if err := tx.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	f, err := mem.Open(copied)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(f)
+	f.Close()
+	if string(data) != "jpeg" {
+		t.Errorf("copy reads %q in memory", data)
+	}
+	if fs.IsFile(src) || !fs.IsFile(moved) {
+		t.Error("move not visible through the service")
+	}
+
+	// Nothing reached the disk.
+	if _, err := os.Stat(src); err != nil {
+		t.Errorf("source moved on disk: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "out")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("destination created on disk: %v", err)
+	}
+
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+	if !fs.IsFile(src) || fs.IsDirectory(filepath.Join(dir, "out")) {
+		t.Error("rollback did not restore the in-memory tree")
+	}
+}
+
+func TestFSOf(t *testing.T) {
+	if !vfs.OnDisk(FSOf(newFiles())) {
+		t.Error("Files without a file system should use the disk")
+	}
+	mem := vfs.NewMem(nil)
+	fs := WithPermissions(WithFS(newFiles(), mem), Permissions{FileMode: 0o600})
+	if FSOf(fs) != vfs.FS(mem) {
+		t.Error("decorators should forward the wrapped file system")
+	}
+}
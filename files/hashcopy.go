@@ -0,0 +1,89 @@
+package files
+
+import (
+	"bytes"
+	"fmt"
+	"hash"
+	"io"
+
+	"github.com/Tmunayyer/gocamelpack/vfs"
+)
+
+// TeeCopier is implemented by services that can pass the data they copy
+// to another writer on the way.
+type TeeCopier interface {
+	CopyTee(src, dst string, size int, tee io.Writer) error
+}
+
+// copyTee copies src to dst through fs, writing the data to tee as well.
+// Services that cannot tee have the source read a second time instead.
+func copyTee(fs FilesService, src, dst string, size int, tee io.Writer) error {
+	if tc, ok := fs.(TeeCopier); ok {
+		return tc.CopyTee(src, dst, size, tee)
+	}
+	if err := fs.Copy(src, dst); err != nil {
+		return err
+	}
+	return hashInto(tee, FSOf(fs), src)
+}
+
+func hashInto(w io.Writer, fsys vfs.FS, path string) error {
+	f, err := fsys.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// CopyHash configures WithCopyHash.
+type CopyHash struct {
+	// New returns the hash to compute.
+	New func() hash.Hash
+	// Verify reads each copy back and fails it, removing the copy, when
+	// its hash differs from the source's.
+	Verify bool
+	// Record receives the source's hash after a successful copy.
+	Record func(src string, sum []byte)
+}
+
+// WithCopyHash returns fs with Copy hashing the source as it streams it to
+// the destination, so verifying the copy, or cataloguing it, does not read
+// the source again. Like WithBufferSize it must wrap the base service, or
+// the buffered one, before any other decorator.
+func WithCopyHash(fs FilesService, ch CopyHash) FilesService {
+	return &hashingFiles{wrapped: wrapped{fs}, ch: ch}
+}
+
+// hashingFiles decorates a FilesService with hashing copies.
+type hashingFiles struct {
+	wrapped
+	ch CopyHash
+}
+
+func (hf *hashingFiles) Copy(src, dst string) error {
+	h := hf.ch.New()
+	if err := copyTee(hf.FilesService, src, dst, 0, h); err != nil {
+		return err
+	}
+	sum := h.Sum(nil)
+	if hf.ch.Verify {
+		check := hf.ch.New()
+		if err := hashInto(check, hf.FS(), dst); err != nil {
+			return fmt.Errorf("verifying %q: %w", dst, err)
+		}
+		if !bytes.Equal(check.Sum(nil), sum) {
+			hf.FS().Remove(dst)
+			return Errorf(ErrCorrupt, "verifying %q: the copy differs from %q", dst, src)
+		}
+	}
+	if hf.ch.Record != nil {
+		hf.ch.Record(src, sum)
+	}
+	return nil
+}
+
+func (hf *hashingFiles) NewTransaction(overwrite bool) Transaction {
+	return NewTransaction(hf, overwrite)
+}
@@ -0,0 +1,62 @@
+package files
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+// tamperingFiles damages every copy after it is made.
+type tamperingFiles struct {
+	FilesService
+}
+
+func (tf tamperingFiles) CopyTee(src, dst string, size int, tee io.Writer) error {
+	if err := copyTee(tf.FilesService, src, dst, size, tee); err != nil {
+		return err
+	}
+	return os.WriteFile(dst, []byte("damaged"), filePermRW)
+}
+
+func TestWithCopyHash(t *testing.T) {
+	tmp := testutil.TempDir(t)
+	src := filepath.Join(tmp, "src.bin")
+	data := bytes.Repeat([]byte("0123456789"), 10000)
+	if err := os.WriteFile(src, data, filePermRW); err != nil {
+		t.Fatal(err)
+	}
+	want := sha256.Sum256(data)
+
+	var recorded []byte
+	ch := CopyHash{New: sha256.New, Verify: true, Record: func(s string, sum []byte) {
+		if s == src {
+			recorded = sum
+		}
+	}}
+	fs := WithCopyHash(WithBufferSize(WithTags(newFiles(), []string{"CreationDate"}), 4096), ch)
+	dst := filepath.Join(tmp, "out", "dst.bin")
+	if err := fs.Copy(src, dst); err != nil {
+		t.Fatalf("Copy: %v", err)
+	}
+	if got, _ := os.ReadFile(dst); !bytes.Equal(got, data) {
+		t.Error("hashed copy differs from the source")
+	}
+	if !bytes.Equal(recorded, want[:]) {
+		t.Errorf("recorded %x, want %x", recorded, want)
+	}
+
+	bad := filepath.Join(tmp, "out", "bad.bin")
+	err := WithCopyHash(tamperingFiles{newFiles()}, ch).Copy(src, bad)
+	if !errors.Is(err, ErrCorrupt) {
+		t.Fatalf("expected a verification failure, got %v", err)
+	}
+	if _, err := os.Stat(bad); !os.IsNotExist(err) {
+		t.Error("a copy that fails verification should be removed")
+	}
+}
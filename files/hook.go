@@ -0,0 +1,15 @@
+package files
+
+// PostOperationHook is notified after an operation has been applied
+// successfully. For atomic runs hooks fire only once the whole transaction
+// has committed, so they never observe work that is later rolled back.
+type PostOperationHook interface {
+	OnOperationComplete(op Operation)
+}
+
+// RunHooks notifies every hook about op in registration order.
+func RunHooks(hooks []PostOperationHook, op Operation) {
+	for _, h := range hooks {
+		h.OnOperationComplete(op)
+	}
+}
@@ -0,0 +1,116 @@
+package files
+
+import (
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// SlowestKept is how many of the slowest files IOStats remembers.
+const SlowestKept = 5
+
+// now is replaceable in tests.
+var now = time.Now
+
+// FileTiming is how long one file's transfer took.
+type FileTiming struct {
+	Path     string
+	Bytes    int64
+	Duration time.Duration
+}
+
+// IOStats measures the transfers of a run, for diagnosing slow
+// destinations such as a NAS. Only successful transfers are counted. It is
+// safe for concurrent use.
+type IOStats struct {
+	Files        int
+	BytesRead    int64
+	BytesWritten int64
+	// Busy is the time spent transferring, excluding planning.
+	Busy time.Duration
+	// Slowest holds up to Keep transfers, slowest first.
+	Slowest []FileTiming
+	// Keep is how many of the slowest transfers are remembered; 0 means
+	// SlowestKept.
+	Keep int
+
+	mu sync.Mutex
+}
+
+// Measure runs fn, the transfer of src by an operation of type kind, and
+// records it in s, which may be nil. Copies read and write the source's
+// size; moves are renames within a volume and transfer no data.
+func (s *IOStats) Measure(kind OperationType, src string, fn func() error) error {
+	if s == nil {
+		return fn()
+	}
+	var size int64
+	if info, err := os.Stat(src); err == nil {
+		size = info.Size()
+	}
+	start := now()
+	if err := fn(); err != nil {
+		return err
+	}
+	s.record(kind, src, size, now().Sub(start))
+	return nil
+}
+
+func (s *IOStats) record(kind OperationType, src string, size int64, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Files++
+	s.Busy += d
+	if kind == OperationCopy {
+		s.BytesRead += size
+		s.BytesWritten += size
+	}
+
+	keep := s.Keep
+	if keep <= 0 {
+		keep = SlowestKept
+	}
+	i := sort.Search(len(s.Slowest), func(i int) bool { return s.Slowest[i].Duration < d })
+	if i >= keep {
+		return
+	}
+	s.Slowest = append(s.Slowest, FileTiming{})
+	copy(s.Slowest[i+1:], s.Slowest[i:])
+	s.Slowest[i] = FileTiming{Path: src, Bytes: size, Duration: d}
+	if len(s.Slowest) > keep {
+		s.Slowest = s.Slowest[:keep]
+	}
+}
+
+// BytesPerSecond is the write throughput while transferring.
+func (s *IOStats) BytesPerSecond() float64 {
+	if s.Busy <= 0 {
+		return 0
+	}
+	return float64(s.BytesWritten) / s.Busy.Seconds()
+}
+
+// FilesPerSecond is the file rate while transferring.
+func (s *IOStats) FilesPerSecond() float64 {
+	if s.Busy <= 0 {
+		return 0
+	}
+	return float64(s.Files) / s.Busy.Seconds()
+}
+
+// MeasuredOperation decorates an operation so its Execute is recorded in
+// an IOStats.
+type MeasuredOperation struct {
+	WrappedOperation
+	stats *IOStats
+}
+
+// NewMeasuredOperation wraps op; stats may be nil.
+func NewMeasuredOperation(op Operation, stats *IOStats) *MeasuredOperation {
+	return &MeasuredOperation{WrappedOperation: WrappedOperation{Operation: op}, stats: stats}
+}
+
+func (mo *MeasuredOperation) Execute(fs FilesService) error {
+	return mo.stats.Measure(mo.Type(), mo.Source(), func() error { return mo.Operation.Execute(fs) })
+}
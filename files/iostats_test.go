@@ -0,0 +1,122 @@
+package files
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+// fakeClock replaces now with a clock that advances by each of steps in
+// turn, one step per Measure.
+func fakeClock(t *testing.T, steps ...time.Duration) {
+	t.Helper()
+	orig := now
+	var at time.Time
+	calls := 0
+	now = func() time.Time {
+		if calls%2 == 1 {
+			at = at.Add(steps[calls/2])
+		}
+		calls++
+		return at
+	}
+	t.Cleanup(func() { now = orig })
+}
+
+func TestIOStats_Measure(t *testing.T) {
+	tmp := testutil.TempDir(t)
+	var sources []string
+	for i, size := range []int{10, 20, 30} {
+		p := filepath.Join(tmp, string(rune('a'+i)))
+		if err := os.WriteFile(p, make([]byte, size), filePermRW); err != nil {
+			t.Fatal(err)
+		}
+		sources = append(sources, p)
+	}
+	fakeClock(t, time.Second, 3*time.Second, 2*time.Second)
+
+	var s IOStats
+	for _, src := range sources {
+		if err := s.Measure(OperationCopy, src, func() error { return nil }); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if s.Files != 3 || s.BytesRead != 60 || s.BytesWritten != 60 || s.Busy != 6*time.Second {
+		t.Errorf("stats = %+v", &s)
+	}
+	if got := s.BytesPerSecond(); got != 10 {
+		t.Errorf("BytesPerSecond = %v, want 10", got)
+	}
+	if got := s.FilesPerSecond(); got != 0.5 {
+		t.Errorf("FilesPerSecond = %v, want 0.5", got)
+	}
+	if len(s.Slowest) != 3 || s.Slowest[0].Path != sources[1] || s.Slowest[1].Path != sources[2] || s.Slowest[2].Path != sources[0] {
+		t.Errorf("slowest = %+v", s.Slowest)
+	}
+}
+
+func TestIOStats_MovesAndFailures(t *testing.T) {
+	tmp := testutil.TempDir(t)
+	src := filepath.Join(tmp, "a")
+	if err := os.WriteFile(src, make([]byte, 10), filePermRW); err != nil {
+		t.Fatal(err)
+	}
+
+	var s IOStats
+	if err := s.Measure(OperationMove, src, func() error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	boom := errors.New("boom")
+	if err := s.Measure(OperationCopy, src, func() error { return boom }); err != boom {
+		t.Fatalf("err = %v, want boom", err)
+	}
+	if s.Files != 1 || s.BytesWritten != 0 {
+		t.Errorf("a rename should count as a file without bytes, and failures not at all: %+v", &s)
+	}
+
+	var none *IOStats
+	if err := none.Measure(OperationCopy, src, func() error { return nil }); err != nil {
+		t.Errorf("nil stats should just run fn: %v", err)
+	}
+}
+
+func TestIOStats_KeepsSlowest(t *testing.T) {
+	var s IOStats
+	for i := 1; i <= SlowestKept+3; i++ {
+		s.record(OperationCopy, string(rune('a'+i)), 0, time.Duration(i)*time.Second)
+	}
+	if len(s.Slowest) != SlowestKept {
+		t.Fatalf("kept %d, want %d", len(s.Slowest), SlowestKept)
+	}
+	if s.Slowest[0].Duration != time.Duration(SlowestKept+3)*time.Second || s.Slowest[SlowestKept-1].Duration != 4*time.Second {
+		t.Errorf("slowest = %+v", s.Slowest)
+	}
+}
+
+func TestIOStats_Keep(t *testing.T) {
+	s := IOStats{Keep: 2}
+	for i := 1; i <= 4; i++ {
+		s.record(OperationMove, string(rune('a'+i)), 0, time.Duration(i)*time.Second)
+	}
+	if len(s.Slowest) != 2 || s.Slowest[1].Duration != 3*time.Second {
+		t.Errorf("slowest = %+v", s.Slowest)
+	}
+}
+
+func TestWithTagTiming(t *testing.T) {
+	fakeClock(t, time.Second, 2*time.Second)
+	var timing TagTiming
+	fs := WithTagTiming(newMockFilesService(), &timing)
+	fs.GetFileTags([]string{"a", "b"})
+	fs.GetFileTags([]string{"c"})
+	if timing.Calls != 2 || timing.Files != 3 || timing.Duration != 3*time.Second || timing.PerFile() != time.Second {
+		t.Errorf("timing: %d call(s), %d file(s), %s", timing.Calls, timing.Files, timing.Duration)
+	}
+	if tx, ok := fs.NewTransaction(false).(*FileTransaction); !ok || tx.fs != fs {
+		t.Error("transactions should run through the decorated service")
+	}
+}
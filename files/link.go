@@ -0,0 +1,109 @@
+package files
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/Tmunayyer/gocamelpack/vfs"
+)
+
+// LinkMode selects how Linked places files.
+type LinkMode string
+
+const (
+	HardLink     LinkMode = "hard"
+	SymbolicLink LinkMode = "symlink"
+)
+
+// ParseLinkMode accepts "hard" and "symlink".
+func ParseLinkMode(s string) (LinkMode, error) {
+	switch m := LinkMode(s); m {
+	case HardLink, SymbolicLink:
+		return m, nil
+	}
+	return "", fmt.Errorf("invalid link mode %q (want hard or symlink)", s)
+}
+
+// Linked returns fs with Copy placing a hard or symbolic link to the source
+// instead of copying its bytes. Directories are still created through fs.
+// Transactions created from the result link the same way.
+func Linked(fs FilesService, mode LinkMode) FilesService {
+	return &linkedFiles{FilesService: fs, mode: mode}
+}
+
+// linkedFiles decorates a FilesService to link rather than copy.
+type linkedFiles struct {
+	FilesService
+	mode LinkMode
+}
+
+func (lf *linkedFiles) Copy(src, dst string) error {
+	if err := lf.ValidateCopyArgs(src, dst); err != nil {
+		return err
+	}
+	if err := lf.EnsureDir(filepath.Dir(dst), PermissionsOf(lf.FilesService).DirPerm()); err != nil {
+		return err
+	}
+	if lf.mode == SymbolicLink {
+		abs, err := filepath.Abs(src)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(abs, dst)
+	}
+	err := os.Link(src, dst)
+	if errors.Is(err, syscall.EXDEV) {
+		return fmt.Errorf("hard link %q: source and destination must be on the same file system", dst)
+	}
+	return err
+}
+
+func (lf *linkedFiles) NewTransaction(overwrite bool) Transaction {
+	return NewTransaction(lf, overwrite)
+}
+
+func (lf *linkedFiles) Permissions() Permissions {
+	return PermissionsOf(lf.FilesService)
+}
+
+func (lf *linkedFiles) FS() vfs.FS {
+	return FSOf(lf.FilesService)
+}
+
+// WithLinks returns fs with Copy creating a hard link instead whenever
+// existing reports a file with the same content as the source, e.g. one
+// already in the archive. Copy falls back to copying when linking fails,
+// such as across volumes or when the destination exists. Transactions
+// created from the result link the same way.
+func WithLinks(fs FilesService, existing func(src string) (string, bool)) FilesService {
+	return &linkingFiles{wrapped: wrapped{fs}, existing: existing}
+}
+
+// linkingFiles decorates a FilesService with hard-link deduplication.
+type linkingFiles struct {
+	wrapped
+	existing func(src string) (string, bool)
+}
+
+func (lf *linkingFiles) Copy(src, dst string) error {
+	if target, ok := lf.existing(src); ok {
+		if err := lf.FilesService.EnsureDir(filepath.Dir(dst), PermissionsOf(lf.FilesService).DirPerm()); err != nil {
+			return err
+		}
+		if os.Link(target, dst) == nil {
+			return nil
+		}
+	}
+	return lf.FilesService.Copy(src, dst)
+}
+
+func (lf *linkingFiles) NewTransaction(overwrite bool) Transaction {
+	return NewTransaction(lf, overwrite)
+}
+
+func (lf *linkingFiles) Permissions() Permissions {
+	return PermissionsOf(lf.FilesService)
+}
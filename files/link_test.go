@@ -0,0 +1,68 @@
+package files
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+func TestLinked(t *testing.T) {
+	tmp := testutil.TempDir(t)
+	src := filepath.Join(tmp, "IMG_0001.jpg")
+	if err := os.WriteFile(src, []byte("data"), filePermRW); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("hard", func(t *testing.T) {
+		dst := filepath.Join(tmp, "hard", "2025", "15_30.jpg")
+		if err := Linked(newFiles(), HardLink).Copy(src, dst); err != nil {
+			t.Fatal(err)
+		}
+		a, _ := os.Stat(src)
+		b, err := os.Stat(dst)
+		if err != nil || !os.SameFile(a, b) {
+			t.Errorf("destination should be a hard link to the source (err %v)", err)
+		}
+		if err := Linked(newFiles(), HardLink).Copy(src, dst); err == nil {
+			t.Error("linking over an existing destination should fail")
+		}
+	})
+
+	t.Run("symlink in a transaction", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("symlinks need extra privileges on Windows")
+		}
+		dst := filepath.Join(tmp, "sym", "2025", "15_30.jpg")
+		tx := Linked(newFiles(), SymbolicLink).NewTransaction(false)
+		if err := tx.Add(NewCopyOperation(src, dst)); err != nil {
+			t.Fatal(err)
+		}
+		if err := tx.Execute(); err != nil {
+			t.Fatal(err)
+		}
+		if target, err := os.Readlink(dst); err != nil || target != src {
+			t.Errorf("Readlink = %q, %v; want %q", target, err, src)
+		}
+		if err := tx.Rollback(); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.Lstat(dst); !os.IsNotExist(err) {
+			t.Errorf("rollback should remove the link, lstat err = %v", err)
+		}
+		if _, err := os.Stat(src); err != nil {
+			t.Errorf("rollback must keep the source: %v", err)
+		}
+	})
+}
+
+func TestParseLinkMode(t *testing.T) {
+	if m, err := ParseLinkMode("symlink"); err != nil || m != SymbolicLink {
+		t.Errorf("ParseLinkMode(symlink) = %q, %v", m, err)
+	}
+	if _, err := ParseLinkMode("soft"); err == nil {
+		t.Error("expected an error for an unknown mode")
+	}
+}
@@ -0,0 +1,250 @@
+package files
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// LockName is the advisory lock file created in a destination root while a
+// run writes into it.
+const LockName = ".gocamelpack.lock"
+
+// lockOwner is the content of a lock file, identifying the run holding it.
+type lockOwner struct {
+	PID     int       `json:"pid"`
+	Host    string    `json:"host"`
+	Started time.Time `json:"started"`
+	// Token tells apart locks taken by the same process.
+	Token string `json:"token,omitempty"`
+}
+
+// same reports whether o and other are the same lock.
+func (o lockOwner) same(other lockOwner) bool {
+	return o.PID == other.PID && o.Host == other.Host && o.Token == other.Token && o.Started.Equal(other.Started)
+}
+
+const (
+	// lockAttempts bounds how often LockDir looks at a lock that is
+	// released or taken over while it tries to take it.
+	lockAttempts = 50
+	// takeoverWait is how long LockDir waits for another run's takeover of
+	// a stale lock to finish before looking again.
+	takeoverWait = 10 * time.Millisecond
+)
+
+// Lock is an acquired advisory lock on a destination root.
+type Lock struct {
+	path string
+}
+
+// LockDir takes the advisory lock on root, creating root if needed. It fails
+// with ErrLocked while another live run holds the lock. A lock left behind by
+// a process that no longer exists on this host is taken over.
+//
+// The lock only guards against other gocamelpack runs; nothing stops other
+// programs from writing into root.
+func LockDir(root string) (*Lock, error) {
+	if err := CheckWritable(root); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(root, DefaultDirPerm); err != nil {
+		return nil, fmt.Errorf("creating %q: %w", root, err)
+	}
+	path := filepath.Join(root, LockName)
+
+	host, _ := os.Hostname()
+	token := make([]byte, 8)
+	rand.Read(token)
+	me := lockOwner{PID: os.Getpid(), Host: host, Started: time.Now(), Token: hex.EncodeToString(token)}
+	data, err := json.Marshal(me)
+	if err != nil {
+		return nil, err
+	}
+
+	for attempt := 0; ; attempt++ {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err == nil {
+			_, werr := f.Write(data)
+			if cerr := f.Close(); werr == nil {
+				werr = cerr
+			}
+			if werr != nil {
+				os.Remove(path)
+				return nil, fmt.Errorf("writing lock %q: %w", path, werr)
+			}
+			return &Lock{path: path}, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("creating lock %q: %w", path, err)
+		}
+
+		owner, rerr := readLockOwner(path)
+		if errors.Is(rerr, os.ErrNotExist) && attempt < lockAttempts {
+			continue // released in the meantime
+		}
+		if rerr == nil && attempt < lockAttempts && owner.gone(host) {
+			ok, err := takeOver(path, owner, me, data)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				return &Lock{path: path}, nil
+			}
+			continue // another run got there first; look again
+		}
+		if rerr != nil {
+			return nil, Errorf(ErrLocked, "%s is locked by another run (%s)", root, path)
+		}
+		return nil, Errorf(ErrLocked, "%s is locked by process %d on %s since %s (%s)",
+			root, owner.PID, owner.Host, owner.Started.Format(time.RFC3339), path)
+	}
+}
+
+// takeOver replaces the stale lock at path with me's, whose content is
+// data. Takeovers are serialized by a guard file next to the lock, so two
+// runs finding the same stale lock cannot both replace it; the new lock is
+// written to a temporary file and renamed over the stale one, so the lock
+// file never goes missing and a run starting meanwhile sees it as taken.
+// ok is false when another run holds the guard or took the lock over first.
+func takeOver(path string, stale, me lockOwner, data []byte) (ok bool, err error) {
+	guard := path + ".takeover"
+	g, err := os.OpenFile(guard, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if errors.Is(err, os.ErrExist) {
+		// A guard whose run died midway would block every takeover.
+		if holder, err := readLockOwner(guard); err == nil && holder.gone(me.Host) {
+			os.Remove(guard)
+		}
+		time.Sleep(takeoverWait)
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("taking over stale lock %q: %w", path, err)
+	}
+	_, werr := g.Write(data)
+	if cerr := g.Close(); werr == nil {
+		werr = cerr
+	}
+	defer os.Remove(guard)
+	if werr != nil {
+		return false, fmt.Errorf("taking over stale lock %q: %w", path, werr)
+	}
+
+	// The lock may have been taken over and released since it was read.
+	if cur, err := readLockOwner(path); err != nil || !cur.same(stale) {
+		return false, nil
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), LockName+".*")
+	if err != nil {
+		return false, fmt.Errorf("taking over stale lock %q: %w", path, err)
+	}
+	_, werr = tmp.Write(data)
+	if cerr := tmp.Close(); werr == nil {
+		werr = cerr
+	}
+	if werr == nil {
+		werr = os.Rename(tmp.Name(), path)
+	}
+	if werr != nil {
+		os.Remove(tmp.Name())
+		return false, fmt.Errorf("taking over stale lock %q: %w", path, werr)
+	}
+	cur, err := readLockOwner(path)
+	if err != nil {
+		return false, fmt.Errorf("taking over stale lock %q: %w", path, err)
+	}
+	return cur.same(me), nil
+}
+
+// Locks are locks taken together on several roots.
+type Locks []*Lock
+
+// LockDirs takes the lock on each of roots, once each and in sorted order,
+// so runs sharing roots contend for them in the same order. If one cannot
+// be taken, those already taken are released.
+func LockDirs(roots []string) (Locks, error) {
+	sorted := make([]string, len(roots))
+	for i, root := range roots {
+		sorted[i] = filepath.Clean(root)
+	}
+	slices.Sort(sorted)
+	var ls Locks
+	for _, root := range slices.Compact(sorted) {
+		l, err := LockDir(root)
+		if err != nil {
+			ls.Release()
+			return nil, err
+		}
+		ls = append(ls, l)
+	}
+	return ls, nil
+}
+
+// Release releases every lock, in reverse order. Releasing nil locks is a
+// no-op.
+func (ls Locks) Release() error {
+	var errs []error
+	for i := len(ls) - 1; i >= 0; i-- {
+		if err := ls[i].Release(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// gone reports whether the run holding the lock has exited. Only runs on
+// host can be checked; others are assumed alive.
+func (o lockOwner) gone(host string) bool {
+	return o.Host == host && !processAlive(o.PID)
+}
+
+// StaleLock reports whether root holds a lock left behind by a run that no
+// longer exists, the kind LockDir takes over. A missing lock is not stale.
+func StaleLock(root string) (bool, error) {
+	owner, err := readLockOwner(filepath.Join(root, LockName))
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	host, _ := os.Hostname()
+	return owner.gone(host), nil
+}
+
+func readLockOwner(path string) (lockOwner, error) {
+	var owner lockOwner
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return owner, err
+	}
+	if err := json.Unmarshal(data, &owner); err != nil {
+		return owner, fmt.Errorf("parsing lock %q: %w", path, err)
+	}
+	return owner, nil
+}
+
+// Path returns the lock file's path.
+func (l *Lock) Path() string {
+	return l.path
+}
+
+// Release removes the lock file. Releasing a nil or already released lock
+// is a no-op.
+func (l *Lock) Release() error {
+	if l == nil || l.path == "" {
+		return nil
+	}
+	err := os.Remove(l.path)
+	l.path = ""
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("releasing lock: %w", err)
+	}
+	return nil
+}
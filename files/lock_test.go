@@ -0,0 +1,160 @@
+package files
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+func TestLockDir_ExcludesSecondRun(t *testing.T) {
+	root := filepath.Join(testutil.TempDir(t), "dst")
+
+	lock, err := LockDir(root)
+	if err != nil {
+		t.Fatalf("LockDir: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, LockName)); err != nil {
+		t.Fatalf("lock file not created: %v", err)
+	}
+
+	if _, err := LockDir(root); !errors.Is(err, ErrLocked) {
+		t.Fatalf("second LockDir: expected ErrLocked, got %v", err)
+	}
+
+	if err := lock.Release(); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if err := lock.Release(); err != nil {
+		t.Fatalf("second Release: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, LockName)); !os.IsNotExist(err) {
+		t.Fatalf("lock file still present after release: %v", err)
+	}
+
+	again, err := LockDir(root)
+	if err != nil {
+		t.Fatalf("LockDir after release: %v", err)
+	}
+	again.Release()
+}
+
+func TestLockDirs_LocksEveryRoot(t *testing.T) {
+	dir := testutil.TempDir(t)
+	a, b := filepath.Join(dir, "a"), filepath.Join(dir, "b")
+
+	locks, err := LockDirs([]string{b, a, b + "/"})
+	if err != nil {
+		t.Fatalf("LockDirs: %v", err)
+	}
+	if len(locks) != 2 || locks[0].Path() != filepath.Join(a, LockName) || locks[1].Path() != filepath.Join(b, LockName) {
+		t.Fatalf("locks = %v, want a then b once each", locks)
+	}
+
+	// A run sharing one root is refused and keeps none of the others.
+	c := filepath.Join(dir, "c")
+	if _, err := LockDirs([]string{c, b}); !errors.Is(err, ErrLocked) {
+		t.Fatalf("overlapping LockDirs: expected ErrLocked, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(c, LockName)); !os.IsNotExist(err) {
+		t.Errorf("lock on c kept after the failure: %v", err)
+	}
+
+	if err := locks.Release(); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	for _, root := range []string{a, b} {
+		if _, err := os.Stat(filepath.Join(root, LockName)); !os.IsNotExist(err) {
+			t.Errorf("lock on %s still present after release: %v", root, err)
+		}
+	}
+}
+
+func TestLockDir_TakesOverStaleLock(t *testing.T) {
+	root := testutil.TempDir(t)
+	host, _ := os.Hostname()
+
+	// A PID far above any real one stands in for an exited process.
+	stale, _ := json.Marshal(lockOwner{PID: 1 << 30, Host: host, Started: time.Now()})
+	if err := os.WriteFile(filepath.Join(root, LockName), stale, filePermRW); err != nil {
+		t.Fatal(err)
+	}
+
+	lock, err := LockDir(root)
+	if err != nil {
+		t.Fatalf("expected stale lock to be taken over, got %v", err)
+	}
+	defer lock.Release()
+
+	owner, err := readLockOwner(lock.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if owner.PID != os.Getpid() {
+		t.Errorf("lock owner PID = %d, want %d", owner.PID, os.Getpid())
+	}
+}
+
+func TestLockDir_ConcurrentTakeovers(t *testing.T) {
+	host, _ := os.Hostname()
+	stale, _ := json.Marshal(lockOwner{PID: 1 << 30, Host: host, Started: time.Now()})
+
+	for i := 0; i < 20; i++ {
+		root := testutil.TempDir(t)
+		if err := os.WriteFile(filepath.Join(root, LockName), stale, filePermRW); err != nil {
+			t.Fatal(err)
+		}
+
+		var (
+			wg    sync.WaitGroup
+			start = make(chan struct{})
+			locks [2]*Lock
+			errs  [2]error
+		)
+		for n := range locks {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				<-start
+				locks[n], errs[n] = LockDir(root)
+			}()
+		}
+		close(start)
+		wg.Wait()
+
+		taken := 0
+		for n := range locks {
+			if errs[n] == nil {
+				taken++
+				locks[n].Release()
+			} else if !errors.Is(errs[n], ErrLocked) {
+				t.Fatalf("LockDir: %v", errs[n])
+			}
+		}
+		if taken != 1 {
+			t.Fatalf("round %d: %d runs took the stale lock over, want exactly 1", i, taken)
+		}
+		entries, _ := os.ReadDir(root)
+		if len(entries) != 0 {
+			t.Errorf("round %d: left behind %v", i, entries)
+		}
+	}
+}
+
+func TestLockDir_RespectsOtherHosts(t *testing.T) {
+	root := testutil.TempDir(t)
+
+	other, _ := json.Marshal(lockOwner{PID: 1 << 30, Host: "some-other-host", Started: time.Now()})
+	if err := os.WriteFile(filepath.Join(root, LockName), other, filePermRW); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := LockDir(root); !errors.Is(err, ErrLocked) {
+		t.Fatalf("expected ErrLocked for a lock held on another host, got %v", err)
+	}
+}
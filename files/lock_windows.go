@@ -0,0 +1,7 @@
+//go:build windows
+
+package files
+
+// processAlive conservatively assumes the lock holder is still running;
+// stale locks on Windows must be removed by hand.
+func processAlive(pid int) bool { return true }
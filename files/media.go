@@ -0,0 +1,51 @@
+package files
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// MediaKind classifies a file by the kind of media it holds.
+type MediaKind int
+
+const (
+	MediaUnknown MediaKind = iota
+	MediaImage
+	MediaVideo
+)
+
+func (mk MediaKind) String() string {
+	switch mk {
+	case MediaImage:
+		return "image"
+	case MediaVideo:
+		return "video"
+	default:
+		return "unknown"
+	}
+}
+
+var imageExts = map[string]bool{
+	".jpg": true, ".jpeg": true, ".png": true, ".gif": true, ".bmp": true,
+	".tif": true, ".tiff": true, ".webp": true, ".heic": true, ".heif": true,
+	".dng": true, ".cr2": true, ".cr3": true, ".nef": true, ".arw": true,
+	".orf": true, ".rw2": true, ".raf": true,
+}
+
+var videoExts = map[string]bool{
+	".mp4": true, ".mov": true, ".m4v": true, ".avi": true, ".mts": true,
+	".m2ts": true, ".3gp": true, ".mkv": true, ".wmv": true,
+}
+
+// MediaKindOf reports the media kind of path based on its extension.
+func MediaKindOf(path string) MediaKind {
+	ext := strings.ToLower(filepath.Ext(path))
+	switch {
+	case imageExts[ext]:
+		return MediaImage
+	case videoExts[ext]:
+		return MediaVideo
+	default:
+		return MediaUnknown
+	}
+}
@@ -0,0 +1,23 @@
+package files
+
+import "testing"
+
+func TestMediaKindOf(t *testing.T) {
+	tests := []struct {
+		path string
+		want MediaKind
+	}{
+		{"/a/IMG_0001.JPG", MediaImage},
+		{"/a/photo.heic", MediaImage},
+		{"/a/raw.CR3", MediaImage},
+		{"/a/clip.mov", MediaVideo},
+		{"/a/clip.MP4", MediaVideo},
+		{"/a/track.gpx", MediaUnknown},
+		{"/a/noext", MediaUnknown},
+	}
+	for _, tc := range tests {
+		if got := MediaKindOf(tc.path); got != tc.want {
+			t.Errorf("MediaKindOf(%q) = %v, want %v", tc.path, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package files
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Normalization selects the Unicode normalization form applied to the
+// destination names gocamelpack creates. macOS tends to produce decomposed
+// (NFD) names while Linux tools expect composed (NFC) ones, so the same
+// "Café" can otherwise end up as two different-looking folders.
+type Normalization string
+
+const (
+	NormalizeNFC  Normalization = "nfc"
+	NormalizeNFD  Normalization = "nfd"
+	NormalizeNone Normalization = "none"
+)
+
+// DefaultNormalization is used when nothing is configured.
+const DefaultNormalization = NormalizeNFC
+
+// ParseNormalization validates a user-supplied form; "" means the default.
+func ParseNormalization(s string) (Normalization, error) {
+	switch n := Normalization(strings.ToLower(strings.TrimSpace(s))); n {
+	case "":
+		return DefaultNormalization, nil
+	case NormalizeNFC, NormalizeNFD, NormalizeNone:
+		return n, nil
+	default:
+		return "", fmt.Errorf("invalid normalization %q (want nfc, nfd or none)", s)
+	}
+}
+
+// Apply returns s in the normalization form n.
+func (n Normalization) Apply(s string) string {
+	switch n {
+	case NormalizeNFC:
+		return norm.NFC.String(s)
+	case NormalizeNFD:
+		return norm.NFD.String(s)
+	default:
+		return s
+	}
+}
+
+// NormalizeBelow normalizes the part of path below root, leaving root
+// itself untouched since it names a directory that already exists in
+// whatever form the user's system gave it.
+func NormalizeBelow(root, path string, n Normalization) string {
+	if n != NormalizeNFC && n != NormalizeNFD {
+		return path
+	}
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return n.Apply(path)
+	}
+	return filepath.Join(root, n.Apply(rel))
+}
@@ -0,0 +1,70 @@
+package files
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+const (
+	cafeNFC = "Café"  // é as one code point
+	cafeNFD = "Café" // e + combining acute accent
+)
+
+func TestParseNormalization(t *testing.T) {
+	for in, want := range map[string]Normalization{"": NormalizeNFC, "NFD": NormalizeNFD, " none ": NormalizeNone} {
+		got, err := ParseNormalization(in)
+		if err != nil || got != want {
+			t.Errorf("ParseNormalization(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseNormalization("nfkc"); err == nil {
+		t.Error("expected error for unsupported form")
+	}
+}
+
+func TestNormalizeBelow(t *testing.T) {
+	root := filepath.Join("/Volumes", cafeNFD) // existing root keeps its form
+	dst := filepath.Join(root, "2025", cafeNFD+".jpg")
+
+	if got, want := NormalizeBelow(root, dst, NormalizeNFC), filepath.Join(root, "2025", cafeNFC+".jpg"); got != want {
+		t.Errorf("NFC: got %q, want %q", got, want)
+	}
+	if got := NormalizeBelow(root, filepath.Join(root, cafeNFC), NormalizeNFD); got != filepath.Join(root, cafeNFD) {
+		t.Errorf("NFD: got %q", got)
+	}
+	if got := NormalizeBelow(root, dst, NormalizeNone); got != dst {
+		t.Errorf("none: got %q, want unchanged", got)
+	}
+}
+
+func TestCollisionTracker_Normalization(t *testing.T) {
+	dir := testutil.TempDir(t)
+	c := NewCollisionTracker(false)
+
+	if err := c.Add(filepath.Join(dir, "a", cafeNFC+".jpg")); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Add(filepath.Join(dir, "a", cafeNFD+".jpg")); ErrorCode(err) != CodeConflict {
+		t.Errorf("expected normalization collision, got %v", err)
+	}
+
+	// A differently normalized file already on disk is a collision too.
+	if err := os.WriteFile(filepath.Join(dir, cafeNFD+".png"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Add(filepath.Join(dir, cafeNFC+".png")); ErrorCode(err) != CodeConflict {
+		t.Errorf("expected collision with existing file, got %v", err)
+	}
+	// The identical name is left to ordinary conflict validation.
+	if err := c.Add(filepath.Join(dir, cafeNFD+".png")); err != nil {
+		t.Errorf("identical existing name should not be reported here: %v", err)
+	}
+
+	// Case differences only matter when folding.
+	if err := c.Add(filepath.Join(dir, "a", "CAFÉ.jpg")); err != nil {
+		t.Errorf("case-sensitive tracker reported a case difference: %v", err)
+	}
+}
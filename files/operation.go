@@ -4,12 +4,18 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+
+	"github.com/Tmunayyer/gocamelpack/vfs"
 )
 
 // CopyOperation represents a file copy operation.
 type CopyOperation struct {
 	src string
 	dst string
+	// overwrite allows replacing an existing destination. The replaced file
+	// is kept in backup until the transaction commits or rolls back.
+	overwrite bool
+	backup    string
 }
 
 // NewCopyOperation creates a new copy operation.
@@ -29,22 +35,54 @@ func (co *CopyOperation) Type() OperationType {
 	return OperationCopy
 }
 
+func (co *CopyOperation) setOverwrite(overwrite bool) {
+	co.overwrite = overwrite
+}
+
 func (co *CopyOperation) Execute(fs FilesService) error {
-	return fs.Copy(co.src, co.dst)
+	fsys := FSOf(fs)
+	if co.overwrite {
+		backup, err := setAside(fsys, co.dst)
+		if err != nil {
+			return err
+		}
+		co.backup = backup
+	}
+
+	if err := fs.Copy(co.src, co.dst); err != nil {
+		if rerr := co.restore(fsys); rerr != nil {
+			return fmt.Errorf("%v; %w", err, rerr)
+		}
+		return err
+	}
+	return nil
 }
 
 func (co *CopyOperation) Rollback(fs FilesService) error {
 	// For copy operations, rollback removes the destination file
-	if err := os.Remove(co.dst); err != nil && !os.IsNotExist(err) {
+	fsys := FSOf(fs)
+	if err := fsys.Remove(co.dst); err != nil && !os.IsNotExist(err) {
 		return fmt.Errorf("failed to remove copied file %q: %w", co.dst, err)
 	}
-	return nil
+	// ...and puts back whatever was there before.
+	return co.restore(fsys)
+}
+
+// Commit discards the pre-existing destination once the overwrite is final.
+func (co *CopyOperation) Commit(fs FilesService) error {
+	return discardBackup(FSOf(fs), &co.backup)
+}
+
+func (co *CopyOperation) restore(fsys vfs.FS) error {
+	return restoreBackup(fsys, &co.backup, co.dst)
 }
 
 // MoveOperation represents a file move operation.
 type MoveOperation struct {
-	src string
-	dst string
+	src       string
+	dst       string
+	overwrite bool   // see CopyOperation
+	backup    string
 }
 
 // NewMoveOperation creates a new move operation.
@@ -64,23 +102,169 @@ func (mo *MoveOperation) Type() OperationType {
 	return OperationMove
 }
 
+func (mo *MoveOperation) setOverwrite(overwrite bool) {
+	mo.overwrite = overwrite
+}
+
 func (mo *MoveOperation) Execute(fs FilesService) error {
 	// Ensure destination directory exists (similar to how move command works)
-	if err := fs.EnsureDir(filepath.Dir(mo.dst), 0o755); err != nil {
+	perms, fsys := PermissionsOf(fs), FSOf(fs)
+	if err := fs.EnsureDir(filepath.Dir(mo.dst), perms.DirPerm()); err != nil {
 		return err
 	}
 	
+	// Rename would silently replace an existing destination: refuse, or
+	// keep it aside when overwriting.
+	if mo.overwrite {
+		backup, err := setAside(fsys, mo.dst)
+		if err != nil {
+			return err
+		}
+		mo.backup = backup
+	} else if _, err := fsys.Lstat(mo.dst); err == nil {
+		return Errorf(ErrConflict, "destination %q already exists", mo.dst)
+	}
+	
 	// Perform the move (rename)
-	if err := os.Rename(mo.src, mo.dst); err != nil {
-		return fmt.Errorf("move %q to %q: %w", mo.src, mo.dst, err)
+	if err := fsys.Rename(mo.src, mo.dst); err != nil {
+		err = fmt.Errorf("move %q to %q: %w", mo.src, mo.dst, err)
+		if rerr := restoreBackup(fsys, &mo.backup, mo.dst); rerr != nil {
+			return fmt.Errorf("%v; %w", err, rerr)
+		}
+		return err
+	}
+	err := perms.ApplyFile(mo.dst)
+	if err == nil {
+		err = syncRename(fsys, mo.src, mo.dst)
+	}
+	if err != nil {
+		// Not durable or not as requested: undo so the failed operation
+		// leaves no trace.
+		fsys.Rename(mo.dst, mo.src)
+		restoreBackup(fsys, &mo.backup, mo.dst)
+		return err
 	}
 	return nil
 }
 
 func (mo *MoveOperation) Rollback(fs FilesService) error {
+	fsys := FSOf(fs)
+	if err := mo.moveBack(fsys); err != nil {
+		return err
+	}
+	return restoreBackup(fsys, &mo.backup, mo.dst)
+}
+
+// Commit discards the pre-existing destination once the overwrite is final.
+func (mo *MoveOperation) Commit(fs FilesService) error {
+	return discardBackup(FSOf(fs), &mo.backup)
+}
+
+func (mo *MoveOperation) moveBack(fsys vfs.FS) error {
 	// For move operations, rollback moves the file back to its original location
-	if err := os.Rename(mo.dst, mo.src); err != nil && !os.IsNotExist(err) {
+	if _, err := fsys.Lstat(mo.dst); os.IsNotExist(err) {
+		return nil
+	}
+	// Never clobber something that appeared at the source in the meantime.
+	if _, err := fsys.Lstat(mo.src); err == nil {
+		return fmt.Errorf("cannot restore %q: source path is occupied", mo.src)
+	}
+	// The source directory may have been removed since the move.
+	if err := fsys.MkdirAll(filepath.Dir(mo.src), DefaultDirPerm); err != nil {
+		return fmt.Errorf("recreating source directory for %q: %w", mo.src, err)
+	}
+	if err := fsys.Rename(mo.dst, mo.src); err != nil {
 		return fmt.Errorf("failed to restore moved file %q to %q: %w", mo.dst, mo.src, err)
 	}
+	return syncRename(fsys, mo.dst, mo.src)
+}
+
+// syncRename flushes both directories touched by a rename. Only the real
+// disk has anything to flush.
+func syncRename(fsys vfs.FS, from, to string) error {
+	if !vfs.OnDisk(fsys) {
+		return nil
+	}
+	if err := syncDir(filepath.Dir(to)); err != nil {
+		return fmt.Errorf("sync %q: %w", filepath.Dir(to), err)
+	}
+	if filepath.Dir(from) != filepath.Dir(to) {
+		if err := syncDir(filepath.Dir(from)); err != nil {
+			return fmt.Errorf("sync %q: %w", filepath.Dir(from), err)
+		}
+	}
+	return nil
+}
+
+// overwriter is implemented by operations that can replace an existing
+// destination; the transaction tells them whether they may.
+type overwriter interface {
+	setOverwrite(overwrite bool)
+}
+
+// previousSuffix is appended to a destination that existed before an
+// overwriting operation, while the transaction can still roll back.
+const previousSuffix = ".gocamelpack-prev"
+
+// setAside renames an existing dst out of the way and returns the backup
+// path, or "" when dst does not exist.
+func setAside(fsys vfs.FS, dst string) (string, error) {
+	if _, err := fsys.Lstat(dst); os.IsNotExist(err) {
+		return "", nil
+	} else if err != nil {
+		return "", fmt.Errorf("checking destination %q: %w", dst, err)
+	}
+
+	// Several operations of one transaction may overwrite the same path;
+	// each keeps its own backup so rollback can unwind them in order.
+	backup := dst + previousSuffix
+	for i := 1; ; i++ {
+		if _, err := fsys.Lstat(backup); os.IsNotExist(err) {
+			break
+		}
+		backup = fmt.Sprintf("%s%s.%d", dst, previousSuffix, i)
+	}
+	if err := fsys.Rename(dst, backup); err != nil {
+		return "", fmt.Errorf("backing up existing %q: %w", dst, err)
+	}
+	return backup, nil
+}
+
+// Backups returns the files set aside for dst by overwriting operations
+// that were never restored or discarded, the oldest — the original
+// destination — first.
+func Backups(fsys vfs.FS, dst string) []string {
+	var found []string
+	backup := dst + previousSuffix
+	for i := 1; ; i++ {
+		if _, err := fsys.Lstat(backup); err != nil {
+			return found
+		}
+		found = append(found, backup)
+		backup = fmt.Sprintf("%s%s.%d", dst, previousSuffix, i)
+	}
+}
+
+// restoreBackup puts a set-aside file back at dst, replacing anything there.
+func restoreBackup(fsys vfs.FS, backup *string, dst string) error {
+	if *backup == "" {
+		return nil
+	}
+	if err := fsys.Rename(*backup, dst); err != nil {
+		return fmt.Errorf("restoring original %q from %q: %w", dst, *backup, err)
+	}
+	*backup = ""
 	return nil
-}
\ No newline at end of file
+}
+
+// discardBackup deletes a set-aside file that is no longer needed.
+func discardBackup(fsys vfs.FS, backup *string) error {
+	if *backup == "" {
+		return nil
+	}
+	if err := fsys.Remove(*backup); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("removing backup %q: %w", *backup, err)
+	}
+	*backup = ""
+	return nil
+}
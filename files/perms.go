@@ -0,0 +1,200 @@
+package files
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// DefaultDirPerm is used for created directories when no policy sets one.
+// The process umask applies, so with the usual 022 directories end up 0755.
+const DefaultDirPerm os.FileMode = 0o777
+
+// Owner is the user and group given to created files and directories. A
+// negative ID leaves that part unchanged.
+type Owner struct {
+	UID, GID int
+}
+
+// Permissions is the mode and ownership policy for everything an import
+// creates. The zero value keeps the source file's mode, creates directories
+// per the umask and leaves ownership alone. Explicit modes are set exactly,
+// regardless of the umask.
+type Permissions struct {
+	FileMode os.FileMode // exact mode for created files; 0 keeps the source's
+	DirMode  os.FileMode // exact mode for created directories; 0 means DefaultDirPerm
+	Owner    *Owner      // nil leaves ownership unchanged
+}
+
+// IsDefault reports whether p changes nothing compared to the zero policy.
+func (p Permissions) IsDefault() bool {
+	return p.FileMode == 0 && p.DirMode == 0 && p.Owner == nil
+}
+
+// DirPerm returns the mode to create directories with.
+func (p Permissions) DirPerm() os.FileMode {
+	if p.DirMode != 0 {
+		return p.DirMode
+	}
+	return DefaultDirPerm
+}
+
+// ApplyFile sets the policy's mode and owner on a created file. The mode is
+// set explicitly so the umask does not narrow it.
+func (p Permissions) ApplyFile(path string) error {
+	if p.FileMode != 0 {
+		if err := os.Chmod(path, p.FileMode); err != nil {
+			return fmt.Errorf("setting mode of %q: %w", path, err)
+		}
+	}
+	return p.chown(path)
+}
+
+// applyDir sets the policy's mode and owner on a created directory.
+func (p Permissions) applyDir(path string) error {
+	if p.DirMode != 0 {
+		if err := os.Chmod(path, p.DirMode); err != nil {
+			return fmt.Errorf("setting mode of %q: %w", path, err)
+		}
+	}
+	return p.chown(path)
+}
+
+func (p Permissions) chown(path string) error {
+	if p.Owner == nil {
+		return nil
+	}
+	if err := os.Lchown(path, p.Owner.UID, p.Owner.GID); err != nil {
+		return fmt.Errorf("changing owner of %q: %w", path, err)
+	}
+	return nil
+}
+
+// ParseMode parses an octal permission mode such as "0644" or "755".
+func ParseMode(s string) (os.FileMode, error) {
+	v, err := strconv.ParseUint(strings.TrimPrefix(s, "0o"), 8, 32)
+	if err != nil || v == 0 || v > 0o7777 {
+		return 0, fmt.Errorf("invalid mode %q (want octal, e.g. 0644)", s)
+	}
+	return os.FileMode(v), nil
+}
+
+// ParseOwner parses "user:group", "user" or ":group", where each part is a
+// name or a numeric ID.
+func ParseOwner(s string) (*Owner, error) {
+	if runtime.GOOS == "windows" {
+		return nil, fmt.Errorf("changing ownership is not supported on Windows")
+	}
+	userPart, groupPart, _ := strings.Cut(s, ":")
+	if userPart == "" && groupPart == "" {
+		return nil, fmt.Errorf("invalid owner %q (want user:group)", s)
+	}
+
+	o := &Owner{UID: -1, GID: -1}
+	if userPart != "" {
+		id, err := lookupID(userPart, func(name string) (string, error) {
+			u, err := user.Lookup(name)
+			if err != nil {
+				return "", err
+			}
+			return u.Uid, nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("unknown user %q: %w", userPart, err)
+		}
+		o.UID = id
+	}
+	if groupPart != "" {
+		id, err := lookupID(groupPart, func(name string) (string, error) {
+			g, err := user.LookupGroup(name)
+			if err != nil {
+				return "", err
+			}
+			return g.Gid, nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("unknown group %q: %w", groupPart, err)
+		}
+		o.GID = id
+	}
+	return o, nil
+}
+
+func lookupID(s string, lookup func(string) (string, error)) (int, error) {
+	if id, err := strconv.Atoi(s); err == nil && id >= 0 {
+		return id, nil
+	}
+	idStr, err := lookup(s)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(idStr)
+}
+
+// PermissionsOf returns the policy fs applies, or the zero policy.
+func PermissionsOf(fs FilesService) Permissions {
+	if h, ok := fs.(interface{ Permissions() Permissions }); ok {
+		return h.Permissions()
+	}
+	return Permissions{}
+}
+
+// WithPermissions returns fs with p applied to the files and directories it
+// creates: Copy and EnsureDir apply it directly, and transactions created
+// from the result apply it to their operations. fs is returned unchanged for
+// the zero policy.
+func WithPermissions(fs FilesService, p Permissions) FilesService {
+	if p.IsDefault() {
+		return fs
+	}
+	return &permissionedFiles{wrapped: wrapped{fs}, perms: p}
+}
+
+// permissionedFiles decorates a FilesService with a permission policy.
+type permissionedFiles struct {
+	wrapped
+	perms Permissions
+}
+
+func (pf *permissionedFiles) Permissions() Permissions {
+	return pf.perms
+}
+
+// EnsureDir creates path and applies the policy to every directory it had
+// to create. perm is superseded by the policy's directory mode.
+func (pf *permissionedFiles) EnsureDir(path string, perm os.FileMode) error {
+	missing := missingDirs(FSOf(pf.FilesService), path)
+	if err := pf.FilesService.EnsureDir(path, pf.perms.DirPerm()); err != nil {
+		return err
+	}
+	// Parents first, so a restrictive owner change never locks us out of
+	// a child we still have to adjust.
+	for i := len(missing) - 1; i >= 0; i-- {
+		if err := pf.perms.applyDir(missing[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (pf *permissionedFiles) Copy(src, dst string) error {
+	if err := pf.EnsureDir(filepath.Dir(dst), pf.perms.DirPerm()); err != nil {
+		return err
+	}
+	if err := pf.FilesService.Copy(src, dst); err != nil {
+		return err
+	}
+	if err := pf.perms.ApplyFile(dst); err != nil {
+		os.Remove(dst)
+		return err
+	}
+	return nil
+}
+
+func (pf *permissionedFiles) NewTransaction(overwrite bool) Transaction {
+	return NewTransaction(pf, overwrite)
+}
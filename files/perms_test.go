@@ -0,0 +1,113 @@
+package files
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+func TestParseMode(t *testing.T) {
+	for in, want := range map[string]os.FileMode{"0644": 0o644, "755": 0o755, "0o600": 0o600, "2775": 0o2775} {
+		if got, err := ParseMode(in); err != nil || got != want {
+			t.Errorf("ParseMode(%q) = %o, %v; want %o", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "0", "rw-r--r--", "0888", "17777"} {
+		if _, err := ParseMode(in); err == nil {
+			t.Errorf("ParseMode(%q): expected error", in)
+		}
+	}
+}
+
+func TestParseOwner(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("ownership is not supported on Windows")
+	}
+	o, err := ParseOwner("1000:100")
+	if err != nil || *o != (Owner{UID: 1000, GID: 100}) {
+		t.Errorf("ParseOwner(1000:100) = %+v, %v", o, err)
+	}
+	o, err = ParseOwner(":100")
+	if err != nil || *o != (Owner{UID: -1, GID: 100}) {
+		t.Errorf("ParseOwner(:100) = %+v, %v", o, err)
+	}
+	if _, err := ParseOwner("no-such-user-gocamelpack"); err == nil {
+		t.Error("expected unknown user to be rejected")
+	}
+	if _, err := ParseOwner(":"); err == nil {
+		t.Error("expected empty owner to be rejected")
+	}
+}
+
+func modeOf(t *testing.T, path string) os.FileMode {
+	t.Helper()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return info.Mode().Perm()
+}
+
+func TestWithPermissions_Copy(t *testing.T) {
+	tmp := testutil.TempDir(t)
+	src := filepath.Join(tmp, "src.txt")
+	if err := os.WriteFile(src, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	fs := WithPermissions(newFiles(), Permissions{FileMode: 0o600, DirMode: 0o750})
+	dst := filepath.Join(tmp, "out", "a", "dst.txt")
+	if err := fs.Copy(src, dst); err != nil {
+		t.Fatalf("Copy: %v", err)
+	}
+	if got := modeOf(t, dst); got != 0o600 {
+		t.Errorf("file mode = %o, want 600", got)
+	}
+	for _, dir := range []string{filepath.Join(tmp, "out"), filepath.Join(tmp, "out", "a")} {
+		if got := modeOf(t, dir); got != 0o750 {
+			t.Errorf("%s mode = %o, want 750", dir, got)
+		}
+	}
+	if got := modeOf(t, tmp); got == 0o750 {
+		t.Error("pre-existing directory must not be changed")
+	}
+}
+
+func TestWithPermissions_TransactionalMove(t *testing.T) {
+	tmp := testutil.TempDir(t)
+	src := filepath.Join(tmp, "src.txt")
+	if err := os.WriteFile(src, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	perms := Permissions{FileMode: 0o640, DirMode: 0o700}
+	if runtime.GOOS != "windows" {
+		perms.Owner = &Owner{UID: os.Getuid(), GID: os.Getgid()}
+	}
+	fs := WithPermissions(newFiles(), perms)
+	dst := filepath.Join(tmp, "moved", "dst.txt")
+
+	tx := fs.NewTransaction(false)
+	if err := tx.Add(NewMoveOperation(src, dst)); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Execute(); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if got := modeOf(t, dst); got != 0o640 {
+		t.Errorf("moved file mode = %o, want 640", got)
+	}
+	if got := modeOf(t, filepath.Dir(dst)); got != 0o700 {
+		t.Errorf("created directory mode = %o, want 700", got)
+	}
+}
+
+func TestWithPermissions_DefaultIsUnwrapped(t *testing.T) {
+	f := newFiles()
+	if WithPermissions(f, Permissions{}) != FilesService(f) {
+		t.Error("zero policy should return the service unchanged")
+	}
+}
@@ -0,0 +1,71 @@
+//go:build !windows
+
+package files
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+func TestDefaultDirPermFollowsUmask(t *testing.T) {
+	old := syscall.Umask(0o027)
+	defer syscall.Umask(old)
+
+	tmp := testutil.TempDir(t)
+	src := filepath.Join(tmp, "src.txt")
+	if err := os.WriteFile(src, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Both the plain copy and a transactional move create directories.
+	f := newFiles()
+	if err := f.Copy(src, filepath.Join(tmp, "copied", "dst.txt")); err != nil {
+		t.Fatal(err)
+	}
+	tx := f.NewTransaction(false)
+	if err := tx.Add(NewMoveOperation(src, filepath.Join(tmp, "moved", "dst.txt"))); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, dir := range []string{"copied", "moved"} {
+		if got := modeOf(t, filepath.Join(tmp, dir)); got != 0o750 {
+			t.Errorf("%s: mode = %o, want 750 under umask 027", dir, got)
+		}
+	}
+}
+
+func TestRunAs_CheckWritable(t *testing.T) {
+	dir := filepath.Join(testutil.TempDir(t), "archive")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	owner, group, _ := fileOwner(info)
+	other := &RunAs{Name: "other", Owner: Owner{UID: owner + 1, GID: group + 1}, groups: map[int]bool{group + 1: true}}
+	if err := other.CheckWritable(filepath.Join(dir, "2025", "01")); err == nil {
+		t.Error("expected a 0755 folder of another user to be refused")
+	}
+	if err := os.Chmod(dir, 0o777); err != nil {
+		t.Fatal(err)
+	}
+	if err := other.CheckWritable(filepath.Join(dir, "2025", "01")); err != nil {
+		t.Errorf("a world-writable folder should be accepted: %v", err)
+	}
+	if err := os.Chmod(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	self := &RunAs{Name: "self", Owner: Owner{UID: owner, GID: group}, groups: map[int]bool{group: true}}
+	if err := self.CheckWritable(dir); err != nil {
+		t.Errorf("the folder's owner should be accepted: %v", err)
+	}
+}
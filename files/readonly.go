@@ -0,0 +1,40 @@
+package files
+
+import "path/filepath"
+
+// writableCheck is replaceable in tests, which cannot mount a read-only
+// file system.
+var writableCheck = dirWritable
+
+// CheckWritable fails with ErrReadOnly when dir, or the nearest existing
+// directory above it, lives on a read-only file system. It does not write
+// anything, so it is safe during dry runs.
+func CheckWritable(dir string) error {
+	probeDir, err := existingDir(dir)
+	if err != nil {
+		// Nothing to probe; creating the directory will report the problem.
+		return nil
+	}
+	if err := writableCheck(probeDir); err != nil && isReadOnlyErr(err) {
+		return Errorf(ErrReadOnly, "destination %q is on a read-only file system; remount it read-write or choose another destination", dir)
+	}
+	return nil
+}
+
+// writableChecker caches CheckWritable per existing directory, so planning
+// many files into the same tree probes each directory once.
+type writableChecker map[string]error
+
+func (c writableChecker) check(dst string) error {
+	dir := filepath.Dir(dst)
+	probeDir, err := existingDir(dir)
+	if err != nil {
+		return nil
+	}
+	if err, ok := c[probeDir]; ok {
+		return err
+	}
+	err = CheckWritable(probeDir)
+	c[probeDir] = err
+	return err
+}
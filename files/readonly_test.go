@@ -0,0 +1,72 @@
+package files
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+// fakeReadOnly makes every directory look read-only until the test ends.
+func fakeReadOnly(t *testing.T) {
+	t.Helper()
+	orig := writableCheck
+	writableCheck = func(string) error { return &os.PathError{Op: "access", Path: "x", Err: readOnlyErrno} }
+	t.Cleanup(func() { writableCheck = orig })
+}
+
+func TestCheckWritable(t *testing.T) {
+	dir := testutil.TempDir(t)
+	if err := CheckWritable(filepath.Join(dir, "not", "yet")); err != nil {
+		t.Fatalf("writable directory reported as %v", err)
+	}
+
+	fakeReadOnly(t)
+	err := CheckWritable(filepath.Join(dir, "not", "yet"))
+	if !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("expected ErrReadOnly, got %v", err)
+	}
+	if ErrorCode(err) != CodeReadOnly {
+		t.Errorf("ErrorCode = %q, want %q", ErrorCode(err), CodeReadOnly)
+	}
+}
+
+func TestErrorCode_ReadOnlyErrno(t *testing.T) {
+	err := fmt.Errorf("create: %w", &os.PathError{Op: "open", Path: "/mnt/x", Err: readOnlyErrno})
+	if ErrorCode(err) != CodeReadOnly {
+		t.Errorf("ErrorCode = %q, want %q", ErrorCode(err), CodeReadOnly)
+	}
+	if ErrorCode(syscall.ENOENT) == CodeReadOnly {
+		t.Error("unrelated errno classified as read-only")
+	}
+}
+
+func TestTransactionValidate_ReadOnlyDestination(t *testing.T) {
+	f := newFiles()
+	tmp := testutil.TempDir(t)
+	src := filepath.Join(tmp, "src.txt")
+	if err := os.WriteFile(src, []byte("x"), filePermRW); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, overwrite := range []bool{false, true} {
+		t.Run(fmt.Sprintf("overwrite=%v", overwrite), func(t *testing.T) {
+			tx := f.NewTransaction(overwrite)
+			if err := tx.Add(NewCopyOperation(src, filepath.Join(tmp, "out", "dst.txt"))); err != nil {
+				t.Fatal(err)
+			}
+			if err := tx.Validate(); err != nil {
+				t.Fatalf("unexpected error on writable destination: %v", err)
+			}
+
+			fakeReadOnly(t)
+			if err := tx.Validate(); !errors.Is(err, ErrReadOnly) {
+				t.Errorf("expected ErrReadOnly, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+//go:build !windows
+
+package files
+
+import (
+	"errors"
+	"syscall"
+)
+
+// wOK is access(2)'s W_OK, which package syscall does not export.
+const wOK = 0x2
+
+// dirWritable asks the kernel whether dir could be written, without writing.
+// access(2) reports EROFS for read-only mounts even to root.
+func dirWritable(dir string) error {
+	return syscall.Access(dir, wOK)
+}
+
+// isReadOnlyErr reports whether err was caused by a read-only file system.
+func isReadOnlyErr(err error) bool {
+	return errors.Is(err, syscall.EROFS)
+}
+
+// readOnlyErrno is the error a read-only file system reports, for tests.
+const readOnlyErrno = syscall.EROFS
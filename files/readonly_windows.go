@@ -0,0 +1,23 @@
+//go:build windows
+
+package files
+
+import (
+	"errors"
+	"syscall"
+)
+
+// errorWriteProtect is ERROR_WRITE_PROTECT, returned for read-only media.
+const errorWriteProtect = syscall.Errno(19)
+
+// dirWritable cannot be answered without writing on Windows; read-only
+// volumes are still recognised from the errors of the first write.
+func dirWritable(dir string) error { return nil }
+
+// isReadOnlyErr reports whether err was caused by read-only media.
+func isReadOnlyErr(err error) bool {
+	return errors.Is(err, errorWriteProtect)
+}
+
+// readOnlyErrno is the error read-only media report, for tests.
+const readOnlyErrno = errorWriteProtect
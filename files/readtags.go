@@ -0,0 +1,132 @@
+package files
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/barasher/go-exiftool"
+)
+
+// ReadOptions selects how tags are extracted by TagReader implementations.
+type ReadOptions struct {
+	// Groups restricts output to these exiftool family-0 groups (EXIF, XMP,
+	// QuickTime, Composite, …). When set, tag names are group-qualified,
+	// e.g. "EXIF:Model".
+	Groups []string
+
+	// Raw reports machine-readable values (exiftool -n) instead of the
+	// human-readable print conversions.
+	Raw bool
+
+	// Tags restricts extraction to these tags (exiftool -TAG), which is
+	// much faster than extracting all of them.
+	Tags []string
+}
+
+// IsDefault reports whether opts matches the behaviour of GetFileTags.
+func (o ReadOptions) IsDefault() bool {
+	return len(o.Groups) == 0 && !o.Raw && len(o.Tags) == 0
+}
+
+// TagReader is implemented by services that can extract tags with
+// non-default exiftool options.
+type TagReader interface {
+	ReadTags(paths []string, opts ReadOptions) ([]FileMetadata, error)
+}
+
+// ReadTags extracts tags from paths with opts if fs supports it.
+func ReadTags(fs FilesService, paths []string, opts ReadOptions) ([]FileMetadata, error) {
+	tr, ok := fs.(TagReader)
+	if !ok {
+		return nil, fmt.Errorf("files service does not support reading tag groups")
+	}
+	return tr.ReadTags(paths, opts)
+}
+
+// ReadTags extracts tags according to opts. Non-default options need their
+// own exiftool process, since output flags are fixed when it starts.
+func (f *Files) ReadTags(paths []string, opts ReadOptions) ([]FileMetadata, error) {
+	if opts.IsDefault() {
+		return f.GetFileTags(paths), nil
+	}
+	if len(opts.Tags) > 0 {
+		return f.readSelected(paths, opts)
+	}
+
+	var etOpts []func(*exiftool.Exiftool) error
+	if len(opts.Groups) > 0 {
+		etOpts = append(etOpts, exiftool.PrintGroupNames("0"))
+	}
+	if opts.Raw {
+		etOpts = append(etOpts, exiftool.NoPrintConversion())
+	}
+
+	et, err := exiftool.NewExiftool(etOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("error intializing exiftool: %v", err)
+	}
+	defer et.Close()
+
+	var result []FileMetadata
+	for _, r := range et.ExtractMetadata(paths...) {
+		tags := make(map[string]string)
+		for k, v := range r.Fields {
+			tags[k] = fmt.Sprintf("%v", v)
+		}
+		result = append(result, FileMetadata{Filepath: r.File, Tags: tags})
+	}
+	return FilterGroups(result, opts.Groups), nil
+}
+
+// FilterGroups keeps only group-qualified tags ("GROUP:Name") whose group is
+// listed in groups, compared case-insensitively. An empty groups list
+// returns md unchanged.
+func FilterGroups(md []FileMetadata, groups []string) []FileMetadata {
+	if len(groups) == 0 {
+		return md
+	}
+
+	want := make(map[string]bool, len(groups))
+	for _, g := range groups {
+		want[strings.ToLower(strings.TrimSpace(g))] = true
+	}
+
+	out := make([]FileMetadata, len(md))
+	for i, m := range md {
+		tags := make(map[string]string)
+		for k, v := range m.Tags {
+			group, _, ok := strings.Cut(k, ":")
+			if ok && want[strings.ToLower(group)] {
+				tags[k] = v
+			}
+		}
+		out[i] = FileMetadata{Filepath: m.Filepath, Tags: tags}
+	}
+	return out
+}
+
+// readSelected is ReadTags for options that select tags, which go-exiftool
+// cannot pass.
+func (f *Files) readSelected(paths []string, opts ReadOptions) ([]FileMetadata, error) {
+	args, err := tagArgs(opts.Tags)
+	if err != nil {
+		return nil, err
+	}
+	if len(opts.Groups) > 0 {
+		args = append(args, "-G0")
+	}
+	if opts.Raw {
+		args = append(args, "-n")
+	}
+
+	s, err := startTagSession()
+	if err != nil {
+		return nil, err
+	}
+	defer s.close()
+	md, err := s.extract(args, paths)
+	if err != nil {
+		return nil, err
+	}
+	return FilterGroups(md, opts.Groups), nil
+}
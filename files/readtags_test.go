@@ -0,0 +1,72 @@
+package files
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFilterGroups(t *testing.T) {
+	md := []FileMetadata{{
+		Filepath: "/a.jpg",
+		Tags: map[string]string{
+			"EXIF:Model":          "X100V",
+			"XMP:Rating":          "5",
+			"QuickTime:Duration":  "3.2",
+			"Composite:ImageSize": "6240x4160",
+			"SourceFile":          "/a.jpg",
+		},
+	}}
+
+	tests := []struct {
+		name   string
+		groups []string
+		want   map[string]string
+	}{
+		{
+			name:   "single group",
+			groups: []string{"EXIF"},
+			want:   map[string]string{"EXIF:Model": "X100V"},
+		},
+		{
+			name:   "case-insensitive and trimmed",
+			groups: []string{" xmp", "composite "},
+			want:   map[string]string{"XMP:Rating": "5", "Composite:ImageSize": "6240x4160"},
+		},
+		{
+			name:   "unknown group",
+			groups: []string{"MakerNotes"},
+			want:   map[string]string{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := FilterGroups(md, tc.groups)
+			if len(got) != 1 || got[0].Filepath != "/a.jpg" {
+				t.Fatalf("unexpected result %+v", got)
+			}
+			if !reflect.DeepEqual(got[0].Tags, tc.want) {
+				t.Errorf("want %v, got %v", tc.want, got[0].Tags)
+			}
+		})
+	}
+}
+
+func TestFilterGroups_NoGroupsIsIdentity(t *testing.T) {
+	md := []FileMetadata{{Filepath: "/a.jpg", Tags: map[string]string{"Model": "X"}}}
+	if got := FilterGroups(md, nil); !reflect.DeepEqual(got, md) {
+		t.Errorf("expected input unchanged, got %v", got)
+	}
+}
+
+func TestReadOptions_IsDefault(t *testing.T) {
+	if !(ReadOptions{}).IsDefault() {
+		t.Error("zero ReadOptions should be default")
+	}
+	if (ReadOptions{Raw: true}).IsDefault() {
+		t.Error("Raw should not be default")
+	}
+	if (ReadOptions{Groups: []string{"EXIF"}}).IsDefault() {
+		t.Error("Groups should not be default")
+	}
+}
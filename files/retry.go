@@ -0,0 +1,110 @@
+package files
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy retries a failing step when the failure looks transient.
+// The zero value tries once.
+type RetryPolicy struct {
+	// Retries is how many times a step is retried after the first attempt.
+	Retries int
+	// Delay is the wait before the first retry; it doubles for each further
+	// retry.
+	Delay time.Duration
+}
+
+// sleep is replaceable in tests.
+var sleep = time.Sleep
+
+// Do runs fn until it succeeds, fails with a non-transient error, or the
+// retries are exhausted. It returns the number of attempts made.
+func (p RetryPolicy) Do(fn func() error) (attempts int, err error) {
+	delay := p.Delay
+	for {
+		attempts++
+		err = fn()
+		if err == nil || attempts > p.Retries || !Transient(err) {
+			break
+		}
+		sleep(delay)
+		delay *= 2
+	}
+	if err != nil && attempts > 1 {
+		err = fmt.Errorf("%w (after %d attempts)", err, attempts)
+	}
+	return attempts, err
+}
+
+// transientErrnos are system errors that may go away on their own, typically
+// on network or removable storage.
+var transientErrnos = []error{
+	syscall.EIO,
+	syscall.EAGAIN,
+	syscall.ETIMEDOUT,
+	syscall.ECONNRESET,
+	syscall.ECONNABORTED,
+}
+
+// Transient reports whether err is worth retrying: an I/O error or a
+// timeout. Classified errors such as ErrConflict never are.
+func Transient(err error) bool {
+	if err == nil || ErrorCode(err) != CodeUnknown {
+		return false
+	}
+	for _, errno := range transientErrnos {
+		if errors.Is(err, errno) {
+			return true
+		}
+	}
+	var timeout interface{ Timeout() bool }
+	return errors.As(err, &timeout) && timeout.Timeout()
+}
+
+// RetryStats counts retries across a run for the summary. It is safe for
+// concurrent use.
+type RetryStats struct {
+	Retried  int // files that needed more than one attempt
+	Attempts int // attempts made beyond the first
+
+	mu sync.Mutex
+}
+
+// record adds the outcome of one file's attempts.
+func (s *RetryStats) record(attempts int) {
+	if s == nil || attempts <= 1 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Retried++
+	s.Attempts += attempts - 1
+}
+
+// Run retries fn under p and records the attempts in s, which may be nil.
+func (s *RetryStats) Run(p RetryPolicy, fn func() error) error {
+	attempts, err := p.Do(fn)
+	s.record(attempts)
+	return err
+}
+
+// RetryOperation decorates an operation so that its Execute is retried
+// under a RetryPolicy.
+type RetryOperation struct {
+	WrappedOperation
+	policy RetryPolicy
+	stats  *RetryStats
+}
+
+// NewRetryOperation wraps op; attempts are recorded in stats when non-nil.
+func NewRetryOperation(op Operation, policy RetryPolicy, stats *RetryStats) *RetryOperation {
+	return &RetryOperation{WrappedOperation: WrappedOperation{Operation: op}, policy: policy, stats: stats}
+}
+
+func (ro *RetryOperation) Execute(fs FilesService) error {
+	return ro.stats.Run(ro.policy, func() error { return ro.Operation.Execute(fs) })
+}
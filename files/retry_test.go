@@ -0,0 +1,135 @@
+package files
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+// recordSleeps replaces the retry sleep and collects the requested delays.
+func recordSleeps(t *testing.T) *[]time.Duration {
+	t.Helper()
+	var slept []time.Duration
+	orig := sleep
+	sleep = func(d time.Duration) { slept = append(slept, d) }
+	t.Cleanup(func() { sleep = orig })
+	return &slept
+}
+
+func TestRetryPolicy_RetriesTransientErrors(t *testing.T) {
+	slept := recordSleeps(t)
+
+	calls := 0
+	attempts, err := RetryPolicy{Retries: 3, Delay: time.Second}.Do(func() error {
+		calls++
+		if calls < 3 {
+			return &os.PathError{Op: "write", Path: "/nas/x", Err: syscall.EIO}
+		}
+		return nil
+	})
+	if err != nil || attempts != 3 {
+		t.Fatalf("Do = %d, %v; want 3 attempts and success", attempts, err)
+	}
+	if want := []time.Duration{time.Second, 2 * time.Second}; len(*slept) != 2 || (*slept)[0] != want[0] || (*slept)[1] != want[1] {
+		t.Errorf("backoff delays = %v, want %v", *slept, want)
+	}
+}
+
+func TestRetryPolicy_GivesUp(t *testing.T) {
+	recordSleeps(t)
+
+	calls := 0
+	attempts, err := RetryPolicy{Retries: 2}.Do(func() error {
+		calls++
+		return syscall.ETIMEDOUT
+	})
+	if attempts != 3 || calls != 3 {
+		t.Errorf("attempts = %d, calls = %d; want 3", attempts, calls)
+	}
+	if !errors.Is(err, syscall.ETIMEDOUT) || !strings.Contains(err.Error(), "after 3 attempts") {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestRetryPolicy_DoesNotRetryPermanentErrors(t *testing.T) {
+	recordSleeps(t)
+
+	calls := 0
+	_, err := RetryPolicy{Retries: 5}.Do(func() error {
+		calls++
+		return Errorf(ErrConflict, "exists")
+	})
+	if calls != 1 {
+		t.Errorf("permanent error retried: %d calls", calls)
+	}
+	if err.Error() != "exists" {
+		t.Errorf("single attempt should not annotate the error, got %q", err)
+	}
+}
+
+func TestTransient(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{errors.New("boom"), false},
+		{&os.PathError{Op: "read", Path: "x", Err: syscall.EIO}, true},
+		{syscall.ECONNRESET, true},
+		{os.ErrDeadlineExceeded, true},
+		{syscall.ENOENT, false},
+		{Errorf(ErrConflict, "x"), false},
+	}
+	for _, tc := range tests {
+		if got := Transient(tc.err); got != tc.want {
+			t.Errorf("Transient(%v) = %v, want %v", tc.err, got, tc.want)
+		}
+	}
+}
+
+// flakyOperation fails its first failures executions with EIO.
+type flakyOperation struct {
+	Operation
+	failures int
+}
+
+func (fo *flakyOperation) Execute(fs FilesService) error {
+	if fo.failures > 0 {
+		fo.failures--
+		return syscall.EIO
+	}
+	return fo.Operation.Execute(fs)
+}
+
+func TestRetryOperation_InTransaction(t *testing.T) {
+	recordSleeps(t)
+	f := newFiles()
+	tmp := testutil.TempDir(t)
+	src := filepath.Join(tmp, "src.txt")
+	dst := filepath.Join(tmp, "out", "dst.txt")
+	if err := os.WriteFile(src, []byte("x"), filePermRW); err != nil {
+		t.Fatal(err)
+	}
+
+	stats := &RetryStats{}
+	tx := f.NewTransaction(false)
+	op := &flakyOperation{Operation: NewCopyOperation(src, dst), failures: 2}
+	if err := tx.Add(NewRetryOperation(op, RetryPolicy{Retries: 2}, stats)); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Execute(); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if _, err := os.Stat(dst); err != nil {
+		t.Errorf("destination missing after retried copy: %v", err)
+	}
+	if *stats != (RetryStats{Retried: 1, Attempts: 2}) {
+		t.Errorf("stats = %+v", stats)
+	}
+}
@@ -6,9 +6,9 @@ import (
 	"io"
 	"os"
 	"path/filepath"
-	"strings"
-	"time"
+	"sync"
 
+	"github.com/Tmunayyer/gocamelpack/vfs"
 	"github.com/barasher/go-exiftool"
 )
 
@@ -33,6 +33,13 @@ type FilesService interface {
 type Files struct {
 	et *exiftool.Exiftool
 	pr PathResolver
+	// fsys is what files are read and written through; nil is the real
+	// disk.
+	fsys vfs.FS
+
+	// sel extracts chosen tags for SelectTags; started on first use.
+	selMu sync.Mutex
+	sel   *tagSession
 }
 
 func CreateFiles() (*Files, error) {
@@ -67,10 +74,24 @@ func (f *Files) GetFileTags(files []string) []FileMetadata {
 
 func (f *Files) Close() {
 	f.et.Close()
+	f.selMu.Lock()
+	defer f.selMu.Unlock()
+	if f.sel != nil {
+		f.sel.close()
+		f.sel = nil
+	}
+}
+
+// FS returns the file system f reads and writes through.
+func (f *Files) FS() vfs.FS {
+	if f.fsys == nil {
+		return vfs.OS
+	}
+	return f.fsys
 }
 
 func (f *Files) IsFile(path string) bool {
-	info, err := os.Stat(path)
+	info, err := f.FS().Stat(path)
 	if err != nil {
 		return false // File does not exist or other error
 	}
@@ -78,7 +99,7 @@ func (f *Files) IsFile(path string) bool {
 }
 
 func (f *Files) IsDirectory(path string) bool {
-	info, err := os.Stat(path)
+	info, err := f.FS().Stat(path)
 	if err != nil {
 		return false
 	}
@@ -86,7 +107,7 @@ func (f *Files) IsDirectory(path string) bool {
 }
 
 func (f *Files) ReadDirectory(dirPath string) ([]string, error) {
-	entries, err := os.ReadDir(dirPath)
+	entries, err := f.FS().ReadDir(dirPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read directory: %w", err)
 	}
@@ -104,18 +125,12 @@ func (f *Files) ReadDirectory(dirPath string) ([]string, error) {
 func (f *Files) DestinationFromMetadata(md FileMetadata, baseDir string) (string, error) {
 	raw := md.Tags["CreationDate"]
 	if raw == "" {
-		return "", fmt.Errorf("CreationDate is missing")
+		return "", Errorf(ErrNoDate, "CreationDate is missing")
 	}
 
-	// Normalize to RFC3339-like format
-	// From: 2025:01:27 07:31:15-06:00
-	// To:   2025-01-27T07:31:15-06:00
-	rfcish := strings.Replace(raw, ":", "-", 2)
-	rfcish = strings.Replace(rfcish, " ", "T", 1)
-
-	t, err := time.Parse(time.RFC3339, rfcish)
+	t, err := ParseExifDate(raw)
 	if err != nil {
-		return "", fmt.Errorf("failed to parse CreationDate %q: %w", raw, err)
+		return "", Errorf(ErrNoDate, "failed to parse CreationDate %q: %v", raw, err)
 	}
 
 	year, month, day := t.Date()
@@ -140,7 +155,7 @@ func (f *Files) EnsureDir(path string, perm os.FileMode) error {
 	if path == "" {
 		return fmt.Errorf("directory path is empty")
 	}
-	if err := os.MkdirAll(path, perm); err != nil {
+	if err := f.FS().MkdirAll(path, perm); err != nil {
 		return fmt.Errorf("creating directory %q: %w", path, err)
 	}
 	return nil
@@ -152,25 +167,40 @@ func (f *Files) ValidateCopyArgs(src, dst string) error {
 		return fmt.Errorf("source and destination must be provided")
 	}
 	if !f.IsFile(src) {
-		return fmt.Errorf("source %q is not a regular file", src)
+		return Errorf(ErrSourceMissing, "source %q is not a regular file", src)
 	}
-	if _, err := os.Stat(dst); err == nil {
-		return fmt.Errorf("destination %q already exists", dst)
+	if _, err := f.FS().Stat(dst); err == nil {
+		return Errorf(ErrConflict, "destination %q already exists", dst)
 	} else if !errors.Is(err, os.ErrNotExist) {
 		return fmt.Errorf("checking destination: %w", err)
 	}
-	return nil
+	if !vfs.OnDisk(f.FS()) {
+		return nil
+	}
+	return CheckWritable(filepath.Dir(dst))
 }
 
 // Copy performs a single‑threaded, safe file copy preserving permissions.
 func (f *Files) Copy(src, dst string) error {
+	return f.CopyBuffer(src, dst, 0)
+}
+
+// CopyBuffer is Copy with the data moved through a size-byte buffer; see
+// CopyBuffered.
+func (f *Files) CopyBuffer(src, dst string, size int) error {
+	return f.CopyTee(src, dst, size, nil)
+}
+
+// CopyTee is CopyBuffer that also writes the data it copies to tee, such
+// as a hash, so the source need not be read again. A nil tee is ignored.
+func (f *Files) CopyTee(src, dst string, size int, tee io.Writer) error {
 	// Basic validations
 	if err := f.ValidateCopyArgs(src, dst); err != nil {
 		return err
 	}
 
 	// Open source
-	in, err := os.Open(src)
+	in, err := f.FS().Open(src)
 	if err != nil {
 		return fmt.Errorf("open %q: %w", src, err)
 	}
@@ -182,12 +212,12 @@ func (f *Files) Copy(src, dst string) error {
 	}
 
 	// Ensure destination directory exists
-	if err := f.EnsureDir(filepath.Dir(dst), 0o755); err != nil {
+	if err := f.EnsureDir(filepath.Dir(dst), DefaultDirPerm); err != nil {
 		return err
 	}
 
 	// Create destination exclusively so we never clobber existing files
-	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_EXCL, srcInfo.Mode())
+	out, err := f.FS().OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_EXCL, srcInfo.Mode())
 	if err != nil {
 		return fmt.Errorf("create %q: %w", dst, err)
 	}
@@ -196,19 +226,23 @@ func (f *Files) Copy(src, dst string) error {
 	defer func() {
 		if copyErr != nil {
 			out.Close()
-			os.Remove(dst)
+			f.FS().Remove(dst)
 		}
 	}()
 
 	// Transfer data
-	if _, copyErr = io.Copy(out, in); copyErr != nil {
+	var w io.Writer = out
+	if tee != nil {
+		w = io.MultiWriter(out, tee)
+	}
+	if _, copyErr = CopyBuffered(w, in, size); copyErr != nil {
 		return fmt.Errorf("copy data: %w", copyErr)
 	}
 
 	// Flush to disk
 	if err = out.Sync(); err != nil {
 		out.Close()
-		os.Remove(dst)
+		f.FS().Remove(dst)
 		return fmt.Errorf("sync %q: %w", dst, err)
 	}
 
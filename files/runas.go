@@ -0,0 +1,213 @@
+package files
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// RunAs is the user a run started as root writes for (--run-as). The run
+// keeps root's rights, for reading and writing alike, but everything it
+// creates is given to the user, and it refuses destinations the user could
+// not write to itself, so a sudo import leaves no root-owned files in the
+// archive. It is not a privilege drop.
+type RunAs struct {
+	Name string
+	Owner
+	groups map[int]bool
+}
+
+// LookupRunAs resolves a user name or numeric ID. Created files get the
+// user's primary group.
+func LookupRunAs(name string) (*RunAs, error) {
+	if runtime.GOOS == "windows" {
+		return nil, fmt.Errorf("running as another user is not supported on Windows")
+	}
+	u, err := user.Lookup(name)
+	if err != nil {
+		if u, err = user.LookupId(name); err != nil {
+			return nil, fmt.Errorf("unknown user %q", name)
+		}
+	}
+	r := &RunAs{Name: u.Username, groups: map[int]bool{}}
+	if r.UID, err = strconv.Atoi(u.Uid); err != nil {
+		return nil, fmt.Errorf("user %q has no numeric ID", name)
+	}
+	if r.GID, err = strconv.Atoi(u.Gid); err != nil {
+		return nil, fmt.Errorf("user %q has no numeric group ID", name)
+	}
+	r.groups[r.GID] = true
+	if gids, err := u.GroupIds(); err == nil {
+		for _, g := range gids {
+			if id, err := strconv.Atoi(g); err == nil {
+				r.groups[id] = true
+			}
+		}
+	}
+	return r, nil
+}
+
+// CheckWritable fails when the user could not create files in dir, or in
+// the nearest existing directory above it.
+func (r *RunAs) CheckWritable(dir string) error {
+	return r.CheckPath(dir)
+}
+
+// CheckPath fails when the user could not create path itself. Every
+// existing folder on the way must let the user through and the nearest one
+// must let the user write, by its mode bits and POSIX ACL. A symbolic link
+// on the way is refused when the user could have planted it, in a folder
+// the user may write to or owned by the user, so root is not led to write
+// where the link points. The files are still written by root: a folder the
+// user may write to can change after the check.
+func (r *RunAs) CheckPath(path string) error {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	dir := string(filepath.Separator)
+	rest := strings.Split(strings.TrimPrefix(path, filepath.VolumeName(path)), string(filepath.Separator))
+	for _, name := range rest {
+		if name == "" {
+			continue
+		}
+		next := filepath.Join(dir, name)
+		info, err := os.Lstat(next)
+		if errors.Is(err, fs.ErrNotExist) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if info.Mode()&fs.ModeSymlink != 0 {
+			parent, err := os.Stat(dir)
+			if err != nil {
+				return err
+			}
+			if r.owns(info) || r.allowed(dir, parent, permWrite) {
+				return fmt.Errorf("%s could have created the symbolic link %s; refusing to write through it", r.Name, next)
+			}
+			if next, err = filepath.EvalSymlinks(next); err != nil {
+				return err
+			}
+			if info, err = os.Stat(next); err != nil {
+				return err
+			}
+		}
+		if !info.IsDir() {
+			break
+		}
+		if !r.allowed(next, info, permSearch) {
+			return fmt.Errorf("%s may not enter %s; give the user access or choose another destination", r.Name, next)
+		}
+		dir = next
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if !r.allowed(dir, info, permWrite) {
+		return fmt.Errorf("%s may not write to %s; give the user write access or choose another destination", r.Name, dir)
+	}
+	return nil
+}
+
+// The permission bits allowed checks, as in an rwx triple.
+const (
+	permWrite  = 0o2
+	permSearch = 0o1
+)
+
+// owns reports whether the user owns the file described by info.
+func (r *RunAs) owns(info fs.FileInfo) bool {
+	uid, _, ok := fileOwner(info)
+	return ok && uid == r.UID
+}
+
+// allowed applies the POSIX ACL of path, or else the owner, group or other
+// bits of info, as the kernel would for the user.
+func (r *RunAs) allowed(path string, info fs.FileInfo, want fs.FileMode) bool {
+	uid, gid, ok := fileOwner(info)
+	if !ok {
+		return true
+	}
+	if acl, ok := accessACL(path); ok {
+		return r.aclAllows(acl, uid, gid, want)
+	}
+	perm := info.Mode().Perm()
+	switch {
+	case uid == r.UID:
+		return perm>>6&want == want
+	case r.groups[gid]:
+		return perm>>3&want == want
+	default:
+		return perm&want == want
+	}
+}
+
+// aclAllows runs the POSIX.1e access check of acl for the user on a file
+// owned by uid and gid.
+func (r *RunAs) aclAllows(acl []aclEntry, uid, gid int, want fs.FileMode) bool {
+	mask := fs.FileMode(0o7)
+	for _, e := range acl {
+		if e.tag == aclMask {
+			mask = e.perm
+		}
+	}
+	grants := func(perm fs.FileMode) bool { return perm&want == want }
+	if uid == r.UID {
+		for _, e := range acl {
+			if e.tag == aclUserObj {
+				return grants(e.perm)
+			}
+		}
+		return false
+	}
+	for _, e := range acl {
+		if e.tag == aclUser && e.id == r.UID {
+			return grants(e.perm & mask)
+		}
+	}
+	inGroup := false
+	for _, e := range acl {
+		if (e.tag == aclGroupObj && r.groups[gid]) || (e.tag == aclGroup && r.groups[e.id]) {
+			inGroup = true
+			if grants(e.perm & mask) {
+				return true
+			}
+		}
+	}
+	if inGroup {
+		return false
+	}
+	for _, e := range acl {
+		if e.tag == aclOther {
+			return grants(e.perm)
+		}
+	}
+	return false
+}
+
+// Chown gives path, and everything below it when it is a directory, to the
+// user. A missing path is not an error.
+func (r *RunAs) Chown(path string) error {
+	err := filepath.WalkDir(path, func(p string, _ fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := os.Lchown(p, r.UID, r.GID); err != nil {
+			return fmt.Errorf("changing owner of %q: %w", p, err)
+		}
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
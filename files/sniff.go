@@ -0,0 +1,162 @@
+package files
+
+import (
+	"bytes"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/Tmunayyer/gocamelpack/vfs"
+)
+
+// FileType is a media format recognized from a file's content.
+type FileType struct {
+	Name string
+	Kind MediaKind
+	// Exts are the extensions the format is stored under, the canonical
+	// one first. Raw formats built on TIFF share TIFF's.
+	Exts []string
+}
+
+// Ext returns the canonical extension of the format.
+func (ft FileType) Ext() string {
+	return ft.Exts[0]
+}
+
+// Accepts reports whether ext (with its dot, any case) is a usual
+// extension for the format.
+func (ft FileType) Accepts(ext string) bool {
+	ext = strings.ToLower(ext)
+	for _, e := range ft.Exts {
+		if e == ext {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	typeJPEG = FileType{"JPEG", MediaImage, []string{".jpg", ".jpeg", ".jpe"}}
+	typePNG  = FileType{"PNG", MediaImage, []string{".png"}}
+	typeGIF  = FileType{"GIF", MediaImage, []string{".gif"}}
+	typeBMP  = FileType{"BMP", MediaImage, []string{".bmp"}}
+	typeWebP = FileType{"WebP", MediaImage, []string{".webp"}}
+	typeTIFF = FileType{"TIFF", MediaImage, []string{".tif", ".tiff", ".dng", ".nef", ".nrw", ".arw", ".srf", ".sr2", ".pef", ".cr2", ".srw", ".3fr", ".erf", ".kdc", ".mos"}}
+	typeCR2  = FileType{"Canon CR2", MediaImage, []string{".cr2"}}
+	typeORF  = FileType{"Olympus ORF", MediaImage, []string{".orf"}}
+	typeRW2  = FileType{"Panasonic RW2", MediaImage, []string{".rw2"}}
+	typeRAF  = FileType{"Fujifilm RAF", MediaImage, []string{".raf"}}
+	typeHEIC = FileType{"HEIF", MediaImage, []string{".heic", ".heif", ".hif"}}
+	typeCR3  = FileType{"Canon CR3", MediaImage, []string{".cr3"}}
+	typeAVIF = FileType{"AVIF", MediaImage, []string{".avif"}}
+	typeMOV  = FileType{"QuickTime", MediaVideo, []string{".mov", ".mp4", ".m4v", ".qt"}}
+	typeMP4  = FileType{"MPEG-4", MediaVideo, []string{".mp4", ".m4v", ".mov", ".3gp", ".3g2"}}
+	type3GP  = FileType{"3GPP", MediaVideo, []string{".3gp", ".3g2", ".mp4"}}
+	typeAVI  = FileType{"AVI", MediaVideo, []string{".avi"}}
+	typeMKV  = FileType{"Matroska", MediaVideo, []string{".mkv", ".webm", ".mka"}}
+	typeWMV  = FileType{"ASF", MediaVideo, []string{".wmv", ".asf"}}
+	typeMTS  = FileType{"AVCHD", MediaVideo, []string{".mts", ".m2ts", ".m2t", ".ts"}}
+)
+
+// sniffLen is how much of a file SniffType needs.
+const sniffLen = 400
+
+// SniffType recognizes the media format of a file from its first bytes.
+// ok is false for content it does not know.
+func SniffType(head []byte) (ft FileType, ok bool) {
+	has := func(off int, magic string) bool {
+		return len(head) >= off+len(magic) && string(head[off:off+len(magic)]) == magic
+	}
+	switch {
+	case has(0, "\xff\xd8\xff"):
+		return typeJPEG, true
+	case has(0, "\x89PNG\r\n\x1a\n"):
+		return typePNG, true
+	case has(0, "GIF87a"), has(0, "GIF89a"):
+		return typeGIF, true
+	case has(0, "BM") && has(6, "\x00\x00\x00\x00"):
+		return typeBMP, true
+	case has(0, "RIFF") && has(8, "WEBP"):
+		return typeWebP, true
+	case has(0, "RIFF") && has(8, "AVI "):
+		return typeAVI, true
+	case has(0, "FUJIFILMCCD-RAW"):
+		return typeRAF, true
+	case has(0, "IIRO"), has(0, "IIRS"), has(0, "MMOR"):
+		return typeORF, true
+	case has(0, "IIU\x00"):
+		return typeRW2, true
+	case has(0, "II*\x00") && has(8, "CR"):
+		return typeCR2, true
+	case has(0, "II*\x00"), has(0, "MM\x00*"):
+		return typeTIFF, true
+	case has(0, "\x1a\x45\xdf\xa3"):
+		return typeMKV, true
+	case has(0, "\x30\x26\xb2\x75\x8e\x66\xcf\x11"):
+		return typeWMV, true
+	case has(4, "ftyp"):
+		return sniffBMFF(string(head[8:min(len(head), 12)]))
+	case transportStream(head):
+		return typeMTS, true
+	}
+	return FileType{}, false
+}
+
+// sniffBMFF tells ISO base media files apart by their major brand.
+func sniffBMFF(brand string) (FileType, bool) {
+	switch brand {
+	case "heic", "heix", "heim", "heis", "hevc", "hevx", "mif1", "msf1":
+		return typeHEIC, true
+	case "avif", "avis":
+		return typeAVIF, true
+	case "crx ":
+		return typeCR3, true
+	case "qt  ":
+		return typeMOV, true
+	case "3gp4", "3gp5", "3gp6", "3g2a":
+		return type3GP, true
+	case "isom", "iso2", "iso4", "iso5", "iso6", "mp41", "mp42", "avc1", "M4V ", "M4VH", "M4VP", "dash", "XAVC", "MSNV":
+		return typeMP4, true
+	}
+	return FileType{}, false
+}
+
+// transportStream recognizes MPEG transport streams, bare (188-byte
+// packets) or as in AVCHD's .mts (192-byte packets with a timestamp).
+func transportStream(head []byte) bool {
+	for _, p := range []struct{ off, size int }{{0, 188}, {4, 192}} {
+		if len(head) >= p.off+2*p.size+1 && head[p.off] == 0x47 && head[p.off+p.size] == 0x47 && head[p.off+2*p.size] == 0x47 {
+			return true
+		}
+	}
+	return false
+}
+
+// DetectType reads the start of the file at path from fsys and sniffs its
+// format.
+func DetectType(fsys vfs.FS, path string) (FileType, bool) {
+	f, err := fsys.Open(path)
+	if err != nil {
+		return FileType{}, false
+	}
+	defer f.Close()
+	var buf bytes.Buffer
+	if _, err := io.CopyN(&buf, f, sniffLen); err != nil && err != io.EOF {
+		return FileType{}, false
+	}
+	return SniffType(buf.Bytes())
+}
+
+// CorrectExt returns name with the extension its content calls for:
+// a missing or unknown extension gets ft's appended, a media extension of
+// another format is replaced. Names whose extension ft accepts are kept.
+func CorrectExt(name string, ft FileType) string {
+	ext := filepath.Ext(name)
+	switch {
+	case ft.Accepts(ext):
+		return name
+	case MediaKindOf(name) != MediaUnknown:
+		return strings.TrimSuffix(name, ext) + ft.Ext()
+	}
+	return name + ft.Ext()
+}
@@ -0,0 +1,86 @@
+package files
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/testutil"
+	"github.com/Tmunayyer/gocamelpack/vfs"
+)
+
+func TestSniffType(t *testing.T) {
+	ts := make([]byte, 400)
+	for _, off := range []int{4, 196, 388} {
+		ts[off] = 0x47
+	}
+	tests := []struct {
+		name string
+		head string
+		want string
+	}{
+		{"jpeg", "\xff\xd8\xff\xe1\x00\x10Exif", "JPEG"},
+		{"png", "\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR", "PNG"},
+		{"gif", "GIF89a\x01\x00", "GIF"},
+		{"webp", "RIFF\x10\x00\x00\x00WEBPVP8 ", "WebP"},
+		{"dng", "II*\x00\x08\x00\x00\x00", "TIFF"},
+		{"cr2", "II*\x00\x10\x00\x00\x00CR\x02\x00", "Canon CR2"},
+		{"heic", "\x00\x00\x00\x18ftypheic\x00\x00\x00\x00", "HEIF"},
+		{"cr3", "\x00\x00\x00\x18ftypcrx \x00\x00\x00\x01", "Canon CR3"},
+		{"mov", "\x00\x00\x00\x14ftypqt  \x00\x00\x02\x00", "QuickTime"},
+		{"mp4", "\x00\x00\x00\x20ftypisom\x00\x00\x02\x00", "MPEG-4"},
+		{"mkv", "\x1a\x45\xdf\xa3\x9f\x42\x86\x81", "Matroska"},
+		{"mts", string(ts), "AVCHD"},
+	}
+	for _, tc := range tests {
+		ft, ok := SniffType([]byte(tc.head))
+		if !ok || ft.Name != tc.want {
+			t.Errorf("%s: got %q (%v), want %q", tc.name, ft.Name, ok, tc.want)
+		}
+	}
+
+	for _, head := range []string{"", "hello, world", "\x00\x00\x00\x18ftypzzzz", "BM"} {
+		if ft, ok := SniffType([]byte(head)); ok {
+			t.Errorf("%q sniffed as %s", head, ft.Name)
+		}
+	}
+}
+
+func TestCorrectExt(t *testing.T) {
+	tests := []struct {
+		name string
+		ft   FileType
+		want string
+	}{
+		{"IMG_0001", typeJPEG, "IMG_0001.jpg"},
+		{"IMG_0001.JPG", typeJPEG, "IMG_0001.JPG"},
+		{"IMG_0001.jpeg", typeJPEG, "IMG_0001.jpeg"},
+		{"IMG_0001.png", typeJPEG, "IMG_0001.jpg"},
+		{"clip.mp4", typeMOV, "clip.mp4"},
+		{"photo.2025", typeHEIC, "photo.2025.heic"},
+		{"raw.dng", typeTIFF, "raw.dng"},
+	}
+	for _, tc := range tests {
+		if got := CorrectExt(tc.name, tc.ft); got != tc.want {
+			t.Errorf("CorrectExt(%q, %s) = %q, want %q", tc.name, tc.ft.Name, got, tc.want)
+		}
+	}
+}
+
+func TestDetectType(t *testing.T) {
+	dir := filepath.Join(testutil.TempDir(t), "sniff")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "IMG_0001")
+	if err := os.WriteFile(path, []byte("\x89PNG\r\n\x1a\n"+strings.Repeat("x", 1000)), filePermRW); err != nil {
+		t.Fatal(err)
+	}
+	if ft, ok := DetectType(vfs.OS, path); !ok || ft.Ext() != ".png" {
+		t.Errorf("DetectType = %+v, %v", ft, ok)
+	}
+	if _, ok := DetectType(vfs.OS, filepath.Join(dir, "missing")); ok {
+		t.Error("a missing file should not be detected")
+	}
+}
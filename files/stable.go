@@ -0,0 +1,95 @@
+package files
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// StabilityCheck finds source files that are still being written, e.g. by a
+// card reader or another program still copying into a watched folder.
+type StabilityCheck struct {
+	// Wait is the time between the two size/modification-time checks.
+	Wait time.Duration
+	// Probe additionally treats files another process holds open (lsof) or
+	// locked (flock) as still being written.
+	Probe bool
+}
+
+// fileState is what two checks compare.
+type fileState struct {
+	size    int64
+	modTime time.Time
+}
+
+func statFile(path string) (fileState, bool) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileState{}, false
+	}
+	return fileState{size: info.Size(), modTime: info.ModTime()}, true
+}
+
+// Split partitions paths into files that look complete and files that
+// changed (or disappeared) during the wait or are held open elsewhere. It
+// waits once for the whole batch.
+func (c StabilityCheck) Split(paths []string) (stable, unstable []string) {
+	before := make(map[string]fileState, len(paths))
+	for _, p := range paths {
+		if st, ok := statFile(p); ok {
+			before[p] = st
+		}
+	}
+	if c.Wait > 0 {
+		sleep(c.Wait)
+	}
+
+	for _, p := range paths {
+		prev, ok := before[p]
+		now, ok2 := statFile(p)
+		if !ok || !ok2 || now != prev || (c.Probe && fileLocked(p)) {
+			unstable = append(unstable, p)
+			continue
+		}
+		stable = append(stable, p)
+	}
+
+	if c.Probe && len(stable) > 0 {
+		open := openFiles(stable)
+		if len(open) > 0 {
+			kept := stable[:0]
+			for _, p := range stable {
+				if open[p] {
+					unstable = append(unstable, p)
+				} else {
+					kept = append(kept, p)
+				}
+			}
+			stable = kept
+		}
+	}
+	return stable, unstable
+}
+
+// openFiles asks lsof which of paths some process has open. It returns nil
+// when lsof is not installed.
+func openFiles(paths []string) map[string]bool {
+	lsof, err := exec.LookPath("lsof")
+	if err != nil {
+		return nil
+	}
+	// -F n prints one "n<path>" line per open file; lsof exits 1 when none
+	// of the files are open, which is not an error here.
+	out, _ := exec.Command(lsof, append([]string{"-F", "n", "--"}, paths...)...).Output()
+
+	open := map[string]bool{}
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		if line := sc.Text(); len(line) > 1 && line[0] == 'n' {
+			open[line[1:]] = true
+		}
+	}
+	return open
+}
@@ -0,0 +1,43 @@
+package files
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+func TestStabilityCheck_Split(t *testing.T) {
+	tmp := testutil.TempDir(t)
+	done := filepath.Join(tmp, "done.jpg")
+	growing := filepath.Join(tmp, "growing.mov")
+	vanishing := filepath.Join(tmp, "vanishing.jpg")
+	for _, p := range []string{done, growing, vanishing} {
+		if err := os.WriteFile(p, []byte("data"), filePermRW); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Simulate other writers making progress while the check waits.
+	orig := sleep
+	sleep = func(d time.Duration) {
+		if d != 2*time.Second {
+			t.Errorf("waited %v, want 2s", d)
+		}
+		f, _ := os.OpenFile(growing, os.O_APPEND|os.O_WRONLY, 0)
+		f.Write([]byte("more"))
+		f.Close()
+		os.Remove(vanishing)
+	}
+	defer func() { sleep = orig }()
+
+	stable, unstable := StabilityCheck{Wait: 2 * time.Second}.Split([]string{done, growing, vanishing, filepath.Join(tmp, "missing")})
+	if len(stable) != 1 || stable[0] != done {
+		t.Errorf("stable = %v, want only %s", stable, done)
+	}
+	if len(unstable) != 3 {
+		t.Errorf("unstable = %v, want the growing, vanished and missing files", unstable)
+	}
+}
@@ -0,0 +1,43 @@
+//go:build !windows
+
+package files
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+func TestStabilityCheck_ProbeSkipsLockedFiles(t *testing.T) {
+	tmp := testutil.TempDir(t)
+	locked := filepath.Join(tmp, "locked.jpg")
+	free := filepath.Join(tmp, "free.jpg")
+	for _, p := range []string{locked, free} {
+		if err := os.WriteFile(p, []byte("data"), filePermRW); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A writer holding an exclusive lock while it produces the file.
+	w, err := os.OpenFile(locked, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if err := syscall.Flock(int(w.Fd()), syscall.LOCK_EX); err != nil {
+		t.Skipf("flock unavailable: %v", err)
+	}
+
+	stable, unstable := StabilityCheck{Probe: true}.Split([]string{locked, free})
+	if len(unstable) == 0 || unstable[0] != locked {
+		t.Errorf("unstable = %v, want %s", unstable, locked)
+	}
+	// lsof, when installed, also sees the writer's descriptor; the free
+	// file must be kept either way.
+	if len(stable) != 1 || stable[0] != free {
+		t.Errorf("stable = %v, want only %s", stable, free)
+	}
+}
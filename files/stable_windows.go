@@ -0,0 +1,6 @@
+//go:build windows
+
+package files
+
+// fileLocked has no flock equivalent to probe on Windows.
+func fileLocked(path string) bool { return false }
@@ -0,0 +1,16 @@
+//go:build !windows
+
+package files
+
+import "os"
+
+// syncDir flushes a directory's entries to disk so a rename into or out of
+// it survives a crash.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
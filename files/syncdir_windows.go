@@ -0,0 +1,7 @@
+//go:build windows
+
+package files
+
+// syncDir is a no-op on Windows, where directory handles cannot be synced
+// and NTFS journals renames itself.
+func syncDir(dir string) error { return nil }
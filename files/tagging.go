@@ -0,0 +1,143 @@
+package files
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/Tmunayyer/gocamelpack/vfs"
+	"github.com/barasher/go-exiftool"
+)
+
+// TagWriter is implemented by services that can write metadata tags back into
+// files. It is kept separate from FilesService so read-only implementations
+// do not have to provide it.
+type TagWriter interface {
+	WriteTags(path string, tags map[string]string) error
+}
+
+// WriteTags writes tags into path using exiftool's write mode. The file is
+// modified in place; no "_original" backup is left behind.
+func (f *Files) WriteTags(path string, tags map[string]string) error {
+	md := exiftool.EmptyFileMetadata()
+	md.File = path
+	for k, v := range tags {
+		md.SetString(k, v)
+	}
+
+	batch := []exiftool.FileMetadata{md}
+	f.et.WriteMetadata(batch)
+	if batch[0].Err != nil {
+		return fmt.Errorf("writing tags to %q: %w", path, batch[0].Err)
+	}
+	return nil
+}
+
+// WriteTags writes tags into path if fs supports it.
+func WriteTags(fs FilesService, path string, tags map[string]string) error {
+	tw, ok := fs.(TagWriter)
+	if !ok {
+		return fmt.Errorf("files service does not support writing tags")
+	}
+	return tw.WriteTags(path, tags)
+}
+
+// backupSuffix is appended to a destination while its pre-tagging content is
+// kept around for rollback.
+const backupSuffix = ".gocamelpack-orig"
+
+// TaggedOperation decorates an operation by writing tags into its destination
+// after it executes. For moves the destination is the user's original file,
+// so its untagged content is backed up and restored on rollback; the backup
+// is discarded when the transaction commits.
+type TaggedOperation struct {
+	WrappedOperation
+	tags   map[string]string
+	backup string
+}
+
+// NewTaggedOperation wraps op so that tags are written after it executes.
+func NewTaggedOperation(op Operation, tags map[string]string) *TaggedOperation {
+	return &TaggedOperation{WrappedOperation: WrappedOperation{Operation: op}, tags: tags}
+}
+
+// Tags returns the tags that will be written into the destination.
+func (to *TaggedOperation) Tags() map[string]string {
+	return to.tags
+}
+
+func (to *TaggedOperation) Execute(fs FilesService) error {
+	if err := to.Operation.Execute(fs); err != nil {
+		return err
+	}
+
+	if to.Type() == OperationMove {
+		to.backup = to.Destination() + backupSuffix
+		if err := copyFileContents(FSOf(fs), to.Destination(), to.backup); err != nil {
+			to.backup = ""
+			return to.undo(fs, fmt.Errorf("backing up %q before tagging: %w", to.Destination(), err))
+		}
+	}
+
+	if err := WriteTags(fs, to.Destination(), to.tags); err != nil {
+		return to.undo(fs, err)
+	}
+	return nil
+}
+
+// undo reverts a half-applied operation. The transaction only rolls back
+// operations that completed, so a failed tagging step cleans up after itself.
+func (to *TaggedOperation) undo(fs FilesService, cause error) error {
+	if err := to.Rollback(fs); err != nil {
+		return fmt.Errorf("%v; rollback also failed: %w", cause, err)
+	}
+	return cause
+}
+
+func (to *TaggedOperation) Rollback(fs FilesService) error {
+	if to.backup != "" {
+		if err := FSOf(fs).Rename(to.backup, to.Destination()); err != nil {
+			return fmt.Errorf("restoring untagged %q: %w", to.Destination(), err)
+		}
+		to.backup = ""
+	}
+	return to.Operation.Rollback(fs)
+}
+
+// Commit discards the pre-tagging backup once the transaction has succeeded,
+// then lets the wrapped operation commit.
+func (to *TaggedOperation) Commit(fs FilesService) error {
+	if to.backup != "" {
+		if err := FSOf(fs).Remove(to.backup); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("removing backup %q: %w", to.backup, err)
+		}
+		to.backup = ""
+	}
+	return to.WrappedOperation.Commit(fs)
+}
+
+// copyFileContents writes a byte-for-byte copy of src to dst within fsys,
+// preserving mode.
+func copyFileContents(fsys vfs.FS, src, dst string) error {
+	in, err := fsys.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	out, err := fsys.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		fsys.Remove(dst)
+		return err
+	}
+	return out.Close()
+}
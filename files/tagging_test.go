@@ -0,0 +1,161 @@
+package files
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+// tagRecordingFiles is a real *Files whose WriteTags appends a marker to the
+// file instead of calling exiftool, or fails when failOn matches the path.
+type tagRecordingFiles struct {
+	*Files
+	failOn string
+}
+
+func (t *tagRecordingFiles) WriteTags(path string, tags map[string]string) error {
+	if path == t.failOn {
+		return errors.New("mock tag write error")
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	for k, v := range tags {
+		if _, err := f.WriteString("|" + k + "=" + v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *tagRecordingFiles) NewTransaction(overwrite bool) Transaction {
+	return NewTransaction(t, overwrite)
+}
+
+func readString(t *testing.T, path string) string {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read %s: %v", path, err)
+	}
+	return string(b)
+}
+
+func TestTaggedOperation_MoveCommitRemovesBackup(t *testing.T) {
+	tmp := testutil.TempDir(t)
+	src := filepath.Join(tmp, "src.jpg")
+	dst := filepath.Join(tmp, "out", "dst.jpg")
+	if err := os.WriteFile(src, []byte("pixels"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	fs := &tagRecordingFiles{Files: newFiles()}
+	tx := fs.NewTransaction(false)
+	if err := tx.Add(NewTaggedOperation(NewMoveOperation(src, dst), map[string]string{"ID": "s-1"})); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Execute(); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	if got := readString(t, dst); got != "pixels|ID=s-1" {
+		t.Errorf("unexpected destination content %q", got)
+	}
+	if _, err := os.Stat(dst + backupSuffix); !os.IsNotExist(err) {
+		t.Errorf("backup should be removed on commit, stat err = %v", err)
+	}
+}
+
+func TestTaggedOperation_TagFailureUndoesMove(t *testing.T) {
+	tmp := testutil.TempDir(t)
+	src := filepath.Join(tmp, "src.jpg")
+	dst := filepath.Join(tmp, "out", "dst.jpg")
+	if err := os.WriteFile(src, []byte("pixels"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	fs := &tagRecordingFiles{Files: newFiles(), failOn: dst}
+	tx := fs.NewTransaction(false)
+	if err := tx.Add(NewTaggedOperation(NewMoveOperation(src, dst), map[string]string{"ID": "s-1"})); err != nil {
+		t.Fatal(err)
+	}
+
+	err := tx.Execute()
+	var txErr *TransactionError
+	if !errors.As(err, &txErr) || txErr.Phase != "execution" {
+		t.Fatalf("expected execution TransactionError, got %v", err)
+	}
+
+	if got := readString(t, src); got != "pixels" {
+		t.Errorf("source should be restored untouched, got %q", got)
+	}
+	if _, err := os.Stat(dst); !os.IsNotExist(err) {
+		t.Errorf("destination should not exist, stat err = %v", err)
+	}
+	if _, err := os.Stat(dst + backupSuffix); !os.IsNotExist(err) {
+		t.Errorf("backup should not be left behind, stat err = %v", err)
+	}
+}
+
+func TestTaggedOperation_RollbackRestoresUntaggedOriginal(t *testing.T) {
+	tmp := testutil.TempDir(t)
+	src1 := filepath.Join(tmp, "a.jpg")
+	src2 := filepath.Join(tmp, "b.jpg")
+	dst1 := filepath.Join(tmp, "out", "a.jpg")
+	dst2 := filepath.Join(tmp, "out", "b.jpg")
+	for _, p := range []string{src1, src2} {
+		if err := os.WriteFile(p, []byte("pixels"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The second operation fails while tagging, so the first (already tagged)
+	// move must be reverted to its original bytes.
+	fs := &tagRecordingFiles{Files: newFiles(), failOn: dst2}
+	tx := fs.NewTransaction(false)
+	tx.Add(NewTaggedOperation(NewMoveOperation(src1, dst1), map[string]string{"ID": "s-1"}))
+	tx.Add(NewTaggedOperation(NewMoveOperation(src2, dst2), map[string]string{"ID": "s-2"}))
+
+	if err := tx.Execute(); err == nil {
+		t.Fatal("expected execution to fail")
+	}
+
+	for _, p := range []string{src1, src2} {
+		if got := readString(t, p); got != "pixels" {
+			t.Errorf("%s should be restored untagged, got %q", p, got)
+		}
+	}
+}
+
+func TestTaggedOperation_CopyHasNoBackup(t *testing.T) {
+	tmp := testutil.TempDir(t)
+	src := filepath.Join(tmp, "src.jpg")
+	dst := filepath.Join(tmp, "out", "dst.jpg")
+	if err := os.WriteFile(src, []byte("pixels"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	fs := &tagRecordingFiles{Files: newFiles()}
+	op := NewTaggedOperation(NewCopyOperation(src, dst), map[string]string{"ID": "s-1"})
+	if err := op.Execute(fs); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	if _, err := os.Stat(dst + backupSuffix); !os.IsNotExist(err) {
+		t.Errorf("copies must not create a backup, stat err = %v", err)
+	}
+	if got := readString(t, src); got != "pixels" {
+		t.Errorf("source must stay untagged, got %q", got)
+	}
+}
+
+func TestWriteTags_Unsupported(t *testing.T) {
+	if err := WriteTags(newMockFilesService(), "/x.jpg", map[string]string{"A": "b"}); err == nil {
+		t.Fatal("expected error for a service without TagWriter")
+	}
+}
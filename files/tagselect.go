@@ -0,0 +1,63 @@
+package files
+
+import "io"
+
+// TagSelector is implemented by services that can extract only the named
+// tags, which is much faster than extracting all of them when a run needs
+// little more than the capture date.
+type TagSelector interface {
+	SelectTags(paths, tags []string) ([]FileMetadata, error)
+}
+
+// SelectTags extracts the named tags of paths through an exiftool process
+// kept open for the purpose, started on first use and ended by Close.
+func (f *Files) SelectTags(paths, tags []string) ([]FileMetadata, error) {
+	args, err := tagArgs(tags)
+	if err != nil {
+		return nil, err
+	}
+	f.selMu.Lock()
+	if f.sel == nil {
+		f.sel, err = startTagSession()
+	}
+	s := f.sel
+	f.selMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	return s.extract(args, paths)
+}
+
+// WithTags decorates fs so GetFileTags extracts only tags, when fs is a
+// TagSelector. Otherwise, or when the selective read fails, every tag is
+// extracted as before. An empty tags list returns fs unchanged.
+func WithTags(fs FilesService, tags []string) FilesService {
+	if len(tags) == 0 {
+		return fs
+	}
+	return &selectingFiles{wrapped: wrapped{fs}, tags: tags}
+}
+
+// selectingFiles decorates a FilesService with tag selection.
+type selectingFiles struct {
+	wrapped
+	tags []string
+}
+
+func (sf *selectingFiles) GetFileTags(paths []string) []FileMetadata {
+	if ts, ok := sf.FilesService.(TagSelector); ok {
+		if md, err := ts.SelectTags(paths, sf.tags); err == nil {
+			return md
+		}
+	}
+	return sf.FilesService.GetFileTags(paths)
+}
+
+// CopyTee lets WithCopyHash tee copies through the wrapped service.
+func (sf *selectingFiles) CopyTee(src, dst string, size int, tee io.Writer) error {
+	return copyTee(sf.FilesService, src, dst, size, tee)
+}
+
+func (sf *selectingFiles) NewTransaction(overwrite bool) Transaction {
+	return NewTransaction(sf, overwrite)
+}
@@ -0,0 +1,82 @@
+package files
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+// fakeExiftool speaks the -stay_open protocol, reporting the arguments of
+// each request for the last file named in it.
+const fakeExiftool = `#!/bin/sh
+args=""
+file=""
+while IFS= read -r line; do
+	case "$line" in
+	-execute) printf '[{"SourceFile":"%s","Args":"%s"}]\n{ready}\n' "$file" "$args"; args=""; file="" ;;
+	-stay_open) ;;
+	False) exit 0 ;;
+	-*) args="$args$line " ;;
+	*) file="$line" ;;
+	esac
+done
+`
+
+func TestFiles_SelectTags(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake exiftool is a shell script")
+	}
+	bin := filepath.Join(testutil.TempDir(t), "exiftool")
+	if err := os.WriteFile(bin, []byte(fakeExiftool), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	old := exiftoolBin
+	exiftoolBin = bin
+	defer func() { exiftoolBin = old }()
+
+	f := newFiles()
+	defer func() {
+		if f.sel != nil {
+			f.sel.close()
+		}
+	}()
+	for i := 0; i < 2; i++ { // the session is reused
+		md, err := f.SelectTags([]string{"/a.jpg", "/b.jpg"}, []string{"CreationDate", "EXIF:Model"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(md) != 2 || md[0].Filepath != "/a.jpg" || len(md[0].Tags) != 0 {
+			t.Fatalf("got %+v, want /a.jpg without tags first", md)
+		}
+		if got := md[1].Tags["Args"]; got != "-j -CreationDate -EXIF:Model " {
+			t.Errorf("request args = %q", got)
+		}
+	}
+
+	if _, err := f.SelectTags([]string{"/a.jpg"}, []string{"-overwrite_original"}); err == nil {
+		t.Error("expected an option passed as a tag to be rejected")
+	}
+}
+
+// allTagsFiles is a FilesService that cannot select tags.
+type allTagsFiles struct {
+	FilesService
+}
+
+func (allTagsFiles) GetFileTags(paths []string) []FileMetadata {
+	return []FileMetadata{{Filepath: paths[0], Tags: map[string]string{"CreationDate": "2024:01:02 03:04:05", "Model": "X"}}}
+}
+
+func TestWithTags_FallsBack(t *testing.T) {
+	fs := WithTags(allTagsFiles{}, []string{"CreationDate"})
+	md := fs.GetFileTags([]string{"/a.jpg"})
+	if len(md) != 1 || md[0].Tags["Model"] != "X" {
+		t.Errorf("got %+v, want every tag of the wrapped service", md)
+	}
+	if WithTags(allTagsFiles{}, nil) != (allTagsFiles{}) {
+		t.Error("expected no tags to leave the service undecorated")
+	}
+}
@@ -0,0 +1,53 @@
+package files
+
+import (
+	"sync"
+	"time"
+)
+
+// TagTiming measures the metadata reads of a run, to tell a slow exiftool
+// from a slow disk. It is safe for concurrent use.
+type TagTiming struct {
+	Calls    int
+	Files    int
+	Duration time.Duration
+
+	mu sync.Mutex
+}
+
+// PerFile is the average time a file's metadata took.
+func (t *TagTiming) PerFile() time.Duration {
+	if t.Files == 0 {
+		return 0
+	}
+	return t.Duration / time.Duration(t.Files)
+}
+
+// WithTagTiming decorates fs so the time its GetFileTags calls take is
+// added to t.
+func WithTagTiming(fs FilesService, t *TagTiming) FilesService {
+	return &timedFiles{wrapped: wrapped{fs}, timing: t}
+}
+
+// timedFiles decorates a FilesService with metadata timing.
+type timedFiles struct {
+	wrapped
+	timing *TagTiming
+}
+
+func (tf *timedFiles) GetFileTags(paths []string) []FileMetadata {
+	start := now()
+	md := tf.FilesService.GetFileTags(paths)
+	d := now().Sub(start)
+
+	tf.timing.mu.Lock()
+	defer tf.timing.mu.Unlock()
+	tf.timing.Calls++
+	tf.timing.Files += len(paths)
+	tf.timing.Duration += d
+	return md
+}
+
+func (tf *timedFiles) NewTransaction(overwrite bool) Transaction {
+	return NewTransaction(tf, overwrite)
+}
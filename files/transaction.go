@@ -34,9 +34,15 @@ type Operation interface {
 	Rollback(fs FilesService) error
 }
 
+// Committer is implemented by operations that hold temporary state (such as
+// backups) until the whole transaction has succeeded.
+type Committer interface {
+	Commit(fs FilesService) error
+}
+
 // TransactionError represents errors that occur during transaction operations.
 type TransactionError struct {
-	Phase     string      // "planning", "execution", "rollback"
+	Phase     string      // "planning", "execution", "rollback", "commit"
 	Operation Operation   // Operation that failed (may be nil for planning errors)
 	Err       error       // Underlying error
 }
@@ -60,6 +66,9 @@ type Transaction interface {
 	
 	// AddMove plans a move operation from src to dst.
 	AddMove(src, dst string) error
+
+	// Add plans an arbitrary operation, such as a decorated copy or move.
+	Add(op Operation) error
 	
 	// Validate checks all planned operations for potential issues.
 	// This should be called before Execute to catch problems early.
@@ -1,9 +1,14 @@
 package files
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
 
 	"github.com/Tmunayyer/gocamelpack/progress"
+	"github.com/Tmunayyer/gocamelpack/vfs"
 )
 
 // FileTransaction implements the Transaction interface.
@@ -12,6 +17,7 @@ type FileTransaction struct {
 	operations  []Operation
 	completed   []Operation
 	overwrite   bool
+	createdDirs []string // directories that did not exist before execution
 }
 
 // NewTransaction creates a new file transaction.
@@ -34,7 +40,17 @@ func (ft *FileTransaction) AddMove(src, dst string) error {
 	return nil
 }
 
+func (ft *FileTransaction) Add(op Operation) error {
+	if op == nil {
+		return fmt.Errorf("operation must not be nil")
+	}
+	ft.operations = append(ft.operations, op)
+	return nil
+}
+
 func (ft *FileTransaction) Validate() error {
+	writable := writableChecker{}
+	onDisk := vfs.OnDisk(FSOf(ft.fs))
 	for _, op := range ft.operations {
 		if !ft.overwrite {
 			if err := ft.fs.ValidateCopyArgs(op.Source(), op.Destination()); err != nil {
@@ -57,10 +73,21 @@ func (ft *FileTransaction) Validate() error {
 				return &TransactionError{
 					Phase:     "planning",
 					Operation: op,
-					Err:       fmt.Errorf("source %q is not a regular file", op.Source()),
+					Err:       Errorf(ErrSourceMissing, "source %q is not a regular file", op.Source()),
 				}
 			}
 		}
+		// Fail before anything is written rather than file by file.
+		if !onDisk {
+			continue
+		}
+		if err := writable.check(op.Destination()); err != nil {
+			return &TransactionError{
+				Phase:     "planning",
+				Operation: op,
+				Err:       err,
+			}
+		}
 	}
 	return nil
 }
@@ -72,6 +99,7 @@ func (ft *FileTransaction) Execute() error {
 func (ft *FileTransaction) ExecuteWithProgress(reporter progress.ProgressReporter) error {
 	// Reset completed operations
 	ft.completed = ft.completed[:0]
+	ft.createdDirs = ft.createdDirs[:0]
 	
 	// Set up progress tracking
 	reporter.SetTotal(len(ft.operations))
@@ -81,7 +109,13 @@ func (ft *FileTransaction) ExecuteWithProgress(reporter progress.ProgressReporte
 		// Update progress message
 		reporter.SetMessage(fmt.Sprintf("%s %s", op.Type(), op.Source()))
 		
-		if err := op.Execute(ft.fs); err != nil {
+		if o, ok := op.(overwriter); ok {
+			o.setOverwrite(ft.overwrite)
+		}
+		missing := missingDirs(FSOf(ft.fs), filepath.Dir(op.Destination()))
+		err := op.Execute(ft.fs)
+		ft.trackCreatedDirs(missing)
+		if err != nil {
 			// Report error to progress before attempting rollback
 			reporter.SetError(err)
 			
@@ -110,6 +144,26 @@ func (ft *FileTransaction) ExecuteWithProgress(reporter progress.ProgressReporte
 	
 	// Mark as finished
 	reporter.Finish()
+	return ft.commit()
+}
+
+// commit lets operations release temporary state once every operation has
+// succeeded. Failures are reported but nothing is rolled back at this point.
+func (ft *FileTransaction) commit() error {
+	var commitErrors []error
+	for _, op := range ft.completed {
+		if c, ok := op.(Committer); ok {
+			if err := c.Commit(ft.fs); err != nil {
+				commitErrors = append(commitErrors, err)
+			}
+		}
+	}
+	if len(commitErrors) > 0 {
+		return &TransactionError{
+			Phase: "commit",
+			Err:   fmt.Errorf("commit errors: %v", commitErrors),
+		}
+	}
 	return nil
 }
 
@@ -125,6 +179,12 @@ func (ft *FileTransaction) Rollback() error {
 		}
 	}
 	
+	// Remove directories the transaction created, now that they should be
+	// empty again.
+	if err := ft.removeCreatedDirs(); err != nil {
+		rollbackErrors = append(rollbackErrors, err)
+	}
+	
 	// Clear completed operations after rollback attempt
 	ft.completed = ft.completed[:0]
 	
@@ -150,4 +210,59 @@ func (ft *FileTransaction) Completed() []Operation {
 	ops := make([]Operation, len(ft.completed))
 	copy(ops, ft.completed)
 	return ops
-}
\ No newline at end of file
+}
+// missingDirs returns dir and those of its ancestors that do not exist yet.
+func missingDirs(fsys vfs.FS, dir string) []string {
+	var missing []string
+	for dir != "" {
+		if _, err := fsys.Lstat(dir); err == nil {
+			break
+		}
+		missing = append(missing, dir)
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	return missing
+}
+
+// trackCreatedDirs records which of the previously missing directories an
+// operation created.
+func (ft *FileTransaction) trackCreatedDirs(missing []string) {
+	fsys := FSOf(ft.fs)
+	for _, dir := range missing {
+		if info, err := fsys.Lstat(dir); err == nil && info.IsDir() {
+			ft.createdDirs = append(ft.createdDirs, dir)
+		}
+	}
+}
+
+// removeCreatedDirs deletes directories created during execution, deepest
+// first. Directories that are no longer empty — because something else
+// wrote into them — are left alone.
+func (ft *FileTransaction) removeCreatedDirs() error {
+	dirs := append([]string(nil), ft.createdDirs...)
+	sort.Slice(dirs, func(i, j int) bool { return len(dirs[i]) > len(dirs[j]) })
+	ft.createdDirs = ft.createdDirs[:0]
+
+	fsys := FSOf(ft.fs)
+	var errs []error
+	for _, dir := range dirs {
+		entries, err := fsys.ReadDir(dir)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				errs = append(errs, fmt.Errorf("inspecting created directory %q: %w", dir, err))
+			}
+			continue
+		}
+		if len(entries) > 0 {
+			continue
+		}
+		if err := fsys.Remove(dir); err != nil && !os.IsNotExist(err) {
+			errs = append(errs, fmt.Errorf("removing created directory %q: %w", dir, err))
+		}
+	}
+	return errors.Join(errs...)
+}
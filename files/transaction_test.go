@@ -331,4 +331,212 @@ func TestTransaction_Validation(t *testing.T) {
 	if err != nil {
 		t.Errorf("Expected validation to succeed with overwrite enabled: %v", err)
 	}
-}
\ No newline at end of file
+}
+// TestTransaction_RollbackRemovesCreatedDirs checks that rollback deletes the
+// directories execution created, but not ones that already existed or that
+// something else wrote into.
+func TestTransaction_RollbackRemovesCreatedDirs(t *testing.T) {
+	tempDir := t.TempDir()
+	srcDir := filepath.Join(tempDir, "src")
+	dstDir := filepath.Join(tempDir, "dst")
+	if err := os.MkdirAll(srcDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(dstDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	file1 := filepath.Join(srcDir, "file1.txt")
+	file2 := filepath.Join(srcDir, "file2.txt")
+	for _, f := range []string{file1, file2} {
+		if err := os.WriteFile(f, []byte(f), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// file1 lands in a fresh 2025/01/27 hierarchy; file2 in a fresh
+	// directory that another process also writes into.
+	dst1 := filepath.Join(dstDir, "2025", "01", "27", "file1.txt")
+	shared := filepath.Join(dstDir, "shared")
+	dst2 := filepath.Join(shared, "file2.txt")
+
+	tx := NewTransaction(newFiles(), false)
+	if err := tx.AddMove(file1, dst1); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.AddMove(file2, dst2); err != nil {
+		t.Fatal(err)
+	}
+	// A third move whose source is gone makes execution fail.
+	if err := tx.AddMove(filepath.Join(srcDir, "missing.txt"), filepath.Join(dstDir, "x", "missing.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Simulate the other writer once file2 has been moved.
+	ft := tx.(*FileTransaction)
+	ft.operations[1] = &hookOperation{Operation: ft.operations[1], after: func() {
+		os.WriteFile(filepath.Join(shared, "other.txt"), nil, 0o644)
+	}}
+
+	if err := tx.Execute(); err == nil {
+		t.Fatal("expected execution to fail")
+	}
+
+	for _, f := range []string{file1, file2} {
+		if _, err := os.Stat(f); err != nil {
+			t.Errorf("%s was not restored: %v", f, err)
+		}
+	}
+	for _, gone := range []string{filepath.Join(dstDir, "2025"), filepath.Join(dstDir, "x")} {
+		if _, err := os.Stat(gone); !os.IsNotExist(err) {
+			t.Errorf("created directory %s should have been removed", gone)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(shared, "other.txt")); err != nil {
+		t.Errorf("directory with foreign content must be kept: %v", err)
+	}
+	if _, err := os.Stat(dstDir); err != nil {
+		t.Errorf("pre-existing destination root must be kept: %v", err)
+	}
+}
+
+// TestMoveOperation_RollbackRecreatesSourceDir checks that a move can be
+// undone after its source directory disappeared.
+func TestMoveOperation_RollbackRecreatesSourceDir(t *testing.T) {
+	tempDir := t.TempDir()
+	src := filepath.Join(tempDir, "card", "DCIM", "IMG_0001.jpg")
+	dst := filepath.Join(tempDir, "archive", "IMG_0001.jpg")
+	if err := os.MkdirAll(filepath.Dir(src), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(src, []byte("img"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	op := NewMoveOperation(src, dst)
+	fs := newFiles()
+	if err := op.Execute(fs); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if err := os.RemoveAll(filepath.Join(tempDir, "card")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := op.Rollback(fs); err != nil {
+		t.Fatalf("Rollback: %v", err)
+	}
+	if _, err := os.Stat(src); err != nil {
+		t.Errorf("source not restored: %v", err)
+	}
+
+	// A file that reappeared at the source is never overwritten.
+	if err := op.Execute(fs); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(src, []byte("new"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := op.Rollback(fs); err == nil {
+		t.Error("expected rollback to refuse to overwrite the source")
+	}
+}
+
+// hookOperation runs after once its wrapped operation has executed.
+type hookOperation struct {
+	Operation
+	after func()
+}
+
+func (h *hookOperation) Execute(fs FilesService) error {
+	if err := h.Operation.Execute(fs); err != nil {
+		return err
+	}
+	h.after()
+	return nil
+}
+
+// TestTransaction_OverwriteRollbackRestoresOriginal checks that rolling back
+// an overwriting copy or move puts the pre-existing destination back instead
+// of deleting it, and that a successful run leaves no backups behind.
+func TestTransaction_OverwriteRollbackRestoresOriginal(t *testing.T) {
+	for _, move := range []bool{false, true} {
+		name := "copy"
+		if move {
+			name = "move"
+		}
+		t.Run(name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			src := filepath.Join(tempDir, "new.txt")
+			dst := filepath.Join(tempDir, "archive.txt")
+			if err := os.WriteFile(src, []byte("new"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(dst, []byte("original"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			tx := NewTransaction(newFiles(), true)
+			add := tx.AddCopy
+			if move {
+				add = tx.AddMove
+			}
+			if err := add(src, dst); err != nil {
+				t.Fatal(err)
+			}
+			// Fails after the overwrite has happened.
+			if err := tx.AddCopy(filepath.Join(tempDir, "missing.txt"), filepath.Join(tempDir, "x.txt")); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := tx.Execute(); err == nil {
+				t.Fatal("expected execution to fail")
+			}
+			if got, _ := os.ReadFile(dst); string(got) != "original" {
+				t.Errorf("destination after rollback = %q, want original content", got)
+			}
+			if got, _ := os.ReadFile(src); string(got) != "new" {
+				t.Errorf("source after rollback = %q", got)
+			}
+			if _, err := os.Stat(dst + previousSuffix); !os.IsNotExist(err) {
+				t.Errorf("backup left behind after rollback")
+			}
+
+			// The same overwrite on its own commits cleanly.
+			tx = NewTransaction(newFiles(), true)
+			add = tx.AddCopy
+			if move {
+				add = tx.AddMove
+			}
+			if err := add(src, dst); err != nil {
+				t.Fatal(err)
+			}
+			if err := tx.Execute(); err != nil {
+				t.Fatalf("Execute: %v", err)
+			}
+			if got, _ := os.ReadFile(dst); string(got) != "new" {
+				t.Errorf("destination after commit = %q, want new content", got)
+			}
+			if _, err := os.Stat(dst + previousSuffix); !os.IsNotExist(err) {
+				t.Errorf("backup left behind after commit")
+			}
+		})
+	}
+}
+
+// TestMoveOperation_RefusesToReplaceWithoutOverwrite checks that a move never
+// silently replaces an existing destination.
+func TestMoveOperation_RefusesToReplaceWithoutOverwrite(t *testing.T) {
+	tempDir := t.TempDir()
+	src := filepath.Join(tempDir, "a.txt")
+	dst := filepath.Join(tempDir, "b.txt")
+	os.WriteFile(src, []byte("a"), 0o644)
+	os.WriteFile(dst, []byte("b"), 0o644)
+
+	err := NewMoveOperation(src, dst).Execute(newFiles())
+	if ErrorCode(err) != CodeConflict {
+		t.Fatalf("expected conflict error, got %v", err)
+	}
+	if got, _ := os.ReadFile(dst); string(got) != "b" {
+		t.Errorf("destination replaced: %q", got)
+	}
+}
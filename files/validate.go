@@ -0,0 +1,204 @@
+package files
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/Tmunayyer/gocamelpack/vfs"
+)
+
+// ValidateMedia checks that the JPEG, PNG, HEIF or MP4/QuickTime file at
+// path is structurally sound: its header is right and its segments, chunks
+// or boxes add up to a complete file. Truncated copies and files cut off by
+// a failing card are the usual finds. Other formats are not checked. The
+// error matches ErrCorrupt when the file is damaged.
+func ValidateMedia(fsys vfs.FS, path string) error {
+	ft, ok := DetectType(fsys, path)
+	if !ok {
+		// A checked extension over unrecognized content is damage too.
+		if name, checked := checkedExts[strings.ToLower(filepath.Ext(path))]; checked {
+			return Errorf(ErrCorrupt, "%s: not a %s file", path, name)
+		}
+		return nil
+	}
+	var check func(f vfs.File, size int64) error
+	switch ft.Name {
+	case typeJPEG.Name:
+		check = checkJPEG
+	case typePNG.Name:
+		check = checkPNG
+	case typeHEIC.Name, typeMP4.Name, typeMOV.Name, type3GP.Name:
+		check = checkBMFF
+	default:
+		return nil
+	}
+
+	f, err := fsys.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if err := check(f, info.Size()); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+			err = errors.New("the file is truncated")
+		}
+		return Errorf(ErrCorrupt, "%s: damaged %s: %v", path, ft.Name, err)
+	}
+	return nil
+}
+
+// checkedExts maps the extensions ValidateMedia checks to their format.
+var checkedExts = map[string]string{
+	".jpg": typeJPEG.Name, ".jpeg": typeJPEG.Name, ".jpe": typeJPEG.Name,
+	".png":  typePNG.Name,
+	".heic": typeHEIC.Name, ".heif": typeHEIC.Name, ".hif": typeHEIC.Name,
+	".mp4": typeMP4.Name, ".m4v": typeMP4.Name, ".mov": typeMOV.Name,
+}
+
+// checkJPEG walks the marker segments up to the image data and requires an
+// end-of-image marker after it; data some cameras append after that marker
+// is allowed.
+func checkJPEG(f vfs.File, _ int64) error {
+	br := bufio.NewReaderSize(f, 64<<10)
+	if _, err := br.Discard(2); err != nil { // start of image
+		return err
+	}
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			return err
+		}
+		if b != 0xff {
+			return fmt.Errorf("expected a marker, found 0x%02x", b)
+		}
+		marker, err := br.ReadByte()
+		for err == nil && marker == 0xff { // fill bytes
+			marker, err = br.ReadByte()
+		}
+		if err != nil {
+			return err
+		}
+		switch {
+		case marker == 0xd9:
+			return errors.New("no image data")
+		case marker == 0x01 || (marker >= 0xd0 && marker <= 0xd7):
+			continue
+		}
+		var l [2]byte
+		if _, err := io.ReadFull(br, l[:]); err != nil {
+			return err
+		}
+		n := int(binary.BigEndian.Uint16(l[:]))
+		if n < 2 {
+			return fmt.Errorf("segment 0x%02x has an invalid length", marker)
+		}
+		if _, err := br.Discard(n - 2); err != nil {
+			return err
+		}
+		if marker == 0xda { // start of scan: entropy-coded data follows
+			break
+		}
+	}
+	prevFF := false
+	for {
+		b, err := br.ReadByte()
+		if err == io.EOF {
+			return errors.New("no end-of-image marker; the file is truncated")
+		}
+		if err != nil {
+			return err
+		}
+		if prevFF && b == 0xd9 {
+			return nil
+		}
+		prevFF = b == 0xff
+	}
+}
+
+// checkPNG walks the chunks up to IEND.
+func checkPNG(f vfs.File, _ int64) error {
+	br := bufio.NewReader(f)
+	if _, err := br.Discard(8); err != nil { // signature
+		return err
+	}
+	for {
+		var hdr [8]byte
+		if _, err := io.ReadFull(br, hdr[:]); err != nil {
+			return err
+		}
+		if string(hdr[4:]) == "IEND" {
+			return nil
+		}
+		// Skip the data and the CRC.
+		if err := skip(br, int64(binary.BigEndian.Uint32(hdr[:4]))+4); err != nil {
+			return err
+		}
+	}
+}
+
+// checkBMFF walks the top-level boxes of an ISO base media file (HEIF,
+// MP4, QuickTime) and requires them to fill the file exactly, with an ftyp
+// box first and the movie or image metadata present.
+func checkBMFF(f vfs.File, size int64) error {
+	var pos int64
+	var seen []string
+	for pos < size {
+		var hdr [8]byte
+		if _, err := io.ReadFull(f, hdr[:]); err != nil {
+			return err
+		}
+		boxSize := int64(binary.BigEndian.Uint32(hdr[:4]))
+		typ := string(hdr[4:])
+		headLen := int64(8)
+		switch boxSize {
+		case 0: // the box runs to the end of the file
+			boxSize = size - pos
+		case 1:
+			var large [8]byte
+			if _, err := io.ReadFull(f, large[:]); err != nil {
+				return err
+			}
+			boxSize = int64(binary.BigEndian.Uint64(large[:]))
+			headLen = 16
+		}
+		if boxSize < headLen {
+			return fmt.Errorf("box %q has an invalid size", typ)
+		}
+		if pos+boxSize > size {
+			return fmt.Errorf("box %q runs %d byte(s) past the end of the file; the file is truncated", typ, pos+boxSize-size)
+		}
+		if len(seen) == 0 && typ != "ftyp" {
+			return errors.New("no ftyp box")
+		}
+		seen = append(seen, typ)
+		if err := skip(f, boxSize-headLen); err != nil {
+			return err
+		}
+		pos += boxSize
+	}
+	for _, typ := range seen {
+		if typ == "moov" || typ == "meta" {
+			return nil
+		}
+	}
+	return errors.New("no moov or meta box")
+}
+
+// skip discards the next n bytes of r, seeking past them when r can.
+func skip(r io.Reader, n int64) error {
+	if s, ok := r.(io.Seeker); ok {
+		_, err := s.Seek(n, io.SeekCurrent)
+		return err
+	}
+	_, err := io.CopyN(io.Discard, r, n)
+	return err
+}
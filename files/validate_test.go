@@ -0,0 +1,58 @@
+package files
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/testutil"
+	"github.com/Tmunayyer/gocamelpack/vfs"
+)
+
+// box builds an ISO base media box.
+func box(typ string, payload string) string {
+	var size [4]byte
+	binary.BigEndian.PutUint32(size[:], uint32(8+len(payload)))
+	return string(size[:]) + typ + payload
+}
+
+func TestValidateMedia(t *testing.T) {
+	dir := filepath.Join(testutil.TempDir(t), "validate")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	jpeg := "\xff\xd8\xff\xe0\x00\x04JF\xff\xda\x00\x02\x12\x34\xff\x00\x56\xff\xd9"
+	png := "\x89PNG\r\n\x1a\n" + "\x00\x00\x00\x02IHDR\x00\x01CRC!" + "\x00\x00\x00\x00IEND\xaeB`\x82"
+	mp4 := box("ftyp", "isom\x00\x00\x02\x00") + box("moov", "xxxx") + box("mdat", "data")
+
+	tests := []struct {
+		name    string
+		content string
+		corrupt bool
+	}{
+		{"ok.jpg", jpeg, false},
+		{"trailer.jpg", jpeg + "extra", false},
+		{"cut.jpg", jpeg[:len(jpeg)-2], true},
+		{"nosos.jpg", "\xff\xd8\xff\xd9", true},
+		{"ok.png", png, false},
+		{"cut.png", png[:20], true},
+		{"ok.mp4", mp4, false},
+		{"cut.mp4", mp4[:len(mp4)-3], true},
+		{"nomoov.mp4", box("ftyp", "isom\x00\x00\x02\x00") + box("mdat", "data"), true},
+		{"empty.jpg", "", true},
+		{"garbage.heic", "hello, world", true},
+		{"notes.txt", "hello, world", false},
+	}
+	for _, tc := range tests {
+		path := filepath.Join(dir, tc.name)
+		if err := os.WriteFile(path, []byte(tc.content), filePermRW); err != nil {
+			t.Fatal(err)
+		}
+		err := ValidateMedia(vfs.OS, path)
+		if got := errors.Is(err, ErrCorrupt); got != tc.corrupt {
+			t.Errorf("%s: got %v, want corrupt %v", tc.name, err, tc.corrupt)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package files
+
+import "github.com/Tmunayyer/gocamelpack/vfs"
+
+// wrapped is embedded by decorators for the service they wrap. Embedding
+// FilesService alone would hide the optional interfaces callers detect, so
+// wrapped forwards FS, WriteTags and ReadTags too; a decorator then only
+// defines the methods it changes.
+type wrapped struct {
+	FilesService
+}
+
+func (w wrapped) FS() vfs.FS {
+	return FSOf(w.FilesService)
+}
+
+func (w wrapped) WriteTags(path string, tags map[string]string) error {
+	return WriteTags(w.FilesService, path, tags)
+}
+
+func (w wrapped) ReadTags(paths []string, opts ReadOptions) ([]FileMetadata, error) {
+	return ReadTags(w.FilesService, paths, opts)
+}
+
+// WrappedOperation is embedded by operation decorators for the operation
+// they wrap. Beyond the Operation methods it forwards the overwrite setting
+// a transaction hands down and Commit, which embedding Operation alone
+// would hide.
+type WrappedOperation struct {
+	Operation
+}
+
+func (w WrappedOperation) setOverwrite(overwrite bool) {
+	if o, ok := w.Operation.(overwriter); ok {
+		o.setOverwrite(overwrite)
+	}
+}
+
+// Commit lets the wrapped operation commit.
+func (w WrappedOperation) Commit(fs FilesService) error {
+	if c, ok := w.Operation.(Committer); ok {
+		return c.Commit(fs)
+	}
+	return nil
+}
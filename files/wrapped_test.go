@@ -0,0 +1,41 @@
+package files
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestWrappedOperation_ForwardsOverwriteAndCommit checks that an overwrite
+// through stacked decorators replaces the destination and that the commit
+// reaches the innermost operation, which discards the set-aside original.
+func TestWrappedOperation_ForwardsOverwriteAndCommit(t *testing.T) {
+	tempDir := t.TempDir()
+	src := filepath.Join(tempDir, "new.txt")
+	dst := filepath.Join(tempDir, "archive.txt")
+	if err := os.WriteFile(src, []byte("new"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dst, []byte("original"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var op Operation = NewCopyOperation(src, dst)
+	op = NewRetryOperation(op, RetryPolicy{}, nil)
+	op = NewMeasuredOperation(op, nil)
+	op = NewFaultyOperation(op, nil)
+
+	tx := NewTransaction(newFiles(), true)
+	if err := tx.Add(op); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Execute(); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if got, _ := os.ReadFile(dst); string(got) != "new" {
+		t.Errorf("destination = %q, want new content", got)
+	}
+	if _, err := os.Stat(dst + previousSuffix); !os.IsNotExist(err) {
+		t.Errorf("original left behind after commit")
+	}
+}
@@ -0,0 +1,106 @@
+package files
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+)
+
+// Preserve is which extended attributes a copy carries over from its
+// source. Moves are renames and keep them all.
+type Preserve string
+
+const (
+	// PreserveNone copies file content only.
+	PreserveNone Preserve = ""
+	// PreserveBasic copies the attributes users set by hand: Finder tags
+	// and color labels on macOS, user.* attributes such as user.xdg.tags
+	// on Linux.
+	PreserveBasic Preserve = "basic"
+	// PreserveAll copies every extended attribute the process may set,
+	// including POSIX ACLs on Linux.
+	PreserveAll Preserve = "all"
+)
+
+// ParsePreserve parses a --preserve value; empty means PreserveNone.
+func ParsePreserve(s string) (Preserve, error) {
+	switch p := Preserve(s); p {
+	case PreserveNone:
+		return p, nil
+	case PreserveBasic, PreserveAll:
+		if !xattrSupported {
+			return "", fmt.Errorf("preserving extended attributes is not supported on %s", runtime.GOOS)
+		}
+		return p, nil
+	}
+	return "", fmt.Errorf("invalid preserve %q (want basic or all)", s)
+}
+
+// CopyXattrs copies the extended attributes p selects from src to dst.
+func CopyXattrs(src, dst string, p Preserve) error {
+	if p == PreserveNone {
+		return nil
+	}
+	names, err := listXattrs(src)
+	if err != nil {
+		return fmt.Errorf("listing extended attributes of %q: %w", src, err)
+	}
+	for _, name := range names {
+		if p == PreserveBasic && !basicXattr(name) {
+			continue
+		}
+		value, err := getXattr(src, name)
+		if err != nil {
+			return fmt.Errorf("reading extended attribute %s of %q: %w", name, src, err)
+		}
+		if err := setXattr(dst, name, value); err != nil {
+			return fmt.Errorf("setting extended attribute %s on %q: %w", name, dst, err)
+		}
+	}
+	return nil
+}
+
+// WithPreserve returns fs with Copy also copying the extended attributes p
+// selects. fs is returned unchanged for PreserveNone.
+func WithPreserve(fs FilesService, p Preserve) FilesService {
+	if p == PreserveNone {
+		return fs
+	}
+	return &preservingFiles{wrapped: wrapped{fs}, preserve: p}
+}
+
+// preservingFiles decorates a FilesService with extended attribute copying.
+type preservingFiles struct {
+	wrapped
+	preserve Preserve
+}
+
+func (pf *preservingFiles) Copy(src, dst string) error {
+	if err := pf.FilesService.Copy(src, dst); err != nil {
+		return err
+	}
+	if err := CopyXattrs(src, dst, pf.preserve); err != nil {
+		os.Remove(dst)
+		return err
+	}
+	return nil
+}
+
+func (pf *preservingFiles) NewTransaction(overwrite bool) Transaction {
+	return NewTransaction(pf, overwrite)
+}
+
+// splitXattrNames splits the NUL-terminated names listxattr returns.
+func splitXattrNames(buf []byte) []string {
+	var names []string
+	start := 0
+	for i, b := range buf {
+		if b == 0 {
+			if i > start {
+				names = append(names, string(buf[start:i]))
+			}
+			start = i + 1
+		}
+	}
+	return names
+}
@@ -0,0 +1,101 @@
+//go:build darwin
+
+package files
+
+import (
+	"errors"
+	"syscall"
+	"unsafe"
+)
+
+const xattrSupported = true
+
+// Finder keeps tags and color labels in these attributes.
+const (
+	xattrFinderTags = "com.apple.metadata:_kMDItemUserTags"
+	xattrFinderInfo = "com.apple.FinderInfo"
+)
+
+// basicXattr reports whether name is set by users rather than the system.
+// ACLs are not extended attributes on macOS and are not copied.
+func basicXattr(name string) bool {
+	return name == xattrFinderTags || name == xattrFinderInfo
+}
+
+// The syscall package has no xattr wrappers for darwin, so these call
+// the system calls directly, as golang.org/x/sys/unix once did.
+
+func listXattrs(path string) ([]string, error) {
+	p, err := syscall.BytePtrFromString(path)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		n, _, errno := syscall.Syscall6(syscall.SYS_LISTXATTR, uintptr(unsafe.Pointer(p)), 0, 0, 0, 0, 0)
+		if errno != 0 {
+			return nil, errno
+		}
+		if n == 0 {
+			return nil, nil
+		}
+		buf := make([]byte, n)
+		n, _, errno = syscall.Syscall6(syscall.SYS_LISTXATTR, uintptr(unsafe.Pointer(p)), uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf)), 0, 0, 0)
+		// The list grew since it was sized; try again.
+		if errors.Is(errno, syscall.ERANGE) {
+			continue
+		}
+		if errno != 0 {
+			return nil, errno
+		}
+		return splitXattrNames(buf[:n]), nil
+	}
+}
+
+func getXattr(path, name string) ([]byte, error) {
+	p, err := syscall.BytePtrFromString(path)
+	if err != nil {
+		return nil, err
+	}
+	a, err := syscall.BytePtrFromString(name)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		n, _, errno := syscall.Syscall6(syscall.SYS_GETXATTR, uintptr(unsafe.Pointer(p)), uintptr(unsafe.Pointer(a)), 0, 0, 0, 0)
+		if errno != 0 {
+			return nil, errno
+		}
+		if n == 0 {
+			return nil, nil
+		}
+		buf := make([]byte, n)
+		n, _, errno = syscall.Syscall6(syscall.SYS_GETXATTR, uintptr(unsafe.Pointer(p)), uintptr(unsafe.Pointer(a)), uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf)), 0, 0)
+		if errors.Is(errno, syscall.ERANGE) {
+			continue
+		}
+		if errno != 0 {
+			return nil, errno
+		}
+		return buf[:n], nil
+	}
+}
+
+func setXattr(path, name string, value []byte) error {
+	p, err := syscall.BytePtrFromString(path)
+	if err != nil {
+		return err
+	}
+	a, err := syscall.BytePtrFromString(name)
+	if err != nil {
+		return err
+	}
+	var v unsafe.Pointer
+	if len(value) > 0 {
+		v = unsafe.Pointer(&value[0])
+	}
+	_, _, errno := syscall.Syscall6(syscall.SYS_SETXATTR, uintptr(unsafe.Pointer(p)), uintptr(unsafe.Pointer(a)), uintptr(v), uintptr(len(value)), 0, 0)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
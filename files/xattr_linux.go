@@ -0,0 +1,57 @@
+//go:build linux
+
+package files
+
+import (
+	"errors"
+	"strings"
+	"syscall"
+)
+
+const xattrSupported = true
+
+// basicXattr reports whether name is set by users rather than the system.
+func basicXattr(name string) bool {
+	return strings.HasPrefix(name, "user.")
+}
+
+func listXattrs(path string) ([]string, error) {
+	for {
+		n, err := syscall.Listxattr(path, nil)
+		if err != nil || n == 0 {
+			return nil, err
+		}
+		buf := make([]byte, n)
+		n, err = syscall.Listxattr(path, buf)
+		// The list grew since it was sized; try again.
+		if errors.Is(err, syscall.ERANGE) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return splitXattrNames(buf[:n]), nil
+	}
+}
+
+func getXattr(path, name string) ([]byte, error) {
+	for {
+		n, err := syscall.Getxattr(path, name, nil)
+		if err != nil || n == 0 {
+			return nil, err
+		}
+		buf := make([]byte, n)
+		n, err = syscall.Getxattr(path, name, buf)
+		if errors.Is(err, syscall.ERANGE) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	}
+}
+
+func setXattr(path, name string, value []byte) error {
+	return syscall.Setxattr(path, name, value, 0)
+}
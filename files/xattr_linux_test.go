@@ -0,0 +1,63 @@
+//go:build linux
+
+package files
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+func TestWithPreserve_CopiesUserXattrs(t *testing.T) {
+	tmp := testutil.TempDir(t)
+	src := filepath.Join(tmp, "src.jpg")
+	if err := os.WriteFile(src, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := syscall.Setxattr(src, "user.xdg.tags", []byte("Iceland"), 0); err != nil {
+		if errors.Is(err, syscall.ENOTSUP) {
+			t.Skip("the temp file system has no user extended attributes")
+		}
+		t.Fatal(err)
+	}
+
+	plain := filepath.Join(tmp, "plain.jpg")
+	if err := newFiles().Copy(src, plain); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := getXattr(plain, "user.xdg.tags"); v != nil {
+		t.Errorf("a plain copy carried the tags over: %q", v)
+	}
+
+	for _, p := range []Preserve{PreserveBasic, PreserveAll} {
+		dst := filepath.Join(tmp, string(p), "dst.jpg")
+		if err := WithPreserve(newFiles(), p).Copy(src, dst); err != nil {
+			t.Fatalf("%s: %v", p, err)
+		}
+		if v, err := getXattr(dst, "user.xdg.tags"); err != nil || string(v) != "Iceland" {
+			t.Errorf("%s: user.xdg.tags = %q, %v", p, v, err)
+		}
+	}
+}
+
+func TestParsePreserve(t *testing.T) {
+	for in, want := range map[string]Preserve{"": PreserveNone, "basic": PreserveBasic, "all": PreserveAll} {
+		if got, err := ParsePreserve(in); err != nil || got != want {
+			t.Errorf("ParsePreserve(%q) = %q, %v", in, got, err)
+		}
+	}
+	if _, err := ParsePreserve("acl"); err == nil {
+		t.Error("expected an error for an unknown value")
+	}
+}
+
+func TestSplitXattrNames(t *testing.T) {
+	got := splitXattrNames([]byte("user.a\x00system.posix_acl_access\x00"))
+	if len(got) != 2 || got[0] != "user.a" || got[1] != "system.posix_acl_access" {
+		t.Errorf("splitXattrNames = %q", got)
+	}
+}
@@ -0,0 +1,19 @@
+//go:build !linux && !darwin
+
+package files
+
+import "errors"
+
+// Extended attributes are only copied on Linux and macOS; ParsePreserve
+// refuses the other settings elsewhere.
+const xattrSupported = false
+
+var errXattrUnsupported = errors.New("extended attributes are not supported")
+
+func basicXattr(string) bool { return false }
+
+func listXattrs(string) ([]string, error) { return nil, errXattrUnsupported }
+
+func getXattr(string, string) ([]byte, error) { return nil, errXattrUnsupported }
+
+func setXattr(string, string, []byte) error { return errXattrUnsupported }
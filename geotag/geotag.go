@@ -0,0 +1,170 @@
+// Package geotag reads GPX tracks and finds where photos were taken by
+// matching their capture times against the track points, as exiftool's
+// geotag feature does.
+package geotag
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultMaxGap is the largest time between a photo and the track points
+// its position is taken from; exiftool's GeoMaxIntSecs and GeoMaxExtSecs
+// default to the same half hour.
+const DefaultMaxGap = 30 * time.Minute
+
+// Point is a timed position on a track.
+type Point struct {
+	Time     time.Time
+	Lat, Lon float64
+	// Ele is the elevation in metres, when HasEle.
+	Ele    float64
+	HasEle bool
+	// Track is the name of the GPX file the point came from.
+	Track string
+}
+
+// Tracks are the points of any number of GPX files, in time order.
+type Tracks struct {
+	points []Point
+}
+
+type gpxFile struct {
+	Tracks []struct {
+		Segments []struct {
+			Points []gpxPoint `xml:"trkpt"`
+		} `xml:"trkseg"`
+	} `xml:"trk"`
+}
+
+type gpxPoint struct {
+	Lat  float64  `xml:"lat,attr"`
+	Lon  float64  `xml:"lon,attr"`
+	Ele  *float64 `xml:"ele"`
+	Time string   `xml:"time"`
+}
+
+// IsGPX reports whether path is named like a GPX track.
+func IsGPX(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".gpx")
+}
+
+// Load adds the points of the GPX file at path.
+func (t *Tracks) Load(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := t.Read(f, filepath.Base(path)); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// Read adds the points of a GPX document; points without a time are
+// left out.
+func (t *Tracks) Read(r io.Reader, name string) error {
+	var doc gpxFile
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return fmt.Errorf("reading GPX: %w", err)
+	}
+	for _, trk := range doc.Tracks {
+		for _, seg := range trk.Segments {
+			for _, gp := range seg.Points {
+				at, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(gp.Time))
+				if err != nil {
+					continue
+				}
+				p := Point{Time: at, Lat: gp.Lat, Lon: gp.Lon, Track: name}
+				if gp.Ele != nil {
+					p.Ele, p.HasEle = *gp.Ele, true
+				}
+				t.points = append(t.points, p)
+			}
+		}
+	}
+	sort.SliceStable(t.points, func(i, j int) bool { return t.points[i].Time.Before(t.points[j].Time) })
+	return nil
+}
+
+// Len returns the number of track points.
+func (t *Tracks) Len() int {
+	return len(t.points)
+}
+
+// Locate returns where the track was at the given time: interpolated
+// between the points either side when they are at most maxGap apart,
+// otherwise the nearer of them when it is within maxGap.
+func (t *Tracks) Locate(at time.Time, maxGap time.Duration) (Point, bool) {
+	i := sort.Search(len(t.points), func(i int) bool { return !t.points[i].Time.Before(at) })
+	if i < len(t.points) && t.points[i].Time.Equal(at) {
+		return t.points[i], true
+	}
+	var prev, next *Point
+	if i > 0 {
+		prev = &t.points[i-1]
+	}
+	if i < len(t.points) {
+		next = &t.points[i]
+	}
+	if prev != nil && next != nil && next.Time.Sub(prev.Time) <= maxGap {
+		f := float64(at.Sub(prev.Time)) / float64(next.Time.Sub(prev.Time))
+		p := Point{
+			Time:   at,
+			Lat:    prev.Lat + f*(next.Lat-prev.Lat),
+			Lon:    prev.Lon + f*(next.Lon-prev.Lon),
+			HasEle: prev.HasEle && next.HasEle,
+			Track:  prev.Track,
+		}
+		if p.HasEle {
+			p.Ele = prev.Ele + f*(next.Ele-prev.Ele)
+		}
+		return p, true
+	}
+	switch {
+	case prev != nil && at.Sub(prev.Time) <= maxGap && (next == nil || at.Sub(prev.Time) <= next.Time.Sub(at)):
+		return *prev, true
+	case next != nil && next.Time.Sub(at) <= maxGap:
+		return *next, true
+	}
+	return Point{}, false
+}
+
+// Tags returns the EXIF GPS tags that place a photo at p, for exiftool to
+// write.
+func (p Point) Tags() map[string]string {
+	ref := func(v float64, pos, neg string) string {
+		if v < 0 {
+			return neg
+		}
+		return pos
+	}
+	format := func(v float64) string { return strconv.FormatFloat(math.Abs(v), 'f', 6, 64) }
+	tags := map[string]string{
+		"GPSLatitude":     format(p.Lat),
+		"GPSLatitudeRef":  ref(p.Lat, "N", "S"),
+		"GPSLongitude":    format(p.Lon),
+		"GPSLongitudeRef": ref(p.Lon, "E", "W"),
+		"GPSDateStamp":    p.Time.UTC().Format("2006:01:02"),
+		"GPSTimeStamp":    p.Time.UTC().Format("15:04:05"),
+	}
+	if p.HasEle {
+		tags["GPSAltitude"] = strconv.FormatFloat(math.Abs(p.Ele), 'f', 1, 64)
+		tags["GPSAltitudeRef"] = ref(p.Ele, "Above Sea Level", "Below Sea Level")
+	}
+	return tags
+}
+
+// String formats the position as "lat, lon".
+func (p Point) String() string {
+	return fmt.Sprintf("%.5f, %.5f", p.Lat, p.Lon)
+}
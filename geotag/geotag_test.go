@@ -0,0 +1,85 @@
+package geotag
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const track = `<?xml version="1.0" encoding="UTF-8"?>
+<gpx version="1.1" creator="test" xmlns="http://www.topografix.com/GPX/1/1">
+  <trk><name>walk</name><trkseg>
+    <trkpt lat="48.0" lon="2.0"><ele>100</ele><time>2025-01-27T21:00:00Z</time></trkpt>
+    <trkpt lat="48.1" lon="2.2"><ele>200</ele><time>2025-01-27T21:10:00Z</time></trkpt>
+    <trkpt lat="10.0" lon="-3.0"><time>2025-01-27T23:00:00Z</time></trkpt>
+    <trkpt lat="0" lon="0"></trkpt>
+  </trkseg></trk>
+</gpx>`
+
+func TestTracks_Locate(t *testing.T) {
+	var tr Tracks
+	if err := tr.Read(strings.NewReader(track), "walk.gpx"); err != nil {
+		t.Fatal(err)
+	}
+	if tr.Len() != 3 {
+		t.Fatalf("expected the 3 timed points, got %d", tr.Len())
+	}
+	at := func(s string) time.Time {
+		t.Helper()
+		v, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+	tests := []struct {
+		name     string
+		at       string
+		ok       bool
+		lat, lon float64
+	}{
+		{"exact", "2025-01-27T21:00:00Z", true, 48.0, 2.0},
+		{"interpolated", "2025-01-27T15:05:00-06:00", true, 48.05, 2.1},
+		{"after the last point", "2025-01-27T23:20:00Z", true, 10.0, -3.0},
+		{"nearer end of a long gap", "2025-01-27T21:25:00Z", true, 48.1, 2.2},
+		{"middle of a long gap", "2025-01-27T22:05:00Z", false, 0, 0},
+		{"before the track", "2025-01-27T20:00:00Z", false, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, ok := tr.Locate(at(tt.at), DefaultMaxGap)
+			if ok != tt.ok {
+				t.Fatalf("ok = %v, want %v", ok, tt.ok)
+			}
+			if ok && (abs(p.Lat-tt.lat) > 1e-9 || abs(p.Lon-tt.lon) > 1e-9) {
+				t.Errorf("position = %s, want %.5f, %.5f", p, tt.lat, tt.lon)
+			}
+		})
+	}
+}
+
+func TestPoint_Tags(t *testing.T) {
+	p := Point{Time: time.Date(2025, 1, 27, 21, 5, 0, 0, time.UTC), Lat: -33.8568, Lon: 151.2153, Ele: -4, HasEle: true}
+	want := map[string]string{
+		"GPSLatitude": "33.856800", "GPSLatitudeRef": "S",
+		"GPSLongitude": "151.215300", "GPSLongitudeRef": "E",
+		"GPSDateStamp": "2025:01:27", "GPSTimeStamp": "21:05:00",
+		"GPSAltitude": "4.0", "GPSAltitudeRef": "Below Sea Level",
+	}
+	got := p.Tags()
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %q, want %q", k, got[k], v)
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("unexpected tags: %v", got)
+	}
+}
+
+func abs(v float64) float64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -5,6 +5,7 @@ go 1.24.4
 require (
 	github.com/barasher/go-exiftool v1.10.0
 	github.com/spf13/cobra v1.9.1
+	golang.org/x/text v0.30.0
 )
 
 require (
@@ -0,0 +1,91 @@
+package hashindex
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strings"
+)
+
+// Algo is a content hash algorithm.
+type Algo string
+
+const (
+	// SHA256 is strong and the default. Its hashes are recorded as plain
+	// hex, as before other algorithms were supported.
+	SHA256 Algo = "sha256"
+	// XXH64 is xxHash64: much faster, but only guards against accidental
+	// corruption. Its hashes are recorded as "xxh64:<hex>".
+	XXH64 Algo = "xxh64"
+)
+
+// ParseAlgo accepts "sha256" and "xxhash64" (or "xxh64"); empty is SHA256.
+func ParseAlgo(s string) (Algo, error) {
+	switch strings.ToLower(s) {
+	case "", "sha256", "sha-256":
+		return SHA256, nil
+	case "xxhash64", "xxh64", "xxhash":
+		return XXH64, nil
+	}
+	return "", fmt.Errorf("unknown hash algorithm %q (want sha256 or xxhash64)", s)
+}
+
+// AlgoOf returns the algorithm a recorded hash was computed with.
+func AlgoOf(hash string) Algo {
+	if strings.HasPrefix(hash, string(XXH64)+":") {
+		return XXH64
+	}
+	return SHA256
+}
+
+// Recorded reports whether hash was computed with a. The zero Algo is
+// SHA256.
+func (a Algo) Recorded(hash string) bool {
+	if a == "" {
+		a = SHA256
+	}
+	return AlgoOf(hash) == a
+}
+
+// New returns a fresh hash of the algorithm.
+func (a Algo) New() hash.Hash {
+	if a == XXH64 {
+		return newXXH64()
+	}
+	return sha256.New()
+}
+
+// Record formats the sum of a hash from New as it is recorded in indexes
+// and catalogs, naming the algorithm unless it is SHA256.
+func (a Algo) Record(sum []byte) string {
+	if a == XXH64 {
+		return string(XXH64) + ":" + hex.EncodeToString(sum)
+	}
+	return hex.EncodeToString(sum)
+}
+
+// Hex strips the algorithm from a recorded hash, leaving the hex digest
+// that checksum tools print.
+func Hex(hash string) string {
+	if i := strings.IndexByte(hash, ':'); i >= 0 {
+		return hash[i+1:]
+	}
+	return hash
+}
+
+// HashFile returns the recorded hash of the file at path.
+func (a Algo) HashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := a.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return a.Record(h.Sum(nil)), nil
+}
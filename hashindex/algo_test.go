@@ -0,0 +1,64 @@
+package hashindex
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestXXH64(t *testing.T) {
+	tests := map[string]string{
+		"":    "ef46db3751d8e999",
+		"a":   "d24ec4f1a98c6e5b",
+		"abc": "44bc2cf5ad770999",
+		"Nobody inspects the spammish repetition": "fbcea83c8a378bf1",
+	}
+	for in, want := range tests {
+		if got := XXH64.Record(hashOf(XXH64, []byte(in), len(in)+1)); got != "xxh64:"+want {
+			t.Errorf("xxh64(%q) = %s, want %s", in, got, want)
+		}
+	}
+	// Written in pieces that straddle the 32-byte stripes.
+	long := []byte(strings.Repeat("0123456789abcdef", 20) + "xyz")
+	whole := hashOf(XXH64, long, len(long))
+	for _, chunk := range []int{1, 7, 31, 33} {
+		if got := hashOf(XXH64, long, chunk); string(got) != string(whole) {
+			t.Errorf("writing in chunks of %d gives %x, want %x", chunk, got, whole)
+		}
+	}
+}
+
+func hashOf(a Algo, data []byte, chunk int) []byte {
+	h := a.New()
+	for len(data) > 0 {
+		n := min(chunk, len(data))
+		h.Write(data[:n])
+		data = data[n:]
+	}
+	return h.Sum(nil)
+}
+
+func TestAlgo(t *testing.T) {
+	for in, want := range map[string]Algo{"": SHA256, "SHA256": SHA256, "xxhash64": XXH64, "xxh64": XXH64} {
+		if got, err := ParseAlgo(in); err != nil || got != want {
+			t.Errorf("ParseAlgo(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseAlgo("md5"); err == nil {
+		t.Error("expected an error for md5")
+	}
+
+	path := filepath.Join(t.TempDir(), "a.jpg")
+	if err := os.WriteFile(path, []byte("abc"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sha, _ := SHA256.HashFile(path)
+	xx, _ := XXH64.HashFile(path)
+	if sha != "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad" || AlgoOf(sha) != SHA256 {
+		t.Errorf("sha256 = %s", sha)
+	}
+	if xx != "xxh64:44bc2cf5ad770999" || AlgoOf(xx) != XXH64 || Hex(xx) != "44bc2cf5ad770999" {
+		t.Errorf("xxh64 = %s", xx)
+	}
+}
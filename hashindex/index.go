@@ -0,0 +1,222 @@
+// Package hashindex keeps a content-hash index of an archive, so a file can
+// be recognised as already archived wherever it was placed. The index lives
+// in a hidden file at the archive root and is refreshed incrementally: only
+// files whose size or modification time changed are hashed again.
+package hashindex
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// FileName is the index file kept at the archive root.
+const FileName = ".gocamelpack-index.json"
+
+// Entry is what the index knows about one archived file.
+type Entry struct {
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mtime"`
+	// Hash is hex SHA-256, or prefixed with its algorithm, e.g. "xxh64:".
+	Hash string `json:"hash"`
+}
+
+// UnmarshalJSON also reads the "sha256" key indexes used for the hash
+// before other algorithms were supported.
+func (e *Entry) UnmarshalJSON(b []byte) error {
+	type entry Entry
+	var v struct {
+		entry
+		SHA256 string `json:"sha256"`
+	}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	*e = Entry(v.entry)
+	if e.Hash == "" {
+		e.Hash = v.SHA256
+	}
+	return nil
+}
+
+// Index maps the archive's files, by slash-separated path relative to the
+// root, to their content hashes.
+type Index struct {
+	root    string
+	algo    Algo
+	entries map[string]Entry
+	byHash  map[string][]string
+	dirty   bool
+}
+
+// Load reads the index of the archive at root, which Refresh keeps in
+// hashes of algo. A missing index is empty.
+func Load(root string, algo Algo) (*Index, error) {
+	ix := &Index{root: root, algo: algo, entries: map[string]Entry{}}
+	data, err := os.ReadFile(filepath.Join(root, FileName))
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return nil, fmt.Errorf("reading archive index: %w", err)
+	default:
+		if err := json.Unmarshal(data, &ix.entries); err != nil {
+			return nil, fmt.Errorf("parsing archive index %s: %w", filepath.Join(root, FileName), err)
+		}
+	}
+	ix.reindex()
+	return ix, nil
+}
+
+func (ix *Index) reindex() {
+	ix.byHash = map[string][]string{}
+	for rel, e := range ix.entries {
+		ix.byHash[e.Hash] = append(ix.byHash[e.Hash], rel)
+	}
+}
+
+// Len returns the number of indexed files.
+func (ix *Index) Len() int {
+	return len(ix.entries)
+}
+
+// Refresh brings the index up to date with the archive: new and changed
+// files, and files hashed with another algorithm, are hashed and vanished
+// ones dropped. Hidden files and folders are not indexed. It returns how many files had to be hashed.
+func (ix *Index) Refresh() (hashed int, err error) {
+	seen := map[string]bool{}
+	if _, err := os.Stat(ix.root); errors.Is(err, fs.ErrNotExist) {
+		// A new archive; the index is created along with it.
+		ix.entries = map[string]Entry{}
+		ix.reindex()
+		return 0, nil
+	}
+	err = filepath.WalkDir(ix.root, func(path string, e fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(e.Name(), ".") && path != ix.root {
+			if e.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !e.Type().IsRegular() {
+			return nil
+		}
+		info, err := e.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := ix.rel(path)
+		if err != nil {
+			return err
+		}
+		seen[rel] = true
+		if old, ok := ix.entries[rel]; ok && old.Size == info.Size() && old.ModTime.Equal(info.ModTime()) && ix.algo.Recorded(old.Hash) {
+			return nil
+		}
+		hash, err := ix.algo.HashFile(path)
+		if err != nil {
+			return err
+		}
+		ix.entries[rel] = Entry{Size: info.Size(), ModTime: info.ModTime(), Hash: hash}
+		ix.dirty = true
+		hashed++
+		return nil
+	})
+	if err != nil {
+		return hashed, fmt.Errorf("indexing archive: %w", err)
+	}
+	for rel := range ix.entries {
+		if !seen[rel] {
+			delete(ix.entries, rel)
+			ix.dirty = true
+		}
+	}
+	ix.reindex()
+	return hashed, nil
+}
+
+// Lookup returns the absolute path of an archived file with the given
+// content hash. Files changed or removed since they were indexed are not
+// returned.
+func (ix *Index) Lookup(hash string) (string, bool) {
+	for _, rel := range ix.byHash[hash] {
+		path := filepath.Join(ix.root, filepath.FromSlash(rel))
+		info, err := os.Stat(path)
+		e := ix.entries[rel]
+		if err == nil && info.Size() == e.Size && info.ModTime().Equal(e.ModTime) {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// Add records the file at path, inside the archive, as having hash.
+func (ix *Index) Add(path, hash string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	rel, err := ix.rel(path)
+	if err != nil {
+		return err
+	}
+	old, ok := ix.entries[rel]
+	if ok {
+		ix.byHash[old.Hash] = slices.DeleteFunc(ix.byHash[old.Hash], func(r string) bool { return r == rel })
+	}
+	ix.entries[rel] = Entry{Size: info.Size(), ModTime: info.ModTime(), Hash: hash}
+	ix.byHash[hash] = append(ix.byHash[hash], rel)
+	ix.dirty = true
+	return nil
+}
+
+func (ix *Index) rel(path string) (string, error) {
+	rel, err := filepath.Rel(ix.root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside the archive %s", path, ix.root)
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// Save writes the index if it changed, through a temporary file and rename
+// so a crash never leaves a truncated index.
+func (ix *Index) Save() error {
+	if !ix.dirty {
+		return nil
+	}
+	data, err := json.Marshal(ix.entries)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(ix.root, ".gocamelpack-index-*")
+	if err != nil {
+		return fmt.Errorf("writing archive index: %w", err)
+	}
+	_, werr := tmp.Write(append(data, '\n'))
+	cerr := tmp.Close()
+	if werr == nil {
+		werr = cerr
+	}
+	if werr == nil {
+		werr = os.Rename(tmp.Name(), filepath.Join(ix.root, FileName))
+	}
+	if werr != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("writing archive index: %w", werr)
+	}
+	ix.dirty = false
+	return nil
+}
+
+// HashFile returns the hex SHA-256 of the file at path.
+func HashFile(path string) (string, error) {
+	return SHA256.HashFile(path)
+}
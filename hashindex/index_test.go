@@ -0,0 +1,130 @@
+package hashindex
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func write(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIndex_RefreshIsIncremental(t *testing.T) {
+	root := t.TempDir()
+	a := filepath.Join(root, "2025/01/27/15_30.jpg")
+	b := filepath.Join(root, "2025/01/28/09_00.jpg")
+	write(t, a, "a")
+	write(t, b, "b")
+	write(t, filepath.Join(root, ".thumbnails/a.jpg"), "a")
+
+	ix, err := Load(root, SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, err := ix.Refresh(); err != nil || n != 2 {
+		t.Fatalf("first refresh hashed %d file(s), err %v; want 2", n, err)
+	}
+	if err := ix.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	ix, err = Load(root, SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ix.Len() != 2 {
+		t.Fatalf("loaded %d entries, want 2", ix.Len())
+	}
+	write(t, b, "changed")
+	os.Chtimes(b, time.Now(), time.Now().Add(time.Minute))
+	os.Remove(a)
+	if n, err := ix.Refresh(); err != nil || n != 1 {
+		t.Fatalf("second refresh hashed %d file(s), err %v; want only the changed one", n, err)
+	}
+	if ix.Len() != 1 {
+		t.Errorf("removed file should be dropped, have %d entries", ix.Len())
+	}
+
+	hash, err := HashFile(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := ix.Lookup(hash); !ok || got != b {
+		t.Errorf("Lookup = %q, %v; want %q", got, ok, b)
+	}
+	if _, ok := ix.Lookup("0000"); ok {
+		t.Error("unknown hash should not be found")
+	}
+}
+
+func TestIndex_AddAndStaleEntries(t *testing.T) {
+	root := t.TempDir()
+	ix, err := Load(root, SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ix.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+
+	p := filepath.Join(root, "2025/15_30.jpg")
+	write(t, p, "content")
+	hash, _ := HashFile(p)
+	if err := ix.Add(p, hash); err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := ix.Lookup(hash); !ok || got != p {
+		t.Errorf("Lookup after Add = %q, %v", got, ok)
+	}
+	if err := ix.Add(filepath.Join(filepath.Dir(root), "elsewhere.jpg"), hash); err == nil {
+		t.Error("files outside the archive should be rejected")
+	}
+
+	// Edited since indexing: no longer a trustworthy match.
+	write(t, p, "edited content")
+	if _, ok := ix.Lookup(hash); ok {
+		t.Error("a changed file should not be returned")
+	}
+}
+
+func TestIndex_MissingRoot(t *testing.T) {
+	ix, err := Load(filepath.Join(t.TempDir(), "new"), SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, err := ix.Refresh(); err != nil || n != 0 {
+		t.Errorf("Refresh of a missing archive = %d, %v", n, err)
+	}
+}
+
+func TestIndex_RefreshRehashesOtherAlgo(t *testing.T) {
+	root := t.TempDir()
+	a := filepath.Join(root, "a.jpg")
+	write(t, a, "abc")
+	ix, _ := Load(root, SHA256)
+	if _, err := ix.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	if err := ix.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	ix, err := Load(root, XXH64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, err := ix.Refresh(); err != nil || n != 1 {
+		t.Fatalf("refresh with xxh64 hashed %d file(s), err %v; want the sha256 entry rehashed", n, err)
+	}
+	if got, ok := ix.Lookup("xxh64:44bc2cf5ad770999"); !ok || got != a {
+		t.Errorf("Lookup = %q, %v; want %q", got, ok, a)
+	}
+}
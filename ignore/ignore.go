@@ -0,0 +1,214 @@
+// Package ignore reads .gocamelpackignore files, which leave files and
+// folders of a source directory out of every run. The syntax is that of
+// .gitignore: one glob pattern per line, # for comments, ! to re-include,
+// a trailing / for directories only, and a leading or inner / anchoring the
+// pattern to the directory of the ignore file. ** matches any number of
+// folders.
+package ignore
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/Tmunayyer/gocamelpack/vfs"
+)
+
+// Filename is the name of ignore files. Ignore files are never collected
+// themselves.
+const Filename = ".gocamelpackignore"
+
+type pattern struct {
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// Matcher holds the patterns of one ignore file.
+type Matcher struct {
+	patterns []pattern
+}
+
+// Parse reads the patterns of an ignore file.
+func Parse(r io.Reader) (*Matcher, error) {
+	var m Matcher
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		p, ok, err := parseLine(sc.Text())
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		if ok {
+			m.patterns = append(m.patterns, p)
+		}
+	}
+	return &m, sc.Err()
+}
+
+// parseLine turns one line into a pattern; ok is false for blank lines
+// and comments.
+func parseLine(line string) (p pattern, ok bool, err error) {
+	line = strings.TrimSuffix(line, "\r")
+	// Trailing spaces are dropped unless escaped.
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, "\\ ") {
+		line = line[:len(line)-1]
+	}
+	if line == "" || line[0] == '#' {
+		return p, false, nil
+	}
+	if line[0] == '!' {
+		p.negate = true
+		line = line[1:]
+	} else if line[0] == '\\' && len(line) > 1 && (line[1] == '#' || line[1] == '!') {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		p.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return p, false, nil
+	}
+
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+	expr, err := translate(line)
+	if err != nil {
+		return p, false, err
+	}
+	if !anchored {
+		expr = "(?:.*/)?" + expr
+	}
+	p.re, err = regexp.Compile("^" + expr + "$")
+	return p, err == nil, err
+}
+
+// translate turns a glob into a regular expression over slash-separated
+// paths.
+func translate(glob string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/") && (i == 0 || glob[i-1] == '/'):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case glob[i:] == "**" && i > 0 && glob[i-1] == '/':
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '\\':
+			if i+1 == len(glob) {
+				return "", errors.New("trailing backslash")
+			}
+			i++
+			b.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				return "", fmt.Errorf("unterminated [ in %q", glob)
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String(), nil
+}
+
+// Match reports whether the last pattern matching rel, a slash-separated
+// path relative to the ignore file's directory, ignores it. matched is
+// false when no pattern applies.
+func (m *Matcher) Match(rel string, isDir bool) (ignored, matched bool) {
+	for i := len(m.patterns) - 1; i >= 0; i-- {
+		p := m.patterns[i]
+		if p.dirOnly && !isDir {
+			continue
+		}
+		if p.re.MatchString(rel) {
+			return !p.negate, true
+		}
+	}
+	return false, false
+}
+
+// Tree applies the ignore files of a directory tree: each one to the paths
+// below its directory, deeper ones taking precedence. Ignore files are
+// read as they are first needed. A Tree is not safe for concurrent use.
+type Tree struct {
+	fsys     vfs.FS
+	root     string
+	matchers map[string]*Matcher // directory -> its ignore file, nil if none
+}
+
+// reserved are gocamelpack's own files in a source tree, which are never
+// collected: ignore files and the archive marker (files.ArchiveMarker).
+var reserved = map[string]bool{Filename: true, ".gocamelpack-archive": true}
+
+// NewTree returns the ignore files of the tree at root.
+func NewTree(fsys vfs.FS, root string) *Tree {
+	return &Tree{fsys: fsys, root: filepath.Clean(root), matchers: map[string]*Matcher{}}
+}
+
+// Ignored reports whether path, a file or, with isDir, a directory below
+// the root, is left out. Paths below an ignored directory should not be
+// asked about: like git, a Tree cannot re-include them.
+func (t *Tree) Ignored(path string, isDir bool) (bool, error) {
+	if !isDir && reserved[filepath.Base(path)] {
+		return true, nil
+	}
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		m, err := t.matcher(dir)
+		if err != nil {
+			return false, err
+		}
+		if m != nil {
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return false, err
+			}
+			if ignored, matched := m.Match(filepath.ToSlash(rel), isDir); matched {
+				return ignored, nil
+			}
+		}
+		if dir == t.root || dir == filepath.Dir(dir) {
+			return false, nil
+		}
+	}
+}
+
+// matcher returns the ignore file of dir, or nil when it has none.
+func (t *Tree) matcher(dir string) (*Matcher, error) {
+	if m, ok := t.matchers[dir]; ok {
+		return m, nil
+	}
+	path := filepath.Join(dir, Filename)
+	f, err := t.fsys.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		t.matchers[dir] = nil
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	m, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	t.matchers[dir] = m
+	return m, nil
+}
@@ -0,0 +1,100 @@
+package ignore
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/testutil"
+	"github.com/Tmunayyer/gocamelpack/vfs"
+)
+
+func TestMatcher(t *testing.T) {
+	m, err := Parse(strings.NewReader(`# NAS and phone clutter
+@eaDir/
+.thumbnails
+*.tmp
+!keep.tmp
+/top.jpg
+raw/**/*.xmp
+**/cache/*.db
+\#hash.jpg
+IMG_00[!0]?.jpg
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"@eaDir", true, true},
+		{"DCIM/@eaDir", true, true},
+		{"@eaDir", false, false},
+		{"DCIM/.thumbnails", true, true},
+		{"a.tmp", false, true},
+		{"DCIM/keep.tmp", false, false},
+		{"top.jpg", false, true},
+		{"DCIM/top.jpg", false, false},
+		{"raw/a.xmp", false, true},
+		{"raw/2025/01/a.xmp", false, true},
+		{"other/raw/a.xmp", false, false},
+		{"x/y/cache/thumbs.db", false, true},
+		{"#hash.jpg", false, true},
+		{"IMG_0012.jpg", false, true},
+		{"IMG_0001.jpg", false, false},
+	}
+	for _, tc := range tests {
+		if got, _ := m.Match(tc.path, tc.isDir); got != tc.want {
+			t.Errorf("Match(%q, %v) = %v, want %v", tc.path, tc.isDir, got, tc.want)
+		}
+	}
+
+	if _, err := Parse(strings.NewReader("ok\n[abc\n")); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("unterminated class: got %v", err)
+	}
+}
+
+func TestTree(t *testing.T) {
+	root := filepath.Join(testutil.TempDir(t), "ignore-tree")
+	write := func(path, content string) {
+		t.Helper()
+		path = filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(Filename, "*.mov\nprivate/\n")
+	write(filepath.Join("DCIM", Filename), "!*.mov\n/skip.jpg\n")
+
+	tree := NewTree(vfs.OS, root)
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{Filename, false, true},
+		{".gocamelpack-archive", false, true},
+		{"clip.mov", false, true},
+		{"DCIM/clip.mov", false, false},
+		{"DCIM/skip.jpg", false, true},
+		{"skip.jpg", false, false},
+		{"DCIM/sub/skip.jpg", false, false},
+		{"DCIM/private", true, true},
+		{"a.jpg", false, false},
+	}
+	for _, tc := range tests {
+		got, err := tree.Ignored(filepath.Join(root, tc.path), tc.isDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tc.want {
+			t.Errorf("Ignored(%q) = %v, want %v", tc.path, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,360 @@
+// Package importer is the library API for copying or moving media into a
+// dated archive. It runs the same plan → validate → execute pipeline as
+// the copy and move commands, and tells the embedding application about
+// each file through the Reporter callbacks instead of terminal output.
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/internal/engine"
+	"github.com/Tmunayyer/gocamelpack/pathtmpl"
+	"github.com/Tmunayyer/gocamelpack/progress"
+)
+
+// File is one source and where it goes. Destination is empty for a file
+// that failed before it was planned.
+type File struct {
+	Source      string
+	Destination string
+}
+
+// Reporter receives per-file events. The calls never overlap, even with
+// several jobs.
+type Reporter interface {
+	// OnFileStart is called before a file is transferred.
+	OnFileStart(f File)
+	// OnFileDone is called once a file has been transferred.
+	OnFileDone(f File)
+	// OnError is called for a file that could not be planned, validated
+	// or transferred.
+	OnError(f File, err error)
+}
+
+// Callbacks is a Reporter made of functions; nil ones are skipped.
+type Callbacks struct {
+	FileStart func(f File)
+	FileDone  func(f File)
+	Error     func(f File, err error)
+}
+
+// OnFileStart implements Reporter.
+func (c Callbacks) OnFileStart(f File) {
+	if c.FileStart != nil {
+		c.FileStart(f)
+	}
+}
+
+// OnFileDone implements Reporter.
+func (c Callbacks) OnFileDone(f File) {
+	if c.FileDone != nil {
+		c.FileDone(f)
+	}
+}
+
+// OnError implements Reporter.
+func (c Callbacks) OnError(f File, err error) {
+	if c.Error != nil {
+		c.Error(f, err)
+	}
+}
+
+// Options configures an Importer.
+type Options struct {
+	// Move moves the sources instead of copying them.
+	Move bool
+	// Atomic runs the files as one transaction: on the first failure the
+	// files already transferred are rolled back.
+	Atomic bool
+	// Overwrite replaces existing destinations instead of refusing them.
+	Overwrite bool
+	// Template lays out the destination paths; empty is pathtmpl.Default.
+	Template string
+	// Jobs is how many files are transferred at once; not with Atomic.
+	Jobs int
+	// SkipErrors reports a failed file and carries on with the others;
+	// not with Atomic.
+	SkipErrors bool
+	// SkipExisting leaves out sources whose destination already exists
+	// instead of failing them; not with Overwrite.
+	SkipExisting bool
+	// Progress, when set, is shown the overall progress of a run.
+	Progress progress.ProgressReporter
+}
+
+// Importer copies or moves files into a dated archive. An Importer may
+// run several times, one run at a time.
+type Importer struct {
+	fs   files.FilesService
+	opts Options
+	tmpl *pathtmpl.Template
+
+	mu        sync.Mutex
+	reporters []Reporter
+}
+
+// New returns an Importer working through fs.
+func New(fs files.FilesService, opts Options) (*Importer, error) {
+	if opts.Atomic && opts.SkipErrors {
+		return nil, fmt.Errorf("SkipErrors cannot be combined with Atomic")
+	}
+	if opts.Atomic && opts.Jobs > 1 {
+		return nil, fmt.Errorf("Jobs cannot be combined with Atomic")
+	}
+	if opts.Overwrite && opts.SkipExisting {
+		return nil, fmt.Errorf("SkipExisting cannot be combined with Overwrite")
+	}
+	if opts.Template == "" {
+		opts.Template = pathtmpl.Default
+	}
+	tmpl, err := pathtmpl.Parse(opts.Template)
+	if err != nil {
+		return nil, err
+	}
+	return &Importer{fs: fs, opts: opts, tmpl: tmpl}, nil
+}
+
+// Register adds r to the reporters told about each file, in
+// registration order.
+func (im *Importer) Register(r Reporter) {
+	im.mu.Lock()
+	defer im.mu.Unlock()
+	im.reporters = append(im.reporters, r)
+}
+
+// Failure is a file that failed and why.
+type Failure struct {
+	File
+	Err error
+}
+
+// Result is what a run did, including when it ended early. Every source
+// is in exactly one of Completed, Skipped, Failed and Pending.
+type Result struct {
+	// Completed are the files transferred and still in place.
+	Completed []File
+	// Skipped are the sources SkipExisting left out.
+	Skipped []File
+	// Failed are the files that failed.
+	Failed []Failure
+	// Pending are the sources the run ended before transferring, or whose
+	// transfer was rolled back. Those not planned yet have no
+	// Destination.
+	Pending []File
+	// RolledBack is set when an atomic run failed while executing and
+	// undid the files it had transferred.
+	RolledBack bool
+}
+
+// run is the state of one Run. Its methods tell the reporters about a
+// file and record it; they serialize both, since Transfer runs
+// concurrently with several jobs.
+type run struct {
+	reporters []Reporter
+
+	mu      sync.Mutex
+	planned map[string]string
+	settled map[string]bool
+	res     Result
+}
+
+func (ru *run) plan(src, dst string) {
+	ru.mu.Lock()
+	defer ru.mu.Unlock()
+	ru.planned[src] = dst
+}
+
+func (ru *run) fileStart(f File) {
+	ru.mu.Lock()
+	defer ru.mu.Unlock()
+	for _, r := range ru.reporters {
+		r.OnFileStart(f)
+	}
+}
+
+func (ru *run) fileDone(f File) {
+	ru.mu.Lock()
+	defer ru.mu.Unlock()
+	for _, r := range ru.reporters {
+		r.OnFileDone(f)
+	}
+	ru.settled[f.Source] = true
+	ru.res.Completed = append(ru.res.Completed, f)
+}
+
+func (ru *run) fileError(f File, err error) {
+	ru.mu.Lock()
+	defer ru.mu.Unlock()
+	for _, r := range ru.reporters {
+		r.OnError(f, err)
+	}
+	ru.settled[f.Source] = true
+	ru.res.Failed = append(ru.res.Failed, Failure{File: f, Err: err})
+}
+
+func (ru *run) skip(f File) {
+	ru.mu.Lock()
+	defer ru.mu.Unlock()
+	ru.settled[f.Source] = true
+	ru.res.Skipped = append(ru.res.Skipped, f)
+}
+
+// rollBack moves the completed files back to pending.
+func (ru *run) rollBack() {
+	ru.mu.Lock()
+	defer ru.mu.Unlock()
+	for _, f := range ru.res.Completed {
+		ru.settled[f.Source] = false
+	}
+	ru.res.Completed = nil
+	ru.res.RolledBack = true
+}
+
+// result lists the sources not settled as pending.
+func (ru *run) result(sources []string) Result {
+	ru.mu.Lock()
+	defer ru.mu.Unlock()
+	res := ru.res
+	for _, src := range sources {
+		if !ru.settled[src] {
+			res.Pending = append(res.Pending, File{Source: src, Destination: ru.planned[src]})
+		}
+	}
+	return res
+}
+
+// Run copies or moves sources under dstRoot. It stops at the first
+// failure unless SkipErrors is set, and once ctx is done: with Atomic the
+// files already transferred are then rolled back, otherwise they stay and
+// the file being transferred is finished first. The Result says where
+// every source ended up, also when err is not nil.
+func (im *Importer) Run(ctx context.Context, sources []string, dstRoot string) (Result, error) {
+	kind := files.OperationCopy
+	if im.opts.Move {
+		kind = files.OperationMove
+	}
+	r := im.opts.Progress
+	if r == nil {
+		r = progress.NewNoOpReporter()
+	}
+	im.mu.Lock()
+	ru := &run{
+		reporters: append([]Reporter(nil), im.reporters...),
+		planned:   map[string]string{},
+		settled:   map[string]bool{},
+	}
+	im.mu.Unlock()
+
+	md := map[string]files.FileMetadata{}
+	for _, m := range im.fs.GetFileTags(sources) {
+		md[m.Filepath] = m
+	}
+
+	failed := func(it engine.Item, err error) error {
+		ru.fileError(File(it), err)
+		if im.opts.SkipErrors {
+			return nil
+		}
+		return err
+	}
+
+	var mode engine.Mode
+	if im.opts.Atomic {
+		mode = engine.NewAtomic(r, engine.AtomicOptions{
+			Kind: kind,
+			Tx:   im.fs.NewTransaction(im.opts.Overwrite),
+			Decorate: func(op files.Operation) files.Operation {
+				return &reportedOperation{WrappedOperation: files.WrappedOperation{Operation: op}, run: ru}
+			},
+			Execute: func(tx files.Transaction) error {
+				err := tx.ExecuteWithProgress(r)
+				var te *files.TransactionError
+				if err != nil && !(errors.As(err, &te) && te.Phase == "commit") {
+					ru.rollBack()
+				}
+				return err
+			},
+			Context: ctx,
+		})
+	} else {
+		direct := engine.DirectOptions{
+			Kind: kind,
+			Transfer: func(it engine.Item) (files.Operation, error) {
+				ru.fileStart(File(it))
+				op := it.Operation(kind)
+				tx := im.fs.NewTransaction(im.opts.Overwrite)
+				if err := tx.Add(op); err != nil {
+					return nil, err
+				}
+				return op, tx.Execute()
+			},
+			Done: func(op files.Operation) error {
+				ru.fileDone(File{Source: op.Source(), Destination: op.Destination()})
+				return nil
+			},
+			Jobs:    im.opts.Jobs,
+			Failed:  failed,
+			Context: ctx,
+		}
+		if !im.opts.Overwrite {
+			direct.Check = func(it engine.Item) error { return im.fs.ValidateCopyArgs(it.Source, it.Destination) }
+		}
+		mode = engine.NewDirect(r, len(sources), direct)
+	}
+
+	pipeline := engine.Pipeline{
+		Plan: func(src string) (string, bool, error) {
+			m, ok := md[src]
+			if !ok {
+				return "", false, fmt.Errorf("no metadata for %s", src)
+			}
+			dst, err := im.tmpl.Destination(m, dstRoot)
+			if err != nil {
+				return "", false, err
+			}
+			ru.plan(src, dst)
+			if im.opts.SkipExisting {
+				if _, err := files.FSOf(im.fs).Stat(dst); err == nil {
+					ru.skip(File{Source: src, Destination: dst})
+					return dst, true, nil
+				} else if !os.IsNotExist(err) {
+					return "", false, err
+				}
+			}
+			return dst, false, nil
+		},
+		Mode:    mode,
+		Failed:  failed,
+		Context: ctx,
+	}
+	_, err := pipeline.Run(sources)
+	// An atomic run refused before it executed fails the file refused.
+	var te *files.TransactionError
+	if errors.As(err, &te) && te.Phase == "planning" && te.Operation != nil {
+		ru.fileError(File{Source: te.Operation.Source(), Destination: te.Operation.Destination()}, te.Err)
+	}
+	return ru.result(sources), err
+}
+
+// reportedOperation tells the run's reporters about an operation of an
+// atomic run as the transaction executes it.
+type reportedOperation struct {
+	files.WrappedOperation
+	run *run
+}
+
+func (ro *reportedOperation) Execute(fs files.FilesService) error {
+	f := File{Source: ro.Source(), Destination: ro.Destination()}
+	ro.run.fileStart(f)
+	if err := ro.Operation.Execute(fs); err != nil {
+		ro.run.fileError(f, err)
+		return err
+	}
+	ro.run.fileDone(f)
+	return nil
+}
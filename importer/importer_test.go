@@ -0,0 +1,278 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+// diskFiles is a FilesService on the real disk whose every file was taken
+// on 2025-01-27 at 15:30; copies of the sources in fail are refused.
+type diskFiles struct {
+	fail map[string]bool
+}
+
+func (d diskFiles) Close() {}
+
+func (d diskFiles) IsFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+func (d diskFiles) IsDirectory(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+func (d diskFiles) GetFileTags(paths []string) []files.FileMetadata {
+	var md []files.FileMetadata
+	for _, p := range paths {
+		md = append(md, files.FileMetadata{Filepath: p, Tags: map[string]string{"CreationDate": "2025:01:27 15:30:45-06:00"}})
+	}
+	return md
+}
+
+func (d diskFiles) ReadDirectory(dirPath string) ([]string, error) {
+	return nil, errors.New("not supported")
+}
+
+func (d diskFiles) DestinationFromMetadata(md files.FileMetadata, baseDir string) (string, error) {
+	return "", errors.New("not supported")
+}
+
+func (d diskFiles) Copy(src, dst string) error {
+	if d.fail[src] {
+		return fmt.Errorf("copy %s: refused", src)
+	}
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(dst, data, 0o644)
+}
+
+func (d diskFiles) EnsureDir(path string, perm os.FileMode) error {
+	return os.MkdirAll(path, perm)
+}
+
+func (d diskFiles) ValidateCopyArgs(src, dst string) error {
+	if !d.IsFile(src) {
+		return fmt.Errorf("source %q is not a regular file", src)
+	}
+	if _, err := os.Stat(dst); err == nil {
+		return fmt.Errorf("destination %q already exists", dst)
+	}
+	return nil
+}
+
+func (d diskFiles) NewTransaction(overwrite bool) files.Transaction {
+	return files.NewTransaction(d, overwrite)
+}
+
+// events records what the reporter was told, in order.
+type events []string
+
+func (e *events) reporter() Callbacks {
+	return Callbacks{
+		FileStart: func(f File) { *e = append(*e, "start "+filepath.Base(f.Source)) },
+		FileDone:  func(f File) { *e = append(*e, "done "+filepath.Base(f.Source)) },
+		Error:     func(f File, err error) { *e = append(*e, "error "+filepath.Base(f.Source)) },
+	}
+}
+
+// sources writes the named files under a src folder of dir.
+func sources(t *testing.T, dir string, names ...string) []string {
+	t.Helper()
+	var paths []string
+	for _, name := range names {
+		p := filepath.Join(dir, "src", name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, p)
+	}
+	return paths
+}
+
+func equal(a, b []string) bool {
+	return fmt.Sprint(a) == fmt.Sprint(b)
+}
+
+func TestImporter_CallbacksOnSuccess(t *testing.T) {
+	dir := testutil.TempDir(t)
+	srcs := sources(t, dir, "a.jpg")
+	dst := filepath.Join(dir, "dst")
+
+	im, err := New(diskFiles{}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got events
+	im.Register(got.reporter())
+	if _, err := im.Run(context.Background(), srcs, dst); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	if want := []string{"start a.jpg", "done a.jpg"}; !equal(got, want) {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "2025", "01", "27", "15_30.jpg")); err != nil {
+		t.Errorf("copy missing: %v", err)
+	}
+}
+
+func TestImporter_CallbacksOnFailure(t *testing.T) {
+	dir := testutil.TempDir(t)
+	srcs := sources(t, dir, "a.jpg", "b.png")
+	dst := filepath.Join(dir, "dst")
+
+	im, err := New(diskFiles{fail: map[string]bool{srcs[0]: true}}, Options{SkipErrors: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got events
+	im.Register(got.reporter())
+	if _, err := im.Run(context.Background(), srcs, dst); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	if want := []string{"start a.jpg", "error a.jpg", "start b.png", "done b.png"}; !equal(got, want) {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+}
+
+func TestImporter_AtomicCallbacksOnFailure(t *testing.T) {
+	dir := testutil.TempDir(t)
+	srcs := sources(t, dir, "a.jpg", "b.png")
+	dst := filepath.Join(dir, "dst")
+
+	im, err := New(diskFiles{fail: map[string]bool{srcs[1]: true}}, Options{Atomic: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got events
+	im.Register(got.reporter())
+	res, err := im.Run(context.Background(), srcs, dst)
+	if err == nil {
+		t.Fatal("Run succeeded, want the refused copy's error")
+	}
+
+	if want := []string{"start a.jpg", "done a.jpg", "start b.png", "error b.png"}; !equal(got, want) {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "2025", "01", "27", "15_30.jpg")); !os.IsNotExist(err) {
+		t.Errorf("a.jpg was not rolled back: %v", err)
+	}
+	if !res.RolledBack || len(res.Completed) != 0 || len(res.Failed) != 1 || len(res.Pending) != 1 || res.Pending[0].Source != srcs[0] {
+		t.Errorf("result = %+v, want b.png failed and a.jpg rolled back to pending", res)
+	}
+}
+
+// cancelAfter returns a context that is canceled once n files are done,
+// and a reporter recording the events.
+func cancelAfter(n int, got *events) (context.Context, Reporter) {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := got.reporter()
+	done := r.FileDone
+	r.FileDone = func(f File) {
+		done(f)
+		if n--; n == 0 {
+			cancel()
+		}
+	}
+	return ctx, r
+}
+
+func TestImporter_CancelDirect(t *testing.T) {
+	dir := testutil.TempDir(t)
+	srcs := sources(t, dir, "a.jpg", "b.png", "c.gif")
+	dst := filepath.Join(dir, "dst")
+
+	im, err := New(diskFiles{}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got events
+	ctx, r := cancelAfter(1, &got)
+	im.Register(r)
+	res, err := im.Run(ctx, srcs, dst)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Run = %v, want context.Canceled", err)
+	}
+
+	if want := []string{"start a.jpg", "done a.jpg"}; !equal(got, want) {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+	if len(res.Completed) != 1 || res.Completed[0].Source != srcs[0] || len(res.Pending) != 2 || res.RolledBack {
+		t.Errorf("result = %+v, want a.jpg completed and the rest pending", res)
+	}
+	// Files transferred before the cancel stay.
+	if _, err := os.Stat(filepath.Join(dst, "2025", "01", "27", "15_30.jpg")); err != nil {
+		t.Errorf("a.jpg was not kept: %v", err)
+	}
+}
+
+func TestImporter_CancelAtomic(t *testing.T) {
+	dir := testutil.TempDir(t)
+	srcs := sources(t, dir, "a.jpg", "b.png", "c.gif")
+	dst := filepath.Join(dir, "dst")
+
+	im, err := New(diskFiles{}, Options{Atomic: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got events
+	ctx, r := cancelAfter(2, &got)
+	im.Register(r)
+	res, err := im.Run(ctx, srcs, dst)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Run = %v, want context.Canceled", err)
+	}
+
+	if want := []string{"start a.jpg", "done a.jpg", "start b.png", "done b.png"}; !equal(got, want) {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+	if !res.RolledBack || len(res.Completed) != 0 || len(res.Failed) != 0 || len(res.Pending) != 3 {
+		t.Errorf("result = %+v, want every file rolled back to pending", res)
+	}
+	if _, err := os.Stat(dst); !os.IsNotExist(err) {
+		t.Errorf("the transaction was not rolled back: %v", err)
+	}
+}
+
+func TestImporter_SkipExisting(t *testing.T) {
+	dir := testutil.TempDir(t)
+	srcs := sources(t, dir, "a.jpg", "b.png")
+	dst := filepath.Join(dir, "dst")
+	existing := filepath.Join(dst, "2025", "01", "27", "15_30.jpg")
+	if err := os.MkdirAll(filepath.Dir(existing), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(existing, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	im, err := New(diskFiles{}, Options{SkipExisting: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := im.Run(context.Background(), srcs, dst)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(res.Skipped) != 1 || res.Skipped[0].Source != srcs[0] || len(res.Completed) != 1 || len(res.Pending) != 0 {
+		t.Errorf("result = %+v, want a.jpg skipped and b.png completed", res)
+	}
+}
@@ -0,0 +1,110 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/progress"
+)
+
+// AtomicOptions configures an Atomic mode.
+type AtomicOptions struct {
+	Kind   files.OperationType
+	DryRun bool
+	// Tx receives the planned operations.
+	Tx files.Transaction
+	// Decorate, when set, wraps each operation before it joins Tx.
+	Decorate func(op files.Operation) files.Operation
+	// Execute runs the validated transaction; it reports the
+	// transaction's own progress.
+	Execute func(tx files.Transaction) error
+	// Context, when set, is checked before each operation executes; once
+	// it is done the operation fails with its error, which rolls the
+	// transaction back.
+	Context context.Context
+}
+
+// Atomic collects the planned files into a transaction, which is validated
+// and executed once planning is done.
+type Atomic struct {
+	opts     AtomicOptions
+	planning progress.ProgressReporter
+	// done is set once planning has finished.
+	done bool
+}
+
+// NewAtomic returns an Atomic mode whose planning is reported to r.
+func NewAtomic(r progress.ProgressReporter, opts AtomicOptions) *Atomic {
+	return &Atomic{opts: opts, planning: r}
+}
+
+// Reporter implements Mode.
+func (a *Atomic) Reporter() progress.ProgressReporter { return a.planning }
+
+// Describe implements Mode.
+func (a *Atomic) Describe(src string) string {
+	return fmt.Sprintf("Planning %s for %s", a.opts.Kind, src)
+}
+
+// Add implements Mode.
+func (a *Atomic) Add(it Item) error {
+	op := it.Operation(a.opts.Kind)
+	if a.opts.Decorate != nil {
+		op = a.opts.Decorate(op)
+	}
+	if a.opts.Context != nil {
+		op = &cancelable{WrappedOperation: files.WrappedOperation{Operation: op}, ctx: a.opts.Context}
+	}
+	if err := a.opts.Tx.Add(op); err != nil {
+		return err
+	}
+	a.planning.Increment()
+	return nil
+}
+
+// Finish implements Mode.
+func (a *Atomic) Finish() error {
+	a.planning.Finish()
+	a.done = true
+	if err := a.opts.Tx.Validate(); err != nil {
+		return err
+	}
+	if a.opts.DryRun {
+		return nil
+	}
+	if err := canceled(a.opts.Context); err != nil {
+		return err
+	}
+	return a.opts.Execute(a.opts.Tx)
+}
+
+// Fail implements Mode.
+func (a *Atomic) Fail(err error) {
+	if !a.done {
+		a.planning.SetError(err)
+	}
+}
+
+// Planned implements Mode.
+func (a *Atomic) Planned() []Item {
+	ops := a.opts.Tx.Operations()
+	items := make([]Item, len(ops))
+	for i, op := range ops {
+		items[i] = Item{Source: op.Source(), Destination: op.Destination()}
+	}
+	return items
+}
+
+// cancelable fails instead of executing once its context is done.
+type cancelable struct {
+	files.WrappedOperation
+	ctx context.Context
+}
+
+func (c *cancelable) Execute(fs files.FilesService) error {
+	if err := c.ctx.Err(); err != nil {
+		return err
+	}
+	return c.Operation.Execute(fs)
+}
@@ -0,0 +1,162 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/progress"
+	"github.com/Tmunayyer/gocamelpack/sched"
+)
+
+// DirectOptions configures a Direct mode.
+type DirectOptions struct {
+	Kind   files.OperationType
+	DryRun bool
+	// Check, when set, is run on each file before it is transferred,
+	// e.g. to refuse existing destinations.
+	Check func(it Item) error
+	// Transfer copies or moves one file. With Jobs above 1 it runs
+	// concurrently.
+	Transfer func(it Item) (files.Operation, error)
+	// Done finishes a transferred file. It never runs concurrently.
+	Done func(op files.Operation) error
+	// Jobs is how many files are transferred at once; with more than one,
+	// files are transferred once all of them are planned, taken in the
+	// Schedule order.
+	Jobs     int
+	Schedule sched.Strategy
+	// Size returns the size of a source, for the schedule and for
+	// reporters that show the transfers in progress.
+	Size func(src string) int64
+	// Failed, when set, is handed a file that failed its Check, Transfer
+	// or Done; returning nil carries on with the other files. It never
+	// runs concurrently.
+	Failed func(it Item, err error) error
+	// Context, when set, stops the transfers once it is done: no file is
+	// started after that and its error ends the run, Failed or not.
+	Context context.Context
+}
+
+// Direct transfers each file as soon as it is planned; files transferred
+// before a failure stay in place.
+type Direct struct {
+	opts     DirectOptions
+	reporter progress.ProgressReporter
+
+	planned []Item
+	queued  []Item
+	tasks   []sched.Task
+	// mu serializes Done and the reporter for parallel jobs.
+	mu sync.Mutex
+}
+
+// NewDirect returns a Direct mode for n sources reporting to r.
+func NewDirect(r progress.ProgressReporter, n int, opts DirectOptions) *Direct {
+	r.SetTotal(n)
+	return &Direct{opts: opts, reporter: r}
+}
+
+// Reporter implements Mode.
+func (d *Direct) Reporter() progress.ProgressReporter { return d.reporter }
+
+// Describe implements Mode.
+func (d *Direct) Describe(src string) string {
+	return fmt.Sprintf("%s %s", d.opts.Kind, src)
+}
+
+// Add implements Mode.
+func (d *Direct) Add(it Item) error {
+	if d.opts.DryRun {
+		d.planned = append(d.planned, it)
+		d.reporter.Increment()
+		return nil
+	}
+	if d.opts.Check != nil {
+		if err := d.opts.Check(it); err != nil {
+			return d.failed(it, err)
+		}
+	}
+	if d.opts.Jobs > 1 {
+		var size int64
+		if d.opts.Size != nil {
+			size = d.opts.Size(it.Source)
+		}
+		d.tasks = append(d.tasks, sched.Task{Index: len(d.queued), Size: size})
+		d.queued = append(d.queued, it)
+		return nil
+	}
+	return d.transfer(it)
+}
+
+// transfer carries out one file and finishes it.
+func (d *Direct) transfer(it Item) error {
+	if err := canceled(d.opts.Context); err != nil {
+		return err
+	}
+	op, err := d.transferShown(it)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err != nil {
+		return d.failed(it, err)
+	}
+	if d.opts.Done != nil {
+		if err := d.opts.Done(op); err != nil {
+			return d.failed(it, err)
+		}
+	}
+	d.reporter.Increment()
+	return nil
+}
+
+// failed hands err to Failed; a file it absorbs still counts as processed.
+func (d *Direct) failed(it Item, err error) error {
+	if d.opts.Failed == nil {
+		return err
+	}
+	if err := d.opts.Failed(it, err); err != nil {
+		return err
+	}
+	d.reporter.Increment()
+	return nil
+}
+
+// transferShown runs Transfer, showing the file on reporters that list
+// the transfers in progress.
+func (d *Direct) transferShown(it Item) (files.Operation, error) {
+	act, ok := progress.Activity(d.reporter)
+	if !ok {
+		return d.opts.Transfer(it)
+	}
+	var size int64
+	if d.opts.Size != nil {
+		size = d.opts.Size(it.Source)
+	}
+	slot := act.Begin(filepath.Base(it.Source), size)
+	op, err := d.opts.Transfer(it)
+	act.End(slot, err)
+	return op, err
+}
+
+// Finish implements Mode.
+func (d *Direct) Finish() error {
+	if len(d.queued) > 0 {
+		d.reporter.SetMessage(fmt.Sprintf("%s %d file(s) with %d jobs", d.opts.Kind, len(d.queued), d.opts.Jobs))
+		err := sched.Run(d.tasks, d.opts.Jobs, d.opts.Schedule, func(t sched.Task) error {
+			return d.transfer(d.queued[t.Index])
+		})
+		if err != nil {
+			return err
+		}
+	}
+	d.reporter.Finish()
+	return nil
+}
+
+// Fail implements Mode.
+func (d *Direct) Fail(err error) { d.reporter.SetError(err) }
+
+// Planned implements Mode.
+func (d *Direct) Planned() []Item { return d.planned }
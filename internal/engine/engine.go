@@ -0,0 +1,124 @@
+// Package engine runs the plan → validate → execute pipeline behind copy
+// and move. A Pipeline plans each source and hands it to a Mode, which
+// decides how planned files are executed: Direct transfers each one as it
+// is planned, Atomic collects them into a transaction that is executed,
+// and rolled back on failure, once planning is done. Everything a run
+// does per file lives in the functions the caller supplies, so features
+// such as filters, hooks or concurrency plug in at one place for both
+// commands and both modes.
+package engine
+
+import (
+	"context"
+
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/progress"
+)
+
+// Item is one planned file.
+type Item struct {
+	Source      string
+	Destination string
+}
+
+// Operation returns the copy or move of the item.
+func (it Item) Operation(kind files.OperationType) files.Operation {
+	if kind == files.OperationMove {
+		return files.NewMoveOperation(it.Source, it.Destination)
+	}
+	return files.NewCopyOperation(it.Source, it.Destination)
+}
+
+// Mode carries out planned files.
+type Mode interface {
+	// Reporter is where the progress of the run goes.
+	Reporter() progress.ProgressReporter
+	// Describe is the progress message while src is planned.
+	Describe(src string) string
+	// Add takes the next planned and validated file.
+	Add(it Item) error
+	// Finish carries out what Add left for the end of planning.
+	Finish() error
+	// Fail reports an error that ended the run early.
+	Fail(err error)
+	// Planned lists the files planned, for dry runs.
+	Planned() []Item
+}
+
+// Pipeline plans sources and hands them to its Mode.
+type Pipeline struct {
+	// Plan returns where src goes; skip leaves it out of the run.
+	Plan func(src string) (dst string, skip bool, err error)
+	// Validate, when set, checks each planned file before the mode takes
+	// it, e.g. against the files planned before it.
+	Validate func(it Item) error
+	Mode     Mode
+	// Failed, when set, is handed a file that could not be planned or
+	// validated; returning nil leaves the file out and carries on.
+	Failed func(it Item, err error) error
+	// Context, when set, ends the run with its error once it is done;
+	// sources are checked between one another.
+	Context context.Context
+}
+
+// Run plans every source, in order, and has the mode carry them out. It
+// stops at the first error Failed does not absorb. skipped counts the
+// sources Plan left out.
+func (p Pipeline) Run(sources []string) (skipped int, err error) {
+	r := p.Mode.Reporter()
+	defer func() {
+		// Errors returned before execution finished still end the display.
+		if err != nil {
+			p.Mode.Fail(err)
+		}
+	}()
+
+	for _, src := range sources {
+		if err := canceled(p.Context); err != nil {
+			return skipped, err
+		}
+		r.SetMessage(p.Mode.Describe(src))
+		dst, skip, err := p.Plan(src)
+		if err != nil {
+			if err := p.failed(Item{Source: src}, err); err != nil {
+				return skipped, err
+			}
+			r.Increment()
+			continue
+		}
+		if skip {
+			skipped++
+			r.Increment()
+			continue
+		}
+		it := Item{Source: src, Destination: dst}
+		if p.Validate != nil {
+			if err := p.Validate(it); err != nil {
+				if err := p.failed(it, err); err != nil {
+					return skipped, err
+				}
+				r.Increment()
+				continue
+			}
+		}
+		if err := p.Mode.Add(it); err != nil {
+			return skipped, err
+		}
+	}
+	return skipped, p.Mode.Finish()
+}
+
+// canceled returns the error of ctx once it is done; a nil ctx never is.
+func canceled(ctx context.Context) error {
+	if ctx == nil {
+		return nil
+	}
+	return ctx.Err()
+}
+
+func (p Pipeline) failed(it Item, err error) error {
+	if p.Failed == nil {
+		return err
+	}
+	return p.Failed(it, err)
+}
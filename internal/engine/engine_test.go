@@ -0,0 +1,183 @@
+package engine
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/progress"
+	"github.com/Tmunayyer/gocamelpack/sched"
+)
+
+// plan sends each source to "/dst/<src>" and skips those starting with
+// "skip".
+func plan(src string) (string, bool, error) {
+	if strings.HasPrefix(src, "skip") {
+		return "", true, nil
+	}
+	return "/dst/" + src, false, nil
+}
+
+func TestPipeline_Direct(t *testing.T) {
+	var mu sync.Mutex
+	var transferred, done []string
+	opts := DirectOptions{
+		Kind: files.OperationCopy,
+		Transfer: func(it Item) (files.Operation, error) {
+			if it.Source == "bad" {
+				return nil, errors.New("boom")
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			transferred = append(transferred, it.Source)
+			return it.Operation(files.OperationCopy), nil
+		},
+		Done: func(op files.Operation) error {
+			done = append(done, op.Destination())
+			return nil
+		},
+	}
+
+	skipped, err := Pipeline{Plan: plan, Mode: NewDirect(progress.NewNoOpReporter(), 3, opts)}.Run([]string{"a", "skip1", "b"})
+	if err != nil || skipped != 1 {
+		t.Fatalf("Run = %d, %v", skipped, err)
+	}
+	if strings.Join(transferred, ",") != "a,b" || strings.Join(done, ",") != "/dst/a,/dst/b" {
+		t.Errorf("transferred %v, done %v", transferred, done)
+	}
+
+	// Files before a failure stay transferred.
+	transferred, done = nil, nil
+	if _, err := (Pipeline{Plan: plan, Mode: NewDirect(progress.NewNoOpReporter(), 3, opts)}).Run([]string{"a", "bad", "b"}); err == nil {
+		t.Fatal("expected the failing transfer to stop the run")
+	}
+	if strings.Join(transferred, ",") != "a" {
+		t.Errorf("transferred %v", transferred)
+	}
+
+	// Validation runs before the mode sees a file.
+	transferred = nil
+	refuse := func(it Item) error {
+		if it.Source == "b" {
+			return errors.New("collision")
+		}
+		return nil
+	}
+	if _, err := (Pipeline{Plan: plan, Validate: refuse, Mode: NewDirect(progress.NewNoOpReporter(), 2, opts)}).Run([]string{"a", "b"}); err == nil || len(transferred) != 1 {
+		t.Errorf("Validate: err %v, transferred %v", err, transferred)
+	}
+
+	// With jobs, everything is planned before anything is transferred.
+	transferred, done = nil, nil
+	opts.Jobs, opts.Schedule = 2, sched.Planned
+	if _, err := (Pipeline{Plan: plan, Mode: NewDirect(progress.NewNoOpReporter(), 3, opts)}).Run([]string{"a", "b", "c"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(transferred) != 3 || len(done) != 3 {
+		t.Errorf("jobs: transferred %v, done %v", transferred, done)
+	}
+
+	// Dry runs only plan.
+	transferred = nil
+	opts.DryRun = true
+	mode := NewDirect(progress.NewNoOpReporter(), 2, opts)
+	if _, err := (Pipeline{Plan: plan, Mode: mode}).Run([]string{"a", "b"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(transferred) != 0 || len(mode.Planned()) != 2 || mode.Planned()[1].Destination != "/dst/b" {
+		t.Errorf("dry run: transferred %v, planned %v", transferred, mode.Planned())
+	}
+}
+
+func TestPipeline_Failed(t *testing.T) {
+	var transferred, failed []string
+	absorb := func(it Item, err error) error {
+		failed = append(failed, it.Source+": "+err.Error())
+		return nil
+	}
+	opts := DirectOptions{
+		Kind: files.OperationCopy,
+		Transfer: func(it Item) (files.Operation, error) {
+			if it.Source == "bad" {
+				return nil, errors.New("boom")
+			}
+			transferred = append(transferred, it.Source)
+			return it.Operation(files.OperationCopy), nil
+		},
+		Failed: absorb,
+	}
+	planOrFail := func(src string) (string, bool, error) {
+		if src == "nodate" {
+			return "", false, errors.New("no date")
+		}
+		return plan(src)
+	}
+
+	r := progress.NewSimpleProgressBar(io.Discard)
+	p := Pipeline{Plan: planOrFail, Mode: NewDirect(r, 4, opts), Failed: absorb}
+	if _, err := p.Run([]string{"a", "nodate", "bad", "b"}); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(transferred, ",") != "a,b" {
+		t.Errorf("transferred %v", transferred)
+	}
+	if strings.Join(failed, ",") != "nodate: no date,bad: boom" {
+		t.Errorf("failed %v", failed)
+	}
+	if r.Current() != 4 {
+		t.Errorf("progress %d, want every file counted", r.Current())
+	}
+
+	// With jobs, failures during the parallel transfers are absorbed too.
+	transferred, failed = nil, nil
+	opts.Jobs, opts.Schedule = 2, sched.Planned
+	p.Mode = NewDirect(progress.NewNoOpReporter(), 3, opts)
+	if _, err := p.Run([]string{"a", "bad", "b"}); err != nil || len(failed) != 1 || len(transferred) != 2 {
+		t.Errorf("jobs: err %v, failed %v, transferred %v", err, failed, transferred)
+	}
+}
+
+// recordingTx is a transaction that only records what is added to it.
+type recordingTx struct {
+	files.Transaction
+	ops []files.Operation
+}
+
+func (tx *recordingTx) Add(op files.Operation) error {
+	tx.ops = append(tx.ops, op)
+	return nil
+}
+
+func (tx *recordingTx) Validate() error { return nil }
+
+func (tx *recordingTx) Operations() []files.Operation { return tx.ops }
+
+func TestPipeline_Atomic(t *testing.T) {
+	tx := &recordingTx{}
+	executed := 0
+	mode := NewAtomic(progress.NewNoOpReporter(), AtomicOptions{
+		Kind: files.OperationMove,
+		Tx:   tx,
+		Execute: func(got files.Transaction) error {
+			// Nothing runs before every file is planned.
+			if len(got.Operations()) != 2 {
+				t.Errorf("executed with %d operation(s)", len(got.Operations()))
+			}
+			executed++
+			return nil
+		},
+	})
+	skipped, err := Pipeline{Plan: plan, Mode: mode}.Run([]string{"a", "skip1", "b"})
+	if err != nil || skipped != 1 || executed != 1 {
+		t.Fatalf("Run = %d, %v; executed %d time(s)", skipped, err, executed)
+	}
+	if tx.ops[0].Type() != files.OperationMove || mode.Planned()[1].Destination != "/dst/b" {
+		t.Errorf("planned %v", mode.Planned())
+	}
+	if got := mode.Describe("a"); got != "Planning move for a" {
+		t.Errorf("Describe = %q", got)
+	}
+}
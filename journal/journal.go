@@ -0,0 +1,209 @@
+// Package journal records the transfers of a run in the destination, one
+// JSON line per committed batch, so a run that was split into several
+// transactions can still be traced and reviewed as one session.
+package journal
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Tmunayyer/gocamelpack/vfs"
+)
+
+// Dir is the folder, below the destination root, that holds the journals.
+const Dir = ".gocamelpack-journal"
+
+// Operation is one transferred file.
+type Operation struct {
+	Type        string `json:"type"`
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+}
+
+// Failure is a file a run carried on without, e.g. with --skip-errors.
+// Its destination is empty when it failed before one was planned.
+type Failure struct {
+	Operation
+	Error string `json:"error"`
+}
+
+// Entry is one committed, or rolled back, batch of a session, or the
+// files it failed.
+type Entry struct {
+	Session    string      `json:"session"`
+	Chunk      int         `json:"chunk"`
+	Chunks     int         `json:"chunks"`
+	Time       time.Time   `json:"time"`
+	Operations []Operation `json:"operations"`
+	// RolledBack marks a batch that failed and was rolled back; its
+	// operations are the ones planned, not the ones that ran.
+	RolledBack bool `json:"rolled_back,omitempty"`
+	// Overwrite is set when the batch was allowed to replace existing
+	// destinations.
+	Overwrite bool `json:"overwrite,omitempty"`
+	// Failed lists the files of a non-atomic run that failed; the entry
+	// has no chunk.
+	Failed []Failure `json:"failed,omitempty"`
+	// Tags are the key/values the run was given, e.g. trip=Iceland2025,
+	// repeated on every entry of the session.
+	Tags map[string]string `json:"tags,omitempty"`
+}
+
+// Journal appends the entries of one session to its file.
+type Journal struct {
+	fsys    vfs.FS
+	path    string
+	session string
+	tags    map[string]string
+	now     func() time.Time
+}
+
+// Open returns the journal of session in the destination root. The file is
+// created with the first entry.
+func Open(root, session string) *Journal {
+	return OpenIn(vfs.OS, root, session)
+}
+
+// OpenIn is Open with the journal written through fsys, e.g. the file
+// system a simulated run writes to.
+func OpenIn(fsys vfs.FS, root, session string) *Journal {
+	return &Journal{fsys: fsys, path: Path(root, session), session: session}
+}
+
+// Path returns where the journal of session in root is kept.
+func Path(root, session string) string {
+	return filepath.Join(root, Dir, session+".jsonl")
+}
+
+// Path returns the journal's file.
+func (j *Journal) Path() string {
+	return j.path
+}
+
+// SetTags sets the tags written with every entry from now on.
+func (j *Journal) SetTags(tags map[string]string) {
+	j.tags = tags
+}
+
+// SetClock sets the clock entries are timestamped with; nil means the
+// system clock.
+func (j *Journal) SetClock(now func() time.Time) {
+	j.now = now
+}
+
+// Record appends an entry for chunk (1-based) of chunks and syncs it to
+// disk, so it survives a crash in a later chunk.
+func (j *Journal) Record(chunk, chunks int, ops []Operation) error {
+	return j.append(Entry{Chunk: chunk, Chunks: chunks, Operations: ops})
+}
+
+// RecordRollback appends an entry for a chunk that failed and was rolled
+// back, with the operations it planned, so the rollback can be verified
+// later.
+func (j *Journal) RecordRollback(chunk, chunks int, overwrite bool, ops []Operation) error {
+	return j.append(Entry{Chunk: chunk, Chunks: chunks, Operations: ops, RolledBack: true, Overwrite: overwrite})
+}
+
+// RecordFailures appends an entry for the files a non-atomic run failed,
+// so they can be retried later.
+func (j *Journal) RecordFailures(failed []Failure) error {
+	return j.append(Entry{Failed: failed})
+}
+
+func (j *Journal) append(e Entry) error {
+	now := time.Now
+	if j.now != nil {
+		now = j.now
+	}
+	e.Session, e.Time, e.Tags = j.session, now().UTC(), j.tags
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if err := j.fsys.MkdirAll(filepath.Dir(j.path), 0o755); err != nil {
+		return fmt.Errorf("writing journal: %w", err)
+	}
+	f, err := j.fsys.OpenFile(j.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("writing journal: %w", err)
+	}
+	_, err = f.Write(append(line, '\n'))
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("writing journal: %w", err)
+	}
+	return nil
+}
+
+// Complete reports whether entries record a finished session: every chunk
+// committed and none rolled back, and no file failed.
+func Complete(entries []Entry) bool {
+	if len(entries) == 0 {
+		return false
+	}
+	for _, e := range entries {
+		if e.RolledBack || len(e.Failed) > 0 {
+			return false
+		}
+	}
+	last := entries[len(entries)-1]
+	return last.Chunk == last.Chunks
+}
+
+// Retryable returns the operations of entries that did not go through:
+// those of rolled-back chunks and the failed files, in journal order.
+// Files that failed before their destination was planned are left out.
+func Retryable(entries []Entry) []Operation {
+	var ops []Operation
+	for _, e := range entries {
+		if e.RolledBack {
+			ops = append(ops, e.Operations...)
+		}
+		for _, f := range e.Failed {
+			if f.Destination != "" {
+				ops = append(ops, f.Operation)
+			}
+		}
+	}
+	return ops
+}
+
+// Tagged reports whether the session of entries was given tags.
+func Tagged(entries []Entry) bool {
+	for _, e := range entries {
+		if len(e.Tags) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Read returns the entries of the journal file at path.
+func Read(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []Entry
+	sc := bufio.NewScanner(f)
+	sc.Buffer(nil, 64<<20)
+	for n := 1; sc.Scan(); n++ {
+		var e Entry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, n, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, sc.Err()
+}
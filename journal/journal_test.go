@@ -0,0 +1,113 @@
+package journal
+
+import (
+	"os"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+func TestRecordAndRead(t *testing.T) {
+	root := testutil.TempDir(t)
+	j := Open(root, "20250127-153045-9f2c")
+	if j.Path() != Path(root, "20250127-153045-9f2c") {
+		t.Errorf("Path = %s", j.Path())
+	}
+
+	if err := j.Record(1, 2, []Operation{{Type: "copy", Source: "/card/a.jpg", Destination: root + "/2025/a.jpg"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := j.Record(2, 2, []Operation{{Type: "copy", Source: "/card/b.jpg", Destination: root + "/2025/b.jpg"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := Read(j.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Chunk != 1 || entries[1].Chunks != 2 || entries[1].Session != "20250127-153045-9f2c" {
+		t.Fatalf("unexpected entries %+v", entries)
+	}
+	if ops := entries[1].Operations; len(ops) != 1 || ops[0].Source != "/card/b.jpg" {
+		t.Errorf("unexpected operations %+v", ops)
+	}
+}
+
+func TestRead_Invalid(t *testing.T) {
+	root := testutil.TempDir(t)
+	path := Path(root, "bad")
+	if err := os.MkdirAll(root+"/"+Dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("{not json\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Read(path); err == nil {
+		t.Error("expected an error for a corrupt journal")
+	}
+	if _, err := Read(Path(root, "missing")); err == nil {
+		t.Error("expected an error for a missing journal")
+	}
+}
+
+func TestRecordRollback(t *testing.T) {
+	root := testutil.TempDir(t)
+	j := Open(root, "rolled-back")
+	ops := []Operation{{Type: "move", Source: "/card/a.jpg", Destination: root + "/2025/a.jpg"}}
+	if err := j.RecordRollback(2, 3, true, ops); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := Read(j.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || !entries[0].RolledBack || !entries[0].Overwrite || entries[0].Chunk != 2 || len(entries[0].Operations) != 1 {
+		t.Errorf("unexpected entries %+v", entries)
+	}
+}
+
+func TestRecordFailuresAndRetryable(t *testing.T) {
+	root := testutil.TempDir(t)
+	j := Open(root, "failed")
+	if err := j.RecordRollback(1, 1, false, []Operation{{Type: "copy", Source: "/card/a.jpg", Destination: root + "/a.jpg"}}); err != nil {
+		t.Fatal(err)
+	}
+	failed := []Failure{
+		{Operation: Operation{Type: "copy", Source: "/card/b.jpg", Destination: root + "/b.jpg"}, Error: "input/output error"},
+		{Operation: Operation{Type: "copy", Source: "/card/c.jpg"}, Error: "CreationDate is missing"},
+	}
+	if err := j.RecordFailures(failed); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := Read(j.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if Complete(entries) || Complete(entries[1:]) {
+		t.Error("a session with failed files is not complete")
+	}
+	ops := Retryable(entries)
+	if len(ops) != 2 || ops[0].Source != "/card/a.jpg" || ops[1].Source != "/card/b.jpg" {
+		t.Errorf("Retryable = %+v", ops)
+	}
+}
+
+func TestSetTags(t *testing.T) {
+	root := testutil.TempDir(t)
+	j := Open(root, "tagged")
+	if err := j.Record(1, 1, nil); err != nil {
+		t.Fatal(err)
+	}
+	j.SetTags(map[string]string{"trip": "Iceland2025"})
+	if err := j.Record(1, 1, nil); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := Read(j.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if Tagged(entries[:1]) || !Tagged(entries) || entries[1].Tags["trip"] != "Iceland2025" {
+		t.Errorf("unexpected entries %+v", entries)
+	}
+}
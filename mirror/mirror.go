@@ -0,0 +1,176 @@
+// Package mirror plans how to make one archive root match another: which
+// files to add, which to update and, optionally, which to delete.
+package mirror
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Tmunayyer/gocamelpack/hashindex"
+)
+
+// Action is what a Change does to the destination.
+type Action string
+
+const (
+	Add    Action = "add"
+	Update Action = "update"
+	Delete Action = "delete"
+)
+
+// Change is one step towards a mirrored destination. Rel is slash
+// separated and relative to both roots.
+type Change struct {
+	Action Action
+	Rel    string
+	Size   int64
+}
+
+// Options controls how a destination is compared with its source.
+type Options struct {
+	// Checksum compares the content of files of equal size instead of
+	// their modification times, hashing them with Algo (SHA-256 when
+	// unset).
+	Checksum bool
+	Algo     hashindex.Algo
+	// Delete removes destination files missing from the source.
+	Delete bool
+	// Exclude lists glob patterns (path.Match syntax) matched against the
+	// relative paths of each file and its folders, and against their names.
+	// Excluded files are neither copied nor deleted.
+	Exclude []string
+}
+
+// Validate checks the exclusion patterns.
+func (o Options) Validate() error {
+	for _, p := range o.Exclude {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("invalid exclude pattern %q: %w", p, err)
+		}
+	}
+	return nil
+}
+
+// Excluded reports whether rel matches one of the exclusion patterns.
+func (o Options) Excluded(rel string) bool {
+	for _, p := range o.Exclude {
+		for prefix := rel; prefix != "." && prefix != "/"; prefix = path.Dir(prefix) {
+			if ok, _ := path.Match(p, prefix); ok {
+				return true
+			}
+			if ok, _ := path.Match(p, path.Base(prefix)); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Plan compares the trees below src and dst. Hidden files and folders, such
+// as the destination lock, are ignored on both sides. Changes are sorted by
+// path, with deletions last.
+func Plan(src, dst string, o Options) ([]Change, error) {
+	srcFiles, err := listFiles(src, o)
+	if err != nil {
+		return nil, err
+	}
+	dstFiles, err := listFiles(dst, o)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []Change
+	for _, rel := range sortedKeys(srcFiles) {
+		s := srcFiles[rel]
+		d, ok := dstFiles[rel]
+		if !ok {
+			changes = append(changes, Change{Action: Add, Rel: rel, Size: s.Size()})
+			continue
+		}
+		differs, err := o.differs(filepath.Join(src, rel), filepath.Join(dst, rel), s, d)
+		if err != nil {
+			return nil, err
+		}
+		if differs {
+			changes = append(changes, Change{Action: Update, Rel: rel, Size: s.Size()})
+		}
+	}
+	if o.Delete {
+		for _, rel := range sortedKeys(dstFiles) {
+			if _, ok := srcFiles[rel]; !ok {
+				changes = append(changes, Change{Action: Delete, Rel: rel, Size: dstFiles[rel].Size()})
+			}
+		}
+	}
+	return changes, nil
+}
+
+func (o Options) differs(srcPath, dstPath string, s, d fs.FileInfo) (bool, error) {
+	if s.Size() != d.Size() {
+		return true, nil
+	}
+	if !o.Checksum {
+		return !s.ModTime().Equal(d.ModTime()), nil
+	}
+	a, err := o.Algo.HashFile(srcPath)
+	if err != nil {
+		return false, err
+	}
+	b, err := o.Algo.HashFile(dstPath)
+	if err != nil {
+		return false, err
+	}
+	return a != b, nil
+}
+
+// listFiles maps the relative paths of the regular files below root that
+// are neither hidden nor excluded to their info. A missing root is empty.
+func listFiles(root string, o Options) (map[string]fs.FileInfo, error) {
+	found := map[string]fs.FileInfo{}
+	if _, err := os.Stat(root); os.IsNotExist(err) {
+		return found, nil
+	}
+	err := filepath.WalkDir(root, func(p string, e fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == root {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if strings.HasPrefix(e.Name(), ".") || o.Excluded(rel) {
+			if e.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !e.Type().IsRegular() {
+			return nil
+		}
+		info, err := e.Info()
+		if err != nil {
+			return err
+		}
+		found[rel] = info
+		return nil
+	})
+	return found, err
+}
+
+func sortedKeys(m map[string]fs.FileInfo) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
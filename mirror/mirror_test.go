@@ -0,0 +1,92 @@
+package mirror
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Tmunayyer/gocamelpack/hashindex"
+)
+
+func write(t *testing.T, root, rel, content string, mtime time.Time) {
+	t.Helper()
+	p := filepath.Join(root, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(p, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPlan(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	then := time.Date(2025, 1, 27, 15, 30, 0, 0, time.UTC)
+	write(t, src, "2025/01/27/15_30.jpg", "same", then)
+	write(t, dst, "2025/01/27/15_30.jpg", "same", then)
+	write(t, src, "2025/01/28/09_00.jpg", "new", then)
+	write(t, src, "2025/01/29/10_00.jpg", "edited", then.Add(time.Hour))
+	write(t, dst, "2025/01/29/10_00.jpg", "original", then)
+	write(t, src, "2025/01/30/11_00.jpg", "abcd", then.Add(time.Hour)) // touched only
+	write(t, dst, "2025/01/30/11_00.jpg", "abcd", then)
+	write(t, dst, "2024/12/31/23_59.jpg", "removed from source", then)
+	write(t, src, "cache/thumb.jpg", "excluded", then)
+	write(t, dst, "2024/notes.txt", "excluded on the destination", then)
+	write(t, src, ".gocamelpack.lock", "{}", then)
+
+	o := Options{Exclude: []string{"cache", "*.txt"}}
+	got, err := Plan(src, dst, o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Change{
+		{Action: Add, Rel: "2025/01/28/09_00.jpg", Size: 3},
+		{Action: Update, Rel: "2025/01/29/10_00.jpg", Size: 6},
+		{Action: Update, Rel: "2025/01/30/11_00.jpg", Size: 4},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Plan = %+v\nwant %+v", got, want)
+	}
+
+	// With checksums the touched file is recognised as unchanged; with
+	// deletion the file missing from the source goes.
+	o.Checksum, o.Delete = true, true
+	got, err = Plan(src, dst, o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []Change{
+		{Action: Add, Rel: "2025/01/28/09_00.jpg", Size: 3},
+		{Action: Update, Rel: "2025/01/29/10_00.jpg", Size: 6},
+		{Action: Delete, Rel: "2024/12/31/23_59.jpg", Size: 19},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Plan = %+v\nwant %+v", got, want)
+	}
+	o.Algo = hashindex.XXH64
+	if got, err = Plan(src, dst, o); err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("Plan with xxh64 = %+v, %v\nwant %+v", got, err, want)
+	}
+}
+
+func TestOptions_Excluded(t *testing.T) {
+	o := Options{Exclude: []string{"2019/*", "*.xmp", "@eaDir"}}
+	for rel, want := range map[string]bool{
+		"2019/05/01/15_30.jpg":  true,
+		"2020/05/01/15_30.xmp":  true,
+		"2020/@eaDir/thumb.jpg": true,
+		"2020/05/01/15_30.jpg":  false,
+	} {
+		if got := o.Excluded(rel); got != want {
+			t.Errorf("Excluded(%q) = %v, want %v", rel, got, want)
+		}
+	}
+	if err := (Options{Exclude: []string{"["}}).Validate(); err == nil {
+		t.Error("expected an error for a malformed pattern")
+	}
+}
@@ -0,0 +1,51 @@
+package output
+
+import (
+	"os"
+	"strings"
+)
+
+// ASCIIOnly reports whether output should be limited to ASCII, leaving out
+// arrows, check marks and block characters: when GOCAMELPACK_ASCII is set
+// to anything but 0, or when the terminal's encoding is not UTF-8.
+func ASCIIOnly() bool {
+	if v := os.Getenv("GOCAMELPACK_ASCII"); v != "" {
+		return v != "0"
+	}
+	return !terminalUTF8()
+}
+
+// localeUTF8 reports whether the locale in effect (LC_ALL, then LC_CTYPE,
+// then LANG) uses UTF-8. ok is false when none of them is set.
+func localeUTF8() (utf8, ok bool) {
+	for _, name := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if v := strings.ToLower(os.Getenv(name)); v != "" {
+			return strings.Contains(v, "utf-8") || strings.Contains(v, "utf8"), true
+		}
+	}
+	return false, false
+}
+
+// Glyphs used by Printer, with their ASCII stand-ins.
+const (
+	arrowUnicode = "→"
+	arrowASCII   = "->"
+	crossUnicode = "✗"
+	crossASCII   = "x"
+)
+
+// Arrow returns the arrow between a source and its destination.
+func (p *Printer) Arrow() string {
+	if p.ascii {
+		return arrowASCII
+	}
+	return arrowUnicode
+}
+
+// Cross returns the mark in front of a failure.
+func (p *Printer) Cross() string {
+	if p.ascii {
+		return crossASCII
+	}
+	return crossUnicode
+}
@@ -0,0 +1,47 @@
+package output
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestASCIIOnly(t *testing.T) {
+	tests := []struct {
+		name              string
+		force, all, ctype string
+		lang              string
+		want              bool
+	}{
+		{name: "utf-8 locale", lang: "en_US.UTF-8", want: false},
+		{name: "utf8 spelling", lang: "de_DE.utf8", want: false},
+		{name: "C locale", lang: "C", want: true},
+		{name: "latin-1", ctype: "en_US.ISO-8859-1", lang: "en_US.UTF-8", want: true},
+		{name: "LC_ALL wins", all: "en_US.UTF-8", ctype: "C", want: false},
+		{name: "forced", force: "1", lang: "en_US.UTF-8", want: true},
+		{name: "forced off", force: "0", lang: "C", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GOCAMELPACK_ASCII", tt.force)
+			t.Setenv("LC_ALL", tt.all)
+			t.Setenv("LC_CTYPE", tt.ctype)
+			t.Setenv("LANG", tt.lang)
+			if got := ASCIIOnly(); got != tt.want {
+				t.Errorf("ASCIIOnly() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrinter_ASCII(t *testing.T) {
+	var buf bytes.Buffer
+	p := NewWithColor(&buf, false)
+	p.SetASCII(true)
+	p.Mappings("Would copy", []Mapping{{Source: "/a", Destination: "/b"}})
+	p.ErrorList("Failed:", []error{errors.New("boom")})
+
+	if want := "Would copy /a -> /b\nFailed:\n  x boom\n"; buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !windows
+
+package output
+
+// terminalUTF8 reports whether the locale uses UTF-8. Without a locale Go's
+// own default, UTF-8, is assumed.
+func terminalUTF8() bool {
+	utf8, ok := localeUTF8()
+	return utf8 || !ok
+}
@@ -0,0 +1,21 @@
+//go:build windows
+
+package output
+
+import "syscall"
+
+// cpUTF8 is the UTF-8 code page.
+const cpUTF8 = 65001
+
+var getConsoleOutputCP = syscall.NewLazyDLL("kernel32.dll").NewProc("GetConsoleOutputCP")
+
+// terminalUTF8 reports whether the console's output code page is UTF-8.
+// A locale from a Unix-like shell (MSYS, Cygwin) takes precedence; without
+// a console, UTF-8 is assumed.
+func terminalUTF8() bool {
+	if utf8, ok := localeUTF8(); ok {
+		return utf8
+	}
+	cp, _, _ := getConsoleOutputCP.Call()
+	return cp == 0 || cp == cpUTF8
+}
@@ -0,0 +1,165 @@
+// Package output formats user-facing terminal output: aligned src → dst
+// listings, coloured status lines, and warnings. Colour is only emitted to
+// terminals and is disabled by the NO_COLOR convention (https://no-color.org).
+// Arrows and marks fall back to ASCII on terminals that are not UTF-8 (see
+// ASCIIOnly).
+package output
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// Style selects how a piece of text is highlighted.
+type Style int
+
+const (
+	Plain Style = iota
+	Success
+	Warning
+	Error
+	Conflict
+	Dim
+)
+
+var ansi = map[Style]string{
+	Success:  "\x1b[32m",
+	Warning:  "\x1b[33m",
+	Error:    "\x1b[31m",
+	Conflict: "\x1b[1;35m",
+	Dim:      "\x1b[2m",
+}
+
+const reset = "\x1b[0m"
+
+// Printer writes formatted output to an underlying writer.
+type Printer struct {
+	w     io.Writer
+	color bool
+	ascii bool
+}
+
+// New returns a Printer that colours output only when w is a terminal and
+// colour has not been disabled through the environment, and that sticks to
+// ASCII when ASCIIOnly says so.
+func New(w io.Writer) *Printer {
+	p := NewWithColor(w, ColorEnabled(w))
+	p.ascii = ASCIIOnly()
+	return p
+}
+
+// NewWithColor returns a Printer with colour explicitly on or off.
+func NewWithColor(w io.Writer, color bool) *Printer {
+	return &Printer{w: w, color: color}
+}
+
+// SetASCII limits the Printer's glyphs to ASCII, or lifts the limit.
+func (p *Printer) SetASCII(ascii bool) {
+	p.ascii = ascii
+}
+
+// ColorEnabled reports whether colour should be used for w: NO_COLOR must be
+// unset or empty, TERM must not be "dumb", and w must be a terminal.
+func ColorEnabled(w io.Writer) bool {
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	return IsTerminal(w)
+}
+
+// IsTerminal reports whether w is a character device such as a TTY.
+func IsTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// Writer returns the underlying writer.
+func (p *Printer) Writer() io.Writer {
+	return p.w
+}
+
+// Colorize wraps text in the escape codes for style when colour is enabled.
+func (p *Printer) Colorize(style Style, text string) string {
+	code, ok := ansi[style]
+	if !p.color || !ok {
+		return text
+	}
+	return code + text + reset
+}
+
+// Printf writes a formatted line fragment in the given style.
+func (p *Printer) Printf(style Style, format string, args ...any) {
+	fmt.Fprint(p.w, p.Colorize(style, fmt.Sprintf(format, args...)))
+}
+
+// Println writes a formatted line in the given style.
+func (p *Printer) Println(style Style, format string, args ...any) {
+	fmt.Fprintln(p.w, p.Colorize(style, fmt.Sprintf(format, args...)))
+}
+
+// Success writes a completion summary line.
+func (p *Printer) Success(format string, args ...any) { p.Println(Success, format, args...) }
+
+// Warn writes a line prefixed with "Warning:".
+func (p *Printer) Warn(format string, args ...any) {
+	p.Println(Warning, "Warning: "+format, args...)
+}
+
+// Error writes a line prefixed with "Error:".
+func (p *Printer) Error(format string, args ...any) {
+	p.Println(Error, "Error: "+format, args...)
+}
+
+// Mapping is a single planned or executed src → dst pair.
+type Mapping struct {
+	Source      string
+	Destination string
+	Conflict    bool // destination already exists
+	// Notes are printed indented below the mapping, e.g. why it was
+	// planned that way.
+	Notes []string
+}
+
+// Mappings writes one "<verb> src → dst" line per mapping with the arrows
+// aligned in a single column, followed by its notes. Conflicting
+// destinations are highlighted and annotated.
+func (p *Printer) Mappings(verb string, ms []Mapping) {
+	width := 0
+	for _, m := range ms {
+		width = max(width, utf8.RuneCountInString(m.Source))
+	}
+
+	for _, m := range ms {
+		pad := strings.Repeat(" ", width-utf8.RuneCountInString(m.Source))
+		line := fmt.Sprintf("%s %s%s %s ", verb, m.Source, pad, p.Arrow())
+		if m.Conflict {
+			fmt.Fprintln(p.w, line+p.Colorize(Conflict, m.Destination+" (exists)"))
+		} else {
+			fmt.Fprintln(p.w, line+m.Destination)
+		}
+		for _, note := range m.Notes {
+			fmt.Fprintln(p.w, p.Colorize(Dim, "    "+note))
+		}
+	}
+}
+
+// ErrorList writes a heading followed by one indented line per error.
+func (p *Printer) ErrorList(heading string, errs []error) {
+	if len(errs) == 0 {
+		return
+	}
+	p.Println(Error, "%s", heading)
+	for _, err := range errs {
+		fmt.Fprintf(p.w, "  %s %v\n", p.Colorize(Error, p.Cross()), err)
+	}
+}
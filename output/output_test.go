@@ -0,0 +1,106 @@
+package output
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestMappings_Aligned(t *testing.T) {
+	var buf bytes.Buffer
+	p := NewWithColor(&buf, false)
+
+	p.Mappings("Would copy", []Mapping{
+		{Source: "/src/a.jpg", Destination: "/dst/2025/01/a.jpg"},
+		{Source: "/src/longer-name.jpg", Destination: "/dst/2025/01/b.jpg"},
+	})
+
+	want := "Would copy /src/a.jpg           → /dst/2025/01/a.jpg\n" +
+		"Would copy /src/longer-name.jpg → /dst/2025/01/b.jpg\n"
+	if buf.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestMappings_Conflict(t *testing.T) {
+	var buf bytes.Buffer
+	NewWithColor(&buf, false).Mappings("Would move", []Mapping{
+		{Source: "/a", Destination: "/b", Conflict: true},
+	})
+	if want := "Would move /a → /b (exists)\n"; buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+
+	buf.Reset()
+	NewWithColor(&buf, true).Mappings("Would move", []Mapping{
+		{Source: "/a", Destination: "/b", Conflict: true},
+	})
+	if want := "Would move /a → \x1b[1;35m/b (exists)\x1b[0m\n"; buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestColorize(t *testing.T) {
+	if got := NewWithColor(nil, false).Colorize(Error, "x"); got != "x" {
+		t.Errorf("colour disabled should return plain text, got %q", got)
+	}
+	if got := NewWithColor(nil, true).Colorize(Error, "x"); got != "\x1b[31mx\x1b[0m" {
+		t.Errorf("unexpected colourised text %q", got)
+	}
+	if got := NewWithColor(nil, true).Colorize(Plain, "x"); got != "x" {
+		t.Errorf("Plain style should never be coloured, got %q", got)
+	}
+}
+
+func TestStatusLines(t *testing.T) {
+	var buf bytes.Buffer
+	p := NewWithColor(&buf, false)
+
+	p.Success("Copied %d file(s).", 3)
+	p.Warn("%d thumbnails failed", 2)
+	p.Error("%v", errors.New("boom"))
+
+	want := "Copied 3 file(s).\nWarning: 2 thumbnails failed\nError: boom\n"
+	if buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestErrorList(t *testing.T) {
+	var buf bytes.Buffer
+	p := NewWithColor(&buf, false)
+
+	p.ErrorList("Failed files:", nil)
+	if buf.Len() != 0 {
+		t.Fatalf("empty list should print nothing, got %q", buf.String())
+	}
+
+	p.ErrorList("Failed files:", []error{errors.New("a"), errors.New("b")})
+	if want := "Failed files:\n  ✗ a\n  ✗ b\n"; buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestColorEnabled(t *testing.T) {
+	var buf bytes.Buffer
+	if ColorEnabled(&buf) {
+		t.Error("buffers are not terminals")
+	}
+
+	t.Setenv("NO_COLOR", "1")
+	if ColorEnabled(os.Stdout) {
+		t.Error("NO_COLOR must disable colour")
+	}
+}
+
+func TestMappings_Notes(t *testing.T) {
+	var buf bytes.Buffer
+	NewWithColor(&buf, false).Mappings("Would copy", []Mapping{
+		{Source: "/a", Destination: "/b", Notes: []string{"date: CreationDate", "layout: built-in"}},
+	})
+	want := "Would copy /a → /b\n    date: CreationDate\n    layout: built-in\n"
+	if buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
@@ -0,0 +1,24 @@
+package output
+
+import (
+	"io"
+	"os"
+	"strconv"
+)
+
+// TerminalWidth returns the number of columns of the terminal w writes to,
+// or 0 when w is not a terminal. COLUMNS is used when the terminal cannot
+// be asked.
+func TerminalWidth(w io.Writer) int {
+	f, ok := w.(*os.File)
+	if !ok || !IsTerminal(w) {
+		return 0
+	}
+	if cols := terminalColumns(f); cols > 0 {
+		return cols
+	}
+	if cols, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && cols > 0 {
+		return cols
+	}
+	return 0
+}
@@ -0,0 +1,26 @@
+//go:build windows
+
+package output
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+var getConsoleScreenBufferInfo = syscall.NewLazyDLL("kernel32.dll").NewProc("GetConsoleScreenBufferInfo")
+
+// terminalColumns asks the console behind f for the width of its window.
+func terminalColumns(f *os.File) int {
+	var info struct {
+		size, cursor             [2]int16
+		attributes               uint16
+		left, top, right, bottom int16
+		maxSize                  [2]int16
+	}
+	ok, _, _ := getConsoleScreenBufferInfo.Call(f.Fd(), uintptr(unsafe.Pointer(&info)))
+	if ok == 0 {
+		return 0
+	}
+	return int(info.right-info.left) + 1
+}
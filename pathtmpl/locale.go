@@ -0,0 +1,133 @@
+package pathtmpl
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/text/language"
+)
+
+// Locale names months and weekdays for the {month_name} and {weekday}
+// tokens. Names are written as the language writes them inside a sentence,
+// so German months are capitalized and French ones are not. A nil *Locale
+// is English.
+type Locale struct {
+	Tag      language.Tag
+	months   [12]string
+	weekdays [7]string // Sunday first, like time.Weekday
+}
+
+// English is the default locale.
+var English = &Locale{
+	Tag:      language.English,
+	months:   [12]string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"},
+	weekdays: [7]string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"},
+}
+
+var locales = []*Locale{
+	English,
+	{
+		Tag:      language.German,
+		months:   [12]string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+		weekdays: [7]string{"Sonntag", "Montag", "Dienstag", "Mittwoch", "Donnerstag", "Freitag", "Samstag"},
+	},
+	{
+		Tag:      language.French,
+		months:   [12]string{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+		weekdays: [7]string{"dimanche", "lundi", "mardi", "mercredi", "jeudi", "vendredi", "samedi"},
+	},
+	{
+		Tag:      language.Spanish,
+		months:   [12]string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+		weekdays: [7]string{"domingo", "lunes", "martes", "miércoles", "jueves", "viernes", "sábado"},
+	},
+	{
+		Tag:      language.Italian,
+		months:   [12]string{"gennaio", "febbraio", "marzo", "aprile", "maggio", "giugno", "luglio", "agosto", "settembre", "ottobre", "novembre", "dicembre"},
+		weekdays: [7]string{"domenica", "lunedì", "martedì", "mercoledì", "giovedì", "venerdì", "sabato"},
+	},
+	{
+		Tag:      language.Dutch,
+		months:   [12]string{"januari", "februari", "maart", "april", "mei", "juni", "juli", "augustus", "september", "oktober", "november", "december"},
+		weekdays: [7]string{"zondag", "maandag", "dinsdag", "woensdag", "donderdag", "vrijdag", "zaterdag"},
+	},
+	{
+		Tag:      language.Portuguese,
+		months:   [12]string{"janeiro", "fevereiro", "março", "abril", "maio", "junho", "julho", "agosto", "setembro", "outubro", "novembro", "dezembro"},
+		weekdays: [7]string{"domingo", "segunda-feira", "terça-feira", "quarta-feira", "quinta-feira", "sexta-feira", "sábado"},
+	},
+	{
+		Tag:      language.Swedish,
+		months:   [12]string{"januari", "februari", "mars", "april", "maj", "juni", "juli", "augusti", "september", "oktober", "november", "december"},
+		weekdays: [7]string{"söndag", "måndag", "tisdag", "onsdag", "torsdag", "fredag", "lördag"},
+	},
+	{
+		Tag:      language.Danish,
+		months:   [12]string{"januar", "februar", "marts", "april", "maj", "juni", "juli", "august", "september", "oktober", "november", "december"},
+		weekdays: [7]string{"søndag", "mandag", "tirsdag", "onsdag", "torsdag", "fredag", "lørdag"},
+	},
+	{
+		Tag:      language.Norwegian,
+		months:   [12]string{"januar", "februar", "mars", "april", "mai", "juni", "juli", "august", "september", "oktober", "november", "desember"},
+		weekdays: [7]string{"søndag", "mandag", "tirsdag", "onsdag", "torsdag", "fredag", "lørdag"},
+	},
+	{
+		Tag:      language.Finnish,
+		months:   [12]string{"tammikuu", "helmikuu", "maaliskuu", "huhtikuu", "toukokuu", "kesäkuu", "heinäkuu", "elokuu", "syyskuu", "lokakuu", "marraskuu", "joulukuu"},
+		weekdays: [7]string{"sunnuntai", "maanantai", "tiistai", "keskiviikko", "torstai", "perjantai", "lauantai"},
+	},
+	{
+		Tag:      language.Polish,
+		months:   [12]string{"styczeń", "luty", "marzec", "kwiecień", "maj", "czerwiec", "lipiec", "sierpień", "wrzesień", "październik", "listopad", "grudzień"},
+		weekdays: [7]string{"niedziela", "poniedziałek", "wtorek", "środa", "czwartek", "piątek", "sobota"},
+	},
+}
+
+var localeMatcher = func() language.Matcher {
+	tags := make([]language.Tag, len(locales))
+	for i, l := range locales {
+		tags[i] = l.Tag
+	}
+	return language.NewMatcher(tags)
+}()
+
+// ParseLocale returns the supported locale closest to the BCP 47 tag s,
+// e.g. "de" or "de-AT" for German. Languages without month names here are
+// an error rather than a silent fallback to English.
+func ParseLocale(s string) (*Locale, error) {
+	tag, err := language.Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("locale %q: %w", s, err)
+	}
+	_, i, conf := localeMatcher.Match(tag)
+	if conf == language.No {
+		return nil, fmt.Errorf("locale %q is not supported (supported: %s)", s, strings.Join(SupportedLocales(), ", "))
+	}
+	return locales[i], nil
+}
+
+// SupportedLocales lists the languages ParseLocale accepts.
+func SupportedLocales() []string {
+	out := make([]string, len(locales))
+	for i, l := range locales {
+		out[i] = l.Tag.String()
+	}
+	return out
+}
+
+// Month returns the name of m.
+func (l *Locale) Month(m time.Month) string {
+	if l == nil {
+		l = English
+	}
+	return l.months[m-1]
+}
+
+// Weekday returns the name of d.
+func (l *Locale) Weekday(d time.Weekday) string {
+	if l == nil {
+		l = English
+	}
+	return l.weekdays[d]
+}
@@ -0,0 +1,49 @@
+package pathtmpl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseLocale(t *testing.T) {
+	tests := []struct {
+		locale  string
+		month   string
+		weekday string
+	}{
+		{"en", "July", "Sunday"},
+		{"de", "Juli", "Sonntag"},
+		{"de-AT", "Juli", "Sonntag"},
+		{"fr-CA", "juillet", "dimanche"},
+		{"es", "julio", "domingo"},
+		{"pt-BR", "julho", "domingo"},
+		{"nb", "juli", "søndag"},
+		{"pl", "lipiec", "niedziela"},
+	}
+	for _, tc := range tests {
+		l, err := ParseLocale(tc.locale)
+		if err != nil {
+			t.Errorf("ParseLocale(%q): %v", tc.locale, err)
+			continue
+		}
+		if got := l.Month(time.July); got != tc.month {
+			t.Errorf("%s: Month = %q, want %q", tc.locale, got, tc.month)
+		}
+		if got := l.Weekday(time.Sunday); got != tc.weekday {
+			t.Errorf("%s: Weekday = %q, want %q", tc.locale, got, tc.weekday)
+		}
+	}
+
+	for _, bad := range []string{"", "not a tag", "ja"} {
+		if _, err := ParseLocale(bad); err == nil {
+			t.Errorf("ParseLocale(%q) should fail", bad)
+		}
+	}
+}
+
+func TestLocale_NilIsEnglish(t *testing.T) {
+	var l *Locale
+	if l.Month(time.December) != "December" || l.Weekday(time.Saturday) != "Saturday" {
+		t.Error("nil locale should name months and weekdays in English")
+	}
+}
@@ -0,0 +1,205 @@
+package pathtmpl
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+)
+
+// modifier rewrites a token's value, e.g. the |lower in {model|lower}.
+type modifier struct {
+	name  string
+	apply func(string) string
+}
+
+// modifierDef is a modifier's name and usage, and builds it from its
+// arguments.
+type modifierDef struct {
+	name, usage string
+	build       func(args []string) (func(string) string, error)
+}
+
+var modifiers = []modifierDef{
+	{name: "lower", usage: "lower", build: noArgs(strings.ToLower)},
+	{name: "upper", usage: "upper", build: noArgs(strings.ToUpper)},
+	{name: "title", usage: "title", build: noArgs(title)},
+	{
+		name:  "replace",
+		usage: "replace 'old' 'new'",
+		build: func(args []string) (func(string) string, error) {
+			if len(args) != 2 {
+				return nil, fmt.Errorf("takes the text to replace and its replacement")
+			}
+			if args[0] == "" {
+				return nil, fmt.Errorf("cannot replace empty text")
+			}
+			return func(v string) string { return strings.ReplaceAll(v, args[0], args[1]) }, nil
+		},
+	},
+	{
+		name:  "slice",
+		usage: "slice start [end]",
+		build: func(args []string) (func(string) string, error) {
+			if len(args) < 1 || len(args) > 2 {
+				return nil, fmt.Errorf("takes a start and an optional end")
+			}
+			bounds := make([]int, len(args))
+			for i, a := range args {
+				n, err := strconv.Atoi(a)
+				if err != nil || n < 0 {
+					return nil, fmt.Errorf("%q is not a character position", a)
+				}
+				bounds[i] = n
+			}
+			if len(bounds) == 2 && bounds[1] < bounds[0] {
+				return nil, fmt.Errorf("end %d is before start %d", bounds[1], bounds[0])
+			}
+			return func(v string) string {
+				r := []rune(v)
+				start, end := min(bounds[0], len(r)), len(r)
+				if len(bounds) == 2 {
+					end = min(bounds[1], len(r))
+				}
+				return string(r[start:end])
+			}, nil
+		},
+	},
+}
+
+// title upper-cases the first letter of each word. A Caser keeps state, so
+// each call gets its own for parallel rendering.
+func title(v string) string {
+	return cases.Title(language.Und, cases.NoLower).String(v)
+}
+
+func noArgs(f func(string) string) func([]string) (func(string) string, error) {
+	return func(args []string) (func(string) string, error) {
+		if len(args) > 0 {
+			return nil, fmt.Errorf("takes no arguments")
+		}
+		return f, nil
+	}
+}
+
+// parseExpr parses the inside of braces, such as "model|replace ' ' '_'",
+// starting at offset pos of tmpl, into a token and its modifiers.
+func parseExpr(tmpl, expr string, pos int) (*Token, []modifier, error) {
+	segs, err := splitPipes(tmpl, expr, pos)
+	if err != nil {
+		return nil, nil, err
+	}
+	name := strings.TrimSpace(segs[0].text)
+	tok, ok := Lookup(name)
+	if !ok {
+		return nil, nil, &ParseError{Template: tmpl, Pos: pos - 1, Msg: fmt.Sprintf("unknown token {%s}", name)}
+	}
+	var mods []modifier
+	for _, seg := range segs[1:] {
+		words, err := splitArgs(tmpl, seg)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(words) == 0 {
+			return nil, nil, &ParseError{Template: tmpl, Pos: seg.pos, Msg: "empty modifier after '|'"}
+		}
+		def, ok := lookupModifier(words[0])
+		if !ok {
+			return nil, nil, &ParseError{Template: tmpl, Pos: seg.pos, Msg: fmt.Sprintf("unknown modifier %q", words[0])}
+		}
+		apply, err := def.build(words[1:])
+		if err != nil {
+			return nil, nil, &ParseError{Template: tmpl, Pos: seg.pos, Msg: fmt.Sprintf("modifier %s %v (usage: %s)", def.name, err, def.usage)}
+		}
+		mods = append(mods, modifier{name: def.name, apply: apply})
+	}
+	return &tok, mods, nil
+}
+
+func lookupModifier(name string) (modifierDef, bool) {
+	for _, m := range modifiers {
+		if m.name == name {
+			return m, true
+		}
+	}
+	return modifierDef{}, false
+}
+
+// segment is a piece of a brace expression and its offset in the template.
+type segment struct {
+	text string
+	pos  int
+}
+
+// splitPipes splits expr at the '|' outside quotes.
+func splitPipes(tmpl, expr string, pos int) ([]segment, error) {
+	var segs []segment
+	start := 0
+	var quote byte
+	for i := 0; i < len(expr); i++ {
+		switch c := expr[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '|':
+			segs = append(segs, segment{text: expr[start:i], pos: pos + start})
+			start = i + 1
+		}
+	}
+	if quote != 0 {
+		return nil, &ParseError{Template: tmpl, Pos: pos + len(expr), Msg: "unclosed quote"}
+	}
+	return append(segs, segment{text: expr[start:], pos: pos + start}), nil
+}
+
+// splitArgs splits a modifier into its name and arguments: words separated
+// by spaces, or text in single or double quotes, which may be empty.
+func splitArgs(tmpl string, seg segment) ([]string, error) {
+	var words []string
+	s := seg.text
+	for i := 0; i < len(s); {
+		switch c := s[i]; {
+		case c == ' ':
+			i++
+		case c == '\'' || c == '"':
+			end := strings.IndexByte(s[i+1:], c)
+			if end < 0 {
+				return nil, &ParseError{Template: tmpl, Pos: seg.pos + i, Msg: "unclosed quote"}
+			}
+			words = append(words, s[i+1:i+1+end])
+			i += end + 2
+		default:
+			end := strings.IndexAny(s[i:], " '\"")
+			if end < 0 {
+				end = len(s) - i
+			}
+			words = append(words, s[i:i+end])
+			i += end
+		}
+	}
+	return words, nil
+}
+
+// closingBrace returns the offset in s of the '}' closing the '{' at
+// open, skipping quoted text, or -1.
+func closingBrace(s string, open int) int {
+	var quote byte
+	for i := open + 1; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '}':
+			return i
+		}
+	}
+	return -1
+}
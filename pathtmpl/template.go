@@ -0,0 +1,302 @@
+package pathtmpl
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/Tmunayyer/gocamelpack/files"
+)
+
+// Default reproduces the built-in YYYY/MM/DD/HH_mm layout.
+const Default = "{year}/{month}/{day}/{hour}_{minute}"
+
+// ParseError reports a problem at a byte offset within a template.
+type ParseError struct {
+	Template string
+	Pos      int
+	Msg      string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("template %q: %s at position %d", e.Template, e.Msg, e.Pos)
+}
+
+// part is a literal run of text, a single token with the modifiers
+// applied to its value, or a conditional.
+type part struct {
+	literal string
+	token   *Token
+	mods    []modifier
+	cond    *conditional
+}
+
+// conditional is {if token}then{else}otherwise{end}: then is rendered when
+// the token has a value, otherwise the else branch, which may be empty.
+type conditional struct {
+	token *Token
+	then  []part
+	els   []part
+}
+
+// Template is a parsed destination template such as
+// "{year}/{month}/{model}/{hour}_{minute}". Paths use forward slashes
+// regardless of platform. A token's value can be rewritten by modifiers,
+// as in "{model|lower|replace ' ' '_'}" or "{orig|slice 0 8}", and
+// "{if model}{model}{else}unknown-camera{end}" falls back when a tag is
+// missing.
+type Template struct {
+	raw    string
+	parts  []part
+	locale *Locale
+}
+
+// Parse validates and compiles a template.
+func Parse(s string) (*Template, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, &ParseError{Template: s, Msg: "template is empty"}
+	}
+	if strings.HasPrefix(s, "/") {
+		return nil, &ParseError{Template: s, Pos: 0, Msg: "template must be relative"}
+	}
+
+	t := &Template{raw: s}
+	// open holds the conditionals not yet closed by {end}, innermost last,
+	// with where each began.
+	type block struct {
+		cond   *conditional
+		pos    int
+		inElse bool
+	}
+	var open []block
+	current := func() *[]part {
+		if len(open) == 0 {
+			return &t.parts
+		}
+		b := open[len(open)-1]
+		if b.inElse {
+			return &b.cond.els
+		}
+		return &b.cond.then
+	}
+	var lit strings.Builder
+	flush := func() {
+		if lit.Len() > 0 {
+			parts := current()
+			*parts = append(*parts, part{literal: lit.String()})
+			lit.Reset()
+		}
+	}
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			end := closingBrace(s, i)
+			if end < 0 {
+				return nil, &ParseError{Template: s, Pos: i, Msg: "unclosed '{'"}
+			}
+			expr := strings.TrimSpace(s[i+1 : end])
+			flush()
+			switch {
+			case expr == "if" || strings.HasPrefix(expr, "if "):
+				name := strings.TrimSpace(strings.TrimPrefix(expr, "if"))
+				if name == "" {
+					return nil, &ParseError{Template: s, Pos: i, Msg: "{if} needs a token, as in {if model}"}
+				}
+				tok, ok := Lookup(name)
+				if !ok {
+					return nil, &ParseError{Template: s, Pos: i, Msg: fmt.Sprintf("unknown token %q in {if}", name)}
+				}
+				open = append(open, block{cond: &conditional{token: &tok}, pos: i})
+			case expr == "else":
+				if len(open) == 0 {
+					return nil, &ParseError{Template: s, Pos: i, Msg: "{else} without {if}"}
+				}
+				if open[len(open)-1].inElse {
+					return nil, &ParseError{Template: s, Pos: i, Msg: "second {else} in one {if}"}
+				}
+				open[len(open)-1].inElse = true
+			case expr == "end":
+				if len(open) == 0 {
+					return nil, &ParseError{Template: s, Pos: i, Msg: "{end} without {if}"}
+				}
+				b := open[len(open)-1]
+				open = open[:len(open)-1]
+				parts := current()
+				*parts = append(*parts, part{cond: b.cond})
+			default:
+				tok, mods, err := parseExpr(s, s[i+1:end], i+1)
+				if err != nil {
+					return nil, err
+				}
+				parts := current()
+				*parts = append(*parts, part{token: tok, mods: mods})
+			}
+			i = end
+		case '}':
+			return nil, &ParseError{Template: s, Pos: i, Msg: "unexpected '}'"}
+		default:
+			lit.WriteByte(s[i])
+		}
+	}
+	if len(open) > 0 {
+		return nil, &ParseError{Template: s, Pos: open[len(open)-1].pos, Msg: "{if} without {end}"}
+	}
+	flush()
+	return t, nil
+}
+
+// MustParse is like Parse but panics on error. Intended for constants.
+func MustParse(s string) *Template {
+	t, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// String returns the template source.
+func (t *Template) String() string {
+	return t.raw
+}
+
+// SetLocale makes {month_name} and {weekday} render in l; nil is English.
+func (t *Template) SetLocale(l *Locale) {
+	t.locale = l
+}
+
+// Tokens returns the names of the tokens used, conditions included, in
+// order of appearance.
+func (t *Template) Tokens() []string {
+	var names []string
+	eachToken(t.parts, func(tok *Token) { names = append(names, tok.Name) })
+	return names
+}
+
+// Tags returns the metadata tags the template's tokens consult, each
+// once, in order of appearance.
+func (t *Template) Tags() []string {
+	var tags []string
+	seen := map[string]bool{}
+	eachToken(t.parts, func(tok *Token) {
+		for _, tag := range tok.Tags {
+			if !seen[tag] {
+				seen[tag] = true
+				tags = append(tags, tag)
+			}
+		}
+	})
+	return tags
+}
+
+// eachToken calls fn for the tokens of parts, descending into
+// conditionals.
+func eachToken(parts []part, fn func(*Token)) {
+	for _, p := range parts {
+		switch {
+		case p.token != nil:
+			fn(p.token)
+		case p.cond != nil:
+			fn(p.cond.token)
+			eachToken(p.cond.then, fn)
+			eachToken(p.cond.els, fn)
+		}
+	}
+}
+
+// uses reports whether the template contains the named token.
+func (t *Template) uses(name string) bool {
+	for _, n := range t.Tokens() {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Render produces the relative destination path for md. When the template
+// contains neither {ext} nor {orig}, the source file's extension is
+// appended so the file type is preserved.
+func (t *Template) Render(md files.FileMetadata) (string, error) {
+	var b strings.Builder
+	if err := t.render(&b, t.parts, md); err != nil {
+		return "", err
+	}
+
+	// Folders left empty by missing or emptied values are dropped rather
+	// than rendered as "//" or a leading "/".
+	var segs []string
+	for _, seg := range strings.Split(b.String(), "/") {
+		if seg != "" {
+			segs = append(segs, seg)
+		}
+	}
+	if len(segs) == 0 {
+		return "", fmt.Errorf("template %q rendered an empty path for %s", t.raw, md.Filepath)
+	}
+	rel := strings.Join(segs, "/")
+	if !t.uses("ext") && !t.uses("orig") {
+		rel += filepath.Ext(md.Filepath)
+	}
+
+	rel = filepath.Clean(filepath.FromSlash(rel))
+	if rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) || filepath.IsAbs(rel) {
+		return "", fmt.Errorf("template %q rendered %q, which escapes the destination root", t.raw, rel)
+	}
+	return rel, nil
+}
+
+func (t *Template) render(b *strings.Builder, parts []part, md files.FileMetadata) error {
+	for _, p := range parts {
+		switch {
+		case p.cond != nil:
+			branch := p.cond.els
+			if v, _, ok := p.cond.token.ResolveIn(md, t.locale); ok && v != "" {
+				branch = p.cond.then
+			}
+			if err := t.render(b, branch, md); err != nil {
+				return err
+			}
+			continue
+		case p.token == nil:
+			b.WriteString(p.literal)
+			continue
+		}
+		v, _, ok := p.token.ResolveIn(md, t.locale)
+		if !ok {
+			if p.token.isDate() {
+				return files.Errorf(files.ErrNoDate, "template token %s: no usable date tag (%s)",
+					p.token.Placeholder(), strings.Join(DateTags, ", "))
+			}
+			return fmt.Errorf("template token %s: no value (needs %s)",
+				p.token.Placeholder(), strings.Join(p.token.Tags, " or "))
+		}
+		for _, m := range p.mods {
+			v = m.apply(v)
+		}
+		b.WriteString(sanitizeSegment(v))
+	}
+	return nil
+}
+
+// Destination renders md under baseDir.
+func (t *Template) Destination(md files.FileMetadata, baseDir string) (string, error) {
+	rel, err := t.Render(md)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(baseDir, rel), nil
+}
+
+// sanitizeSegment keeps tag values from introducing path separators.
+func sanitizeSegment(v string) string {
+	v = strings.TrimSpace(v)
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\':
+			return '-'
+		case 0:
+			return -1
+		}
+		return r
+	}, v)
+}
@@ -0,0 +1,194 @@
+package pathtmpl
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/files"
+)
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		tpl     string
+		wantPos int
+		wantMsg string
+	}{
+		{"", 0, "template is empty"},
+		{"/abs/{year}", 0, "must be relative"},
+		{"{year}/{nope}", 7, "unknown token {nope}"},
+		{"{year}/{month", 7, "unclosed '{'"},
+		{"{year}}", 6, "unexpected '}'"},
+		{"{year}/{model|shout}", 14, `unknown modifier "shout"`},
+		{"{model|replace 'a'}", 7, "usage: replace 'old' 'new'"},
+		{"{orig|slice 8 2}", 6, "end 2 is before start 8"},
+		{"{orig|slice x}", 6, "not a character position"},
+		{"{model|replace 'a }", 0, "unclosed '{'"},
+		{"{model|}", 7, "empty modifier"},
+		{"{year}/{if model}{model}", 7, "{if} without {end}"},
+		{"{year}/{else}x{end}", 7, "{else} without {if}"},
+		{"{year}{end}", 6, "{end} without {if}"},
+		{"{if model}a{else}b{else}c{end}", 18, "second {else}"},
+		{"{if nope}a{end}", 0, `unknown token "nope" in {if}`},
+		{"{if}a{end}", 0, "{if} needs a token"},
+		{"{if make}{if model}{model}{end}", 0, "{if} without {end}"},
+	}
+	for _, tc := range tests {
+		_, err := Parse(tc.tpl)
+		var pe *ParseError
+		if !errors.As(err, &pe) {
+			t.Errorf("Parse(%q): expected ParseError, got %v", tc.tpl, err)
+			continue
+		}
+		if pe.Pos != tc.wantPos || !strings.Contains(pe.Msg, tc.wantMsg) {
+			t.Errorf("Parse(%q) = pos %d %q, want pos %d containing %q", tc.tpl, pe.Pos, pe.Msg, tc.wantPos, tc.wantMsg)
+		}
+	}
+}
+
+func TestTemplate_Tokens(t *testing.T) {
+	tpl := MustParse("{year}/{model}/{hour}_{minute}")
+	want := []string{"year", "model", "hour", "minute"}
+	if got := tpl.Tokens(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Tokens() = %v, want %v", got, want)
+	}
+}
+
+func TestTemplate_Render(t *testing.T) {
+	md := files.FileMetadata{
+		Filepath: "/card/IMG_0001.jpg",
+		Tags: map[string]string{
+			"CreationDate": "2025:01:27 07:31:15-06:00",
+			"Model":        "EOS 5D Mark II / Body",
+		},
+	}
+
+	tests := []struct {
+		tpl  string
+		want string
+	}{
+		{Default, "2025/01/27/07_31.jpg"},
+		{"{year}/{model}/{hour}{minute}", "2025/EOS 5D Mark II - Body/0731.jpg"},
+		{"{year}-{month}/{minute}.{ext}", "2025-01/31.jpg"},
+		{"{year}/{month}/{day}/{orig}", "2025/01/27/IMG_0001.jpg"},
+		{"{year}/{orig_noext}_{hour}{minute}", "2025/IMG_0001_0731.jpg"},
+		{"{model|lower|replace ' ' '_'}/{orig}", "eos_5d_mark_ii_-_body/IMG_0001.jpg"},
+		{"{orig|slice 0 8}.{ext|upper}", "IMG_0001.JPG"},
+		{"{orig_noext|slice 4}/{orig|lower}", "0001/img_0001.jpg"},
+		{"{model|replace 'EOS ' ''|slice 0 50}", "5D Mark II - Body.jpg"},
+		{`{model|lower|title|replace "|" "-"}`, "Eos 5D Mark Ii - Body.jpg"},
+	}
+	for _, tc := range tests {
+		got, err := MustParse(tc.tpl).Render(md)
+		if err != nil {
+			t.Errorf("Render(%q) error: %v", tc.tpl, err)
+			continue
+		}
+		if got != filepath.FromSlash(tc.want) {
+			t.Errorf("Render(%q) = %q, want %q", tc.tpl, got, tc.want)
+		}
+	}
+}
+
+func TestTemplate_RenderLocale(t *testing.T) {
+	md := files.FileMetadata{
+		Filepath: "/card/IMG_0001.jpg",
+		Tags:     map[string]string{"CreationDate": "2025:07:27 07:31:15-06:00"},
+	}
+	tpl := MustParse("{year}/{month}-{month_name}/{weekday}")
+	if got, _ := tpl.Render(md); got != filepath.FromSlash("2025/07-July/Sunday.jpg") {
+		t.Errorf("default locale rendered %q", got)
+	}
+	de, err := ParseLocale("de")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpl.SetLocale(de)
+	if got, _ := tpl.Render(md); got != filepath.FromSlash("2025/07-Juli/Sonntag.jpg") {
+		t.Errorf("German locale rendered %q", got)
+	}
+}
+
+func TestTemplate_RenderMissingValues(t *testing.T) {
+	md := files.FileMetadata{Filepath: "/a.jpg", Tags: map[string]string{}}
+
+	_, err := MustParse(Default).Render(md)
+	if !errors.Is(err, files.ErrNoDate) {
+		t.Errorf("missing date should be ErrNoDate, got %v", err)
+	}
+
+	_, err = MustParse("{model}").Render(md)
+	if err == nil || !strings.Contains(err.Error(), "{model}") {
+		t.Errorf("missing model should name the token, got %v", err)
+	}
+}
+
+func TestTemplate_RenderConditionals(t *testing.T) {
+	tagged := files.FileMetadata{Filepath: "/card/a.jpg", Tags: map[string]string{"CreationDate": "2025:01:27 07:31:15", "Make": "Canon", "Model": "EOS R5"}}
+	bare := files.FileMetadata{Filepath: "/card/a.jpg", Tags: map[string]string{"CreationDate": "2025:01:27 07:31:15"}}
+
+	tests := []struct {
+		tpl          string
+		tagged, bare string
+	}{
+		{"{year}/{if model}{model}{else}unknown-camera{end}/{orig}", "2025/EOS R5/a.jpg", "2025/unknown-camera/a.jpg"},
+		{"{year}/{if model}{model}{end}/{orig}", "2025/EOS R5/a.jpg", "2025/a.jpg"},
+		{"{if make}{make}/{if model}{model|lower}{end}{end}/{year}", "Canon/eos r5/2025.jpg", "2025.jpg"},
+	}
+	for _, tc := range tests {
+		tpl := MustParse(tc.tpl)
+		for _, c := range []struct {
+			md   files.FileMetadata
+			want string
+		}{{tagged, tc.tagged}, {bare, tc.bare}} {
+			got, err := tpl.Render(c.md)
+			if err != nil || got != filepath.FromSlash(c.want) {
+				t.Errorf("Render(%q) = %q, %v; want %q", tc.tpl, got, err, c.want)
+			}
+		}
+	}
+
+	if _, err := MustParse("{if model}{model}{else}{make}{end}").Render(bare); err == nil || !strings.Contains(err.Error(), "{make}") {
+		t.Errorf("a missing token in the taken branch should fail, got %v", err)
+	}
+	if _, err := MustParse("{if model}{model}{end}").Render(files.FileMetadata{Filepath: "/card/a"}); err == nil {
+		t.Error("expected an empty rendered path to fail")
+	}
+}
+
+func TestTemplate_RenderRejectsEscapes(t *testing.T) {
+	md := files.FileMetadata{Filepath: "/a.jpg", Tags: map[string]string{"Model": ".."}}
+	if _, err := MustParse("{model}/../../x").Render(md); err == nil {
+		t.Error("expected error for a path escaping the root")
+	}
+}
+
+func TestTemplate_Destination(t *testing.T) {
+	md := files.FileMetadata{
+		Filepath: "/card/clip.MOV",
+		Tags:     map[string]string{"CreationDate": "2025:06:15 12:34:56-06:00"},
+	}
+	got, err := MustParse(Default).Destination(md, "/archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join("/archive", "2025", "06", "15", "12_34.MOV"); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestTemplate_Tags(t *testing.T) {
+	tpl := MustParse("{year}/{model}/{month}/{orig}")
+	want := append(append([]string{}, DateTags...), "Model")
+	if got := tpl.Tags(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Tags() = %v, want %v", got, want)
+	}
+
+	tpl = MustParse("{year}/{if lens}{lens}{else}{make}{end}")
+	want = append(append([]string{}, DateTags...), "LensModel", "LensID", "Make")
+	if got := tpl.Tags(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Tags() with a conditional = %v, want %v", got, want)
+	}
+}
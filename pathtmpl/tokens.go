@@ -0,0 +1,173 @@
+// Package pathtmpl defines the tokens that destination templates are built
+// from and how each one is derived from a file's metadata.
+package pathtmpl
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Tmunayyer/gocamelpack/files"
+)
+
+// DateTags lists the metadata tags consulted for date tokens, in order of
+// preference. CreationDate comes first to match the default layout.
+var DateTags = []string{"CreationDate", "DateTimeOriginal", "CreateDate", "MediaCreateDate"}
+
+// Token is a placeholder such as {year} that can appear in a template.
+type Token struct {
+	Name        string // without braces
+	Description string
+	Tags        []string // metadata tags consulted, in order of preference
+
+	value func(md files.FileMetadata, l *Locale) (value, source string, ok bool)
+	date  bool
+}
+
+// isDate reports whether the token is derived from the capture date.
+func (t Token) isDate() bool {
+	return t.date
+}
+
+// Placeholder returns the token as written in a template, e.g. "{year}".
+func (t Token) Placeholder() string {
+	return "{" + t.Name + "}"
+}
+
+// Resolve returns the token's value for md together with the tag it came
+// from. ok is false when none of the token's tags are usable. Names are
+// English; see ResolveIn.
+func (t Token) Resolve(md files.FileMetadata) (value, source string, ok bool) {
+	return t.value(md, nil)
+}
+
+// ResolveIn is like Resolve with month and weekday names from l.
+func (t Token) ResolveIn(md files.FileMetadata, l *Locale) (value, source string, ok bool) {
+	return t.value(md, l)
+}
+
+// CaptureTime returns the first parseable date among DateTags.
+func CaptureTime(md files.FileMetadata) (time.Time, string, bool) {
+	for _, tag := range DateTags {
+		raw := md.Tags[tag]
+		if raw == "" {
+			continue
+		}
+		if t, err := files.ParseExifDate(raw); err == nil {
+			return t, tag, true
+		}
+	}
+	return time.Time{}, "", false
+}
+
+func dateToken(name, desc string, format func(time.Time) string) Token {
+	return namedDateToken(name, desc, func(t time.Time, _ *Locale) string { return format(t) })
+}
+
+// namedDateToken is a date token whose value depends on the locale.
+func namedDateToken(name, desc string, format func(time.Time, *Locale) string) Token {
+	return Token{
+		Name:        name,
+		Description: desc,
+		Tags:        DateTags,
+		date:        true,
+		value: func(md files.FileMetadata, l *Locale) (string, string, bool) {
+			t, src, ok := CaptureTime(md)
+			if !ok {
+				return "", "", false
+			}
+			return format(t, l), src, true
+		},
+	}
+}
+
+func tagToken(name, desc string, tags ...string) Token {
+	return Token{
+		Name:        name,
+		Description: desc,
+		Tags:        tags,
+		value: func(md files.FileMetadata, _ *Locale) (string, string, bool) {
+			for _, tag := range tags {
+				if v := strings.TrimSpace(md.Tags[tag]); v != "" {
+					return v, tag, true
+				}
+			}
+			return "", "", false
+		},
+	}
+}
+
+var tokens = []Token{
+	dateToken("year", "four-digit capture year", func(t time.Time) string { return fmt.Sprintf("%04d", t.Year()) }),
+	dateToken("month", "two-digit capture month", func(t time.Time) string { return fmt.Sprintf("%02d", int(t.Month())) }),
+	namedDateToken("month_name", "capture month's name in the --locale language", func(t time.Time, l *Locale) string { return l.Month(t.Month()) }),
+	dateToken("day", "two-digit capture day", func(t time.Time) string { return fmt.Sprintf("%02d", t.Day()) }),
+	namedDateToken("weekday", "capture weekday's name in the --locale language", func(t time.Time, l *Locale) string { return l.Weekday(t.Weekday()) }),
+	dateToken("week", "two-digit ISO 8601 week of the capture date (pair with {yearweek}, not {year}, around New Year)", func(t time.Time) string {
+		_, w := t.ISOWeek()
+		return fmt.Sprintf("%02d", w)
+	}),
+	dateToken("yearweek", "ISO 8601 week-numbering year and week, e.g. 2026-W01 for 2025-12-29", func(t time.Time) string {
+		y, w := t.ISOWeek()
+		return fmt.Sprintf("%04d-W%02d", y, w)
+	}),
+	dateToken("quarter", "capture quarter, 1 to 4", func(t time.Time) string { return fmt.Sprintf("%d", (int(t.Month())+2)/3) }),
+	dateToken("hour", "two-digit capture hour (24h)", func(t time.Time) string { return fmt.Sprintf("%02d", t.Hour()) }),
+	dateToken("minute", "two-digit capture minute", func(t time.Time) string { return fmt.Sprintf("%02d", t.Minute()) }),
+	dateToken("second", "two-digit capture second", func(t time.Time) string { return fmt.Sprintf("%02d", t.Second()) }),
+	tagToken("make", "camera manufacturer", "Make"),
+	tagToken("model", "camera model", "Model"),
+	tagToken("lens", "lens model", "LensModel", "LensID"),
+	tagToken("filetype", "file type reported by exiftool", "FileType"),
+	{
+		Name:        "ext",
+		Description: "original file extension without the dot",
+		value: func(md files.FileMetadata, _ *Locale) (string, string, bool) {
+			ext := strings.TrimPrefix(filepath.Ext(md.Filepath), ".")
+			if ext == "" {
+				return "", "", false
+			}
+			return ext, "file name", true
+		},
+	},
+	{
+		Name:        "orig",
+		Description: "original file name with its extension",
+		value: func(md files.FileMetadata, _ *Locale) (string, string, bool) {
+			name := filepath.Base(md.Filepath)
+			if md.Filepath == "" || name == "." {
+				return "", "", false
+			}
+			return name, "file name", true
+		},
+	},
+	{
+		Name:        "orig_noext",
+		Description: "original file name without its extension",
+		value: func(md files.FileMetadata, _ *Locale) (string, string, bool) {
+			name := strings.TrimSuffix(filepath.Base(md.Filepath), filepath.Ext(md.Filepath))
+			if md.Filepath == "" || name == "" || name == "." {
+				return "", "", false
+			}
+			return name, "file name", true
+		},
+	},
+}
+
+// Tokens returns every supported token in display order.
+func Tokens() []Token {
+	out := make([]Token, len(tokens))
+	copy(out, tokens)
+	return out
+}
+
+// Lookup finds a token by name (without braces).
+func Lookup(name string) (Token, bool) {
+	for _, t := range tokens {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return Token{}, false
+}
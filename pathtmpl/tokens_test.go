@@ -0,0 +1,96 @@
+package pathtmpl
+
+import (
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/files"
+)
+
+func TestTokenResolve(t *testing.T) {
+	md := files.FileMetadata{
+		Filepath: "/card/DCIM/IMG_0001.JPG",
+		Tags: map[string]string{
+			"DateTimeOriginal": "2025:01:27 07:31:15",
+			"Model":            "X100V",
+			"LensID":           "Fujinon 23mm",
+		},
+	}
+
+	tests := []struct {
+		token      string
+		wantValue  string
+		wantSource string
+		wantOK     bool
+	}{
+		{"year", "2025", "DateTimeOriginal", true},
+		{"month", "01", "DateTimeOriginal", true},
+		{"minute", "31", "DateTimeOriginal", true},
+		{"model", "X100V", "Model", true},
+		{"lens", "Fujinon 23mm", "LensID", true},
+		{"make", "", "", false},
+		{"ext", "JPG", "file name", true},
+	}
+
+	for _, tc := range tests {
+		tok, ok := Lookup(tc.token)
+		if !ok {
+			t.Fatalf("token %q not registered", tc.token)
+		}
+		v, src, ok := tok.Resolve(md)
+		if v != tc.wantValue || src != tc.wantSource || ok != tc.wantOK {
+			t.Errorf("%s: got (%q, %q, %v), want (%q, %q, %v)",
+				tok.Placeholder(), v, src, ok, tc.wantValue, tc.wantSource, tc.wantOK)
+		}
+	}
+}
+
+func TestWeekAndQuarterTokens(t *testing.T) {
+	tests := []struct {
+		date                    string
+		week, yearweek, quarter string
+	}{
+		{"2025:01:27 07:31:15", "05", "2025-W05", "1"},
+		// ISO weeks belong to the year of their Thursday.
+		{"2025:12:29 10:00:00", "01", "2026-W01", "4"},
+		{"2021:01:03 10:00:00", "53", "2020-W53", "1"},
+		{"2024:06:30 23:59:59", "26", "2024-W26", "2"},
+		{"2024:07:01 00:00:00", "27", "2024-W27", "3"},
+	}
+	for _, tc := range tests {
+		md := files.FileMetadata{Tags: map[string]string{"DateTimeOriginal": tc.date}}
+		for name, want := range map[string]string{"week": tc.week, "yearweek": tc.yearweek, "quarter": tc.quarter} {
+			tok, _ := Lookup(name)
+			if got, _, ok := tok.Resolve(md); !ok || got != want {
+				t.Errorf("{%s} of %s = %q, want %q", name, tc.date, got, want)
+			}
+		}
+	}
+}
+
+func TestCaptureTime_PrefersCreationDate(t *testing.T) {
+	md := files.FileMetadata{Tags: map[string]string{
+		"CreationDate":     "2024:12:31 23:00:00-06:00",
+		"DateTimeOriginal": "2025:01:27 07:31:15",
+	}}
+	tm, src, ok := CaptureTime(md)
+	if !ok || src != "CreationDate" || tm.Year() != 2024 {
+		t.Errorf("expected CreationDate to win, got %v from %q (ok=%v)", tm, src, ok)
+	}
+}
+
+func TestCaptureTime_SkipsUnparseable(t *testing.T) {
+	md := files.FileMetadata{Tags: map[string]string{
+		"CreationDate": "0000:00:00 00:00:00",
+		"CreateDate":   "2025:02:01 10:00:00",
+	}}
+	_, src, ok := CaptureTime(md)
+	if !ok || src != "CreateDate" {
+		t.Errorf("expected fallback to CreateDate, got %q (ok=%v)", src, ok)
+	}
+}
+
+func TestLookup_Unknown(t *testing.T) {
+	if _, ok := Lookup("nope"); ok {
+		t.Error("expected unknown token lookup to fail")
+	}
+}
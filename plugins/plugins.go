@@ -0,0 +1,276 @@
+// Package plugins runs external programs that extend planning without a
+// fork: metadata providers, which add tags to a file's metadata (say, the
+// people a face recogniser finds), and destination resolvers, which choose
+// where a file goes.
+//
+// A plugin is started once per run and speaks JSON over stdin and stdout:
+// it reads one request per line and answers each with one line. Its stderr
+// is passed through.
+//
+//	-> {"version":1,"type":"metadata","path":"/card/a.jpg","tags":{"Model":"X-T5"}}
+//	<- {"tags":{"Person":"alice"}}
+//	-> {"version":1,"type":"resolve","path":"/card/a.jpg","tags":{"Person":"alice"},"root":"/archive"}
+//	<- {"destination":"people/alice/a.jpg"}
+//
+// An answer with "error" set fails the file. A resolver that answers
+// without a destination leaves the file to the template and rules.
+package plugins
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/Tmunayyer/gocamelpack/files"
+)
+
+// Version is the protocol version sent with every request.
+const Version = 1
+
+// Kind is what a plugin does.
+type Kind string
+
+const (
+	KindMetadata Kind = "metadata"
+	KindResolver Kind = "resolver"
+)
+
+// Spec configures a plugin.
+type Spec struct {
+	Name string `json:"name"`
+	Kind Kind   `json:"kind"`
+	// Command is the program and its arguments.
+	Command []string `json:"command"`
+	// Tags are the metadata tags the plugin needs besides the capture
+	// dates, which are always read.
+	Tags []string `json:"tags,omitempty"`
+}
+
+// Validate reports a spec that cannot be run.
+func (s Spec) Validate() error {
+	switch {
+	case s.Name == "":
+		return fmt.Errorf("plugin without a name")
+	case s.Kind != KindMetadata && s.Kind != KindResolver:
+		return fmt.Errorf("plugin %s: unknown kind %q (want %s or %s)", s.Name, s.Kind, KindMetadata, KindResolver)
+	case len(s.Command) == 0:
+		return fmt.Errorf("plugin %s: no command", s.Name)
+	}
+	return nil
+}
+
+// Request is one line sent to a plugin.
+type Request struct {
+	Version int               `json:"version"`
+	Type    string            `json:"type"`
+	Path    string            `json:"path"`
+	Tags    map[string]string `json:"tags"`
+	// Root is the destination root, for resolvers.
+	Root string `json:"root,omitempty"`
+}
+
+// Response is a plugin's answer to a Request.
+type Response struct {
+	// Tags are added to the file's metadata, replacing tags of the same
+	// name.
+	Tags map[string]string `json:"tags,omitempty"`
+	// Destination is the file's path relative to the root; empty leaves
+	// the file to the template and rules.
+	Destination string `json:"destination,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// plugin is a running plugin process, started on its first request.
+type plugin struct {
+	spec   Spec
+	stderr io.Writer
+
+	// mu serialises requests, for parallel planning.
+	mu  sync.Mutex
+	cmd *exec.Cmd
+	in  io.WriteCloser
+	out *bufio.Reader
+	// broken is why the plugin cannot answer any more requests.
+	broken error
+}
+
+func (p *plugin) start() error {
+	c := exec.Command(p.spec.Command[0], p.spec.Command[1:]...)
+	// Wrapped so the plugin's stderr is copied with plain writes: a
+	// writer's ReadFrom, such as bytes.Buffer's, would hold the writer
+	// for as long as the plugin runs.
+	c.Stderr = struct{ io.Writer }{p.stderr}
+	in, err := c.StdinPipe()
+	if err != nil {
+		return err
+	}
+	out, err := c.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := c.Start(); err != nil {
+		return err
+	}
+	p.cmd, p.in, p.out = c, in, bufio.NewReader(out)
+	return nil
+}
+
+// call sends req and reads the answer. A plugin that fails to start, exits
+// or answers with something other than a JSON line is not asked again.
+func (p *plugin) call(req Request) (Response, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.broken == nil && p.cmd == nil {
+		p.broken = p.start()
+	}
+	if p.broken != nil {
+		return Response{}, fmt.Errorf("plugin %s: %w", p.spec.Name, p.broken)
+	}
+
+	req.Version = Version
+	line, err := json.Marshal(req)
+	if err != nil {
+		return Response{}, err
+	}
+	var resp Response
+	if _, err := p.in.Write(append(line, '\n')); err != nil {
+		p.broken = err
+	} else if answer, err := p.out.ReadBytes('\n'); err != nil {
+		p.broken = fmt.Errorf("no answer: %w", err)
+	} else if err := json.Unmarshal(answer, &resp); err != nil {
+		p.broken = fmt.Errorf("invalid answer %q: %w", strings.TrimSpace(string(answer)), err)
+	}
+	if p.broken != nil {
+		return Response{}, fmt.Errorf("plugin %s: %w", p.spec.Name, p.broken)
+	}
+	if resp.Error != "" {
+		return Response{}, fmt.Errorf("plugin %s: %s", p.spec.Name, resp.Error)
+	}
+	return resp, nil
+}
+
+// close ends the plugin by closing its stdin and waits for it to exit.
+func (p *plugin) close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cmd == nil {
+		return nil
+	}
+	p.in.Close()
+	err := p.cmd.Wait()
+	p.cmd = nil
+	if err != nil {
+		return fmt.Errorf("plugin %s: %w", p.spec.Name, err)
+	}
+	return nil
+}
+
+// Host runs a run's plugins. A nil Host has none.
+type Host struct {
+	metadata  []*plugin
+	resolvers []*plugin
+}
+
+// New validates specs and returns their host, or nil without specs. The
+// plugins are started when first needed; their stderr goes to stderr.
+func New(specs []Spec, stderr io.Writer) (*Host, error) {
+	if len(specs) == 0 {
+		return nil, nil
+	}
+	h := &Host{}
+	names := map[string]bool{}
+	for _, s := range specs {
+		if err := s.Validate(); err != nil {
+			return nil, err
+		}
+		if names[s.Name] {
+			return nil, fmt.Errorf("plugin %s is configured twice", s.Name)
+		}
+		names[s.Name] = true
+		p := &plugin{spec: s, stderr: stderr}
+		if s.Kind == KindMetadata {
+			h.metadata = append(h.metadata, p)
+		} else {
+			h.resolvers = append(h.resolvers, p)
+		}
+	}
+	return h, nil
+}
+
+// Tags returns the tags the plugins need.
+func (h *Host) Tags() []string {
+	if h == nil {
+		return nil
+	}
+	var tags []string
+	for _, p := range append(append([]*plugin(nil), h.metadata...), h.resolvers...) {
+		tags = append(tags, p.spec.Tags...)
+	}
+	return tags
+}
+
+// Metadata returns md with the tags of every metadata provider added, in
+// the configured order.
+func (h *Host) Metadata(md files.FileMetadata) (files.FileMetadata, error) {
+	if h == nil || len(h.metadata) == 0 {
+		return md, nil
+	}
+	tags := make(map[string]string, len(md.Tags))
+	for k, v := range md.Tags {
+		tags[k] = v
+	}
+	for _, p := range h.metadata {
+		resp, err := p.call(Request{Type: string(KindMetadata), Path: md.Filepath, Tags: tags})
+		if err != nil {
+			return md, err
+		}
+		for k, v := range resp.Tags {
+			tags[k] = v
+		}
+	}
+	return files.FileMetadata{Filepath: md.Filepath, Tags: tags}, nil
+}
+
+// Resolve asks the resolvers, in the configured order, where md goes below
+// root. The first to answer with a destination decides; by is its name,
+// empty when none did.
+func (h *Host) Resolve(md files.FileMetadata, root string) (dst, by string, err error) {
+	if h == nil {
+		return "", "", nil
+	}
+	for _, p := range h.resolvers {
+		resp, err := p.call(Request{Type: "resolve", Path: md.Filepath, Tags: md.Tags, Root: root})
+		if err != nil {
+			return "", "", err
+		}
+		if resp.Destination == "" {
+			continue
+		}
+		rel := filepath.Clean(filepath.FromSlash(resp.Destination))
+		if filepath.IsAbs(rel) || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return "", "", fmt.Errorf("plugin %s: destination %q must be a file path relative to the root", p.spec.Name, resp.Destination)
+		}
+		return filepath.Join(root, rel), p.spec.Name, nil
+	}
+	return "", "", nil
+}
+
+// Close ends the plugins that were started.
+func (h *Host) Close() error {
+	if h == nil {
+		return nil
+	}
+	var errs []error
+	for _, p := range append(append([]*plugin(nil), h.metadata...), h.resolvers...) {
+		if err := p.close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
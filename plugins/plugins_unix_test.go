@@ -0,0 +1,85 @@
+//go:build !windows
+
+package plugins
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/files"
+)
+
+// script writes an executable shell script and returns its spec.
+func script(t *testing.T, name string, kind Kind, body string) Spec {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return Spec{Name: name, Kind: kind, Command: []string{path}}
+}
+
+func TestHost_MetadataAndResolve(t *testing.T) {
+	faces := script(t, "faces", KindMetadata, `while read line; do echo '{"tags":{"Person":"alice","Model":"X"}}'; done`)
+	people := script(t, "people", KindResolver, `while read line; do
+  case "$line" in
+  *'"Person":"alice"'*) echo '{"destination":"people/alice/a.jpg"}' ;;
+  *) echo '{}' ;;
+  esac
+done`)
+	h, err := New([]Spec{faces, people}, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	md, err := h.Metadata(files.FileMetadata{Filepath: "/card/a.jpg", Tags: map[string]string{"Model": "Y", "Make": "Z"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if md.Tags["Person"] != "alice" || md.Tags["Model"] != "X" || md.Tags["Make"] != "Z" {
+		t.Errorf("tags = %v", md.Tags)
+	}
+	dst, by, err := h.Resolve(md, "/archive")
+	if err != nil || by != "people" || dst != filepath.Join("/archive", "people", "alice", "a.jpg") {
+		t.Errorf("Resolve = %q, %q, %v", dst, by, err)
+	}
+	if _, by, err := h.Resolve(files.FileMetadata{Filepath: "/card/b.jpg"}, "/archive"); by != "" || err != nil {
+		t.Errorf("a resolver without a destination should defer, got %q, %v", by, err)
+	}
+	if err := h.Close(); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+}
+
+func TestHost_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"error answer", `read line; echo '{"error":"no faces"}'`, "no faces"},
+		{"escaping destination", `read line; echo '{"destination":"../etc/a.jpg"}'`, "relative to the root"},
+		{"not json", `read line; echo nope`, "invalid answer"},
+		{"exits", `read line; exit 0`, "no answer"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h, err := New([]Spec{script(t, "p", KindResolver, tc.body)}, io.Discard)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer h.Close()
+			if _, _, err := h.Resolve(files.FileMetadata{Filepath: "a.jpg"}, "/archive"); err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("expected %q, got %v", tc.want, err)
+			}
+		})
+	}
+
+	if _, err := New([]Spec{{Name: "x", Kind: "router", Command: []string{"x"}}}, io.Discard); err == nil {
+		t.Error("expected an unknown kind to be refused")
+	}
+}
@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"unicode/utf8"
+
+	"github.com/Tmunayyer/gocamelpack/output"
 )
 
 // ProgressBar implements a visual ASCII progress bar.
@@ -12,6 +15,10 @@ type ProgressBar struct {
 	width     int
 	barChar   rune
 	emptyChar rune
+	doneMark  string
+	failMark  string
+	ellipsis  string
+	maxWidth  int // terminal width the message is shortened to fit; 0 for no limit
 	showMsg   bool
 	finished  bool
 	errored   bool
@@ -26,8 +33,12 @@ func NewProgressBar(writer io.Writer, width int) *ProgressBar {
 	return &ProgressBar{
 		ProgressState: NewProgressState(writer),
 		width:         width,
-		barChar:       '█',
-		emptyChar:     '░',
+		barChar:       UnicodeGlyphs.Filled,
+		emptyChar:     UnicodeGlyphs.Empty,
+		doneMark:      UnicodeGlyphs.Done,
+		failMark:      UnicodeGlyphs.Failed,
+		ellipsis:      UnicodeGlyphs.Ellipsis,
+		maxWidth:      output.TerminalWidth(writer),
 		showMsg:       true,
 	}
 }
@@ -37,6 +48,36 @@ func NewSimpleProgressBar(writer io.Writer) *ProgressBar {
 	return NewProgressBar(writer, 40)
 }
 
+// SetGlyphs sets the bar characters and the finish and error marks.
+func (pb *ProgressBar) SetGlyphs(g Glyphs) {
+	pb.barChar, pb.emptyChar = g.Filled, g.Empty
+	pb.doneMark, pb.failMark, pb.ellipsis = g.Done, g.Failed, g.Ellipsis
+}
+
+// SetMaxWidth limits lines to width columns by shortening the message in
+// the middle; 0 lifts the limit. It defaults to the width of the terminal
+// the bar is written to.
+func (pb *ProgressBar) SetMaxWidth(width int) {
+	pb.maxWidth = width
+}
+
+// messagePart returns the " - message" that fits after line, leaving
+// reserve columns for what follows it.
+func (pb *ProgressBar) messagePart(line string, reserve int) string {
+	if !pb.showMsg || pb.message == "" {
+		return ""
+	}
+	if pb.maxWidth <= 0 {
+		return " - " + pb.message
+	}
+	// The last column is left free: writing to it wraps on some terminals.
+	room := pb.maxWidth - 1 - utf8.RuneCountInString(line) - reserve - len(" - ")
+	if room < minMessage {
+		return ""
+	}
+	return " - " + fit(pb.message, room, pb.ellipsis)
+}
+
 // SetBarChar sets the character used for the filled portion of the bar.
 func (pb *ProgressBar) SetBarChar(char rune) {
 	pb.barChar = char
@@ -97,9 +138,7 @@ func (pb *ProgressBar) Render() string {
 	result.WriteString(fmt.Sprintf(" %s", pb.String()))
 	
 	// Add message if enabled and present
-	if pb.showMsg && pb.message != "" {
-		result.WriteString(fmt.Sprintf(" - %s", pb.message))
-	}
+	result.WriteString(pb.messagePart(result.String(), 0))
 	
 	return result.String()
 }
@@ -142,11 +181,9 @@ func (pb *ProgressBar) Finish() {
 	// Add final stats
 	result.WriteString(fmt.Sprintf(" %s", pb.String()))
 	
-	if pb.showMsg && pb.message != "" {
-		result.WriteString(fmt.Sprintf(" - %s", pb.message))
-	}
+	result.WriteString(pb.messagePart(result.String(), 1+utf8.RuneCountInString(pb.doneMark)))
 	
-	result.WriteString(" ✓\n") // Checkmark and newline to finish
+	result.WriteString(" " + pb.doneMark + "\n") // Checkmark and newline to finish
 	
 	fmt.Fprint(pb.writer, "\r"+result.String())
 }
@@ -196,6 +233,24 @@ func (pb *ProgressBar) SetMessage(message string) {
 	pb.Update()
 }
 
+// SetTotalBytes sets the byte total and updates the display.
+func (pb *ProgressBar) SetTotalBytes(total int64) {
+	if pb.errored || pb.finished {
+		return
+	}
+	pb.ProgressState.SetTotalBytes(total)
+	pb.Update()
+}
+
+// AddBytes records transferred bytes and updates the display.
+func (pb *ProgressBar) AddBytes(n int64) {
+	if pb.errored || pb.finished {
+		return
+	}
+	pb.ProgressState.AddBytes(n)
+	pb.Update()
+}
+
 // SetError marks the progress bar as errored and displays an error state.
 func (pb *ProgressBar) SetError(err error) {
 	if pb.finished {
@@ -234,11 +289,9 @@ func (pb *ProgressBar) SetError(err error) {
 	// Add current stats
 	result.WriteString(fmt.Sprintf(" %s", pb.String()))
 	
-	if pb.showMsg && pb.message != "" {
-		result.WriteString(fmt.Sprintf(" - %s", pb.message))
-	}
+	result.WriteString(pb.messagePart(result.String(), 1+utf8.RuneCountInString(pb.failMark)))
 	
-	result.WriteString(" ✗") // Error mark
+	result.WriteString(" " + pb.failMark) // Error mark
 	if err != nil {
 		result.WriteString(fmt.Sprintf(" - Error: %s", err.Error()))
 	}
@@ -0,0 +1,371 @@
+package progress
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Tmunayyer/gocamelpack/output"
+	"github.com/Tmunayyer/gocamelpack/units"
+)
+
+// Dashboard is a status block for large imports, redrawn in place like
+// MultiBar:
+//
+//	copy 37/120 (30%) 1.2 GiB  ETA 1m20s
+//	[████████████░░░░░░░░░░░░░░░░░░░░░░░░░░░░]
+//	throughput ▁▂▃▅▇█▇▅  85.3 MiB/s
+//	worker 1: IMG_0001.JPG  3s
+//	worker 2: idle
+//	errors: none
+//
+// It is a ProgressReporter for the run and an ActivityReporter for the
+// files being transferred. The throughput graph gets one sample per
+// interval. All methods are safe for concurrent use.
+type Dashboard struct {
+	mu     sync.Mutex
+	state  *ProgressState
+	writer io.Writer
+	width  int
+	glyphs Glyphs
+	// maxWidth is the terminal's width; longer lines are shortened, since
+	// a wrapped line would throw off the redraw. 0 means no limit.
+	maxWidth int
+	slots    []dashSlot
+	history  []float64 // throughput samples, oldest first
+	errors   []string
+	failed   int // errors seen, including those no longer shown
+
+	now      func() time.Time
+	drawn    int // lines written by the last redraw
+	finished bool
+	stop     chan struct{}
+}
+
+// dashSlot is what one worker is transferring.
+type dashSlot struct {
+	name    string
+	size    int64
+	started time.Time
+	active  bool
+}
+
+// Dashboard layout limits.
+const (
+	dashboardGraph  = 40 // throughput samples shown
+	dashboardErrors = 3  // most recent errors shown
+)
+
+// NewDashboard creates a dashboard on writer with a line for each of
+// workers concurrent transfers, sampling throughput every second until
+// Finish or SetError.
+func NewDashboard(writer io.Writer, workers int) *Dashboard {
+	return newDashboard(writer, workers, time.Second)
+}
+
+// newDashboard samples throughput every interval; 0 leaves sampling to
+// the caller, for tests.
+func newDashboard(writer io.Writer, workers int, interval time.Duration) *Dashboard {
+	if workers < 1 {
+		workers = 1
+	}
+	d := &Dashboard{
+		state:    NewProgressState(writer),
+		writer:   writer,
+		width:    40,
+		glyphs:   UnicodeGlyphs,
+		maxWidth: output.TerminalWidth(writer),
+		slots:    make([]dashSlot, workers),
+		now:      time.Now,
+		stop:     make(chan struct{}),
+	}
+	if interval > 0 {
+		go d.tick(interval)
+	}
+	return d
+}
+
+func (d *Dashboard) tick(interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-d.stop:
+			return
+		case <-t.C:
+			d.sample()
+		}
+	}
+}
+
+// sample adds the current throughput to the graph: bytes per second once
+// bytes are known, files per second before.
+func (d *Dashboard) sample() {
+	d.update(func() {
+		d.history = append(d.history, d.throughput())
+		if len(d.history) > dashboardGraph {
+			d.history = d.history[len(d.history)-dashboardGraph:]
+		}
+	})
+}
+
+func (d *Dashboard) throughput() float64 {
+	if d.state.currentBytes > 0 {
+		return d.state.ByteRate()
+	}
+	return d.state.Rate()
+}
+
+// Begin implements ActivityReporter: it puts name in the first idle
+// worker line.
+func (d *Dashboard) Begin(name string, size int64) int {
+	slot := -1
+	d.update(func() {
+		for i := range d.slots {
+			if !d.slots[i].active {
+				slot = i
+				break
+			}
+		}
+		if slot < 0 {
+			d.slots = append(d.slots, dashSlot{})
+			slot = len(d.slots) - 1
+		}
+		d.slots[slot] = dashSlot{name: name, size: size, started: d.now(), active: true}
+	})
+	return slot
+}
+
+// End implements ActivityReporter: the worker line goes idle and the
+// file's bytes count towards the throughput, or err goes to the error
+// pane.
+func (d *Dashboard) End(slot int, err error) {
+	d.update(func() {
+		if slot < 0 || slot >= len(d.slots) {
+			return
+		}
+		s := d.slots[slot]
+		d.slots[slot] = dashSlot{}
+		if err != nil {
+			d.addError(fmt.Sprintf("%s: %v", s.name, err))
+			return
+		}
+		d.state.AddBytes(s.size)
+	})
+}
+
+// addError keeps the most recent errors. Callers must hold d.mu.
+func (d *Dashboard) addError(msg string) {
+	d.failed++
+	d.errors = append(d.errors, msg)
+	if len(d.errors) > dashboardErrors {
+		d.errors = d.errors[len(d.errors)-dashboardErrors:]
+	}
+}
+
+// update applies fn under the lock and redraws.
+func (d *Dashboard) update(fn func()) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.finished {
+		return
+	}
+	fn()
+	d.redraw(d.lines())
+}
+
+// redraw moves the cursor back over the previous block and replaces it
+// with lines. Callers must hold d.mu.
+func (d *Dashboard) redraw(lines []string) {
+	var b strings.Builder
+	if d.drawn > 0 {
+		fmt.Fprintf(&b, ansiCursorUp, d.drawn)
+	}
+	b.WriteString("\r" + ansiClearDown)
+	for _, l := range lines {
+		b.WriteString(fit(l, d.maxWidth-1, d.glyphs.Ellipsis) + "\n")
+	}
+	d.drawn = len(lines)
+	fmt.Fprint(d.writer, b.String())
+}
+
+// lines renders the whole block. Callers must hold d.mu.
+func (d *Dashboard) lines() []string {
+	lines := []string{d.header(), d.bar(), d.graph()}
+	for i, s := range d.slots {
+		if !s.active {
+			lines = append(lines, fmt.Sprintf("worker %d: idle", i+1))
+			continue
+		}
+		line := fmt.Sprintf("worker %d: %s", i+1, s.name)
+		if s.size > 0 {
+			line += "  " + units.ByteSize(s.size).String()
+		}
+		line += "  " + d.now().Sub(s.started).Round(time.Second).String()
+		lines = append(lines, line)
+	}
+	return append(lines, d.errorPane()...)
+}
+
+func (d *Dashboard) header() string {
+	s := d.state
+	line := s.String()
+	if s.message != "" {
+		line = s.message + " " + line
+	}
+	if s.totalBytes == 0 && s.currentBytes > 0 {
+		line += " " + units.ByteSize(s.currentBytes).String()
+	}
+	if eta, ok := s.ETA(); ok && s.Remaining() > 0 {
+		line += "  ETA " + eta.String()
+	}
+	return line
+}
+
+func (d *Dashboard) bar() string {
+	s := d.state
+	filled := 0
+	if s.total > 0 {
+		filled = min(int(float64(s.current)/float64(s.total)*float64(d.width)), d.width)
+	}
+	return "[" + strings.Repeat(string(d.glyphs.Filled), filled) + strings.Repeat(string(d.glyphs.Empty), d.width-filled) + "]"
+}
+
+// graph draws the throughput samples scaled to the largest one.
+func (d *Dashboard) graph() string {
+	var peak float64
+	for _, v := range d.history {
+		peak = max(peak, v)
+	}
+	var b strings.Builder
+	b.WriteString("throughput ")
+	levels := d.glyphs.Levels
+	for _, v := range d.history {
+		level := 0
+		if peak > 0 {
+			level = min(int(v/peak*float64(len(levels)-1)+0.5), len(levels)-1)
+		}
+		b.WriteRune(levels[level])
+	}
+	rate := d.throughput()
+	if d.state.currentBytes > 0 {
+		fmt.Fprintf(&b, "  %s/s", units.ByteSize(int64(rate)))
+	} else {
+		fmt.Fprintf(&b, "  %.1f files/s", rate)
+	}
+	return b.String()
+}
+
+// errorPane lists the most recent errors. Callers must hold d.mu.
+func (d *Dashboard) errorPane() []string {
+	if d.failed == 0 {
+		return []string{"errors: none"}
+	}
+	lines := []string{fmt.Sprintf("errors: %d", d.failed)}
+	if hidden := d.failed - len(d.errors); hidden > 0 {
+		lines = append(lines, fmt.Sprintf("  ... %d earlier", hidden))
+	}
+	for _, e := range d.errors {
+		lines = append(lines, "  "+e)
+	}
+	return lines
+}
+
+// SetGlyphs sets the characters the dashboard draws with.
+func (d *Dashboard) SetGlyphs(g Glyphs) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.glyphs = g
+}
+
+// SetTotal sets the number of files in the run.
+func (d *Dashboard) SetTotal(total int) { d.update(func() { d.state.SetTotal(total) }) }
+
+// Increment records one more finished file.
+func (d *Dashboard) Increment() { d.update(func() { d.state.Increment() }) }
+
+// IncrementBy records amount more finished files.
+func (d *Dashboard) IncrementBy(amount int) { d.update(func() { d.state.IncrementBy(amount) }) }
+
+// SetCurrent sets the number of finished files.
+func (d *Dashboard) SetCurrent(current int) { d.update(func() { d.state.SetCurrent(current) }) }
+
+// SetMessage sets the message shown at the start of the header.
+func (d *Dashboard) SetMessage(message string) { d.update(func() { d.state.SetMessage(message) }) }
+
+// SetTotalBytes sets the byte total of the run.
+func (d *Dashboard) SetTotalBytes(total int64) { d.update(func() { d.state.SetTotalBytes(total) }) }
+
+// AddBytes records transferred bytes for the run.
+func (d *Dashboard) AddBytes(n int64) { d.update(func() { d.state.AddBytes(n) }) }
+
+// Finish replaces the block with the completed bar, keeping the error
+// pane when there were errors.
+func (d *Dashboard) Finish() { d.finish(false, nil) }
+
+// SetError stops the display with err in the error pane.
+func (d *Dashboard) SetError(err error) { d.finish(true, err) }
+
+func (d *Dashboard) finish(failed bool, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.finished {
+		return
+	}
+	d.finished = true
+	close(d.stop)
+	if err != nil {
+		d.addError(err.Error())
+	}
+	mark := d.glyphs.Done
+	if failed {
+		mark = d.glyphs.Failed
+	}
+	lines := []string{d.bar() + " " + d.header() + " " + mark}
+	if d.failed > 0 {
+		lines = append(lines, d.errorPane()...)
+	}
+	d.redraw(lines)
+}
+
+// IsComplete reports whether the run has finished.
+func (d *Dashboard) IsComplete() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.finished || d.state.IsComplete()
+}
+
+// Current returns the number of finished files.
+func (d *Dashboard) Current() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.state.Current()
+}
+
+// Total returns the number of files in the run.
+func (d *Dashboard) Total() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.state.Total()
+}
+
+// CurrentBytes returns the bytes transferred so far.
+func (d *Dashboard) CurrentBytes() int64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.state.CurrentBytes()
+}
+
+// TotalBytes returns the byte total of the run.
+func (d *Dashboard) TotalBytes() int64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.state.TotalBytes()
+}
+
+var (
+	_ ByteProgressReporter = (*Dashboard)(nil)
+	_ ActivityReporter     = (*Dashboard)(nil)
+)
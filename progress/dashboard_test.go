@@ -0,0 +1,91 @@
+package progress
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDashboard_Block(t *testing.T) {
+	buf := &bytes.Buffer{}
+	d := newDashboard(buf, 2, 0)
+	now := time.Date(2025, 1, 27, 10, 0, 0, 0, time.UTC)
+	d.now = func() time.Time { return now }
+	d.width = 10
+	d.SetTotal(4)
+	d.SetMessage("copy")
+
+	slot := d.Begin("IMG_0001.JPG", 2048)
+	now = now.Add(3 * time.Second)
+	d.sample()
+	d.Begin("IMG_0002.JPG", 0)
+	d.End(slot, nil)
+	d.Increment()
+	d.sample()
+
+	frames := strings.Split(buf.String(), "\r\x1b[J")
+	last := frames[len(frames)-1]
+	for _, want := range []string{
+		"copy 1/4 (25%)",
+		"[██░░░░░░░░]\n",
+		"throughput ▁█  ",
+		"worker 1: idle\n",
+		"worker 2: IMG_0002.JPG  0s\n",
+		"errors: none\n",
+	} {
+		if !strings.Contains(last, want) {
+			t.Errorf("last frame missing %q:\n%s", want, last)
+		}
+	}
+	if !strings.Contains(buf.String(), "\x1b[6A") {
+		t.Error("expected the block to be redrawn in place with cursor-up sequences")
+	}
+	if got := d.CurrentBytes(); got != 2048 {
+		t.Errorf("CurrentBytes() = %d, want the finished file's 2048", got)
+	}
+}
+
+func TestDashboard_Errors(t *testing.T) {
+	buf := &bytes.Buffer{}
+	d := newDashboard(buf, 1, 0)
+	d.SetTotal(5)
+	for _, name := range []string{"a.jpg", "b.jpg", "c.jpg", "d.jpg"} {
+		d.End(d.Begin(name, 10), errors.New("permission denied"))
+	}
+	if got := d.CurrentBytes(); got != 0 {
+		t.Errorf("failed transfers counted %d bytes", got)
+	}
+
+	buf.Reset()
+	d.SetError(errors.New("run stopped"))
+	out := buf.String()
+	for _, want := range []string{"✗", "errors: 5\n", "  ... 2 earlier\n", "  c.jpg: permission denied\n", "  d.jpg: permission denied\n", "  run stopped\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("final block missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "worker 1") {
+		t.Errorf("final block should drop the worker lines:\n%s", out)
+	}
+
+	buf.Reset()
+	d.Increment()
+	d.Finish()
+	if buf.Len() != 0 {
+		t.Errorf("updates after SetError should be ignored, got %q", buf.String())
+	}
+}
+
+func TestActivity_Unwraps(t *testing.T) {
+	d := newDashboard(&bytes.Buffer{}, 1, 0)
+	r := NewStatusFileReporter(d, t.TempDir()+"/status.json", time.Hour)
+	defer r.Finish()
+	if a, ok := Activity(r); !ok || a != ActivityReporter(d) {
+		t.Errorf("Activity(status file over dashboard) = %v, %v", a, ok)
+	}
+	if _, ok := Activity(NewSimpleProgressBar(&bytes.Buffer{})); ok {
+		t.Error("a progress bar does not show activity")
+	}
+}
@@ -0,0 +1,32 @@
+package progress
+
+// Glyphs are the characters the progress displays draw with.
+type Glyphs struct {
+	Filled, Empty rune   // the bar
+	Done, Failed  string // the mark after a finished or failed bar
+	Ellipsis      string // stands in for the middle of a shortened message
+	Levels        []rune // the throughput graph, lowest first
+}
+
+var (
+	// UnicodeGlyphs are the default block characters and marks.
+	UnicodeGlyphs = Glyphs{Filled: '█', Empty: '░', Done: "✓", Failed: "✗", Ellipsis: "…", Levels: []rune("▁▂▃▄▅▆▇█")}
+	// ASCIIGlyphs are for terminals and logs that cannot show UTF-8.
+	ASCIIGlyphs = Glyphs{Filled: '#', Empty: '-', Done: "OK", Failed: "FAILED", Ellipsis: "...", Levels: []rune("_.-:=+*#")}
+)
+
+// SetGlyphs switches r, or the display it wraps, to g. Reporters that
+// draw no glyphs are left alone.
+func SetGlyphs(r ProgressReporter, g Glyphs) {
+	for r != nil {
+		if s, ok := r.(interface{ SetGlyphs(Glyphs) }); ok {
+			s.SetGlyphs(g)
+			return
+		}
+		u, ok := r.(interface{ Unwrap() ProgressReporter })
+		if !ok {
+			return
+		}
+		r = u.Unwrap()
+	}
+}
@@ -0,0 +1,32 @@
+package progress
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSetGlyphs_ASCII(t *testing.T) {
+	buf := &bytes.Buffer{}
+	bar := NewProgressBar(buf, 4)
+	r := NewStatusFileReporter(bar, t.TempDir()+"/status.json", time.Hour)
+	SetGlyphs(r, ASCIIGlyphs)
+	r.SetTotal(2)
+	r.Increment()
+	r.SetError(errors.New("disk full"))
+
+	out := buf.String()
+	if !strings.Contains(out, "[##--] 1/2 (50%) FAILED - Error: disk full") {
+		t.Errorf("expected an ASCII error bar, got %q", out)
+	}
+	for _, r := range out {
+		if r > 127 {
+			t.Fatalf("ASCII bar contains %q: %q", r, out)
+		}
+	}
+
+	// Reporters without glyphs are left alone.
+	SetGlyphs(NewNoOpReporter(), ASCIIGlyphs)
+}
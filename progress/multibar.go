@@ -0,0 +1,266 @@
+package progress
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/Tmunayyer/gocamelpack/output"
+)
+
+// ANSI sequences used to redraw the multi-line display in place.
+const (
+	ansiClearLine = "\x1b[2K"
+	ansiClearDown = "\x1b[J"
+	ansiCursorUp  = "\x1b[%dA"
+)
+
+// MultiBar renders one status line per concurrent worker plus an aggregate
+// progress bar underneath, redrawing the whole block in place:
+//
+//	worker 1: IMG_0001.JPG  42%
+//	worker 2: MVI_0002.MOV  7%
+//	[████████░░░░] 10/40 (25%)
+//
+// MultiBar itself is the aggregate ProgressReporter; per-file progress goes
+// through the WorkerBar returned by Worker. All methods are safe for
+// concurrent use.
+type MultiBar struct {
+	mu       sync.Mutex
+	state    *ProgressState
+	writer   io.Writer
+	width    int
+	glyphs   Glyphs
+	maxWidth int // terminal width lines are shortened to; 0 for no limit
+	workers  []*WorkerBar
+	drawn    int // lines written by the last redraw
+	finished bool
+	errored  bool
+}
+
+// WorkerBar is the status line of one worker.
+type WorkerBar struct {
+	m      *MultiBar
+	id     int
+	name   string
+	done   int64
+	size   int64
+	active bool
+}
+
+// NewMultiBar creates a display for the given number of workers with an
+// aggregate bar of the given width (40 when width <= 0).
+func NewMultiBar(writer io.Writer, workers, width int) *MultiBar {
+	if width <= 0 {
+		width = 40
+	}
+	if workers < 1 {
+		workers = 1
+	}
+	m := &MultiBar{
+		state:    NewProgressState(writer),
+		writer:   writer,
+		width:    width,
+		glyphs:   UnicodeGlyphs,
+		maxWidth: output.TerminalWidth(writer),
+	}
+	for i := 0; i < workers; i++ {
+		m.workers = append(m.workers, &WorkerBar{m: m, id: i + 1})
+	}
+	return m
+}
+
+// Worker returns the status line for worker i (0-based).
+func (m *MultiBar) Worker(i int) *WorkerBar {
+	return m.workers[i]
+}
+
+// Start shows that the worker began processing name, which has size bytes
+// (0 if unknown).
+func (w *WorkerBar) Start(name string, size int64) {
+	w.m.update(func() {
+		w.name, w.size, w.done, w.active = name, size, 0, true
+	})
+}
+
+// SetBytes records how many bytes of the current file are done.
+func (w *WorkerBar) SetBytes(done int64) {
+	w.m.update(func() { w.done = done })
+}
+
+// Done marks the worker idle again.
+func (w *WorkerBar) Done() {
+	w.m.update(func() { w.name, w.active = "", false })
+}
+
+func (w *WorkerBar) render() string {
+	if !w.active {
+		return fmt.Sprintf("worker %d: idle", w.id)
+	}
+	if w.size <= 0 {
+		return fmt.Sprintf("worker %d: %s", w.id, w.name)
+	}
+	pct := int(float64(w.done) / float64(w.size) * 100)
+	if pct > 100 {
+		pct = 100
+	}
+	return fmt.Sprintf("worker %d: %s  %d%%", w.id, w.name, pct)
+}
+
+// update applies fn under the lock and redraws.
+func (m *MultiBar) update(fn func()) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.finished {
+		return
+	}
+	fn()
+	m.redraw()
+}
+
+// redraw moves the cursor back over the previous block and rewrites every
+// line. Callers must hold m.mu.
+func (m *MultiBar) redraw() {
+	var b strings.Builder
+	if m.drawn > 0 {
+		fmt.Fprintf(&b, ansiCursorUp, m.drawn)
+	}
+	for _, w := range m.workers {
+		b.WriteString("\r" + ansiClearLine + m.fit(w.render()) + "\n")
+	}
+	b.WriteString("\r" + ansiClearLine + m.fit(m.aggregate()) + "\n")
+	m.drawn = len(m.workers) + 1
+	fmt.Fprint(m.writer, b.String())
+}
+
+// fit shortens line to the terminal, so the block's lines never wrap.
+func (m *MultiBar) fit(line string) string {
+	return fit(line, m.maxWidth-1, m.glyphs.Ellipsis)
+}
+
+func (m *MultiBar) aggregate() string {
+	s := m.state
+	filled := 0
+	if s.total > 0 {
+		filled = int(float64(s.current) / float64(s.total) * float64(m.width))
+	}
+	if filled > m.width {
+		filled = m.width
+	}
+	line := "[" + strings.Repeat(string(m.glyphs.Filled), filled) + strings.Repeat(string(m.glyphs.Empty), m.width-filled) + "] " + s.String()
+	if s.message != "" {
+		line += " - " + s.message
+	}
+	return line
+}
+
+// SetGlyphs sets the characters the display draws with.
+func (m *MultiBar) SetGlyphs(g Glyphs) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.glyphs = g
+}
+
+// SetTotal sets the number of files in the run.
+func (m *MultiBar) SetTotal(total int) { m.update(func() { m.state.SetTotal(total) }) }
+
+// Increment records one more finished file.
+func (m *MultiBar) Increment() { m.update(func() { m.state.Increment() }) }
+
+// IncrementBy records amount more finished files.
+func (m *MultiBar) IncrementBy(amount int) { m.update(func() { m.state.IncrementBy(amount) }) }
+
+// SetCurrent sets the number of finished files.
+func (m *MultiBar) SetCurrent(current int) { m.update(func() { m.state.SetCurrent(current) }) }
+
+// SetMessage sets the message shown after the aggregate bar.
+func (m *MultiBar) SetMessage(message string) { m.update(func() { m.state.SetMessage(message) }) }
+
+// SetTotalBytes sets the byte total of the run.
+func (m *MultiBar) SetTotalBytes(total int64) { m.update(func() { m.state.SetTotalBytes(total) }) }
+
+// AddBytes records transferred bytes for the run.
+func (m *MultiBar) AddBytes(n int64) { m.update(func() { m.state.AddBytes(n) }) }
+
+// Finish clears the worker lines and leaves the completed aggregate bar.
+func (m *MultiBar) Finish() {
+	m.finish(func(g Glyphs) string { return " " + g.Done })
+}
+
+// SetError stops the display and shows err under the aggregate bar.
+func (m *MultiBar) SetError(err error) {
+	m.mu.Lock()
+	m.errored = !m.finished
+	m.mu.Unlock()
+	m.finish(func(g Glyphs) string {
+		suffix := " " + g.Failed
+		if err != nil {
+			suffix += " - Error: " + err.Error()
+		}
+		return suffix
+	})
+}
+
+// finish replaces the block with the aggregate line and the suffix for
+// the glyphs in use.
+func (m *MultiBar) finish(suffix func(Glyphs) string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.finished {
+		return
+	}
+	m.finished = true
+
+	// Replace the whole block with the final aggregate line.
+	var b strings.Builder
+	if m.drawn > 0 {
+		fmt.Fprintf(&b, ansiCursorUp, m.drawn)
+	}
+	b.WriteString("\r" + ansiClearDown + m.fit(m.aggregate()+suffix(m.glyphs)) + "\n")
+	fmt.Fprint(m.writer, b.String())
+}
+
+// IsComplete reports whether every file has been processed.
+func (m *MultiBar) IsComplete() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state.IsComplete()
+}
+
+// IsErrored reports whether SetError was called.
+func (m *MultiBar) IsErrored() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.errored
+}
+
+// Current returns the number of finished files.
+func (m *MultiBar) Current() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state.Current()
+}
+
+// Total returns the number of files in the run.
+func (m *MultiBar) Total() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state.Total()
+}
+
+// CurrentBytes returns the bytes transferred so far.
+func (m *MultiBar) CurrentBytes() int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state.CurrentBytes()
+}
+
+// TotalBytes returns the byte total of the run.
+func (m *MultiBar) TotalBytes() int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state.TotalBytes()
+}
+
+var _ ByteProgressReporter = (*MultiBar)(nil)
@@ -0,0 +1,104 @@
+package progress
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestMultiBar_RendersWorkersAndAggregate(t *testing.T) {
+	buf := &bytes.Buffer{}
+	m := NewMultiBar(buf, 2, 10)
+	m.SetTotal(4)
+
+	w := m.Worker(0)
+	w.Start("IMG_0001.JPG", 200)
+	w.SetBytes(50)
+	m.Increment()
+
+	out := buf.String()
+	frames := strings.Split(out, "\x1b[3A")
+	last := frames[len(frames)-1]
+	for _, want := range []string{
+		"worker 1: IMG_0001.JPG  25%",
+		"worker 2: idle",
+		"[██░░░░░░░░] 1/4 (25%)",
+	} {
+		if !strings.Contains(last, want) {
+			t.Errorf("last frame missing %q:\n%q", want, last)
+		}
+	}
+	if len(frames) < 2 {
+		t.Error("expected the block to be redrawn in place with cursor-up sequences")
+	}
+
+	w.Done()
+	if !strings.HasSuffix(buf.String(), "[██░░░░░░░░] 1/4 (25%)\n") ||
+		!strings.Contains(buf.String()[len(out):], "worker 1: idle") {
+		t.Errorf("worker should be idle after Done: %q", buf.String()[len(out):])
+	}
+}
+
+func TestMultiBar_FinishCollapsesBlock(t *testing.T) {
+	buf := &bytes.Buffer{}
+	m := NewMultiBar(buf, 3, 4)
+	m.SetTotal(1)
+	m.Increment()
+	buf.Reset()
+
+	m.Finish()
+	want := "\x1b[4A\r\x1b[J[████] 1/1 (100%) ✓\n"
+	if buf.String() != want {
+		t.Errorf("Finish() wrote %q, want %q", buf.String(), want)
+	}
+
+	buf.Reset()
+	m.Increment()
+	m.Worker(0).Start("late.jpg", 0)
+	if buf.Len() != 0 {
+		t.Errorf("no output expected after Finish, got %q", buf.String())
+	}
+}
+
+func TestMultiBar_SetError(t *testing.T) {
+	buf := &bytes.Buffer{}
+	m := NewMultiBar(buf, 1, 4)
+	m.SetTotal(2)
+	m.SetError(errors.New("disk full"))
+
+	if !m.IsErrored() {
+		t.Error("IsErrored() should be true")
+	}
+	if !strings.HasSuffix(buf.String(), "✗ - Error: disk full\n") {
+		t.Errorf("unexpected error output %q", buf.String())
+	}
+}
+
+func TestMultiBar_ConcurrentWorkers(t *testing.T) {
+	m := NewMultiBar(&bytes.Buffer{}, 4, 20)
+	m.SetTotal(400)
+	m.SetTotalBytes(400 * 10)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(w *WorkerBar) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				w.Start("file", 10)
+				w.SetBytes(10)
+				m.AddBytes(10)
+				m.Increment()
+				w.Done()
+			}
+		}(m.Worker(i))
+	}
+	wg.Wait()
+	m.Finish()
+
+	if m.Current() != 400 || m.CurrentBytes() != 4000 || !m.IsComplete() {
+		t.Errorf("got %d files, %d bytes", m.Current(), m.CurrentBytes())
+	}
+}
@@ -0,0 +1,162 @@
+package progress
+
+import (
+	"fmt"
+	"io"
+)
+
+// Style is how progress is drawn on the terminal.
+type Style string
+
+const (
+	// StyleBar is the bar redrawn in place with block characters.
+	StyleBar Style = "bar"
+	// StylePlain writes a line of plain ASCII text now and then, with no
+	// carriage returns, cursor movement or color, for screen readers,
+	// dumb terminals and logs.
+	StylePlain Style = "plain"
+)
+
+// ParseStyle parses a progress style name; empty means StyleBar.
+func ParseStyle(s string) (Style, error) {
+	switch Style(s) {
+	case "", StyleBar:
+		return StyleBar, nil
+	case StylePlain:
+		return StylePlain, nil
+	}
+	return "", fmt.Errorf("unknown progress style %q (want bar or plain)", s)
+}
+
+// NewStyled creates the progress display of the given style on writer.
+func NewStyled(style Style, writer io.Writer) ProgressReporter {
+	if style == StylePlain {
+		return NewPlainReporter(writer)
+	}
+	return NewSimpleProgressBar(writer)
+}
+
+// Plain reporter pacing: a line every plainStep percentage points, or
+// every plainItems items while the total is unknown.
+const (
+	plainStep  = 10
+	plainItems = 100
+)
+
+// PlainReporter writes progress as complete lines of plain text:
+//
+//	Progress: 0/40 (0%)
+//	Progress: 4/40 (10%)
+//	...
+//	Done: 40/40 (100%)
+//
+// A line is written when the message changes and then every tenth of the
+// run, so a screen reader is not flooded.
+type PlainReporter struct {
+	*ProgressState
+	shown    int    // count at the last line
+	shownPct int    // percentage at the last line
+	shownMsg string // message at the last line
+	started  bool
+	finished bool
+	errored  bool
+}
+
+// NewPlainReporter creates a plain text progress reporter on writer.
+func NewPlainReporter(writer io.Writer) *PlainReporter {
+	return &PlainReporter{ProgressState: NewProgressState(writer)}
+}
+
+// line renders the counts, prefixed with label or the message.
+func (r *PlainReporter) line(label string) string {
+	if label == "" {
+		label = r.message
+	}
+	if label == "" {
+		label = "Progress"
+	}
+	return label + ": " + r.String()
+}
+
+// update writes a line when the message changed or enough progress was
+// made since the last one.
+func (r *PlainReporter) update() {
+	if r.finished {
+		return
+	}
+	pct := r.Percentage()
+	due := r.message != r.shownMsg
+	if r.total > 0 {
+		due = due || !r.started || pct >= r.shownPct+plainStep
+	} else {
+		due = due || r.current/plainItems > r.shown/plainItems
+	}
+	if !due {
+		return
+	}
+	r.started = true
+	r.shown, r.shownPct, r.shownMsg = r.current, pct-pct%plainStep, r.message
+	fmt.Fprintln(r.writer, r.line(""))
+}
+
+// Increment increases progress by 1.
+func (r *PlainReporter) Increment() {
+	r.ProgressState.Increment()
+	r.update()
+}
+
+// IncrementBy increases progress by amount.
+func (r *PlainReporter) IncrementBy(amount int) {
+	r.ProgressState.IncrementBy(amount)
+	r.update()
+}
+
+// SetCurrent sets the current progress.
+func (r *PlainReporter) SetCurrent(current int) {
+	r.ProgressState.SetCurrent(current)
+	r.update()
+}
+
+// SetTotal sets the total. The first line waits for a total or a message,
+// so a run is not announced as "0 items processed" first.
+func (r *PlainReporter) SetTotal(total int) {
+	r.ProgressState.SetTotal(total)
+	if total > 0 {
+		r.update()
+	}
+}
+
+// SetMessage sets the message, which starts a new line.
+func (r *PlainReporter) SetMessage(message string) {
+	r.ProgressState.SetMessage(message)
+	r.update()
+}
+
+// Finish writes the final counts.
+func (r *PlainReporter) Finish() {
+	if r.finished {
+		return
+	}
+	r.finished = true
+	fmt.Fprintln(r.writer, r.line("Done"))
+}
+
+// SetError writes the counts reached and err.
+func (r *PlainReporter) SetError(err error) {
+	if r.finished {
+		return
+	}
+	r.finished, r.errored = true, true
+	s := r.line("Failed")
+	if err != nil {
+		s += " - Error: " + err.Error()
+	}
+	fmt.Fprintln(r.writer, s)
+}
+
+// IsErrored reports whether SetError was called.
+func (r *PlainReporter) IsErrored() bool {
+	return r.errored
+}
+
+var _ ByteProgressReporter = (*PlainReporter)(nil)
@@ -0,0 +1,70 @@
+package progress
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestPlainReporter_Lines(t *testing.T) {
+	buf := &bytes.Buffer{}
+	r := NewPlainReporter(buf)
+	r.SetTotal(20)
+	for i := 0; i < 20; i++ {
+		r.Increment()
+	}
+	r.Finish()
+	r.Increment()
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	want := []string{"Progress: 0/20 (0%)"}
+	for i := 2; i <= 20; i += 2 {
+		want = append(want, fmt.Sprintf("Progress: %d/20 (%d%%)", i, i*5))
+	}
+	want = append(want, "Done: 20/20 (100%)")
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("lines =\n%s\nwant\n%s", strings.Join(lines, "\n"), strings.Join(want, "\n"))
+	}
+	if strings.ContainsAny(buf.String(), "\r\x1b█░✓") {
+		t.Errorf("plain output has control or block characters: %q", buf.String())
+	}
+}
+
+func TestPlainReporter_MessagesAndErrors(t *testing.T) {
+	buf := &bytes.Buffer{}
+	r := NewPlainReporter(buf)
+	r.SetTotal(0)
+	for i := 0; i < 250; i++ {
+		r.Increment()
+	}
+	r.SetMessage("Planning operations")
+	r.SetError(errors.New("disk full"))
+	r.SetError(errors.New("again"))
+
+	want := "Progress: 100 items processed\n" +
+		"Progress: 200 items processed\n" +
+		"Planning operations: 250 items processed\n" +
+		"Failed: 250 items processed - Error: disk full\n"
+	if buf.String() != want {
+		t.Errorf("output =\n%s\nwant\n%s", buf.String(), want)
+	}
+	if !r.IsErrored() {
+		t.Error("IsErrored() = false after SetError")
+	}
+}
+
+func TestParseStyle(t *testing.T) {
+	for in, want := range map[string]Style{"": StyleBar, "bar": StyleBar, "plain": StylePlain} {
+		if got, err := ParseStyle(in); err != nil || got != want {
+			t.Errorf("ParseStyle(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseStyle("fancy"); err == nil {
+		t.Error("ParseStyle(fancy) should fail")
+	}
+	if _, ok := NewStyled(StylePlain, &bytes.Buffer{}).(*PlainReporter); !ok {
+		t.Error("NewStyled(plain) should be a PlainReporter")
+	}
+}
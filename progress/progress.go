@@ -3,6 +3,9 @@ package progress
 import (
 	"fmt"
 	"io"
+	"time"
+
+	"github.com/Tmunayyer/gocamelpack/units"
 )
 
 // ProgressReporter defines the interface for reporting progress during file operations.
@@ -38,6 +41,66 @@ type ProgressReporter interface {
 	Total() int
 }
 
+// ByteProgressReporter is implemented by reporters that can also track the
+// number of bytes transferred alongside the item count. Callers should use
+// the SetTotalBytes and AddBytes helpers, which are no-ops for reporters
+// that only count items.
+type ByteProgressReporter interface {
+	ProgressReporter
+
+	// SetTotalBytes sets the total number of bytes to be transferred
+	SetTotalBytes(total int64)
+
+	// AddBytes records n more bytes as transferred
+	AddBytes(n int64)
+
+	// CurrentBytes returns the number of bytes transferred so far
+	CurrentBytes() int64
+
+	// TotalBytes returns the total number of bytes to be transferred
+	TotalBytes() int64
+}
+
+// SetTotalBytes sets the byte total on r if it tracks bytes.
+func SetTotalBytes(r ProgressReporter, total int64) {
+	if br, ok := r.(ByteProgressReporter); ok {
+		br.SetTotalBytes(total)
+	}
+}
+
+// AddBytes records n transferred bytes on r if it tracks bytes.
+func AddBytes(r ProgressReporter, n int64) {
+	if br, ok := r.(ByteProgressReporter); ok {
+		br.AddBytes(n)
+	}
+}
+
+// ActivityReporter is implemented by reporters that show which files are
+// being transferred right now, such as Dashboard.
+type ActivityReporter interface {
+	// Begin shows that a transfer of name, of size bytes (0 if unknown),
+	// started and returns the slot to pass to End.
+	Begin(name string, size int64) int
+
+	// End marks the transfer in slot as done, failed when err is set.
+	End(slot int, err error)
+}
+
+// Activity returns the ActivityReporter r is, or wraps.
+func Activity(r ProgressReporter) (ActivityReporter, bool) {
+	for r != nil {
+		if a, ok := r.(ActivityReporter); ok {
+			return a, true
+		}
+		u, ok := r.(interface{ Unwrap() ProgressReporter })
+		if !ok {
+			break
+		}
+		r = u.Unwrap()
+	}
+	return nil, false
+}
+
 // NoOpReporter is a progress reporter that does nothing, useful for when progress is disabled.
 type NoOpReporter struct{}
 
@@ -55,6 +118,10 @@ func (n *NoOpReporter) SetError(err error)        {}
 func (n *NoOpReporter) IsComplete() bool          { return false }
 func (n *NoOpReporter) Current() int              { return 0 }
 func (n *NoOpReporter) Total() int                { return 0 }
+func (n *NoOpReporter) SetTotalBytes(total int64) {}
+func (n *NoOpReporter) AddBytes(b int64)          {}
+func (n *NoOpReporter) CurrentBytes() int64       { return 0 }
+func (n *NoOpReporter) TotalBytes() int64         { return 0 }
 
 // ProgressState represents the current state of progress tracking.
 type ProgressState struct {
@@ -63,13 +130,36 @@ type ProgressState struct {
 	actualCurrent int // Track actual current before capping, for percentage calculation
 	message       string
 	writer        io.Writer
+
+	// Byte counters are independent of the item counters; they are only
+	// shown once a byte total has been set.
+	currentBytes int64
+	totalBytes   int64
+
+	// Timing for rate and ETA estimates. now is replaceable in tests.
+	now     func() time.Time
+	started time.Time
+	samples []sample
+}
+
+// sample is a snapshot of the counters used by the rate estimator.
+type sample struct {
+	at    time.Time
+	items int
+	bytes int64
 }
 
+// rateWindow is how far back the moving-average rate looks. Long enough to
+// smooth over a few large files, short enough to follow a slowing disk.
+const rateWindow = 10 * time.Second
+
 // NewProgressState creates a new progress state.
 func NewProgressState(writer io.Writer) *ProgressState {
-	return &ProgressState{
+	p := &ProgressState{
 		writer: writer,
 	}
+	p.record()
+	return p
 }
 
 // SetTotal sets the total number of items to be processed.
@@ -78,6 +168,9 @@ func (p *ProgressState) SetTotal(total int) {
 		total = 0
 	}
 	p.total = total
+	if p.started.IsZero() {
+		p.record()
+	}
 }
 
 // SetCurrent sets the current progress.
@@ -90,6 +183,7 @@ func (p *ProgressState) SetCurrent(current int) {
 		current = p.total
 	}
 	p.current = current
+	p.record()
 }
 
 // Increment increases the progress by 1.
@@ -131,6 +225,124 @@ func (p *ProgressState) IsComplete() bool {
 	return p.total > 0 && p.current >= p.total
 }
 
+// SetTotalBytes sets the total number of bytes to be transferred.
+func (p *ProgressState) SetTotalBytes(total int64) {
+	if total < 0 {
+		total = 0
+	}
+	p.totalBytes = total
+}
+
+// AddBytes records n more bytes as transferred.
+func (p *ProgressState) AddBytes(n int64) {
+	if n < 0 {
+		return
+	}
+	p.currentBytes += n
+	p.record()
+}
+
+// CurrentBytes returns the number of bytes transferred so far.
+func (p *ProgressState) CurrentBytes() int64 {
+	return p.currentBytes
+}
+
+// TotalBytes returns the total number of bytes to be transferred.
+func (p *ProgressState) TotalBytes() int64 {
+	return p.totalBytes
+}
+
+// clock returns the current time from the injected clock, if any.
+func (p *ProgressState) clock() time.Time {
+	if p.now != nil {
+		return p.now()
+	}
+	return time.Now()
+}
+
+// record adds a sample for the rate estimator and drops samples that have
+// fallen out of the window, keeping one older sample as the baseline.
+func (p *ProgressState) record() {
+	now := p.clock()
+	if p.started.IsZero() {
+		p.started = now
+	}
+	p.samples = append(p.samples, sample{at: now, items: p.actualCurrent, bytes: p.currentBytes})
+
+	cut := 0
+	for cut < len(p.samples)-2 && now.Sub(p.samples[cut+1].at) >= rateWindow {
+		cut++
+	}
+	if cut > 0 {
+		p.samples = append(p.samples[:0], p.samples[cut:]...)
+	}
+}
+
+// Elapsed returns the time since progress tracking started.
+func (p *ProgressState) Elapsed() time.Duration {
+	if p.started.IsZero() {
+		return 0
+	}
+	return p.clock().Sub(p.started)
+}
+
+// Rate returns the recent throughput in items per second, averaged over
+// the last rateWindow. It is 0 until progress has been made.
+func (p *ProgressState) Rate() float64 {
+	return p.rate(func(s sample) float64 { return float64(s.items) })
+}
+
+// ByteRate returns the recent throughput in bytes per second.
+func (p *ProgressState) ByteRate() float64 {
+	return p.rate(func(s sample) float64 { return float64(s.bytes) })
+}
+
+func (p *ProgressState) rate(value func(sample) float64) float64 {
+	if len(p.samples) < 2 {
+		return 0
+	}
+	first, last := p.samples[0], p.samples[len(p.samples)-1]
+	dt := last.at.Sub(first.at).Seconds()
+	if dt <= 0 {
+		return 0
+	}
+	r := (value(last) - value(first)) / dt
+	if r < 0 {
+		return 0
+	}
+	return r
+}
+
+// Remaining returns the number of items still to be processed.
+func (p *ProgressState) Remaining() int {
+	if r := p.total - p.actualCurrent; r > 0 {
+		return r
+	}
+	return 0
+}
+
+// ETA estimates the time left from the recent rate. Byte counts are used
+// when a byte total is set, since file sizes vary widely. ok is false while
+// there is not enough information for an estimate.
+func (p *ProgressState) ETA() (eta time.Duration, ok bool) {
+	var left, rate float64
+	if p.totalBytes > 0 {
+		left, rate = float64(p.totalBytes-p.currentBytes), p.ByteRate()
+	} else {
+		if p.total == 0 {
+			return 0, false
+		}
+		left, rate = float64(p.Remaining()), p.Rate()
+	}
+	if left <= 0 {
+		return 0, true
+	}
+	if rate <= 0 {
+		return 0, false
+	}
+	return time.Duration(left / rate * float64(time.Second)).Round(time.Second), true
+}
+
 // Percentage returns the completion percentage (0-100).
 func (p *ProgressState) Percentage() int {
 	if p.total == 0 {
@@ -141,8 +353,14 @@ func (p *ProgressState) Percentage() int {
 
 // String returns a string representation of the progress.
 func (p *ProgressState) String() string {
+	var s string
 	if p.total == 0 {
-		return fmt.Sprintf("%d items processed", p.current)
+		s = fmt.Sprintf("%d items processed", p.current)
+	} else {
+		s = fmt.Sprintf("%d/%d (%d%%)", p.current, p.total, p.Percentage())
+	}
+	if p.totalBytes > 0 {
+		s += fmt.Sprintf(" %s/%s", units.ByteSize(p.currentBytes), units.ByteSize(p.totalBytes))
 	}
-	return fmt.Sprintf("%d/%d (%d%%)", p.current, p.total, p.Percentage())
+	return s
 }
\ No newline at end of file
@@ -3,6 +3,7 @@ package progress
 import (
 	"bytes"
 	"testing"
+	"time"
 )
 
 func TestNoOpReporter(t *testing.T) {
@@ -258,4 +259,112 @@ func TestProgressState_NewProgressState(t *testing.T) {
 	if p.Message() != "" {
 		t.Errorf("NewProgressState() message: got %q, want empty", p.Message())
 	}
-}
\ No newline at end of file
+}
+func TestProgressState_Bytes(t *testing.T) {
+	p := NewProgressState(nil)
+	p.SetTotal(4)
+	p.SetCurrent(1)
+
+	if got := p.String(); got != "1/4 (25%)" {
+		t.Errorf("String() without byte total = %q", got)
+	}
+
+	p.SetTotalBytes(4 << 20)
+	p.AddBytes(1 << 20)
+	p.AddBytes(-5) // ignored
+	if p.CurrentBytes() != 1<<20 || p.TotalBytes() != 4<<20 {
+		t.Errorf("bytes = %d/%d", p.CurrentBytes(), p.TotalBytes())
+	}
+	if got, want := p.String(), "1/4 (25%) 1.0 MiB/4.0 MiB"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+
+	p.SetTotalBytes(-1)
+	if p.TotalBytes() != 0 {
+		t.Errorf("negative byte total should clamp to 0, got %d", p.TotalBytes())
+	}
+}
+
+func TestByteHelpers(t *testing.T) {
+	bar := NewSimpleProgressBar(&bytes.Buffer{})
+	var r ProgressReporter = bar
+	SetTotalBytes(r, 100)
+	AddBytes(r, 40)
+	if bar.CurrentBytes() != 40 || bar.TotalBytes() != 100 {
+		t.Errorf("bar bytes = %d/%d", bar.CurrentBytes(), bar.TotalBytes())
+	}
+
+	// Reporters that only count items must still be accepted.
+	var itemsOnly ProgressReporter = struct{ ProgressReporter }{NewNoOpReporter()}
+	SetTotalBytes(itemsOnly, 100)
+	AddBytes(itemsOnly, 40)
+}
+
+// fakeClock is a manually advanced clock for timing tests.
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func TestProgressState_RateAndETA(t *testing.T) {
+	clk := &fakeClock{t: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	p := &ProgressState{now: clk.now}
+	p.SetTotal(100)
+
+	if _, ok := p.ETA(); ok {
+		t.Error("ETA should be unknown before any progress")
+	}
+
+	// 2 items/s for 5 seconds.
+	for i := 0; i < 5; i++ {
+		clk.advance(time.Second)
+		p.IncrementBy(2)
+	}
+	if got := p.Elapsed(); got != 5*time.Second {
+		t.Errorf("Elapsed() = %v, want 5s", got)
+	}
+	if got := p.Rate(); got != 2 {
+		t.Errorf("Rate() = %v, want 2", got)
+	}
+	if got := p.Remaining(); got != 90 {
+		t.Errorf("Remaining() = %d, want 90", got)
+	}
+	if eta, ok := p.ETA(); !ok || eta != 45*time.Second {
+		t.Errorf("ETA() = %v, %v; want 45s", eta, ok)
+	}
+
+	// The rate follows a slowdown once old samples leave the window.
+	for i := 0; i < 20; i++ {
+		clk.advance(time.Second)
+		if i%2 == 1 {
+			p.Increment()
+		}
+	}
+	if got := p.Rate(); got < 0.45 || got > 0.6 {
+		t.Errorf("Rate() after slowdown = %v, want about 0.5", got)
+	}
+}
+
+func TestProgressState_ETAPrefersBytes(t *testing.T) {
+	clk := &fakeClock{t: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	p := &ProgressState{now: clk.now}
+	p.SetTotal(2)
+	p.SetTotalBytes(1000)
+
+	// One small file done quickly, a big one still to go.
+	clk.advance(time.Second)
+	p.AddBytes(100)
+	p.Increment()
+
+	if got := p.ByteRate(); got != 100 {
+		t.Errorf("ByteRate() = %v, want 100", got)
+	}
+	if eta, ok := p.ETA(); !ok || eta != 9*time.Second {
+		t.Errorf("ETA() = %v, %v; want 9s from bytes", eta, ok)
+	}
+
+	p.AddBytes(900)
+	if eta, ok := p.ETA(); !ok || eta != 0 {
+		t.Errorf("ETA() when done = %v, %v", eta, ok)
+	}
+}
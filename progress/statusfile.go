@@ -0,0 +1,260 @@
+package progress
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// DefaultStatusInterval is how often a StatusFileReporter rewrites its file.
+const DefaultStatusInterval = time.Second
+
+// Status is the JSON snapshot written by StatusFileReporter.
+type Status struct {
+	State          string    `json:"state"` // running, done or error
+	Message        string    `json:"message,omitempty"`
+	Current        int       `json:"current"`
+	Total          int       `json:"total"`
+	Percent        int       `json:"percent"`
+	BytesDone      int64     `json:"bytes_done,omitempty"`
+	BytesTotal     int64     `json:"bytes_total,omitempty"`
+	ElapsedSeconds float64   `json:"elapsed_seconds"`
+	ETASeconds     *float64  `json:"eta_seconds,omitempty"`
+	Error          string    `json:"error,omitempty"`
+	PID            int       `json:"pid"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// Status states.
+const (
+	StatusRunning = "running"
+	StatusDone    = "done"
+	StatusError   = "error"
+)
+
+// ReadStatus reads the snapshot a StatusFileReporter keeps at path.
+func ReadStatus(path string) (Status, error) {
+	var st Status
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return st, err
+	}
+	err = json.Unmarshal(data, &st)
+	return st, err
+}
+
+// StatusFileReporter forwards progress to another reporter and keeps a small
+// JSON status file up to date, so external monitors can poll progress
+// without parsing terminal output. The file is rewritten at most once per
+// interval while running (and at least once per interval, even without
+// progress) and a final time on Finish or SetError. Writes go through a
+// temporary file and rename, so readers never see a partial snapshot.
+type StatusFileReporter struct {
+	inner ProgressReporter
+	path  string
+
+	mu       sync.Mutex
+	state    *ProgressState
+	status   string
+	err      error
+	interval time.Duration
+	written  time.Time
+	stop     chan struct{}
+	stopped  bool
+}
+
+// NewStatusFileReporter wraps inner (use NewNoOpReporter for a quiet run)
+// and starts writing snapshots to path every interval (DefaultStatusInterval
+// when interval <= 0).
+func NewStatusFileReporter(inner ProgressReporter, path string, interval time.Duration) *StatusFileReporter {
+	if interval <= 0 {
+		interval = DefaultStatusInterval
+	}
+	r := &StatusFileReporter{
+		inner:    inner,
+		path:     path,
+		state:    NewProgressState(nil),
+		status:   StatusRunning,
+		interval: interval,
+		stop:     make(chan struct{}),
+	}
+	r.write()
+	go r.tick()
+	return r
+}
+
+func (r *StatusFileReporter) tick() {
+	t := time.NewTicker(r.interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-t.C:
+			r.mu.Lock()
+			if time.Since(r.written) >= r.interval {
+				r.write()
+			}
+			r.mu.Unlock()
+		}
+	}
+}
+
+// Snapshot returns the current status.
+func (r *StatusFileReporter) Snapshot() Status {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.snapshot()
+}
+
+func (r *StatusFileReporter) snapshot() Status {
+	s := r.state
+	st := Status{
+		State:          r.status,
+		Message:        s.Message(),
+		Current:        s.Current(),
+		Total:          s.Total(),
+		Percent:        s.Percentage(),
+		BytesDone:      s.CurrentBytes(),
+		BytesTotal:     s.TotalBytes(),
+		ElapsedSeconds: s.Elapsed().Seconds(),
+		PID:            os.Getpid(),
+		UpdatedAt:      time.Now().UTC(),
+	}
+	if r.status == StatusRunning {
+		if eta, ok := s.ETA(); ok {
+			secs := eta.Seconds()
+			st.ETASeconds = &secs
+		}
+	}
+	if r.err != nil {
+		st.Error = r.err.Error()
+	}
+	return st
+}
+
+// write stores the snapshot. Callers must hold r.mu. Failures are ignored:
+// the status file is best-effort and must never fail the import.
+func (r *StatusFileReporter) write() {
+	r.written = time.Now()
+	data, err := json.MarshalIndent(r.snapshot(), "", "  ")
+	if err != nil {
+		return
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(r.path), ".gocamelpack-status-*")
+	if err != nil {
+		return
+	}
+	_, werr := tmp.Write(append(data, '\n'))
+	cerr := tmp.Close()
+	if werr != nil || cerr != nil || os.Rename(tmp.Name(), r.path) != nil {
+		os.Remove(tmp.Name())
+	}
+}
+
+// update applies fn to the state and rewrites the file if it is due.
+func (r *StatusFileReporter) update(fn func(*ProgressState)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	fn(r.state)
+	if r.status == StatusRunning && time.Since(r.written) >= r.interval {
+		r.write()
+	}
+}
+
+// end records the final state, writes it and stops the ticker.
+func (r *StatusFileReporter) end(status string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stopped {
+		return
+	}
+	r.stopped = true
+	r.status, r.err = status, err
+	close(r.stop)
+	r.write()
+}
+
+func (r *StatusFileReporter) SetTotal(total int) {
+	r.inner.SetTotal(total)
+	r.update(func(s *ProgressState) { s.SetTotal(total) })
+}
+
+func (r *StatusFileReporter) Increment() {
+	r.inner.Increment()
+	r.update(func(s *ProgressState) { s.Increment() })
+}
+
+func (r *StatusFileReporter) IncrementBy(amount int) {
+	r.inner.IncrementBy(amount)
+	r.update(func(s *ProgressState) { s.IncrementBy(amount) })
+}
+
+func (r *StatusFileReporter) SetCurrent(current int) {
+	r.inner.SetCurrent(current)
+	r.update(func(s *ProgressState) { s.SetCurrent(current) })
+}
+
+func (r *StatusFileReporter) SetMessage(message string) {
+	r.inner.SetMessage(message)
+	r.update(func(s *ProgressState) { s.SetMessage(message) })
+}
+
+func (r *StatusFileReporter) SetTotalBytes(total int64) {
+	SetTotalBytes(r.inner, total)
+	r.update(func(s *ProgressState) { s.SetTotalBytes(total) })
+}
+
+func (r *StatusFileReporter) AddBytes(n int64) {
+	AddBytes(r.inner, n)
+	r.update(func(s *ProgressState) { s.AddBytes(n) })
+}
+
+func (r *StatusFileReporter) Finish() {
+	r.inner.Finish()
+	r.end(StatusDone, nil)
+}
+
+func (r *StatusFileReporter) SetError(err error) {
+	r.inner.SetError(err)
+	r.end(StatusError, err)
+}
+
+func (r *StatusFileReporter) IsComplete() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.state.IsComplete()
+}
+
+func (r *StatusFileReporter) Current() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.state.Current()
+}
+
+func (r *StatusFileReporter) Total() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.state.Total()
+}
+
+func (r *StatusFileReporter) CurrentBytes() int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.state.CurrentBytes()
+}
+
+func (r *StatusFileReporter) TotalBytes() int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.state.TotalBytes()
+}
+
+// Unwrap returns the reporter the snapshots are taken alongside.
+func (r *StatusFileReporter) Unwrap() ProgressReporter {
+	return r.inner
+}
+
+var _ ByteProgressReporter = (*StatusFileReporter)(nil)
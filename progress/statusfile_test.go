@@ -0,0 +1,94 @@
+package progress
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+func readStatus(t *testing.T, path string) Status {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read status: %v", err)
+	}
+	var st Status
+	if err := json.Unmarshal(data, &st); err != nil {
+		t.Fatalf("decode status %q: %v", data, err)
+	}
+	return st
+}
+
+func TestStatusFileReporter_WritesSnapshots(t *testing.T) {
+	path := filepath.Join(testutil.TempDir(t), "gocamelpack.status")
+	r := NewStatusFileReporter(NewNoOpReporter(), path, time.Hour)
+
+	// The initial snapshot is written immediately.
+	if st := readStatus(t, path); st.State != StatusRunning || st.PID != os.Getpid() {
+		t.Fatalf("unexpected initial status %+v", st)
+	}
+
+	r.SetTotal(4)
+	r.SetMessage("copy a.jpg")
+	r.Increment()
+	// Updates within the interval are not written...
+	if st := readStatus(t, path); st.Current != 0 {
+		t.Errorf("status rewritten before interval: %+v", st)
+	}
+	// ...but the in-memory snapshot is current.
+	if st := r.Snapshot(); st.Current != 1 || st.Total != 4 || st.Percent != 25 || st.Message != "copy a.jpg" {
+		t.Errorf("unexpected snapshot %+v", st)
+	}
+
+	r.Finish()
+	st := readStatus(t, path)
+	if st.State != StatusDone || st.Current != 1 || st.ETASeconds != nil {
+		t.Errorf("unexpected final status %+v", st)
+	}
+	matches, _ := filepath.Glob(filepath.Join(filepath.Dir(path), ".gocamelpack-status-*"))
+	if len(matches) != 0 {
+		t.Errorf("temporary files left behind: %v", matches)
+	}
+}
+
+func TestStatusFileReporter_Periodic(t *testing.T) {
+	path := filepath.Join(testutil.TempDir(t), "status.json")
+	r := NewStatusFileReporter(NewNoOpReporter(), path, 10*time.Millisecond)
+	defer r.Finish()
+
+	r.SetTotal(2)
+	r.Increment()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if readStatus(t, path).Current == 1 {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("status file was not refreshed by the ticker")
+}
+
+func TestStatusFileReporter_Error(t *testing.T) {
+	path := filepath.Join(testutil.TempDir(t), "status.json")
+	bar := NewSimpleProgressBar(io.Discard)
+	r := NewStatusFileReporter(bar, path, time.Hour)
+
+	r.SetTotal(3)
+	r.SetError(errors.New("disk full"))
+	r.Finish() // no effect after an error
+
+	st := readStatus(t, path)
+	if st.State != StatusError || st.Error != "disk full" {
+		t.Errorf("unexpected status %+v", st)
+	}
+	if !bar.IsErrored() {
+		t.Error("inner reporter should receive the error")
+	}
+}
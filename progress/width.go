@@ -0,0 +1,24 @@
+package progress
+
+import "unicode/utf8"
+
+// minMessage is the narrowest a shortened message gets; with less room it
+// is left out.
+const minMessage = 8
+
+// fit shortens s to at most width runes by replacing its middle with
+// ellipsis, which keeps both the start of a long path and its file name.
+// width <= 0 means no limit.
+func fit(s string, width int, ellipsis string) string {
+	n := utf8.RuneCountInString(s)
+	if width <= 0 || n <= width {
+		return s
+	}
+	keep := width - utf8.RuneCountInString(ellipsis)
+	if keep <= 0 {
+		return string([]rune(s)[:width])
+	}
+	r := []rune(s)
+	head := keep / 2
+	return string(r[:head]) + ellipsis + string(r[n-(keep-head):])
+}
@@ -0,0 +1,55 @@
+package progress
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestFit(t *testing.T) {
+	tests := []struct {
+		s     string
+		width int
+		want  string
+	}{
+		{"short", 10, "short"},
+		{"no limit at all", 0, "no limit at all"},
+		{"copy /cards/DCIM/100CANON/IMG_0001.JPG", 24, "copy /cards…IMG_0001.JPG"},
+		{"abcdef", 1, "a"},
+	}
+	for _, tt := range tests {
+		if got := fit(tt.s, tt.width, "…"); got != tt.want {
+			t.Errorf("fit(%q, %d) = %q, want %q", tt.s, tt.width, got, tt.want)
+		}
+	}
+}
+
+func TestProgressBar_MessageFitsWidth(t *testing.T) {
+	buf := &bytes.Buffer{}
+	pb := NewProgressBar(buf, 10)
+	pb.SetMaxWidth(60)
+	pb.SetTotal(4)
+	pb.SetMessage("copy /Volumes/EOS_DIGITAL/DCIM/100CANON/2025-01-27/IMG_0001.JPG")
+	pb.Increment()
+	pb.Finish()
+
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\r") {
+		if n := utf8.RuneCountInString(line); n > 59 {
+			t.Errorf("line of %d columns exceeds the terminal: %q", n, line)
+		}
+	}
+	if !strings.Contains(buf.String(), "IMG_0001.JPG ✓\n") || !strings.Contains(buf.String(), "- copy /Volumes/E…") {
+		t.Errorf("expected the message shortened in the middle, got %q", buf.String())
+	}
+
+	// Too little room drops the message rather than the bar.
+	buf.Reset()
+	pb = NewProgressBar(buf, 10)
+	pb.SetMaxWidth(30)
+	pb.SetTotal(4)
+	pb.SetMessage("copy /a/long/path.jpg")
+	if got := buf.String(); strings.Contains(got, " - ") {
+		t.Errorf("got %q", got)
+	}
+}
@@ -0,0 +1,89 @@
+package queue
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Daemon is the heartbeat a running daemon keeps next to the queue, so
+// other processes can tell whether one is working through it.
+type Daemon struct {
+	PID       int       `json:"pid"`
+	Host      string    `json:"host"`
+	Started   time.Time `json:"started"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// Poll is how often the daemon refreshes the heartbeat, at the latest.
+	Poll time.Duration `json:"poll"`
+	// Running lists the IDs of the jobs the daemon is running.
+	Running []string `json:"running,omitempty"`
+}
+
+// Alive reports whether the heartbeat is recent enough at now for the
+// daemon to still be running. A daemon that exits removes it; one that
+// crashed leaves a heartbeat that goes stale.
+func (d Daemon) Alive(now time.Time) bool {
+	grace := max(3*d.Poll, 10*time.Second)
+	return now.Sub(d.UpdatedAt) <= grace
+}
+
+// daemonPath is where the heartbeat of q's daemon is kept.
+func (q *Queue) daemonPath() string {
+	return filepath.Join(filepath.Dir(q.path), "daemon.json")
+}
+
+// Heartbeat records d as the queue's daemon, stamped with the current
+// time.
+func (q *Queue) Heartbeat(d Daemon) error {
+	d.UpdatedAt = q.now()
+	data, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(q.path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(q.path), ".gocamelpack-daemon-*")
+	if err != nil {
+		return err
+	}
+	_, werr := tmp.Write(append(data, '\n'))
+	cerr := tmp.Close()
+	if werr == nil {
+		werr = cerr
+	}
+	if werr == nil {
+		werr = os.Rename(tmp.Name(), q.daemonPath())
+	}
+	if werr != nil {
+		os.Remove(tmp.Name())
+	}
+	return werr
+}
+
+// Daemon returns the last heartbeat of the queue's daemon. ok is false when
+// no daemon has run or the last one exited.
+func (q *Queue) Daemon() (d Daemon, ok bool, err error) {
+	data, err := os.ReadFile(q.daemonPath())
+	if errors.Is(err, fs.ErrNotExist) {
+		return d, false, nil
+	}
+	if err != nil {
+		return d, false, err
+	}
+	if err := json.Unmarshal(data, &d); err != nil {
+		return d, false, err
+	}
+	return d, true, nil
+}
+
+// Stopped removes the heartbeat when the daemon exits.
+func (q *Queue) Stopped() error {
+	if err := os.Remove(q.daemonPath()); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
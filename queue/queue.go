@@ -0,0 +1,300 @@
+// Package queue keeps a persistent list of import jobs, and the schedules
+// that add jobs periodically, for the daemon. Both live in a single JSON file
+// so they survive restarts and other processes (e.g. "daemon submit") can
+// add work while the daemon runs.
+package queue
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/Tmunayyer/gocamelpack/session"
+)
+
+// State is where a job is in its lifecycle.
+type State string
+
+const (
+	Pending State = "pending"
+	Running State = "running"
+	Done    State = "done"
+	Failed  State = "failed"
+)
+
+// Job is one queued copy or move.
+type Job struct {
+	ID          string    `json:"id"`
+	Command     string    `json:"command"` // copy or move
+	Source      string    `json:"source"`
+	Destination string    `json:"destination"`
+	Flags       []string  `json:"flags,omitempty"`
+	Schedule    string    `json:"schedule,omitempty"` // ID of the schedule that queued it
+	State       State     `json:"state"`
+	Submitted   time.Time `json:"submitted"`
+	Started     time.Time `json:"started,omitzero"`
+	Finished    time.Time `json:"finished,omitzero"`
+	Error       string    `json:"error,omitempty"`
+	// Resumed is how far the job had got when a daemon restart
+	// interrupted it and it was queued again.
+	Resumed *Progress `json:"resumed,omitempty"`
+}
+
+// Progress is a snapshot of how far a job got.
+type Progress struct {
+	Current    int       `json:"current"`
+	Total      int       `json:"total"`
+	BytesDone  int64     `json:"bytes_done,omitempty"`
+	BytesTotal int64     `json:"bytes_total,omitempty"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// Args returns the command line that runs the job.
+func (j Job) Args() []string {
+	args := append([]string{j.Command}, j.Flags...)
+	return append(args, j.Source, j.Destination)
+}
+
+// Validate checks that the job can be run.
+func (j Job) Validate() error {
+	if j.Command != "copy" && j.Command != "move" {
+		return fmt.Errorf("unsupported job command %q (want copy or move)", j.Command)
+	}
+	if j.Source == "" || j.Destination == "" {
+		return fmt.Errorf("job needs a source and a destination")
+	}
+	return nil
+}
+
+// DefaultPath returns the queue file used when none is given.
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gocamelpack", "queue.json"), nil
+}
+
+// lockTimeout bounds how long an update waits for another process.
+const lockTimeout = 5 * time.Second
+
+// Queue is a handle on a queue file. Every method reads the file afresh
+// and writes it back atomically under a lock file, so several processes
+// can share one queue.
+type Queue struct {
+	path string
+	mu   sync.Mutex // serialises updates within this process
+
+	now func() time.Time // replaceable in tests
+}
+
+// Open returns a handle on the queue at path. The file is created on the
+// first update.
+func Open(path string) *Queue {
+	return &Queue{path: path, now: time.Now}
+}
+
+// Path returns the queue file's path.
+func (q *Queue) Path() string {
+	return q.path
+}
+
+// Jobs returns all jobs in submission order.
+func (q *Queue) Jobs() ([]Job, error) {
+	var jobs []Job
+	err := q.update(func(st *state) (bool, error) {
+		jobs = st.Jobs
+		return false, nil
+	})
+	return jobs, err
+}
+
+// Submit validates j, adds it as pending and returns it with its ID set.
+func (q *Queue) Submit(j Job) (Job, error) {
+	if err := j.Validate(); err != nil {
+		return j, err
+	}
+	err := q.update(func(st *state) (bool, error) {
+		j = st.add(j, q.now())
+		return true, nil
+	})
+	return j, err
+}
+
+// Claim marks the oldest pending job that busy does not reject as running
+// and returns it. ok is false when there is no such job.
+func (q *Queue) Claim(busy func(Job) bool) (job Job, ok bool, err error) {
+	err = q.update(func(st *state) (bool, error) {
+		js := st.Jobs
+		for i := range js {
+			if js[i].State != Pending || (busy != nil && busy(js[i])) {
+				continue
+			}
+			js[i].State = Running
+			js[i].Started = q.now()
+			job, ok = js[i], true
+			return true, nil
+		}
+		return false, nil
+	})
+	return job, ok, err
+}
+
+// Finish records the outcome of a running job.
+func (q *Queue) Finish(id string, jobErr error) error {
+	return q.update(func(st *state) (bool, error) {
+		js := st.Jobs
+		for i := range js {
+			if js[i].ID != id {
+				continue
+			}
+			js[i].State = Done
+			js[i].Finished = q.now()
+			if jobErr != nil {
+				js[i].State = Failed
+				js[i].Error = jobErr.Error()
+			}
+			return true, nil
+		}
+		return false, fmt.Errorf("no job %q in queue", id)
+	})
+}
+
+// Recover puts jobs left running by a previous daemon back to pending and
+// returns how many there were. Call it before claiming jobs on startup.
+func (q *Queue) Recover() (int, error) {
+	return q.RecoverWith(nil)
+}
+
+// RecoverWith is Recover that records, as each requeued job's Resumed,
+// the progress last returns for it. last may be nil or return nil.
+func (q *Queue) RecoverWith(last func(Job) *Progress) (int, error) {
+	n := 0
+	err := q.update(func(st *state) (bool, error) {
+		js := st.Jobs
+		for i := range js {
+			if js[i].State == Running {
+				if last != nil {
+					if p := last(js[i]); p != nil {
+						js[i].Resumed = p
+					}
+				}
+				js[i].State = Pending
+				js[i].Started = time.Time{}
+				n++
+			}
+		}
+		return n > 0, nil
+	})
+	return n, err
+}
+
+// state is the content of the queue file.
+type state struct {
+	Jobs      []Job      `json:"jobs"`
+	Schedules []Schedule `json:"schedules,omitempty"`
+}
+
+// add appends j as a new pending job and returns it.
+func (st *state) add(j Job, now time.Time) Job {
+	j.ID = session.NewID(now)
+	j.State = Pending
+	j.Submitted = now
+	st.Jobs = append(st.Jobs, j)
+	return j
+}
+
+// update loads the queue, passes it to fn and saves it if fn reports a
+// change.
+func (q *Queue) update(fn func(*state) (changed bool, err error)) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	unlock, err := q.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	st, err := q.load()
+	if err != nil {
+		return err
+	}
+	changed, err := fn(st)
+	if err != nil || !changed {
+		return err
+	}
+	return q.save(st)
+}
+
+// lock takes the queue's lock file, waiting for other processes briefly.
+func (q *Queue) lock() (unlock func(), err error) {
+	if err := os.MkdirAll(filepath.Dir(q.path), 0o755); err != nil {
+		return nil, err
+	}
+	lockPath := q.path + ".lock"
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		f, err := os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(lockPath) }, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, fmt.Errorf("locking queue: %w", err)
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("queue %s is locked; remove %s if no gocamelpack process is running", q.path, lockPath)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func (q *Queue) load() (*state, error) {
+	st := &state{Jobs: []Job{}}
+	data, err := os.ReadFile(q.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return st, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading queue: %w", err)
+	}
+	if err := json.Unmarshal(data, st); err != nil {
+		return nil, fmt.Errorf("parsing queue %s: %w", q.path, err)
+	}
+	if st.Jobs == nil {
+		st.Jobs = []Job{}
+	}
+	return st, nil
+}
+
+// save writes st through a temporary file and rename, so a crash never
+// leaves a truncated queue.
+func (q *Queue) save(st *state) error {
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(q.path), ".gocamelpack-queue-*")
+	if err != nil {
+		return fmt.Errorf("writing queue: %w", err)
+	}
+	_, werr := tmp.Write(append(data, '\n'))
+	cerr := tmp.Close()
+	if werr == nil {
+		werr = cerr
+	}
+	if werr == nil {
+		werr = os.Rename(tmp.Name(), q.path)
+	}
+	if werr != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("writing queue: %w", werr)
+	}
+	return nil
+}
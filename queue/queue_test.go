@@ -0,0 +1,143 @@
+package queue
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+func newQueue(t *testing.T) *Queue {
+	t.Helper()
+	return Open(filepath.Join(testutil.TempDir(t), "state", "queue.json"))
+}
+
+func TestSubmitClaimFinish(t *testing.T) {
+	q := newQueue(t)
+
+	a, err := q.Submit(Job{Command: "copy", Source: "/a", Destination: "/dst"})
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	b, _ := q.Submit(Job{Command: "move", Source: "/b", Destination: "/dst"})
+	if a.ID == "" || a.State != Pending {
+		t.Fatalf("unexpected submitted job %+v", a)
+	}
+
+	got, ok, err := q.Claim(nil)
+	if err != nil || !ok || got.ID != a.ID || got.State != Running {
+		t.Fatalf("Claim = %+v, %v, %v; want first job running", got, ok, err)
+	}
+
+	// The second job shares a destination with the running one.
+	busy := func(j Job) bool { return j.Destination == got.Destination }
+	if _, ok, _ := q.Claim(busy); ok {
+		t.Fatal("claimed a job rejected as busy")
+	}
+
+	if err := q.Finish(a.ID, errors.New("disk full")); err != nil {
+		t.Fatalf("Finish: %v", err)
+	}
+	if got, ok, _ := q.Claim(nil); !ok || got.ID != b.ID {
+		t.Fatalf("expected second job next, got %+v", got)
+	}
+	if err := q.Finish(b.ID, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	jobs, err := q.Jobs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if jobs[0].State != Failed || jobs[0].Error != "disk full" || jobs[1].State != Done {
+		t.Errorf("unexpected final states %+v", jobs)
+	}
+	if _, ok, _ := q.Claim(nil); ok {
+		t.Error("claimed from a queue with no pending jobs")
+	}
+}
+
+func TestQueuePersistsAndRecovers(t *testing.T) {
+	q := newQueue(t)
+	job, _ := q.Submit(Job{Command: "copy", Source: "/a", Destination: "/dst"})
+	if _, ok, _ := q.Claim(nil); !ok {
+		t.Fatal("expected to claim the job")
+	}
+
+	// A new handle, as after a daemon restart.
+	restarted := Open(q.Path())
+	n, err := restarted.Recover()
+	if err != nil || n != 1 {
+		t.Fatalf("Recover = %d, %v; want 1 requeued job", n, err)
+	}
+	got, ok, _ := restarted.Claim(nil)
+	if !ok || got.ID != job.ID {
+		t.Errorf("interrupted job not requeued: %+v", got)
+	}
+}
+
+func TestRecoverWithKeepsProgress(t *testing.T) {
+	q := newQueue(t)
+	job, _ := q.Submit(Job{Command: "copy", Source: "/a", Destination: "/dst"})
+	if _, ok, _ := q.Claim(nil); !ok {
+		t.Fatal("expected to claim the job")
+	}
+
+	last := func(j Job) *Progress { return &Progress{Current: 4, Total: 9} }
+	if n, err := Open(q.Path()).RecoverWith(last); err != nil || n != 1 {
+		t.Fatalf("RecoverWith = %d, %v; want 1 requeued job", n, err)
+	}
+	jobs, _ := q.Jobs()
+	if len(jobs) != 1 || jobs[0].ID != job.ID || jobs[0].Resumed == nil || jobs[0].Resumed.Current != 4 {
+		t.Errorf("resumed progress not kept: %+v", jobs)
+	}
+}
+
+func TestSubmitValidates(t *testing.T) {
+	q := newQueue(t)
+	if _, err := q.Submit(Job{Command: "delete", Source: "/a", Destination: "/b"}); err == nil {
+		t.Error("expected unsupported command to be rejected")
+	}
+	if _, err := q.Submit(Job{Command: "copy", Source: "/a"}); err == nil {
+		t.Error("expected missing destination to be rejected")
+	}
+}
+
+func TestJobArgs(t *testing.T) {
+	j := Job{Command: "copy", Source: "/a", Destination: "/b", Flags: []string{"--atomic"}}
+	got := j.Args()
+	want := []string{"copy", "--atomic", "/a", "/b"}
+	if len(got) != len(want) {
+		t.Fatalf("Args = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Args = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestDaemonHeartbeat(t *testing.T) {
+	q := newQueue(t)
+	if _, ok, err := q.Daemon(); ok || err != nil {
+		t.Fatalf("Daemon before any heartbeat = %v, %v", ok, err)
+	}
+	if err := q.Heartbeat(Daemon{PID: 42, Poll: time.Minute, Running: []string{"a"}}); err != nil {
+		t.Fatal(err)
+	}
+	d, ok, err := q.Daemon()
+	if !ok || err != nil || d.PID != 42 || len(d.Running) != 1 {
+		t.Fatalf("Daemon = %+v, %v, %v", d, ok, err)
+	}
+	if !d.Alive(d.UpdatedAt.Add(2*time.Minute)) || d.Alive(d.UpdatedAt.Add(4*time.Minute)) {
+		t.Error("a heartbeat should go stale after three poll intervals")
+	}
+	if err := q.Stopped(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := q.Daemon(); ok {
+		t.Error("heartbeat left after the daemon stopped")
+	}
+}
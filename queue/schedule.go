@@ -0,0 +1,130 @@
+package queue
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Tmunayyer/gocamelpack/cron"
+	"github.com/Tmunayyer/gocamelpack/session"
+)
+
+// HistoryLimit is how many past runs a schedule remembers.
+const HistoryLimit = 20
+
+// Schedule queues a copy of its job whenever its cron expression fires.
+type Schedule struct {
+	ID      string    `json:"id"`
+	Cron    string    `json:"cron"`
+	Job     Job       `json:"job"` // template; only the command fields are used
+	Created time.Time `json:"created"`
+	Next    time.Time `json:"next"`
+	History []Run     `json:"history,omitempty"`
+}
+
+// Run is one firing of a schedule: either a queued job or, when the
+// previous run was still pending or running, a skipped one.
+type Run struct {
+	At      time.Time `json:"at"`
+	Job     string    `json:"job,omitempty"`
+	Skipped string    `json:"skipped,omitempty"`
+}
+
+// AddSchedule validates expr and job and stores a new schedule.
+func (q *Queue) AddSchedule(expr string, job Job) (Schedule, error) {
+	e, err := cron.Parse(expr)
+	if err != nil {
+		return Schedule{}, err
+	}
+	if err := job.Validate(); err != nil {
+		return Schedule{}, err
+	}
+	now := q.now()
+	next := e.Next(now)
+	if next.IsZero() {
+		return Schedule{}, fmt.Errorf("cron expression %q never fires", expr)
+	}
+	s := Schedule{
+		ID:      session.NewID(now),
+		Cron:    expr,
+		Job:     Job{Command: job.Command, Source: job.Source, Destination: job.Destination, Flags: job.Flags},
+		Created: now,
+		Next:    next,
+	}
+	err = q.update(func(st *state) (bool, error) {
+		st.Schedules = append(st.Schedules, s)
+		return true, nil
+	})
+	return s, err
+}
+
+// Schedules returns all schedules in creation order.
+func (q *Queue) Schedules() ([]Schedule, error) {
+	var ss []Schedule
+	err := q.update(func(st *state) (bool, error) {
+		ss = st.Schedules
+		return false, nil
+	})
+	return ss, err
+}
+
+// RemoveSchedule deletes a schedule. Jobs it already queued are kept.
+func (q *Queue) RemoveSchedule(id string) error {
+	return q.update(func(st *state) (bool, error) {
+		for i, s := range st.Schedules {
+			if s.ID == id {
+				st.Schedules = append(st.Schedules[:i], st.Schedules[i+1:]...)
+				return true, nil
+			}
+		}
+		return false, fmt.Errorf("no schedule %q", id)
+	})
+}
+
+// RunDue queues a job for every schedule whose next run is at or before
+// now and returns the runs it recorded. A schedule whose previous job is
+// still pending or running is skipped instead, so runs never overlap. Runs
+// missed while no daemon was running are collapsed into one.
+func (q *Queue) RunDue(now time.Time) ([]Run, error) {
+	var runs []Run
+	err := q.update(func(st *state) (bool, error) {
+		changed := false
+		for i := range st.Schedules {
+			s := &st.Schedules[i]
+			if s.Next.After(now) {
+				continue
+			}
+			e, err := cron.Parse(s.Cron)
+			if err != nil {
+				return changed, fmt.Errorf("schedule %s: %w", s.ID, err)
+			}
+
+			run := Run{At: now}
+			if active := st.active(s.ID); active != "" {
+				run.Skipped = fmt.Sprintf("previous run %s still active", active)
+			} else {
+				job := s.Job
+				job.Schedule = s.ID
+				run.Job = st.add(job, now).ID
+			}
+			s.History = append(s.History, run)
+			if n := len(s.History); n > HistoryLimit {
+				s.History = append([]Run(nil), s.History[n-HistoryLimit:]...)
+			}
+			s.Next = e.Next(now)
+			runs = append(runs, run)
+			changed = true
+		}
+		return changed, nil
+	})
+	return runs, err
+}
+
+// active returns the ID of a pending or running job queued by schedule id.
+func (st *state) active(id string) string {
+	for _, j := range st.Jobs {
+		if j.Schedule == id && (j.State == Pending || j.State == Running) {
+			return j.ID
+		}
+	}
+	return ""
+}
@@ -0,0 +1,126 @@
+package queue
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRunDue(t *testing.T) {
+	q := newQueue(t)
+	now := time.Date(2025, 1, 27, 1, 59, 0, 0, time.UTC)
+	q.now = func() time.Time { return now }
+
+	s, err := q.AddSchedule("0 2 * * *", Job{Command: "copy", Source: "/mnt/camera", Destination: "/archive"})
+	if err != nil {
+		t.Fatalf("AddSchedule: %v", err)
+	}
+	if want := time.Date(2025, 1, 27, 2, 0, 0, 0, time.UTC); !s.Next.Equal(want) {
+		t.Fatalf("Next = %v, want %v", s.Next, want)
+	}
+
+	if runs, _ := q.RunDue(now); len(runs) != 0 {
+		t.Fatalf("nothing should be due yet, got %+v", runs)
+	}
+
+	now = now.Add(time.Minute)
+	runs, err := q.RunDue(now)
+	if err != nil || len(runs) != 1 || runs[0].Job == "" {
+		t.Fatalf("RunDue = %+v, %v; want one queued job", runs, err)
+	}
+	jobs, _ := q.Jobs()
+	if len(jobs) != 1 || jobs[0].Schedule != s.ID || jobs[0].Source != "/mnt/camera" || jobs[0].State != Pending {
+		t.Fatalf("unexpected queued jobs %+v", jobs)
+	}
+
+	// The next day's run overlaps the still-pending first one.
+	now = now.Add(24 * time.Hour)
+	runs, _ = q.RunDue(now)
+	if len(runs) != 1 || runs[0].Skipped == "" {
+		t.Fatalf("expected overlapping run to be skipped, got %+v", runs)
+	}
+
+	// Once the job has finished, the following run is queued again.
+	if _, ok, _ := q.Claim(nil); !ok {
+		t.Fatal("expected to claim the scheduled job")
+	}
+	q.Finish(jobs[0].ID, nil)
+	now = now.Add(24 * time.Hour)
+	if runs, _ = q.RunDue(now); len(runs) != 1 || runs[0].Job == "" {
+		t.Fatalf("expected a new job after the previous finished, got %+v", runs)
+	}
+
+	ss, _ := q.Schedules()
+	if len(ss[0].History) != 3 {
+		t.Errorf("expected 3 runs in history, got %+v", ss[0].History)
+	}
+	if want := time.Date(2025, 1, 30, 2, 0, 0, 0, time.UTC); !ss[0].Next.Equal(want) {
+		t.Errorf("Next = %v, want %v", ss[0].Next, want)
+	}
+}
+
+func TestRunDue_CollapsesMissedRuns(t *testing.T) {
+	q := newQueue(t)
+	start := time.Date(2025, 1, 27, 0, 0, 30, 0, time.UTC)
+	q.now = func() time.Time { return start }
+	if _, err := q.AddSchedule("@hourly", Job{Command: "move", Source: "/in", Destination: "/out"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The daemon was down for a day.
+	later := start.Add(24 * time.Hour)
+	runs, _ := q.RunDue(later)
+	if len(runs) != 1 {
+		t.Fatalf("expected missed runs to collapse into one, got %d", len(runs))
+	}
+	ss, _ := q.Schedules()
+	if !ss[0].Next.After(later) {
+		t.Errorf("next run %v should be after %v", ss[0].Next, later)
+	}
+}
+
+func TestRunDue_HistoryIsCapped(t *testing.T) {
+	q := newQueue(t)
+	now := time.Date(2025, 1, 27, 0, 0, 0, 0, time.UTC)
+	q.now = func() time.Time { return now }
+	if _, err := q.AddSchedule("* * * * *", Job{Command: "copy", Source: "/in", Destination: "/out"}); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < HistoryLimit+5; i++ {
+		now = now.Add(time.Minute)
+		if _, err := q.RunDue(now); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ss, _ := q.Schedules()
+	if len(ss[0].History) != HistoryLimit {
+		t.Errorf("history length = %d, want %d", len(ss[0].History), HistoryLimit)
+	}
+}
+
+func TestAddSchedule_Validates(t *testing.T) {
+	q := newQueue(t)
+	job := Job{Command: "copy", Source: "/a", Destination: "/b"}
+	if _, err := q.AddSchedule("0 2 * *", job); err == nil {
+		t.Error("expected invalid cron expression to be rejected")
+	}
+	if _, err := q.AddSchedule("0 0 31 2 *", job); err == nil {
+		t.Error("expected never-firing expression to be rejected")
+	}
+	if _, err := q.AddSchedule("0 2 * * *", Job{Command: "copy"}); err == nil {
+		t.Error("expected incomplete job to be rejected")
+	}
+}
+
+func TestRemoveSchedule(t *testing.T) {
+	q := newQueue(t)
+	s, _ := q.AddSchedule("@daily", Job{Command: "copy", Source: "/a", Destination: "/b"})
+	if err := q.RemoveSchedule(s.ID); err != nil {
+		t.Fatalf("RemoveSchedule: %v", err)
+	}
+	if err := q.RemoveSchedule(s.ID); err == nil {
+		t.Error("expected error removing a missing schedule")
+	}
+	if ss, _ := q.Schedules(); len(ss) != 0 {
+		t.Errorf("schedule not removed: %+v", ss)
+	}
+}
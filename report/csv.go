@@ -0,0 +1,55 @@
+package report
+
+import (
+	"encoding/csv"
+	"io"
+	"os"
+	"strconv"
+)
+
+// Row is one file of a planned or executed run, as listed by WriteCSV.
+type Row struct {
+	Source      string
+	Destination string
+	Size        int64
+	// Checksum is the hash of the file's content: hex SHA-256, or
+	// "xxh64:<hex>" with --hash-algo xxhash64.
+	Checksum string
+	// DateTag is the metadata tag the file's date was taken from.
+	DateTag string
+	// Status is what became of the file: planned, copied, moved, skipped,
+	// or not transferred when the run stopped before it.
+	Status string
+}
+
+// CSVHeader is the first line written by WriteCSV.
+var CSVHeader = []string{"source", "destination", "size", "checksum", "date_tag", "status"}
+
+// WriteCSV writes rows to w as CSV with a header line, one file per line,
+// for audits in a spreadsheet.
+func WriteCSV(w io.Writer, rows []Row) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(CSVHeader); err != nil {
+		return err
+	}
+	for _, r := range rows {
+		if err := cw.Write([]string{r.Source, r.Destination, strconv.FormatInt(r.Size, 10), r.Checksum, r.DateTag, r.Status}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteCSVFile writes rows into the file at path.
+func WriteCSVFile(path string, rows []Row) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := WriteCSV(f, rows); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
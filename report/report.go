@@ -0,0 +1,190 @@
+// Package report renders a self-contained HTML report of an import run:
+// what was archived where, per-folder counts, conflicts, errors and
+// thumbnails, in a single file that can be shared as proof of the import.
+// WriteCSV lists a run's files, planned or executed, for spreadsheet audits.
+package report
+
+import (
+	"encoding/base64"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Tmunayyer/gocamelpack/units"
+)
+
+// MaxThumbnails is how many thumbnails are embedded in a report; the
+// gallery notes how many more were generated.
+const MaxThumbnails = 200
+
+// File is one archived file.
+type File struct {
+	Source      string
+	Destination string
+	Size        int64
+	// Thumbnail is the path of the file's generated preview, if any.
+	Thumbnail string
+}
+
+// Folder is a destination folder and how many archived files it received.
+type Folder struct {
+	Path  string
+	Files int
+}
+
+// Run describes one copy or move run.
+type Run struct {
+	Command     string
+	Session     string
+	Source      string
+	Destination string
+	Simulated   bool
+	Started     time.Time
+	Finished    time.Time
+
+	Files         []File
+	Skipped       int
+	Retried       int
+	RetryAttempts int
+	BytesWritten  int64
+
+	Conflicts []string
+	Errors    []string
+}
+
+// Failed reports whether the run ended with an error.
+func (r Run) Failed() bool {
+	return len(r.Errors) > 0 || len(r.Conflicts) > 0
+}
+
+// Folders counts the archived files per destination folder, relative to the
+// destination root, in path order.
+func (r Run) Folders() []Folder {
+	counts := map[string]int{}
+	for _, f := range r.Files {
+		dir, err := filepath.Rel(r.Destination, filepath.Dir(f.Destination))
+		if err != nil || strings.HasPrefix(dir, "..") {
+			dir = filepath.Dir(f.Destination)
+		}
+		counts[filepath.ToSlash(dir)]++
+	}
+	folders := make([]Folder, 0, len(counts))
+	for dir, n := range counts {
+		folders = append(folders, Folder{Path: dir, Files: n})
+	}
+	sort.Slice(folders, func(i, j int) bool { return folders[i].Path < folders[j].Path })
+	return folders
+}
+
+// thumbnail is an embedded preview.
+type thumbnail struct {
+	Name string
+	Data template.URL
+}
+
+// Write renders r as HTML to w. Thumbnails are embedded as data URLs, so
+// the report needs no other file; missing ones are left out.
+func Write(w io.Writer, r Run) error {
+	var thumbs []thumbnail
+	more := 0
+	for _, f := range r.Files {
+		if f.Thumbnail == "" {
+			continue
+		}
+		if len(thumbs) == MaxThumbnails {
+			more++
+			continue
+		}
+		data, err := os.ReadFile(f.Thumbnail)
+		if err != nil {
+			continue
+		}
+		thumbs = append(thumbs, thumbnail{
+			Name: filepath.Base(f.Destination),
+			Data: template.URL("data:image/jpeg;base64," + base64.StdEncoding.EncodeToString(data)),
+		})
+	}
+	return page.Execute(w, struct {
+		Run
+		Folders        []Folder
+		Thumbnails     []thumbnail
+		MoreThumbnails int
+	}{r, r.Folders(), thumbs, more})
+}
+
+// WriteFile renders r into the file at path.
+func WriteFile(path string, r Run) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := Write(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+var page = template.Must(template.New("report").Funcs(template.FuncMap{
+	"size":     func(n int64) string { return units.ByteSize(n).String() },
+	"time":     func(t time.Time) string { return t.Format("2006-01-02 15:04:05 MST") },
+	"duration": func(a, b time.Time) string { return b.Sub(a).Round(time.Second).String() },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>gocamelpack {{.Command}} report{{with .Session}} – {{.}}{{end}}</title>
+<style>
+body { font: 14px/1.4 system-ui, sans-serif; margin: 2em auto; max-width: 70em; color: #222; }
+h1 { font-size: 1.6em; }
+h2 { font-size: 1.2em; margin-top: 2em; border-bottom: 1px solid #ddd; }
+table { border-collapse: collapse; width: 100%; }
+th, td { text-align: left; padding: .25em .6em; border-bottom: 1px solid #eee; vertical-align: top; }
+td.n { text-align: right; }
+code { font-size: 12px; word-break: break-all; }
+.ok { color: #1a7f37; } .failed { color: #cf222e; } .badge { background: #eee; border-radius: 3px; padding: 0 .4em; }
+.gallery { display: flex; flex-wrap: wrap; gap: .5em; }
+.gallery figure { margin: 0; width: 128px; } .gallery img { max-width: 128px; max-height: 128px; }
+.gallery figcaption { font-size: 11px; overflow: hidden; text-overflow: ellipsis; white-space: nowrap; }
+</style>
+</head>
+<body>
+<h1>gocamelpack {{.Command}} report {{if .Failed}}<span class="failed">failed</span>{{else}}<span class="ok">complete</span>{{end}}{{if .Simulated}} <span class="badge">simulated</span>{{end}}</h1>
+<h2>Summary</h2>
+<table>
+<tr><th>Source</th><td><code>{{.Source}}</code></td></tr>
+<tr><th>Destination</th><td><code>{{.Destination}}</code></td></tr>
+{{with .Session}}<tr><th>Session</th><td><code>{{.}}</code></td></tr>{{end}}
+<tr><th>Started</th><td>{{time .Started}}</td></tr>
+<tr><th>Duration</th><td>{{duration .Started .Finished}}</td></tr>
+<tr><th>Files archived</th><td>{{len .Files}}</td></tr>
+<tr><th>Files skipped</th><td>{{.Skipped}}</td></tr>
+<tr><th>Data written</th><td>{{size .BytesWritten}}</td></tr>
+{{if .Retried}}<tr><th>Retried</th><td>{{.Retried}} file(s), {{.RetryAttempts}} extra attempt(s)</td></tr>{{end}}
+</table>
+{{if .Errors}}<h2 class="failed">Errors</h2>
+<ul>{{range .Errors}}<li><code>{{.}}</code></li>{{end}}</ul>{{end}}
+{{if .Conflicts}}<h2 class="failed">Conflicts</h2>
+<ul>{{range .Conflicts}}<li><code>{{.}}</code></li>{{end}}</ul>{{end}}
+{{if .Folders}}<h2>Folders</h2>
+<table>
+<tr><th>Folder</th><th>Files</th></tr>
+{{range .Folders}}<tr><td><code>{{.Path}}</code></td><td class="n">{{.Files}}</td></tr>
+{{end}}</table>{{end}}
+{{if .Thumbnails}}<h2>Thumbnails</h2>
+<div class="gallery">
+{{range .Thumbnails}}<figure><img src="{{.Data}}" alt="{{.Name}}"><figcaption>{{.Name}}</figcaption></figure>
+{{end}}</div>
+{{if .MoreThumbnails}}<p>and {{.MoreThumbnails}} more.</p>{{end}}{{end}}
+{{if .Files}}<h2>Files</h2>
+<table>
+<tr><th>Source</th><th>Destination</th><th>Size</th></tr>
+{{range .Files}}<tr><td><code>{{.Source}}</code></td><td><code>{{.Destination}}</code></td><td class="n">{{size .Size}}</td></tr>
+{{end}}</table>{{end}}
+</body>
+</html>
+`))
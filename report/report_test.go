@@ -0,0 +1,79 @@
+package report
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+func TestRun_Folders(t *testing.T) {
+	r := Run{Destination: "/archive", Files: []File{
+		{Destination: "/archive/2025/01/a.jpg"},
+		{Destination: "/archive/2025/02/b.jpg"},
+		{Destination: "/archive/2025/01/c.jpg"},
+	}}
+	got := r.Folders()
+	if len(got) != 2 || got[0] != (Folder{"2025/01", 2}) || got[1] != (Folder{"2025/02", 1}) {
+		t.Errorf("Folders = %v", got)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	dir := filepath.Join(testutil.TempDir(t), "report")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	thumb := filepath.Join(dir, "a.jpg")
+	if err := os.WriteFile(thumb, []byte("jpeg"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2025, 1, 27, 15, 30, 0, 0, time.UTC)
+	r := Run{
+		Command: "copy", Source: "/card", Destination: "/archive",
+		Started: start, Finished: start.Add(90 * time.Second),
+		Files: []File{
+			{Source: "/card/a.jpg", Destination: "/archive/2025/a.jpg", Size: 2048, Thumbnail: thumb},
+			{Source: "/card/<b>.jpg", Destination: "/archive/2025/b.jpg", Thumbnail: filepath.Join(dir, "missing.jpg")},
+		},
+		Errors: []string{"disk on fire"},
+	}
+	var buf bytes.Buffer
+	if err := Write(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	html := buf.String()
+	for _, want := range []string{
+		"failed", "disk on fire", "2025</code></td><td class=\"n\">2", "1m30s", "2.0 KiB",
+		"data:image/jpeg;base64,anBlZw==", "&lt;b&gt;.jpg",
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("report lacks %q", want)
+		}
+	}
+	if strings.Count(html, "<figure>") != 1 {
+		t.Error("want exactly the one readable thumbnail embedded")
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteCSV(&buf, []Row{
+		{Source: "/card/a,1.jpg", Destination: "/archive/2025/a.jpg", Size: 3, Checksum: "abc", DateTag: "CreationDate", Status: "copied"},
+		{Source: "/card/b.jpg", Status: "skipped"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "source,destination,size,checksum,date_tag,status\n" +
+		"\"/card/a,1.jpg\",/archive/2025/a.jpg,3,abc,CreationDate,copied\n" +
+		"/card/b.jpg,,0,,,skipped\n"
+	if buf.String() != want {
+		t.Errorf("WriteCSV =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
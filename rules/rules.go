@@ -0,0 +1,334 @@
+// Package rules decides, per file, whether it is imported and which template
+// places it. Rules are evaluated in order and the first match wins; files
+// that match no rule use the default template.
+package rules
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/pathtmpl"
+	"github.com/Tmunayyer/gocamelpack/units"
+)
+
+// Action is what happens to a file that matches a rule.
+type Action string
+
+const (
+	// ActionTemplate places the file using the rule's template.
+	ActionTemplate Action = "template"
+	// ActionSkip leaves the file out of the run.
+	ActionSkip Action = "skip"
+	// ActionUnsorted places the file under <root>/unsorted/ by name.
+	ActionUnsorted Action = "unsorted"
+)
+
+// UnsortedDir is the folder that ActionUnsorted files are placed in.
+const UnsortedDir = "unsorted"
+
+// Match lists the conditions of a rule. Every condition that is set must
+// hold; a rule with no conditions matches every file.
+type Match struct {
+	// Ext matches the file extension, case-insensitively, with or without
+	// the leading dot.
+	Ext []string `json:"ext,omitempty"`
+	// Tags maps tag names to glob patterns (see path.Match) compared
+	// case-insensitively. An empty pattern only requires the tag to be set.
+	Tags map[string]string `json:"tags,omitempty"`
+	// Missing lists tags that must be absent or empty.
+	Missing []string `json:"missing,omitempty"`
+	// MinSize and MaxSize bound the file size in bytes, inclusive.
+	MinSize units.ByteSize `json:"min_size,omitempty"`
+	MaxSize units.ByteSize `json:"max_size,omitempty"`
+}
+
+// Rule is a named condition with an action.
+type Rule struct {
+	Name     string `json:"name"`
+	Match    Match  `json:"match"`
+	Action   Action `json:"action,omitempty"`   // defaults to "template"
+	Template string `json:"template,omitempty"` // required for "template"
+	// Root is the destination root for the rule's files, e.g. a bulk disk
+	// for videos, instead of the one given on the command line. It must be
+	// absolute.
+	Root string `json:"root,omitempty"`
+
+	tmpl *pathtmpl.Template
+}
+
+// Subject is the file being evaluated.
+type Subject struct {
+	Path     string
+	Metadata files.FileMetadata
+	Size     int64 // negative when unknown; size conditions then never match
+}
+
+// Decision is the outcome of evaluating a Subject.
+type Decision struct {
+	Rule     *Rule // nil when no rule matched
+	Action   Action
+	Template *pathtmpl.Template // nil means the built-in layout
+}
+
+// Root returns the destination root the decision places the file under:
+// the matched rule's root, else dstRoot.
+func (d Decision) Root(dstRoot string) string {
+	if d.Rule != nil && d.Rule.Root != "" {
+		return d.Rule.Root
+	}
+	return dstRoot
+}
+
+// RuleName returns the matched rule's name, or "default".
+func (d Decision) RuleName() string {
+	if d.Rule == nil {
+		return "default"
+	}
+	return d.Rule.Name
+}
+
+// Engine evaluates an ordered rule list.
+type Engine struct {
+	rules    []Rule
+	fallback *pathtmpl.Template
+}
+
+// New validates rules and compiles their templates. defaultTemplate is used
+// when no rule matches; empty means the built-in layout.
+func New(rs []Rule, defaultTemplate string) (*Engine, error) {
+	e := &Engine{rules: make([]Rule, len(rs))}
+
+	if defaultTemplate != "" {
+		t, err := pathtmpl.Parse(defaultTemplate)
+		if err != nil {
+			return nil, err
+		}
+		e.fallback = t
+	}
+
+	for i, r := range rs {
+		if r.Name == "" {
+			r.Name = fmt.Sprintf("rule %d", i+1)
+		}
+		if r.Action == "" {
+			r.Action = ActionTemplate
+		}
+		switch r.Action {
+		case ActionTemplate:
+			if r.Template == "" {
+				return nil, fmt.Errorf("rule %q: action %q needs a template", r.Name, r.Action)
+			}
+			t, err := pathtmpl.Parse(r.Template)
+			if err != nil {
+				return nil, fmt.Errorf("rule %q: %w", r.Name, err)
+			}
+			r.tmpl = t
+		case ActionSkip, ActionUnsorted:
+			if r.Template != "" {
+				return nil, fmt.Errorf("rule %q: action %q does not take a template", r.Name, r.Action)
+			}
+		default:
+			return nil, fmt.Errorf("rule %q: unknown action %q", r.Name, r.Action)
+		}
+		if r.Root != "" {
+			if r.Action == ActionSkip {
+				return nil, fmt.Errorf("rule %q: action %q does not take a root", r.Name, r.Action)
+			}
+			if !filepath.IsAbs(r.Root) {
+				return nil, fmt.Errorf("rule %q: root %q is not an absolute path", r.Name, r.Root)
+			}
+			r.Root = filepath.Clean(r.Root)
+		}
+		if r.Match.MaxSize > 0 && r.Match.MinSize > r.Match.MaxSize {
+			return nil, fmt.Errorf("rule %q: min_size is larger than max_size", r.Name)
+		}
+		e.rules[i] = r
+	}
+	return e, nil
+}
+
+// SetLocale makes the templates of e name months and weekdays in l.
+func (e *Engine) SetLocale(l *pathtmpl.Locale) {
+	if e.fallback != nil {
+		e.fallback.SetLocale(l)
+	}
+	for _, r := range e.rules {
+		if r.tmpl != nil {
+			r.tmpl.SetLocale(l)
+		}
+	}
+}
+
+// Rules returns the validated rules in evaluation order.
+func (e *Engine) Rules() []Rule {
+	out := make([]Rule, len(e.rules))
+	copy(out, e.rules)
+	return out
+}
+
+// Evaluate returns the decision for s.
+func (e *Engine) Evaluate(s Subject) Decision {
+	for i := range e.rules {
+		r := &e.rules[i]
+		if r.Match.matches(s) {
+			return Decision{Rule: r, Action: r.Action, Template: r.tmpl}
+		}
+	}
+	return Decision{Action: ActionTemplate, Template: e.fallback}
+}
+
+// Destination resolves where s goes under dstRoot, or under the root of the
+// matching rule when it has one. builtin computes the built-in layout and is
+// used when the decision carries no template. For ActionSkip the returned
+// path is empty.
+func (e *Engine) Destination(s Subject, dstRoot string, builtin func(files.FileMetadata, string) (string, error)) (string, Decision, error) {
+	d := e.Evaluate(s)
+	dstRoot = d.Root(dstRoot)
+	switch d.Action {
+	case ActionSkip:
+		return "", d, nil
+	case ActionUnsorted:
+		return filepath.Join(dstRoot, UnsortedDir, filepath.Base(s.Path)), d, nil
+	}
+
+	if d.Template == nil {
+		dst, err := builtin(s.Metadata, dstRoot)
+		return dst, d, err
+	}
+	dst, err := d.Template.Destination(s.Metadata, dstRoot)
+	if err != nil {
+		return "", d, fmt.Errorf("%s (rule %s): %w", s.Path, d.RuleName(), err)
+	}
+	return dst, d, nil
+}
+
+// Tags returns the metadata tags e consults: those of its templates and
+// of the rules' tag conditions, each once. The built-in layout's tags are
+// not included; callers that pass one to Destination add them.
+func (e *Engine) Tags() []string {
+	var tags []string
+	seen := map[string]bool{}
+	add := func(names ...string) {
+		for _, n := range names {
+			if !seen[n] {
+				seen[n] = true
+				tags = append(tags, n)
+			}
+		}
+	}
+	if e.fallback != nil {
+		add(e.fallback.Tags()...)
+	}
+	for _, r := range e.rules {
+		if r.tmpl != nil {
+			add(r.tmpl.Tags()...)
+		}
+		conds := make([]string, 0, len(r.Match.Tags))
+		for tag := range r.Match.Tags {
+			conds = append(conds, tag)
+		}
+		sort.Strings(conds)
+		add(conds...)
+		add(r.Match.Missing...)
+	}
+	return tags
+}
+
+// NeedsSize reports whether any rule has a size condition, so callers can
+// skip stat calls otherwise.
+func (e *Engine) NeedsSize() bool {
+	for _, r := range e.rules {
+		if r.Match.MinSize > 0 || r.Match.MaxSize > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (m Match) matches(s Subject) bool {
+	if len(m.Ext) > 0 {
+		ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(s.Path), "."))
+		found := false
+		for _, want := range m.Ext {
+			if strings.ToLower(strings.TrimPrefix(want, ".")) == ext {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	for tag, pattern := range m.Tags {
+		v := strings.TrimSpace(s.Metadata.Tags[tag])
+		if v == "" {
+			return false
+		}
+		if pattern == "" {
+			continue
+		}
+		ok, err := path.Match(strings.ToLower(pattern), strings.ToLower(v))
+		if err != nil || !ok {
+			return false
+		}
+	}
+
+	for _, tag := range m.Missing {
+		if strings.TrimSpace(s.Metadata.Tags[tag]) != "" {
+			return false
+		}
+	}
+
+	if m.MinSize > 0 || m.MaxSize > 0 {
+		if s.Size < 0 {
+			return false
+		}
+		if m.MinSize > 0 && s.Size < int64(m.MinSize) {
+			return false
+		}
+		if m.MaxSize > 0 && s.Size > int64(m.MaxSize) {
+			return false
+		}
+	}
+	return true
+}
+
+// Describe renders the rule's conditions for display, e.g.
+// "ext in [mp4 mov] and size >= 100.0 MiB".
+func (r Rule) Describe() string {
+	var conds []string
+	m := r.Match
+	if len(m.Ext) > 0 {
+		conds = append(conds, fmt.Sprintf("ext in %v", m.Ext))
+	}
+	tags := make([]string, 0, len(m.Tags))
+	for tag := range m.Tags {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	for _, tag := range tags {
+		if pattern := m.Tags[tag]; pattern == "" {
+			conds = append(conds, fmt.Sprintf("has %s", tag))
+		} else {
+			conds = append(conds, fmt.Sprintf("%s ~ %q", tag, pattern))
+		}
+	}
+	for _, tag := range m.Missing {
+		conds = append(conds, fmt.Sprintf("missing %s", tag))
+	}
+	if m.MinSize > 0 {
+		conds = append(conds, fmt.Sprintf("size >= %s", m.MinSize))
+	}
+	if m.MaxSize > 0 {
+		conds = append(conds, fmt.Sprintf("size <= %s", m.MaxSize))
+	}
+	if len(conds) == 0 {
+		return "always"
+	}
+	return strings.Join(conds, " and ")
+}
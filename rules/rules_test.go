@@ -0,0 +1,151 @@
+package rules
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/files"
+	"github.com/Tmunayyer/gocamelpack/units"
+)
+
+func subject(path string, size int64, tags map[string]string) Subject {
+	return Subject{Path: path, Size: size, Metadata: files.FileMetadata{Filepath: path, Tags: tags}}
+}
+
+func builtin(md files.FileMetadata, base string) (string, error) {
+	return filepath.Join(base, "builtin", filepath.Base(md.Filepath)), nil
+}
+
+func TestEngine_FirstMatchWins(t *testing.T) {
+	e, err := New([]Rule{
+		{Name: "screenshots", Match: Match{Tags: map[string]string{"Software": "*screenshot*"}}, Action: ActionSkip},
+		{Name: "videos", Match: Match{Ext: []string{".MP4", "mov"}}, Template: "video/{year}/{month}"},
+		{Name: "undated", Match: Match{Missing: []string{"CreationDate", "DateTimeOriginal"}}, Action: ActionUnsorted},
+	}, "")
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	dated := map[string]string{"CreationDate": "2024:05:06 07:08:09"}
+	tests := []struct {
+		name   string
+		s      Subject
+		rule   string
+		action Action
+		want   string
+	}{
+		{"skip", subject("/in/a.png", 10, map[string]string{"Software": "macOS Screenshot"}), "screenshots", ActionSkip, ""},
+		{"video", subject("/in/b.mp4", 10, dated), "videos", ActionTemplate, "/out/video/2024/05.mp4"},
+		{"unsorted", subject("/in/c.jpg", 10, map[string]string{}), "undated", ActionUnsorted, "/out/unsorted/c.jpg"},
+		{"default", subject("/in/d.jpg", 10, dated), "default", ActionTemplate, "/out/builtin/d.jpg"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dst, d, err := e.Destination(tt.s, "/out", builtin)
+			if err != nil {
+				t.Fatalf("Destination: %v", err)
+			}
+			if d.RuleName() != tt.rule || d.Action != tt.action {
+				t.Errorf("decision = %s/%s, want %s/%s", d.RuleName(), d.Action, tt.rule, tt.action)
+			}
+			if dst != filepath.FromSlash(tt.want) {
+				t.Errorf("dst = %q, want %q", dst, tt.want)
+			}
+		})
+	}
+}
+
+func TestEngine_DefaultTemplate(t *testing.T) {
+	e, err := New(nil, "{year}/{month}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst, _, err := e.Destination(subject("/in/a.jpg", -1, map[string]string{"CreationDate": "2024:05:06 07:08:09"}), "/out", builtin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.FromSlash("/out/2024/05.jpg"); dst != want {
+		t.Errorf("dst = %q, want %q", dst, want)
+	}
+}
+
+func TestMatch_Size(t *testing.T) {
+	m := Match{MinSize: units.ByteSize(10 << 10), MaxSize: units.ByteSize(1 << 20)}
+	cases := map[int64]bool{-1: false, 100: false, 10 * 1024: true, 1 << 20: true, 1<<20 + 1: false}
+	for size, want := range cases {
+		if got := m.matches(subject("/a.jpg", size, nil)); got != want {
+			t.Errorf("size %d: got %v want %v", size, got, want)
+		}
+	}
+}
+
+func TestNew_Invalid(t *testing.T) {
+	bad := [][]Rule{
+		{{Name: "no template"}},
+		{{Name: "bad action", Action: "explode"}},
+		{{Name: "skip with template", Action: ActionSkip, Template: "{year}"}},
+		{{Name: "bad template", Template: "{nope}"}},
+		{{Name: "sizes", Action: ActionSkip, Match: Match{MinSize: 10, MaxSize: 5}}},
+		{{Name: "relative root", Template: "{year}", Root: "bulk"}},
+		{{Name: "skip with root", Action: ActionSkip, Root: "/mnt/bulk"}},
+	}
+	for _, rs := range bad {
+		if _, err := New(rs, ""); err == nil {
+			t.Errorf("expected error for %+v", rs[0])
+		}
+	}
+}
+
+func TestEngine_RuleRoot(t *testing.T) {
+	bulk := filepath.Join(string(filepath.Separator)+"mnt", "bulk")
+	if !filepath.IsAbs(bulk) {
+		t.Skip("absolute paths need a volume name here")
+	}
+	e, err := New([]Rule{
+		{Name: "videos", Match: Match{Ext: []string{"mp4"}}, Template: "{year}", Root: bulk},
+		{Name: "undated", Match: Match{Ext: []string{"mov"}}, Action: ActionUnsorted, Root: bulk},
+	}, "{year}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tags := map[string]string{"CreationDate": "2024:03:09 10:00:00"}
+	for _, tc := range []struct{ path, want string }{
+		{"/in/clip.mp4", filepath.Join(bulk, "2024.mp4")},
+		{"/in/clip.mov", filepath.Join(bulk, UnsortedDir, "clip.mov")},
+		{"/in/photo.jpg", filepath.Join("ssd", "2024.jpg")},
+	} {
+		got, _, err := e.Destination(subject(tc.path, 1, tags), "ssd", builtin)
+		if err != nil || got != tc.want {
+			t.Errorf("Destination(%s) = %q, %v; want %q", tc.path, got, err, tc.want)
+		}
+	}
+}
+
+func TestEngine_Tags(t *testing.T) {
+	e, err := New([]Rule{
+		{Name: "phone", Match: Match{Tags: map[string]string{"Make": "apple", "Model": ""}}, Template: "{year}/{model}"},
+		{Name: "screenshots", Match: Match{Missing: []string{"Make"}}, Action: ActionUnsorted},
+	}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := strings.Join(e.Tags(), ",")
+	want := "CreationDate,DateTimeOriginal,CreateDate,MediaCreateDate,Model,Make"
+	if got != want {
+		t.Errorf("Tags() = %s, want %s", got, want)
+	}
+}
+
+func TestRule_Describe(t *testing.T) {
+	r := Rule{Match: Match{Ext: []string{"jpg"}, Tags: map[string]string{"Model": "", "Make": "apple", "Lens": "*"}, MinSize: units.ByteSize(1 << 20)}}
+	want := `ext in [jpg] and Lens ~ "*" and Make ~ "apple" and has Model and size >= 1.0 MiB`
+	for i := 0; i < 10; i++ {
+		if got := r.Describe(); got != want {
+			t.Fatalf("Describe() = %s, want %s", got, want)
+		}
+	}
+	if got := (Rule{}).Describe(); got != "always" {
+		t.Errorf("Describe() of an empty match = %s", got)
+	}
+}
@@ -0,0 +1,104 @@
+// Package sched runs per-file work on a pool of workers. The order in which
+// workers take tasks from the shared queue is a pluggable Strategy: with
+// files of very different sizes, starting the largest first keeps one
+// worker from still copying a long video after all the others are done.
+package sched
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Task is one unit of work. Index identifies it to the caller; Size, in
+// bytes, is what strategies balance.
+type Task struct {
+	Index int
+	Size  int64
+}
+
+// Strategy orders the queue workers take tasks from.
+type Strategy interface {
+	Order(tasks []Task)
+}
+
+// StrategyFunc adapts a function to a Strategy.
+type StrategyFunc func(tasks []Task)
+
+func (f StrategyFunc) Order(tasks []Task) { f(tasks) }
+
+// Planned keeps tasks in the order they were planned.
+var Planned Strategy = StrategyFunc(func([]Task) {})
+
+// LargestFirst starts the largest tasks first, so the last tasks left to run
+// are small and workers finish at about the same time. It keeps the planned
+// order among tasks of equal size.
+var LargestFirst Strategy = StrategyFunc(func(tasks []Task) {
+	sort.SliceStable(tasks, func(i, j int) bool { return tasks[i].Size > tasks[j].Size })
+})
+
+var strategies = map[string]Strategy{
+	"planned":       Planned,
+	"largest-first": LargestFirst,
+}
+
+// Register makes a strategy available to Parse under name.
+func Register(name string, s Strategy) {
+	strategies[name] = s
+}
+
+// Parse returns the strategy registered under name.
+func Parse(name string) (Strategy, error) {
+	if s, ok := strategies[name]; ok {
+		return s, nil
+	}
+	names := make([]string, 0, len(strategies))
+	for n := range strategies {
+		names = append(names, n)
+	}
+	slices.Sort(names)
+	return nil, fmt.Errorf("invalid schedule %q (want %s)", name, strings.Join(names, " or "))
+}
+
+// Run orders tasks with s and runs fn on each with up to workers calls at
+// once. After the first error no further tasks are started; Run waits for
+// the running ones and returns that error.
+func Run(tasks []Task, workers int, s Strategy, fn func(Task) error) error {
+	queue := slices.Clone(tasks)
+	s.Order(queue)
+
+	next := make(chan Task)
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+		failed   = make(chan struct{})
+	)
+	for range max(min(workers, len(queue)), 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for t := range next {
+				if err := fn(t); err != nil {
+					once.Do(func() {
+						firstErr = err
+						close(failed)
+					})
+				}
+			}
+		}()
+	}
+feed:
+	for _, t := range queue {
+		select {
+		case next <- t:
+		case <-failed:
+			break feed
+		}
+	}
+	close(next)
+	wg.Wait()
+	return firstErr
+}
@@ -0,0 +1,93 @@
+package sched
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLargestFirst(t *testing.T) {
+	tasks := []Task{{0, 10}, {1, 300}, {2, 10}, {3, 5000}}
+	LargestFirst.Order(tasks)
+	var got []int
+	for _, task := range tasks {
+		got = append(got, task.Index)
+	}
+	if want := []int{3, 1, 0, 2}; len(got) != 4 || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] || got[3] != want[3] {
+		t.Errorf("order = %v, want %v", got, want)
+	}
+}
+
+func TestParse(t *testing.T) {
+	if s, err := Parse("largest-first"); err != nil || s == nil {
+		t.Errorf("Parse(largest-first) = %v, %v", s, err)
+	}
+	if _, err := Parse("random"); err == nil {
+		t.Error("expected an error for an unknown strategy")
+	}
+
+	Register("smallest-first", StrategyFunc(func([]Task) {}))
+	t.Cleanup(func() { delete(strategies, "smallest-first") })
+	if _, err := Parse("smallest-first"); err != nil {
+		t.Errorf("registered strategy not found: %v", err)
+	}
+}
+
+func TestRun(t *testing.T) {
+	tasks := make([]Task, 20)
+	for i := range tasks {
+		tasks[i] = Task{Index: i, Size: int64(i)}
+	}
+
+	var mu sync.Mutex
+	var running, peak int
+	var order []int
+	err := Run(tasks, 3, LargestFirst, func(task Task) error {
+		mu.Lock()
+		running++
+		peak = max(peak, running)
+		order = append(order, task.Index)
+		mu.Unlock()
+		time.Sleep(time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(order) != 20 || peak > 3 || peak < 2 {
+		t.Errorf("ran %d task(s) with at most %d at once; want 20 with 3 workers", len(order), peak)
+	}
+	if order[0] < 17 {
+		t.Errorf("the largest tasks should start first, got %v", order[:3])
+	}
+	if tasks[0].Index != 0 {
+		t.Error("Run must not reorder the caller's slice")
+	}
+}
+
+func TestRun_StopsAfterError(t *testing.T) {
+	tasks := make([]Task, 100)
+	for i := range tasks {
+		tasks[i] = Task{Index: i}
+	}
+	boom := errors.New("boom")
+	var ran atomic.Int32
+	err := Run(tasks, 2, Planned, func(task Task) error {
+		ran.Add(1)
+		if task.Index == 3 {
+			return boom
+		}
+		return nil
+	})
+	if err != boom {
+		t.Fatalf("err = %v, want boom", err)
+	}
+	if n := ran.Load(); n >= 100 {
+		t.Errorf("all %d tasks ran despite the error", n)
+	}
+}
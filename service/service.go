@@ -0,0 +1,194 @@
+// Package service renders and installs the init-system definitions that keep
+// the gocamelpack daemon running: a systemd user unit on Linux and a launchd
+// agent on macOS.
+package service
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Init is a supported init system.
+type Init string
+
+const (
+	Systemd Init = "systemd"
+	Launchd Init = "launchd"
+)
+
+// Name is the systemd unit name; Label the launchd job label.
+const (
+	Name  = "gocamelpack"
+	Label = "io.github.tmunayyer.gocamelpack"
+)
+
+// DefaultInit returns the init system of the running OS.
+func DefaultInit() (Init, error) {
+	switch runtime.GOOS {
+	case "linux":
+		return Systemd, nil
+	case "darwin":
+		return Launchd, nil
+	default:
+		return "", fmt.Errorf("service installation is not supported on %s", runtime.GOOS)
+	}
+}
+
+// ParseInit parses an --init value; "" or "auto" picks DefaultInit.
+func ParseInit(s string) (Init, error) {
+	switch Init(s) {
+	case "", "auto":
+		return DefaultInit()
+	case Systemd, Launchd:
+		return Init(s), nil
+	default:
+		return "", fmt.Errorf("invalid init system %q (want systemd or launchd)", s)
+	}
+}
+
+// Spec describes the daemon process to run.
+type Spec struct {
+	Executable string   // absolute path of the gocamelpack binary
+	Args       []string // e.g. daemon --config /home/me/.config/gocamelpack/config.json
+	LogDir     string   // launchd only: where stdout and stderr go
+}
+
+// DefaultDir returns where the user-level definition for init is installed.
+func DefaultDir(init Init) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	if init == Launchd {
+		return filepath.Join(home, "Library", "LaunchAgents"), nil
+	}
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "systemd", "user"), nil
+	}
+	return filepath.Join(home, ".config", "systemd", "user"), nil
+}
+
+// FileName returns the definition's file name for init.
+func FileName(init Init) string {
+	if init == Launchd {
+		return Label + ".plist"
+	}
+	return Name + ".service"
+}
+
+// Render returns the definition file for init.
+func Render(init Init, s Spec) (string, error) {
+	if !filepath.IsAbs(s.Executable) {
+		return "", fmt.Errorf("executable path %q must be absolute", s.Executable)
+	}
+	if init == Launchd {
+		return renderLaunchd(s)
+	}
+	return renderSystemd(s), nil
+}
+
+func renderSystemd(s Spec) string {
+	cmd := make([]string, 0, len(s.Args)+1)
+	for _, a := range append([]string{s.Executable}, s.Args...) {
+		cmd = append(cmd, systemdQuote(a))
+	}
+
+	var b strings.Builder
+	b.WriteString("[Unit]\n")
+	b.WriteString("Description=gocamelpack import daemon\n")
+	b.WriteString("After=local-fs.target network-online.target\n\n")
+	b.WriteString("[Service]\n")
+	b.WriteString("ExecStart=" + strings.Join(cmd, " ") + "\n")
+	b.WriteString("Restart=on-failure\n")
+	b.WriteString("RestartSec=10\n\n")
+	b.WriteString("[Install]\n")
+	b.WriteString("WantedBy=default.target\n")
+	return b.String()
+}
+
+// systemdQuote quotes an ExecStart word when it contains spaces, quotes,
+// backslashes or specifier percent signs.
+func systemdQuote(s string) string {
+	s = strings.ReplaceAll(s, "%", "%%")
+	if s != "" && !strings.ContainsAny(s, " \t\"'\\") {
+		return s
+	}
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	return `"` + r.Replace(s) + `"`
+}
+
+func renderLaunchd(s Spec) (string, error) {
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	b.WriteString(`<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">` + "\n")
+	b.WriteString("<plist version=\"1.0\">\n<dict>\n")
+
+	key := func(k string) { fmt.Fprintf(&b, "  <key>%s</key>\n", k) }
+	str := func(indent, v string) error {
+		b.WriteString(indent + "<string>")
+		if err := xml.EscapeText(&b, []byte(v)); err != nil {
+			return err
+		}
+		b.WriteString("</string>\n")
+		return nil
+	}
+
+	key("Label")
+	str("  ", Label)
+	key("ProgramArguments")
+	b.WriteString("  <array>\n")
+	for _, a := range append([]string{s.Executable}, s.Args...) {
+		if err := str("    ", a); err != nil {
+			return "", err
+		}
+	}
+	b.WriteString("  </array>\n")
+	key("RunAtLoad")
+	b.WriteString("  <true/>\n")
+	key("KeepAlive")
+	b.WriteString("  <dict>\n    <key>SuccessfulExit</key>\n    <false/>\n  </dict>\n")
+	if s.LogDir != "" {
+		key("StandardOutPath")
+		str("  ", filepath.Join(s.LogDir, "daemon.log"))
+		key("StandardErrorPath")
+		str("  ", filepath.Join(s.LogDir, "daemon.log"))
+	}
+	b.WriteString("</dict>\n</plist>\n")
+	return b.String(), nil
+}
+
+// Commands lists the commands that activate, deactivate and query an
+// installed definition.
+type Commands struct {
+	Enable  [][]string
+	Disable [][]string
+	Status  []string
+}
+
+// CommandsFor returns the commands for the definition at path.
+func CommandsFor(init Init, path string) Commands {
+	if init == Launchd {
+		return Commands{
+			Enable:  [][]string{{"launchctl", "load", "-w", path}},
+			Disable: [][]string{{"launchctl", "unload", "-w", path}},
+			Status:  []string{"launchctl", "list", Label},
+		}
+	}
+	unit := Name + ".service"
+	return Commands{
+		Enable: [][]string{
+			{"systemctl", "--user", "daemon-reload"},
+			{"systemctl", "--user", "enable", "--now", unit},
+		},
+		Disable: [][]string{
+			{"systemctl", "--user", "disable", "--now", unit},
+			{"systemctl", "--user", "daemon-reload"},
+		},
+		Status: []string{"systemctl", "--user", "is-active", unit},
+	}
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderSystemd(t *testing.T) {
+	got, err := Render(Systemd, Spec{
+		Executable: "/usr/local/bin/gocamelpack",
+		Args:       []string{"daemon", "--config", "/home/me/My Config/100%.json"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `ExecStart=/usr/local/bin/gocamelpack daemon --config "/home/me/My Config/100%%.json"`
+	if !strings.Contains(got, want+"\n") {
+		t.Errorf("missing %q in:\n%s", want, got)
+	}
+	for _, line := range []string{"[Service]", "Restart=on-failure", "WantedBy=default.target"} {
+		if !strings.Contains(got, line) {
+			t.Errorf("missing %q in:\n%s", line, got)
+		}
+	}
+}
+
+func TestRenderLaunchd(t *testing.T) {
+	got, err := Render(Launchd, Spec{
+		Executable: "/opt/bin/gocamelpack",
+		Args:       []string{"daemon", "--queue", "/Users/me/a&b/queue.json"},
+		LogDir:     "/Users/me/Library/Logs/gocamelpack",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"<string>" + Label + "</string>",
+		"<string>/opt/bin/gocamelpack</string>",
+		"<string>/Users/me/a&amp;b/queue.json</string>",
+		"<key>RunAtLoad</key>",
+		"<string>/Users/me/Library/Logs/gocamelpack/daemon.log</string>",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in:\n%s", want, got)
+		}
+	}
+}
+
+func TestRenderRequiresAbsoluteExecutable(t *testing.T) {
+	if _, err := Render(Systemd, Spec{Executable: "gocamelpack"}); err == nil {
+		t.Error("expected error for relative executable")
+	}
+}
+
+func TestParseInit(t *testing.T) {
+	if got, err := ParseInit("launchd"); err != nil || got != Launchd {
+		t.Errorf("ParseInit(launchd) = %q, %v", got, err)
+	}
+	if _, err := ParseInit("upstart"); err == nil {
+		t.Error("expected error for unknown init system")
+	}
+}
@@ -0,0 +1,18 @@
+// Package session identifies individual import runs so their output can be
+// traced back later.
+package session
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+)
+
+// NewID returns an identifier for a run started at now, e.g.
+// "20250127-153045-9f2c". IDs sort chronologically and the random suffix
+// keeps concurrent runs apart.
+func NewID(now time.Time) string {
+	var b [2]byte
+	_, _ = rand.Read(b[:])
+	return now.UTC().Format("20060102-150405") + "-" + hex.EncodeToString(b[:])
+}
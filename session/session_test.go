@@ -0,0 +1,25 @@
+package session
+
+import (
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestNewID(t *testing.T) {
+	now := time.Date(2025, 1, 27, 15, 30, 45, 0, time.UTC)
+	id := NewID(now)
+
+	if !regexp.MustCompile(`^20250127-153045-[0-9a-f]{4}$`).MatchString(id) {
+		t.Errorf("unexpected session ID format: %q", id)
+	}
+}
+
+func TestNewID_UsesUTC(t *testing.T) {
+	loc := time.FixedZone("CST", -6*60*60)
+	id := NewID(time.Date(2025, 1, 27, 20, 0, 0, 0, loc))
+
+	if id[:15] != "20250128-020000" {
+		t.Errorf("expected UTC timestamp prefix, got %q", id)
+	}
+}
@@ -0,0 +1,109 @@
+package thumbnail
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif" // register decoders for image.Decode
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/Tmunayyer/gocamelpack/files"
+)
+
+// ErrUnsupported is returned by a Decoder that cannot handle a file. The
+// generator moves on to the next decoder in the chain.
+var ErrUnsupported = errors.New("no preview available")
+
+// Decoder produces a full-size image for a media file.
+type Decoder interface {
+	Decode(path string) (image.Image, error)
+}
+
+// DecoderFunc adapts a plain function to the Decoder interface.
+type DecoderFunc func(path string) (image.Image, error)
+
+func (f DecoderFunc) Decode(path string) (image.Image, error) { return f(path) }
+
+// DefaultDecoders returns the standard chain: the Go image decoders first,
+// then exiftool's embedded previews (RAW, HEIC), then an ffmpeg frame grab
+// for videos. External tools are skipped when they are not installed.
+func DefaultDecoders() []Decoder {
+	return []Decoder{
+		DecoderFunc(decodeStd),
+		DecoderFunc(decodeEmbeddedPreview),
+		DecoderFunc(decodeVideoFrame),
+	}
+}
+
+var stdExts = map[string]bool{".jpg": true, ".jpeg": true, ".png": true, ".gif": true}
+
+func decodeStd(path string) (image.Image, error) {
+	if !stdExts[strings.ToLower(filepath.Ext(path))] {
+		return nil, ErrUnsupported
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("decode: %w", err)
+	}
+	return img, nil
+}
+
+// decodeEmbeddedPreview asks exiftool for the largest JPEG embedded in the
+// file, which covers RAW formats and HEIC without native decoders.
+func decodeEmbeddedPreview(path string) (image.Image, error) {
+	if files.MediaKindOf(path) != files.MediaImage {
+		return nil, ErrUnsupported
+	}
+	bin, err := exec.LookPath("exiftool")
+	if err != nil {
+		return nil, ErrUnsupported
+	}
+	for _, tag := range []string{"-PreviewImage", "-JpgFromRaw", "-ThumbnailImage"} {
+		out, err := exec.Command(bin, "-b", tag, path).Output()
+		if err != nil || len(out) == 0 {
+			continue
+		}
+		if img, _, err := image.Decode(bytes.NewReader(out)); err == nil {
+			return img, nil
+		}
+	}
+	return nil, ErrUnsupported
+}
+
+// decodeVideoFrame grabs a single frame one second into a video via ffmpeg.
+func decodeVideoFrame(path string) (image.Image, error) {
+	if files.MediaKindOf(path) != files.MediaVideo {
+		return nil, ErrUnsupported
+	}
+	bin, err := exec.LookPath("ffmpeg")
+	if err != nil {
+		return nil, ErrUnsupported
+	}
+	out, err := exec.Command(bin, "-v", "error", "-ss", "1", "-i", path,
+		"-frames:v", "1", "-f", "image2pipe", "-vcodec", "mjpeg", "-").Output()
+	if err != nil || len(out) == 0 {
+		// Very short clips have no frame at 1s; retry from the start.
+		out, err = exec.Command(bin, "-v", "error", "-i", path,
+			"-frames:v", "1", "-f", "image2pipe", "-vcodec", "mjpeg", "-").Output()
+		if err != nil {
+			return nil, fmt.Errorf("ffmpeg: %w", err)
+		}
+	}
+	img, _, err := image.Decode(bytes.NewReader(out))
+	if err != nil {
+		return nil, fmt.Errorf("decode video frame: %w", err)
+	}
+	return img, nil
+}
@@ -0,0 +1,54 @@
+package thumbnail
+
+import (
+	"image"
+	"image/color"
+)
+
+// Scale shrinks img so its longest edge is at most maxEdge pixels, averaging
+// every source pixel that falls into a destination pixel. Images that are
+// already small enough are copied unchanged.
+func Scale(img image.Image, maxEdge int) *image.RGBA {
+	b := img.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+
+	dw, dh := sw, sh
+	if sw > maxEdge || sh > maxEdge {
+		if sw >= sh {
+			dw = maxEdge
+			dh = max(1, sh*maxEdge/sw)
+		} else {
+			dh = maxEdge
+			dw = max(1, sw*maxEdge/sh)
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for dy := 0; dy < dh; dy++ {
+		y0 := b.Min.Y + dy*sh/dh
+		y1 := max(y0+1, b.Min.Y+(dy+1)*sh/dh)
+		for dx := 0; dx < dw; dx++ {
+			x0 := b.Min.X + dx*sw/dw
+			x1 := max(x0+1, b.Min.X+(dx+1)*sw/dw)
+
+			var r, g, bl, a, n uint64
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					cr, cg, cb, ca := img.At(x, y).RGBA()
+					r += uint64(cr)
+					g += uint64(cg)
+					bl += uint64(cb)
+					a += uint64(ca)
+					n++
+				}
+			}
+			dst.Set(dx, dy, color.RGBA64{
+				R: uint16(r / n),
+				G: uint16(g / n),
+				B: uint16(bl / n),
+				A: uint16(a / n),
+			})
+		}
+	}
+	return dst
+}
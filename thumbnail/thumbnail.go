@@ -0,0 +1,205 @@
+// Package thumbnail renders small JPEG previews of imported media into a
+// directory tree that mirrors the archive layout. A preview keeps the
+// whole name of its file, extension included, so IMG_0001.JPG and
+// IMG_0001.MOV get IMG_0001.JPG.jpg and IMG_0001.MOV.jpg.
+package thumbnail
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/Tmunayyer/gocamelpack/files"
+)
+
+// DefaultSize is the length in pixels of a thumbnail's longest edge.
+const DefaultSize = 256
+
+// Options configures a Generator.
+type Options struct {
+	Size    int // longest edge in pixels; DefaultSize when zero
+	Workers int // concurrent renderers; min(NumCPU, 4) when zero
+	Quality int // JPEG quality 1-100; 80 when zero
+}
+
+// Generator renders thumbnails on its own worker pool. It implements
+// files.PostOperationHook so it can be attached to copy and move runs.
+type Generator struct {
+	roots    []mirror
+	opts     Options
+	decoders []Decoder
+
+	jobs      chan string
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+
+	mu        sync.Mutex
+	errs      []error
+	generated int
+}
+
+// NewGenerator starts a generator that mirrors files found under archiveRoot
+// into thumbRoot. Call Close to wait for pending work.
+func NewGenerator(archiveRoot, thumbRoot string, opts Options) *Generator {
+	if opts.Size <= 0 {
+		opts.Size = DefaultSize
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = min(runtime.NumCPU(), 4)
+	}
+	if opts.Quality <= 0 || opts.Quality > 100 {
+		opts.Quality = 80
+	}
+
+	g := &Generator{
+		roots:    []mirror{{archive: archiveRoot, thumbs: thumbRoot}},
+		opts:     opts,
+		decoders: DefaultDecoders(),
+		jobs:     make(chan string, opts.Workers*4),
+	}
+
+	for i := 0; i < opts.Workers; i++ {
+		g.wg.Add(1)
+		go g.worker()
+	}
+	return g
+}
+
+// mirror is an archive root and the tree its thumbnails go to.
+type mirror struct {
+	archive string
+	thumbs  string
+}
+
+// AddRoot mirrors files found under another archive root, such as the
+// root of a routing rule, into thumbRoot. It must be called before any
+// work is submitted.
+func (g *Generator) AddRoot(archiveRoot, thumbRoot string) {
+	g.roots = append(g.roots, mirror{archive: archiveRoot, thumbs: thumbRoot})
+}
+
+// SetDecoders replaces the decoder chain. It must be called before any work
+// is submitted.
+func (g *Generator) SetDecoders(decoders ...Decoder) {
+	g.decoders = decoders
+}
+
+// OnOperationComplete queues a thumbnail for the operation's destination.
+func (g *Generator) OnOperationComplete(op files.Operation) {
+	g.Submit(op.Destination())
+}
+
+// Submit queues path for thumbnail generation. Files that are neither images
+// nor videos are ignored.
+func (g *Generator) Submit(path string) {
+	if files.MediaKindOf(path) == files.MediaUnknown {
+		return
+	}
+	g.jobs <- path
+}
+
+// Close waits for all queued thumbnails and returns the accumulated errors.
+func (g *Generator) Close() error {
+	g.closeOnce.Do(func() {
+		close(g.jobs)
+		g.wg.Wait()
+	})
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return errors.Join(g.errs...)
+}
+
+// Generated returns the number of thumbnails written so far.
+func (g *Generator) Generated() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.generated
+}
+
+// ThumbnailPath maps a file inside an archive root to its thumbnail
+// location; of nested roots the innermost wins.
+func (g *Generator) ThumbnailPath(path string) (string, error) {
+	var (
+		best mirror
+		rel  string
+	)
+	for _, m := range g.roots {
+		r, err := filepath.Rel(m.archive, path)
+		if err != nil || r == ".." || strings.HasPrefix(r, ".."+string(filepath.Separator)) {
+			continue
+		}
+		if rel == "" || len(m.archive) > len(best.archive) {
+			best, rel = m, r
+		}
+	}
+	if rel == "" {
+		return "", fmt.Errorf("%q is outside archive root %q", path, g.roots[0].archive)
+	}
+	return filepath.Join(best.thumbs, rel+".jpg"), nil
+}
+
+func (g *Generator) worker() {
+	defer g.wg.Done()
+	for path := range g.jobs {
+		err := g.render(path)
+
+		g.mu.Lock()
+		if err != nil {
+			g.errs = append(g.errs, fmt.Errorf("thumbnail for %q: %w", path, err))
+		} else {
+			g.generated++
+		}
+		g.mu.Unlock()
+	}
+}
+
+func (g *Generator) render(path string) error {
+	out, err := g.ThumbnailPath(path)
+	if err != nil {
+		return err
+	}
+
+	img, err := g.decode(path)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(out), 0o755); err != nil {
+		return fmt.Errorf("creating directory: %w", err)
+	}
+
+	f, err := os.Create(out)
+	if err != nil {
+		return fmt.Errorf("create %q: %w", out, err)
+	}
+	if err := jpeg.Encode(f, Scale(img, g.opts.Size), &jpeg.Options{Quality: g.opts.Quality}); err != nil {
+		f.Close()
+		os.Remove(out)
+		return fmt.Errorf("encode: %w", err)
+	}
+	return f.Close()
+}
+
+func (g *Generator) decode(path string) (image.Image, error) {
+	var errs []error
+	for _, d := range g.decoders {
+		img, err := d.Decode(path)
+		if err == nil {
+			return img, nil
+		}
+		if !errors.Is(err, ErrUnsupported) {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return nil, ErrUnsupported
+}
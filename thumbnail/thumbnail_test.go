@@ -0,0 +1,175 @@
+package thumbnail
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Tmunayyer/gocamelpack/testutil"
+)
+
+func writePNG(t *testing.T, path string, w, h int) {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := png.Encode(f, img); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestScale(t *testing.T) {
+	tests := []struct {
+		name         string
+		w, h, max    int
+		wantW, wantH int
+	}{
+		{"landscape", 400, 200, 100, 100, 50},
+		{"portrait", 200, 400, 100, 50, 100},
+		{"already small", 40, 30, 100, 40, 30},
+		{"extreme aspect", 1000, 2, 100, 100, 1},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := Scale(image.NewRGBA(image.Rect(0, 0, tc.w, tc.h)), tc.max)
+			if got.Bounds().Dx() != tc.wantW || got.Bounds().Dy() != tc.wantH {
+				t.Errorf("Scale(%dx%d, %d) = %dx%d, want %dx%d",
+					tc.w, tc.h, tc.max, got.Bounds().Dx(), got.Bounds().Dy(), tc.wantW, tc.wantH)
+			}
+		})
+	}
+}
+
+func TestScale_AveragesPixels(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	src.Set(0, 0, color.RGBA{R: 0, A: 255})
+	src.Set(1, 0, color.RGBA{R: 200, A: 255})
+
+	got := Scale(src, 1).RGBAAt(0, 0)
+	if got.R != 100 {
+		t.Errorf("expected averaged red channel 100, got %d", got.R)
+	}
+}
+
+func TestThumbnailPath(t *testing.T) {
+	g := NewGenerator("/archive", "/thumbs", Options{})
+	defer g.Close()
+
+	got, err := g.ThumbnailPath("/archive/2025/01/27/15_30.png")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := filepath.Join("/thumbs", "2025", "01", "27", "15_30.png.jpg")
+	if got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+
+	// Files sharing a stem keep apart.
+	jpg, _ := g.ThumbnailPath("/archive/IMG_0001.JPG")
+	mov, _ := g.ThumbnailPath("/archive/IMG_0001.MOV")
+	if jpg == mov {
+		t.Errorf("IMG_0001.JPG and IMG_0001.MOV share the thumbnail %q", jpg)
+	}
+
+	if _, err := g.ThumbnailPath("/elsewhere/a.jpg"); err == nil {
+		t.Error("expected error for path outside the archive root")
+	}
+}
+
+func TestThumbnailPath_AddedRoots(t *testing.T) {
+	g := NewGenerator("/archive", "/thumbs", Options{})
+	g.AddRoot("/bulk", "/thumbs/_roots/bulk")
+	g.AddRoot("/archive/videos", "/video-thumbs")
+	defer g.Close()
+
+	for path, want := range map[string]string{
+		"/archive/2025/a.jpg":   "/thumbs/2025/a.jpg.jpg",
+		"/bulk/2025/clip.mov":   "/thumbs/_roots/bulk/2025/clip.mov.jpg",
+		"/archive/videos/b.mov": "/video-thumbs/b.mov.jpg",
+	} {
+		got, err := g.ThumbnailPath(path)
+		if err != nil || got != filepath.FromSlash(want) {
+			t.Errorf("ThumbnailPath(%q) = %q, %v; want %q", path, got, err, want)
+		}
+	}
+}
+
+func TestGenerator_WritesMirroredThumbnails(t *testing.T) {
+	tmp := testutil.TempDir(t)
+	archive := filepath.Join(tmp, "archive")
+	thumbs := filepath.Join(tmp, "thumbs")
+
+	src := filepath.Join(archive, "2025", "01", "27", "15_30.png")
+	writePNG(t, src, 300, 150)
+
+	g := NewGenerator(archive, thumbs, Options{Size: 64, Workers: 2})
+	g.Submit(src)
+	g.Submit(filepath.Join(archive, "notes.txt")) // ignored: not media
+	if err := g.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+
+	out := filepath.Join(thumbs, "2025", "01", "27", "15_30.png.jpg")
+	f, err := os.Open(out)
+	if err != nil {
+		t.Fatalf("thumbnail not written: %v", err)
+	}
+	defer f.Close()
+
+	cfg, err := jpeg.DecodeConfig(f)
+	if err != nil {
+		t.Fatalf("thumbnail is not a JPEG: %v", err)
+	}
+	if cfg.Width != 64 || cfg.Height != 32 {
+		t.Errorf("expected 64x32 thumbnail, got %dx%d", cfg.Width, cfg.Height)
+	}
+	if g.Generated() != 1 {
+		t.Errorf("expected 1 generated thumbnail, got %d", g.Generated())
+	}
+}
+
+func TestGenerator_CollectsErrors(t *testing.T) {
+	tmp := testutil.TempDir(t)
+
+	g := NewGenerator(tmp, filepath.Join(tmp, "thumbs"), Options{Workers: 1})
+	g.SetDecoders(DecoderFunc(func(string) (image.Image, error) {
+		return nil, errors.New("boom")
+	}))
+	g.Submit(filepath.Join(tmp, "a.jpg"))
+
+	err := g.Close()
+	if err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Fatalf("expected decoder error to be reported, got %v", err)
+	}
+}
+
+func TestGenerator_UnsupportedWhenNoDecoderMatches(t *testing.T) {
+	tmp := testutil.TempDir(t)
+
+	g := NewGenerator(tmp, filepath.Join(tmp, "thumbs"), Options{Workers: 1})
+	g.SetDecoders(DecoderFunc(func(string) (image.Image, error) {
+		return nil, ErrUnsupported
+	}))
+	g.Submit(filepath.Join(tmp, "clip.mp4"))
+
+	if err := g.Close(); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("expected ErrUnsupported, got %v", err)
+	}
+}
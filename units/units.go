@@ -0,0 +1,94 @@
+// Package units parses and formats human-friendly quantities used in flags
+// and configuration, such as "100MB".
+package units
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ByteSize is a number of bytes that can be written as "512", "10KB",
+// "1.5GiB", … Decimal suffixes (KB, MB, GB, TB) are powers of 1000 and
+// binary suffixes (KiB, MiB, GiB, TiB) powers of 1024.
+type ByteSize int64
+
+var suffixes = []struct {
+	name string
+	mult float64
+}{
+	// Longest suffixes first so "KiB" is not read as "B".
+	{"kib", 1 << 10}, {"mib", 1 << 20}, {"gib", 1 << 30}, {"tib", 1 << 40},
+	{"kb", 1e3}, {"mb", 1e6}, {"gb", 1e9}, {"tb", 1e12},
+	{"k", 1e3}, {"m", 1e6}, {"g", 1e9}, {"t", 1e12},
+	{"b", 1},
+}
+
+// ParseByteSize parses s into a ByteSize.
+func ParseByteSize(s string) (ByteSize, error) {
+	str := strings.ToLower(strings.TrimSpace(s))
+	if str == "" {
+		return 0, fmt.Errorf("empty size")
+	}
+
+	mult := 1.0
+	for _, suf := range suffixes {
+		if strings.HasSuffix(str, suf.name) {
+			mult = suf.mult
+			str = strings.TrimSpace(strings.TrimSuffix(str, suf.name))
+			break
+		}
+	}
+
+	n, err := strconv.ParseFloat(str, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return ByteSize(n * mult), nil
+}
+
+// String formats the size with a binary suffix, e.g. "1.5 GiB".
+func (b ByteSize) String() string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%d B", int64(b))
+	}
+	div, exp := int64(unit), 0
+	for n := int64(b) / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(b)/float64(div), "KMGTPE"[exp])
+}
+
+// UnmarshalJSON accepts either a JSON number of bytes or a size string.
+func (b *ByteSize) UnmarshalJSON(data []byte) error {
+	var n int64
+	if err := json.Unmarshal(data, &n); err == nil {
+		*b = ByteSize(n)
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("size must be a number or string: %w", err)
+	}
+	v, err := ParseByteSize(s)
+	if err != nil {
+		return err
+	}
+	*b = v
+	return nil
+}
+
+// Set and Type let ByteSize be used as a pflag value.
+func (b *ByteSize) Set(s string) error {
+	v, err := ParseByteSize(s)
+	if err != nil {
+		return err
+	}
+	*b = v
+	return nil
+}
+
+func (b *ByteSize) Type() string { return "size" }
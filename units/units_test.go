@@ -0,0 +1,72 @@
+package units
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    ByteSize
+		wantErr bool
+	}{
+		{"512", 512, false},
+		{"10KB", 10_000, false},
+		{"10 kb", 10_000, false},
+		{"1.5GiB", 1610612736, false},
+		{"100M", 100_000_000, false},
+		{"2TiB", 2 << 40, false},
+		{"7b", 7, false},
+		{"", 0, true},
+		{"-1", 0, true},
+		{"lots", 0, true},
+	}
+	for _, tc := range tests {
+		got, err := ParseByteSize(tc.in)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("ParseByteSize(%q) expected error", tc.in)
+			}
+			continue
+		}
+		if err != nil || got != tc.want {
+			t.Errorf("ParseByteSize(%q) = %d, %v; want %d", tc.in, got, err, tc.want)
+		}
+	}
+}
+
+func TestByteSizeString(t *testing.T) {
+	tests := []struct {
+		in   ByteSize
+		want string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KiB"},
+		{1536 * 1024, "1.5 MiB"},
+		{3 << 30, "3.0 GiB"},
+	}
+	for _, tc := range tests {
+		if got := tc.in.String(); got != tc.want {
+			t.Errorf("ByteSize(%d).String() = %q, want %q", int64(tc.in), got, tc.want)
+		}
+	}
+}
+
+func TestByteSizeUnmarshalJSON(t *testing.T) {
+	var v struct {
+		A ByteSize `json:"a"`
+		B ByteSize `json:"b"`
+	}
+	if err := json.Unmarshal([]byte(`{"a": 2048, "b": "1MB"}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.A != 2048 || v.B != 1_000_000 {
+		t.Errorf("unexpected values %+v", v)
+	}
+
+	if err := json.Unmarshal([]byte(`{"a": "huge"}`), &v); err == nil {
+		t.Error("expected error for invalid size string")
+	}
+}
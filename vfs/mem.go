@@ -0,0 +1,444 @@
+package vfs
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// errDirRename is returned when renaming a directory, which Mem does not
+// support.
+var errDirRename = errors.New("vfs: renaming directories is not supported")
+
+// Mem is an in-memory file system. With a base, Mem is an overlay: what is
+// not written in memory is read from base, and base is never modified, so
+// a run against the real disk can be played through without changing it.
+// Mem is safe for concurrent use.
+type Mem struct {
+	// Discard drops the data written to files, keeping only their size;
+	// reading such a file back returns zeros. It bounds memory when
+	// simulating large transfers.
+	Discard bool
+
+	mu   sync.Mutex
+	base FS
+	// nodes holds what was written in memory, by cleaned path.
+	nodes map[string]*memNode
+	// removed hides base paths that were removed or renamed away.
+	removed map[string]bool
+}
+
+// NewMem returns an empty in-memory file system over base, or over nothing
+// when base is nil.
+func NewMem(base FS) *Mem {
+	return &Mem{base: base, nodes: map[string]*memNode{}, removed: map[string]bool{}}
+}
+
+// memNode is a file or directory of a Mem.
+type memNode struct {
+	dir     bool
+	mode    fs.FileMode
+	modTime time.Time
+	data    []byte
+	size    int64
+	// lazy, when set, is the base path holding the content of a file that
+	// was renamed but has not been written since.
+	lazy string
+}
+
+// memInfo is the fs.FileInfo of a memNode.
+type memInfo struct {
+	name string
+	mode fs.FileMode
+	mod  time.Time
+	size int64
+}
+
+func (i memInfo) Name() string       { return i.name }
+func (i memInfo) Size() int64        { return i.size }
+func (i memInfo) Mode() fs.FileMode  { return i.mode }
+func (i memInfo) ModTime() time.Time { return i.mod }
+func (i memInfo) IsDir() bool        { return i.mode.IsDir() }
+func (i memInfo) Sys() any           { return nil }
+
+func (n *memNode) info(name string) memInfo {
+	mode := n.mode
+	if n.dir {
+		mode |= fs.ModeDir
+	}
+	return memInfo{name: filepath.Base(name), mode: mode, mod: n.modTime, size: n.size}
+}
+
+func (m *Mem) Stat(name string) (fs.FileInfo, error) {
+	return m.stat("stat", name, false)
+}
+
+func (m *Mem) Lstat(name string) (fs.FileInfo, error) {
+	return m.stat("lstat", name, true)
+}
+
+func (m *Mem) stat(op, name string, link bool) (fs.FileInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	name = filepath.Clean(name)
+	if n, ok := m.nodes[name]; ok {
+		return n.info(name), nil
+	}
+	if m.base == nil || m.removed[name] {
+		if isRoot(name) {
+			return memInfo{name: name, mode: fs.ModeDir | 0o755}, nil
+		}
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	if link {
+		return m.base.Lstat(name)
+	}
+	return m.base.Stat(name)
+}
+
+func (m *Mem) Open(name string) (File, error) {
+	return m.OpenFile(name, os.O_RDONLY, 0)
+}
+
+func (m *Mem) OpenFile(name string, flag int, perm fs.FileMode) (File, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	name = filepath.Clean(name)
+	write := flag&(os.O_WRONLY|os.O_RDWR) != 0
+
+	n, ok := m.nodes[name]
+	if !ok && !write {
+		if m.base == nil || m.removed[name] {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+		}
+		return m.base.Open(name)
+	}
+	if !ok {
+		info, err := m.lstatLocked(name)
+		switch {
+		case err == nil && info.IsDir():
+			return nil, &fs.PathError{Op: "open", Path: name, Err: syscall.EISDIR}
+		case err == nil:
+			// A base file written to is copied into memory first.
+			n = &memNode{mode: info.Mode().Perm(), modTime: info.ModTime(), size: info.Size(), lazy: name}
+		case !errors.Is(err, fs.ErrNotExist):
+			return nil, err
+		case flag&os.O_CREATE == 0:
+			return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+		default:
+			if err := m.checkParentLocked("open", name); err != nil {
+				return nil, err
+			}
+			n = &memNode{mode: perm.Perm(), modTime: time.Now()}
+		}
+		if err == nil && flag&(os.O_CREATE|os.O_EXCL) == os.O_CREATE|os.O_EXCL {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrExist}
+		}
+		m.nodes[name] = n
+		delete(m.removed, name)
+	} else {
+		if n.dir && write {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: syscall.EISDIR}
+		}
+		if flag&(os.O_CREATE|os.O_EXCL) == os.O_CREATE|os.O_EXCL {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrExist}
+		}
+	}
+
+	if write && flag&os.O_TRUNC != 0 {
+		n.data, n.size, n.lazy = nil, 0, ""
+		n.modTime = time.Now()
+	}
+	if write && n.lazy != "" {
+		if err := m.loadLocked(n); err != nil {
+			return nil, err
+		}
+	}
+	if !write && n.lazy != "" {
+		return m.base.Open(n.lazy)
+	}
+	return &memFile{m: m, n: n, name: name, write: write, read: flag&os.O_WRONLY == 0, append: flag&os.O_APPEND != 0}, nil
+}
+
+// loadLocked copies the base content of a lazy node into memory.
+func (m *Mem) loadLocked(n *memNode) error {
+	if m.Discard {
+		n.lazy = ""
+		return nil
+	}
+	f, err := m.base.Open(n.lazy)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return err
+	}
+	n.data, n.size, n.lazy = data, int64(len(data)), ""
+	return nil
+}
+
+func (m *Mem) ReadDir(name string) ([]fs.DirEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	name = filepath.Clean(name)
+	info, err := m.lstatLocked(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	if !info.IsDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: syscall.ENOTDIR}
+	}
+
+	entries := map[string]fs.DirEntry{}
+	if m.base != nil {
+		// A removed base directory had all of its entries removed first, so
+		// listing it again behind a new directory in memory is harmless.
+		if list, err := m.base.ReadDir(name); err == nil {
+			for _, e := range list {
+				if p := filepath.Join(name, e.Name()); !m.removed[p] {
+					entries[e.Name()] = e
+				}
+			}
+		}
+	}
+	for p, n := range m.nodes {
+		if filepath.Dir(p) == name && p != name {
+			entries[filepath.Base(p)] = fs.FileInfoToDirEntry(n.info(p))
+		}
+	}
+
+	list := make([]fs.DirEntry, 0, len(entries))
+	for _, e := range entries {
+		list = append(list, e)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name() < list[j].Name() })
+	return list, nil
+}
+
+func (m *Mem) MkdirAll(name string, perm fs.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	name = filepath.Clean(name)
+	var missing []string
+	for p := name; ; p = filepath.Dir(p) {
+		info, err := m.lstatLocked(p)
+		if err == nil {
+			if !info.IsDir() {
+				return &fs.PathError{Op: "mkdir", Path: p, Err: syscall.ENOTDIR}
+			}
+			break
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		missing = append(missing, p)
+		if isRoot(p) {
+			break
+		}
+	}
+	for _, p := range missing {
+		m.nodes[p] = &memNode{dir: true, mode: perm.Perm(), modTime: time.Now()}
+	}
+	return nil
+}
+
+func (m *Mem) Remove(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	name = filepath.Clean(name)
+	info, err := m.lstatLocked(name)
+	if err != nil {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+	}
+	if info.IsDir() && m.hasChildrenLocked(name) {
+		return &fs.PathError{Op: "remove", Path: name, Err: syscall.ENOTEMPTY}
+	}
+	m.forgetLocked(name)
+	return nil
+}
+
+func (m *Mem) Rename(oldname, newname string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	oldname, newname = filepath.Clean(oldname), filepath.Clean(newname)
+	info, err := m.lstatLocked(oldname)
+	if err != nil {
+		return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: fs.ErrNotExist}
+	}
+	if info.IsDir() {
+		return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: errDirRename}
+	}
+	if oldname == newname {
+		return nil
+	}
+	if err := m.checkParentLocked("rename", newname); err != nil {
+		return err
+	}
+	if to, err := m.lstatLocked(newname); err == nil && to.IsDir() {
+		return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: syscall.EISDIR}
+	}
+
+	n, ok := m.nodes[oldname]
+	if !ok {
+		n = &memNode{mode: info.Mode().Perm(), modTime: info.ModTime(), size: info.Size(), lazy: oldname}
+	}
+	m.forgetLocked(oldname)
+	m.nodes[newname] = n
+	delete(m.removed, newname)
+	return nil
+}
+
+// lstatLocked is Lstat for callers holding m.mu.
+func (m *Mem) lstatLocked(name string) (fs.FileInfo, error) {
+	if n, ok := m.nodes[name]; ok {
+		return n.info(name), nil
+	}
+	if isRoot(name) {
+		return memInfo{name: name, mode: fs.ModeDir | 0o755}, nil
+	}
+	if m.base == nil || m.removed[name] {
+		return nil, &fs.PathError{Op: "lstat", Path: name, Err: fs.ErrNotExist}
+	}
+	return m.base.Lstat(name)
+}
+
+// checkParentLocked fails unless the directory of name exists.
+func (m *Mem) checkParentLocked(op, name string) error {
+	info, err := m.lstatLocked(filepath.Dir(name))
+	if err != nil {
+		return &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	if !info.IsDir() {
+		return &fs.PathError{Op: op, Path: name, Err: syscall.ENOTDIR}
+	}
+	return nil
+}
+
+func (m *Mem) hasChildrenLocked(dir string) bool {
+	for p := range m.nodes {
+		if p != dir && filepath.Dir(p) == dir {
+			return true
+		}
+	}
+	if m.base == nil {
+		return false
+	}
+	list, _ := m.base.ReadDir(dir)
+	for _, e := range list {
+		if !m.removed[filepath.Join(dir, e.Name())] {
+			return true
+		}
+	}
+	return false
+}
+
+// forgetLocked removes name from memory and hides it in base.
+func (m *Mem) forgetLocked(name string) {
+	delete(m.nodes, name)
+	if m.base != nil {
+		if _, err := m.base.Lstat(name); err == nil {
+			m.removed[name] = true
+		}
+	}
+}
+
+func isRoot(name string) bool {
+	return filepath.Dir(name) == name
+}
+
+// memFile is an open file of a Mem. Writes go straight to the node, so
+// they are visible to other open files at once.
+type memFile struct {
+	m      *Mem
+	n      *memNode
+	name   string
+	off    int64
+	read   bool
+	write  bool
+	append bool
+	closed bool
+}
+
+func (f *memFile) Read(p []byte) (int, error) {
+	f.m.mu.Lock()
+	defer f.m.mu.Unlock()
+	if err := f.check("read", f.read); err != nil {
+		return 0, err
+	}
+	if f.off >= f.n.size {
+		return 0, io.EOF
+	}
+	k := int(min(int64(len(p)), f.n.size-f.off))
+	if f.n.data != nil {
+		copy(p[:k], f.n.data[f.off:])
+	} else {
+		clear(p[:k])
+	}
+	f.off += int64(k)
+	return k, nil
+}
+
+func (f *memFile) Write(p []byte) (int, error) {
+	f.m.mu.Lock()
+	defer f.m.mu.Unlock()
+	if err := f.check("write", f.write); err != nil {
+		return 0, err
+	}
+	if f.append {
+		f.off = f.n.size
+	}
+	end := f.off + int64(len(p))
+	if !f.m.Discard {
+		if end > int64(len(f.n.data)) {
+			f.n.data = append(f.n.data, make([]byte, end-int64(len(f.n.data)))...)
+		}
+		copy(f.n.data[f.off:], p)
+	}
+	f.n.size = max(f.n.size, end)
+	f.n.modTime = time.Now()
+	f.off = end
+	return len(p), nil
+}
+
+func (f *memFile) Stat() (fs.FileInfo, error) {
+	f.m.mu.Lock()
+	defer f.m.mu.Unlock()
+	if err := f.check("stat", true); err != nil {
+		return nil, err
+	}
+	return f.n.info(f.name), nil
+}
+
+func (f *memFile) Sync() error {
+	f.m.mu.Lock()
+	defer f.m.mu.Unlock()
+	return f.check("sync", true)
+}
+
+func (f *memFile) Close() error {
+	f.m.mu.Lock()
+	defer f.m.mu.Unlock()
+	if err := f.check("close", true); err != nil {
+		return err
+	}
+	f.closed = true
+	return nil
+}
+
+func (f *memFile) check(op string, allowed bool) error {
+	if f.closed {
+		return &fs.PathError{Op: op, Path: f.name, Err: fs.ErrClosed}
+	}
+	if !allowed {
+		return &fs.PathError{Op: op, Path: f.name, Err: fs.ErrPermission}
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package vfs
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFile(t *testing.T, fsys FS, name, data string) {
+	t.Helper()
+	f, err := fsys.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.WriteString(f, data); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readFile(t *testing.T, fsys FS, name string) string {
+	t.Helper()
+	f, err := fsys.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func names(t *testing.T, fsys FS, dir string) []string {
+	t.Helper()
+	entries, err := fsys.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out []string
+	for _, e := range entries {
+		out = append(out, e.Name())
+	}
+	return out
+}
+
+func TestMem(t *testing.T) {
+	m := NewMem(nil)
+	if err := m.MkdirAll("/a/b", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, m, "/a/b/f.txt", "hello")
+	if got := readFile(t, m, "/a/b/f.txt"); got != "hello" {
+		t.Errorf("read %q", got)
+	}
+	if info, err := m.Stat("/a/b/f.txt"); err != nil || info.Size() != 5 || info.IsDir() {
+		t.Errorf("Stat = %v, %v", info, err)
+	}
+
+	if _, err := m.OpenFile("/a/b/f.txt", os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644); !errors.Is(err, fs.ErrExist) {
+		t.Errorf("exclusive create of existing file: %v", err)
+	}
+	if _, err := m.OpenFile("/missing/f.txt", os.O_CREATE|os.O_WRONLY, 0o644); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("create without parent: %v", err)
+	}
+	if err := m.Remove("/a/b"); err == nil {
+		t.Error("removed a non-empty directory")
+	}
+
+	if err := m.Rename("/a/b/f.txt", "/a/g.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Lstat("/a/b/f.txt"); !os.IsNotExist(err) {
+		t.Errorf("old name still exists: %v", err)
+	}
+	if got := names(t, m, "/a"); len(got) != 2 || got[0] != "b" || got[1] != "g.txt" {
+		t.Errorf("ReadDir = %v", got)
+	}
+	if err := m.Remove("/a/b"); err != nil {
+		t.Errorf("removing emptied directory: %v", err)
+	}
+}
+
+func TestMem_Overlay(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src.txt")
+	if err := os.WriteFile(src, []byte("on disk"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewMem(OS)
+	if got := readFile(t, m, src); got != "on disk" {
+		t.Errorf("read through %q", got)
+	}
+	moved := filepath.Join(dir, "out", "moved.txt")
+	if err := m.MkdirAll(filepath.Dir(moved), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Rename(src, moved); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, m, moved); got != "on disk" {
+		t.Errorf("renamed file reads %q", got)
+	}
+	writeFile(t, m, filepath.Join(dir, "new.txt"), "new")
+	if got := names(t, m, dir); len(got) != 2 || got[0] != "new.txt" || got[1] != "out" {
+		t.Errorf("ReadDir = %v, want new.txt and out", got)
+	}
+
+	// The disk is untouched.
+	if got := names(t, OS, dir); len(got) != 1 || got[0] != "src.txt" {
+		t.Errorf("disk has %v, want only src.txt", got)
+	}
+}
+
+func TestMem_Discard(t *testing.T) {
+	m := NewMem(nil)
+	m.Discard = true
+	writeFile(t, m, "/big", "0123456789")
+	if info, err := m.Stat("/big"); err != nil || info.Size() != 10 {
+		t.Fatalf("Stat = %v, %v", info, err)
+	}
+	if got := readFile(t, m, "/big"); got != string(make([]byte, 10)) {
+		t.Errorf("discarded content reads %q, want zeros", got)
+	}
+}
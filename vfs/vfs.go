@@ -0,0 +1,67 @@
+// Package vfs is the file system gocamelpack reads and writes through. OS
+// is the real disk; Mem keeps everything in memory, optionally reading
+// through to another file system, so tests and simulated runs never touch
+// the disk.
+package vfs
+
+import (
+	"io"
+	"io/fs"
+	"os"
+)
+
+// File is an open file.
+type File interface {
+	io.Reader
+	io.Writer
+	io.Closer
+	Stat() (fs.FileInfo, error)
+	Sync() error
+}
+
+// FS is a writable file system addressed by OS paths. Errors follow the os
+// package, so errors.Is(err, fs.ErrNotExist) and friends work for every
+// implementation.
+type FS interface {
+	Stat(name string) (fs.FileInfo, error)
+	Lstat(name string) (fs.FileInfo, error)
+	Open(name string) (File, error)
+	OpenFile(name string, flag int, perm fs.FileMode) (File, error)
+	// ReadDir returns the entries of a directory sorted by name.
+	ReadDir(name string) ([]fs.DirEntry, error)
+	MkdirAll(name string, perm fs.FileMode) error
+	Remove(name string) error
+	Rename(oldname, newname string) error
+}
+
+// OSFS is the real file system.
+type OSFS struct{}
+
+// OS is the real file system.
+var OS FS = OSFS{}
+
+func (OSFS) Stat(name string) (fs.FileInfo, error)  { return os.Stat(name) }
+func (OSFS) Lstat(name string) (fs.FileInfo, error) { return os.Lstat(name) }
+func (OSFS) Open(name string) (File, error)         { return open(os.Open(name)) }
+func (OSFS) OpenFile(name string, flag int, perm fs.FileMode) (File, error) {
+	return open(os.OpenFile(name, flag, perm))
+}
+func (OSFS) ReadDir(name string) ([]fs.DirEntry, error)   { return os.ReadDir(name) }
+func (OSFS) MkdirAll(name string, perm fs.FileMode) error { return os.MkdirAll(name, perm) }
+func (OSFS) Remove(name string) error                     { return os.Remove(name) }
+func (OSFS) Rename(oldname, newname string) error         { return os.Rename(oldname, newname) }
+
+// open avoids returning a non-nil File holding a nil *os.File.
+func open(f *os.File, err error) (File, error) {
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// OnDisk reports whether fsys is the real file system, e.g. to decide
+// whether flushing directories or probing the destination makes sense.
+func OnDisk(fsys FS) bool {
+	_, ok := fsys.(OSFS)
+	return ok
+}